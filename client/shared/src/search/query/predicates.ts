@@ -26,6 +26,10 @@ export const PREDICATES: Access[] = [
                     },
                 ],
             },
+            {
+                name: 'has',
+                fields: [{ name: 'meta' }],
+            },
         ],
     },
     {
@@ -35,6 +39,10 @@ export const PREDICATES: Access[] = [
                 name: 'contains',
                 fields: [{ name: 'content' }],
             },
+            {
+                name: 'has',
+                fields: [{ name: 'owner' }],
+            },
         ],
     },
 ]
@@ -164,6 +172,11 @@ export const predicateCompletion = (field: string): Completion[] => {
                 insertText: 'contains.commit.after(${1:1 month ago})',
                 asSnippet: true,
             },
+            {
+                label: 'has.meta(...)',
+                insertText: 'has.meta(${1:key}:${2:value})',
+                asSnippet: true,
+            },
         ]
     }
     return []
@@ -56,11 +56,30 @@ const helpTexts: Record<ExternalServiceKind, JSX.Element> = {
             with <code>write</code> permissions on the project and repository level.
         </>
     ),
+    [ExternalServiceKind.GITEA]: (
+        <>
+            <a href={HELP_TEXT_LINK_URL} rel="noreferrer noopener" target="_blank">
+                Create a new access token
+            </a>{' '}
+            with <code>write</code> permissions on repositories and issues.
+        </>
+    ),
+
+    [ExternalServiceKind.BITBUCKETCLOUD]: (
+        <>
+            <a href={HELP_TEXT_LINK_URL} rel="noreferrer noopener" target="_blank">
+                Create a new app password
+            </a>{' '}
+            with <code>pullrequest:write</code> and <code>repository:write</code> permissions.
+        </>
+    ),
 
     // These are just for type completeness and serve as placeholders for a bright future.
-    [ExternalServiceKind.BITBUCKETCLOUD]: <span>Unsupported</span>,
+    [ExternalServiceKind.GERRIT]: <span>Unsupported</span>,
     [ExternalServiceKind.GITOLITE]: <span>Unsupported</span>,
+    [ExternalServiceKind.GOMODULES]: <span>Unsupported</span>,
     [ExternalServiceKind.JVMPACKAGES]: <span>Unsupported</span>,
+    [ExternalServiceKind.NPMPACKAGES]: <span>Unsupported</span>,
     [ExternalServiceKind.PERFORCE]: <span>Unsupported</span>,
     [ExternalServiceKind.PHABRICATOR]: <span>Unsupported</span>,
     [ExternalServiceKind.AWSCODECOMMIT]: <span>Unsupported</span>,
@@ -82,6 +101,7 @@ export const AddCredentialModal: React.FunctionComponent<AddCredentialModalProps
     const labelId = 'addCredential'
     const [isLoading, setIsLoading] = useState<boolean | Error>(false)
     const [credential, setCredential] = useState<string>('')
+    const [username, setUsername] = useState<string>('')
     const [sshPublicKey, setSSHPublicKey] = useState<string>()
     const [step, setStep] = useState<Step>(initialStep)
 
@@ -89,6 +109,14 @@ export const AddCredentialModal: React.FunctionComponent<AddCredentialModalProps
         setCredential(event.target.value)
     }, [])
 
+    const onChangeUsername = useCallback<React.ChangeEventHandler<HTMLInputElement>>(event => {
+        setUsername(event.target.value)
+    }, [])
+
+    // Bitbucket Cloud app passwords are used together with the username of
+    // the account they belong to.
+    const requiresUsername = externalServiceKind === ExternalServiceKind.BITBUCKETCLOUD
+
     const onSubmit = useCallback<React.FormEventHandler>(
         async event => {
             event.preventDefault()
@@ -97,6 +125,7 @@ export const AddCredentialModal: React.FunctionComponent<AddCredentialModalProps
                 const createdCredential = await createBatchChangesCredential({
                     user: userID,
                     credential,
+                    username: requiresUsername ? username : null,
                     externalServiceKind,
                     externalServiceURL,
                 })
@@ -114,6 +143,8 @@ export const AddCredentialModal: React.FunctionComponent<AddCredentialModalProps
             afterCreate,
             userID,
             credential,
+            username,
+            requiresUsername,
             externalServiceKind,
             externalServiceURL,
             requiresSSH,
@@ -164,8 +195,27 @@ export const AddCredentialModal: React.FunctionComponent<AddCredentialModalProps
                     <>
                         {isErrorLike(isLoading) && <ErrorAlert error={isLoading} />}
                         <Form onSubmit={onSubmit}>
+                            {requiresUsername && (
+                                <div className="form-group">
+                                    <label htmlFor="username">Username</label>
+                                    <input
+                                        id="username"
+                                        name="username"
+                                        type="text"
+                                        autoComplete="off"
+                                        className="form-control test-add-credential-modal-username-input"
+                                        required={true}
+                                        spellCheck="false"
+                                        minLength={1}
+                                        value={username}
+                                        onChange={onChangeUsername}
+                                    />
+                                </div>
+                            )}
                             <div className="form-group">
-                                <label htmlFor="token">Personal access token</label>
+                                <label htmlFor="token">
+                                    {requiresUsername ? 'App password' : 'Personal access token'}
+                                </label>
                                 <input
                                     id="token"
                                     name="token"
@@ -191,7 +241,11 @@ export const AddCredentialModal: React.FunctionComponent<AddCredentialModalProps
                                 </button>
                                 <button
                                     type="submit"
-                                    disabled={isLoading === true || credential.length === 0}
+                                    disabled={
+                                        isLoading === true ||
+                                        credential.length === 0 ||
+                                        (requiresUsername && username.length === 0)
+                                    }
                                     className="btn btn-primary test-add-credential-modal-submit"
                                 >
                                     {isLoading === true && <LoadingSpinner className="icon-inline" />}
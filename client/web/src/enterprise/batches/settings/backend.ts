@@ -36,12 +36,14 @@ export function createBatchChangesCredential(
             mutation CreateBatchChangesCredential(
                 $user: ID
                 $credential: String!
+                $username: String
                 $externalServiceKind: ExternalServiceKind!
                 $externalServiceURL: String!
             ) {
                 createBatchChangesCredential(
                     user: $user
                     credential: $credential
+                    username: $username
                     externalServiceKind: $externalServiceKind
                     externalServiceURL: $externalServiceURL
                 ) {
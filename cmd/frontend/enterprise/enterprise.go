@@ -20,8 +20,10 @@ type Services struct {
 	GitHubWebhook             webhooks.Registerer
 	GitLabWebhook             http.Handler
 	BitbucketServerWebhook    http.Handler
+	BitbucketCloudWebhook     http.Handler
 	NewCodeIntelUploadHandler NewCodeIntelUploadHandler
 	NewExecutorProxyHandler   NewExecutorProxyHandler
+	SCIMHandler               http.Handler // serves /.api/scim/ with its own bearer token auth
 	AuthzResolver             graphqlbackend.AuthzResolver
 	BatchChangesResolver      graphqlbackend.BatchChangesResolver
 	CodeIntelResolver         graphqlbackend.CodeIntelResolver
@@ -46,8 +48,10 @@ func DefaultServices() Services {
 		GitHubWebhook:             registerFunc(func(webhook *webhooks.GitHubWebhook) {}),
 		GitLabWebhook:             makeNotFoundHandler("gitlab webhook"),
 		BitbucketServerWebhook:    makeNotFoundHandler("bitbucket server webhook"),
+		BitbucketCloudWebhook:     makeNotFoundHandler("bitbucket cloud webhook"),
 		NewCodeIntelUploadHandler: func(_ bool) http.Handler { return makeNotFoundHandler("code intel upload") },
 		NewExecutorProxyHandler:   func() http.Handler { return makeNotFoundHandler("executor proxy") },
+		SCIMHandler:               makeNotFoundHandler("SCIM API"),
 	}
 }
 
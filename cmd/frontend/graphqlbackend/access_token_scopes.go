@@ -0,0 +1,85 @@
+package graphqlbackend
+
+import (
+	"context"
+
+	"github.com/cockroachdb/errors"
+
+	"github.com/sourcegraph/sourcegraph/internal/actor"
+	"github.com/sourcegraph/sourcegraph/internal/authz"
+)
+
+// batchChangesMutations are the mutations that access tokens with the "batch-changes:write" scope
+// may run. Mutations managing code host credentials are deliberately left out.
+var batchChangesMutations = map[string]bool{
+	"createChangesetSpec":                true,
+	"syncChangeset":                      true,
+	"reenqueueChangeset":                 true,
+	"createBatchChange":                  true,
+	"createBatchSpec":                    true,
+	"createBatchSpecFromRaw":             true,
+	"replaceBatchSpecInput":              true,
+	"deleteBatchSpec":                    true,
+	"executeBatchSpec":                   true,
+	"applyBatchChange":                   true,
+	"closeBatchChange":                   true,
+	"moveBatchChange":                    true,
+	"deleteBatchChange":                  true,
+	"detachChangesets":                   true,
+	"createChangesetComments":            true,
+	"reenqueueChangesets":                true,
+	"mergeChangesets":                    true,
+	"closeChangesets":                    true,
+	"publishChangesets":                  true,
+	"cancelBatchSpecExecution":           true,
+	"cancelBatchSpecWorkspaceExecution":  true,
+	"retryBatchSpecWorkspaceExecution":   true,
+	"retryBatchSpecExecution":            true,
+	"enqueueBatchSpecWorkspaceExecution": true,
+	"toggleBatchSpecAutoApply":           true,
+}
+
+// ErrAccessTokenScopes is the error of the fields the access token used by the actor does not
+// allow to resolve.
+var ErrAccessTokenScopes = errors.New("the access token's scopes do not allow this request")
+
+// checkAccessTokenScopes checks that the access token scopes of the actor, if any, allow resolving
+// the given field. Tokens with the "api:read" or "batch-changes:write" scopes may resolve the fields
+// of queries, and the latter may also run the batch changes mutations. Only the fields of the root
+// types are checked: the others are resolved under one of them.
+func checkAccessTokenScopes(ctx context.Context, typeName, fieldName string) error {
+	a := actor.FromContext(ctx)
+	if a.Scopes == nil {
+		return nil
+	}
+
+	switch typeName {
+	case "Query":
+		if a.HasScope(authz.ScopeAPIRead) || a.HasScope(authz.ScopeBatchChangesWrite) {
+			return nil
+		}
+	case "Mutation":
+		if a.HasScope(authz.ScopeBatchChangesWrite) && batchChangesMutations[fieldName] {
+			return nil
+		}
+	default:
+		return nil
+	}
+	return ErrAccessTokenScopes
+}
+
+// deniedContext is the context of a field whose resolver must not run: graphql-go doesn't run
+// the resolvers of fields whose context is done, and reports its error instead.
+type deniedContext struct {
+	context.Context
+	err error
+}
+
+var closedChan = func() chan struct{} {
+	c := make(chan struct{})
+	close(c)
+	return c
+}()
+
+func (c deniedContext) Done() <-chan struct{} { return closedChan }
+func (c deniedContext) Err() error            { return c.err }
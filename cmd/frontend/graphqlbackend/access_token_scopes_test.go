@@ -0,0 +1,71 @@
+package graphqlbackend
+
+import (
+	"context"
+	"testing"
+
+	"github.com/sourcegraph/sourcegraph/internal/actor"
+	"github.com/sourcegraph/sourcegraph/internal/authz"
+)
+
+func TestCheckAccessTokenScopes(t *testing.T) {
+	tests := []struct {
+		scopes    []string
+		typeName  string
+		fieldName string
+		allowed   bool
+	}{
+		{scopes: nil, typeName: "Mutation", fieldName: "deleteUser", allowed: true},
+		{scopes: []string{authz.ScopeAPIRead}, typeName: "Query", fieldName: "currentUser", allowed: true},
+		{scopes: []string{authz.ScopeAPIRead}, typeName: "Mutation", fieldName: "applyBatchChange", allowed: false},
+		{scopes: []string{authz.ScopeCodeIntelUpload}, typeName: "Query", fieldName: "currentUser", allowed: false},
+		{scopes: []string{authz.ScopeBatchChangesWrite}, typeName: "Query", fieldName: "currentUser", allowed: true},
+		{scopes: []string{authz.ScopeBatchChangesWrite}, typeName: "Mutation", fieldName: "applyBatchChange", allowed: true},
+		{scopes: []string{authz.ScopeBatchChangesWrite}, typeName: "Mutation", fieldName: "deleteUser", allowed: false},
+		{scopes: []string{authz.ScopeCodeIntelUpload}, typeName: "User", fieldName: "username", allowed: true},
+	}
+	for _, test := range tests {
+		ctx := actor.WithActor(context.Background(), &actor.Actor{UID: 1, Scopes: test.scopes})
+		err := checkAccessTokenScopes(ctx, test.typeName, test.fieldName)
+		if allowed := err == nil; allowed != test.allowed {
+			t.Errorf("scopes %q, field %s.%s: got allowed %t (error %v), want %t", test.scopes, test.typeName, test.fieldName, allowed, err, test.allowed)
+		}
+	}
+}
+
+func TestAccessTokenScopesExec(t *testing.T) {
+	const (
+		query            = `query { currentUser { username } }`
+		otherMutation    = `mutation { deleteUser(user: "x") { alwaysNil } }`
+		fragmentMutation = `mutation { ...F } fragment F on Mutation { deleteUser(user: "x") { alwaysNil } }`
+		aliasMutation    = `mutation { applyBatchChange: deleteUser(user: "x") { alwaysNil } }`
+	)
+
+	tests := []struct {
+		scopes  []string
+		query   string
+		allowed bool
+	}{
+		{scopes: nil, query: otherMutation, allowed: true},
+		{scopes: []string{authz.ScopeAPIRead}, query: query, allowed: true},
+		{scopes: []string{authz.ScopeCodeIntelUpload}, query: query, allowed: false},
+		{scopes: []string{authz.ScopeBatchChangesWrite}, query: otherMutation, allowed: false},
+		{scopes: []string{authz.ScopeBatchChangesWrite}, query: fragmentMutation, allowed: false},
+		{scopes: []string{authz.ScopeBatchChangesWrite}, query: aliasMutation, allowed: false},
+	}
+	for _, test := range tests {
+		ctx := actor.WithActor(context.Background(), &actor.Actor{UID: 1, Scopes: test.scopes})
+		// The resolvers aren't set up, so allowed fields may still fail: only the scope
+		// errors matter.
+		result := mustParseGraphQLSchema(t).Exec(ctx, test.query, "", nil)
+		allowed := true
+		for _, err := range result.Errors {
+			if err.Message == ErrAccessTokenScopes.Error() {
+				allowed = false
+			}
+		}
+		if allowed != test.allowed {
+			t.Errorf("scopes %q, query %q: got allowed %t (errors %v), want %t", test.scopes, test.query, allowed, result.Errors, test.allowed)
+		}
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"sort"
 	"sync"
+	"time"
 
 	"github.com/cockroachdb/errors"
 	"github.com/graph-gophers/graphql-go"
@@ -20,9 +21,10 @@ import (
 )
 
 type createAccessTokenInput struct {
-	User   graphql.ID
-	Scopes []string
-	Note   string
+	User      graphql.ID
+	Scopes    []string
+	Note      string
+	ExpiresAt *DateTime
 }
 
 func (r *schemaResolver) CreateAccessToken(ctx context.Context, args *createAccessTokenInput) (*createAccessTokenResult, error) {
@@ -53,13 +55,15 @@ func (r *schemaResolver) CreateAccessToken(ctx context.Context, args *createAcce
 	}
 
 	// Validate scopes.
-	var hasUserAllScope bool
+	var hasUserAllScope, hasSudoScope, hasRestrictedScope bool
 	seenScope := map[string]struct{}{}
 	sort.Strings(args.Scopes)
 	for _, scope := range args.Scopes {
 		switch scope {
 		case authz.ScopeUserAll:
 			hasUserAllScope = true
+		case authz.ScopeAPIRead, authz.ScopeCodeIntelUpload, authz.ScopeBatchChangesWrite:
+			hasRestrictedScope = true
 		case authz.ScopeSiteAdminSudo:
 			// 🚨 SECURITY: Only site admins may create a token with the "site-admin:sudo" scope.
 			if err := backend.CheckCurrentUserIsSiteAdmin(ctx, r.db); err != nil {
@@ -67,6 +71,14 @@ func (r *schemaResolver) CreateAccessToken(ctx context.Context, args *createAcce
 			} else if envvar.SourcegraphDotComMode() {
 				return nil, errors.New("creation of access tokens with sudo scope is disabled")
 			}
+			hasSudoScope = true
+		case authz.ScopeExecutor:
+			// 🚨 SECURITY: Only site admins may create a token with the "executor" scope, which
+			// grants access to the executor queue API.
+			if err := backend.CheckCurrentUserIsSiteAdmin(ctx, r.db); err != nil {
+				return nil, err
+			}
+			hasRestrictedScope = true
 		default:
 			return nil, errors.Errorf("unknown access token scope %q (valid scopes: %q)", scope, authz.AllScopes)
 		}
@@ -76,11 +88,30 @@ func (r *schemaResolver) CreateAccessToken(ctx context.Context, args *createAcce
 		}
 		seenScope[scope] = struct{}{}
 	}
-	if !hasUserAllScope {
-		return nil, errors.Errorf("all access tokens must have scope %q", authz.ScopeUserAll)
+	if hasSudoScope && !hasUserAllScope {
+		return nil, errors.Errorf("access tokens with scope %q must also have scope %q", authz.ScopeSiteAdminSudo, authz.ScopeUserAll)
+	}
+	if !hasUserAllScope && !hasRestrictedScope {
+		return nil, errors.Errorf("access tokens must have scope %q or at least one of the restricted scopes %q", authz.ScopeUserAll, []string{authz.ScopeAPIRead, authz.ScopeCodeIntelUpload, authz.ScopeBatchChangesWrite, authz.ScopeExecutor})
+	}
+
+	var expiresAt *time.Time
+	if args.ExpiresAt != nil {
+		if !args.ExpiresAt.Time.After(time.Now()) {
+			return nil, errors.New("the expiry of an access token must be in the future")
+		}
+		expiresAt = &args.ExpiresAt.Time
 	}
 
-	id, token, err := database.AccessTokens(r.db).Create(ctx, userID, args.Scopes, args.Note, actor.FromContext(ctx).UID)
+	id, token, err := database.AccessTokens(r.db).Create(ctx, userID, args.Scopes, args.Note, actor.FromContext(ctx).UID, expiresAt)
+	if err == nil {
+		logSecurityEvent(ctx, r.db, database.SecurityEventNameAccessTokenCreated, map[string]interface{}{
+			"access_token_id": id,
+			"subject_user_id": userID,
+			"scopes":          args.Scopes,
+			"expires_at":      expiresAt,
+		})
+	}
 
 	if conf.CanSendEmail() {
 		if err := backend.UserEmails.SendUserEmailOnFieldUpdate(ctx, userID, "created an access token"); err != nil {
@@ -112,7 +143,10 @@ func (r *schemaResolver) DeleteAccessToken(ctx context.Context, args *deleteAcce
 		return nil, errors.New("exactly one of byID or byToken must be specified")
 	}
 
-	var subjectUserID int32
+	var (
+		subjectUserID int32
+		tokenID       int64
+	)
 	switch {
 	case args.ByID != nil:
 		accessTokenID, err := unmarshalAccessTokenID(*args.ByID)
@@ -123,7 +157,7 @@ func (r *schemaResolver) DeleteAccessToken(ctx context.Context, args *deleteAcce
 		if err != nil {
 			return nil, err
 		}
-		subjectUserID = token.SubjectUserID
+		subjectUserID, tokenID = token.SubjectUserID, token.ID
 
 		// 🚨 SECURITY: Only site admins and the user can delete a user's access token.
 		if err := backend.CheckSiteAdminOrSameUser(ctx, r.db, token.SubjectUserID); err != nil {
@@ -138,7 +172,7 @@ func (r *schemaResolver) DeleteAccessToken(ctx context.Context, args *deleteAcce
 		if err != nil {
 			return nil, err
 		}
-		subjectUserID = token.SubjectUserID
+		subjectUserID, tokenID = token.SubjectUserID, token.ID
 
 		// 🚨 SECURITY: This is easier than the ByID case because anyone holding the access token's
 		// secret value is assumed to be allowed to delete it.
@@ -148,6 +182,11 @@ func (r *schemaResolver) DeleteAccessToken(ctx context.Context, args *deleteAcce
 
 	}
 
+	logSecurityEvent(ctx, r.db, database.SecurityEventNameAccessTokenDeleted, map[string]interface{}{
+		"access_token_id": tokenID,
+		"subject_user_id": subjectUserID,
+	})
+
 	if conf.CanSendEmail() {
 		if err := backend.UserEmails.SendUserEmailOnFieldUpdate(ctx, subjectUserID, "deleted an access token"); err != nil {
 			log15.Warn("Failed to send email to inform user of access token deletion", "error", err)
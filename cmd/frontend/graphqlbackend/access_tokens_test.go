@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/graph-gophers/graphql-go"
@@ -26,7 +27,7 @@ func TestMutation_CreateAccessToken(t *testing.T) {
 	db := new(dbtesting.MockDB)
 
 	mockAccessTokensCreate := func(t *testing.T, wantCreatorUserID int32, wantScopes []string) {
-		database.Mocks.AccessTokens.Create = func(subjectUserID int32, scopes []string, note string, creatorUserID int32) (int64, string, error) {
+		database.Mocks.AccessTokens.Create = func(subjectUserID int32, scopes []string, note string, creatorUserID int32, expiresAt *time.Time) (int64, string, error) {
 			if want := int32(1); subjectUserID != want {
 				t.Errorf("got %v, want %v", subjectUserID, want)
 			}
@@ -108,6 +109,88 @@ func TestMutation_CreateAccessToken(t *testing.T) {
 		}
 	})
 
+	t.Run("authenticated as user, using restricted scopes with expiry", func(t *testing.T) {
+		resetMocks()
+		wantExpiresAt := time.Now().Add(time.Hour).Truncate(time.Second)
+		database.Mocks.AccessTokens.Create = func(subjectUserID int32, scopes []string, note string, creatorUserID int32, expiresAt *time.Time) (int64, string, error) {
+			if want := []string{authz.ScopeAPIRead, authz.ScopeCodeIntelUpload}; !reflect.DeepEqual(scopes, want) {
+				t.Errorf("got %q, want %q", scopes, want)
+			}
+			if expiresAt == nil || !expiresAt.Equal(wantExpiresAt) {
+				t.Errorf("got expiry %v, want %v", expiresAt, wantExpiresAt)
+			}
+			return 1, "t", nil
+		}
+		database.Mocks.Users.GetByCurrentAuthUser = func(ctx context.Context) (*types.User, error) {
+			return &types.User{ID: 1, SiteAdmin: false}, nil
+		}
+
+		ctx := actor.WithActor(context.Background(), &actor.Actor{UID: 1})
+		result, err := (&schemaResolver{db: db}).CreateAccessToken(ctx, &createAccessTokenInput{
+			User:      uid1GQLID,
+			Scopes:    []string{authz.ScopeCodeIntelUpload, authz.ScopeAPIRead},
+			Note:      "n",
+			ExpiresAt: &DateTime{Time: wantExpiresAt},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result == nil {
+			t.Error("result == nil")
+		}
+
+		// Expiries must be in the future.
+		_, err = (&schemaResolver{db: db}).CreateAccessToken(ctx, &createAccessTokenInput{
+			User:      uid1GQLID,
+			Scopes:    []string{authz.ScopeAPIRead},
+			Note:      "n",
+			ExpiresAt: &DateTime{Time: time.Now().Add(-time.Hour)},
+		})
+		if err == nil {
+			t.Error("err == nil")
+		}
+	})
+
+	t.Run("authenticated as user, using executor scope", func(t *testing.T) {
+		resetMocks()
+		database.Mocks.Users.GetByCurrentAuthUser = func(ctx context.Context) (*types.User, error) {
+			return &types.User{ID: 1, SiteAdmin: false}, nil
+		}
+
+		ctx := actor.WithActor(context.Background(), &actor.Actor{UID: 1})
+		result, err := (&schemaResolver{db: db}).CreateAccessToken(ctx, &createAccessTokenInput{
+			User:   uid1GQLID,
+			Scopes: []string{authz.ScopeExecutor},
+			Note:   "n",
+		})
+		if want := backend.ErrMustBeSiteAdmin; err != want {
+			t.Errorf("got err %v, want %v", err, want)
+		}
+		if result != nil {
+			t.Errorf("got result %v, want nil", result)
+		}
+	})
+
+	t.Run("authenticated as site admin, using sudo scope without user:all", func(t *testing.T) {
+		resetMocks()
+		database.Mocks.Users.GetByCurrentAuthUser = func(ctx context.Context) (*types.User, error) {
+			return &types.User{ID: 1, SiteAdmin: true}, nil
+		}
+
+		ctx := actor.WithActor(context.Background(), &actor.Actor{UID: 1})
+		result, err := (&schemaResolver{db: db}).CreateAccessToken(ctx, &createAccessTokenInput{
+			User:   uid1GQLID,
+			Scopes: []string{authz.ScopeAPIRead, authz.ScopeSiteAdminSudo},
+			Note:   "n",
+		})
+		if err == nil {
+			t.Error("err == nil")
+		}
+		if result != nil {
+			t.Errorf("got result %v, want nil", result)
+		}
+	})
+
 	t.Run("authenticated as site admin, using site-admin-only scopes", func(t *testing.T) {
 		resetMocks()
 		mockAccessTokensCreate(t, 1, []string{authz.ScopeSiteAdminSudo, authz.ScopeUserAll})
@@ -2,13 +2,10 @@ package graphqlbackend
 
 import (
 	"context"
-	"regexp"
 
-	"github.com/cockroachdb/errors"
 	"github.com/sourcegraph/go-langserver/pkg/lsp"
 	"github.com/sourcegraph/sourcegraph/internal/compute"
 	"github.com/sourcegraph/sourcegraph/internal/database/dbutil"
-	"github.com/sourcegraph/sourcegraph/internal/search/query"
 	"github.com/sourcegraph/sourcegraph/internal/search/result"
 	"github.com/sourcegraph/sourcegraph/internal/types"
 )
@@ -109,18 +106,25 @@ func toLspRange(r compute.Range) lsp.Range {
 // ComputeText GQL result resolver definitions.
 
 type computeTextResolver struct {
-	repository *RepositoryResolver //nolint
-	commit     string              //nolint
-	path       string              //nolint
+	repository *RepositoryResolver
+	commit     string
+	path       string
 	t          *compute.Text
 }
 
-func (c *computeTextResolver) Repository() *RepositoryResolver { return nil }
-func (r *computeTextResolver) Commit() *string                 { return nil }
-func (r *computeTextResolver) Path() *string                   { return nil }
-func (r *computeTextResolver) Kind() *string                   { return nil }
+func (c *computeTextResolver) Repository() *RepositoryResolver { return c.repository }
+func (r *computeTextResolver) Commit() *string                 { return nonEmptyStringPtr(r.commit) }
+func (r *computeTextResolver) Path() *string                   { return nonEmptyStringPtr(r.path) }
+func (r *computeTextResolver) Kind() *string                   { return nonEmptyStringPtr(r.t.Kind) }
 func (r *computeTextResolver) Value() string                   { return r.t.Value }
 
+func nonEmptyStringPtr(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
 // Definitions required by https://github.com/graph-gophers/graphql-go to resolve
 // a union type in GraphQL.
 
@@ -163,59 +167,48 @@ func toComputeMatchContextResolver(fm *result.FileMatch, mc *compute.MatchContex
 	}
 }
 
-func toComputeResultResolver(r *computeMatchContextResolver) *computeResultResolver {
-	return &computeResultResolver{result: r}
-}
-
-func regexpFromQuery(q string) (*regexp.Regexp, error) {
-	plan, err := query.Pipeline(query.Init(q, query.SearchTypeRegex))
-	if err != nil {
-		return nil, err
-	}
-	if len(plan) != 1 {
-		return nil, errors.New("compute endpoint only supports one search pattern currently ('and' or 'or' operators are not supported yet)")
-	}
-	switch node := plan[0].Pattern.(type) {
-	case query.Operator:
-		if len(node.Operands) == 1 {
-			if pattern, ok := node.Operands[0].(query.Pattern); ok && !pattern.Negated {
-				rp, err := regexp.Compile(pattern.Value)
-				if err != nil {
-					return nil, errors.Wrap(err, "regular expression is not valid for compute endpoint")
-				}
-				return rp, nil
-			}
-		}
-		return nil, errors.New("compute endpoint only supports one search pattern currently ('and' or 'or' operators are not supported yet)")
-	case query.Pattern:
-		if !node.Negated {
-			return regexp.Compile(node.Value)
-		}
+func toComputeTextResolver(fm *result.FileMatch, t *compute.Text, db dbutil.DB) *computeTextResolver {
+	repository := NewRepositoryResolver(db, fm.Repo.ToRepo())
+	return &computeTextResolver{
+		repository: repository,
+		commit:     string(fm.CommitID),
+		path:       fm.Path,
+		t:          t,
 	}
-	// unreachable
-	return nil, nil
 }
 
-func toResultResolverList(pattern *regexp.Regexp, matches []result.Match, db dbutil.DB) []*computeResultResolver {
+func toResultResolverList(ctx context.Context, cmd compute.Command, matches []result.Match, db dbutil.DB) ([]*computeResultResolver, error) {
 	var computeResult []*computeResultResolver
 	for _, m := range matches {
-		if fm, ok := m.(*result.FileMatch); ok {
-			matchContext := compute.FromFileMatch(fm, pattern)
-			computeResult = append(computeResult, toComputeResultResolver(toComputeMatchContextResolver(fm, matchContext, db)))
+		fm, ok := m.(*result.FileMatch)
+		if !ok {
+			continue
+		}
+		r, err := compute.Run(ctx, cmd, fm)
+		if err != nil {
+			return nil, err
+		}
+		switch v := r.(type) {
+		case *compute.MatchContext:
+			computeResult = append(computeResult, &computeResultResolver{result: toComputeMatchContextResolver(fm, v, db)})
+		case *compute.Text:
+			if v != nil {
+				computeResult = append(computeResult, &computeResultResolver{result: toComputeTextResolver(fm, v, db)})
+			}
 		}
 	}
-	return computeResult
+	return computeResult, nil
 }
 
 // NewComputeImplementer is a function that abstracts away the need to have a
 // handle on (*schemaResolver) Compute.
 func NewComputeImplementer(ctx context.Context, db dbutil.DB, args *ComputeArgs) ([]*computeResultResolver, error) {
-	pattern, err := regexpFromQuery(args.Query)
+	computeQuery, err := compute.Parse(args.Query)
 	if err != nil {
 		return nil, err
 	}
 	patternType := "regexp"
-	job, err := NewSearchImplementer(ctx, db, &SearchArgs{Query: args.Query, PatternType: &patternType})
+	job, err := NewSearchImplementer(ctx, db, &SearchArgs{Query: computeQuery.SearchQuery, PatternType: &patternType})
 	if err != nil {
 		return nil, err
 	}
@@ -224,7 +217,7 @@ func NewComputeImplementer(ctx context.Context, db dbutil.DB, args *ComputeArgs)
 	if err != nil {
 		return nil, err
 	}
-	return toResultResolverList(pattern, results.Matches, db), nil
+	return toResultResolverList(ctx, computeQuery.Command, results.Matches, db)
 }
 
 func (r *schemaResolver) Compute(ctx context.Context, args *ComputeArgs) ([]*computeResultResolver, error) {
@@ -1,11 +1,13 @@
 package graphqlbackend
 
 import (
+	"context"
 	"encoding/json"
 	"regexp"
 	"testing"
 
 	"github.com/hexops/autogold"
+	"github.com/sourcegraph/sourcegraph/internal/compute"
 	"github.com/sourcegraph/sourcegraph/internal/database/dbtesting"
 	"github.com/sourcegraph/sourcegraph/internal/search/result"
 )
@@ -20,7 +22,10 @@ func TestToResultResolverList(t *testing.T) {
 		},
 	}
 	test := func(input string) string {
-		resolvers := toResultResolverList(regexp.MustCompile(input), matches, new(dbtesting.MockDB))
+		resolvers, err := toResultResolverList(context.Background(), &compute.MatchOnly{MatchPattern: regexp.MustCompile(input)}, matches, new(dbtesting.MockDB))
+		if err != nil {
+			t.Fatal(err)
+		}
 		var results []string
 		for _, r := range resolvers {
 			for _, m := range r.result.(*computeMatchContextResolver).matches {
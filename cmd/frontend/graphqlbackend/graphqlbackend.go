@@ -123,7 +123,14 @@ VARIABLES
 
 func (prometheusTracer) TraceField(ctx context.Context, label, typeName, fieldName string, trivial bool, args map[string]interface{}) (context.Context, trace.TraceFieldFinishFunc) {
 	start := time.Now()
-	return ctx, func(err *gqlerrors.QueryError) {
+	fieldCtx := ctx
+	// 🚨 SECURITY: Actors authenticated with access tokens without the "user:all" scope may
+	// only resolve the fields allowed by their scopes. This is checked during execution, on the
+	// fields graphql-go resolves, so that fragments and aliases can't hide them.
+	if err := checkAccessTokenScopes(ctx, typeName, fieldName); err != nil {
+		fieldCtx = deniedContext{Context: ctx, err: err}
+	}
+	return fieldCtx, func(err *gqlerrors.QueryError) {
 		isErrStr := strconv.FormatBool(err != nil)
 		graphqlFieldHistogram.WithLabelValues(
 			prometheusTypeName(typeName),
@@ -0,0 +1,98 @@
+package graphqlbackend
+
+import (
+	"context"
+	"strings"
+
+	"github.com/cockroachdb/errors"
+	"github.com/graph-gophers/graphql-go"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/backend"
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/database"
+)
+
+type KeyValuePairResolver struct {
+	kvp database.KeyValuePair
+}
+
+func (k *KeyValuePairResolver) Key() string    { return k.kvp.Key }
+func (k *KeyValuePairResolver) Value() *string { return k.kvp.Value }
+
+func (r *RepositoryResolver) KeyValuePairs(ctx context.Context) ([]*KeyValuePairResolver, error) {
+	kvps, err := database.RepoKVPs(r.db).List(ctx, r.IDInt32())
+	if err != nil {
+		return nil, err
+	}
+	resolvers := make([]*KeyValuePairResolver, 0, len(kvps))
+	for _, kvp := range kvps {
+		resolvers = append(resolvers, &KeyValuePairResolver{kvp: kvp})
+	}
+	return resolvers, nil
+}
+
+type repoKeyValuePairArgs struct {
+	Repo  graphql.ID
+	Key   string
+	Value *string
+}
+
+// resolveRepoKeyValuePairArgs checks that the current user may edit the
+// metadata of the repository, and returns the repository's ID.
+func (r *schemaResolver) resolveRepoKeyValuePairArgs(ctx context.Context, args *repoKeyValuePairArgs) (api.RepoID, error) {
+	// 🚨 SECURITY: Only site admins may edit repository metadata.
+	if err := backend.CheckCurrentUserIsSiteAdmin(ctx, r.db); err != nil {
+		return 0, err
+	}
+
+	if strings.TrimSpace(args.Key) == "" {
+		return 0, errors.New("key must not be empty")
+	}
+	// The key may not contain a colon, which separates it from the value in
+	// the repo:has.meta() predicate.
+	if args.Key != strings.TrimSpace(args.Key) || strings.Contains(args.Key, ":") {
+		return 0, errors.Errorf("invalid key %q: keys must not contain colons or leading or trailing whitespace", args.Key)
+	}
+
+	repoID, err := UnmarshalRepositoryID(args.Repo)
+	if err != nil {
+		return 0, err
+	}
+	// Ensure the repository exists and is visible to the user.
+	if _, err := database.Repos(r.db).Get(ctx, repoID); err != nil {
+		return 0, err
+	}
+	return repoID, nil
+}
+
+func (r *schemaResolver) AddRepoKeyValuePair(ctx context.Context, args *repoKeyValuePairArgs) (*EmptyResponse, error) {
+	repoID, err := r.resolveRepoKeyValuePairArgs(ctx, args)
+	if err != nil {
+		return nil, err
+	}
+	kvp := database.KeyValuePair{Key: args.Key, Value: args.Value}
+	return &EmptyResponse{}, database.RepoKVPs(r.db).Create(ctx, repoID, kvp)
+}
+
+func (r *schemaResolver) UpdateRepoKeyValuePair(ctx context.Context, args *repoKeyValuePairArgs) (*EmptyResponse, error) {
+	repoID, err := r.resolveRepoKeyValuePairArgs(ctx, args)
+	if err != nil {
+		return nil, err
+	}
+	kvp := database.KeyValuePair{Key: args.Key, Value: args.Value}
+	if _, err := database.RepoKVPs(r.db).Update(ctx, repoID, kvp); err != nil {
+		return nil, err
+	}
+	return &EmptyResponse{}, nil
+}
+
+func (r *schemaResolver) DeleteRepoKeyValuePair(ctx context.Context, args *struct {
+	Repo graphql.ID
+	Key  string
+}) (*EmptyResponse, error) {
+	repoID, err := r.resolveRepoKeyValuePairArgs(ctx, &repoKeyValuePairArgs{Repo: args.Repo, Key: args.Key})
+	if err != nil {
+		return nil, err
+	}
+	return &EmptyResponse{}, database.RepoKVPs(r.db).Delete(ctx, repoID, args.Key)
+}
@@ -33,6 +33,7 @@ import (
 	"github.com/sourcegraph/sourcegraph/internal/honey"
 	"github.com/sourcegraph/sourcegraph/internal/rcache"
 	"github.com/sourcegraph/sourcegraph/internal/search"
+	"github.com/sourcegraph/sourcegraph/internal/search/codeowners"
 	"github.com/sourcegraph/sourcegraph/internal/search/filter"
 	"github.com/sourcegraph/sourcegraph/internal/search/query"
 	searchrepos "github.com/sourcegraph/sourcegraph/internal/search/repos"
@@ -501,13 +502,21 @@ func (r *searchResolver) toRepoOptions(q query.Q, opts resolveRepositoriesOpts)
 	commitAfter, _ := q.StringValue(query.FieldRepoHasCommitAfter)
 	searchContextSpec, _ := q.StringValue(query.FieldContext)
 
+	var hasKVPs []search.RepoKVPFilter
+	kvpValues, _ := q.StringValues(query.FieldRepoHasKVP)
+	for _, v := range kvpValues {
+		// Values are validated when the query is parsed.
+		key, value, _ := query.ParseKVP(v)
+		hasKVPs = append(hasKVPs, search.RepoKVPFilter{Key: key, Value: value})
+	}
+
 	var versionContextName string
 	if r.VersionContext != nil {
 		versionContextName = *r.VersionContext
 	}
 
 	var CacheLookup bool
-	if len(opts.effectiveRepoFieldValues) == 0 && opts.limit == 0 {
+	if len(opts.effectiveRepoFieldValues) == 0 && opts.limit == 0 && len(hasKVPs) == 0 {
 		// indicates resolving repositories should cache DB lookups
 		CacheLookup = true
 	}
@@ -525,6 +534,7 @@ func (r *searchResolver) toRepoOptions(q query.Q, opts resolveRepositoriesOpts)
 		NoArchived:         archived == query.No,
 		Visibility:         visibility,
 		CommitAfter:        commitAfter,
+		HasKVPs:            hasKVPs,
 		Query:              q,
 		Ranked:             true,
 		Limit:              opts.limit,
@@ -544,7 +554,7 @@ func withMode(args search.TextParameters, st query.SearchType, versionContext *s
 		if !searchcontexts.IsGlobalSearchContextSpec(querySearchContextSpec) {
 			return false
 		}
-		return len(args.Query.Values(query.FieldRepo)) == 0 && len(args.Query.Values(query.FieldRepoGroup)) == 0 && len(args.Query.Values(query.FieldRepoHasFile)) == 0
+		return len(args.Query.Values(query.FieldRepo)) == 0 && len(args.Query.Values(query.FieldRepoGroup)) == 0 && len(args.Query.Values(query.FieldRepoHasFile)) == 0 && len(args.Query.Values(query.FieldRepoHasKVP)) == 0
 	}
 
 	hasGlobalSearchResultType := args.ResultTypes.Has(result.TypeFile | result.TypePath | result.TypeSymbol)
@@ -1043,7 +1053,7 @@ func (r *searchResolver) resultsRecursive(ctx context.Context, plan query.Plan)
 	}
 
 	for _, q := range plan {
-		predicatePlan, err := substitutePredicates(q, func(pred query.Predicate) (*SearchResults, error) {
+		predicatePlan, err := substitutePredicates(ctx, q, func(pred query.Predicate) (*SearchResults, error) {
 			// Disable streaming for subqueries so we can use
 			// the results rather than sending them back to the caller
 			orig := r.stream
@@ -1077,6 +1087,12 @@ func (r *searchResolver) resultsRecursive(ctx context.Context, plan query.Plan)
 
 		if newResult != nil {
 			newResult.Matches = result.Select(newResult.Matches, q)
+			if sp, _ := q.ToParseTree().StringValue(query.FieldSelect); sp == "file.owners" {
+				newResult.Matches, err = codeowners.SelectOwners(ctx, newResult.Matches)
+				if err != nil {
+					return nil, err
+				}
+			}
 			sr = union(sr, newResult)
 			if len(sr.Matches) > wantCount {
 				sr.Matches = sr.Matches[:wantCount]
@@ -1140,6 +1156,72 @@ func searchResultsToFileNodes(matches []result.Match) ([]query.Node, error) {
 	return nodes, nil
 }
 
+// searchResultsToOwnedFileNodes converts a set of CODEOWNERS file matches into
+// repo/file nodes matching the files owned by owner, so that they can replace
+// a file:has.owner() predicate.
+func searchResultsToOwnedFileNodes(ctx context.Context, matches []result.Match, owner string) ([]query.Node, error) {
+	var nodes []query.Node
+	seen := make(map[api.RepoName]struct{}, len(matches))
+	for _, match := range matches {
+		fileMatch, ok := match.(*result.FileMatch)
+		if !ok {
+			return nil, errors.Errorf("expected type %T, but got %T", &result.FileMatch{}, match)
+		}
+		if _, ok := seen[fileMatch.Repo.Name]; ok {
+			continue
+		}
+		seen[fileMatch.Repo.Name] = struct{}{}
+
+		// The matched file may not be the CODEOWNERS file that applies to
+		// the repository, so look it up.
+		f, err := codeowners.Find(ctx, fileMatch.Repo.Name, fileMatch.CommitID)
+		if err != nil {
+			return nil, err
+		}
+		if f == nil {
+			continue
+		}
+
+		owned := f.OwnedBy(owner)
+		if len(owned.Patterns) == 0 {
+			continue
+		}
+
+		// The owners come from the CODEOWNERS file at the commit we read,
+		// so we search the same revision.
+		repoNode := query.Parameter{
+			Field: query.FieldRepo,
+			Value: "^" + regexp.QuoteMeta(string(fileMatch.Repo.Name)) + "$@" + string(fileMatch.CommitID),
+		}
+		excludes := make([]query.Node, 0, len(owned.Excludes))
+		for _, exclude := range owned.Excludes {
+			excludes = append(excludes, query.Parameter{
+				Field:   query.FieldFile,
+				Value:   exclude,
+				Negated: true,
+			})
+		}
+		for _, p := range owned.Patterns {
+			operands := []query.Node{repoNode, query.Parameter{
+				Field: query.FieldFile,
+				Value: p.Include,
+			}}
+			if p.ExcludeFrom < len(excludes) {
+				operands = append(operands, query.Operator{
+					Kind:     query.And,
+					Operands: excludes[p.ExcludeFrom:],
+				})
+			}
+			nodes = append(nodes, query.Operator{
+				Kind:     query.And,
+				Operands: operands,
+			})
+		}
+	}
+
+	return nodes, nil
+}
+
 // resultsWithTimeoutSuggestion calls doResults, and in case of deadline
 // exceeded returns a search alert with a did-you-mean link for the same
 // query with a longer timeout.
@@ -1167,7 +1249,7 @@ func (r *searchResolver) resultsWithTimeoutSuggestion(ctx context.Context, args
 
 // substitutePredicates replaces all the predicates in a query with their expanded form. The predicates
 // are expanded using the doExpand function.
-func substitutePredicates(q query.Basic, evaluate func(query.Predicate) (*SearchResults, error)) (query.Plan, error) {
+func substitutePredicates(ctx context.Context, q query.Basic, evaluate func(query.Predicate) (*SearchResults, error)) (query.Plan, error) {
 	var topErr error
 	success := false
 	newQ := query.MapParameter(q.ToParseTree(), func(field, value string, neg bool, ann query.Annotation) query.Node {
@@ -1204,7 +1286,11 @@ func substitutePredicates(q query.Basic, evaluate func(query.Predicate) (*Search
 				return nil
 			}
 		case query.FieldFile:
-			nodes, err = searchResultsToFileNodes(srr.Matches)
+			if hasOwner, ok := predicate.(*query.FileHasOwnerPredicate); ok {
+				nodes, err = searchResultsToOwnedFileNodes(ctx, srr.Matches, hasOwner.Owner)
+			} else {
+				nodes, err = searchResultsToFileNodes(srr.Matches)
+			}
 			if err != nil {
 				topErr = err
 				return nil
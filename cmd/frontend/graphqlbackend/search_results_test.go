@@ -3,6 +3,7 @@ package graphqlbackend
 import (
 	"context"
 	"fmt"
+	"os"
 	"reflect"
 	"sort"
 	"strings"
@@ -31,6 +32,7 @@ import (
 	"github.com/sourcegraph/sourcegraph/internal/search/symbol"
 	"github.com/sourcegraph/sourcegraph/internal/search/unindexed"
 	"github.com/sourcegraph/sourcegraph/internal/types"
+	"github.com/sourcegraph/sourcegraph/internal/vcs/git"
 	"github.com/sourcegraph/sourcegraph/schema"
 )
 
@@ -1069,3 +1071,28 @@ func TestZeroElapsedMilliseconds(t *testing.T) {
 		t.Fatalf("got %d, want %d", got, 0)
 	}
 }
+
+func TestSearchResultsToOwnedFileNodes(t *testing.T) {
+	git.Mocks.ReadFile = func(commit api.CommitID, name string) ([]byte, error) {
+		if name == ".github/CODEOWNERS" {
+			return []byte("* @everyone\n/internal/search/ @search\n/internal/search/backend/ @zoekt\n"), nil
+		}
+		return nil, &os.PathError{Op: "open", Path: name, Err: os.ErrNotExist}
+	}
+	defer func() { git.Mocks.ReadFile = nil }()
+
+	codeownersMatch := &result.FileMatch{File: result.File{
+		Repo:     types.RepoName{Name: "github.com/sourcegraph/sourcegraph"},
+		CommitID: "deadbeef",
+		Path:     ".github/CODEOWNERS",
+	}}
+	nodes, err := searchResultsToOwnedFileNodes(context.Background(), []result.Match{codeownersMatch}, "@search")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := `(repo:^github\.com/sourcegraph/sourcegraph$@deadbeef and file:^internal/search/ and -file:^internal/search/backend/)`
+	if got := query.StringHuman(nodes); got != want {
+		t.Fatalf("got %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,182 @@
+package graphqlbackend
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/cockroachdb/errors"
+	"github.com/graph-gophers/graphql-go"
+	"github.com/graph-gophers/graphql-go/relay"
+	"github.com/inconshreveable/log15"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/backend"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/graphqlbackend/graphqlutil"
+	"github.com/sourcegraph/sourcegraph/internal/actor"
+	"github.com/sourcegraph/sourcegraph/internal/database"
+	"github.com/sourcegraph/sourcegraph/internal/database/dbutil"
+	"github.com/sourcegraph/sourcegraph/internal/errcode"
+)
+
+// maxSecurityEventsFirst is the maximum number of security events returned by a query.
+const maxSecurityEventsFirst = 1000
+
+type securityEventsArgs struct {
+	First int32
+	After *string
+	Name  *string
+	User  *graphql.ID
+}
+
+func (r *schemaResolver) SecurityEvents(ctx context.Context, args *securityEventsArgs) (*securityEventConnectionResolver, error) {
+	// 🚨 SECURITY: Only site admins may list security events.
+	if err := backend.CheckCurrentUserIsSiteAdmin(ctx, r.db); err != nil {
+		return nil, err
+	}
+
+	if args.First < 1 || args.First > maxSecurityEventsFirst {
+		return nil, errors.Errorf("first must be between 1 and %d", maxSecurityEventsFirst)
+	}
+
+	opt := database.SecurityEventsListOptions{Limit: int(args.First)}
+	if args.After != nil {
+		before, err := unmarshalSecurityEventID(graphql.ID(*args.After))
+		if err != nil {
+			return nil, err
+		}
+		opt.Before = before
+	}
+	if args.Name != nil {
+		opt.Name = database.SecurityEventName(*args.Name)
+	}
+	if args.User != nil {
+		userID, err := UnmarshalUserID(*args.User)
+		if err != nil {
+			return nil, err
+		}
+		opt.UserID = userID
+	}
+	return &securityEventConnectionResolver{db: r.db, opt: opt}, nil
+}
+
+type securityEventConnectionResolver struct {
+	db  dbutil.DB
+	opt database.SecurityEventsListOptions
+
+	// cache results because they are used by multiple fields
+	once   sync.Once
+	events []*database.SecurityEvent
+	next   int64
+	err    error
+}
+
+func (r *securityEventConnectionResolver) compute(ctx context.Context) ([]*database.SecurityEvent, int64, error) {
+	r.once.Do(func() {
+		// Fetch one more event to know whether there is a next page.
+		opt := r.opt
+		if opt.Limit > 0 {
+			opt.Limit++
+		}
+		r.events, r.err = database.SecurityEventLogs(r.db).List(ctx, opt)
+		if r.opt.Limit > 0 && len(r.events) > r.opt.Limit {
+			r.events = r.events[:r.opt.Limit]
+			r.next = r.events[len(r.events)-1].ID
+		}
+	})
+	return r.events, r.next, r.err
+}
+
+func (r *securityEventConnectionResolver) Nodes(ctx context.Context) ([]*securityEventResolver, error) {
+	events, _, err := r.compute(ctx)
+	if err != nil {
+		return nil, err
+	}
+	resolvers := make([]*securityEventResolver, 0, len(events))
+	for _, e := range events {
+		resolvers = append(resolvers, &securityEventResolver{db: r.db, event: e})
+	}
+	return resolvers, nil
+}
+
+func (r *securityEventConnectionResolver) TotalCount(ctx context.Context) (int32, error) {
+	// Reset pagination cursor to get correct total count
+	opt := r.opt
+	opt.Before = 0
+	count, err := database.SecurityEventLogs(r.db).Count(ctx, opt)
+	return int32(count), err
+}
+
+func (r *securityEventConnectionResolver) PageInfo(ctx context.Context) (*graphqlutil.PageInfo, error) {
+	_, next, err := r.compute(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if next == 0 {
+		return graphqlutil.HasNextPage(false), nil
+	}
+	return graphqlutil.NextPageCursor(string(marshalSecurityEventID(next))), nil
+}
+
+type securityEventResolver struct {
+	db    dbutil.DB
+	event *database.SecurityEvent
+}
+
+func marshalSecurityEventID(id int64) graphql.ID { return relay.MarshalID("SecurityEvent", id) }
+
+func unmarshalSecurityEventID(id graphql.ID) (eventID int64, err error) {
+	err = relay.UnmarshalSpec(id, &eventID)
+	return
+}
+
+func (r *securityEventResolver) ID() graphql.ID { return marshalSecurityEventID(r.event.ID) }
+
+func (r *securityEventResolver) Name() string { return string(r.event.Name) }
+
+func (r *securityEventResolver) User(ctx context.Context) (*UserResolver, error) {
+	if r.event.UserID == 0 {
+		return nil, nil
+	}
+	user, err := UserByIDInt32(ctx, r.db, int32(r.event.UserID))
+	if errcode.IsNotFound(err) {
+		// The user may have been deleted since.
+		return nil, nil
+	}
+	return user, err
+}
+
+func (r *securityEventResolver) AnonymousUserID() string { return r.event.AnonymousUserID }
+
+func (r *securityEventResolver) URL() string { return r.event.URL }
+
+func (r *securityEventResolver) Source() string { return r.event.Source }
+
+func (r *securityEventResolver) Argument() JSONValue { return JSONValue{r.event.Argument} }
+
+func (r *securityEventResolver) Version() string { return r.event.Version }
+
+func (r *securityEventResolver) Timestamp() DateTime { return DateTime{Time: r.event.Timestamp} }
+
+// logSecurityEvent logs a security event caused by the current actor, with the argument
+// marshaled to JSON.
+func logSecurityEvent(ctx context.Context, db dbutil.DB, name database.SecurityEventName, argument interface{}) {
+	arg, err := json.Marshal(argument)
+	if err != nil {
+		log15.Error("logSecurityEvent: failed to marshal JSON", "argument", argument)
+	}
+
+	event := &database.SecurityEvent{
+		Name:      name,
+		UserID:    uint32(actor.FromContext(ctx).UID),
+		Argument:  arg,
+		Source:    "BACKEND",
+		Timestamp: time.Now(),
+	}
+	// Unauthenticated actors, such as clients deleting an access token by its value, need an
+	// anonymous user ID to satisfy the security_event_logs_check_has_user constraint.
+	if event.UserID == 0 {
+		event.AnonymousUserID = "anonymous"
+	}
+	database.SecurityEventLogs(db).LogEvent(ctx, event)
+}
@@ -0,0 +1,80 @@
+package graphqlbackend
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/backend"
+	"github.com/sourcegraph/sourcegraph/internal/actor"
+	"github.com/sourcegraph/sourcegraph/internal/database"
+	"github.com/sourcegraph/sourcegraph/internal/database/dbtesting"
+	"github.com/sourcegraph/sourcegraph/internal/types"
+)
+
+func TestSecurityEvents(t *testing.T) {
+	resetMocks()
+	database.Mocks.Users.GetByCurrentAuthUser = func(ctx context.Context) (*types.User, error) {
+		return &types.User{ID: 1, SiteAdmin: false}, nil
+	}
+	defer resetMocks()
+
+	// 🚨 SECURITY: Only site admins may list security events.
+	ctx := actor.WithActor(context.Background(), &actor.Actor{UID: 1})
+	result, err := (&schemaResolver{db: new(dbtesting.MockDB)}).SecurityEvents(ctx, &securityEventsArgs{First: 50})
+	if want := backend.ErrMustBeSiteAdmin; err != want {
+		t.Errorf("got err %v, want %v", err, want)
+	}
+	if result != nil {
+		t.Errorf("got result %v, want nil", result)
+	}
+}
+
+func TestSecurityEventsFirst(t *testing.T) {
+	resetMocks()
+	database.Mocks.Users.GetByCurrentAuthUser = func(ctx context.Context) (*types.User, error) {
+		return &types.User{ID: 1, SiteAdmin: true}, nil
+	}
+	defer resetMocks()
+
+	ctx := actor.WithActor(context.Background(), &actor.Actor{UID: 1})
+	for _, first := range []int32{-1, 0, maxSecurityEventsFirst + 1} {
+		if _, err := (&schemaResolver{db: new(dbtesting.MockDB)}).SecurityEvents(ctx, &securityEventsArgs{First: first}); err == nil {
+			t.Errorf("first=%d: expected an error", first)
+		}
+	}
+}
+
+func TestChangedSiteConfigKeys(t *testing.T) {
+	for name, tc := range map[string]struct {
+		before, after string
+		want          []string
+	}{
+		"no changes": {
+			before: `{"externalURL": "https://sourcegraph.example.com", "log": {}}`,
+			after: `{
+				// Comments and formatting are ignored.
+				"log": {},
+				"externalURL": "https://sourcegraph.example.com",
+			}`,
+			want: []string{},
+		},
+		"changed, added and removed settings": {
+			before: `{"externalURL": "https://sourcegraph.example.com", "auth.providers": [{"type": "builtin"}], "disablePublicRepoRedirects": true}`,
+			after:  `{"externalURL": "https://sourcegraph.example.com", "auth.providers": [{"type": "builtin", "allowSignup": true}], "log": {}}`,
+			want:   []string{"auth.providers", "disablePublicRepoRedirects", "log"},
+		},
+		"invalid configuration": {
+			before: `{"externalURL": `,
+			after:  `{"externalURL": "https://sourcegraph.example.com"}`,
+			want:   []string{"externalURL"},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			if diff := cmp.Diff(tc.want, changedSiteConfigKeys(tc.before, tc.after)); diff != "" {
+				t.Errorf("unexpected keys (-want +have):\n%s", diff)
+			}
+		})
+	}
+}
@@ -1,8 +1,11 @@
 package graphqlbackend
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"os"
+	"sort"
 	"strconv"
 	"strings"
 
@@ -17,6 +20,7 @@ import (
 	"github.com/sourcegraph/sourcegraph/internal/database"
 	"github.com/sourcegraph/sourcegraph/internal/database/dbutil"
 	"github.com/sourcegraph/sourcegraph/internal/env"
+	"github.com/sourcegraph/sourcegraph/internal/jsonc"
 	"github.com/sourcegraph/sourcegraph/internal/version"
 
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/globals"
@@ -176,14 +180,51 @@ func (r *schemaResolver) UpdateSiteConfiguration(ctx context.Context, args *stru
 	}
 
 	prev := globals.ConfigurationServerFrontendOnly.Raw()
+	before := prev.Site
 	prev.Site = args.Input
 	// TODO(slimsag): future: actually pass lastID through to prevent race conditions
 	if err := globals.ConfigurationServerFrontendOnly.Write(ctx, prev); err != nil {
 		return false, err
 	}
+
+	// 🚨 SECURITY: Only the names of the changed settings are logged, as their values may be
+	// secrets.
+	logSecurityEvent(ctx, r.db, database.SecurityEventNameSiteConfigUpdated, map[string]interface{}{
+		"changed": changedSiteConfigKeys(before, args.Input),
+	})
 	return globals.ConfigurationServerFrontendOnly.NeedServerRestart(), nil
 }
 
+// changedSiteConfigKeys returns the sorted top-level keys whose values differ between the
+// before and after site configurations. Unparseable configurations are treated as empty.
+func changedSiteConfigKeys(before, after string) []string {
+	var b, a map[string]json.RawMessage
+	_ = jsonc.Unmarshal(before, &b)
+	_ = jsonc.Unmarshal(after, &a)
+
+	changed := []string{}
+	for k, v := range a {
+		if w, ok := b[k]; !ok || !bytes.Equal(compactJSON(v), compactJSON(w)) {
+			changed = append(changed, k)
+		}
+	}
+	for k := range b {
+		if _, ok := a[k]; !ok {
+			changed = append(changed, k)
+		}
+	}
+	sort.Strings(changed)
+	return changed
+}
+
+func compactJSON(v json.RawMessage) []byte {
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, v); err != nil {
+		return v
+	}
+	return buf.Bytes()
+}
+
 var siteConfigAllowEdits, _ = strconv.ParseBool(env.Get("SITE_CONFIG_ALLOW_EDITS", "false", "When SITE_CONFIG_FILE is in use, allow edits in the application to be made which will be overwritten on next process restart"))
 
 func canUpdateSiteConfiguration() bool {
@@ -126,6 +126,11 @@ func getRepoNameFromService(ctx context.Context, cloneURL string, svc *types.Ext
 	case *schema.BitbucketServerConnection:
 		rs = reposource.BitbucketServer{BitbucketServerConnection: c}
 		host = c.Url
+	case *schema.GerritConnection:
+		rs = reposource.Gerrit{GerritConnection: c}
+	case *schema.GiteaConnection:
+		rs = reposource.Gitea{GiteaConnection: c}
+		host = c.Url
 	case *schema.AWSCodeCommitConnection:
 		rs = reposource.AWS{AWSCodeCommitConnection: c}
 		// AWS type does not have URL
@@ -1,17 +1,24 @@
 package httpapi
 
 import (
+	"crypto/sha256"
+	"encoding/json"
 	"net/http"
+	"strings"
+	"time"
 
+	lru "github.com/hashicorp/golang-lru"
 	"github.com/inconshreveable/log15"
 
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/backend"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/envvar"
 	"github.com/sourcegraph/sourcegraph/internal/actor"
 	"github.com/sourcegraph/sourcegraph/internal/authz"
 	"github.com/sourcegraph/sourcegraph/internal/conf"
 	"github.com/sourcegraph/sourcegraph/internal/database"
 	"github.com/sourcegraph/sourcegraph/internal/database/dbutil"
 	"github.com/sourcegraph/sourcegraph/internal/errcode"
+	"github.com/sourcegraph/sourcegraph/internal/httpserver"
 )
 
 // AccessTokenAuthMiddleware authenticates the user based on the
@@ -63,23 +70,41 @@ func AccessTokenAuthMiddleware(db dbutil.DB, next http.Handler) http.Handler {
 			//
 			// 🚨 SECURITY: It's important we check for the correct scopes to know what this token
 			// is allowed to do.
-			var requiredScope string
+			var acceptedScopes []string
 			if sudoUser == "" {
-				requiredScope = authz.ScopeUserAll
+				acceptedScopes = userScopes
 			} else {
-				requiredScope = authz.ScopeSiteAdminSudo
+				acceptedScopes = []string{authz.ScopeSiteAdminSudo}
 			}
-			subjectUserID, err := database.AccessTokens(db).Lookup(r.Context(), token, requiredScope)
+			subjectUserID, tokenScopes, err := database.AccessTokens(db).Lookup(r.Context(), token, acceptedScopes, httpserver.RemoteIP(r))
 			if err != nil {
 				log15.Error("Invalid access token.", "token", token, "err", err)
 				http.Error(w, "Invalid access token.", http.StatusUnauthorized)
 				return
 			}
 
+			// 🚨 SECURITY: Tokens without the "user:all" scope may only be used with the endpoints
+			// allowed by their scopes, which further restrict what the actor may do.
+			var restrictedScopes []string
+			if sudoUser == "" && !hasScope(tokenScopes, authz.ScopeUserAll) {
+				restrictedScopes = tokenScopes
+				if !restrictedScopesAllowPath(restrictedScopes, r.URL.Path) {
+					http.Error(w, "The access token's scopes do not allow this request.", http.StatusForbidden)
+					return
+				}
+			}
+
 			// Determine the actor's user ID.
 			var actorUserID int32
 			if sudoUser == "" {
 				actorUserID = subjectUserID
+				// Sourcegraph.com serves too many API requests to record each of them.
+				if !envvar.SourcegraphDotComMode() && shouldLogAccessTokenUsed(token, httpserver.RemoteIP(r), time.Now()) {
+					logAccessTokenEvent(r, db, database.SecurityEventNameAccessTokenUsed, subjectUserID, map[string]interface{}{
+						"scopes":    tokenScopes,
+						"remote_ip": httpserver.RemoteIP(r),
+					})
+				}
 			} else {
 				// 🚨 SECURITY: Confirm that the sudo token's subject is still a site admin, to
 				// prevent users from retaining site admin privileges after being demoted.
@@ -105,11 +130,94 @@ func AccessTokenAuthMiddleware(db dbutil.DB, next http.Handler) http.Handler {
 				}
 				actorUserID = user.ID
 				log15.Debug("HTTP request used sudo token.", "requestURI", r.URL.RequestURI(), "tokenSubjectUserID", subjectUserID, "actorUserID", actorUserID, "actorUsername", user.Username)
+				logAccessTokenEvent(r, db, database.SecurityEventNameSudoUsed, subjectUserID, map[string]interface{}{
+					"sudo_user_id":  user.ID,
+					"sudo_username": user.Username,
+					"remote_ip":     httpserver.RemoteIP(r),
+				})
 			}
 
-			r = r.WithContext(actor.WithActor(r.Context(), &actor.Actor{UID: actorUserID}))
+			r = r.WithContext(actor.WithActor(r.Context(), &actor.Actor{UID: actorUserID, Scopes: restrictedScopes}))
 		}
 
 		next.ServeHTTP(w, r)
 	})
 }
+
+// logAccessTokenEvent logs a security event for a request authenticated with an access token of
+// the user subjectUserID.
+func logAccessTokenEvent(r *http.Request, db dbutil.DB, name database.SecurityEventName, subjectUserID int32, argument map[string]interface{}) {
+	arg, err := json.Marshal(argument)
+	if err != nil {
+		log15.Error("logAccessTokenEvent: failed to marshal JSON", "argument", argument)
+	}
+
+	database.SecurityEventLogs(db).LogEvent(r.Context(), &database.SecurityEvent{
+		Name:      name,
+		URL:       r.URL.Path,
+		UserID:    uint32(subjectUserID),
+		Argument:  arg,
+		Source:    "BACKEND",
+		Timestamp: time.Now(),
+	})
+}
+
+// accessTokenUsedInterval is the minimum interval between two AccessTokenUsed events of an
+// access token used from the same address, so that API requests don't each write to the
+// database.
+const accessTokenUsedInterval = 5 * time.Minute
+
+// accessTokenUsedLogged maps the access tokens and addresses of the recent AccessTokenUsed events
+// to when they were recorded.
+var accessTokenUsedLogged, _ = lru.New(10000)
+
+// shouldLogAccessTokenUsed reports whether an AccessTokenUsed event must be recorded for a request
+// authenticated with token from remoteIP at the time now, which is the case if no event was
+// recorded for them during the last accessTokenUsedInterval.
+func shouldLogAccessTokenUsed(token, remoteIP string, now time.Time) bool {
+	// Hash the token so that it isn't kept in memory.
+	key := sha256.Sum256([]byte(token + "\x00" + remoteIP))
+	if loggedAt, ok := accessTokenUsedLogged.Get(key); ok && now.Sub(loggedAt.(time.Time)) < accessTokenUsedInterval {
+		return false
+	}
+	accessTokenUsedLogged.Add(key, now)
+	return true
+}
+
+// userScopes are the scopes of access tokens that can authenticate requests as their subject user.
+var userScopes = []string{
+	authz.ScopeUserAll,
+	authz.ScopeAPIRead,
+	authz.ScopeCodeIntelUpload,
+	authz.ScopeBatchChangesWrite,
+}
+
+// restrictedScopePaths are the paths that access tokens without the "user:all" scope may request,
+// by scope. The handlers of these paths further check that the actor has the required scope, for
+// example the GraphQL handler only runs queries for "api:read" tokens.
+var restrictedScopePaths = map[string][]string{
+	authz.ScopeAPIRead:           {"/.api/graphql", "/.api/search/stream"},
+	authz.ScopeCodeIntelUpload:   {"/.api/lsif/upload"},
+	authz.ScopeBatchChangesWrite: {"/.api/graphql", "/.api/search/stream"},
+}
+
+func restrictedScopesAllowPath(scopes []string, path string) bool {
+	path = strings.TrimSuffix(path, "/")
+	for _, scope := range scopes {
+		for _, p := range restrictedScopePaths[scope] {
+			if path == p {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func hasScope(scopes []string, scope string) bool {
+	for _, s := range scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
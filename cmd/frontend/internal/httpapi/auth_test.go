@@ -6,7 +6,9 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"reflect"
 	"testing"
+	"time"
 
 	"github.com/cockroachdb/errors"
 
@@ -23,6 +25,9 @@ func TestAccessTokenAuthMiddleware(t *testing.T) {
 		actor := actor.FromContext(r.Context())
 		if actor.IsAuthenticated() {
 			fmt.Fprintf(w, "user %v", actor.UID)
+			if actor.Scopes != nil {
+				fmt.Fprintf(w, " scopes %v", actor.Scopes)
+			}
 		} else {
 			fmt.Fprint(w, "no user")
 		}
@@ -71,9 +76,9 @@ func TestAccessTokenAuthMiddleware(t *testing.T) {
 		req, _ := http.NewRequest("GET", "/", nil)
 		req.Header.Set("Authorization", "token badbad")
 		var calledAccessTokensLookup bool
-		database.Mocks.AccessTokens.Lookup = func(tokenHexEncoded, requiredScope string) (subjectUserID int32, err error) {
+		database.Mocks.AccessTokens.Lookup = func(tokenHexEncoded string, acceptedScopes []string, remoteIP string) (subjectUserID int32, scopes []string, err error) {
 			calledAccessTokensLookup = true
-			return 0, errors.New("x")
+			return 0, nil, errors.New("x")
 		}
 		defer func() { database.Mocks = database.MockStores{} }()
 		checkHTTPResponse(t, req, http.StatusUnauthorized, "Invalid access token.\n")
@@ -87,15 +92,15 @@ func TestAccessTokenAuthMiddleware(t *testing.T) {
 			req, _ := http.NewRequest("GET", "/", nil)
 			req.Header.Set("Authorization", headerValue)
 			var calledAccessTokensLookup bool
-			database.Mocks.AccessTokens.Lookup = func(tokenHexEncoded, requiredScope string) (subjectUserID int32, err error) {
+			database.Mocks.AccessTokens.Lookup = func(tokenHexEncoded string, acceptedScopes []string, remoteIP string) (subjectUserID int32, scopes []string, err error) {
 				calledAccessTokensLookup = true
 				if want := "abcdef"; tokenHexEncoded != want {
 					t.Errorf("got %q, want %q", tokenHexEncoded, want)
 				}
-				if want := authz.ScopeUserAll; requiredScope != want {
-					t.Errorf("got %q, want %q", requiredScope, want)
+				if want := userScopes; !reflect.DeepEqual(acceptedScopes, want) {
+					t.Errorf("got %q, want %q", acceptedScopes, want)
 				}
-				return 123, nil
+				return 123, []string{authz.ScopeUserAll}, nil
 			}
 			defer func() { database.Mocks = database.MockStores{} }()
 			checkHTTPResponse(t, req, http.StatusOK, "user 123")
@@ -111,15 +116,15 @@ func TestAccessTokenAuthMiddleware(t *testing.T) {
 		req.Header.Set("Authorization", "token abcdef")
 		req = req.WithContext(actor.WithActor(context.Background(), &actor.Actor{UID: 456}))
 		var calledAccessTokensLookup bool
-		database.Mocks.AccessTokens.Lookup = func(tokenHexEncoded, requiredScope string) (subjectUserID int32, err error) {
+		database.Mocks.AccessTokens.Lookup = func(tokenHexEncoded string, acceptedScopes []string, remoteIP string) (subjectUserID int32, scopes []string, err error) {
 			calledAccessTokensLookup = true
 			if want := "abcdef"; tokenHexEncoded != want {
 				t.Errorf("got %q, want %q", tokenHexEncoded, want)
 			}
-			if want := authz.ScopeUserAll; requiredScope != want {
-				t.Errorf("got %q, want %q", requiredScope, want)
+			if want := userScopes; !reflect.DeepEqual(acceptedScopes, want) {
+				t.Errorf("got %q, want %q", acceptedScopes, want)
 			}
-			return 123, nil
+			return 123, []string{authz.ScopeUserAll}, nil
 		}
 		defer func() { database.Mocks = database.MockStores{} }()
 		checkHTTPResponse(t, req, http.StatusOK, "user 123")
@@ -145,15 +150,15 @@ func TestAccessTokenAuthMiddleware(t *testing.T) {
 			}
 			req = req.WithContext(actor.WithActor(context.Background(), &actor.Actor{UID: 456}))
 			var calledAccessTokensLookup bool
-			database.Mocks.AccessTokens.Lookup = func(tokenHexEncoded, requiredScope string) (subjectUserID int32, err error) {
+			database.Mocks.AccessTokens.Lookup = func(tokenHexEncoded string, acceptedScopes []string, remoteIP string) (subjectUserID int32, scopes []string, err error) {
 				calledAccessTokensLookup = true
 				if want := "abcdef"; tokenHexEncoded != want {
 					t.Errorf("got %q, want %q", tokenHexEncoded, want)
 				}
-				if want := authz.ScopeUserAll; requiredScope != want {
-					t.Errorf("got %q, want %q", requiredScope, want)
+				if want := userScopes; !reflect.DeepEqual(acceptedScopes, want) {
+					t.Errorf("got %q, want %q", acceptedScopes, want)
 				}
-				return 123, nil
+				return 123, []string{authz.ScopeUserAll}, nil
 			}
 			defer func() { database.Mocks = database.MockStores{} }()
 			checkHTTPResponse(t, req, http.StatusOK, "user 123")
@@ -163,19 +168,42 @@ func TestAccessTokenAuthMiddleware(t *testing.T) {
 		})
 	}
 
+	// Test that tokens without the "user:all" scope are restricted to the endpoints allowed by their
+	// scopes.
+	for path, wantStatusCode := range map[string]int{
+		"/.api/graphql":       http.StatusOK,
+		"/.api/search/stream": http.StatusOK,
+		"/.api/lsif/upload":   http.StatusForbidden,
+		"/users/alice":        http.StatusForbidden,
+	} {
+		t.Run("valid restricted token: "+path, func(t *testing.T) {
+			req, _ := http.NewRequest("GET", path, nil)
+			req.Header.Set("Authorization", "token abcdef")
+			database.Mocks.AccessTokens.Lookup = func(tokenHexEncoded string, acceptedScopes []string, remoteIP string) (subjectUserID int32, scopes []string, err error) {
+				return 123, []string{authz.ScopeAPIRead}, nil
+			}
+			defer func() { database.Mocks = database.MockStores{} }()
+			wantBody := "user 123 scopes [api:read]"
+			if wantStatusCode != http.StatusOK {
+				wantBody = "The access token's scopes do not allow this request.\n"
+			}
+			checkHTTPResponse(t, req, wantStatusCode, wantBody)
+		})
+	}
+
 	t.Run("valid sudo token", func(t *testing.T) {
 		req, _ := http.NewRequest("GET", "/", nil)
 		req.Header.Set("Authorization", `token-sudo token="abcdef",user="alice"`)
 		var calledAccessTokensLookup bool
-		database.Mocks.AccessTokens.Lookup = func(tokenHexEncoded, requiredScope string) (subjectUserID int32, err error) {
+		database.Mocks.AccessTokens.Lookup = func(tokenHexEncoded string, acceptedScopes []string, remoteIP string) (subjectUserID int32, scopes []string, err error) {
 			calledAccessTokensLookup = true
 			if want := "abcdef"; tokenHexEncoded != want {
 				t.Errorf("got %q, want %q", tokenHexEncoded, want)
 			}
-			if want := authz.ScopeSiteAdminSudo; requiredScope != want {
-				t.Errorf("got %q, want %q", requiredScope, want)
+			if want := []string{authz.ScopeSiteAdminSudo}; !reflect.DeepEqual(acceptedScopes, want) {
+				t.Errorf("got %q, want %q", acceptedScopes, want)
 			}
-			return 123, nil
+			return 123, []string{authz.ScopeSiteAdminSudo}, nil
 		}
 		var calledUsersGetByID bool
 		database.Mocks.Users.GetByID = func(ctx context.Context, userID int32) (*types.User, error) {
@@ -212,15 +240,15 @@ func TestAccessTokenAuthMiddleware(t *testing.T) {
 		req, _ := http.NewRequest("GET", "/", nil)
 		req.Header.Set("Authorization", `token-sudo token="abcdef",user="alice"`)
 		var calledAccessTokensLookup bool
-		database.Mocks.AccessTokens.Lookup = func(tokenHexEncoded, requiredScope string) (subjectUserID int32, err error) {
+		database.Mocks.AccessTokens.Lookup = func(tokenHexEncoded string, acceptedScopes []string, remoteIP string) (subjectUserID int32, scopes []string, err error) {
 			calledAccessTokensLookup = true
 			if want := "abcdef"; tokenHexEncoded != want {
 				t.Errorf("got %q, want %q", tokenHexEncoded, want)
 			}
-			if want := authz.ScopeSiteAdminSudo; requiredScope != want {
-				t.Errorf("got %q, want %q", requiredScope, want)
+			if want := []string{authz.ScopeSiteAdminSudo}; !reflect.DeepEqual(acceptedScopes, want) {
+				t.Errorf("got %q, want %q", acceptedScopes, want)
 			}
-			return 123, nil
+			return 123, []string{authz.ScopeSiteAdminSudo}, nil
 		}
 		var calledUsersGetByID bool
 		database.Mocks.Users.GetByID = func(ctx context.Context, userID int32) (*types.User, error) {
@@ -244,15 +272,15 @@ func TestAccessTokenAuthMiddleware(t *testing.T) {
 		req, _ := http.NewRequest("GET", "/", nil)
 		req.Header.Set("Authorization", `token-sudo token="abcdef",user="doesntexist"`)
 		var calledAccessTokensLookup bool
-		database.Mocks.AccessTokens.Lookup = func(tokenHexEncoded, requiredScope string) (subjectUserID int32, err error) {
+		database.Mocks.AccessTokens.Lookup = func(tokenHexEncoded string, acceptedScopes []string, remoteIP string) (subjectUserID int32, scopes []string, err error) {
 			calledAccessTokensLookup = true
 			if want := "abcdef"; tokenHexEncoded != want {
 				t.Errorf("got %q, want %q", tokenHexEncoded, want)
 			}
-			if want := authz.ScopeSiteAdminSudo; requiredScope != want {
-				t.Errorf("got %q, want %q", requiredScope, want)
+			if want := []string{authz.ScopeSiteAdminSudo}; !reflect.DeepEqual(acceptedScopes, want) {
+				t.Errorf("got %q, want %q", acceptedScopes, want)
 			}
-			return 123, nil
+			return 123, []string{authz.ScopeSiteAdminSudo}, nil
 		}
 		var calledUsersGetByID bool
 		database.Mocks.Users.GetByID = func(ctx context.Context, userID int32) (*types.User, error) {
@@ -283,3 +311,22 @@ func TestAccessTokenAuthMiddleware(t *testing.T) {
 		}
 	})
 }
+
+func TestShouldLogAccessTokenUsed(t *testing.T) {
+	now := time.Now()
+	for _, tc := range []struct {
+		token, remoteIP string
+		at              time.Time
+		want            bool
+	}{
+		{token: "t1", remoteIP: "203.0.113.1", at: now, want: true},
+		{token: "t1", remoteIP: "203.0.113.1", at: now.Add(time.Minute), want: false},
+		{token: "t1", remoteIP: "203.0.113.2", at: now.Add(time.Minute), want: true},
+		{token: "t2", remoteIP: "203.0.113.1", at: now.Add(time.Minute), want: true},
+		{token: "t1", remoteIP: "203.0.113.1", at: now.Add(accessTokenUsedInterval), want: true},
+	} {
+		if have := shouldLogAccessTokenUsed(tc.token, tc.remoteIP, tc.at); have != tc.want {
+			t.Errorf("shouldLogAccessTokenUsed(%q, %q, %s) = %t, want %t", tc.token, tc.remoteIP, tc.at, have, tc.want)
+		}
+	}
+}
@@ -636,26 +636,29 @@ func serveGitExec(w http.ResponseWriter, r *http.Request) error {
 		return errors.Wrap(err, "Encode")
 	}
 
-	// Find the correct shard to query
-	addr := gitserver.DefaultClient.AddrForRepo(repo.Name)
+	// Find the correct shards to query
+	addrs := gitserver.DefaultClient.AddrsForRepo(repo.Name)
 
 	director := func(req *http.Request) {
 		req.URL.Scheme = "http"
-		req.URL.Host = addr
 		req.URL.Path = "/exec"
 		req.Body = io.NopCloser(bytes.NewReader(buf.Bytes()))
+		req.GetBody = func() (io.ReadCloser, error) {
+			return io.NopCloser(bytes.NewReader(buf.Bytes())), nil
+		}
 		req.ContentLength = int64(buf.Len())
 	}
 
-	gitserver.DefaultReverseProxy.ServeHTTP(repo.Name, "POST", "exec", director, w, r)
+	gitserver.DefaultReverseProxy.ServeHTTP(repo.Name, "POST", "exec", addrs, director, w, r)
 	return nil
 }
 
 // gitServiceHandler are handlers which redirect git clone requests to the
-// gitserver for the repo.
+// gitserver for the repo: its primary one, or the first of its replicas which
+// has cloned it.
 type gitServiceHandler struct {
 	Gitserver interface {
-		AddrForRepo(api.RepoName) string
+		ClonedAddrForRepo(context.Context, api.RepoName) string
 	}
 }
 
@@ -672,7 +675,7 @@ func (s *gitServiceHandler) redirectToGitServer(w http.ResponseWriter, r *http.R
 
 	u := &url.URL{
 		Scheme:   "http",
-		Host:     s.Gitserver.AddrForRepo(api.RepoName(repo)),
+		Host:     s.Gitserver.ClonedAddrForRepo(r.Context(), api.RepoName(repo)),
 		Path:     path.Join("/git", repo, gitPath),
 		RawQuery: r.URL.RawQuery,
 	}
@@ -26,7 +26,7 @@ func TestGitServiceHandlers(t *testing.T) {
 	m := apirouter.NewInternal(mux.NewRouter())
 
 	gitService := &gitServiceHandler{
-		Gitserver: mockAddrForRepo{},
+		Gitserver: mockClonedAddrForRepo{},
 	}
 	m.Get(apirouter.GitInfoRefs).Handler(http.HandlerFunc(gitService.serveInfoRefs))
 	m.Get(apirouter.GitUploadPack).Handler(http.HandlerFunc(gitService.serveGitUploadPack))
@@ -55,9 +55,9 @@ func TestGitServiceHandlers(t *testing.T) {
 	}
 }
 
-type mockAddrForRepo struct{}
+type mockClonedAddrForRepo struct{}
 
-func (mockAddrForRepo) AddrForRepo(name api.RepoName) string {
+func (mockClonedAddrForRepo) ClonedAddrForRepo(_ context.Context, name api.RepoName) string {
 	return strings.ReplaceAll(string(name), "/", ".") + ".gitserver"
 }
 
@@ -0,0 +1,31 @@
+package webhookhandlers
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/cockroachdb/errors"
+	gh "github.com/google/go-github/v28/github"
+	"github.com/inconshreveable/log15"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/webhooks"
+	"github.com/sourcegraph/sourcegraph/internal/database/dbutil"
+	"github.com/sourcegraph/sourcegraph/internal/types"
+)
+
+// handleGitHubRepoPushEvent handles github push events, and enqueues the pushed repo for
+// an update in repo-updater, so that new commits show up before its next scheduled update.
+func handleGitHubRepoPushEvent(db dbutil.DB) func(ctx context.Context, extSvc *types.ExternalService, payload interface{}) error {
+	return func(ctx context.Context, extSvc *types.ExternalService, payload interface{}) error {
+		log15.Debug("handleGitHubRepoPushEvent: Got github event", "type", fmt.Sprintf("%T", payload))
+
+		e, ok := payload.(*gh.PushEvent)
+		if !ok {
+			return errors.Errorf("incorrect event type sent to github push event handler: %T", payload)
+		}
+		if e.GetRepo().GetNodeID() == "" {
+			return nil
+		}
+		return webhooks.EnqueueRepoUpdate(ctx, db, extSvc, e.GetRepo().GetNodeID())
+	}
+}
@@ -10,6 +10,9 @@ func Init(db dbutil.DB, w *webhooks.GitHubWebhook) {
 	// Refer to https://docs.github.com/en/developers/webhooks-and-events/webhooks/webhook-events-and-payloads
 	// for event types
 
+	// Push events enqueue an update of the pushed repository
+	w.Register(handleGitHubRepoPushEvent(db), "push")
+
 	// Repository events
 	w.Register(handleGitHubRepoAuthzEvent(authz.FetchPermsOptions{}), "public")
 	w.Register(handleGitHubRepoAuthzEvent(authz.FetchPermsOptions{}), "repository")
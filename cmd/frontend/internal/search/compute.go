@@ -0,0 +1,216 @@
+package search
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/inconshreveable/log15"
+
+	"github.com/sourcegraph/sourcegraph/internal/compute"
+	computeclient "github.com/sourcegraph/sourcegraph/internal/compute/client"
+	"github.com/sourcegraph/sourcegraph/internal/database/dbutil"
+	"github.com/sourcegraph/sourcegraph/internal/search/result"
+	"github.com/sourcegraph/sourcegraph/internal/search/streaming"
+	streamhttp "github.com/sourcegraph/sourcegraph/internal/search/streaming/http"
+	"github.com/sourcegraph/sourcegraph/internal/trace"
+)
+
+// ComputeStreamHandler is an http handler which streams back compute results
+// as search results arrive. It uses the same event stream format as
+// StreamHandler, except that matches are sent as "results" events.
+func ComputeStreamHandler(db dbutil.DB) http.Handler {
+	return &computeStreamHandler{
+		search: &streamHandler{
+			db:                  db,
+			newSearchResolver:   defaultNewSearchResolver,
+			flushTickerInternal: 100 * time.Millisecond,
+			pingTickerInterval:  5 * time.Second,
+		},
+	}
+}
+
+type computeStreamHandler struct {
+	// search runs the search of the compute query.
+	search *streamHandler
+}
+
+func (h *computeStreamHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+
+	q := r.URL.Query().Get("q")
+	if q == "" {
+		http.Error(w, "no query found", http.StatusBadRequest)
+		return
+	}
+	computeQuery, err := compute.Parse(q)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	tr, ctx := trace.New(ctx, "compute.ServeStream", q,
+		trace.Tag{Key: "command", Value: computeQuery.Command.String()},
+	)
+	defer func() {
+		tr.SetError(err)
+		tr.Finish()
+	}()
+
+	eventWriter, err := streamhttp.NewWriter(w)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// Always send a final done event so clients know the stream is shutting
+	// down.
+	defer eventWriter.Event("done", map[string]interface{}{})
+
+	// Log events to trace
+	eventWriter.StatHook = eventStreamOTHook(tr.LogFields)
+
+	events, inputs, results := h.search.startSearch(ctx, &args{
+		Query:       computeQuery.SearchQuery,
+		Version:     "V2",
+		PatternType: "regexp",
+	})
+	events = batchEvents(events, 50*time.Millisecond)
+
+	progress := progressAggregator{
+		Start:        time.Now(),
+		Limit:        inputs.MaxResults(),
+		Trace:        trace.URL(trace.ID(ctx)),
+		DisplayLimit: inputs.MaxResults(),
+	}
+
+	sendProgress := func() {
+		_ = eventWriter.Event("progress", progress.Current())
+	}
+
+	// Store marshalled results and flush periodically or when we go over
+	// 32kb, like StreamHandler does for matches.
+	resultsBuf := streamhttp.NewJSONArrayBuf(32*1024, func(data []byte) error {
+		return eventWriter.EventBytes("results", data)
+	})
+	resultsFlush := func() {
+		if err := resultsBuf.Flush(); err != nil {
+			// EOF
+			return
+		}
+
+		if progress.Dirty {
+			sendProgress()
+		}
+	}
+	flushTicker := time.NewTicker(h.search.flushTickerInternal)
+	defer flushTicker.Stop()
+
+	pingTicker := time.NewTicker(h.search.pingTickerInterval)
+	defer pingTicker.Stop()
+
+	handleEvent := func(event streaming.SearchEvent) {
+		progress.Update(event)
+
+		repoMetadata, err := getEventRepoMetadata(ctx, h.search.db, event)
+		if err != nil {
+			log15.Error("failed to get repo metadata", "error", err)
+			return
+		}
+		for _, match := range event.Results {
+			fm, ok := match.(*result.FileMatch)
+			if !ok {
+				continue
+			}
+			// Don't compute results for matches which we cannot map to a
+			// repo the actor has access to. See StreamHandler.
+			if md, ok := repoMetadata[fm.Repo.ID]; !ok || md.Name != fm.Repo.Name {
+				continue
+			}
+			computeResult, err := compute.Run(ctx, computeQuery.Command, fm)
+			if err != nil {
+				log15.Warn("compute: failed to run command", "repo", fm.Repo.Name, "path", fm.Path, "error", err)
+				continue
+			}
+			if eventResult := fromComputeResult(fm, computeResult); eventResult != nil {
+				_ = resultsBuf.Append(eventResult)
+			}
+		}
+	}
+
+LOOP:
+	for {
+		select {
+		case event, ok := <-events:
+			if !ok {
+				break LOOP
+			}
+			handleEvent(event)
+		case <-flushTicker.C:
+			resultsFlush()
+		case <-pingTicker.C:
+			sendProgress()
+		}
+	}
+
+	resultsFlush()
+
+	resultsResolver, err := results()
+	if err != nil {
+		_ = eventWriter.Event("error", streamhttp.EventError{Message: err.Error()})
+		return
+	}
+
+	if alert := resultsResolver.Alert(); alert != nil {
+		var pqs []streamhttp.ProposedQuery
+		if proposed := alert.ProposedQueries(); proposed != nil {
+			for _, pq := range *proposed {
+				pqs = append(pqs, streamhttp.ProposedQuery{
+					Description: fromStrPtr(pq.Description()),
+					Query:       pq.Query(),
+				})
+			}
+		}
+		_ = eventWriter.Event("alert", streamhttp.EventAlert{
+			Title:           alert.Title(),
+			Description:     fromStrPtr(alert.Description()),
+			ProposedQueries: pqs,
+		})
+	}
+
+	_ = eventWriter.Event("progress", progress.Final())
+}
+
+// fromComputeResult returns the event for the result of running a compute
+// command on fm. It returns nil if there is nothing to send.
+func fromComputeResult(fm *result.FileMatch, r compute.Result) computeclient.EventResult {
+	switch v := r.(type) {
+	case *compute.MatchContext:
+		if len(v.Matches) == 0 {
+			return nil
+		}
+		return &computeclient.EventMatchContext{
+			Type:         computeclient.MatchContextType,
+			Repository:   string(fm.Repo.Name),
+			RepositoryID: int32(fm.Repo.ID),
+			Commit:       string(fm.CommitID),
+			Path:         fm.Path,
+			Matches:      v.Matches,
+		}
+	case *compute.Text:
+		if v == nil {
+			return nil
+		}
+		return &computeclient.EventText{
+			Type:         computeclient.TextType,
+			Repository:   string(fm.Repo.Name),
+			RepositoryID: int32(fm.Repo.ID),
+			Commit:       string(fm.CommitID),
+			Path:         fm.Path,
+			Kind:         v.Kind,
+			Value:        v.Value,
+		}
+	}
+	return nil
+}
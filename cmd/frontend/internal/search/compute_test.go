@@ -0,0 +1,102 @@
+package search
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/graphqlbackend"
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	computeclient "github.com/sourcegraph/sourcegraph/internal/compute/client"
+	"github.com/sourcegraph/sourcegraph/internal/database"
+	"github.com/sourcegraph/sourcegraph/internal/database/dbutil"
+	"github.com/sourcegraph/sourcegraph/internal/search/result"
+	"github.com/sourcegraph/sourcegraph/internal/search/streaming"
+	"github.com/sourcegraph/sourcegraph/internal/types"
+)
+
+func TestServeComputeStream(t *testing.T) {
+	mock := &mockSearchResolver{
+		done: make(chan struct{}),
+	}
+
+	database.Mocks.Repos.Metadata = func(ctx context.Context, ids ...api.RepoID) (_ []*types.SearchedRepo, err error) {
+		res := make([]*types.SearchedRepo, 0, len(ids))
+		for _, id := range ids {
+			res = append(res, &types.SearchedRepo{ID: id, Name: "repo"})
+		}
+		return res, nil
+	}
+	defer func() { database.Mocks.Repos.Metadata = nil }()
+
+	var gotSearchQuery string
+	ts := httptest.NewServer(&computeStreamHandler{
+		search: &streamHandler{
+			flushTickerInternal: 1 * time.Millisecond,
+			pingTickerInterval:  1 * time.Millisecond,
+			newSearchResolver: func(_ context.Context, _ dbutil.DB, args *graphqlbackend.SearchArgs) (searchResolver, error) {
+				gotSearchQuery = args.Query
+				mock.c = args.Stream
+				return mock, nil
+			},
+		},
+	})
+	defer ts.Close()
+
+	req, _ := computeclient.NewRequest(ts.URL, `repo:repo output(flag\("(\w+)"\) -> $1)`)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	var got []computeclient.EventResult
+	decoder := computeclient.ComputeStreamDecoder{
+		OnResults: func(results []computeclient.EventResult) {
+			got = append(got, results...)
+		},
+	}
+	g := errgroup.Group{}
+	g.Go(func() error {
+		return decoder.ReadAll(resp.Body)
+	})
+
+	mock.c.Send(streaming.SearchEvent{
+		Results: []result.Match{&result.FileMatch{
+			File: result.File{
+				Repo:     types.RepoName{ID: 1, Name: "repo"},
+				CommitID: "deadbeef",
+				Path:     "flags.go",
+			},
+			LineMatches: []*result.LineMatch{
+				{Preview: `if flag("a") && flag("b") {`, LineNumber: 3},
+			},
+		}},
+	})
+	mock.Close()
+	if err := g.Wait(); err != nil {
+		t.Fatal(err)
+	}
+
+	if want := `repo:repo content:"flag\\(\"(\\w+)\"\\)"`; gotSearchQuery != want {
+		t.Errorf("got search query %q, want %q", gotSearchQuery, want)
+	}
+
+	want := []computeclient.EventResult{&computeclient.EventText{
+		Type:         computeclient.TextType,
+		Repository:   "repo",
+		RepositoryID: 1,
+		Commit:       "deadbeef",
+		Path:         "flags.go",
+		Kind:         "output",
+		Value:        "a\nb",
+	}}
+	if d := cmp.Diff(want, got); d != "" {
+		t.Fatalf("mismatch (-want +got):\n%s", d)
+	}
+}
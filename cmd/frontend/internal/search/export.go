@@ -0,0 +1,605 @@
+package search
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cockroachdb/errors"
+	"github.com/inconshreveable/log15"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/globals"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/graphqlbackend"
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/database/dbutil"
+	"github.com/sourcegraph/sourcegraph/internal/search"
+	"github.com/sourcegraph/sourcegraph/internal/search/query"
+	"github.com/sourcegraph/sourcegraph/internal/search/result"
+	"github.com/sourcegraph/sourcegraph/internal/search/run"
+	"github.com/sourcegraph/sourcegraph/internal/trace"
+)
+
+// Headers and trailers of export responses.
+const (
+	// exportRepositoriesHeader is the number of repositories the export
+	// searches. Rows are sorted by repository, but some repositories may have
+	// no rows.
+	exportRepositoriesHeader = "X-Sourcegraph-Export-Repositories"
+
+	// exportRowsTrailer is the number of rows sent.
+	exportRowsTrailer = "X-Sourcegraph-Export-Rows"
+
+	// exportCompleteTrailer is "true" if all rows were sent. It is missing if
+	// the connection was interrupted.
+	exportCompleteTrailer = "X-Sourcegraph-Export-Complete"
+
+	// exportErrorTrailer is the error which ended the export early.
+	exportErrorTrailer = "X-Sourcegraph-Export-Error"
+)
+
+// ExportStreamHandler is an http handler which streams back every match of a
+// search as JSON lines or CSV rows, for use in scripts and spreadsheets.
+//
+// Unlike StreamHandler there is no display limit. Rows are sorted by
+// repository so that an interrupted export can be resumed: a client passes the
+// repository of the last row it received as the "from" parameter, and discards
+// the rows it received for that repository.
+func ExportStreamHandler(db dbutil.DB) http.Handler {
+	return &exportStreamHandler{
+		search: &streamHandler{
+			db:                  db,
+			newSearchResolver:   defaultNewSearchResolver,
+			flushTickerInternal: 100 * time.Millisecond,
+			pingTickerInterval:  5 * time.Second,
+		},
+		chunkSize: 25,
+	}
+}
+
+type exportStreamHandler struct {
+	// search runs the searches of the export.
+	search *streamHandler
+
+	// chunkSize is the number of repositories searched at a time. The rows
+	// of a chunk are buffered to sort them.
+	chunkSize int
+}
+
+func (h *exportStreamHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+
+	a, err := parseExportURLQuery(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	tr, ctx := trace.New(ctx, "search.ServeExport", a.Query,
+		trace.Tag{Key: "format", Value: a.Format},
+		trace.Tag{Key: "from", Value: a.From},
+	)
+	defer func() {
+		tr.SetError(err)
+		tr.Finish()
+	}()
+
+	plan, err := h.plan(ctx, &a.args)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	// Exports have no display limit, so unless the query sets its own limit
+	// we search for all matches.
+	if plan.ToParseTree().Count() == nil {
+		a.Query += " count:all"
+		if plan, err = h.plan(ctx, &a.args); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	// Searches stream their matches in no particular order. To send sorted
+	// rows, we first list the repositories the query searches, and then
+	// search them a chunk at a time.
+	repos, err := h.searchedRepos(ctx, a, plan)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if a.From != "" {
+		i := sort.Search(len(repos), func(i int) bool { return string(repos[i]) >= a.From })
+		repos = repos[i:]
+	}
+	tr.LazyPrintf("exporting %d repositories", len(repos))
+
+	rw := newExportRowWriter(a.Format, w)
+	w.Header().Set("Content-Type", rw.ContentType())
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set(exportRepositoriesHeader, strconv.Itoa(len(repos)))
+	w.Header().Set("Trailer", strings.Join([]string{exportRowsTrailer, exportCompleteTrailer, exportErrorTrailer}, ", "))
+	w.WriteHeader(http.StatusOK)
+
+	flusher, _ := w.(http.Flusher)
+
+	rowCount := 0
+	defer func() {
+		w.Header().Set(exportRowsTrailer, strconv.Itoa(rowCount))
+		if err != nil {
+			w.Header().Set(exportErrorTrailer, err.Error())
+		} else {
+			w.Header().Set(exportCompleteTrailer, "true")
+		}
+	}()
+
+	// A count: in the query limits each chunk search, so we also apply it to
+	// the rows of the whole export.
+	limit := -1
+	if count := plan.ToParseTree().Count(); count != nil {
+		limit = *count
+	}
+
+	if err = rw.WriteHeader(); err != nil {
+		return
+	}
+	for len(repos) > 0 && limit != 0 {
+		chunk := repos
+		if len(chunk) > h.chunkSize {
+			chunk = chunk[:h.chunkSize]
+		}
+		repos = repos[len(chunk):]
+
+		var rows []*exportRow
+		rows, err = h.searchRows(ctx, a, chunkQuery(plan, chunk))
+		if err != nil {
+			log15.Warn("search export: failed to search repositories", "query", a.Query, "error", err)
+			return
+		}
+		sortExportRows(rows)
+		if limit >= 0 && len(rows) > limit {
+			rows = rows[:limit]
+		}
+		for _, row := range rows {
+			if err = rw.Write(row); err != nil {
+				// EOF
+				return
+			}
+		}
+		if err = rw.Flush(); err != nil {
+			return
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+		rowCount += len(rows)
+		if limit >= 0 {
+			limit -= len(rows)
+		}
+	}
+}
+
+// plan returns the plan of the query of a, without running it.
+func (h *exportStreamHandler) plan(ctx context.Context, a *args) (query.Plan, error) {
+	search, err := h.search.newSearchResolver(ctx, h.search.db, &graphqlbackend.SearchArgs{
+		Query:          a.Query,
+		Version:        a.Version,
+		PatternType:    strPtr(a.PatternType),
+		VersionContext: strPtr(a.VersionContext),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return search.Inputs().Plan, nil
+}
+
+// searchedRepos returns the sorted names of the repositories which plan
+// searches. It only runs the repository filters of plan, so it's much cheaper
+// than finding the repositories with matches.
+func (h *exportStreamHandler) searchedRepos(ctx context.Context, a *exportArgs, plan query.Plan) ([]api.RepoName, error) {
+	set := map[api.RepoName]struct{}{}
+	_, err := h.search.searchAll(ctx, &args{
+		Query:          repoQuery(plan),
+		Version:        a.Version,
+		PatternType:    a.PatternType,
+		VersionContext: a.VersionContext,
+	}, func(matches []result.Match) {
+		for _, match := range matches {
+			set[match.RepoName().Name] = struct{}{}
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	repos := make([]api.RepoName, 0, len(set))
+	for name := range set {
+		repos = append(repos, name)
+	}
+	sort.Slice(repos, func(i, j int) bool { return repos[i] < repos[j] })
+	return repos, nil
+}
+
+// repoFields are the fields which select the repositories a query searches.
+var repoFields = map[string]struct{}{
+	query.FieldRepo:               {},
+	query.FieldRepoGroup:          {},
+	query.FieldContext:            {},
+	query.FieldFork:               {},
+	query.FieldArchived:           {},
+	query.FieldVisibility:         {},
+	query.FieldRepoHasFile:        {},
+	query.FieldRepoHasCommitAfter: {},
+	query.FieldRepoHasKVP:         {},
+	query.FieldTimeout:            {},
+}
+
+// repoQuery returns a query for all the repositories searched by plan.
+func repoQuery(plan query.Plan) string {
+	basics := make([]string, 0, len(plan))
+	for _, b := range plan {
+		parameters := []query.Parameter{
+			{Field: query.FieldType, Value: "repo"},
+			{Field: query.FieldCount, Value: "all"},
+		}
+		for _, p := range b.Parameters {
+			if _, ok := repoFields[p.Field]; ok {
+				parameters = append(parameters, p)
+			}
+		}
+		basics = append(basics, query.StringHuman(query.Basic{Parameters: parameters}.ToParseTree()))
+	}
+	if len(basics) == 1 {
+		return basics[0]
+	}
+	return "(" + strings.Join(basics, ") or (") + ")"
+}
+
+// searchRows returns the rows for all the matches of q.
+func (h *exportStreamHandler) searchRows(ctx context.Context, a *exportArgs, q string) ([]*exportRow, error) {
+	var rows []*exportRow
+	_, err := h.search.searchAll(ctx, &args{
+		Query:          q,
+		Version:        a.Version,
+		PatternType:    a.PatternType,
+		VersionContext: a.VersionContext,
+	}, func(matches []result.Match) {
+		for _, match := range matches {
+			rows = append(rows, exportRows(match)...)
+		}
+	})
+	return rows, err
+}
+
+// searchAll runs the search described by a to completion, calling onMatches
+// with the matches in repositories the actor has access to.
+func (h *streamHandler) searchAll(ctx context.Context, a *args, onMatches func([]result.Match)) (run.SearchInputs, error) {
+	events, inputs, results := h.startSearch(ctx, a)
+	events = batchEvents(events, 50*time.Millisecond)
+
+	var metadataErr error
+	for event := range events {
+		if metadataErr != nil {
+			// Drain the events so the search can finish.
+			continue
+		}
+		repoMetadata, err := getEventRepoMetadata(ctx, h.db, event)
+		if err != nil {
+			metadataErr = err
+			continue
+		}
+		matches := event.Results[:0]
+		for _, match := range event.Results {
+			// Skip matches which we cannot map to a repo the actor has
+			// access to. See StreamHandler.
+			if md, ok := repoMetadata[match.RepoName().ID]; !ok || md.Name != match.RepoName().Name {
+				continue
+			}
+			matches = append(matches, match)
+		}
+		onMatches(matches)
+	}
+
+	resultsResolver, err := results()
+	if err != nil {
+		return inputs, err
+	}
+	if metadataErr != nil {
+		return inputs, metadataErr
+	}
+	if alert := resultsResolver.Alert(); alert != nil && len(inputs.Plan) == 0 {
+		// The query could not be run, for example because it is invalid.
+		return inputs, errors.New(strings.TrimSpace(alert.Title() + ". " + fromStrPtr(alert.Description())))
+	}
+	return inputs, nil
+}
+
+// chunkQuery returns a query which runs plan only in repos. The repository
+// filters of plan are kept so that the same revisions are searched.
+func chunkQuery(plan query.Plan, repos []api.RepoName) string {
+	basics := make([]string, 0, len(plan))
+	for _, b := range plan {
+		// Repositories that match a repo: filter with revisions need to be
+		// searched at the same revisions, otherwise they clash.
+		byRevs := map[string][]string{}
+		var revs []string
+		for _, name := range repos {
+			rev := repoFilterRevs(b, name)
+			if _, ok := byRevs[rev]; !ok {
+				revs = append(revs, rev)
+			}
+			byRevs[rev] = append(byRevs[rev], regexp.QuoteMeta(string(name)))
+		}
+
+		for _, rev := range revs {
+			value := "^(?:" + strings.Join(byRevs[rev], "|") + ")$"
+			if rev != "" {
+				value += "@" + rev
+			}
+			parameters := append([]query.Parameter{{Field: query.FieldRepo, Value: value}}, b.Parameters...)
+			q := query.StringHuman(query.Basic{Parameters: parameters, Pattern: b.Pattern}.ToParseTree())
+			basics = append(basics, q)
+		}
+	}
+	if len(basics) == 1 {
+		return basics[0]
+	}
+	return "(" + strings.Join(basics, ") or (") + ")"
+}
+
+// repoFilterRevs returns the revisions of the first repo: filter of b that
+// matches name, or "" if there are none.
+func repoFilterRevs(b query.Basic, name api.RepoName) string {
+	var revs string
+	query.VisitParameter(b.ToParseTree(), func(field, value string, negated bool, ann query.Annotation) {
+		if revs != "" || field != query.FieldRepo || negated || ann.Labels.IsSet(query.IsPredicate) {
+			return
+		}
+		i := strings.LastIndex(value, "@")
+		if i < 0 {
+			return
+		}
+		repoPattern, _ := search.ParseRepositoryRevisions(value)
+		re, err := regexp.Compile("(?i:" + repoPattern + ")")
+		if err != nil || !re.MatchString(string(name)) {
+			return
+		}
+		revs = value[i+1:]
+	})
+	return revs
+}
+
+// exportRow is a row of an export. Depending on the type of the match, some
+// fields are empty.
+type exportRow struct {
+	Type       string `json:"type"`
+	Repository string `json:"repository"`
+	Revision   string `json:"revision,omitempty"`
+	Commit     string `json:"commit,omitempty"`
+	Path       string `json:"path,omitempty"`
+
+	// Line is the 1-based line number of the match, or 0 if the match is not
+	// on a line.
+	Line    int    `json:"line,omitempty"`
+	Preview string `json:"preview,omitempty"`
+
+	SymbolName      string `json:"symbolName,omitempty"`
+	SymbolKind      string `json:"symbolKind,omitempty"`
+	SymbolContainer string `json:"symbolContainer,omitempty"`
+
+	Author  string `json:"author,omitempty"`
+	Date    string `json:"date,omitempty"`
+	Message string `json:"message,omitempty"`
+
+	URL string `json:"url"`
+}
+
+// exportColumns are the CSV columns of an export, in the same order as the
+// values returned by exportRow.csv.
+var exportColumns = []string{
+	"type", "repository", "revision", "commit", "path", "line", "preview",
+	"symbol_name", "symbol_kind", "symbol_container",
+	"author", "date", "message",
+	"url",
+}
+
+func (r *exportRow) csv() []string {
+	line := ""
+	if r.Line > 0 {
+		line = strconv.Itoa(r.Line)
+	}
+	return []string{
+		r.Type, r.Repository, r.Revision, r.Commit, r.Path, line, r.Preview,
+		r.SymbolName, r.SymbolKind, r.SymbolContainer,
+		r.Author, r.Date, r.Message,
+		r.URL,
+	}
+}
+
+// exportRows returns the rows for a match: one per matched line or symbol of
+// a file, and one for other matches.
+func exportRows(match result.Match) []*exportRow {
+	switch m := match.(type) {
+	case *result.FileMatch:
+		file := exportRow{
+			Repository: string(m.Repo.Name),
+			Commit:     string(m.CommitID),
+			Path:       m.Path,
+		}
+		if m.InputRev != nil {
+			file.Revision = *m.InputRev
+		}
+
+		var rows []*exportRow
+		for _, sym := range m.Symbols {
+			row := file
+			row.Type = "symbol"
+			row.Line = sym.Symbol.Line
+			row.SymbolName = sym.Symbol.Name
+			row.SymbolKind = sym.Symbol.Kind
+			row.SymbolContainer = sym.Symbol.Parent
+			row.URL = absoluteURL(sym.URL())
+			rows = append(rows, &row)
+		}
+		for _, lm := range m.LineMatches {
+			row := file
+			row.Type = "content"
+			row.Line = int(lm.LineNumber) + 1
+			row.Preview = lm.Preview
+			u := m.URL()
+			u.RawQuery = "L" + strconv.Itoa(row.Line)
+			row.URL = absoluteURL(u)
+			rows = append(rows, &row)
+		}
+		if len(rows) == 0 {
+			file.Type = "path"
+			file.URL = absoluteURL(m.URL())
+			rows = append(rows, &file)
+		}
+		return rows
+
+	case *result.RepoMatch:
+		return []*exportRow{{
+			Type:       "repo",
+			Repository: string(m.Name),
+			Revision:   m.Rev,
+			URL:        absoluteURL(m.URL()),
+		}}
+
+	case *result.CommitMatch:
+		row := &exportRow{
+			Type:       "commit",
+			Repository: string(m.Repo.Name),
+			Commit:     string(m.Commit.ID),
+			Author:     fmt.Sprintf("%s <%s>", m.Commit.Author.Name, m.Commit.Author.Email),
+			Date:       m.Commit.Author.Date.UTC().Format(time.RFC3339),
+			Message:    string(m.Commit.Message),
+			URL:        absoluteURL(m.URL()),
+		}
+		if m.DiffPreview != nil {
+			row.Preview = m.DiffPreview.Value
+		} else if m.MessagePreview != nil {
+			row.Preview = m.MessagePreview.Value
+		}
+		return []*exportRow{row}
+	}
+	return nil
+}
+
+func absoluteURL(u *url.URL) string {
+	return globals.ExternalURL().ResolveReference(u).String()
+}
+
+// sortExportRows sorts rows by repository, and then by their position in the
+// repository, so that exports are stable.
+func sortExportRows(rows []*exportRow) {
+	sort.SliceStable(rows, func(i, j int) bool {
+		a, b := rows[i], rows[j]
+		switch {
+		case a.Repository != b.Repository:
+			return a.Repository < b.Repository
+		case a.Revision != b.Revision:
+			return a.Revision < b.Revision
+		case a.Type != b.Type:
+			return a.Type < b.Type
+		case a.Path != b.Path:
+			return a.Path < b.Path
+		case a.Line != b.Line:
+			return a.Line < b.Line
+		case a.Date != b.Date:
+			return a.Date > b.Date
+		}
+		return a.Commit < b.Commit
+	})
+}
+
+// exportRowWriter writes the rows of an export in a format.
+type exportRowWriter interface {
+	ContentType() string
+	WriteHeader() error
+	Write(*exportRow) error
+	Flush() error
+}
+
+func newExportRowWriter(format string, w io.Writer) exportRowWriter {
+	if format == "csv" {
+		return &csvExportRowWriter{w: csv.NewWriter(w)}
+	}
+	return &jsonlExportRowWriter{enc: json.NewEncoder(w)}
+}
+
+// jsonlExportRowWriter writes each row as a JSON object on its own line.
+type jsonlExportRowWriter struct {
+	enc *json.Encoder
+}
+
+func (w *jsonlExportRowWriter) ContentType() string        { return "application/x-ndjson" }
+func (w *jsonlExportRowWriter) WriteHeader() error         { return nil }
+func (w *jsonlExportRowWriter) Write(row *exportRow) error { return w.enc.Encode(row) }
+func (w *jsonlExportRowWriter) Flush() error               { return nil }
+
+// csvExportRowWriter writes a header with exportColumns and then a CSV record
+// for each row.
+type csvExportRowWriter struct {
+	w *csv.Writer
+}
+
+func (w *csvExportRowWriter) ContentType() string { return "text/csv; charset=utf-8" }
+func (w *csvExportRowWriter) WriteHeader() error  { return w.w.Write(exportColumns) }
+func (w *csvExportRowWriter) Write(row *exportRow) error {
+	return w.w.Write(row.csv())
+}
+
+func (w *csvExportRowWriter) Flush() error {
+	w.w.Flush()
+	return w.w.Error()
+}
+
+type exportArgs struct {
+	args
+
+	// Format is the format of the rows, "jsonl" or "csv".
+	Format string
+
+	// From is the repository to resume an export from. Rows for
+	// repositories sorting before it are skipped.
+	From string
+}
+
+func parseExportURLQuery(q url.Values) (*exportArgs, error) {
+	get := func(k, def string) string {
+		v := q.Get(k)
+		if v == "" {
+			return def
+		}
+		return v
+	}
+
+	a := exportArgs{
+		args: args{
+			Query:          get("q", ""),
+			Version:        get("v", "V2"),
+			PatternType:    get("t", ""),
+			VersionContext: get("vc", ""),
+		},
+		Format: get("format", "jsonl"),
+		From:   get("from", ""),
+	}
+
+	if a.Query == "" {
+		return nil, errors.New("no query found")
+	}
+	if a.Format != "jsonl" && a.Format != "csv" {
+		return nil, errors.Errorf("format must be jsonl or csv, got %q", a.Format)
+	}
+
+	return &a, nil
+}
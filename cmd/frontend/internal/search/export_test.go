@@ -0,0 +1,279 @@
+package search
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/graphqlbackend"
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/database"
+	"github.com/sourcegraph/sourcegraph/internal/database/dbutil"
+	"github.com/sourcegraph/sourcegraph/internal/search/query"
+	"github.com/sourcegraph/sourcegraph/internal/search/result"
+	"github.com/sourcegraph/sourcegraph/internal/search/run"
+	"github.com/sourcegraph/sourcegraph/internal/search/streaming"
+	"github.com/sourcegraph/sourcegraph/internal/types"
+	"github.com/sourcegraph/sourcegraph/schema"
+)
+
+// exportSearchResolver sends all matches, or only those of the repositories
+// named in the query when it searches a chunk of repositories. For repository
+// queries, it sends the repositories with matches.
+type exportSearchResolver struct {
+	query   string
+	stream  streaming.Sender
+	matches map[api.RepoName][]result.Match
+}
+
+func (r *exportSearchResolver) Results(ctx context.Context) (*graphqlbackend.SearchResultsResolver, error) {
+	for name, matches := range r.matches {
+		if strings.HasPrefix(r.query, "type:repo ") {
+			r.stream.Send(streaming.SearchEvent{Results: []result.Match{&result.RepoMatch{Name: name, ID: matches[0].RepoName().ID}}})
+		} else if !strings.Contains(r.query, "^(?:") || strings.Contains(r.query, string(name)) {
+			r.stream.Send(streaming.SearchEvent{Results: matches})
+		}
+	}
+	return &graphqlbackend.SearchResultsResolver{
+		UserSettings:  &schema.Settings{},
+		SearchResults: &graphqlbackend.SearchResults{},
+	}, nil
+}
+
+func (r *exportSearchResolver) Inputs() run.SearchInputs {
+	plan, _ := query.Pipeline(query.InitLiteral(r.query))
+	return run.SearchInputs{Plan: plan}
+}
+
+func TestServeExport(t *testing.T) {
+	database.Mocks.Repos.Metadata = func(ctx context.Context, ids ...api.RepoID) (_ []*types.SearchedRepo, err error) {
+		res := make([]*types.SearchedRepo, 0, len(ids))
+		for _, id := range ids {
+			res = append(res, &types.SearchedRepo{ID: id, Name: api.RepoName("foo/" + string(rune('a'+id-1)))})
+		}
+		return res, nil
+	}
+	defer func() { database.Mocks.Repos.Metadata = nil }()
+
+	fileMatch := func(id api.RepoID, path string, lines ...int32) *result.FileMatch {
+		fm := &result.FileMatch{File: result.File{
+			Repo:     types.RepoName{ID: id, Name: api.RepoName("foo/" + string(rune('a'+id-1)))},
+			CommitID: "deadbeef",
+			Path:     path,
+		}}
+		for _, line := range lines {
+			fm.LineMatches = append(fm.LineMatches, &result.LineMatch{Preview: "bar", LineNumber: line})
+		}
+		return fm
+	}
+	matches := map[api.RepoName][]result.Match{
+		"foo/a": {fileMatch(1, "b.go", 9, 2), fileMatch(1, "a.go", 4)},
+		"foo/b": {fileMatch(2, "README.md")},
+		"foo/c": {fileMatch(3, "c.go", 0)},
+	}
+
+	// queries are the queries of the searches, which stream their matches.
+	var queries []string
+	ts := httptest.NewServer(&exportStreamHandler{
+		search: &streamHandler{
+			flushTickerInternal: 1 * time.Millisecond,
+			pingTickerInterval:  1 * time.Millisecond,
+			newSearchResolver: func(_ context.Context, _ dbutil.DB, args *graphqlbackend.SearchArgs) (searchResolver, error) {
+				if args.Stream != nil {
+					queries = append(queries, args.Query)
+				}
+				return &exportSearchResolver{query: args.Query, stream: args.Stream, matches: matches}, nil
+			},
+		},
+		chunkSize: 2,
+	})
+	defer ts.Close()
+
+	get := func(t *testing.T, params string) (*http.Response, string) {
+		t.Helper()
+		resp, err := http.Get(ts.URL + "?q=repo:foo+bar&" + params)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		b, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp, string(b)
+	}
+
+	t.Run("csv", func(t *testing.T) {
+		queries = nil
+		resp, body := get(t, "format=csv")
+
+		want := `type,repository,revision,commit,path,line,preview,symbol_name,symbol_kind,symbol_container,author,date,message,url
+content,foo/a,,deadbeef,a.go,5,bar,,,,,,,http://example.com/foo/a/-/blob/a.go?L5
+content,foo/a,,deadbeef,b.go,3,bar,,,,,,,http://example.com/foo/a/-/blob/b.go?L3
+content,foo/a,,deadbeef,b.go,10,bar,,,,,,,http://example.com/foo/a/-/blob/b.go?L10
+path,foo/b,,deadbeef,README.md,,,,,,,,,http://example.com/foo/b/-/blob/README.md
+content,foo/c,,deadbeef,c.go,1,bar,,,,,,,http://example.com/foo/c/-/blob/c.go?L1
+`
+		if d := cmp.Diff(want, body); d != "" {
+			t.Errorf("mismatch (-want +got):\n%s", d)
+		}
+		if got := resp.Header.Get(exportRepositoriesHeader); got != "3" {
+			t.Errorf("got %s repositories, want 3", got)
+		}
+		if got := resp.Trailer.Get(exportRowsTrailer); got != "5" {
+			t.Errorf("got %s rows, want 5", got)
+		}
+		if got := resp.Trailer.Get(exportCompleteTrailer); got != "true" {
+			t.Errorf("got complete trailer %q, want true", got)
+		}
+
+		wantQueries := []string{
+			"type:repo count:all repo:foo",
+			`repo:^(?:foo/a|foo/b)$ repo:foo count:99999999 bar`,
+			`repo:^(?:foo/c)$ repo:foo count:99999999 bar`,
+		}
+		if d := cmp.Diff(wantQueries, queries); d != "" {
+			t.Errorf("queries mismatch (-want +got):\n%s", d)
+		}
+	})
+
+	t.Run("jsonl from", func(t *testing.T) {
+		resp, body := get(t, "from=foo/b")
+
+		want := `{"type":"path","repository":"foo/b","commit":"deadbeef","path":"README.md","url":"http://example.com/foo/b/-/blob/README.md"}
+{"type":"content","repository":"foo/c","commit":"deadbeef","path":"c.go","line":1,"preview":"bar","url":"http://example.com/foo/c/-/blob/c.go?L1"}
+`
+		if d := cmp.Diff(want, body); d != "" {
+			t.Errorf("mismatch (-want +got):\n%s", d)
+		}
+		if got := resp.Header.Get(exportRepositoriesHeader); got != "2" {
+			t.Errorf("got %s repositories, want 2", got)
+		}
+	})
+
+	t.Run("count", func(t *testing.T) {
+		queries = nil
+		resp, err := http.Get(ts.URL + "?format=csv&q=repo:foo+bar+count:3")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		b, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// The searches of the chunks are limited by count: too, but the
+		// search resolver above ignores it.
+		want := `type,repository,revision,commit,path,line,preview,symbol_name,symbol_kind,symbol_container,author,date,message,url
+content,foo/a,,deadbeef,a.go,5,bar,,,,,,,http://example.com/foo/a/-/blob/a.go?L5
+content,foo/a,,deadbeef,b.go,3,bar,,,,,,,http://example.com/foo/a/-/blob/b.go?L3
+content,foo/a,,deadbeef,b.go,10,bar,,,,,,,http://example.com/foo/a/-/blob/b.go?L10
+`
+		if d := cmp.Diff(want, string(b)); d != "" {
+			t.Errorf("mismatch (-want +got):\n%s", d)
+		}
+		if got := resp.Trailer.Get(exportRowsTrailer); got != "3" {
+			t.Errorf("got %s rows, want 3", got)
+		}
+		if len(queries) != 2 {
+			t.Errorf("got %d searches, want 2: %q", len(queries), queries)
+		}
+	})
+
+	t.Run("count in pattern", func(t *testing.T) {
+		queries = nil
+		resp, err := http.Get(ts.URL + "?q=repo:foo+content:%22count:3%22")
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+
+		// count:3 is part of the pattern, so the export is not limited.
+		if got := resp.Trailer.Get(exportRowsTrailer); got != "5" {
+			t.Errorf("got %s rows, want 5", got)
+		}
+		if len(queries) != 3 || !strings.Contains(queries[1], "count:99999999") {
+			t.Errorf("expected chunk searches for all matches, got %q", queries)
+		}
+	})
+
+	t.Run("invalid format", func(t *testing.T) {
+		resp, _ := get(t, "format=xml")
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("got status %d, want %d", resp.StatusCode, http.StatusBadRequest)
+		}
+	})
+}
+
+func TestChunkQuery(t *testing.T) {
+	cases := []struct {
+		query string
+		repos []api.RepoName
+		want  string
+	}{{
+		query: "repo:foo bar",
+		repos: []api.RepoName{"github.com/foo/a", "github.com/foo/b.c"},
+		want:  `repo:^(?:github\.com/foo/a|github\.com/foo/b\.c)$ repo:foo bar`,
+	}, {
+		query: "repo:foo@main repo:bar bar",
+		repos: []api.RepoName{"github.com/foo/bar", "github.com/baz/bar"},
+		want:  `(repo:^(?:github\.com/foo/bar)$@main repo:foo@main repo:bar bar) or (repo:^(?:github\.com/baz/bar)$ repo:foo@main repo:bar bar)`,
+	}, {
+		query: "bar or baz",
+		repos: []api.RepoName{"a"},
+		want:  `(repo:^(?:a)$ bar) or (repo:^(?:a)$ baz)`,
+	}}
+	for _, tc := range cases {
+		t.Run(tc.query, func(t *testing.T) {
+			plan, err := query.Pipeline(query.InitLiteral(tc.query))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := chunkQuery(plan, tc.repos); got != tc.want {
+				t.Errorf("got %s, want %s", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestExportRows(t *testing.T) {
+	rev := "main"
+	fm := &result.FileMatch{
+		File: result.File{
+			Repo:     types.RepoName{ID: 1, Name: "foo"},
+			CommitID: "deadbeef",
+			InputRev: &rev,
+			Path:     "main.go",
+		},
+		Symbols: []*result.SymbolMatch{{
+			Symbol: result.Symbol{Name: "main", Kind: "function", Line: 3},
+		}},
+	}
+	fm.Symbols[0].File = &fm.File
+
+	got := exportRows(fm)
+	want := []*exportRow{{
+		Type:       "symbol",
+		Repository: "foo",
+		Revision:   "main",
+		Commit:     "deadbeef",
+		Path:       "main.go",
+		Line:       3,
+		SymbolName: "main",
+		SymbolKind: "function",
+		URL:        got[0].URL,
+	}}
+	if d := cmp.Diff(want, got); d != "" {
+		t.Errorf("mismatch (-want +got):\n%s", d)
+	}
+	if !strings.HasSuffix(got[0].URL, "/foo@main/-/blob/main.go#L3:1-3:5") {
+		t.Errorf("unexpected symbol URL %s", got[0].URL)
+	}
+}
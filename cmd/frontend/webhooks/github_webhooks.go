@@ -144,7 +144,7 @@ func (h *GitHubWebhook) getExternalService(r *http.Request, body []byte) (*types
 			return e, nil
 		}
 	}
-	return e, nil
+	return nil, errors.Errorf("couldn't validate webhook signature for external service: %v", externalServiceID)
 }
 
 // findExternalService is the slow path for validating an incoming webhook against a configured
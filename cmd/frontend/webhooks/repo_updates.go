@@ -0,0 +1,83 @@
+package webhooks
+
+import (
+	"context"
+	"net/url"
+
+	"github.com/cockroachdb/errors"
+	"github.com/inconshreveable/log15"
+
+	"github.com/sourcegraph/sourcegraph/internal/actor"
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/database"
+	"github.com/sourcegraph/sourcegraph/internal/database/dbutil"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc"
+	"github.com/sourcegraph/sourcegraph/internal/repoupdater"
+	"github.com/sourcegraph/sourcegraph/internal/types"
+	"github.com/sourcegraph/sourcegraph/schema"
+)
+
+// EnqueueRepoUpdate asks repo-updater to update the repository with the given
+// external ID on the code host of extSvc as soon as possible. It is called
+// when a code host sends a push webhook, so that new commits show up without
+// waiting for the repository's next scheduled update.
+//
+// Pushes to repositories which are not synced by Sourcegraph are ignored.
+func EnqueueRepoUpdate(ctx context.Context, db dbutil.DB, extSvc *types.ExternalService, externalRepoID string) error {
+	serviceID, err := codeHostURL(extSvc)
+	if err != nil {
+		return err
+	}
+
+	// 🚨 SECURITY: we want to be able to find any private repo here, so set internal actor
+	ctx = actor.WithInternalActor(ctx)
+	repos, err := database.Repos(db).List(ctx, database.ReposListOptions{
+		ExternalRepos: []api.ExternalRepoSpec{{
+			ID:          externalRepoID,
+			ServiceType: extsvc.KindToType(extSvc.Kind),
+			ServiceID:   serviceID,
+		}},
+	})
+	if err != nil {
+		return errors.Wrap(err, "listing repos")
+	}
+	if len(repos) == 0 {
+		log15.Debug("EnqueueRepoUpdate: push webhook for unknown repo", "externalServiceID", extSvc.ID, "externalRepoID", externalRepoID)
+		return nil
+	}
+
+	for _, repo := range repos {
+		log15.Debug("EnqueueRepoUpdate: enqueuing update after push webhook", "repo", repo.Name)
+		if _, err := repoupdater.DefaultClient.EnqueueRepoUpdate(ctx, repo.Name); err != nil {
+			return errors.Wrapf(err, "enqueuing update of %s", repo.Name)
+		}
+	}
+	return nil
+}
+
+// codeHostURL returns the normalized URL of the code host of extSvc, which is
+// the ServiceID of the external repo specs of its repositories.
+func codeHostURL(extSvc *types.ExternalService) (string, error) {
+	c, err := extSvc.Configuration()
+	if err != nil {
+		return "", errors.Wrap(err, "getting external service configuration")
+	}
+
+	var rawURL string
+	switch c := c.(type) {
+	case *schema.GitHubConnection:
+		rawURL = c.Url
+	case *schema.GitLabConnection:
+		rawURL = c.Url
+	case *schema.BitbucketServerConnection:
+		rawURL = c.Url
+	default:
+		return "", errors.Errorf("push webhooks are not supported for external services of kind %s", extSvc.Kind)
+	}
+
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", errors.Wrap(err, "parsing code host URL")
+	}
+	return extsvc.NormalizeBaseURL(u).String(), nil
+}
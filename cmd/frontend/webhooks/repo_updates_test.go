@@ -0,0 +1,63 @@
+package webhooks
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/database"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc"
+	"github.com/sourcegraph/sourcegraph/internal/repoupdater"
+	"github.com/sourcegraph/sourcegraph/internal/repoupdater/protocol"
+	"github.com/sourcegraph/sourcegraph/internal/types"
+	"github.com/sourcegraph/sourcegraph/schema"
+)
+
+func TestEnqueueRepoUpdate(t *testing.T) {
+	var gotOpts []database.ReposListOptions
+	database.Mocks.Repos.List = func(ctx context.Context, opt database.ReposListOptions) ([]*types.Repo, error) {
+		gotOpts = append(gotOpts, opt)
+		if opt.ExternalRepos[0].ID != "MDEwOlJlcG9zaXRvcnkxMzk2NjYwMA==" {
+			return nil, nil
+		}
+		return []*types.Repo{{ID: 1, Name: "github.example.com/sourcegraph/sourcegraph"}}, nil
+	}
+	var enqueued []api.RepoName
+	repoupdater.MockEnqueueRepoUpdate = func(ctx context.Context, repo api.RepoName) (*protocol.RepoUpdateResponse, error) {
+		enqueued = append(enqueued, repo)
+		return &protocol.RepoUpdateResponse{Name: string(repo)}, nil
+	}
+	t.Cleanup(func() {
+		database.Mocks.Repos.List = nil
+		repoupdater.MockEnqueueRepoUpdate = nil
+	})
+
+	extSvc := &types.ExternalService{
+		ID:     1,
+		Kind:   extsvc.KindGitHub,
+		Config: marshalJSON(t, &schema.GitHubConnection{Url: "https://github.example.com"}),
+	}
+
+	ctx := context.Background()
+	if err := EnqueueRepoUpdate(ctx, nil, extSvc, "MDEwOlJlcG9zaXRvcnkxMzk2NjYwMA=="); err != nil {
+		t.Fatal(err)
+	}
+	// Pushes to repositories we don't know about are ignored.
+	if err := EnqueueRepoUpdate(ctx, nil, extSvc, "unknown"); err != nil {
+		t.Fatal(err)
+	}
+
+	wantSpec := api.ExternalRepoSpec{
+		ID:          "MDEwOlJlcG9zaXRvcnkxMzk2NjYwMA==",
+		ServiceType: extsvc.TypeGitHub,
+		ServiceID:   "https://github.example.com/",
+	}
+	if d := cmp.Diff([]api.ExternalRepoSpec{wantSpec}, gotOpts[0].ExternalRepos); d != "" {
+		t.Errorf("external repos mismatch (-want +got):\n%s", d)
+	}
+	if d := cmp.Diff([]api.RepoName{"github.example.com/sourcegraph/sourcegraph"}, enqueued); d != "" {
+		t.Errorf("enqueued repos mismatch (-want +got):\n%s", d)
+	}
+}
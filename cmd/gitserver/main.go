@@ -174,8 +174,48 @@ func main() {
 				}
 
 				return &server.JVMPackagesSyncer{Config: &c, DBStore: codeintelDB}, nil
+			case extsvc.TypeNPMPackages:
+				var c schema.NPMPackagesConnection
+				for _, info := range r.Sources {
+					es, err := externalServiceStore.GetByID(ctx, info.ExternalServiceID())
+					if err != nil {
+						return nil, errors.Wrap(err, "get external service")
+					}
+
+					normalized, err := jsonc.Parse(es.Config)
+					if err != nil {
+						return nil, errors.Wrap(err, "normalize JSON")
+					}
+
+					if err = jsoniter.Unmarshal(normalized, &c); err != nil {
+						return nil, errors.Wrap(err, "unmarshal JSON")
+					}
+					break
+				}
+
+				return server.NewNPMPackagesSyncer(&c, nil), nil
+			case extsvc.TypeGoModules:
+				var c schema.GoModulesConnection
+				for _, info := range r.Sources {
+					es, err := externalServiceStore.GetByID(ctx, info.ExternalServiceID())
+					if err != nil {
+						return nil, errors.Wrap(err, "get external service")
+					}
+
+					normalized, err := jsonc.Parse(es.Config)
+					if err != nil {
+						return nil, errors.Wrap(err, "normalize JSON")
+					}
+
+					if err = jsoniter.Unmarshal(normalized, &c); err != nil {
+						return nil, errors.Wrap(err, "unmarshal JSON")
+					}
+					break
+				}
+
+				return server.NewGoModulesSyncer(&c, nil)
 			}
-			return &server.GitRepoSyncer{}, nil
+			return &server.GitRepoSyncer{PartialCloneFilter: server.PartialCloneFilter(repo)}, nil
 		},
 		Hostname:   hostname.Get(),
 		DB:         db,
@@ -81,8 +81,9 @@ const reposStatsName = "repos-stats.json"
 // 4. Ensure correct git attributes
 // 5. Scrub remote URLs
 // 6. Perform garbage collection
-// 7. Re-clone repos after a while. (simulate git gc)
-// 8. Remove repos based on disk pressure.
+// 7. Run git maintenance tasks, see maintainRepo.
+// 8. Re-clone repos after a while. (simulate git gc)
+// 9. Remove repos based on disk pressure.
 func (s *Server) cleanupRepos() {
 	janitorRunning.Set(1)
 	defer janitorRunning.Set(0)
@@ -155,6 +156,24 @@ func (s *Server) cleanupRepos() {
 			}
 		}
 
+		// Re-clone repositories to apply changes of
+		// experimentalFeatures.gitPartialClone to them, for example to turn
+		// a full clone into a blobless one. Partial clones keep the objects
+		// they fetch on demand in promisor packs, so we also re-clone them
+		// to drop those objects once they take up too much space.
+		if repoType == "git" {
+			if changed, err := partialCloneFilterChanged(s.name(dir), dir); err != nil {
+				log15.Warn("failed to check partial clone filter", "repo", dir, "error", err)
+			} else if changed {
+				reason = "partial clone filter changed"
+			}
+			if grown, err := partialCloneGrown(dir); err != nil {
+				log15.Warn("failed to check partial clone size", "repo", dir, "error", err)
+			} else if grown {
+				reason = "partial clone grew"
+			}
+		}
+
 		// We believe converting a Perforce depot to a Git repository is generally a
 		// very expensive operation, therefore we do not try to re-clone/redo the
 		// conversion only because it is old or slow to do "git gc".
@@ -227,6 +246,11 @@ func (s *Server) cleanupRepos() {
 		return false, gitGC(dir)
 	}
 
+	performMaintenance := func(dir GitDir) (done bool, err error) {
+		_, err = s.maintainRepo(bCtx, dir)
+		return false, err
+	}
+
 	type cleanupFn struct {
 		Name string
 		Do   func(GitDir) (bool, error)
@@ -250,6 +274,10 @@ func (s *Server) cleanupRepos() {
 		// invocations of git add, packing refs, pruning reflog, rerere metadata or stale
 		// working trees. May also update ancillary indexes such as the commit-graph.
 		{"garbage collect", performGC},
+		// Writes commit-graphs, multi-pack-indexes and bitmaps, which git gc
+		// --auto doesn't keep up to date, to speed up git log and ancestry
+		// queries on large repositories.
+		{"maintenance", performMaintenance},
 	}
 
 	if !conf.Get().DisableAutoGitUpdates {
@@ -712,7 +740,13 @@ func checkMaybeCorruptRepo(repo api.RepoName, dir GitDir, stderr string) {
 // operate synchronously and be aggressive with its internal heurisitcs when
 // deciding to act (meaning it will act now at lower thresholds).
 func gitGC(dir GitDir) error {
-	cmd := exec.Command("git", "-c", "gc.auto=1", "-c", "gc.autoDetach=false", "gc", "--auto")
+	args := []string{"-c", "gc.auto=1", "-c", "gc.autoDetach=false"}
+	if quickIsPartialClone(dir) {
+		// Every fetch of missing objects writes a small promisor pack, so
+		// we consolidate them sooner than other packs.
+		args = append(args, "-c", "gc.autoPackLimit="+strconv.Itoa(partialCloneAutoPackLimit))
+	}
+	cmd := exec.Command("git", append(args, "gc", "--auto")...)
 	dir.Set(cmd)
 	err := cmd.Run()
 	if err != nil {
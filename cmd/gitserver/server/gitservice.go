@@ -1,6 +1,7 @@
 package server
 
 import (
+	"context"
 	"io"
 	"os/exec"
 	"time"
@@ -41,6 +42,11 @@ func (s *Server) gitServiceHandler() *gitservice.Handler {
 		// Limit rate of stdout from git.
 		CommandHook: func(cmd *exec.Cmd) {
 			cmd.Stdout = flowrateWriter(cmd.Stdout)
+
+			// The repository directory is the last argument. Partial clones
+			// fetch the blobs requested by the client on demand.
+			dir := GitDir(cmd.Args[len(cmd.Args)-1])
+			s.configurePromisorRemote(context.Background(), dir, cmd)
 		},
 
 		Trace: func(svc, repo, protocol string) func(error) {
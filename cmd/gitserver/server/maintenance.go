@@ -0,0 +1,275 @@
+package server
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cockroachdb/errors"
+	"github.com/inconshreveable/log15"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/conf"
+	"github.com/sourcegraph/sourcegraph/internal/database"
+	"github.com/sourcegraph/sourcegraph/schema"
+)
+
+// gitConfigLastMaintained is the git config key storing the last time the
+// janitor ran the maintenance tasks on a repository.
+const gitConfigLastMaintained = "sourcegraph.lastMaintained"
+
+// gitConfigLastMaintenanceAttempt is the git config key storing the last time
+// the janitor started to run the maintenance tasks on a repository, whether
+// they succeeded or not. Failed tasks are retried after the interval only.
+const gitConfigLastMaintenanceAttempt = "sourcegraph.lastMaintenanceAttempt"
+
+var maintenanceTaskDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+	Name:    "src_gitserver_maintenance_task_duration_seconds",
+	Help:    "Duration of the git maintenance tasks run by the gitserver janitor",
+	Buckets: []float64{0.1, 1, 10, 60, 300, 900, 1800, 3600},
+}, []string{"task", "success"})
+
+// packStats describes the packs of a repository.
+type packStats struct {
+	Count     int
+	SizeBytes int64
+
+	// PromisorCount and PromisorSizeBytes describe the promisor packs of
+	// partial clones, which contain the objects fetched from the promisor
+	// remote. They are included in Count and SizeBytes.
+	PromisorCount     int
+	PromisorSizeBytes int64
+}
+
+// maintenanceTask is a git maintenance task the janitor runs on repositories.
+type maintenanceTask struct {
+	Name string
+	// Due reports whether the task should run on a repository with the given
+	// packs.
+	Due  func(c *maintenanceConfig, stats packStats) bool
+	Args []string
+}
+
+// maintenanceTasks are all the maintenance tasks, in the order they run:
+// repacking first means that the other tasks index the new pack.
+var maintenanceTasks = []maintenanceTask{
+	{
+		Name: "repack",
+		Due: func(c *maintenanceConfig, stats packStats) bool {
+			return stats.Count >= c.repackPackCount
+		},
+		// Reachability bitmaps speed up counting objects, for example
+		// when serving fetches.
+		Args: []string{"repack", "-a", "-d", "--write-bitmap-index", "--quiet"},
+	},
+	{
+		Name: "multi-pack-index",
+		Due: func(c *maintenanceConfig, stats packStats) bool {
+			return stats.Count >= 2
+		},
+		Args: []string{"multi-pack-index", "write"},
+	},
+	{
+		Name: "commit-graph",
+		Due: func(c *maintenanceConfig, stats packStats) bool {
+			return true
+		},
+		// Split commit-graphs are written incrementally, which keeps
+		// the task cheap on repositories maintained before.
+		Args: []string{"commit-graph", "write", "--reachable", "--split"},
+	},
+}
+
+// maintenanceConfig is the parsed experimentalFeatures.gitMaintenance.
+type maintenanceConfig struct {
+	tasks           map[string]bool
+	interval        time.Duration
+	minSizeBytes    int64
+	repackPackCount int
+}
+
+var gitMaintenance = conf.Cached(func() interface{} {
+	return buildMaintenanceConfig(conf.Get().ExperimentalFeatures.GitMaintenance)
+})
+
+// buildMaintenanceConfig returns the maintenance configuration, or nil if
+// maintenance is disabled.
+func buildMaintenanceConfig(c *schema.GitMaintenance) *maintenanceConfig {
+	if c == nil {
+		return nil
+	}
+
+	mc := &maintenanceConfig{
+		tasks:           map[string]bool{},
+		interval:        24 * time.Hour,
+		minSizeBytes:    int64(c.MinSizeMB) * 1024 * 1024,
+		repackPackCount: 20,
+	}
+	if c.IntervalHours > 0 {
+		mc.interval = time.Duration(c.IntervalHours) * time.Hour
+	}
+	if c.RepackPackCount > 0 {
+		mc.repackPackCount = c.RepackPackCount
+	}
+	for _, t := range maintenanceTasks {
+		mc.tasks[t.Name] = c.Tasks == nil
+	}
+	for _, name := range c.Tasks {
+		mc.tasks[name] = true
+	}
+	return mc
+}
+
+// maintainRepo runs the configured maintenance tasks on the repository at dir
+// if it is due. It reports whether the tasks ran.
+func (s *Server) maintainRepo(ctx context.Context, dir GitDir) (bool, error) {
+	c, _ := gitMaintenance().(*maintenanceConfig)
+	if c == nil {
+		return false, nil
+	}
+
+	lastMaintained, err := getConfigTime(dir, gitConfigLastMaintained)
+	if err != nil {
+		return false, err
+	}
+	lastAttempt, err := getConfigTime(dir, gitConfigLastMaintenanceAttempt)
+	if err != nil {
+		return false, err
+	}
+	if lastAttempt.After(lastMaintained) {
+		lastMaintained = lastAttempt
+	}
+	// Add a jitter to spread out the maintenance of repos maintained at the
+	// same time, for example when it is first enabled.
+	if time.Since(lastMaintained) < c.interval+jitterDuration(string(dir), c.interval/4) {
+		return false, nil
+	}
+
+	stats, err := getPackStats(dir)
+	if err != nil {
+		return false, err
+	}
+	if stats.SizeBytes < c.minSizeBytes {
+		return false, nil
+	}
+
+	// Record the attempt first so that a failing task, or one interrupted by
+	// a restart, isn't retried on every janitor run.
+	if err := gitConfigSet(dir, gitConfigLastMaintenanceAttempt, strconv.FormatInt(time.Now().Unix(), 10)); err != nil {
+		return false, err
+	}
+
+	for _, task := range maintenanceTasks {
+		if !c.tasks[task.Name] || !task.Due(c, stats) {
+			continue
+		}
+
+		start := time.Now()
+		err := runMaintenanceTask(ctx, dir, task)
+		maintenanceTaskDuration.WithLabelValues(task.Name, strconv.FormatBool(err == nil)).Observe(time.Since(start).Seconds())
+		if err != nil {
+			return false, err
+		}
+
+		// The following tasks depend on the packs left by the previous ones.
+		if stats, err = getPackStats(dir); err != nil {
+			return false, err
+		}
+	}
+
+	now := time.Now()
+	if err := gitConfigSet(dir, gitConfigLastMaintained, strconv.FormatInt(now.Unix(), 10)); err != nil {
+		return true, err
+	}
+	s.setLastMaintainedNonFatal(ctx, s.name(dir), now)
+	return true, nil
+}
+
+func runMaintenanceTask(ctx context.Context, dir GitDir, task maintenanceTask) error {
+	ctx, cancel := context.WithTimeout(ctx, longGitCommandTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "git", task.Args...)
+	dir.Set(cmd)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return errors.Wrapf(err, "maintenance task %s failed with output: %s", task.Name, out)
+	}
+	return nil
+}
+
+// getConfigTime returns the maintenance time stored under the given git config
+// key of the repository at dir, or the zero time if there is none.
+func getConfigTime(dir GitDir, key string) (time.Time, error) {
+	value, err := gitConfigGet(dir, key)
+	if err != nil {
+		return time.Time{}, errors.Wrap(err, "failed to determine last maintenance time")
+	}
+	sec, err := strconv.ParseInt(strings.TrimSpace(value), 10, 0)
+	if err != nil {
+		// Missing or bad values are treated as never maintained.
+		return time.Time{}, nil
+	}
+	return time.Unix(sec, 0), nil
+}
+
+// getPackStats returns the number and total size of the packs of the
+// repository at dir.
+func getPackStats(dir GitDir) (packStats, error) {
+	var stats packStats
+	entries, err := os.ReadDir(dir.Path("objects", "pack"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return stats, nil
+		}
+		return stats, err
+	}
+	// A pack is a promisor pack if there is a .promisor file next to it.
+	promisor := map[string]bool{}
+	for _, e := range entries {
+		if !e.IsDir() && strings.HasSuffix(e.Name(), ".promisor") {
+			promisor[strings.TrimSuffix(e.Name(), ".promisor")] = true
+		}
+	}
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ".pack") {
+			continue
+		}
+		fi, err := e.Info()
+		if err != nil {
+			if os.IsNotExist(err) {
+				// Removed by a concurrent repack.
+				continue
+			}
+			return stats, err
+		}
+		stats.Count++
+		stats.SizeBytes += fi.Size()
+		if promisor[strings.TrimSuffix(e.Name(), ".pack")] {
+			stats.PromisorCount++
+			stats.PromisorSizeBytes += fi.Size()
+		}
+	}
+	return stats, nil
+}
+
+func (s *Server) setLastMaintained(ctx context.Context, name api.RepoName, lastMaintained time.Time) error {
+	if s.DB == nil {
+		return nil
+	}
+	if s.isReplica(name) {
+		return database.GitserverRepos(s.DB).SetReplicaLastMaintained(ctx, name, lastMaintained, s.Hostname)
+	}
+	return database.GitserverRepos(s.DB).SetLastMaintained(ctx, name, lastMaintained, s.Hostname)
+}
+
+// setLastMaintainedNonFatal is the same as setLastMaintained but only logs errors
+func (s *Server) setLastMaintainedNonFatal(ctx context.Context, name api.RepoName, lastMaintained time.Time) {
+	if err := s.setLastMaintained(ctx, name, lastMaintained); err != nil {
+		log15.Warn("Setting last maintained in DB", "error", err)
+	}
+}
@@ -0,0 +1,119 @@
+package server
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/sourcegraph/sourcegraph/schema"
+)
+
+func TestMaintainRepo(t *testing.T) {
+	root := t.TempDir()
+	repo := filepath.Join(root, "example.com", "repo")
+	runCmd(t, root, "git", "init", repo)
+
+	// Every incremental repack creates a new pack.
+	for i := 0; i < 3; i++ {
+		runCmd(t, repo, "sh", "-c", "echo "+strconv.Itoa(i)+" >> file")
+		runCmd(t, repo, "git", "add", "file")
+		runCmd(t, repo, "git", "commit", "-m", "commit")
+		runCmd(t, repo, "git", "repack", "-d", "-q")
+	}
+
+	dir := GitDir(filepath.Join(repo, ".git"))
+	s := &Server{ReposDir: root}
+	ctx := context.Background()
+
+	setConfig := func(c *schema.GitMaintenance) {
+		gitMaintenance = func() interface{} { return buildMaintenanceConfig(c) }
+	}
+	defer setConfig(nil)
+
+	packs := func() int {
+		t.Helper()
+		stats, err := getPackStats(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return stats.Count
+	}
+	exists := func(path ...string) bool {
+		_, err := os.Stat(dir.Path(path...))
+		return err == nil
+	}
+	reset := func() {
+		t.Helper()
+		for _, key := range []string{gitConfigLastMaintained, gitConfigLastMaintenanceAttempt} {
+			if err := gitConfigUnset(dir, key); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	maintain := func(want bool) {
+		t.Helper()
+		ran, err := s.maintainRepo(ctx, dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ran != want {
+			t.Fatalf("maintainRepo: have %t, want %t", ran, want)
+		}
+	}
+
+	// Maintenance is disabled by default.
+	maintain(false)
+
+	// Repositories smaller than the minimum size are skipped.
+	setConfig(&schema.GitMaintenance{MinSizeMB: 1})
+	maintain(false)
+
+	setConfig(&schema.GitMaintenance{Tasks: []string{"multi-pack-index", "commit-graph"}})
+	if packs() != 3 {
+		t.Fatalf("expected 3 packs, got %d", packs())
+	}
+	maintain(true)
+	if !exists("objects", "pack", "multi-pack-index") {
+		t.Error("expected a multi-pack-index")
+	}
+	if !exists("objects", "info", "commit-graphs", "commit-graph-chain") {
+		t.Error("expected a split commit-graph")
+	}
+	if packs() != 3 {
+		t.Errorf("expected packs to be left alone, got %d packs", packs())
+	}
+
+	// The next run is only due after the interval.
+	maintain(false)
+
+	reset()
+	setConfig(&schema.GitMaintenance{RepackPackCount: 2})
+	maintain(true)
+	if packs() != 1 {
+		t.Errorf("expected a single pack after repacking, got %d", packs())
+	}
+	bitmaps, _ := filepath.Glob(dir.Path("objects", "pack", "*.bitmap"))
+	if len(bitmaps) != 1 {
+		t.Errorf("expected a bitmap, got %v", bitmaps)
+	}
+
+	// A failed attempt is only retried after the interval.
+	reset()
+	graphs := dir.Path("objects", "info", "commit-graphs")
+	if err := os.RemoveAll(graphs); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(graphs, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	setConfig(&schema.GitMaintenance{Tasks: []string{"commit-graph"}})
+	if _, err := s.maintainRepo(ctx, dir); err == nil {
+		t.Fatal("expected the commit-graph task to fail")
+	}
+	maintain(false)
+	if lastMaintained, err := getConfigTime(dir, gitConfigLastMaintained); err != nil || !lastMaintained.IsZero() {
+		t.Errorf("expected the failed attempt not to count as maintained, got %v (%v)", lastMaintained, err)
+	}
+}
@@ -0,0 +1,266 @@
+package server
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cockroachdb/errors"
+	"github.com/inconshreveable/log15"
+
+	"github.com/sourcegraph/sourcegraph/internal/actor"
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/conf"
+	"github.com/sourcegraph/sourcegraph/internal/vcs"
+	"github.com/sourcegraph/sourcegraph/schema"
+)
+
+// partialCloneRemote is the name of the promisor remote of partial clones,
+// from which git fetches the objects they are missing. It isn't "origin",
+// which the janitor scrubs.
+//
+// 🚨 SECURITY: The URL of the remote is never written to the git config, so
+// that the credentials it contains don't end up on disk. Every git command
+// that may have to fetch missing objects gets it with -c instead, see
+// promisorRemoteArgs.
+const partialCloneRemote = "sourcegraph"
+
+const (
+	// gitConfigPartialCloneSize is the git config key storing the size of
+	// the promisor packs of a partial clone right after it was cloned.
+	gitConfigPartialCloneSize = "sourcegraph.partialCloneSize"
+
+	// partialCloneMaxGrowth is how many times their size after cloning the
+	// promisor packs of a partial clone may grow, with the objects fetched on
+	// demand, before the janitor re-clones it.
+	partialCloneMaxGrowth = 2
+
+	// partialCloneAutoPackLimit is the gc.autoPackLimit of partial clones.
+	partialCloneAutoPackLimit = 20
+
+	// promisorRemoteURLTTL is how long we reuse the remote URL of a partial
+	// clone for the commands which fetch its missing objects.
+	promisorRemoteURLTTL = time.Minute
+)
+
+var gitPartialClone = conf.Cached(func() interface{} {
+	return buildPartialCloneMappings(conf.Get().ExperimentalFeatures.GitPartialClone)
+})
+
+func buildPartialCloneMappings(c []*schema.GitPartialCloneMapping) map[string]string {
+	m := map[string]string{}
+	for _, mapping := range c {
+		m[strings.Trim(mapping.Repo, "/")] = mapping.Filter
+	}
+	return m
+}
+
+// PartialCloneFilter returns the object filter configured for the given
+// repository in experimentalFeatures.gitPartialClone, or "" if it should be
+// cloned in full. The most specific repository name prefix wins.
+func PartialCloneFilter(repo api.RepoName) string {
+	m := gitPartialClone().(map[string]string)
+	if len(m) == 0 {
+		return ""
+	}
+
+	name := string(repo)
+	for {
+		if filter, ok := m[name]; ok {
+			return filter
+		}
+		i := strings.LastIndex(name, "/")
+		if i < 0 {
+			return ""
+		}
+		name = name[:i]
+	}
+}
+
+// partialCloneFetchCmd returns the command fetching from remoteURL into a
+// partial clone with the given object filter.
+func partialCloneFetchCmd(ctx context.Context, remoteURL *vcs.URL, filter string) *exec.Cmd {
+	refspecs := defaultRefspecs
+	if useRefspecOverrides() {
+		refspecs = refspecOverrides
+	}
+
+	args := append(promisorRemoteArgs(remoteURL), "fetch", "--progress", "--prune", "--filter="+filter, partialCloneRemote)
+	return exec.CommandContext(ctx, "git", append(args, refspecs...)...)
+}
+
+// initPartialClone configures the freshly initialized repository at dir as a
+// partial clone with the given object filter.
+func initPartialClone(dir GitDir, filter string) error {
+	for _, kv := range [][2]string{
+		// extensions are only honored by repository format version 1.
+		{"core.repositoryFormatVersion", "1"},
+		{"extensions.partialClone", partialCloneRemote},
+		{"remote." + partialCloneRemote + ".promisor", "true"},
+		{"remote." + partialCloneRemote + ".partialCloneFilter", filter},
+	} {
+		if err := gitConfigSet(dir, kv[0], kv[1]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// promisorRemoteArgs returns the git options setting the URL of the promisor
+// remote, which must come before the git subcommand.
+func promisorRemoteArgs(remoteURL *vcs.URL) []string {
+	return []string{"-c", "remote." + partialCloneRemote + ".url=" + remoteURL.String()}
+}
+
+// configurePromisorRemote updates cmd, a git command run in the repository at
+// dir, so that it can fetch the objects missing from the repository if it is a
+// partial clone. It is a no-op for other repositories.
+func (s *Server) configurePromisorRemote(ctx context.Context, dir GitDir, cmd *exec.Cmd) {
+	if !quickIsPartialClone(dir) {
+		return
+	}
+
+	repo := s.name(dir)
+	remoteURL, err := s.promisorRemoteURL(ctx, repo)
+	if err != nil {
+		// The command still works as long as it doesn't need missing objects.
+		log15.Warn("failed to determine remote URL of partial clone", "repo", repo, "error", err)
+		return
+	}
+
+	cmd.Args = append(append(cmd.Args[:1:1], promisorRemoteArgs(remoteURL)...), cmd.Args[1:]...)
+	if cmd.Env == nil {
+		cmd.Env = os.Environ()
+	}
+	// Recent versions of git disable lazy fetching in upload-pack by default.
+	cmd.Env = append(cmd.Env, "GIT_NO_LAZY_FETCH=0")
+	configureRemoteGitCommand(cmd, tlsExternal().(*tlsConfig))
+}
+
+// cachedRemoteURL is a remote URL returned by Server.getRemoteURL.
+type cachedRemoteURL struct {
+	url     *vcs.URL
+	expires time.Time
+}
+
+// promisorRemoteURL returns the remote URL of the partial clone of repo. Most
+// commands run in partial clones don't fetch anything, so the URL is cached for
+// a while rather than looked up for every command. It is never written to
+// disk, see partialCloneRemote.
+func (s *Server) promisorRemoteURL(ctx context.Context, repo api.RepoName) (*vcs.URL, error) {
+	s.promisorRemoteURLsMu.Lock()
+	cached, ok := s.promisorRemoteURLs[repo]
+	s.promisorRemoteURLsMu.Unlock()
+	if ok && time.Now().Before(cached.expires) {
+		return cached.url, nil
+	}
+
+	remoteURL, err := s.getRemoteURL(actor.WithInternalActor(ctx), repo)
+	if err != nil {
+		return nil, err
+	}
+
+	s.promisorRemoteURLsMu.Lock()
+	if s.promisorRemoteURLs == nil {
+		s.promisorRemoteURLs = map[api.RepoName]cachedRemoteURL{}
+	}
+	s.promisorRemoteURLs[repo] = cachedRemoteURL{url: remoteURL, expires: time.Now().Add(promisorRemoteURLTTL)}
+	s.promisorRemoteURLsMu.Unlock()
+	return remoteURL, nil
+}
+
+// setPartialCloneSize records the size of the promisor packs of the freshly
+// cloned partial clone at dir.
+func setPartialCloneSize(dir GitDir) error {
+	stats, err := getPackStats(dir)
+	if err != nil {
+		return err
+	}
+	if err := gitConfigSet(dir, gitConfigPartialCloneSize, strconv.FormatInt(stats.PromisorSizeBytes, 10)); err != nil {
+		return errors.Wrap(err, "failed to record the size of the partial clone")
+	}
+	return nil
+}
+
+// partialCloneGrown reports whether the promisor packs of the partial clone at
+// dir grew more than partialCloneMaxGrowth times their size after cloning. It
+// is false for full clones.
+func partialCloneGrown(dir GitDir) (bool, error) {
+	if !quickIsPartialClone(dir) {
+		return false, nil
+	}
+	value, err := gitConfigGet(dir, gitConfigPartialCloneSize)
+	if err != nil {
+		return false, err
+	}
+	cloneSize, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+	if err != nil || cloneSize <= 0 {
+		// The size is missing for partial clones cloned before we recorded
+		// it, so we use the current one.
+		return false, setPartialCloneSize(dir)
+	}
+	stats, err := getPackStats(dir)
+	if err != nil {
+		return false, err
+	}
+	return stats.PromisorSizeBytes > partialCloneMaxGrowth*cloneSize, nil
+}
+
+// partialCloneFilterOf returns the object filter of the partial clone at dir,
+// or "" if it is a full clone.
+func partialCloneFilterOf(dir GitDir) (string, error) {
+	filter, err := gitConfigGet(dir, "remote."+partialCloneRemote+".partialCloneFilter")
+	return strings.TrimSpace(filter), err
+}
+
+// partialCloneFilterChanged reports whether the object filter configured for
+// repo differs from the one of its clone at dir.
+func partialCloneFilterChanged(repo api.RepoName, dir GitDir) (bool, error) {
+	want := PartialCloneFilter(repo)
+	if want == "" && !quickIsPartialClone(dir) {
+		// Avoid running git for the common case of full clones.
+		return false, nil
+	}
+	have, err := partialCloneFilterOf(dir)
+	if err != nil {
+		return false, err
+	}
+	return have != want, nil
+}
+
+// quickIsPartialClone best-effort mimics checking whether the repository at
+// dir is a partial clone with `git config extensions.partialClone`, but
+// doesn't exec a child process. It just reads the config file of the bare git
+// repository directory.
+func quickIsPartialClone(dir GitDir) bool {
+	config, err := os.ReadFile(dir.Path("config"))
+	if err != nil {
+		return false
+	}
+
+	var section string
+	scanner := bufio.NewScanner(bytes.NewReader(config))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "[") {
+			section = strings.ToLower(strings.Trim(line, "[] \t"))
+			continue
+		}
+		if section != "extensions" {
+			continue
+		}
+		i := strings.Index(line, "=")
+		if i < 0 {
+			continue
+		}
+		if strings.EqualFold(strings.TrimSpace(line[:i]), "partialclone") && strings.TrimSpace(line[i+1:]) != "" {
+			return true
+		}
+	}
+	return false
+}
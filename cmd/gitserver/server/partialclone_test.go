@@ -0,0 +1,224 @@
+package server
+
+import (
+	"context"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/vcs"
+	"github.com/sourcegraph/sourcegraph/schema"
+)
+
+func TestPartialCloneFilter(t *testing.T) {
+	gitPartialClone = func() interface{} {
+		return buildPartialCloneMappings([]*schema.GitPartialCloneMapping{
+			{Repo: "github.com/foo", Filter: "blob:none"},
+			{Repo: "github.com/foo/monorepo/", Filter: "blob:limit=1m"},
+		})
+	}
+	defer func() {
+		gitPartialClone = func() interface{} { return buildPartialCloneMappings(nil) }
+	}()
+
+	for repo, want := range map[api.RepoName]string{
+		"github.com/foo/bar":          "blob:none",
+		"github.com/foo/monorepo":     "blob:limit=1m",
+		"github.com/foo/monorepo/sub": "blob:limit=1m",
+		"github.com/foobar/baz":       "",
+		"gitlab.com/foo/bar":          "",
+	} {
+		if have := PartialCloneFilter(repo); have != want {
+			t.Errorf("PartialCloneFilter(%q): have %q, want %q", repo, have, want)
+		}
+	}
+}
+
+func TestGitRepoSyncer_PartialClone(t *testing.T) {
+	root := t.TempDir()
+	remote := filepath.Join(root, "remote")
+	cmd := func(name string, arg ...string) string {
+		t.Helper()
+		return runCmd(t, remote, name, arg...)
+	}
+	runCmd(t, root, "mkdir", "remote")
+	cmd("git", "init", ".")
+	// Serving partial clones must be allowed explicitly.
+	cmd("git", "config", "uploadpack.allowFilter", "true")
+	cmd("sh", "-c", "echo small > small.txt && head -c 4096 /dev/zero | tr '\\0' x > big.txt")
+	cmd("git", "add", ".")
+	cmd("git", "commit", "-m", "initial")
+
+	remoteURL, err := vcs.ParseURL("file://" + remote)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	s := &Server{
+		ReposDir:         filepath.Join(root, "repos"),
+		GetRemoteURLFunc: staticGetRemoteURL(remoteURL.String()),
+	}
+	dir := s.dir("example.com/remote")
+
+	syncer := &GitRepoSyncer{PartialCloneFilter: "blob:limit=1k"}
+	clone, err := syncer.CloneCommand(ctx, remoteURL, string(dir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out, err := runWithRemoteOpts(ctx, clone, nil); err != nil {
+		t.Fatalf("clone failed: %s\n%s", err, out)
+	}
+
+	if !quickIsPartialClone(dir) {
+		t.Fatal("expected a partial clone")
+	}
+	if filter, err := partialCloneFilterOf(dir); err != nil || filter != "blob:limit=1k" {
+		t.Fatalf("unexpected filter %q (error %v)", filter, err)
+	}
+
+	// The big blob is missing, and the remote URL wasn't stored on disk.
+	missing := runCmd(t, string(dir), "git", "rev-list", "--objects", "--all", "--missing=print")
+	if n := strings.Count(missing, "\n?"); n != 1 {
+		t.Fatalf("expected 1 missing object, got %d:\n%s", n, missing)
+	}
+	if out, _ := exec.Command("git", "--git-dir", string(dir), "config", "--get-regexp", `remote\..*\.url`).Output(); len(out) > 0 {
+		t.Fatalf("remote URL stored in git config: %s", out)
+	}
+
+	// Fetches keep the clone partial.
+	if err := syncer.Fetch(ctx, remoteURL, dir); err != nil {
+		t.Fatal(err)
+	}
+
+	// Commands fetch the missing blob on demand.
+	show := exec.Command("git", "show", "HEAD:big.txt")
+	dir.Set(show)
+	s.configurePromisorRemote(ctx, dir, show)
+	out, err := show.Output()
+	if err != nil {
+		t.Fatalf("git show failed: %s", err)
+	}
+	if len(out) != 4096 {
+		t.Fatalf("unexpected big.txt size %d", len(out))
+	}
+
+	// The fetched blob is in a second promisor pack, which the janitor
+	// accounts for.
+	stats, err := getPackStats(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.PromisorCount != 2 || stats.PromisorCount != stats.Count {
+		t.Fatalf("expected 2 promisor packs, got %+v", stats)
+	}
+
+	// The janitor notices when the clone no longer matches the configuration.
+	if changed, err := partialCloneFilterChanged("example.com/remote", dir); err != nil || !changed {
+		t.Fatalf("expected changed filter, got %t (error %v)", changed, err)
+	}
+}
+
+func TestPartialCloneGrown(t *testing.T) {
+	root := t.TempDir()
+	remote := filepath.Join(root, "remote")
+	cmd := func(name string, arg ...string) string {
+		t.Helper()
+		return runCmd(t, remote, name, arg...)
+	}
+	runCmd(t, root, "mkdir", "remote")
+	cmd("git", "init", ".")
+	cmd("git", "config", "uploadpack.allowFilter", "true")
+	cmd("sh", "-c", "head -c 65536 /dev/urandom > big.bin")
+	cmd("git", "add", ".")
+	cmd("git", "commit", "-m", "initial")
+
+	remoteURL, err := vcs.ParseURL("file://" + remote)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	s := &Server{
+		ReposDir:         filepath.Join(root, "repos"),
+		GetRemoteURLFunc: staticGetRemoteURL(remoteURL.String()),
+	}
+	dir := s.dir("example.com/remote")
+
+	clone, err := (&GitRepoSyncer{PartialCloneFilter: "blob:none"}).CloneCommand(ctx, remoteURL, string(dir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out, err := runWithRemoteOpts(ctx, clone, nil); err != nil {
+		t.Fatalf("clone failed: %s\n%s", err, out)
+	}
+
+	// Partial clones cloned before we recorded their size get it on the
+	// first check.
+	if grown, err := partialCloneGrown(dir); err != nil || grown {
+		t.Fatalf("expected the clone not to have grown, got %t (error %v)", grown, err)
+	}
+	if size, _ := gitConfigGet(dir, gitConfigPartialCloneSize); strings.TrimSpace(size) == "" {
+		t.Fatal("expected the size of the clone to be recorded")
+	}
+
+	// Fetching the big blob on demand makes the clone much bigger.
+	show := exec.Command("git", "cat-file", "-p", "HEAD:big.bin")
+	dir.Set(show)
+	s.configurePromisorRemote(ctx, dir, show)
+	if out, err := show.CombinedOutput(); err != nil {
+		t.Fatalf("git cat-file failed: %s\n%s", err, out)
+	}
+	if grown, err := partialCloneGrown(dir); err != nil || !grown {
+		t.Fatalf("expected the clone to have grown, got %t (error %v)", grown, err)
+	}
+}
+
+func TestPromisorRemoteURLCached(t *testing.T) {
+	calls := 0
+	s := &Server{GetRemoteURLFunc: func(context.Context, api.RepoName) (string, error) {
+		calls++
+		return "https://example.com/foo/bar", nil
+	}}
+
+	ctx := context.Background()
+	for i := 0; i < 3; i++ {
+		u, err := s.promisorRemoteURL(ctx, "example.com/foo/bar")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if u.String() != "https://example.com/foo/bar" {
+			t.Fatalf("unexpected remote URL %s", u)
+		}
+	}
+	if calls != 1 {
+		t.Fatalf("expected the remote URL to be looked up once, got %d", calls)
+	}
+
+	// Expired URLs are looked up again.
+	s.promisorRemoteURLs["example.com/foo/bar"] = cachedRemoteURL{expires: time.Now().Add(-time.Second)}
+	if _, err := s.promisorRemoteURL(ctx, "example.com/foo/bar"); err != nil {
+		t.Fatal(err)
+	}
+	if calls != 2 {
+		t.Fatalf("expected the remote URL to be looked up again, got %d lookups", calls)
+	}
+}
+
+func TestQuickIsPartialClone(t *testing.T) {
+	dir := GitDir(t.TempDir())
+	runCmd(t, string(dir), "git", "init", "--bare", ".")
+	if quickIsPartialClone(dir) {
+		t.Fatal("expected a full clone")
+	}
+
+	if err := initPartialClone(dir, "blob:none"); err != nil {
+		t.Fatal(err)
+	}
+	if !quickIsPartialClone(dir) {
+		t.Fatal("expected a partial clone")
+	}
+}
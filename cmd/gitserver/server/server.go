@@ -236,6 +236,9 @@ type Server struct {
 
 	repoUpdateLocksMu sync.Mutex // protects the map below and also updates to locks.once
 	repoUpdateLocks   map[api.RepoName]*locks
+
+	promisorRemoteURLsMu sync.Mutex // protects the map below
+	promisorRemoteURLs   map[api.RepoName]cachedRemoteURL
 }
 
 type locks struct {
@@ -371,19 +374,21 @@ func (s *Server) Janitor(interval time.Duration) {
 
 // SyncRepoState syncs state on disk to the database for all repos and is
 // expected to run in a background goroutine. We perform a full sync if the known
-// gitserver addresses has changed since the last run. Otherwise, we only sync
-// repos that have not yet been assigned a shard.
+// gitserver addresses or the replication factor have changed since the last
+// run. Otherwise, we only sync repos that have not yet been assigned a shard.
 func (s *Server) SyncRepoState(interval time.Duration, batchSize, perSecond int) {
 	var previousAddrs string
 	for {
 		addrs := conf.Get().ServiceConnections.GitServers
+		replicationFactor := gitserver.ReplicationFactor()
 		// We turn addrs into a string here for easy comparison and storage of previous
-		// addresses since we'd need to take a copy of the slice anyway.
-		currentAddrs := strings.Join(addrs, ",")
+		// addresses since we'd need to take a copy of the slice anyway. The
+		// replication factor is part of it since it changes the shards too.
+		currentAddrs := strings.Join(addrs, ",") + "/" + strconv.Itoa(replicationFactor)
 		fullSync := currentAddrs != previousAddrs
 		previousAddrs = currentAddrs
 
-		if err := s.syncRepoState(addrs, batchSize, perSecond, fullSync); err != nil {
+		if err := s.syncRepoState(addrs, replicationFactor, batchSize, perSecond, fullSync); err != nil {
 			log15.Error("Syncing repo state", "error ", err)
 		}
 
@@ -495,7 +500,7 @@ var (
 	}, []string{"success"})
 )
 
-func (s *Server) syncRepoState(addrs []string, batchSize, perSecond int, fullSync bool) error {
+func (s *Server) syncRepoState(addrs []string, replicationFactor, batchSize, perSecond int, fullSync bool) error {
 	log15.Info("starting syncRepoState", "fullSync", fullSync)
 
 	// When fullSync is true we'll scan all repos in the database and ensure we set
@@ -504,6 +509,10 @@ func (s *Server) syncRepoState(addrs []string, batchSize, perSecond int, fullSyn
 	//
 	// When fullSync is false, we assume that we only need to check repos that have
 	// not yet had their shard_id allocated.
+	//
+	// The state of the repos this shard holds a replica of is kept in the
+	// gitserver_repo_replicas table instead, and removed from it when the shard
+	// stops being one of their replicas.
 
 	// Sanity check our host exists in addrs before starting any work
 	var found bool
@@ -534,6 +543,7 @@ func (s *Server) syncRepoState(addrs []string, batchSize, perSecond int, fullSyn
 	}
 
 	batch := make([]*types.GitserverRepo, 0)
+	replicaBatch := make([]*types.GitserverRepoReplica, 0)
 
 	writeBatch := func() {
 		if len(batch) == 0 {
@@ -557,13 +567,35 @@ func (s *Server) syncRepoState(addrs []string, batchSize, perSecond int, fullSyn
 		repoStateUpsertCounter.WithLabelValues("true").Add(float64(len(batch)))
 	}
 
+	writeReplicaBatch := func() {
+		if len(replicaBatch) == 0 {
+			return
+		}
+		// We always clear the batch
+		defer func() {
+			replicaBatch = replicaBatch[0:0]
+		}()
+		err := limiter.WaitN(ctx, len(replicaBatch))
+		if err != nil {
+			log15.Error("Waiting for rate limiter", "error", err)
+			return
+		}
+
+		if err := store.UpsertReplicas(ctx, replicaBatch...); err != nil {
+			repoStateUpsertCounter.WithLabelValues("false").Add(float64(len(replicaBatch)))
+			log15.Error("Upserting GitserverRepoReplicas", "error", err)
+			return
+		}
+		repoStateUpsertCounter.WithLabelValues("true").Add(float64(len(replicaBatch)))
+	}
+
 	totalRepos, err := database.Repos(s.DB).Count(ctx, database.ReposListOptions{})
 	if err != nil {
 		return errors.Wrap(err, "counting repos")
 	}
 
 	var count int
-	options := database.IterateRepoGitserverStatusOptions{}
+	options := database.IterateRepoGitserverStatusOptions{ReplicaShardID: s.Hostname}
 	if !fullSync {
 		options.OnlyWithoutShard = true
 	}
@@ -573,11 +605,37 @@ func (s *Server) syncRepoState(addrs []string, batchSize, perSecond int, fullSyn
 
 		repoSyncStateCounter.WithLabelValues("check").Inc()
 		// Ensure we're only dealing with repos we are responsible for
-		if addr := gitserver.AddrForRepo(repo.Name, addrs); !s.hostnameMatch(addr) {
-			repoSyncStateCounter.WithLabelValues("other_shard").Inc()
+		replicas := gitserver.AddrsForRepo(repo.Name, addrs, replicationFactor)
+		if !s.hostnameMatch(replicas[0]) {
+			var isReplica bool
+			for _, addr := range replicas[1:] {
+				if s.hostnameMatch(addr) {
+					isReplica = true
+					break
+				}
+			}
+			if !isReplica {
+				repoSyncStateCounter.WithLabelValues("other_shard").Inc()
+				if repo.Replica != nil {
+					s.deleteReplicaNonFatal(ctx, repo.ID)
+				}
+				return nil
+			}
+
+			repoSyncStateCounter.WithLabelValues("replica").Inc()
+			if r := s.syncReplicaState(repo); r != nil {
+				replicaBatch = append(replicaBatch, r)
+				if len(replicaBatch) >= batchSize {
+					writeReplicaBatch()
+				}
+			}
 			return nil
 		}
 		repoSyncStateCounter.WithLabelValues("this_shard").Inc()
+		if repo.Replica != nil {
+			// This shard became the primary one of the repo.
+			s.deleteReplicaNonFatal(ctx, repo.ID)
+		}
 
 		dir := s.dir(repo.Name)
 		cloned := repoCloned(dir)
@@ -615,10 +673,61 @@ func (s *Server) syncRepoState(addrs []string, batchSize, perSecond int, fullSyn
 
 	// Attempt final write
 	writeBatch()
+	writeReplicaBatch()
 
 	return err
 }
 
+// syncReplicaState returns the updated state of the replica of repo on this
+// shard, or nil if it is up to date.
+func (s *Server) syncReplicaState(repo types.RepoGitserverStatus) *types.GitserverRepoReplica {
+	dir := s.dir(repo.Name)
+	_, cloning := s.locker.Status(dir)
+	cloneStatus := cloneStatus(repoCloned(dir), cloning)
+
+	if repo.Replica == nil {
+		r := &types.GitserverRepoReplica{
+			RepoID:      repo.ID,
+			ShardID:     s.Hostname,
+			CloneStatus: cloneStatus,
+		}
+		// A replica which isn't cloned yet was never fetched.
+		if cloneStatus == types.CloneStatusCloned {
+			if lastFetched, err := repoLastFetched(dir); err == nil {
+				r.LastFetched = lastFetched
+			}
+		}
+		return r
+	}
+	if repo.Replica.CloneStatus == cloneStatus {
+		return nil
+	}
+	repo.Replica.CloneStatus = cloneStatus
+	return repo.Replica
+}
+
+func (s *Server) deleteReplicaNonFatal(ctx context.Context, id api.RepoID) {
+	if err := database.GitserverRepos(s.DB).DeleteReplica(ctx, id, s.Hostname); err != nil {
+		log15.Warn("Deleting replica state in DB", "error", err)
+	}
+}
+
+// isReplica reports whether this gitserver holds a replica of repo rather than
+// its primary copy, see gitserver.AddrsForRepo.
+func (s *Server) isReplica(repo api.RepoName) bool {
+	addrs := conf.Get().ServiceConnections.GitServers
+	if len(addrs) == 0 {
+		return false
+	}
+	replicas := gitserver.AddrsForRepo(repo, addrs, gitserver.ReplicationFactor())
+	for _, addr := range replicas[1:] {
+		if s.hostnameMatch(addr) {
+			return true
+		}
+	}
+	return false
+}
+
 // Stop cancels the running background jobs and returns when done.
 func (s *Server) Stop() {
 	// idempotent so we can just always set and cancel
@@ -1099,6 +1208,8 @@ func (s *Server) exec(w http.ResponseWriter, r *http.Request, req *protocol.Exec
 	cmdStart = time.Now()
 	cmd := exec.CommandContext(ctx, "git", req.Args...)
 	dir.Set(cmd)
+	// Partial clones fetch the blobs the command needs on demand.
+	s.configurePromisorRemote(ctx, dir, cmd)
 	cmd.Stdout = stdoutW
 	cmd.Stderr = stderrW
 
@@ -1281,6 +1392,9 @@ func (s *Server) setLastError(ctx context.Context, name api.RepoName, error stri
 	if s.DB == nil {
 		return nil
 	}
+	if s.isReplica(name) {
+		return database.GitserverRepos(s.DB).SetReplicaLastError(ctx, name, error, s.Hostname)
+	}
 	return database.GitserverRepos(s.DB).SetLastError(ctx, name, error, s.Hostname)
 }
 
@@ -1288,6 +1402,9 @@ func (s *Server) setLastFetched(ctx context.Context, name api.RepoName, lastFetc
 	if s.DB == nil {
 		return nil
 	}
+	if s.isReplica(name) {
+		return database.GitserverRepos(s.DB).SetReplicaLastFetched(ctx, name, lastFetched, s.Hostname)
+	}
 	return database.GitserverRepos(s.DB).SetLastFetched(ctx, name, lastFetched, s.Hostname)
 }
 
@@ -1306,6 +1423,9 @@ func (s *Server) setCloneStatus(ctx context.Context, name api.RepoName, status t
 	if s.DB == nil {
 		return nil
 	}
+	if s.isReplica(name) {
+		return database.GitserverRepos(s.DB).SetReplicaCloneStatus(ctx, name, status, s.Hostname)
+	}
 	return database.GitserverRepos(s.DB).SetCloneStatus(ctx, name, status, s.Hostname)
 }
 
@@ -1567,6 +1687,12 @@ func (s *Server) doClone(ctx context.Context, repo api.RepoName, dir GitDir, syn
 		return errors.Wrap(err, `git config set "sourcegraph.type"`)
 	}
 
+	if quickIsPartialClone(tmp) {
+		if err := setPartialCloneSize(tmp); err != nil {
+			return err
+		}
+	}
+
 	// Update the last-changed stamp.
 	if err := setLastChanged(tmp); err != nil {
 		return errors.Wrapf(err, "failed to update last changed time")
@@ -938,7 +938,7 @@ func TestSyncRepoState(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	err = s.syncRepoState([]string{hostname}, 10, 10, true)
+	err = s.syncRepoState([]string{hostname}, 1, 10, 10, true)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -953,6 +953,43 @@ func TestSyncRepoState(t *testing.T) {
 	}
 }
 
+func TestSyncReplicaState(t *testing.T) {
+	s := &Server{
+		ReposDir: t.TempDir(),
+		Hostname: "replica",
+		locker:   &RepositoryLocker{},
+	}
+	repo := types.RepoGitserverStatus{ID: 1, Name: "example.com/foo/bar"}
+
+	r := s.syncReplicaState(repo)
+	if r == nil || r.CloneStatus != types.CloneStatusNotCloned {
+		t.Fatalf("got %+v, want a not cloned replica", r)
+	}
+	if !r.LastFetched.IsZero() {
+		t.Errorf("got LastFetched %v for a replica which isn't cloned, want it unset", r.LastFetched)
+	}
+
+	dir := s.dir(repo.Name)
+	if err := os.MkdirAll(dir.Path(), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(dir.Path("HEAD"), []byte("ref: refs/heads/master\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	fetched := time.Now().Add(-time.Hour).Truncate(time.Second)
+	if err := os.Chtimes(dir.Path("HEAD"), fetched, fetched); err != nil {
+		t.Fatal(err)
+	}
+
+	r = s.syncReplicaState(repo)
+	if r == nil || r.CloneStatus != types.CloneStatusCloned {
+		t.Fatalf("got %+v, want a cloned replica", r)
+	}
+	if !r.LastFetched.Equal(fetched) {
+		t.Errorf("got LastFetched %v, want %v", r.LastFetched, fetched)
+	}
+}
+
 func TestMain(m *testing.M) {
 	flag.Parse()
 	if !testing.Verbose() {
@@ -33,7 +33,12 @@ type VCSSyncer interface {
 }
 
 // GitRepoSyncer is a syncer for Git repositories.
-type GitRepoSyncer struct{}
+type GitRepoSyncer struct {
+	// PartialCloneFilter is the object filter to clone the repository with,
+	// for example "blob:none". Empty means a full clone. Fetches into an
+	// existing clone keep using the filter it was cloned with.
+	PartialCloneFilter string
+}
 
 func (s *GitRepoSyncer) Type() string {
 	return "git"
@@ -78,40 +83,61 @@ func (s *GitRepoSyncer) CloneCommand(ctx context.Context, remoteURL *vcs.URL, tm
 		return nil, errors.Wrapf(err, "clone setup failed")
 	}
 
-	cmd, _ = s.fetchCommand(ctx, remoteURL)
+	if s.PartialCloneFilter != "" {
+		if err := initPartialClone(GitDir(tmpPath), s.PartialCloneFilter); err != nil {
+			return nil, errors.Wrapf(err, "partial clone setup failed")
+		}
+	}
+
+	cmd, _ = s.fetchCommand(ctx, remoteURL, s.PartialCloneFilter)
 	cmd.Dir = tmpPath
 	return cmd, nil
 }
 
-func (s *GitRepoSyncer) fetchCommand(ctx context.Context, remoteURL *vcs.URL) (cmd *exec.Cmd, configRemoteOpts bool) {
+// defaultRefspecs are the refspecs fetched from Git remotes.
+var defaultRefspecs = []string{
+	// Normal git refs
+	"+refs/heads/*:refs/heads/*", "+refs/tags/*:refs/tags/*",
+	// GitHub pull requests
+	"+refs/pull/*:refs/pull/*",
+	// GitLab merge requests
+	"+refs/merge-requests/*:refs/merge-requests/*",
+	// Bitbucket pull requests
+	"+refs/pull-requests/*:refs/pull-requests/*",
+	// Gerrit changesets
+	"+refs/changes/*:refs/changes/*",
+	// Possibly deprecated refs for sourcegraph zap experiment?
+	"+refs/sourcegraph/*:refs/sourcegraph/*",
+}
+
+// fetchCommand returns the command fetching from remoteURL. A non-empty
+// filter makes it fetch into a partial clone with that object filter.
+func (s *GitRepoSyncer) fetchCommand(ctx context.Context, remoteURL *vcs.URL, filter string) (cmd *exec.Cmd, configRemoteOpts bool) {
 	configRemoteOpts = true
 	if customCmd := customFetchCmd(ctx, remoteURL); customCmd != nil {
 		cmd = customCmd
 		configRemoteOpts = false
+	} else if filter != "" {
+		cmd = partialCloneFetchCmd(ctx, remoteURL, filter)
 	} else if useRefspecOverrides() {
 		cmd = refspecOverridesFetchCmd(ctx, remoteURL)
 	} else {
-		cmd = exec.CommandContext(ctx, "git", "fetch",
-			"--progress", "--prune", remoteURL.String(),
-			// Normal git refs
-			"+refs/heads/*:refs/heads/*", "+refs/tags/*:refs/tags/*",
-			// GitHub pull requests
-			"+refs/pull/*:refs/pull/*",
-			// GitLab merge requests
-			"+refs/merge-requests/*:refs/merge-requests/*",
-			// Bitbucket pull requests
-			"+refs/pull-requests/*:refs/pull-requests/*",
-			// Gerrit changesets
-			"+refs/changes/*:refs/changes/*",
-			// Possibly deprecated refs for sourcegraph zap experiment?
-			"+refs/sourcegraph/*:refs/sourcegraph/*")
+		cmd = exec.CommandContext(ctx, "git", append([]string{"fetch", "--progress", "--prune", remoteURL.String()}, defaultRefspecs...)...)
 	}
 	return cmd, configRemoteOpts
 }
 
 // Fetch tries to fetch updates of a Git repository.
 func (s *GitRepoSyncer) Fetch(ctx context.Context, remoteURL *vcs.URL, dir GitDir) error {
-	cmd, configRemoteOpts := s.fetchCommand(ctx, remoteURL)
+	// Avoid running git for the common case of full clones.
+	var filter string
+	if quickIsPartialClone(dir) {
+		var err error
+		if filter, err = partialCloneFilterOf(dir); err != nil {
+			return err
+		}
+	}
+	cmd, configRemoteOpts := s.fetchCommand(ctx, remoteURL, filter)
 	dir.Set(cmd)
 	if output, err := runWith(ctx, cmd, configRemoteOpts, nil); err != nil {
 		return errors.Wrapf(err, "failed to update with output %q", newURLRedactor(remoteURL).redact(string(output)))
@@ -0,0 +1,365 @@
+package server
+
+import (
+	"archive/zip"
+	"context"
+	"io"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/cockroachdb/errors"
+	"github.com/inconshreveable/log15"
+
+	"github.com/sourcegraph/sourcegraph/internal/conf/reposource"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/gomodules/proxy"
+	"github.com/sourcegraph/sourcegraph/internal/httpcli"
+	"github.com/sourcegraph/sourcegraph/internal/vcs"
+	"github.com/sourcegraph/sourcegraph/schema"
+)
+
+// sourcegraphGoModuleAuthor is used to set GIT_AUTHOR_NAME for git commands
+// that don't create commits or tags.
+const sourcegraphGoModuleAuthor = "sourcegraph authors"
+
+type GoModulesSyncer struct {
+	Config *schema.GoModulesConnection
+	Client *proxy.Client
+}
+
+var _ VCSSyncer = &GoModulesSyncer{}
+
+// NewGoModulesSyncer returns a syncer for the Go modules of the given
+// connection. If a nil doer is provided, httpcli.ExternalDoer will be used.
+func NewGoModulesSyncer(config *schema.GoModulesConnection, doer httpcli.Doer) (*GoModulesSyncer, error) {
+	client, err := proxy.NewClient(config, doer)
+	if err != nil {
+		return nil, err
+	}
+	return &GoModulesSyncer{
+		Config: config,
+		Client: client,
+	}, nil
+}
+
+func (s *GoModulesSyncer) Type() string {
+	return "go_modules"
+}
+
+// IsCloneable checks to see if the VCS remote URL is cloneable. Any non-nil
+// error indicates there is a problem.
+func (s *GoModulesSyncer) IsCloneable(ctx context.Context, remoteURL *vcs.URL) error {
+	dependencies, err := s.moduleDependencies(ctx, remoteURL.Path)
+	if err != nil {
+		return err
+	}
+
+	for _, dependency := range dependencies {
+		exists, err := s.Client.Exists(ctx, dependency)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return errors.Errorf("Go dependency %s does not exist", dependency.PackageManagerSyntax())
+		}
+	}
+	return nil
+}
+
+// CloneCommand returns the command to be executed for cloning from remote.
+// Like for JVM packages, the actual cloning happens inside this method and the
+// returned command is a no-op.
+func (s *GoModulesSyncer) CloneCommand(ctx context.Context, remoteURL *vcs.URL, bareGitDirectory string) (*exec.Cmd, error) {
+	err := os.MkdirAll(bareGitDirectory, 0755)
+	if err != nil {
+		return nil, err
+	}
+
+	cmd := exec.CommandContext(ctx, "git", "--bare", "init")
+	if _, err := runCommandInDirectoryAs(ctx, cmd, bareGitDirectory, sourcegraphGoModuleAuthor); err != nil {
+		return nil, err
+	}
+
+	// The Fetch method is responsible for cleaning up temporary directories.
+	if err := s.Fetch(ctx, remoteURL, GitDir(bareGitDirectory)); err != nil {
+		return nil, err
+	}
+
+	// no-op command to satisfy VCSSyncer interface, see docstring for more details.
+	return exec.CommandContext(ctx, "git", "--version"), nil
+}
+
+// Fetch adds git tags for newly added dependency versions and removes git tags
+// for deleted versions.
+func (s *GoModulesSyncer) Fetch(ctx context.Context, remoteURL *vcs.URL, dir GitDir) error {
+	dependencies, err := s.moduleDependencies(ctx, remoteURL.Path)
+	if err != nil {
+		return err
+	}
+
+	tags := map[string]bool{}
+
+	out, err := runCommandInDirectoryAs(ctx, exec.CommandContext(ctx, "git", "tag"), string(dir), sourcegraphGoModuleAuthor)
+	if err != nil {
+		return err
+	}
+
+	for _, line := range strings.Split(out, "\n") {
+		if len(line) == 0 {
+			continue
+		}
+		tags[line] = true
+	}
+
+	for i, dependency := range dependencies {
+		if tags[dependency.GitTagFromVersion()] {
+			continue
+		}
+		// the gitPushDependencyTag method is reponsible for cleaning up temporary directories.
+		if err := s.gitPushDependencyTag(ctx, string(dir), dependency, i == 0); err != nil {
+			return errors.Wrapf(err, "error pushing dependency %q", dependency.PackageManagerSyntax())
+		}
+	}
+
+	dependencyTags := make(map[string]struct{}, len(dependencies))
+	for _, dependency := range dependencies {
+		dependencyTags[dependency.GitTagFromVersion()] = struct{}{}
+	}
+
+	for tag := range tags {
+		if _, isDependencyTag := dependencyTags[tag]; !isDependencyTag {
+			cmd := exec.CommandContext(ctx, "git", "tag", "-d", tag)
+			if _, err := runCommandInDirectoryAs(ctx, cmd, string(dir), sourcegraphGoModuleAuthor); err != nil {
+				log15.Error("Failed to delete git tag", "error", err, "tag", tag)
+				continue
+			}
+		}
+	}
+
+	return nil
+}
+
+// RemoteShowCommand returns the command to be executed for showing remote.
+func (s *GoModulesSyncer) RemoteShowCommand(ctx context.Context, remoteURL *vcs.URL) (cmd *exec.Cmd, err error) {
+	return exec.CommandContext(ctx, "git", "remote", "show", "./"), nil
+}
+
+// moduleDependencies returns the list of Go dependencies that belong to the
+// given URL path, sorted by semantic versioning. A URL maps to a single Go
+// module, which may contain multiple versions (one git tag per version). A
+// configured module without a version expands to all the versions listed by
+// the module proxies.
+func (s *GoModulesSyncer) moduleDependencies(ctx context.Context, repoURLPath string) (dependencies []reposource.GoDependency, err error) {
+	mod, err := reposource.ParseGoModuleFromRepoURL(repoURLPath)
+	if err != nil {
+		return nil, err
+	}
+
+	isAdded := map[string]bool{}
+	add := func(dependency reposource.GoDependency) {
+		if !isAdded[dependency.Version] {
+			dependencies = append(dependencies, dependency)
+		}
+		isAdded[dependency.Version] = true
+	}
+
+	for _, dependency := range s.Config.Dependencies {
+		if !mod.MatchesDependencyString(dependency) {
+			continue
+		}
+		dependency, err := reposource.ParseGoDependency(dependency)
+		if err != nil {
+			return nil, err
+		}
+
+		if dependency.Version != "" {
+			add(dependency)
+			continue
+		}
+
+		versions, err := s.Client.ListVersions(ctx, mod)
+		if err != nil {
+			return nil, err
+		}
+		for _, version := range versions {
+			add(reposource.GoDependency{GoModule: mod, Version: version})
+		}
+	}
+
+	if len(dependencies) == 0 {
+		return nil, errors.Errorf("no Go dependencies for URL path %s", repoURLPath)
+	}
+
+	reposource.SortGoDependencies(dependencies)
+	return dependencies, nil
+}
+
+// gitPushDependencyTag pushes a git tag to the given bareGitDirectory path. The
+// tag points to a commit that adds all the files of the module zip of the
+// given dependency. When isLatestVersion is true, the "latest" branch of the
+// bare git directory will also be updated to point to the same commit as the
+// git tag.
+func (s *GoModulesSyncer) gitPushDependencyTag(ctx context.Context, bareGitDirectory string, dependency reposource.GoDependency, isLatestVersion bool) error {
+	tmpDirectory, err := os.MkdirTemp("", "gomod")
+	if err != nil {
+		return err
+	}
+	// Always clean up created temporary directories.
+	defer os.RemoveAll(tmpDirectory)
+
+	// Zip archives need random access, so the module zip is downloaded next
+	// to the working directory first.
+	zipPath := filepath.Join(tmpDirectory, "module.zip")
+	if err := s.downloadZip(ctx, dependency, zipPath); err != nil {
+		return err
+	}
+
+	workingDirectory := filepath.Join(tmpDirectory, "workdir")
+	if err := os.Mkdir(workingDirectory, 0700); err != nil {
+		return err
+	}
+
+	gitName := dependency.Path + " authors"
+
+	cmd := exec.CommandContext(ctx, "git", "init")
+	if _, err := runCommandInDirectoryAs(ctx, cmd, workingDirectory, gitName); err != nil {
+		return err
+	}
+
+	if err := s.commitZip(ctx, dependency, workingDirectory, zipPath); err != nil {
+		return err
+	}
+
+	cmd = exec.CommandContext(ctx, "git", "remote", "add", "origin", bareGitDirectory)
+	if _, err := runCommandInDirectoryAs(ctx, cmd, workingDirectory, gitName); err != nil {
+		return err
+	}
+
+	// Use --no-verify for security reasons. See https://github.com/sourcegraph/sourcegraph/pull/23399
+	cmd = exec.CommandContext(ctx, "git", "push", "--no-verify", "--force", "origin", "--tags")
+	if _, err := runCommandInDirectoryAs(ctx, cmd, workingDirectory, gitName); err != nil {
+		return err
+	}
+
+	if isLatestVersion {
+		defaultBranch, err := runCommandInDirectoryAs(ctx, exec.CommandContext(ctx, "git", "rev-parse", "--abbrev-ref", "HEAD"), workingDirectory, gitName)
+		if err != nil {
+			return err
+		}
+		// Use --no-verify for security reasons. See https://github.com/sourcegraph/sourcegraph/pull/23399
+		cmd = exec.CommandContext(ctx, "git", "push", "--no-verify", "--force", "origin", strings.TrimSpace(defaultBranch)+":latest", dependency.GitTagFromVersion())
+		if _, err := runCommandInDirectoryAs(ctx, cmd, workingDirectory, gitName); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *GoModulesSyncer) downloadZip(ctx context.Context, dependency reposource.GoDependency, zipPath string) (err error) {
+	body, err := s.Client.GetZip(ctx, dependency)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+
+	f, err := os.Create(zipPath)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err1 := f.Close()
+		if err == nil {
+			err = err1
+		}
+	}()
+
+	_, err = io.Copy(f, body)
+	return err
+}
+
+// commitZip creates a git commit in the given working directory that adds all
+// the files of the given module zip, and tags it with the version of the
+// dependency.
+func (s *GoModulesSyncer) commitZip(ctx context.Context, dependency reposource.GoDependency, workingDirectory, zipPath string) error {
+	if err := unzipGoModule(zipPath, dependency.Path+"@"+dependency.Version+"/", workingDirectory); err != nil {
+		return errors.Wrapf(err, "failed to unzip module %s", dependency.PackageManagerSyntax())
+	}
+
+	// Modules that predate Go modules have no go.mod file in their zip, but
+	// the proxy synthesizes one. Precise code intelligence indexing relies on
+	// it to recognize the repository as a Go module.
+	goModPath := filepath.Join(workingDirectory, "go.mod")
+	if _, err := os.Stat(goModPath); os.IsNotExist(err) {
+		mod, err := s.Client.GetMod(ctx, dependency)
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(goModPath, mod, 0600); err != nil {
+			return err
+		}
+	}
+
+	gitName := dependency.Path + " authors"
+
+	cmd := exec.CommandContext(ctx, "git", "add", ".")
+	if _, err := runCommandInDirectoryAs(ctx, cmd, workingDirectory, gitName); err != nil {
+		return err
+	}
+
+	// Use --no-verify for security reasons. See https://github.com/sourcegraph/sourcegraph/pull/23399
+	cmd = exec.CommandContext(ctx, "git", "commit", "--no-verify", "--allow-empty", "-m", dependency.PackageManagerSyntax(), "--date", stableGitCommitDate)
+	if _, err := runCommandInDirectoryAs(ctx, cmd, workingDirectory, gitName); err != nil {
+		return err
+	}
+
+	cmd = exec.CommandContext(ctx, "git", "tag", "-m", dependency.PackageManagerSyntax(), dependency.GitTagFromVersion())
+	if _, err := runCommandInDirectoryAs(ctx, cmd, workingDirectory, gitName); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// unzipGoModule extracts the files of the given module zip into the
+// destination directory. All the files of a module zip are nested under a
+// "module@version/" prefix, which is stripped. Files outside of it are
+// skipped.
+//
+// See https://golang.org/ref/mod#zip-files
+func unzipGoModule(zipPath, prefix, destination string) error {
+	reader, err := zip.OpenReader(zipPath)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+	destinationDirectory := strings.TrimSuffix(destination, string(os.PathSeparator)) + string(os.PathSeparator)
+
+	for _, file := range reader.File {
+		if !strings.HasPrefix(file.Name, prefix) || strings.HasSuffix(file.Name, "/") {
+			continue
+		}
+		name := path.Clean(strings.TrimPrefix(file.Name, prefix))
+
+		if isUnderGitDirectory(name) {
+			// For security reasons, don't unzip files under `.git/`
+			// directories. See https://github.com/sourcegraph/security-issues/issues/163
+			continue
+		}
+
+		outputPath := filepath.Join(destination, filepath.FromSlash(name))
+		if !strings.HasPrefix(outputPath, destinationDirectory) {
+			// For security reasons, skip file if it's not a child
+			// of the target directory. See "Zip Slip Vulnerability".
+			continue
+		}
+
+		if err := copyZipFileEntry(file, outputPath); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
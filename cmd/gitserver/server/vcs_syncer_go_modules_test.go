@@ -0,0 +1,204 @@
+package server
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"os/exec"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/sourcegraph/sourcegraph/internal/vcs"
+	"github.com/sourcegraph/sourcegraph/schema"
+)
+
+const (
+	exampleGoModule        = "example.com/Example"
+	exampleGoModuleURL     = "go/example.com/Example"
+	exampleGoFilePath      = "example.go"
+	exampleGoFileContents  = "package example\n\nconst Version = 1\n"
+	exampleGoFileContents2 = "package example\n\nconst Version = 2\n"
+)
+
+type zipEntry struct {
+	name     string
+	contents string
+}
+
+func createZip(t *testing.T, entries []zipEntry) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zipWriter := zip.NewWriter(&buf)
+	for _, e := range entries {
+		w, err := zipWriter.Create(e.name)
+		assert.Nil(t, err)
+		_, err = w.Write([]byte(e.contents))
+		assert.Nil(t, err)
+	}
+	assert.Nil(t, zipWriter.Close())
+	return buf.Bytes()
+}
+
+// newFakeGoModuleProxy returns a module proxy serving the given zips, keyed by
+// version, for the example.com/Example module.
+func newFakeGoModuleProxy(t *testing.T, zips map[string][]byte) *httptest.Server {
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		p := strings.TrimPrefix(r.URL.EscapedPath(), "/example.com/!example/@v/")
+		if p == "list" {
+			for version := range zips {
+				fmt.Fprintln(w, version)
+			}
+			return
+		}
+		for _, ext := range []string{".info", ".mod", ".zip"} {
+			version := strings.TrimSuffix(p, ext)
+			if zips[version] == nil || !strings.HasSuffix(p, ext) {
+				continue
+			}
+			switch ext {
+			case ".info":
+				fmt.Fprintf(w, `{"Version":%q}`, version)
+			case ".mod":
+				fmt.Fprintf(w, "module %s\n", exampleGoModule)
+			case ".zip":
+				w.Write(zips[version])
+			}
+			return
+		}
+		http.NotFound(w, r)
+	}))
+	t.Cleanup(proxy.Close)
+	return proxy
+}
+
+func (s *GoModulesSyncer) runCloneCommand(t *testing.T, bareGitDirectory string, dependencies []string) {
+	t.Helper()
+	url := vcs.URL{
+		URL: url.URL{Path: exampleGoModuleURL},
+	}
+	s.Config.Dependencies = dependencies
+	cmd, err := s.CloneCommand(context.Background(), &url, bareGitDirectory)
+	assert.Nil(t, err)
+	assert.Nil(t, cmd.Run())
+}
+
+func TestGoModulesCloneCommand(t *testing.T) {
+	dir, err := os.MkdirTemp("", "")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	proxy := newFakeGoModuleProxy(t, map[string][]byte{
+		"v1.0.0": createZip(t, []zipEntry{
+			{name: exampleGoModule + "@v1.0.0/" + exampleGoFilePath, contents: exampleGoFileContents},
+		}),
+		"v1.1.0": createZip(t, []zipEntry{
+			{name: exampleGoModule + "@v1.1.0/go.mod", contents: "module " + exampleGoModule + "\n\ngo 1.17\n"},
+			{name: exampleGoModule + "@v1.1.0/" + exampleGoFilePath, contents: exampleGoFileContents2},
+		}),
+	})
+
+	s, err := NewGoModulesSyncer(&schema.GoModulesConnection{Urls: []string{proxy.URL}}, http.DefaultClient)
+	assert.Nil(t, err)
+	bareGitDirectory := path.Join(dir, "git")
+
+	s.runCloneCommand(t, bareGitDirectory, []string{exampleGoModule + "@v1.0.0"})
+	assertCommandOutput(t,
+		exec.Command("git", "tag", "--list"),
+		bareGitDirectory,
+		"v1.0.0\n",
+	)
+	assertCommandOutput(t,
+		exec.Command("git", "show", "v1.0.0:"+exampleGoFilePath),
+		bareGitDirectory,
+		exampleGoFileContents,
+	)
+	assertCommandOutput(t,
+		exec.Command("git", "show", "v1.0.0:go.mod"),
+		bareGitDirectory,
+		"module "+exampleGoModule+"\n", // verify that the go.mod file of the proxy got added
+	)
+
+	s.runCloneCommand(t, bareGitDirectory, []string{exampleGoModule})
+	assertCommandOutput(t,
+		exec.Command("git", "tag", "--list"),
+		bareGitDirectory,
+		"v1.0.0\nv1.1.0\n", // verify that all the listed versions got added
+	)
+	assertCommandOutput(t,
+		exec.Command("git", "show", "latest:"+exampleGoFilePath),
+		bareGitDirectory,
+		exampleGoFileContents2, // verify that the latest branch points at the newest version
+	)
+	assertCommandOutput(t,
+		exec.Command("git", "show", "latest:go.mod"),
+		bareGitDirectory,
+		"module "+exampleGoModule+"\n\ngo 1.17\n",
+	)
+
+	s.runCloneCommand(t, bareGitDirectory, []string{exampleGoModule + "@v1.1.0"})
+	assertCommandOutput(t,
+		exec.Command("git", "tag", "--list"),
+		bareGitDirectory,
+		"v1.1.0\n", // verify that the v1.0.0 tag has been removed.
+	)
+}
+
+func TestGoModulesCloneCommand_NotFound(t *testing.T) {
+	dir, err := os.MkdirTemp("", "")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	proxy := newFakeGoModuleProxy(t, map[string][]byte{})
+	s, err := NewGoModulesSyncer(&schema.GoModulesConnection{
+		Urls:         []string{proxy.URL},
+		Dependencies: []string{exampleGoModule + "@v1.0.0"},
+	}, http.DefaultClient)
+	assert.Nil(t, err)
+
+	url := vcs.URL{URL: url.URL{Path: exampleGoModuleURL}}
+	assert.NotNil(t, s.IsCloneable(context.Background(), &url))
+	_, err = s.CloneCommand(context.Background(), &url, path.Join(dir, "git"))
+	assert.NotNil(t, err)
+}
+
+func TestUnzipGoModuleNoMaliciousFiles(t *testing.T) {
+	dir, err := os.MkdirTemp("", "")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	const prefix = "example.com/m@v1.0.0/"
+	zipPath := path.Join(dir, "module.zip")
+	assert.Nil(t, os.WriteFile(zipPath, createZip(t, []zipEntry{
+		{name: prefix + "../../../burger", contents: "malicious"},
+		{name: prefix + ".git/config", contents: "malicious"},
+		{name: prefix + "sub/.git/config", contents: "malicious"},
+		{name: "example.com/other@v1.0.0/other.go", contents: "other module"},
+		{name: prefix + "sample/burger", contents: "fine"},
+		{name: prefix + "m.go", contents: "fine"},
+	}), 0600))
+
+	extractPath := path.Join(dir, "extracted")
+	assert.Nil(t, os.Mkdir(extractPath, os.ModePerm))
+	assert.Nil(t, unzipGoModule(zipPath, prefix, extractPath))
+
+	var files []string
+	for _, p := range []string{extractPath, path.Join(extractPath, "sample")} {
+		entries, err := os.ReadDir(p)
+		assert.Nil(t, err)
+		for _, e := range entries {
+			files = append(files, strings.TrimPrefix(path.Join(p, e.Name()), extractPath+"/"))
+		}
+	}
+	assert.Equal(t, []string{"m.go", "sample", "sample/burger"}, files)
+
+	_, err = os.Stat(path.Join(dir, "burger"))
+	assert.True(t, os.IsNotExist(err))
+}
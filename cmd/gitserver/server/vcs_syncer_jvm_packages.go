@@ -473,7 +473,13 @@ func roundJVMVersionToNearestStableVersion(javaVersion int) int {
 }
 
 func runCommandInDirectory(ctx context.Context, cmd *exec.Cmd, workingDirectory string, dependency reposource.MavenDependency) (string, error) {
-	gitName := dependency.MavenModule.CoursierSyntax() + " authors"
+	return runCommandInDirectoryAs(ctx, cmd, workingDirectory, dependency.MavenModule.CoursierSyntax()+" authors")
+}
+
+// runCommandInDirectoryAs runs the given git command in the working directory
+// with a stable author, committer and date, so that package repositories
+// consistently produce the same git revhashes.
+func runCommandInDirectoryAs(ctx context.Context, cmd *exec.Cmd, workingDirectory, gitName string) (string, error) {
 	gitEmail := "code-intel@sourcegraph.com"
 	cmd.Dir = workingDirectory
 	cmd.Env = append(cmd.Env, "EMAIL="+gitEmail)
@@ -0,0 +1,339 @@
+package server
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"io"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/cockroachdb/errors"
+	"github.com/inconshreveable/log15"
+
+	"github.com/sourcegraph/sourcegraph/internal/conf/reposource"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/npmpackages/npm"
+	"github.com/sourcegraph/sourcegraph/internal/httpcli"
+	"github.com/sourcegraph/sourcegraph/internal/vcs"
+	"github.com/sourcegraph/sourcegraph/schema"
+)
+
+// sourcegraphNPMPackageAuthor is used to set GIT_AUTHOR_NAME for git commands
+// that don't create commits or tags.
+const sourcegraphNPMPackageAuthor = "sourcegraph authors"
+
+type NPMPackagesSyncer struct {
+	Config *schema.NPMPackagesConnection
+	Client *npm.Client
+}
+
+var _ VCSSyncer = &NPMPackagesSyncer{}
+
+// NewNPMPackagesSyncer returns a syncer for the npm packages of the given
+// connection. If a nil doer is provided, httpcli.ExternalDoer will be used.
+func NewNPMPackagesSyncer(config *schema.NPMPackagesConnection, doer httpcli.Doer) *NPMPackagesSyncer {
+	return &NPMPackagesSyncer{
+		Config: config,
+		Client: npm.NewClient(config, doer),
+	}
+}
+
+func (s *NPMPackagesSyncer) Type() string {
+	return "npm_packages"
+}
+
+// IsCloneable checks to see if the VCS remote URL is cloneable. Any non-nil
+// error indicates there is a problem.
+func (s *NPMPackagesSyncer) IsCloneable(ctx context.Context, remoteURL *vcs.URL) error {
+	dependencies, err := s.packageDependencies(remoteURL.Path)
+	if err != nil {
+		return err
+	}
+
+	for _, dependency := range dependencies {
+		exists, err := s.Client.Exists(ctx, dependency)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return errors.Errorf("npm dependency %s does not exist", dependency.PackageManagerSyntax())
+		}
+	}
+	return nil
+}
+
+// CloneCommand returns the command to be executed for cloning from remote.
+// Like for JVM packages, the actual cloning happens inside this method and the
+// returned command is a no-op.
+func (s *NPMPackagesSyncer) CloneCommand(ctx context.Context, remoteURL *vcs.URL, bareGitDirectory string) (*exec.Cmd, error) {
+	err := os.MkdirAll(bareGitDirectory, 0755)
+	if err != nil {
+		return nil, err
+	}
+
+	cmd := exec.CommandContext(ctx, "git", "--bare", "init")
+	if _, err := runCommandInDirectoryAs(ctx, cmd, bareGitDirectory, sourcegraphNPMPackageAuthor); err != nil {
+		return nil, err
+	}
+
+	// The Fetch method is responsible for cleaning up temporary directories.
+	if err := s.Fetch(ctx, remoteURL, GitDir(bareGitDirectory)); err != nil {
+		return nil, err
+	}
+
+	// no-op command to satisfy VCSSyncer interface, see docstring for more details.
+	return exec.CommandContext(ctx, "git", "--version"), nil
+}
+
+// Fetch adds git tags for newly added dependency versions and removes git tags
+// for deleted versions.
+func (s *NPMPackagesSyncer) Fetch(ctx context.Context, remoteURL *vcs.URL, dir GitDir) error {
+	dependencies, err := s.packageDependencies(remoteURL.Path)
+	if err != nil {
+		return err
+	}
+
+	tags := map[string]bool{}
+
+	out, err := runCommandInDirectoryAs(ctx, exec.CommandContext(ctx, "git", "tag"), string(dir), sourcegraphNPMPackageAuthor)
+	if err != nil {
+		return err
+	}
+
+	for _, line := range strings.Split(out, "\n") {
+		if len(line) == 0 {
+			continue
+		}
+		tags[line] = true
+	}
+
+	for i, dependency := range dependencies {
+		if tags[dependency.GitTagFromVersion()] {
+			continue
+		}
+		// the gitPushDependencyTag method is reponsible for cleaning up temporary directories.
+		if err := s.gitPushDependencyTag(ctx, string(dir), dependency, i == 0); err != nil {
+			return errors.Wrapf(err, "error pushing dependency %q", dependency.PackageManagerSyntax())
+		}
+	}
+
+	dependencyTags := make(map[string]struct{}, len(dependencies))
+	for _, dependency := range dependencies {
+		dependencyTags[dependency.GitTagFromVersion()] = struct{}{}
+	}
+
+	for tag := range tags {
+		if _, isDependencyTag := dependencyTags[tag]; !isDependencyTag {
+			cmd := exec.CommandContext(ctx, "git", "tag", "-d", tag)
+			if _, err := runCommandInDirectoryAs(ctx, cmd, string(dir), sourcegraphNPMPackageAuthor); err != nil {
+				log15.Error("Failed to delete git tag", "error", err, "tag", tag)
+				continue
+			}
+		}
+	}
+
+	return nil
+}
+
+// RemoteShowCommand returns the command to be executed for showing remote.
+func (s *NPMPackagesSyncer) RemoteShowCommand(ctx context.Context, remoteURL *vcs.URL) (cmd *exec.Cmd, err error) {
+	return exec.CommandContext(ctx, "git", "remote", "show", "./"), nil
+}
+
+// packageDependencies returns the list of npm dependencies that belong to the
+// given URL path, sorted by semantic versioning. A URL maps to a single npm
+// package, which may contain multiple versions (one git tag per version).
+func (s *NPMPackagesSyncer) packageDependencies(repoURLPath string) (dependencies []reposource.NPMDependency, err error) {
+	pkg, err := reposource.ParseNPMPackageFromRepoURL(repoURLPath)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, dependency := range s.Config.Dependencies {
+		if !pkg.MatchesDependencyString(dependency) {
+			continue
+		}
+		dependency, err := reposource.ParseNPMDependency(dependency)
+		if err != nil {
+			return nil, err
+		}
+		dependencies = append(dependencies, dependency)
+	}
+
+	if len(dependencies) == 0 {
+		return nil, errors.Errorf("no npm dependencies for URL path %s", repoURLPath)
+	}
+
+	reposource.SortNPMDependencies(dependencies)
+	return dependencies, nil
+}
+
+// gitPushDependencyTag pushes a git tag to the given bareGitDirectory path. The
+// tag points to a commit that adds all the files of the package tarball of the
+// given dependency. When isLatestVersion is true, the "latest" branch of the
+// bare git directory will also be updated to point to the same commit as the
+// git tag.
+func (s *NPMPackagesSyncer) gitPushDependencyTag(ctx context.Context, bareGitDirectory string, dependency reposource.NPMDependency, isLatestVersion bool) error {
+	tmpDirectory, err := os.MkdirTemp("", "npm")
+	if err != nil {
+		return err
+	}
+	// Always clean up created temporary directories.
+	defer os.RemoveAll(tmpDirectory)
+
+	tarball, err := s.Client.FetchTarball(ctx, dependency)
+	if err != nil {
+		return err
+	}
+	defer tarball.Close()
+
+	gitName := dependency.PackageSyntax() + " authors"
+
+	cmd := exec.CommandContext(ctx, "git", "init")
+	if _, err := runCommandInDirectoryAs(ctx, cmd, tmpDirectory, gitName); err != nil {
+		return err
+	}
+
+	if err := s.commitTarball(ctx, dependency, tmpDirectory, tarball); err != nil {
+		return err
+	}
+
+	cmd = exec.CommandContext(ctx, "git", "remote", "add", "origin", bareGitDirectory)
+	if _, err := runCommandInDirectoryAs(ctx, cmd, tmpDirectory, gitName); err != nil {
+		return err
+	}
+
+	// Use --no-verify for security reasons. See https://github.com/sourcegraph/sourcegraph/pull/23399
+	cmd = exec.CommandContext(ctx, "git", "push", "--no-verify", "--force", "origin", "--tags")
+	if _, err := runCommandInDirectoryAs(ctx, cmd, tmpDirectory, gitName); err != nil {
+		return err
+	}
+
+	if isLatestVersion {
+		defaultBranch, err := runCommandInDirectoryAs(ctx, exec.CommandContext(ctx, "git", "rev-parse", "--abbrev-ref", "HEAD"), tmpDirectory, gitName)
+		if err != nil {
+			return err
+		}
+		// Use --no-verify for security reasons. See https://github.com/sourcegraph/sourcegraph/pull/23399
+		cmd = exec.CommandContext(ctx, "git", "push", "--no-verify", "--force", "origin", strings.TrimSpace(defaultBranch)+":latest", dependency.GitTagFromVersion())
+		if _, err := runCommandInDirectoryAs(ctx, cmd, tmpDirectory, gitName); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// commitTarball creates a git commit in the given working directory that adds
+// all the files of the given gzipped package tarball, and tags it with the
+// version of the dependency.
+func (s *NPMPackagesSyncer) commitTarball(ctx context.Context, dependency reposource.NPMDependency, workingDirectory string, tarball io.Reader) error {
+	if err := decompressTgz(tarball, workingDirectory); err != nil {
+		return errors.Wrapf(err, "failed to decompress tarball of %s", dependency.PackageManagerSyntax())
+	}
+
+	gitName := dependency.PackageSyntax() + " authors"
+
+	cmd := exec.CommandContext(ctx, "git", "add", ".")
+	if _, err := runCommandInDirectoryAs(ctx, cmd, workingDirectory, gitName); err != nil {
+		return err
+	}
+
+	// Use --no-verify for security reasons. See https://github.com/sourcegraph/sourcegraph/pull/23399
+	cmd = exec.CommandContext(ctx, "git", "commit", "--no-verify", "--allow-empty", "-m", dependency.PackageManagerSyntax(), "--date", stableGitCommitDate)
+	if _, err := runCommandInDirectoryAs(ctx, cmd, workingDirectory, gitName); err != nil {
+		return err
+	}
+
+	cmd = exec.CommandContext(ctx, "git", "tag", "-m", dependency.PackageManagerSyntax(), dependency.GitTagFromVersion())
+	if _, err := runCommandInDirectoryAs(ctx, cmd, workingDirectory, gitName); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// decompressTgz extracts the regular files of the given gzipped npm package
+// tarball into the destination directory. npm nests the package contents in a
+// top-level directory (usually "package/"), which is stripped.
+func decompressTgz(tgz io.Reader, destination string) error {
+	gzipReader, err := gzip.NewReader(tgz)
+	if err != nil {
+		return err
+	}
+	defer gzipReader.Close()
+
+	destinationDirectory := strings.TrimSuffix(destination, string(os.PathSeparator)) + string(os.PathSeparator)
+
+	tarReader := tar.NewReader(gzipReader)
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if header.Typeflag != tar.TypeReg {
+			// Skip directories, and for security reasons symlinks and
+			// hard links, which could point outside of the destination.
+			continue
+		}
+
+		name := strings.TrimPrefix(header.Name, "/")
+		i := strings.Index(name, "/")
+		if i < 0 {
+			continue
+		}
+		name = path.Clean(name[i+1:])
+
+		if isUnderGitDirectory(name) {
+			// For security reasons, don't extract files under `.git/`
+			// directories. See https://github.com/sourcegraph/security-issues/issues/163
+			continue
+		}
+
+		outputPath := filepath.Join(destination, filepath.FromSlash(name))
+		if !strings.HasPrefix(outputPath, destinationDirectory) {
+			// For security reasons, skip file if it's not a child
+			// of the target directory. See "Zip Slip Vulnerability".
+			continue
+		}
+
+		if err := copyTarFileEntry(tarReader, outputPath); err != nil {
+			return err
+		}
+	}
+}
+
+func isUnderGitDirectory(name string) bool {
+	for _, component := range strings.Split(name, "/") {
+		if component == ".git" {
+			return true
+		}
+	}
+	return false
+}
+
+func copyTarFileEntry(r io.Reader, outputPath string) (err error) {
+	if err = os.MkdirAll(filepath.Dir(outputPath), 0700); err != nil {
+		return err
+	}
+	outputFile, err := os.OpenFile(outputPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err1 := outputFile.Close()
+		if err == nil {
+			err = err1
+		}
+	}()
+
+	_, err = io.Copy(outputFile, r)
+	return err
+}
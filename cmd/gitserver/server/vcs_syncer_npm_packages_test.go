@@ -0,0 +1,198 @@
+package server
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"os/exec"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/sourcegraph/sourcegraph/internal/vcs"
+	"github.com/sourcegraph/sourcegraph/schema"
+)
+
+const (
+	exampleNPMFilePath      = "index.js"
+	exampleNPMFileContents  = "module.exports = 1;\n"
+	exampleNPMFileContents2 = "module.exports = 2;\n"
+	exampleNPMPackageURL    = "npm/example/example"
+)
+
+type tarEntry struct {
+	name     string
+	contents string
+	typeflag byte
+}
+
+func createTgz(t *testing.T, entries []tarEntry) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gzipWriter := gzip.NewWriter(&buf)
+	tarWriter := tar.NewWriter(gzipWriter)
+	for _, e := range entries {
+		typeflag := e.typeflag
+		if typeflag == 0 {
+			typeflag = tar.TypeReg
+		}
+		header := &tar.Header{
+			Name:     e.name,
+			Typeflag: typeflag,
+			Mode:     0644,
+			Size:     int64(len(e.contents)),
+		}
+		if typeflag == tar.TypeSymlink {
+			header.Linkname = e.contents
+			header.Size = 0
+		}
+		assert.Nil(t, tarWriter.WriteHeader(header))
+		if typeflag == tar.TypeReg {
+			_, err := tarWriter.Write([]byte(e.contents))
+			assert.Nil(t, err)
+		}
+	}
+	assert.Nil(t, tarWriter.Close())
+	assert.Nil(t, gzipWriter.Close())
+	return buf.Bytes()
+}
+
+// newFakeNPMRegistry returns a registry serving the given tarballs, keyed by
+// version, for the @example/example package.
+func newFakeNPMRegistry(t *testing.T, tarballs map[string][]byte) *httptest.Server {
+	var registry *httptest.Server
+	registry = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		parts := strings.Split(strings.TrimPrefix(r.URL.EscapedPath(), "/"), "/")
+		switch {
+		case len(parts) == 2 && parts[0] == "@example%2Fexample" && tarballs[parts[1]] != nil:
+			fmt.Fprintf(w, `{"name":"@example/example","version":%q,"dist":{"tarball":"%s/tarballs/%s.tgz"}}`, parts[1], registry.URL, parts[1])
+		case len(parts) == 2 && parts[0] == "tarballs" && tarballs[strings.TrimSuffix(parts[1], ".tgz")] != nil:
+			w.Write(tarballs[strings.TrimSuffix(parts[1], ".tgz")])
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(registry.Close)
+	return registry
+}
+
+func (s *NPMPackagesSyncer) runCloneCommand(t *testing.T, bareGitDirectory string, dependencies []string) {
+	t.Helper()
+	url := vcs.URL{
+		URL: url.URL{Path: exampleNPMPackageURL},
+	}
+	s.Config.Dependencies = dependencies
+	cmd, err := s.CloneCommand(context.Background(), &url, bareGitDirectory)
+	assert.Nil(t, err)
+	assert.Nil(t, cmd.Run())
+}
+
+func TestNPMCloneCommand(t *testing.T) {
+	dir, err := os.MkdirTemp("", "")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	registry := newFakeNPMRegistry(t, map[string][]byte{
+		"1.0.0": createTgz(t, []tarEntry{{name: "package/" + exampleNPMFilePath, contents: exampleNPMFileContents}}),
+		"2.0.0": createTgz(t, []tarEntry{{name: "package/" + exampleNPMFilePath, contents: exampleNPMFileContents2}}),
+	})
+
+	s := NewNPMPackagesSyncer(&schema.NPMPackagesConnection{Registry: registry.URL}, http.DefaultClient)
+	bareGitDirectory := path.Join(dir, "git")
+
+	s.runCloneCommand(t, bareGitDirectory, []string{"@example/example@1.0.0"})
+	assertCommandOutput(t,
+		exec.Command("git", "tag", "--list"),
+		bareGitDirectory,
+		"v1.0.0\n",
+	)
+	assertCommandOutput(t,
+		exec.Command("git", "show", "v1.0.0:"+exampleNPMFilePath),
+		bareGitDirectory,
+		exampleNPMFileContents,
+	)
+
+	s.runCloneCommand(t, bareGitDirectory, []string{"@example/example@1.0.0", "@example/example@2.0.0"})
+	assertCommandOutput(t,
+		exec.Command("git", "tag", "--list"),
+		bareGitDirectory,
+		"v1.0.0\nv2.0.0\n", // verify that the v2.0.0 tag got added
+	)
+	assertCommandOutput(t,
+		exec.Command("git", "show", "v2.0.0:"+exampleNPMFilePath),
+		bareGitDirectory,
+		exampleNPMFileContents2,
+	)
+	assertCommandOutput(t,
+		exec.Command("git", "show", "latest:"+exampleNPMFilePath),
+		bareGitDirectory,
+		exampleNPMFileContents2, // verify that the latest branch points at the newest version
+	)
+
+	s.runCloneCommand(t, bareGitDirectory, []string{"@example/example@1.0.0"})
+	assertCommandOutput(t,
+		exec.Command("git", "tag", "--list"),
+		bareGitDirectory,
+		"v1.0.0\n", // verify that the v2.0.0 tag has been removed.
+	)
+}
+
+func TestNPMCloneCommand_NotFound(t *testing.T) {
+	dir, err := os.MkdirTemp("", "")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	registry := newFakeNPMRegistry(t, map[string][]byte{})
+	s := NewNPMPackagesSyncer(&schema.NPMPackagesConnection{
+		Registry:     registry.URL,
+		Dependencies: []string{"@example/example@1.0.0"},
+	}, http.DefaultClient)
+
+	url := vcs.URL{URL: url.URL{Path: exampleNPMPackageURL}}
+	assert.NotNil(t, s.IsCloneable(context.Background(), &url))
+	_, err = s.CloneCommand(context.Background(), &url, path.Join(dir, "git"))
+	assert.NotNil(t, err)
+}
+
+func TestDecompressTgzNoMaliciousFiles(t *testing.T) {
+	dir, err := os.MkdirTemp("", "")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	tgz := createTgz(t, []tarEntry{
+		{name: "package/../../burger", contents: "malicious"},
+		{name: "/package/../../../burger", contents: "malicious"},
+		{name: "package/.git/config", contents: "malicious"},
+		{name: "package/sub/.git/config", contents: "malicious"},
+		{name: "package/link", contents: "/etc/passwd", typeflag: tar.TypeSymlink},
+		{name: "package/dir/", typeflag: tar.TypeDir},
+		{name: "toplevel", contents: "no package directory"},
+		{name: "package/sample/burger", contents: "fine"},
+		{name: "package/index.js", contents: "fine"},
+	})
+
+	extractPath := path.Join(dir, "extracted")
+	assert.Nil(t, os.Mkdir(extractPath, os.ModePerm))
+	assert.Nil(t, decompressTgz(bytes.NewReader(tgz), extractPath))
+
+	var files []string
+	for _, p := range []string{extractPath, path.Join(extractPath, "sample")} {
+		entries, err := os.ReadDir(p)
+		assert.Nil(t, err)
+		for _, e := range entries {
+			files = append(files, strings.TrimPrefix(path.Join(p, e.Name()), extractPath+"/"))
+		}
+	}
+	assert.Equal(t, []string{"index.js", "sample", "sample/burger"}, files)
+
+	_, err = os.Stat(path.Join(dir, "burger"))
+	assert.True(t, os.IsNotExist(err))
+}
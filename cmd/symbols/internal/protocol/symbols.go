@@ -2,6 +2,7 @@ package protocol
 
 import (
 	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/search/result"
 )
 
 // SearchArgs are the arguments to perform a search on the symbols service.
@@ -35,6 +36,36 @@ type SearchArgs struct {
 	// need to match to get included in the result
 	ExcludePattern string
 
+	// Kinds is an optional list of symbol kinds as reported by ctags (e.g.
+	// "func" or "struct"). If non-empty, only symbols of one of these kinds
+	// are returned. Kinds are matched case-insensitively.
+	Kinds []string
+
+	// Languages is an optional list of languages as reported by ctags (e.g.
+	// "Go" or "JavaScript"). If non-empty, only symbols in one of these
+	// languages are returned. Languages are matched case-insensitively.
+	Languages []string
+
+	// ContainerPattern is an optional regex that the name of a symbol's
+	// parent (e.g. the class of a method) needs to match to get included in
+	// the result.
+	ContainerPattern string
+
 	// First indicates that only the first n symbols should be returned.
 	First int
+
+	// After is an opaque cursor returned as NextCursor by a previous call to
+	// the paginated search endpoint. If set, only symbols after the cursor are
+	// returned. It is ignored by the non-paginated search endpoint.
+	After string
+}
+
+// SearchPage is a page of results of a paginated symbol search.
+type SearchPage struct {
+	// Symbols are the symbols in this page, in a stable order.
+	Symbols result.Symbols `json:"symbols"`
+
+	// NextCursor is the cursor to pass as SearchArgs.After to get the next
+	// page. It is empty if this is the last page.
+	NextCursor string `json:"nextCursor,omitempty"`
 }
@@ -0,0 +1,232 @@
+package symbols
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+
+	"github.com/cockroachdb/errors"
+	"github.com/inconshreveable/log15"
+	"github.com/jmoiron/sqlx"
+	"github.com/opentracing/opentracing-go/ext"
+	otlog "github.com/opentracing/opentracing-go/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/diskcache"
+	"github.com/sourcegraph/sourcegraph/internal/trace/ot"
+)
+
+// maxAncestorsToSearch is the number of ancestors of a commit that are checked
+// for a cached database which can be updated incrementally.
+const maxAncestorsToSearch = 100
+
+// maxIncrementalPaths is the maximum number of added and modified paths for
+// which a cached database is updated incrementally. Beyond this it is cheaper
+// to parse the whole repository, and the paths would no longer fit in a single
+// archive request to gitserver.
+const maxIncrementalPaths = 1000
+
+// Changes are the paths that changed between two commits.
+type Changes struct {
+	Added    []string
+	Modified []string
+	Deleted  []string
+}
+
+// ParseGitDiffNameStatus parses the output of
+// `git diff -z --name-status --no-renames <commitA> <commitB>`.
+func ParseGitDiffNameStatus(output []byte) (Changes, error) {
+	var changes Changes
+
+	fields := bytes.Split(output, []byte{0})
+	if len(fields) > 0 && len(fields[len(fields)-1]) == 0 {
+		// The output is terminated by a NUL byte.
+		fields = fields[:len(fields)-1]
+	}
+	if len(fields)%2 != 0 {
+		return Changes{}, errors.Errorf("unexpected git diff output: odd number of fields (%d)", len(fields))
+	}
+
+	for i := 0; i < len(fields); i += 2 {
+		status, path := string(fields[i]), string(fields[i+1])
+		if status == "" {
+			return Changes{}, errors.Errorf("unexpected git diff output: empty status for path %q", path)
+		}
+
+		switch status[0] {
+		case 'A':
+			changes.Added = append(changes.Added, path)
+		case 'M', 'T':
+			changes.Modified = append(changes.Modified, path)
+		case 'D':
+			changes.Deleted = append(changes.Deleted, path)
+		default:
+			return Changes{}, errors.Errorf("unexpected git diff status %q for path %q", status, path)
+		}
+	}
+
+	return changes, nil
+}
+
+// writeSymbolsToNewDB writes the symbols of repo@commit to the blank database
+// file `dbFile`. If the database of a nearby ancestor commit is in the disk
+// cache, it is copied and only the paths that changed since that ancestor are
+// parsed. Otherwise the whole repository is parsed.
+func (s *Service) writeSymbolsToNewDB(ctx context.Context, dbFile string, repoName api.RepoName, commitID api.CommitID) error {
+	if s.GitDiff != nil && s.Ancestors != nil {
+		ok, err := s.writeSymbolsIncrementally(ctx, dbFile, repoName, commitID)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			incrementalFailed.Inc()
+			log15.Warn("Failed to incrementally update symbols, parsing all symbols instead", "repo", repoName, "commit", commitID, "error", err)
+		}
+		if ok && err == nil {
+			incrementalUpdates.Inc()
+			return nil
+		}
+
+		// Start over from a blank database file.
+		if err := os.Truncate(dbFile, 0); err != nil {
+			return err
+		}
+	}
+
+	return s.writeAllSymbolsToNewDB(ctx, dbFile, repoName, commitID)
+}
+
+// writeSymbolsIncrementally tries to create the database for repo@commit in
+// `dbFile` from the database of the nearest cached ancestor. It returns false
+// if there is no suitable ancestor.
+func (s *Service) writeSymbolsIncrementally(ctx context.Context, dbFile string, repoName api.RepoName, commitID api.CommitID) (ok bool, err error) {
+	span, ctx := ot.StartSpanFromContext(ctx, "writeSymbolsIncrementally")
+	defer func() {
+		if err != nil {
+			ext.Error.Set(span, true)
+			span.LogFields(otlog.Error(err))
+		}
+		span.Finish()
+	}()
+	span.SetTag("repo", string(repoName))
+	span.SetTag("commit", string(commitID))
+
+	ancestor, ancestorFile, err := s.findCachedAncestor(ctx, repoName, commitID)
+	if err != nil || ancestorFile == nil {
+		return false, err
+	}
+	defer ancestorFile.Close()
+	span.SetTag("ancestor", string(ancestor))
+
+	changes, err := s.GitDiff(ctx, repoName, ancestor, commitID)
+	if err != nil {
+		return false, errors.Wrap(err, "GitDiff")
+	}
+	span.SetTag("added", len(changes.Added))
+	span.SetTag("modified", len(changes.Modified))
+	span.SetTag("deleted", len(changes.Deleted))
+
+	if len(changes.Added)+len(changes.Modified) > maxIncrementalPaths {
+		return false, nil
+	}
+
+	if err := copyFile(ancestorFile.File, dbFile); err != nil {
+		return false, err
+	}
+
+	return true, s.updateSymbols(ctx, dbFile, repoName, commitID, changes)
+}
+
+// findCachedAncestor returns the nearest ancestor of commit whose database is
+// in the disk cache, along with the opened database file. The file is nil if
+// there is no such ancestor.
+func (s *Service) findCachedAncestor(ctx context.Context, repoName api.RepoName, commitID api.CommitID) (api.CommitID, *diskcache.File, error) {
+	ancestors, err := s.Ancestors(ctx, repoName, commitID, maxAncestorsToSearch)
+	if err != nil {
+		return "", nil, errors.Wrap(err, "Ancestors")
+	}
+
+	for _, ancestor := range ancestors {
+		if ancestor == commitID {
+			continue
+		}
+
+		file, err := s.cache.OpenIfExists(dbCacheKey(repoName, ancestor))
+		if err == nil {
+			return ancestor, file, nil
+		}
+		if !os.IsNotExist(err) {
+			return "", nil, err
+		}
+	}
+
+	return "", nil, nil
+}
+
+// updateSymbols updates the database in `dbFile`, which was copied from the
+// database of an ancestor commit, to match repo@commit.
+func (s *Service) updateSymbols(ctx context.Context, dbFile string, repoName api.RepoName, commitID api.CommitID, changes Changes) (err error) {
+	db, err := sqlx.Open("sqlite3_with_pcre", dbFile)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	tx, err := db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+			return
+		}
+		err = tx.Commit()
+	}()
+
+	for _, paths := range [][]string{changes.Modified, changes.Deleted} {
+		for _, path := range paths {
+			if _, err := tx.Exec(`DELETE FROM symbols WHERE path = ?`, path); err != nil {
+				return err
+			}
+		}
+	}
+
+	paths := make([]string, 0, len(changes.Added)+len(changes.Modified))
+	paths = append(paths, changes.Added...)
+	paths = append(paths, changes.Modified...)
+	if len(paths) == 0 {
+		return nil
+	}
+
+	return s.parseAndInsertSymbols(ctx, tx, repoName, commitID, paths)
+}
+
+// copyFile copies the contents of src to the existing file at dstPath.
+func copyFile(src *os.File, dstPath string) error {
+	dst, err := os.OpenFile(dstPath, os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(dst, src); err != nil {
+		dst.Close()
+		return err
+	}
+
+	return dst.Close()
+}
+
+var (
+	incrementalUpdates = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "symbols_store_incremental_updates",
+		Help: "The total number of databases created by updating the database of an ancestor commit.",
+	})
+	incrementalFailed = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "symbols_store_incremental_updates_failed",
+		Help: "The total number of incremental database updates that failed and fell back to parsing all symbols.",
+	})
+)
@@ -0,0 +1,132 @@
+package symbols
+
+import (
+	"context"
+	"io"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/sourcegraph/go-ctags"
+
+	"github.com/sourcegraph/sourcegraph/cmd/symbols/internal/protocol"
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/search/result"
+)
+
+func TestParseGitDiffNameStatus(t *testing.T) {
+	output := []byte("A\x00added.go\x00M\x00modified.go\x00T\x00typechanged\x00D\x00deleted with space.go\x00")
+
+	changes, err := ParseGitDiffNameStatus(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := Changes{
+		Added:    []string{"added.go"},
+		Modified: []string{"modified.go", "typechanged"},
+		Deleted:  []string{"deleted with space.go"},
+	}
+	if !reflect.DeepEqual(changes, want) {
+		t.Errorf("got %+v, want %+v", changes, want)
+	}
+
+	if changes, err := ParseGitDiffNameStatus(nil); err != nil || !reflect.DeepEqual(changes, Changes{}) {
+		t.Errorf("empty output: got %+v, %v, want no changes", changes, err)
+	}
+
+	if _, err := ParseGitDiffNameStatus([]byte("R100\x00a.go\x00b.go\x00")); err == nil {
+		t.Error("expected an error for a rename status")
+	}
+}
+
+func TestServiceIncremental(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { os.RemoveAll(tmpDir) }()
+
+	files := map[api.CommitID]map[string]string{
+		"a": {"a.js": "x", "b.js": "y"},
+		"b": {"a.js": "x2", "c.js": "z"},
+	}
+
+	var fetchedPaths [][]string
+	service := Service{
+		FetchTar: func(ctx context.Context, repo api.RepoName, commit api.CommitID, paths []string) (io.ReadCloser, error) {
+			fetchedPaths = append(fetchedPaths, paths)
+			filtered := map[string]string{}
+			for name, body := range files[commit] {
+				if len(paths) == 0 || contains(paths, name) {
+					filtered[name] = body
+				}
+			}
+			return createTar(filtered)
+		},
+		GitDiff: func(ctx context.Context, repo api.RepoName, commitA, commitB api.CommitID) (Changes, error) {
+			if commitA != "a" || commitB != "b" {
+				t.Fatalf("unexpected diff %s..%s", commitA, commitB)
+			}
+			return Changes{Added: []string{"c.js"}, Modified: []string{"a.js"}, Deleted: []string{"b.js"}}, nil
+		},
+		Ancestors: func(ctx context.Context, repo api.RepoName, commit api.CommitID, n int) ([]api.CommitID, error) {
+			if commit == "b" {
+				return []api.CommitID{"b", "a"}, nil
+			}
+			return []api.CommitID{commit}, nil
+		},
+		NewParser: func() (ctags.Parser, error) {
+			return wordParser{}, nil
+		},
+		Path: tmpDir,
+	}
+	if err := service.Start(); err != nil {
+		t.Fatal(err)
+	}
+
+	search := func(commit api.CommitID) []result.Symbol {
+		res, err := service.search(context.Background(), protocol.SearchArgs{Repo: "r", CommitID: commit, First: 10})
+		if err != nil {
+			t.Fatal(err)
+		}
+		symbols := *res
+		sort.Slice(symbols, func(i, j int) bool { return symbols[i].Path < symbols[j].Path })
+		return symbols
+	}
+
+	if got, want := search("a"), []result.Symbol{{Name: "x", Path: "a.js"}, {Name: "y", Path: "b.js"}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+	if got, want := search("b"), []result.Symbol{{Name: "x2", Path: "a.js"}, {Name: "z", Path: "c.js"}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+
+	if want := [][]string{nil, {"c.js", "a.js"}}; !reflect.DeepEqual(fetchedPaths, want) {
+		t.Errorf("got fetched paths %v, want %v", fetchedPaths, want)
+	}
+}
+
+// wordParser emits a symbol for each word in a file.
+type wordParser struct{}
+
+func (wordParser) Parse(name string, content []byte) ([]*ctags.Entry, error) {
+	var entries []*ctags.Entry
+	for _, word := range strings.Fields(string(content)) {
+		entries = append(entries, &ctags.Entry{Name: word, Path: name})
+	}
+	return entries, nil
+}
+
+func (wordParser) Close() {}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,159 @@
+package symbols
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+
+	"github.com/cockroachdb/errors"
+	"github.com/inconshreveable/log15"
+	"github.com/jmoiron/sqlx"
+	"github.com/keegancsmith/sqlf"
+	"github.com/opentracing/opentracing-go/ext"
+	otlog "github.com/opentracing/opentracing-go/log"
+
+	"github.com/sourcegraph/sourcegraph/cmd/symbols/internal/protocol"
+	"github.com/sourcegraph/sourcegraph/internal/trace/ot"
+)
+
+// handlePaginatedSearch is like handleSearch, but returns the symbols in a
+// stable order along with a cursor to fetch the next page. This lets callers
+// walk through all matching symbols, not just the first maxFirst of them.
+func (s *Service) handlePaginatedSearch(w http.ResponseWriter, r *http.Request) {
+	var args protocol.SearchArgs
+	if err := json.NewDecoder(r.Body).Decode(&args); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	after, err := decodeCursor(args.After)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	page, err := s.searchPage(r.Context(), args, after)
+	if err != nil {
+		if err == context.Canceled && r.Context().Err() == context.Canceled {
+			return // client went away
+		}
+		log15.Error("Paginated symbol search failed", "args", args, "error", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(page); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+func (s *Service) searchPage(ctx context.Context, args protocol.SearchArgs, after *cursor) (*protocol.SearchPage, error) {
+	var page *protocol.SearchPage
+	err := s.withDB(ctx, "searchPage", args, func(ctx context.Context, db *sqlx.DB) (err error) {
+		page, err = filterSymbolsPage(ctx, db, args, after)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return page, nil
+}
+
+// cursor is the position of a symbol in the order of paginated searches.
+//
+// The order mostly depends on the symbols themselves, not on how they are
+// stored, because the database of a repo@commit can be rebuilt (after it was
+// evicted from the cache) or updated incrementally, which reorders its rows.
+// Only symbols with the same path, line, name and kind are ordered by their
+// sqlite3 rowid, which makes the order total.
+type cursor struct {
+	Path  string
+	Line  int
+	Name  string
+	Kind  string
+	RowID int64
+}
+
+// paginatedOrder orders symbols by the columns of a cursor. It is backed by
+// paginated_index, which implicitly ends with the rowid.
+const paginatedOrder = "path, line, name, kind, rowid"
+
+// symbolInDBWithRowID is a symbolInDB along with its sqlite3 rowid.
+type symbolInDBWithRowID struct {
+	RowID int64 `db:"rowid"`
+	symbolInDB
+}
+
+func filterSymbolsPage(ctx context.Context, db *sqlx.DB, args protocol.SearchArgs, after *cursor) (page *protocol.SearchPage, err error) {
+	span, _ := ot.StartSpanFromContext(ctx, "filterSymbolsPage")
+	defer func() {
+		if err != nil {
+			ext.Error.Set(span, true)
+			span.LogFields(otlog.Error(err))
+		}
+		span.Finish()
+	}()
+
+	if args.First <= 0 || args.First > maxFirst {
+		args.First = maxFirst
+	}
+
+	conditions := symbolConditions(args)
+	if after != nil {
+		conditions = append(conditions, sqlf.Sprintf("("+paginatedOrder+") > (%s, %s, %s, %s, %s)", after.Path, after.Line, after.Name, after.Kind, after.RowID))
+	}
+
+	// Ask for one more symbol than the page size to know whether there is a
+	// next page.
+	var sqlQuery *sqlf.Query
+	if len(conditions) == 0 {
+		sqlQuery = sqlf.Sprintf("SELECT rowid, * FROM symbols ORDER BY "+paginatedOrder+" LIMIT %s", args.First+1)
+	} else {
+		sqlQuery = sqlf.Sprintf("SELECT rowid, * FROM symbols WHERE %s ORDER BY "+paginatedOrder+" LIMIT %s", sqlf.Join(conditions, "AND"), args.First+1)
+	}
+
+	var symbolsInDB []symbolInDBWithRowID
+	err = db.Select(&symbolsInDB, sqlQuery.Query(sqlf.PostgresBindVar), sqlQuery.Args()...)
+	if err != nil {
+		return nil, err
+	}
+
+	page = &protocol.SearchPage{}
+	if len(symbolsInDB) > args.First {
+		symbolsInDB = symbolsInDB[:args.First]
+		last := symbolsInDB[len(symbolsInDB)-1]
+		page.NextCursor = encodeCursor(cursor{Path: last.Path, Line: last.Line, Name: last.Name, Kind: last.Kind, RowID: last.RowID})
+	}
+	for _, symbolInDB := range symbolsInDB {
+		page.Symbols = append(page.Symbols, symbolInDBToSymbol(symbolInDB.symbolInDB))
+	}
+
+	span.SetTag("hits", len(page.Symbols))
+	span.SetTag("hasNextPage", page.NextCursor != "")
+	return page, nil
+}
+
+// encodeCursor returns the opaque cursor pointing after the given position.
+func encodeCursor(c cursor) string {
+	b, _ := json.Marshal([]interface{}{c.Path, c.Line, c.Name, c.Kind, c.RowID})
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// decodeCursor returns the position that the cursor points after. The empty
+// cursor points to the beginning, which is returned as nil.
+func decodeCursor(s string) (*cursor, error) {
+	if s == "" {
+		return nil, nil
+	}
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, errors.Errorf("invalid cursor %q", s)
+	}
+	var c cursor
+	if err := json.Unmarshal(b, &[]interface{}{&c.Path, &c.Line, &c.Name, &c.Kind, &c.RowID}); err != nil {
+		return nil, errors.Errorf("invalid cursor %q", s)
+	}
+	return &c, nil
+}
@@ -39,7 +39,9 @@ func (s *Service) startParsers() error {
 	return nil
 }
 
-func (s *Service) parseUncached(ctx context.Context, repo api.RepoName, commitID api.CommitID, callback func(symbol result.Symbol) error) (err error) {
+// parseUncached fetches the repo@commit from gitserver and calls callback for
+// each symbol found. If paths is non-empty, only those paths are parsed.
+func (s *Service) parseUncached(ctx context.Context, repo api.RepoName, commitID api.CommitID, paths []string, callback func(symbol result.Symbol) error) (err error) {
 	span, ctx := ot.StartSpanFromContext(ctx, "parseUncached")
 	defer func() {
 		if err != nil {
@@ -52,7 +54,7 @@ func (s *Service) parseUncached(ctx context.Context, repo api.RepoName, commitID
 	span.SetTag("commit", string(commitID))
 
 	tr := nettrace.New("parseUncached", string(repo))
-	tr.LazyPrintf("commitID: %s", commitID)
+	tr.LazyPrintf("commitID: %s paths: %d", commitID, len(paths))
 
 	totalSymbols := 0
 	defer func() {
@@ -65,7 +67,7 @@ func (s *Service) parseUncached(ctx context.Context, repo api.RepoName, commitID
 	}()
 
 	tr.LazyPrintf("fetch")
-	parseRequests, errChan, err := s.fetchRepositoryArchive(ctx, repo, commitID)
+	parseRequests, errChan, err := s.fetchRepositoryArchive(ctx, repo, commitID, paths)
 	tr.LazyPrintf("fetch (returned chans)")
 	if err != nil {
 		return err
@@ -27,6 +27,9 @@ import (
 // maxFileSize is the limit on file size in bytes. Only files smaller than this are processed.
 const maxFileSize = 1 << 19 // 512KB
 
+// maxFirst is the maximum number of symbols returned by a single search.
+const maxFirst = 500
+
 func (s *Service) handleSearch(w http.ResponseWriter, r *http.Request) {
 	var args protocol.SearchArgs
 	if err := json.NewDecoder(r.Body).Decode(&args); err != nil {
@@ -51,12 +54,25 @@ func (s *Service) handleSearch(w http.ResponseWriter, r *http.Request) {
 }
 
 func (s *Service) search(ctx context.Context, args protocol.SearchArgs) (*result.Symbols, error) {
-	var err error
+	var res result.Symbols
+	err := s.withDB(ctx, "search", args, func(ctx context.Context, db *sqlx.DB) (err error) {
+		res, err = filterSymbols(ctx, db, args)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// withDB calls f with the sqlite3 database for the repo@commit specified in
+// `args`, creating the database first if necessary.
+func (s *Service) withDB(ctx context.Context, operation string, args protocol.SearchArgs, f func(context.Context, *sqlx.DB) error) (err error) {
 	ctx, cancel := context.WithTimeout(ctx, 60*time.Second)
 	defer cancel()
 
-	log15.Debug("Symbol search", "repo", args.Repo, "query", args.Query)
-	span, ctx := ot.StartSpanFromContext(ctx, "search")
+	log15.Debug("Symbol "+operation, "repo", args.Repo, "query", args.Query)
+	span, ctx := ot.StartSpanFromContext(ctx, operation)
 	span.SetTag("repo", args.Repo)
 	span.SetTag("commitID", args.CommitID)
 	span.SetTag("query", args.Query)
@@ -69,7 +85,7 @@ func (s *Service) search(ctx context.Context, args protocol.SearchArgs) (*result
 		span.Finish()
 	}()
 
-	tr := nettrace.New("symbols.search", fmt.Sprintf("args:%+v", args))
+	tr := nettrace.New("symbols."+operation, fmt.Sprintf("args:%+v", args))
 	defer func() {
 		if err != nil {
 			tr.LazyPrintf("error: %v", err)
@@ -80,27 +96,23 @@ func (s *Service) search(ctx context.Context, args protocol.SearchArgs) (*result
 
 	dbFile, err := s.getDBFile(ctx, args)
 	if err != nil {
-		return nil, err
+		return err
 	}
 	db, err := sqlx.Open("sqlite3_with_pcre", dbFile)
 	if err != nil {
-		return nil, err
+		return err
 	}
 	defer db.Close()
 
-	result, err := filterSymbols(ctx, db, args)
-	if err != nil {
-		return nil, err
-	}
-	return &result, nil
+	return f(ctx, db)
 }
 
 // getDBFile returns the path to the sqlite3 database for the repo@commit
 // specified in `args`. If the database doesn't already exist in the disk cache,
 // it will create a new one and write all the symbols into it.
 func (s *Service) getDBFile(ctx context.Context, args protocol.SearchArgs) (string, error) {
-	diskcacheFile, err := s.cache.OpenWithPath(ctx, fmt.Sprintf("%d-%s@%s", symbolsDBVersion, args.Repo, args.CommitID), func(fetcherCtx context.Context, tempDBFile string) error {
-		err := s.writeAllSymbolsToNewDB(fetcherCtx, tempDBFile, args.Repo, args.CommitID)
+	diskcacheFile, err := s.cache.OpenWithPath(ctx, dbCacheKey(args.Repo, args.CommitID), func(fetcherCtx context.Context, tempDBFile string) error {
+		err := s.writeSymbolsToNewDB(fetcherCtx, tempDBFile, args.Repo, args.CommitID)
 		if err != nil {
 			if err == context.Canceled {
 				log15.Error("Unable to parse repository symbols within the context", "repo", args.Repo, "commit", args.CommitID, "query", args.Query)
@@ -117,6 +129,11 @@ func (s *Service) getDBFile(ctx context.Context, args protocol.SearchArgs) (stri
 	return diskcacheFile.File.Name(), err
 }
 
+// dbCacheKey returns the disk cache key of the sqlite3 database for repo@commit.
+func dbCacheKey(repo api.RepoName, commitID api.CommitID) string {
+	return fmt.Sprintf("%d-%s@%s", symbolsDBVersion, repo, commitID)
+}
+
 // isLiteralEquality checks if the given regex matches literal strings exactly.
 // Returns whether or not the regex is exact, along with the literal string if
 // so.
@@ -145,11 +162,36 @@ func filterSymbols(ctx context.Context, db *sqlx.DB, args protocol.SearchArgs) (
 		span.Finish()
 	}()
 
-	const maxFirst = 500
 	if args.First < 0 || args.First > maxFirst {
 		args.First = maxFirst
 	}
 
+	conditions := symbolConditions(args)
+
+	var sqlQuery *sqlf.Query
+	if len(conditions) == 0 {
+		sqlQuery = sqlf.Sprintf("SELECT * FROM symbols LIMIT %s", args.First)
+	} else {
+		sqlQuery = sqlf.Sprintf("SELECT * FROM symbols WHERE %s LIMIT %s", sqlf.Join(conditions, "AND"), args.First)
+	}
+
+	var symbolsInDB []symbolInDB
+	err = db.Select(&symbolsInDB, sqlQuery.Query(sqlf.PostgresBindVar), sqlQuery.Args()...)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, symbolInDB := range symbolsInDB {
+		res = append(res, symbolInDBToSymbol(symbolInDB))
+	}
+
+	span.SetTag("hits", len(res))
+	return res, nil
+}
+
+// symbolConditions returns the SQL conditions that symbols need to satisfy to
+// match args.
+func symbolConditions(args protocol.SearchArgs) []*sqlf.Query {
 	makeCondition := func(column string, regex string) []*sqlf.Query {
 		conditions := []*sqlf.Query{}
 
@@ -185,39 +227,44 @@ func filterSymbols(ctx context.Context, db *sqlx.DB, args protocol.SearchArgs) (
 		return newConditions
 	}
 
+	makeInCondition := func(column string, values []string) []*sqlf.Query {
+		if len(values) == 0 {
+			return nil
+		}
+
+		lowercaseValues := make([]*sqlf.Query, 0, len(values))
+		for _, value := range values {
+			lowercaseValues = append(lowercaseValues, sqlf.Sprintf("%s", strings.ToLower(value)))
+		}
+
+		return []*sqlf.Query{sqlf.Sprintf("lower("+column+") IN (%s)", sqlf.Join(lowercaseValues, ","))}
+	}
+
 	var conditions []*sqlf.Query
 	conditions = append(conditions, makeCondition("name", args.Query)...)
 	for _, includePattern := range args.IncludePatterns {
 		conditions = append(conditions, makeCondition("path", includePattern)...)
 	}
 	conditions = append(conditions, negateAll(makeCondition("path", args.ExcludePattern))...)
-
-	var sqlQuery *sqlf.Query
-	if len(conditions) == 0 {
-		sqlQuery = sqlf.Sprintf("SELECT * FROM symbols LIMIT %s", args.First)
-	} else {
-		sqlQuery = sqlf.Sprintf("SELECT * FROM symbols WHERE %s LIMIT %s", sqlf.Join(conditions, "AND"), args.First)
-	}
-
-	var symbolsInDB []symbolInDB
-	err = db.Select(&symbolsInDB, sqlQuery.Query(sqlf.PostgresBindVar), sqlQuery.Args()...)
-	if err != nil {
-		return nil, err
-	}
-
-	for _, symbolInDB := range symbolsInDB {
-		res = append(res, symbolInDBToSymbol(symbolInDB))
+	conditions = append(conditions, makeInCondition("kind", args.Kinds)...)
+	conditions = append(conditions, makeInCondition("language", args.Languages)...)
+	if args.ContainerPattern != "" {
+		// There is no lowercase column for parent, so always match with a regex.
+		containerPattern := args.ContainerPattern
+		if !args.IsCaseSensitive {
+			containerPattern = "(?i:" + containerPattern + ")"
+		}
+		conditions = append(conditions, sqlf.Sprintf("parent REGEXP %s", containerPattern))
 	}
 
-	span.SetTag("hits", len(res))
-	return res, nil
+	return conditions
 }
 
 // The version of the symbols database schema. This is included in the database
 // filenames to prevent a newer version of the symbols service from attempting
 // to read from a database created by an older (and likely incompatible) symbols
 // service. Increment this when you change the database schema.
-const symbolsDBVersion = 3
+const symbolsDBVersion = 4
 
 // symbolInDB is the same as `protocol.Symbol`, but with two additional columns:
 // namelowercase and pathlowercase, which enable indexed case insensitive
@@ -337,6 +384,18 @@ func (s *Service) writeAllSymbolsToNewDB(ctx context.Context, dbFile string, rep
 		return err
 	}
 
+	// `paginated_index` backs the order of paginated searches.
+	_, err = tx.Exec(`CREATE INDEX paginated_index ON symbols(path, line, name, kind);`)
+	if err != nil {
+		return err
+	}
+
+	return s.parseAndInsertSymbols(ctx, tx, repoName, commitID, nil)
+}
+
+// parseAndInsertSymbols parses the symbols of repo@commit and inserts them into
+// the symbols table. If paths is non-empty, only those paths are parsed.
+func (s *Service) parseAndInsertSymbols(ctx context.Context, tx *sqlx.Tx, repoName api.RepoName, commitID api.CommitID, paths []string) error {
 	insertStatement, err := tx.PrepareNamed(
 		fmt.Sprintf(
 			"INSERT INTO symbols %s VALUES %s",
@@ -346,7 +405,7 @@ func (s *Service) writeAllSymbolsToNewDB(ctx context.Context, dbFile string, rep
 		return err
 	}
 
-	return s.parseUncached(ctx, repoName, commitID, func(symbol result.Symbol) error {
+	return s.parseUncached(ctx, repoName, commitID, paths, func(symbol result.Symbol) error {
 		symbolInDBValue := symbolToSymbolInDB(symbol)
 		_, err := insertStatement.Exec(&symbolInDBValue)
 		return err
@@ -19,9 +19,18 @@ import (
 // Service is the symbols service.
 type Service struct {
 	// FetchTar returns an io.ReadCloser to a tar archive of a repository at the specified Git
-	// remote URL and commit ID. If the error implements "BadRequest() bool", it will be used to
-	// determine if the error is a bad request (eg invalid repo).
-	FetchTar func(context.Context, api.RepoName, api.CommitID) (io.ReadCloser, error)
+	// remote URL and commit ID. If paths is non-empty, the archive only contains those paths.
+	// If the error implements "BadRequest() bool", it will be used to determine if the error
+	// is a bad request (eg invalid repo).
+	FetchTar func(ctx context.Context, repo api.RepoName, commit api.CommitID, paths []string) (io.ReadCloser, error)
+
+	// GitDiff returns the paths that changed between two commits of a repository. When it
+	// and Ancestors are set, the database for a new commit is created by updating a copy of
+	// the database of the nearest cached ancestor instead of parsing the whole repository.
+	GitDiff func(ctx context.Context, repo api.RepoName, commitA, commitB api.CommitID) (Changes, error)
+
+	// Ancestors returns up to n ancestors of the given commit, nearest first.
+	Ancestors func(ctx context.Context, repo api.RepoName, commit api.CommitID, n int) ([]api.CommitID, error)
 
 	// MaxConcurrentFetchTar is the maximum number of concurrent calls allowed
 	// to FetchTar. It defaults to 15.
@@ -82,6 +91,7 @@ func (s *Service) Handler() http.Handler {
 	mux := http.NewServeMux()
 
 	mux.HandleFunc("/search", s.handleSearch)
+	mux.HandleFunc("/paginated-search", s.handlePaginatedSearch)
 	mux.HandleFunc("/healthz", s.handleHealthCheck)
 
 	return mux
@@ -8,6 +8,7 @@ import (
 	"net/http/httptest"
 	"os"
 	"reflect"
+	"strconv"
 	"testing"
 
 	"github.com/sourcegraph/go-ctags"
@@ -22,6 +23,7 @@ import (
 
 func init() {
 	sqliteutil.SetLocalLibpath()
+	sqliteutil.MustRegisterSqlite3WithPcre()
 }
 
 func TestIsLiteralEquality(t *testing.T) {
@@ -62,8 +64,6 @@ func TestIsLiteralEquality(t *testing.T) {
 }
 
 func TestService(t *testing.T) {
-	sqliteutil.MustRegisterSqlite3WithPcre()
-
 	tmpDir, err := os.MkdirTemp("", "")
 	if err != nil {
 		t.Fatal(err)
@@ -72,7 +72,7 @@ func TestService(t *testing.T) {
 
 	files := map[string]string{"a.js": "var x = 1"}
 	service := Service{
-		FetchTar: func(ctx context.Context, repo api.RepoName, commit api.CommitID) (io.ReadCloser, error) {
+		FetchTar: func(ctx context.Context, repo api.RepoName, commit api.CommitID, paths []string) (io.ReadCloser, error) {
 			return createTar(files)
 		},
 		NewParser: func() (ctags.Parser, error) {
@@ -189,3 +189,280 @@ func (m mockParser) Parse(name string, content []byte) ([]*ctags.Entry, error) {
 }
 
 func (mockParser) Close() {}
+
+func TestServiceFilters(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { os.RemoveAll(tmpDir) }()
+
+	files := map[string]string{"a.go": "package a"}
+	service := Service{
+		FetchTar: func(ctx context.Context, repo api.RepoName, commit api.CommitID, paths []string) (io.ReadCloser, error) {
+			return createTar(files)
+		},
+		NewParser: func() (ctags.Parser, error) {
+			return entriesParser{
+				{Name: "Server", Path: "a.go", Kind: "struct", Language: "Go"},
+				{Name: "Start", Path: "a.go", Kind: "method", Language: "Go", Parent: "Server", ParentKind: "struct"},
+				{Name: "main", Path: "a.go", Kind: "func", Language: "Go"},
+			}, nil
+		},
+		Path: tmpDir,
+	}
+
+	if err := service.Start(); err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(service.Handler())
+	defer server.Close()
+	client := symbolsclient.Client{
+		URL:        server.URL,
+		HTTPClient: httpcli.InternalDoer,
+	}
+	serverStruct := result.Symbol{Name: "Server", Path: "a.go", Kind: "struct", Language: "Go"}
+	startMethod := result.Symbol{Name: "Start", Path: "a.go", Kind: "method", Language: "Go", Parent: "Server", ParentKind: "struct"}
+	mainFunc := result.Symbol{Name: "main", Path: "a.go", Kind: "func", Language: "Go"}
+
+	tests := map[string]struct {
+		args search.SymbolsParameters
+		want result.Symbols
+	}{
+		"kind": {
+			args: search.SymbolsParameters{Kinds: []string{"METHOD", "func"}, First: 10},
+			want: []result.Symbol{startMethod, mainFunc},
+		},
+		"language": {
+			args: search.SymbolsParameters{Languages: []string{"go"}, First: 10},
+			want: []result.Symbol{serverStruct, startMethod, mainFunc},
+		},
+		"nolanguagematches": {
+			args: search.SymbolsParameters{Languages: []string{"python"}, First: 10},
+			want: nil,
+		},
+		"container": {
+			args: search.SymbolsParameters{ContainerPattern: "^server$", First: 10},
+			want: []result.Symbol{startMethod},
+		},
+		"casesensitivecontainer": {
+			args: search.SymbolsParameters{ContainerPattern: "^server$", IsCaseSensitive: true, First: 10},
+			want: nil,
+		},
+		"combined": {
+			args: search.SymbolsParameters{Query: "^S", Kinds: []string{"struct"}, Languages: []string{"Go"}, First: 10},
+			want: []result.Symbol{serverStruct},
+		},
+	}
+	for label, test := range tests {
+		t.Run(label, func(t *testing.T) {
+			result, err := client.Search(context.Background(), test.args)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result != nil && !reflect.DeepEqual(*result, test.want) {
+				t.Errorf("got %+v, want %+v", *result, test.want)
+			}
+			if result == nil && test.want != nil {
+				t.Errorf("got nil, want %+v", test.want)
+			}
+		})
+	}
+}
+
+type entriesParser []*ctags.Entry
+
+func (p entriesParser) Parse(name string, content []byte) ([]*ctags.Entry, error) {
+	return p, nil
+}
+
+func (entriesParser) Close() {}
+
+func TestServicePaginatedSearch(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { os.RemoveAll(tmpDir) }()
+
+	files := map[string]string{"a.js": "a b c d e f g"}
+	service := Service{
+		FetchTar: func(ctx context.Context, repo api.RepoName, commit api.CommitID, paths []string) (io.ReadCloser, error) {
+			return createTar(files)
+		},
+		NewParser: func() (ctags.Parser, error) {
+			return wordParser{}, nil
+		},
+		Path: tmpDir,
+	}
+
+	if err := service.Start(); err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(service.Handler())
+	defer server.Close()
+	client := symbolsclient.Client{
+		URL:        server.URL,
+		HTTPClient: httpcli.InternalDoer,
+	}
+
+	tests := map[string]struct {
+		args      search.SymbolsParameters
+		wantPages [][]string
+	}{
+		"all": {
+			args:      search.SymbolsParameters{First: 3},
+			wantPages: [][]string{{"a", "b", "c"}, {"d", "e", "f"}, {"g"}},
+		},
+		"exactpages": {
+			args:      search.SymbolsParameters{Query: "^[a-d]$", First: 2},
+			wantPages: [][]string{{"a", "b"}, {"c", "d"}},
+		},
+		"nomatches": {
+			args:      search.SymbolsParameters{Query: "foo", First: 2},
+			wantPages: [][]string{nil},
+		},
+	}
+	for label, test := range tests {
+		t.Run(label, func(t *testing.T) {
+			var pages [][]string
+			err := client.SearchAll(context.Background(), test.args, func(symbols result.Symbols) error {
+				var names []string
+				for _, symbol := range symbols {
+					names = append(names, symbol.Name)
+				}
+				pages = append(pages, names)
+				return nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(pages, test.wantPages) {
+				t.Errorf("got pages %v, want %v", pages, test.wantPages)
+			}
+		})
+	}
+
+	t.Run("invalid cursor", func(t *testing.T) {
+		if _, err := client.SearchPage(context.Background(), search.SymbolsParameters{After: "-", First: 2}); err == nil {
+			t.Error("expected an error for an invalid cursor")
+		}
+	})
+}
+
+func TestServicePaginatedSearchRebuiltDB(t *testing.T) {
+	entries := []*ctags.Entry{
+		{Name: "b", Path: "b.go", Line: 1, Kind: "func"},
+		{Name: "a", Path: "b.go", Line: 1, Kind: "func"},
+		{Name: "a", Path: "a.go", Line: 2, Kind: "var"},
+		{Name: "a", Path: "a.go", Line: 2, Kind: "func"},
+		{Name: "z", Path: "a.go", Line: 1, Kind: "func"},
+	}
+	reversed := make([]*ctags.Entry, len(entries))
+	for i, e := range entries {
+		reversed[len(entries)-1-i] = e
+	}
+
+	// newClient returns a client of a service whose database contains the
+	// entries in the given order, like a database rebuilt by another parse.
+	newClient := func(t *testing.T, entries []*ctags.Entry) *symbolsclient.Client {
+		service := Service{
+			FetchTar: func(ctx context.Context, repo api.RepoName, commit api.CommitID, paths []string) (io.ReadCloser, error) {
+				return createTar(map[string]string{"a.go": "package a"})
+			},
+			NewParser: func() (ctags.Parser, error) {
+				return entriesParser(entries), nil
+			},
+			Path: t.TempDir(),
+		}
+		if err := service.Start(); err != nil {
+			t.Fatal(err)
+		}
+		server := httptest.NewServer(service.Handler())
+		t.Cleanup(server.Close)
+		return &symbolsclient.Client{URL: server.URL, HTTPClient: httpcli.InternalDoer}
+	}
+
+	symbolKeys := func(symbols result.Symbols) (keys []string) {
+		for _, s := range symbols {
+			keys = append(keys, s.Path+":"+strconv.Itoa(s.Line)+":"+s.Name+":"+s.Kind)
+		}
+		return keys
+	}
+
+	first, err := newClient(t, entries).SearchPage(context.Background(), search.SymbolsParameters{First: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The cursor of the first page resumes at the same position in a
+	// database whose rows are in a different order.
+	second, err := newClient(t, reversed).SearchPage(context.Background(), search.SymbolsParameters{After: first.NextCursor, First: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	have := append(symbolKeys(first.Symbols), symbolKeys(second.Symbols)...)
+	want := []string{"a.go:1:z:func", "a.go:2:a:func", "a.go:2:a:var", "b.go:1:a:func", "b.go:1:b:func"}
+	if !reflect.DeepEqual(have, want) {
+		t.Errorf("got symbols %v, want %v", have, want)
+	}
+}
+
+func TestServicePaginatedSearchDuplicateSymbols(t *testing.T) {
+	// Symbols with the same path, line, name and kind, for example overloads
+	// declared on one line, straddle the page boundary.
+	entries := []*ctags.Entry{
+		{Name: "a", Path: "a.go", Line: 1, Kind: "func", Signature: "(x int)"},
+		{Name: "a", Path: "a.go", Line: 1, Kind: "func", Signature: "(x string)"},
+		{Name: "a", Path: "a.go", Line: 1, Kind: "func", Signature: "(x bool)"},
+		{Name: "b", Path: "a.go", Line: 1, Kind: "func"},
+	}
+	service := Service{
+		FetchTar: func(ctx context.Context, repo api.RepoName, commit api.CommitID, paths []string) (io.ReadCloser, error) {
+			return createTar(map[string]string{"a.go": "package a"})
+		},
+		NewParser: func() (ctags.Parser, error) {
+			return entriesParser(entries), nil
+		},
+		Path: t.TempDir(),
+	}
+	if err := service.Start(); err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(service.Handler())
+	defer server.Close()
+	client := symbolsclient.Client{URL: server.URL, HTTPClient: httpcli.InternalDoer}
+
+	var have []string
+	err := client.SearchAll(context.Background(), search.SymbolsParameters{First: 2}, func(symbols result.Symbols) error {
+		for _, s := range symbols {
+			have = append(have, s.Name+s.Signature)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"a(x int)", "a(x string)", "a(x bool)", "b"}
+	if !reflect.DeepEqual(have, want) {
+		t.Errorf("got symbols %v, want %v", have, want)
+	}
+}
+
+func TestCursor(t *testing.T) {
+	c := cursor{Path: "a/b.go", Line: 12, Name: "Foo", Kind: "func", RowID: 42}
+	have, err := decodeCursor(encodeCursor(c))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *have != c {
+		t.Errorf("got cursor %+v, want %+v", *have, c)
+	}
+
+	for _, invalid := range []string{"-", "e30", "WyJhIiwiYiJd"} {
+		if _, err := decodeCursor(invalid); err == nil {
+			t.Errorf("expected an error for cursor %q", invalid)
+		}
+	}
+}
@@ -4,6 +4,7 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"io"
 	"log"
 	"net"
@@ -12,8 +13,10 @@ import (
 	"os/signal"
 	"runtime"
 	"strconv"
+	"strings"
 	"time"
 
+	"github.com/cockroachdb/errors"
 	"github.com/inconshreveable/log15"
 
 	"github.com/sourcegraph/sourcegraph/cmd/symbols/internal/sqliteutil"
@@ -56,8 +59,32 @@ func main() {
 	go debugserver.NewServerRoutine(ready).Start()
 
 	service := symbols.Service{
-		FetchTar: func(ctx context.Context, repo api.RepoName, commit api.CommitID) (io.ReadCloser, error) {
-			return gitserver.DefaultClient.Archive(ctx, repo, gitserver.ArchiveOptions{Treeish: string(commit), Format: "tar"})
+		FetchTar: func(ctx context.Context, repo api.RepoName, commit api.CommitID, paths []string) (io.ReadCloser, error) {
+			return gitserver.DefaultClient.Archive(ctx, repo, gitserver.ArchiveOptions{Treeish: string(commit), Format: "tar", Paths: paths})
+		},
+		GitDiff: func(ctx context.Context, repo api.RepoName, commitA, commitB api.CommitID) (symbols.Changes, error) {
+			command := gitserver.DefaultClient.Command("git", "diff", "-z", "--name-status", "--no-renames", string(commitA), string(commitB))
+			command.Repo = repo
+			output, stderr, err := command.DividedOutput(ctx)
+			if err != nil {
+				return symbols.Changes{}, errors.WithMessage(err, fmt.Sprintf("git command %v failed (stderr: %q)", command.Args, stderr))
+			}
+			return symbols.ParseGitDiffNameStatus(output)
+		},
+		Ancestors: func(ctx context.Context, repo api.RepoName, commit api.CommitID, n int) ([]api.CommitID, error) {
+			command := gitserver.DefaultClient.Command("git", "rev-list", "--max-count="+strconv.Itoa(n), string(commit))
+			command.Repo = repo
+			output, stderr, err := command.DividedOutput(ctx)
+			if err != nil {
+				return nil, errors.WithMessage(err, fmt.Sprintf("git command %v failed (stderr: %q)", command.Args, stderr))
+			}
+			var ancestors []api.CommitID
+			for _, line := range strings.Split(strings.TrimSpace(string(output)), "\n") {
+				if line != "" {
+					ancestors = append(ancestors, api.CommitID(line))
+				}
+			}
+			return ancestors, nil
 		},
 		NewParser: symbols.NewParser,
 		Path:      cacheDir,
@@ -0,0 +1,172 @@
+// Package groupmapping maps the groups that users belong to in an identity provider to
+// organization memberships and site admin status, as configured by the groupMapping setting of
+// the SAML and OpenID Connect auth providers.
+package groupmapping
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"time"
+
+	"github.com/inconshreveable/log15"
+
+	"github.com/sourcegraph/sourcegraph/internal/database"
+	"github.com/sourcegraph/sourcegraph/internal/database/dbutil"
+	"github.com/sourcegraph/sourcegraph/internal/errcode"
+	"github.com/sourcegraph/sourcegraph/schema"
+)
+
+// state is the organization memberships and site admin status that a user must have according
+// to the group mapping.
+type state struct {
+	// orgs maps the names of the organizations managed by the mapping to whether the user must
+	// be a member.
+	orgs map[string]bool
+	// siteAdmin is nil when the mapping doesn't manage site admin status.
+	siteAdmin *bool
+}
+
+func desiredState(mapping *schema.AuthGroupMapping, groups []string) state {
+	inGroup := make(map[string]bool, len(groups))
+	for _, g := range groups {
+		inGroup[g] = true
+	}
+
+	s := state{orgs: map[string]bool{}}
+	for _, o := range mapping.Organizations {
+		s.orgs[o.Organization] = s.orgs[o.Organization] || inGroup[o.Group]
+	}
+	if len(mapping.SiteAdminGroups) > 0 {
+		siteAdmin := false
+		for _, g := range mapping.SiteAdminGroups {
+			siteAdmin = siteAdmin || inGroup[g]
+		}
+		s.siteAdmin = &siteAdmin
+	}
+	return s
+}
+
+// Sync updates the organization memberships and the site admin status of the user userID, who
+// signed in with an auth provider of the given type and belongs to the groups in the identity
+// provider. It does nothing if mapping is nil.
+func Sync(ctx context.Context, db dbutil.DB, providerType string, mapping *schema.AuthGroupMapping, userID int32, groups []string) (err error) {
+	if mapping == nil {
+		return nil
+	}
+	want := desiredState(mapping, groups)
+
+	var events []*database.SecurityEvent
+	tx, err := database.Orgs(db).Transact(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = tx.Done(err)
+		if err == nil {
+			logEvents(ctx, db, events)
+		}
+	}()
+	orgMembers := database.OrgMembersWith(tx)
+
+	names := make([]string, 0, len(want.orgs))
+	for name := range want.orgs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		member := want.orgs[name]
+		org, err := tx.GetByName(ctx, name)
+		if errcode.IsNotFound(err) {
+			if !member {
+				continue
+			}
+			if org, err = tx.Create(ctx, name, nil); err != nil {
+				// An invalid organization name in the mapping must not prevent users from
+				// signing in.
+				log15.Warn("Unable to create organization from auth provider group mapping.", "org", name, "error", err)
+				continue
+			}
+		} else if err != nil {
+			return err
+		}
+
+		_, err = orgMembers.GetByOrgIDAndUserID(ctx, org.ID, userID)
+		isMember := err == nil
+		if err != nil && !errcode.IsNotFound(err) {
+			return err
+		}
+
+		switch {
+		case member && !isMember:
+			if _, err := orgMembers.Create(ctx, org.ID, userID); err != nil {
+				return err
+			}
+			events = append(events, newEvent(database.SecurityEventNameOrgMemberAdded, providerType, userID, map[string]interface{}{"org_id": org.ID}))
+		case !member && isMember:
+			if err := orgMembers.Remove(ctx, org.ID, userID); err != nil {
+				return err
+			}
+			events = append(events, newEvent(database.SecurityEventNameOrgMemberRemoved, providerType, userID, map[string]interface{}{"org_id": org.ID}))
+		}
+	}
+
+	if want.siteAdmin != nil {
+		users := database.UsersWith(tx)
+		user, err := users.GetByID(ctx, userID)
+		if err != nil {
+			return err
+		}
+		switch {
+		case *want.siteAdmin && !user.SiteAdmin:
+			if err := users.SetIsSiteAdmin(ctx, userID, true); err != nil {
+				return err
+			}
+			events = append(events, newEvent(database.SecurityEventNameRoleChangeGranted, providerType, userID, map[string]interface{}{"site_admin": true}))
+		case !*want.siteAdmin && user.SiteAdmin:
+			// 🚨 SECURITY: Never demote the last site admin, which would lock everyone out of
+			// the site admin area, e.g. when siteAdminGroups is misconfigured.
+			siteAdmins, err := users.CountSiteAdmins(ctx)
+			if err != nil {
+				return err
+			}
+			if siteAdmins <= 1 {
+				log15.Warn("Not removing the site admin status of the last site admin from auth provider group mapping.", "userID", userID)
+				events = append(events, newEvent(database.SecurityEventNameRoleChangeDenied, providerType, userID, map[string]interface{}{
+					"site_admin": false,
+					"reason":     "the user is the last site admin",
+				}))
+				break
+			}
+			if err := users.SetIsSiteAdmin(ctx, userID, false); err != nil {
+				return err
+			}
+			events = append(events, newEvent(database.SecurityEventNameRoleChangeRevoked, providerType, userID, map[string]interface{}{"site_admin": false}))
+		}
+	}
+
+	return nil
+}
+
+func newEvent(name database.SecurityEventName, providerType string, userID int32, argument map[string]interface{}) *database.SecurityEvent {
+	arg, _ := json.Marshal(argument)
+	return &database.SecurityEvent{
+		Name:      name,
+		UserID:    uint32(userID),
+		Argument:  arg,
+		Source:    providerType,
+		Timestamp: time.Now(),
+	}
+}
+
+// logEvents records the changes made by the group mapping as security events on all instances,
+// as they change the permissions of users.
+func logEvents(ctx context.Context, db dbutil.DB, events []*database.SecurityEvent) {
+	store := database.SecurityEventLogs(db)
+	for _, e := range events {
+		if err := store.Insert(ctx, e); err != nil {
+			log15.Error("Failed to log security event.", "event", e.Name, "error", err)
+		}
+	}
+}
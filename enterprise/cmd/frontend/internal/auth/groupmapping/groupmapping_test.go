@@ -0,0 +1,154 @@
+package groupmapping
+
+import (
+	"context"
+	"sort"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/sourcegraph/sourcegraph/internal/database"
+	"github.com/sourcegraph/sourcegraph/internal/database/dbtest"
+	"github.com/sourcegraph/sourcegraph/schema"
+)
+
+func TestDesiredState(t *testing.T) {
+	mapping := &schema.AuthGroupMapping{
+		Attribute: "groups",
+		Organizations: []*schema.AuthGroupOrganization{
+			{Group: "engineering", Organization: "eng"},
+			{Group: "platform", Organization: "eng"},
+			{Group: "platform", Organization: "platform"},
+			{Group: "sales", Organization: "sales"},
+		},
+	}
+
+	for name, tc := range map[string]struct {
+		groups        []string
+		siteAdmins    []string
+		wantOrgs      map[string]bool
+		wantSiteAdmin *bool
+	}{
+		"no groups": {
+			wantOrgs: map[string]bool{"eng": false, "platform": false, "sales": false},
+		},
+		"several groups map to the same organization": {
+			groups:   []string{"platform", "unmapped"},
+			wantOrgs: map[string]bool{"eng": true, "platform": true, "sales": false},
+		},
+		"site admin": {
+			groups:        []string{"sales", "admins"},
+			siteAdmins:    []string{"root", "admins"},
+			wantOrgs:      map[string]bool{"eng": false, "platform": false, "sales": true},
+			wantSiteAdmin: boolPtr(true),
+		},
+		"not site admin": {
+			groups:        []string{"engineering"},
+			siteAdmins:    []string{"admins"},
+			wantOrgs:      map[string]bool{"eng": true, "platform": false, "sales": false},
+			wantSiteAdmin: boolPtr(false),
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			m := *mapping
+			m.SiteAdminGroups = tc.siteAdmins
+			have := desiredState(&m, tc.groups)
+
+			if diff := cmp.Diff(tc.wantOrgs, have.orgs); diff != "" {
+				t.Errorf("unexpected organizations (-want +have):\n%s", diff)
+			}
+			if diff := cmp.Diff(tc.wantSiteAdmin, have.siteAdmin); diff != "" {
+				t.Errorf("unexpected site admin (-want +have):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestSync(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+	ctx := context.Background()
+	db := dbtest.NewDB(t, "")
+
+	// The first user is a site admin, so that the second one isn't.
+	admin, err := database.Users(db).Create(ctx, database.NewUser{Username: "admin"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	user, err := database.Users(db).Create(ctx, database.NewUser{Username: "alice"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	unmanaged, err := database.Orgs(db).Create(ctx, "unmanaged", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := database.OrgMembers(db).Create(ctx, unmanaged.ID, user.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	mapping := &schema.AuthGroupMapping{
+		Attribute: "groups",
+		Organizations: []*schema.AuthGroupOrganization{
+			{Group: "engineering", Organization: "eng"},
+			{Group: "sales", Organization: "sales"},
+			{Group: "sales", Organization: "invalid name!"},
+		},
+		SiteAdminGroups: []string{"admins"},
+	}
+
+	assertState := func(t *testing.T, wantOrgs []string, wantSiteAdmin bool) {
+		t.Helper()
+		orgs, err := database.Orgs(db).GetByUserID(ctx, user.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		haveOrgs := []string{}
+		for _, o := range orgs {
+			haveOrgs = append(haveOrgs, o.Name)
+		}
+		sort.Strings(haveOrgs)
+		if diff := cmp.Diff(wantOrgs, haveOrgs); diff != "" {
+			t.Errorf("unexpected organizations (-want +have):\n%s", diff)
+		}
+		u, err := database.Users(db).GetByID(ctx, user.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if u.SiteAdmin != wantSiteAdmin {
+			t.Errorf("unexpected site admin: want %t, have %t", wantSiteAdmin, u.SiteAdmin)
+		}
+	}
+
+	if err := Sync(ctx, db, "saml", mapping, user.ID, []string{"engineering", "sales", "admins"}); err != nil {
+		t.Fatal(err)
+	}
+	assertState(t, []string{"eng", "sales", "unmanaged"}, true)
+
+	// Memberships of groups the user left are removed on the next sign in.
+	if err := Sync(ctx, db, "saml", mapping, user.ID, []string{"sales"}); err != nil {
+		t.Fatal(err)
+	}
+	assertState(t, []string{"sales", "unmanaged"}, false)
+
+	// Without a mapping, nothing changes.
+	if err := Sync(ctx, db, "saml", nil, user.ID, nil); err != nil {
+		t.Fatal(err)
+	}
+	assertState(t, []string{"sales", "unmanaged"}, false)
+
+	// The last site admin is not demoted.
+	if err := Sync(ctx, db, "saml", mapping, user.ID, []string{"admins"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := database.Users(db).SetIsSiteAdmin(ctx, admin.ID, false); err != nil {
+		t.Fatal(err)
+	}
+	if err := Sync(ctx, db, "saml", mapping, user.ID, nil); err != nil {
+		t.Fatal(err)
+	}
+	assertState(t, []string{"unmanaged"}, true)
+}
+
+func boolPtr(b bool) *bool { return &b }
@@ -8,6 +8,7 @@ import (
 	"github.com/coreos/go-oidc"
 
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/auth"
+	"github.com/sourcegraph/sourcegraph/enterprise/cmd/frontend/internal/auth/groupmapping"
 	"github.com/sourcegraph/sourcegraph/internal/actor"
 	"github.com/sourcegraph/sourcegraph/internal/database"
 	"github.com/sourcegraph/sourcegraph/internal/database/dbutil"
@@ -77,5 +78,50 @@ func getOrCreateUser(ctx context.Context, db dbutil.DB, p *provider, idToken *oi
 	if err != nil {
 		return nil, safeErrMsg, err
 	}
+
+	if m := p.config.GroupMapping; m != nil {
+		groups, err := groupsClaim(m.Attribute, idToken, userInfo)
+		if err != nil {
+			return nil, fmt.Sprintf("Error reading the %q claim of the OpenID Connect token.", m.Attribute), err
+		}
+		if err := groupmapping.Sync(ctx, db, providerType, m, userID, groups); err != nil {
+			return nil, "Error updating the organization memberships of the user from the OpenID Connect groups.", err
+		}
+	}
 	return actor.FromUser(userID), "", nil
 }
+
+// groupsClaim returns the groups of the user in the claim of the ID token, or of the user info
+// if the ID token doesn't have it. The claim is either a list of groups or a single group.
+func groupsClaim(claim string, idToken *oidc.IDToken, userInfo *oidc.UserInfo) ([]string, error) {
+	var value interface{}
+	for _, claims := range []interface{ Claims(interface{}) error }{idToken, userInfo} {
+		var all map[string]interface{}
+		if err := claims.Claims(&all); err != nil {
+			return nil, err
+		}
+		if v, ok := all[claim]; ok {
+			value = v
+			break
+		}
+	}
+
+	switch v := value.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		return []string{v}, nil
+	case []interface{}:
+		groups := make([]string, 0, len(v))
+		for _, g := range v {
+			s, ok := g.(string)
+			if !ok {
+				return nil, errors.Errorf("invalid group %v in claim %q", g, claim)
+			}
+			groups = append(groups, s)
+		}
+		return groups, nil
+	default:
+		return nil, errors.Errorf("invalid value of claim %q, must be a string or a list of strings", claim)
+	}
+}
@@ -8,18 +8,22 @@ import (
 
 	"github.com/cockroachdb/errors"
 	saml2 "github.com/russellhaering/gosaml2"
+	"github.com/russellhaering/gosaml2/types"
 
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/auth"
+	"github.com/sourcegraph/sourcegraph/enterprise/cmd/frontend/internal/auth/groupmapping"
 	"github.com/sourcegraph/sourcegraph/internal/actor"
 	"github.com/sourcegraph/sourcegraph/internal/database"
 	"github.com/sourcegraph/sourcegraph/internal/database/dbutil"
 	"github.com/sourcegraph/sourcegraph/internal/extsvc"
+	"github.com/sourcegraph/sourcegraph/schema"
 )
 
 type authnResponseInfo struct {
 	spec                 extsvc.AccountSpec
 	email, displayName   string
 	unnormalizedUsername string
+	groups               []string
 	accountData          interface{}
 }
 
@@ -71,6 +75,9 @@ func readAuthnResponse(p *provider, encodedResp string) (*authnResponseInfo, err
 		displayName:          firstNonempty(attr.Get("displayName"), attr.Get("givenName")+" "+attr.Get("surname"), attr.Get("http://schemas.xmlsoap.org/claims/CommonName"), attr.Get("http://schemas.xmlsoap.org/ws/2005/05/identity/claims/givenname")),
 		accountData:          assertions,
 	}
+	if m := p.config.GroupMapping; m != nil {
+		info.groups = attributeValues(assertions.Assertions, m.Attribute)
+	}
 	if assertions.NameID == "" {
 		return nil, errors.New("the SAML response did not contain a valid NameID")
 	}
@@ -86,7 +93,7 @@ func readAuthnResponse(p *provider, encodedResp string) (*authnResponseInfo, err
 // getOrCreateUser gets or creates a user account based on the SAML claims. It returns the
 // authenticated actor if successful; otherwise it returns an friendly error message (safeErrMsg)
 // that is safe to display to users, and a non-nil err with lower-level error details.
-func getOrCreateUser(ctx context.Context, db dbutil.DB, allowSignup bool, info *authnResponseInfo) (_ *actor.Actor, safeErrMsg string, err error) {
+func getOrCreateUser(ctx context.Context, db dbutil.DB, allowSignup bool, groupMapping *schema.AuthGroupMapping, info *authnResponseInfo) (_ *actor.Actor, safeErrMsg string, err error) {
 	var data extsvc.AccountData
 	data.SetAccountData(info.accountData)
 
@@ -110,6 +117,9 @@ func getOrCreateUser(ctx context.Context, db dbutil.DB, allowSignup bool, info *
 	if err != nil {
 		return nil, safeErrMsg, err
 	}
+	if err := groupmapping.Sync(ctx, db, providerType, groupMapping, userID, info.groups); err != nil {
+		return nil, "Error updating the organization memberships of the user from the SAML groups.", err
+	}
 	return actor.FromUser(userID), "", nil
 }
 
@@ -127,3 +137,23 @@ func (v samlAssertionValues) Get(key string) string {
 	}
 	return ""
 }
+
+// attributeValues returns all the values of the attribute key in the assertions, e.g. the groups
+// of the user. Unlike assertion values, it includes the values of all the attributes named key,
+// as some identity providers send one attribute per value.
+func attributeValues(assertions []types.Assertion, key string) []string {
+	var values []string
+	for _, assertion := range assertions {
+		if assertion.AttributeStatement == nil {
+			continue
+		}
+		for _, a := range assertion.AttributeStatement.Attributes {
+			if a.Name == key || a.FriendlyName == key {
+				for _, v := range a.Values {
+					values = append(values, strings.TrimSpace(v.Value))
+				}
+			}
+		}
+	}
+	return values
+}
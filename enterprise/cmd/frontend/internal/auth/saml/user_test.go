@@ -12,10 +12,14 @@ import (
 	dsig "github.com/russellhaering/goxmldsig"
 
 	"github.com/sourcegraph/sourcegraph/internal/extsvc"
+	"github.com/sourcegraph/sourcegraph/schema"
 )
 
 func TestReadAuthnResponse(t *testing.T) {
 	p := &provider{
+		config: schema.SAMLAuthProvider{
+			GroupMapping: &schema.AuthGroupMapping{Attribute: "Role"},
+		},
 		samlSP: &saml2.SAMLServiceProvider{
 			IdentityProviderSSOURL:      "http://localhost:3220/auth/realms/master",
 			IdentityProviderIssuer:      "http://localhost:3220/auth/realms/master",
@@ -42,6 +46,12 @@ func TestReadAuthnResponse(t *testing.T) {
 		email:                "bob@example.com",
 		unnormalizedUsername: "bob@example.com",
 		displayName:          "Bob Yang",
+		groups: []string{
+			"view-profile", "uma_authorization", "manage-account", "manage-account-links", "admin", "view-realm",
+			"manage-identity-providers", "query-realms", "manage-clients", "query-clients", "manage-authorization",
+			"query-users", "view-users", "manage-users", "view-events", "create-realm", "create-client", "view-clients",
+			"view-identity-providers", "query-groups", "manage-events", "impersonation", "view-authorization", "manage-realm",
+		},
 	}); !reflect.DeepEqual(info, want) {
 		t.Errorf("got != want\n got %+v\nwant %+v", info, want)
 	}
@@ -2,6 +2,8 @@ package resolvers
 
 import (
 	"context"
+	"encoding/json"
+	"sort"
 	"strings"
 	"time"
 
@@ -16,6 +18,7 @@ import (
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/graphqlbackend"
 	edb "github.com/sourcegraph/sourcegraph/enterprise/internal/database"
 	"github.com/sourcegraph/sourcegraph/enterprise/internal/licensing"
+	"github.com/sourcegraph/sourcegraph/internal/actor"
 	"github.com/sourcegraph/sourcegraph/internal/api"
 	"github.com/sourcegraph/sourcegraph/internal/authz"
 	"github.com/sourcegraph/sourcegraph/internal/database"
@@ -142,7 +145,11 @@ func (r *Resolver) SetRepositoryPermissionsForUsers(ctx context.Context, args *g
 	if err != nil {
 		return nil, errors.Wrap(err, "start transaction")
 	}
-	defer func() { err = txs.Done(err) }()
+	defer func() {
+		if err = txs.Done(err); err == nil {
+			logRepoPermissionsUpdated(ctx, r.store.Handle().DB(), p, pendingBindIDs)
+		}
+	}()
 
 	accounts := &extsvc.Accounts{
 		ServiceType: authz.SourcegraphServiceType,
@@ -159,6 +166,28 @@ func (r *Resolver) SetRepositoryPermissionsForUsers(ctx context.Context, args *g
 	return &graphqlbackend.EmptyResponse{}, nil
 }
 
+// logRepoPermissionsUpdated logs the explicit permissions set on a repository as a security
+// event.
+func logRepoPermissionsUpdated(ctx context.Context, db dbutil.DB, p *authz.RepoPermissions, pendingBindIDs []string) {
+	sort.Strings(pendingBindIDs)
+	arg, err := json.Marshal(map[string]interface{}{
+		"repo_id":          p.RepoID,
+		"user_ids":         p.UserIDs.ToArray(),
+		"pending_bind_ids": pendingBindIDs,
+	})
+	if err != nil {
+		log15.Error("logRepoPermissionsUpdated: failed to marshal JSON", "error", err)
+	}
+
+	database.SecurityEventLogs(db).LogEvent(ctx, &database.SecurityEvent{
+		Name:      database.SecurityEventNameRepoPermissionsUpdated,
+		UserID:    uint32(actor.FromContext(ctx).UID),
+		Argument:  arg,
+		Source:    "BACKEND",
+		Timestamp: time.Now(),
+	})
+}
+
 func (r *Resolver) ScheduleRepositoryPermissionsSync(ctx context.Context, args *graphqlbackend.RepositoryIDArgs) (*graphqlbackend.EmptyResponse, error) {
 	if err := r.checkLicense(); err != nil {
 		return nil, err
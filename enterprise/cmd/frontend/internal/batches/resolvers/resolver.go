@@ -1000,14 +1000,22 @@ func (r *Resolver) CreateBatchChangesCredential(ctx context.Context, args *graph
 		return nil, errors.New("empty credential not allowed")
 	}
 
+	var username string
+	if args.Username != nil {
+		username = *args.Username
+	}
+	if kind == extsvc.KindBitbucketCloud && username == "" {
+		return nil, errors.New("username required for Bitbucket Cloud credentials")
+	}
+
 	if userID != 0 {
-		return r.createBatchChangesUserCredential(ctx, args.ExternalServiceURL, extsvc.KindToType(kind), userID, args.Credential)
+		return r.createBatchChangesUserCredential(ctx, args.ExternalServiceURL, extsvc.KindToType(kind), userID, username, args.Credential)
 	}
 
-	return r.createBatchChangesSiteCredential(ctx, args.ExternalServiceURL, extsvc.KindToType(kind), args.Credential)
+	return r.createBatchChangesSiteCredential(ctx, args.ExternalServiceURL, extsvc.KindToType(kind), username, args.Credential)
 }
 
-func (r *Resolver) createBatchChangesUserCredential(ctx context.Context, externalServiceURL, externalServiceType string, userID int32, credential string) (graphqlbackend.BatchChangesCredentialResolver, error) {
+func (r *Resolver) createBatchChangesUserCredential(ctx context.Context, externalServiceURL, externalServiceType string, userID int32, username, credential string) (graphqlbackend.BatchChangesCredentialResolver, error) {
 	// 🚨 SECURITY: Check that the requesting user can create the credential.
 	if err := backend.CheckSiteAdminOrSameUser(ctx, r.store.DB(), userID); err != nil {
 		return nil, err
@@ -1028,7 +1036,7 @@ func (r *Resolver) createBatchChangesUserCredential(ctx context.Context, externa
 		return nil, ErrDuplicateCredential{}
 	}
 
-	a, err := r.generateAuthenticatorForCredential(ctx, externalServiceType, externalServiceURL, credential)
+	a, err := r.generateAuthenticatorForCredential(ctx, externalServiceType, externalServiceURL, username, credential)
 	if err != nil {
 		return nil, err
 	}
@@ -1040,7 +1048,7 @@ func (r *Resolver) createBatchChangesUserCredential(ctx context.Context, externa
 	return &batchChangesUserCredentialResolver{credential: cred}, nil
 }
 
-func (r *Resolver) createBatchChangesSiteCredential(ctx context.Context, externalServiceURL, externalServiceType string, credential string) (graphqlbackend.BatchChangesCredentialResolver, error) {
+func (r *Resolver) createBatchChangesSiteCredential(ctx context.Context, externalServiceURL, externalServiceType, username, credential string) (graphqlbackend.BatchChangesCredentialResolver, error) {
 	// 🚨 SECURITY: Check that a site credential can only be created
 	// by a site-admin.
 	if err := backend.CheckCurrentUserIsSiteAdmin(ctx, r.store.DB()); err != nil {
@@ -1059,7 +1067,7 @@ func (r *Resolver) createBatchChangesSiteCredential(ctx context.Context, externa
 		return nil, ErrDuplicateCredential{}
 	}
 
-	a, err := r.generateAuthenticatorForCredential(ctx, externalServiceType, externalServiceURL, credential)
+	a, err := r.generateAuthenticatorForCredential(ctx, externalServiceType, externalServiceURL, username, credential)
 	if err != nil {
 		return nil, err
 	}
@@ -1074,7 +1082,7 @@ func (r *Resolver) createBatchChangesSiteCredential(ctx context.Context, externa
 	return &batchChangesSiteCredentialResolver{credential: cred}, nil
 }
 
-func (r *Resolver) generateAuthenticatorForCredential(ctx context.Context, externalServiceType, externalServiceURL, credential string) (auth.Authenticator, error) {
+func (r *Resolver) generateAuthenticatorForCredential(ctx context.Context, externalServiceType, externalServiceURL, username, credential string) (auth.Authenticator, error) {
 	svc := service.New(r.store)
 
 	var a auth.Authenticator
@@ -1082,7 +1090,8 @@ func (r *Resolver) generateAuthenticatorForCredential(ctx context.Context, exter
 	if err != nil {
 		return nil, err
 	}
-	if externalServiceType == extsvc.TypeBitbucketServer {
+	switch externalServiceType {
+	case extsvc.TypeBitbucketServer:
 		// We need to fetch the username for the token, as just an OAuth token isn't enough for some reason..
 		username, err := svc.FetchUsernameForBitbucketServerToken(ctx, externalServiceURL, externalServiceType, credential)
 		if err != nil {
@@ -1097,7 +1106,16 @@ func (r *Resolver) generateAuthenticatorForCredential(ctx context.Context, exter
 			PublicKey:  keypair.PublicKey,
 			Passphrase: keypair.Passphrase,
 		}
-	} else {
+	case extsvc.TypeBitbucketCloud:
+		// Bitbucket Cloud app passwords are used with the username of the
+		// account they were created for.
+		a = &auth.BasicAuthWithSSH{
+			BasicAuth:  auth.BasicAuth{Username: username, Password: credential},
+			PrivateKey: keypair.PrivateKey,
+			PublicKey:  keypair.PublicKey,
+			Passphrase: keypair.Passphrase,
+		}
+	default:
 		a = &auth.OAuthBearerTokenWithSSH{
 			OAuthBearerToken: auth.OAuthBearerToken{Token: credential},
 			PrivateKey:       keypair.PrivateKey,
@@ -0,0 +1,211 @@
+package webhooks
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/cockroachdb/errors"
+	"github.com/inconshreveable/log15"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/webhooks"
+	"github.com/sourcegraph/sourcegraph/enterprise/internal/batches/store"
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/database"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/bitbucketcloud"
+	"github.com/sourcegraph/sourcegraph/internal/types"
+	"github.com/sourcegraph/sourcegraph/internal/vcs/git"
+	"github.com/sourcegraph/sourcegraph/schema"
+)
+
+// bitbucketCloudSecretParam is the query parameter holding the shared webhook
+// secret, since Bitbucket Cloud doesn't sign webhook payloads.
+const bitbucketCloudSecretParam = "secret"
+
+type BitbucketCloudWebhook struct {
+	*Webhook
+}
+
+func NewBitbucketCloudWebhook(store *store.Store) *BitbucketCloudWebhook {
+	return &BitbucketCloudWebhook{
+		Webhook: &Webhook{store, extsvc.TypeBitbucketCloud},
+	}
+}
+
+func (h *BitbucketCloudWebhook) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	e, extSvc, hErr := h.parseEvent(r)
+	if hErr != nil {
+		respond(w, hErr.code, hErr)
+		return
+	}
+
+	// Pushes make repo-updater fetch the repository right away.
+	if e, ok := e.(*bitbucketcloud.PushEvent); ok {
+		if err := webhooks.EnqueueRepoUpdate(r.Context(), h.Store.Handle().DB(), extSvc, e.Repository.UUID); err != nil {
+			respond(w, http.StatusInternalServerError, errors.Wrap(err, "enqueuing repo update"))
+		}
+		return
+	}
+
+	externalServiceID, err := extractExternalServiceID(extSvc)
+	if err != nil {
+		respond(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	pr, ev, err := h.convertEvent(r.Context(), externalServiceID, e)
+	if err != nil {
+		respond(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if pr == (PR{}) {
+		log15.Debug("Dropping Bitbucket Cloud webhook event", "type", fmt.Sprintf("%T", e))
+		return
+	}
+
+	if err := h.upsertChangesetEvent(r.Context(), externalServiceID, pr, ev); err != nil {
+		respond(w, http.StatusInternalServerError, err)
+	}
+}
+
+func (h *BitbucketCloudWebhook) parseEvent(r *http.Request) (interface{}, *types.ExternalService, *httpError) {
+	payload, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, nil, &httpError{http.StatusInternalServerError, err}
+	}
+
+	secret := r.FormValue(bitbucketCloudSecretParam)
+	if secret == "" {
+		return nil, nil, &httpError{http.StatusUnauthorized, errors.New("missing webhook secret")}
+	}
+
+	rawID := r.FormValue(extsvc.IDParam)
+	var externalServiceID int64
+	if rawID != "" {
+		externalServiceID, err = strconv.ParseInt(rawID, 10, 64)
+		if err != nil {
+			return nil, nil, &httpError{http.StatusBadRequest, errors.Wrap(err, "invalid external service id")}
+		}
+	}
+
+	args := database.ExternalServicesListOptions{Kinds: []string{extsvc.KindBitbucketCloud}}
+	if externalServiceID != 0 {
+		args.IDs = append(args.IDs, externalServiceID)
+	}
+	es, err := h.Store.ExternalServices().List(r.Context(), args)
+	if err != nil {
+		return nil, nil, &httpError{http.StatusInternalServerError, err}
+	}
+
+	var extSvc *types.ExternalService
+	for _, e := range es {
+		if ok, _ := validateBitbucketCloudSecret(e, secret); ok {
+			extSvc = e
+			break
+		}
+	}
+
+	if extSvc == nil {
+		return nil, nil, &httpError{http.StatusUnauthorized, errors.New("invalid webhook secret")}
+	}
+
+	e, err := bitbucketcloud.ParseWebhookEvent(bitbucketcloud.WebhookEventType(r), payload)
+	if err != nil {
+		return nil, nil, &httpError{http.StatusBadRequest, errors.Wrap(err, "parsing webhook")}
+	}
+	return e, extSvc, nil
+}
+
+func (h *BitbucketCloudWebhook) convertEvent(ctx context.Context, externalServiceID string, theirs interface{}) (pr PR, ours keyer, err error) {
+	log15.Debug("Bitbucket Cloud webhook received", "type", fmt.Sprintf("%T", theirs))
+
+	switch e := theirs.(type) {
+	case *bitbucketcloud.PullRequestActivityEvent:
+		pr = PR{ID: e.PullRequest.ID, RepoExternalID: e.Repository.UUID}
+		return pr, e.Activity, nil
+
+	case *bitbucketcloud.RepoCommitStatusEvent:
+		// Build status payloads don't reference the pull request, so we have
+		// to find the changeset through its branch instead.
+		status := e.CommitStatus
+		if status.Commit == nil || status.RefName == "" {
+			return PR{}, nil, nil
+		}
+
+		pr, err = h.prForBranch(ctx, externalServiceID, e.Repository.UUID, status.RefName)
+		if err != nil {
+			return PR{}, nil, err
+		}
+		return pr, &bitbucketcloud.CommitStatus{
+			Commit: status.Commit.Hash,
+			Status: status,
+		}, nil
+	}
+
+	return PR{}, nil, nil
+}
+
+// prForBranch returns the PR of the changeset that was opened for the given
+// branch, if there is one.
+func (h *BitbucketCloudWebhook) prForBranch(ctx context.Context, externalServiceID, repoUUID, branch string) (PR, error) {
+	rs, err := h.Store.Repos().List(ctx, database.ReposListOptions{
+		ExternalRepos: []api.ExternalRepoSpec{{
+			ID:          repoUUID,
+			ServiceType: h.ServiceType,
+			ServiceID:   externalServiceID,
+		}},
+	})
+	if err != nil {
+		return PR{}, errors.Wrap(err, "failed to load repository")
+	}
+	if len(rs) != 1 {
+		return PR{}, nil
+	}
+
+	cs, err := h.Store.GetChangeset(ctx, store.GetChangesetOpts{
+		RepoID:              rs[0].ID,
+		ExternalBranch:      git.EnsureRefPrefix(branch),
+		ExternalServiceType: h.ServiceType,
+	})
+	if err != nil {
+		if err == store.ErrNoResults {
+			err = nil // Nothing to do
+		}
+		return PR{}, err
+	}
+
+	id, err := strconv.ParseInt(cs.ExternalID, 10, 64)
+	if err != nil {
+		return PR{}, errors.Wrapf(err, "parsing changeset external ID %s", cs.ExternalID)
+	}
+	return PR{ID: id, RepoExternalID: repoUUID}, nil
+}
+
+// validateBitbucketCloudSecret reports whether the given secret matches the
+// webhook secret of the external service.
+func validateBitbucketCloudSecret(extSvc *types.ExternalService, secret string) (bool, error) {
+	// An empty secret never succeeds.
+	if secret == "" {
+		return false, nil
+	}
+
+	c, err := extSvc.Configuration()
+	if err != nil {
+		return false, errors.Wrap(err, "getting external service configuration")
+	}
+
+	config, ok := c.(*schema.BitbucketCloudConnection)
+	if !ok {
+		return false, errExternalServiceWrongKind
+	}
+
+	if config.WebhookSecret == "" {
+		return false, nil
+	}
+	return subtle.ConstantTimeCompare([]byte(config.WebhookSecret), []byte(secret)) == 1, nil
+}
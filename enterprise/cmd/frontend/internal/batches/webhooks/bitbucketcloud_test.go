@@ -0,0 +1,75 @@
+package webhooks
+
+import (
+	"testing"
+
+	ct "github.com/sourcegraph/sourcegraph/enterprise/internal/batches/testing"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc"
+	"github.com/sourcegraph/sourcegraph/internal/types"
+	"github.com/sourcegraph/sourcegraph/schema"
+)
+
+func TestValidateBitbucketCloudSecret(t *testing.T) {
+	t.Parallel()
+
+	t.Run("empty secret", func(t *testing.T) {
+		ok, err := validateBitbucketCloudSecret(nil, "")
+		if ok {
+			t.Errorf("unexpected ok: %v", ok)
+		}
+		if err != nil {
+			t.Errorf("unexpected non-nil error: %+v", err)
+		}
+	})
+
+	t.Run("not a Bitbucket Cloud connection", func(t *testing.T) {
+		es := &types.ExternalService{Kind: extsvc.KindGitHub}
+		ok, err := validateBitbucketCloudSecret(es, "secret")
+		if ok {
+			t.Errorf("unexpected ok: %v", ok)
+		}
+		if err != errExternalServiceWrongKind {
+			t.Errorf("unexpected error: have %+v; want %+v", err, errExternalServiceWrongKind)
+		}
+	})
+
+	t.Run("no webhook secret", func(t *testing.T) {
+		es := &types.ExternalService{
+			Kind:   extsvc.KindBitbucketCloud,
+			Config: ct.MarshalJSON(t, &schema.BitbucketCloudConnection{}),
+		}
+
+		ok, err := validateBitbucketCloudSecret(es, "secret")
+		if ok {
+			t.Errorf("unexpected ok: %v", ok)
+		}
+		if err != nil {
+			t.Errorf("unexpected non-nil error: %+v", err)
+		}
+	})
+
+	t.Run("webhook secret", func(t *testing.T) {
+		for secret, want := range map[string]bool{
+			"not secret": false,
+			"secret":     true,
+			"secre":      false,
+		} {
+			t.Run(secret, func(t *testing.T) {
+				es := &types.ExternalService{
+					Kind: extsvc.KindBitbucketCloud,
+					Config: ct.MarshalJSON(t, &schema.BitbucketCloudConnection{
+						WebhookSecret: "secret",
+					}),
+				}
+
+				ok, err := validateBitbucketCloudSecret(es, secret)
+				if ok != want {
+					t.Errorf("unexpected ok: have %v; want %v", ok, want)
+				}
+				if err != nil {
+					t.Errorf("unexpected non-nil error: %+v", err)
+				}
+			})
+		}
+	})
+}
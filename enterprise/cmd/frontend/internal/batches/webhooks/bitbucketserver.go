@@ -11,6 +11,7 @@ import (
 	"github.com/hashicorp/go-multierror"
 	"github.com/inconshreveable/log15"
 
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/webhooks"
 	"github.com/sourcegraph/sourcegraph/enterprise/internal/batches/store"
 	"github.com/sourcegraph/sourcegraph/internal/database"
 	"github.com/sourcegraph/sourcegraph/internal/extsvc"
@@ -36,6 +37,14 @@ func (h *BitbucketServerWebhook) ServeHTTP(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
+	// Pushes make repo-updater fetch the repository right away.
+	if e, ok := e.(*bitbucketserver.RepositoryRefsChangedEvent); ok {
+		if err := webhooks.EnqueueRepoUpdate(r.Context(), h.Store.Handle().DB(), extSvc, strconv.Itoa(e.Repository.ID)); err != nil {
+			respond(w, http.StatusInternalServerError, errors.Wrap(err, "enqueuing repo update"))
+		}
+		return
+	}
+
 	externalServiceID, err := extractExternalServiceID(extSvc)
 	if err != nil {
 		respond(w, http.StatusInternalServerError, err)
@@ -10,6 +10,7 @@ import (
 	"github.com/cockroachdb/errors"
 	"github.com/inconshreveable/log15"
 
+	fewebhooks "github.com/sourcegraph/sourcegraph/cmd/frontend/webhooks"
 	"github.com/sourcegraph/sourcegraph/enterprise/internal/batches/store"
 	btypes "github.com/sourcegraph/sourcegraph/enterprise/internal/batches/types"
 	"github.com/sourcegraph/sourcegraph/internal/database"
@@ -199,6 +200,18 @@ func (h *GitLabWebhook) handleEvent(ctx context.Context, extSvc *types.ExternalS
 			}
 		}
 		return nil
+
+	// Push events aren't about changesets, but we handle them here since
+	// GitLab only lets us configure one webhook URL: they make repo-updater
+	// fetch the pushed project right away.
+	case *webhooks.PushEvent:
+		if err := fewebhooks.EnqueueRepoUpdate(ctx, h.Store.Handle().DB(), extSvc, strconv.Itoa(e.Project.ID)); err != nil {
+			return &httpError{
+				code: http.StatusInternalServerError,
+				err:  errors.Wrap(err, "enqueuing repo update"),
+			}
+		}
+		return nil
 	}
 
 	// We don't want to return a non-2XX status code and have GitLab retry the
@@ -18,6 +18,7 @@ import (
 	"github.com/sourcegraph/sourcegraph/enterprise/internal/codeintel/stores/uploadstore"
 	"github.com/sourcegraph/sourcegraph/internal/actor"
 	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/authz"
 	"github.com/sourcegraph/sourcegraph/internal/conf"
 	"github.com/sourcegraph/sourcegraph/internal/errcode"
 	"github.com/sourcegraph/sourcegraph/internal/gitserver"
@@ -49,6 +50,13 @@ var revhashPattern = lazyregexp.New(`^[a-z0-9]{40}$`)
 func (h *UploadHandler) handleEnqueue(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
+	// 🚨 SECURITY: Access tokens without the "user:all" scope must have the "codeintel:upload"
+	// scope to upload indexes.
+	if !h.internal && !actor.FromContext(ctx).HasScope(authz.ScopeCodeIntelUpload) {
+		http.Error(w, "The access token's scopes do not allow uploading code intelligence indexes.", http.StatusForbidden)
+		return
+	}
+
 	var repositoryID int
 	if !hasQuery(r, "uploadId") {
 		repoName := getQuery(r, "repository")
@@ -19,7 +19,9 @@ import (
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/backend"
 	store "github.com/sourcegraph/sourcegraph/enterprise/internal/codeintel/stores/dbstore"
 	uploadstoremocks "github.com/sourcegraph/sourcegraph/enterprise/internal/codeintel/stores/uploadstore/mocks"
+	"github.com/sourcegraph/sourcegraph/internal/actor"
 	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/authz"
 	"github.com/sourcegraph/sourcegraph/internal/types"
 )
 
@@ -441,3 +443,28 @@ func setupRepoMocks(t testing.TB) {
 		return "", nil
 	}
 }
+
+func TestHandleEnqueueAccessTokenScopes(t *testing.T) {
+	mockDBStore := NewMockDBStore()
+	mockUploadStore := uploadstoremocks.NewMockStore()
+
+	r, err := http.NewRequest("POST", "http://test.com/upload?uploadId=42&index=0", nil)
+	if err != nil {
+		t.Fatalf("unexpected error constructing request: %s", err)
+	}
+	r = r.WithContext(actor.WithActor(r.Context(), &actor.Actor{UID: 1, Scopes: []string{authz.ScopeAPIRead}}))
+
+	w := httptest.NewRecorder()
+	h := &UploadHandler{
+		dbStore:     mockDBStore,
+		uploadStore: mockUploadStore,
+	}
+	h.handleEnqueue(w, r)
+
+	if w.Code != http.StatusForbidden {
+		t.Errorf("unexpected status code. want=%d have=%d", http.StatusForbidden, w.Code)
+	}
+	if calls := len(mockDBStore.GetUploadByIDFunc.History()); calls != 0 {
+		t.Errorf("unexpected number of GetUploadByID calls. want=%d have=%d", 0, calls)
+	}
+}
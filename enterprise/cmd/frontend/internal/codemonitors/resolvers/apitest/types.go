@@ -9,6 +9,10 @@ type UpdateCodeMonitorResponse struct {
 	UpdateCodeMonitor Monitor
 }
 
+type UpdateCodeMonitorWebhooksResponse struct {
+	UpdateCodeMonitor MonitorWithWebhooks
+}
+
 type User struct {
 	Monitors MonitorConnection
 }
@@ -34,6 +38,13 @@ type Monitor struct {
 	Actions     ActionConnection
 }
 
+// MonitorWithWebhooks is a monitor whose actions are all webhook or Slack
+// webhook actions.
+type MonitorWithWebhooks struct {
+	Id      string
+	Actions WebhookConnection
+}
+
 type UserOrg struct {
 	Name string `json:"username"`
 }
@@ -62,6 +73,16 @@ type ActionEmail struct {
 	Events     ActionEventConnection
 }
 
+type WebhookConnection struct {
+	Nodes []ActionWebhook
+}
+
+type ActionWebhook struct {
+	Id      string
+	Enabled bool
+	URL     string
+}
+
 type RecipientsConnection struct {
 	Nodes      []UserOrg
 	TotalCount int
@@ -175,6 +175,9 @@ func (r *Resolver) UpdateCodeMonitor(ctx context.Context, args *graphqlbackend.U
 	}
 
 	toCreate, toDelete, err := splitActionIDs(ctx, args, actionIDs)
+	if err != nil {
+		return nil, err
+	}
 	if len(toDelete) == len(actionIDs) {
 		return nil, errors.Errorf("you tried to delete all actions, but every monitor must be connected to at least 1 action")
 	}
@@ -187,7 +190,7 @@ func (r *Resolver) UpdateCodeMonitor(ctx context.Context, args *graphqlbackend.U
 	}
 	defer func() { err = tx.store.Done(err) }()
 
-	err = tx.store.DeleteActionsInt64(ctx, toDelete, monitorID)
+	err = tx.deleteActions(ctx, toDelete, monitorID)
 	if err != nil {
 		return nil, err
 	}
@@ -283,6 +286,22 @@ func (r *Resolver) actionIDsForMonitorIDInt64(ctx context.Context, monitorID int
 		}
 		after = cur
 	}
+
+	ws, err := r.store.ListActionWebhooks(ctx, monitorID)
+	if err != nil {
+		return nil, err
+	}
+	for _, w := range ws {
+		ids = append(ids, (&monitorWebhook{MonitorWebhook: w}).ID())
+	}
+
+	ss, err := r.store.ListActionSlackWebhooks(ctx, monitorID)
+	if err != nil {
+		return nil, err
+	}
+	for _, w := range ss {
+		ids = append(ids, (&monitorSlackWebhook{MonitorWebhook: w}).ID())
+	}
 	return ids, nil
 }
 
@@ -314,35 +333,78 @@ func (r *Resolver) actionIDsForMonitorIDINT64SinglePage(ctx context.Context, q *
 
 // splitActionIDs splits actions into three buckets: create, delete and update.
 // Note: args is mutated. After splitActionIDs, args only contains actions to be updated.
-func splitActionIDs(ctx context.Context, args *graphqlbackend.UpdateCodeMonitorArgs, actionIDs []graphql.ID) (toCreate []*graphqlbackend.CreateActionArgs, toDelete []int64, err error) {
+func splitActionIDs(ctx context.Context, args *graphqlbackend.UpdateCodeMonitorArgs, actionIDs []graphql.ID) (toCreate []*graphqlbackend.CreateActionArgs, toDelete []graphql.ID, err error) {
 	aMap := make(map[graphql.ID]struct{}, len(actionIDs))
 	for _, id := range actionIDs {
 		aMap[id] = struct{}{}
 	}
 	var toUpdateActions []*graphqlbackend.EditActionArgs
 	for _, a := range args.Actions {
-		if a.Email.Id == nil {
-			toCreate = append(toCreate, &graphqlbackend.CreateActionArgs{Email: a.Email.Update})
-			continue
+		var id *graphql.ID
+		switch {
+		case a.Email != nil:
+			if a.Email.Id == nil {
+				toCreate = append(toCreate, &graphqlbackend.CreateActionArgs{Email: a.Email.Update})
+				continue
+			}
+			id = a.Email.Id
+		case a.Webhook != nil:
+			if a.Webhook.Id == nil {
+				toCreate = append(toCreate, &graphqlbackend.CreateActionArgs{Webhook: a.Webhook.Update})
+				continue
+			}
+			id = a.Webhook.Id
+		case a.SlackWebhook != nil:
+			if a.SlackWebhook.Id == nil {
+				toCreate = append(toCreate, &graphqlbackend.CreateActionArgs{SlackWebhook: a.SlackWebhook.Update})
+				continue
+			}
+			id = a.SlackWebhook.Id
+		default:
+			return nil, nil, errors.Errorf("action must specify one of email, webhook or slackWebhook")
 		}
-		if _, ok := aMap[*a.Email.Id]; !ok {
-			return nil, nil, errors.Errorf("unknown ID=%s for action", *a.Email.Id)
+		if _, ok := aMap[*id]; !ok {
+			return nil, nil, errors.Errorf("unknown ID=%s for action", *id)
 		}
 		toUpdateActions = append(toUpdateActions, a)
-		delete(aMap, *a.Email.Id)
+		delete(aMap, *id)
 	}
-	var actionID int64
 	for k := range aMap {
-		err = relay.UnmarshalSpec(k, &actionID)
-		if err != nil {
-			return nil, nil, err
-		}
-		toDelete = append(toDelete, actionID)
+		toDelete = append(toDelete, k)
 	}
 	args.Actions = toUpdateActions
 	return toCreate, toDelete, nil
 }
 
+// deleteActions deletes the actions with the given IDs, which may be of any
+// action kind.
+func (r *Resolver) deleteActions(ctx context.Context, actionIDs []graphql.ID, monitorID int64) error {
+	var emailIDs, webhookIDs, slackWebhookIDs []int64
+	for _, id := range actionIDs {
+		var actionID int64
+		if err := relay.UnmarshalSpec(id, &actionID); err != nil {
+			return err
+		}
+		switch kind := relay.UnmarshalKind(id); kind {
+		case monitorActionEmailKind:
+			emailIDs = append(emailIDs, actionID)
+		case monitorActionWebhookKind:
+			webhookIDs = append(webhookIDs, actionID)
+		case monitorActionSlackWebhookKind:
+			slackWebhookIDs = append(slackWebhookIDs, actionID)
+		default:
+			return errors.Errorf("unknown action kind %q", kind)
+		}
+	}
+	if err := r.store.DeleteActionsInt64(ctx, emailIDs, monitorID); err != nil {
+		return err
+	}
+	if err := r.store.DeleteActionWebhooks(ctx, webhookIDs, monitorID); err != nil {
+		return err
+	}
+	return r.store.DeleteActionSlackWebhooks(ctx, slackWebhookIDs, monitorID)
+}
+
 func (r *Resolver) updateCodeMonitor(ctx context.Context, args *graphqlbackend.UpdateCodeMonitorArgs) (m graphqlbackend.MonitorResolver, err error) {
 	// Update monitor.
 	var mo *cm.Monitor
@@ -365,24 +427,36 @@ func (r *Resolver) updateCodeMonitor(ctx context.Context, args *graphqlbackend.U
 	var emailID int64
 	var e *cm.MonitorEmail
 	for i, action := range args.Actions {
-		if action.Email == nil {
-			return nil, errors.Errorf("missing email object for action %d", i)
-		}
-		err = relay.UnmarshalSpec(*action.Email.Id, &emailID)
-		if err != nil {
-			return nil, err
-		}
-		err = r.store.DeleteRecipients(ctx, emailID)
-		if err != nil {
-			return nil, err
-		}
-		e, err = r.store.UpdateActionEmail(ctx, mo.ID, action)
-		if err != nil {
-			return nil, err
-		}
-		err = r.store.CreateRecipients(ctx, action.Email.Update.Recipients, e.Id)
-		if err != nil {
-			return nil, err
+		switch {
+		case action.Email != nil:
+			err = relay.UnmarshalSpec(*action.Email.Id, &emailID)
+			if err != nil {
+				return nil, err
+			}
+			err = r.store.DeleteRecipients(ctx, emailID)
+			if err != nil {
+				return nil, err
+			}
+			e, err = r.store.UpdateActionEmail(ctx, mo.ID, action)
+			if err != nil {
+				return nil, err
+			}
+			err = r.store.CreateRecipients(ctx, action.Email.Update.Recipients, e.Id)
+			if err != nil {
+				return nil, err
+			}
+		case action.Webhook != nil:
+			_, err = r.store.UpdateActionWebhook(ctx, mo.ID, action.Webhook)
+			if err != nil {
+				return nil, err
+			}
+		case action.SlackWebhook != nil:
+			_, err = r.store.UpdateActionSlackWebhook(ctx, mo.ID, action.SlackWebhook)
+			if err != nil {
+				return nil, err
+			}
+		default:
+			return nil, errors.Errorf("missing action object for action %d", i)
 		}
 	}
 	return &monitor{
@@ -526,6 +600,8 @@ const (
 	monitorTriggerQueryKind         = "CodeMonitorTriggerQuery"
 	monitorTriggerEventKind         = "CodeMonitorTriggerEvent"
 	monitorActionEmailKind          = "CodeMonitorActionEmail"
+	monitorActionWebhookKind        = "CodeMonitorActionWebhook"
+	monitorActionSlackWebhookKind   = "CodeMonitorActionSlackWebhook"
 	monitorActionEventKind          = "CodeMonitorActionEmailEvent"
 	monitorActionEmailRecipientKind = "CodeMonitorActionEmailRecipient"
 )
@@ -572,26 +648,22 @@ func (m *monitor) Actions(ctx context.Context, args *graphqlbackend.ListActionAr
 }
 
 func (r *Resolver) actionConnectionResolverWithTriggerID(ctx context.Context, triggerEventID *int, monitorID int64, args *graphqlbackend.ListActionArgs) (graphqlbackend.MonitorActionConnectionResolver, error) {
-	// For now, we only support emails as actions. Once we add other actions such as
-	// webhooks, we have to query those tables here too.
-	q, err := r.store.ReadActionEmailQuery(ctx, monitorID, args)
-	if err != nil {
-		return nil, err
-	}
-	rows, err := r.store.Query(ctx, q)
+	// A monitor only has a handful of actions, so we load the actions of all
+	// kinds and paginate over them in memory.
+	es, err := r.store.ListActionEmails(ctx, monitorID)
 	if err != nil {
 		return nil, err
 	}
-	defer rows.Close()
-	es, err := cm.ScanEmails(rows)
+	ws, err := r.store.ListActionWebhooks(ctx, monitorID)
 	if err != nil {
 		return nil, err
 	}
-	totalCount, err := r.store.TotalCountActionEmails(ctx, monitorID)
+	ss, err := r.store.ListActionSlackWebhooks(ctx, monitorID)
 	if err != nil {
 		return nil, err
 	}
-	actions := make([]graphqlbackend.MonitorAction, 0, len(es))
+
+	actions := make([]*action, 0, len(es)+len(ws)+len(ss))
 	for _, e := range es {
 		actions = append(actions, &action{
 			email: &monitorEmail{
@@ -601,7 +673,43 @@ func (r *Resolver) actionConnectionResolverWithTriggerID(ctx context.Context, tr
 			},
 		})
 	}
-	return &monitorActionConnection{actions: actions, totalCount: totalCount}, nil
+	for _, w := range ws {
+		actions = append(actions, &action{
+			webhook: &monitorWebhook{
+				Resolver:       r,
+				MonitorWebhook: w,
+				triggerEventID: triggerEventID,
+			},
+		})
+	}
+	for _, w := range ss {
+		actions = append(actions, &action{
+			slackWebhook: &monitorSlackWebhook{
+				Resolver:       r,
+				MonitorWebhook: w,
+				triggerEventID: triggerEventID,
+			},
+		})
+	}
+	totalCount := int32(len(actions))
+
+	if args.After != nil {
+		for i, a := range actions {
+			if string(a.ID()) == *args.After {
+				actions = actions[i+1:]
+				break
+			}
+		}
+	}
+	if args.First >= 0 && int(args.First) < len(actions) {
+		actions = actions[:args.First]
+	}
+
+	nodes := make([]graphqlbackend.MonitorAction, 0, len(actions))
+	for _, a := range actions {
+		nodes = append(nodes, a)
+	}
+	return &monitorActionConnection{actions: nodes, totalCount: totalCount}, nil
 }
 
 //
@@ -739,24 +847,45 @@ func (a *monitorActionConnection) PageInfo(ctx context.Context) (*graphqlutil.Pa
 	if len(a.actions) == 0 {
 		return graphqlutil.HasNextPage(false), nil
 	}
-	last := a.actions[len(a.actions)-1]
-	if email, ok := last.ToMonitorEmail(); ok {
-		return graphqlutil.NextPageCursor(string(email.ID())), nil
+	last, ok := a.actions[len(a.actions)-1].(*action)
+	if !ok {
+		return nil, errors.Errorf("unexpected action type %T", a.actions[len(a.actions)-1])
 	}
-	return nil, errors.Errorf("we only support email actions for now")
+	return graphqlutil.NextPageCursor(string(last.ID())), nil
 }
 
 //
 // Action <<UNION>>
 //
 type action struct {
-	email graphqlbackend.MonitorEmailResolver
+	email        graphqlbackend.MonitorEmailResolver
+	webhook      graphqlbackend.MonitorWebhookResolver
+	slackWebhook graphqlbackend.MonitorSlackWebhookResolver
+}
+
+func (a *action) ID() graphql.ID {
+	switch {
+	case a.email != nil:
+		return a.email.ID()
+	case a.webhook != nil:
+		return a.webhook.ID()
+	default:
+		return a.slackWebhook.ID()
+	}
 }
 
 func (a *action) ToMonitorEmail() (graphqlbackend.MonitorEmailResolver, bool) {
 	return a.email, a.email != nil
 }
 
+func (a *action) ToMonitorWebhook() (graphqlbackend.MonitorWebhookResolver, bool) {
+	return a.webhook, a.webhook != nil
+}
+
+func (a *action) ToMonitorSlackWebhook() (graphqlbackend.MonitorSlackWebhookResolver, bool) {
+	return a.slackWebhook, a.slackWebhook != nil
+}
+
 //
 // Email
 //
@@ -838,6 +967,88 @@ func (m *monitorEmail) Events(ctx context.Context, args *graphqlbackend.ListEven
 	return &monitorActionEventConnection{events: events, totalCount: totalCount}, nil
 }
 
+//
+// Webhook
+//
+type monitorWebhook struct {
+	*Resolver
+	*cm.MonitorWebhook
+
+	// If triggerEventID == nil, all events of this action will be returned.
+	// Otherwise, only those events of this action which are related to the specified
+	// trigger event will be returned.
+	triggerEventID *int
+}
+
+func (m *monitorWebhook) ID() graphql.ID {
+	return relay.MarshalID(monitorActionWebhookKind, m.Id)
+}
+
+func (m *monitorWebhook) Enabled() bool {
+	return m.MonitorWebhook.Enabled
+}
+
+func (m *monitorWebhook) URL() string {
+	return cm.RedactedWebhookURL(m.MonitorWebhook.URL)
+}
+
+func (m *monitorWebhook) Events(ctx context.Context, args *graphqlbackend.ListEventsArgs) (graphqlbackend.MonitorActionEventConnectionResolver, error) {
+	ajs, err := m.store.ReadActionWebhookEvents(ctx, m.Id, m.triggerEventID, args)
+	if err != nil {
+		return nil, err
+	}
+	totalCount, err := m.store.TotalActionWebhookEvents(ctx, m.Id, m.triggerEventID)
+	if err != nil {
+		return nil, err
+	}
+	events := make([]graphqlbackend.MonitorActionEventResolver, len(ajs))
+	for i, aj := range ajs {
+		events[i] = &monitorActionEvent{Resolver: m.Resolver, ActionJob: aj}
+	}
+	return &monitorActionEventConnection{events: events, totalCount: totalCount}, nil
+}
+
+//
+// Slack webhook
+//
+type monitorSlackWebhook struct {
+	*Resolver
+	*cm.MonitorWebhook
+
+	// If triggerEventID == nil, all events of this action will be returned.
+	// Otherwise, only those events of this action which are related to the specified
+	// trigger event will be returned.
+	triggerEventID *int
+}
+
+func (m *monitorSlackWebhook) ID() graphql.ID {
+	return relay.MarshalID(monitorActionSlackWebhookKind, m.Id)
+}
+
+func (m *monitorSlackWebhook) Enabled() bool {
+	return m.MonitorWebhook.Enabled
+}
+
+func (m *monitorSlackWebhook) URL() string {
+	return cm.RedactedWebhookURL(m.MonitorWebhook.URL)
+}
+
+func (m *monitorSlackWebhook) Events(ctx context.Context, args *graphqlbackend.ListEventsArgs) (graphqlbackend.MonitorActionEventConnectionResolver, error) {
+	ajs, err := m.store.ReadActionSlackWebhookEvents(ctx, m.Id, m.triggerEventID, args)
+	if err != nil {
+		return nil, err
+	}
+	totalCount, err := m.store.TotalActionSlackWebhookEvents(ctx, m.Id, m.triggerEventID)
+	if err != nil {
+		return nil, err
+	}
+	events := make([]graphqlbackend.MonitorActionEventResolver, len(ajs))
+	for i, aj := range ajs {
+		events[i] = &monitorActionEvent{Resolver: m.Resolver, ActionJob: aj}
+	}
+	return &monitorActionEventConnection{events: events, totalCount: totalCount}, nil
+}
+
 //
 // MonitorActionEmailRecipientConnection
 //
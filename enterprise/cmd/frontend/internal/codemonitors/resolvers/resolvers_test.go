@@ -21,6 +21,8 @@ import (
 	"github.com/sourcegraph/sourcegraph/internal/actor"
 	"github.com/sourcegraph/sourcegraph/internal/database"
 	"github.com/sourcegraph/sourcegraph/internal/database/dbtesting"
+	"github.com/sourcegraph/sourcegraph/internal/encryption/keyring"
+	et "github.com/sourcegraph/sourcegraph/internal/encryption/testing"
 )
 
 func init() {
@@ -396,7 +398,7 @@ func TestQueryMonitor(t *testing.T) {
 		func() error { return r.store.EnqueueTriggerQueries(ctx) },
 		// To have a consistent state we have to log the number of search results for
 		// each completed trigger job.
-		func() error { return r.store.LogSearch(ctx, "", 1, 1) },
+		func() error { return r.store.LogSearch(ctx, "", 1, nil, 1) },
 	})
 	_, err = r.insertTestMonitorWithOpts(ctx, t, actionOpt, postHookOpt)
 	if err != nil {
@@ -775,6 +777,122 @@ mutation ($monitorID: ID!, $triggerID: ID!, $actionID: ID!, $user1ID: ID!, $user
 }
 `
 
+func TestEditCodeMonitorWebhooks(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+
+	keyring.MockDefault(keyring.Ring{CodeMonitorWebhookKey: et.TestKey{}})
+	defer keyring.MockDefault(keyring.Ring{})
+
+	ctx := actor.WithInternalActor(context.Background())
+	db := dbtesting.GetDB(t)
+	r := newTestResolver(t, db)
+
+	userID := insertTestUser(t, db, "cm-user1", true)
+	ns := relay.MarshalID("User", userID)
+
+	const (
+		webhookURL         = "https://example.com/hooks/secret"
+		slackWebhookURL    = "https://hooks.slack.com/services/T000/B000/XXXX"
+		newSlackWebhookURL = "https://hooks.slack.com/services/T000/B000/YYYY"
+	)
+
+	// Create a code monitor with a webhook and a Slack webhook action.
+	ctx = actor.WithActor(ctx, actor.FromUser(userID))
+	actionOpt := WithActions([]*graphqlbackend.CreateActionArgs{
+		{Webhook: &graphqlbackend.CreateActionWebhookArgs{Enabled: true, URL: webhookURL}},
+		{SlackWebhook: &graphqlbackend.CreateActionSlackWebhookArgs{Enabled: true, URL: slackWebhookURL}},
+	})
+	_, err := r.insertTestMonitorWithOpts(ctx, t, actionOpt)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Update the code monitor.
+	// We disable the webhook without changing its redacted URL, delete the
+	// Slack webhook, and add a new Slack webhook.
+	schema, err := graphqlbackend.NewSchema(db, nil, nil, nil, nil, r, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	updateInput := map[string]interface{}{
+		"monitorID":       string(relay.MarshalID(MonitorKind, 1)),
+		"triggerID":       string(relay.MarshalID(monitorTriggerQueryKind, 1)),
+		"webhookID":       string(relay.MarshalID(monitorActionWebhookKind, 1)),
+		"webhookURL":      cm.RedactedWebhookURL(webhookURL),
+		"slackWebhookURL": newSlackWebhookURL,
+		"namespace":       ns,
+	}
+	got := apitest.UpdateCodeMonitorWebhooksResponse{}
+	batchesApitest.MustExec(ctx, t, schema, updateInput, &got, editMonitorWebhooks)
+
+	want := apitest.UpdateCodeMonitorWebhooksResponse{
+		UpdateCodeMonitor: apitest.MonitorWithWebhooks{
+			Id: string(relay.MarshalID(MonitorKind, 1)),
+			Actions: apitest.WebhookConnection{
+				Nodes: []apitest.ActionWebhook{{
+					Id:      string(relay.MarshalID(monitorActionWebhookKind, 1)),
+					Enabled: false,
+					URL:     "https://example.com/REDACTED",
+				}, {
+					Id:      string(relay.MarshalID(monitorActionSlackWebhookKind, 2)),
+					Enabled: true,
+					URL:     "https://hooks.slack.com/REDACTED",
+				}},
+			},
+		},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Fatalf("unexpected response (-want +got):\n%s", diff)
+	}
+
+	w, err := r.store.ActionWebhookByIDInt64(ctx, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w.URL != webhookURL {
+		t.Errorf("got webhook URL %q, want %q", w.URL, webhookURL)
+	}
+	ss, err := r.store.ListActionSlackWebhooks(ctx, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ss) != 1 || ss[0].URL != newSlackWebhookURL {
+		t.Errorf("got Slack webhooks %+v, want one with URL %q", ss, newSlackWebhookURL)
+	}
+}
+
+const editMonitorWebhooks = `
+mutation ($monitorID: ID!, $triggerID: ID!, $webhookID: ID!, $webhookURL: String!, $slackWebhookURL: String!, $namespace: ID!) {
+  updateCodeMonitor(
+    monitor: {id: $monitorID, update: {description: "test monitor", enabled: true, namespace: $namespace}},
+	trigger: {id: $triggerID, update: {query: "repo:foo"}},
+	actions: [
+	  {webhook: {id: $webhookID, update: {enabled: false, url: $webhookURL}}}
+	  {slackWebhook: {update: {enabled: true, url: $slackWebhookURL}}}
+    ]
+  )
+  {
+	id
+	actions {
+	  nodes {
+		... on MonitorWebhook {
+		  id
+		  enabled
+		  url
+		}
+		... on MonitorSlackWebhook {
+		  id
+		  enabled
+		  url
+		}
+	  }
+	}
+  }
+}
+`
+
 func recipientPaging(ctx context.Context, t *testing.T, schema *graphql.Schema, user1 *testUser, user2 *testUser) {
 	queryInput := map[string]interface{}{
 		"userName":        user1.name,
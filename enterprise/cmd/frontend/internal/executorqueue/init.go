@@ -73,7 +73,7 @@ func Init(ctx context.Context, db dbutil.DB, outOfBandMigrationRunner *oobmigrat
 		return err
 	}
 
-	queueHandler, err := newExecutorQueueHandler(queueOptions, handler)
+	queueHandler, err := newExecutorQueueHandler(db, queueOptions, handler)
 	if err != nil {
 		return err
 	}
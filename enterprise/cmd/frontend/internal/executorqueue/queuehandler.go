@@ -1,6 +1,8 @@
 package executorqueue
 
 import (
+	"context"
+	"encoding/hex"
 	"fmt"
 	"net"
 	"net/http"
@@ -12,9 +14,13 @@ import (
 
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/envvar"
 	"github.com/sourcegraph/sourcegraph/enterprise/cmd/frontend/internal/executorqueue/handler"
+	"github.com/sourcegraph/sourcegraph/internal/authz"
+	"github.com/sourcegraph/sourcegraph/internal/database"
+	"github.com/sourcegraph/sourcegraph/internal/database/dbutil"
+	"github.com/sourcegraph/sourcegraph/internal/httpserver"
 )
 
-func newExecutorQueueHandler(queueOptions map[string]handler.QueueOptions, uploadHandler http.Handler) (func() http.Handler, error) {
+func newExecutorQueueHandler(db dbutil.DB, queueOptions map[string]handler.QueueOptions, uploadHandler http.Handler) (func() http.Handler, error) {
 	host, port, err := net.SplitHostPort(envvar.HTTPAddrInternal)
 	if err != nil {
 		return nil, errors.Wrap(err, fmt.Sprintf("failed to parse internal API address %q", envvar.HTTPAddrInternal))
@@ -26,7 +32,8 @@ func newExecutorQueueHandler(queueOptions map[string]handler.QueueOptions, uploa
 	}
 
 	factory := func() http.Handler {
-		// 🚨 SECURITY: These routes are secured by checking a token shared between services.
+		// 🚨 SECURITY: These routes are secured by checking a token shared between services, or an
+		// access token with the "executor" scope.
 		base := mux.NewRouter().PathPrefix("/.executors/").Subrouter()
 		base.StrictSlash(true)
 
@@ -39,7 +46,7 @@ func newExecutorQueueHandler(queueOptions map[string]handler.QueueOptions, uploa
 		// Upload LSIF indexes without a sudo access token or github tokens.
 		base.Path("/lsif/upload").Methods("POST").Handler(uploadHandler)
 
-		return basicAuthMiddleware(base)
+		return basicAuthMiddleware(db, base)
 	}
 
 	return factory, nil
@@ -48,7 +55,10 @@ func newExecutorQueueHandler(queueOptions map[string]handler.QueueOptions, uploa
 // basicAuthMiddleware rejects requests that do not have a basic auth username and password matching
 // the expected username and password. This should only be used for internal _services_, not users,
 // in which a shared key exchange can be done so safely.
-func basicAuthMiddleware(next http.Handler) http.Handler {
+//
+// Executors may also supply an access token with the "executor" scope as the password, along with
+// the username of the token's subject, who must be a site admin.
+func basicAuthMiddleware(db dbutil.DB, next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		username, password, ok := r.BasicAuth()
 		if !ok {
@@ -59,17 +69,22 @@ func basicAuthMiddleware(next http.Handler) http.Handler {
 			w.WriteHeader(http.StatusUnauthorized)
 			return
 		}
-		if sharedConfig.FrontendUsername == "" {
-			w.WriteHeader(http.StatusInternalServerError)
-			log15.Error("invalid value for EXECUTOR_FRONTEND_USERNAME: no value supplied")
+		if sharedConfig.FrontendUsername != "" && sharedConfig.FrontendPassword != "" &&
+			username == sharedConfig.FrontendUsername && password == sharedConfig.FrontendPassword {
+			next.ServeHTTP(w, r)
 			return
 		}
-		if sharedConfig.FrontendPassword == "" {
+
+		valid, err := isExecutorAccessToken(r.Context(), db, username, password, httpserver.RemoteIP(r))
+		if err != nil {
+			log15.Error("failed to validate executor access token", "error", err)
 			w.WriteHeader(http.StatusInternalServerError)
-			log15.Error("invalid value for EXECUTOR_FRONTEND_PASSWORD: no value supplied")
 			return
 		}
-		if username != sharedConfig.FrontendUsername || password != sharedConfig.FrontendPassword {
+		if !valid {
+			if sharedConfig.FrontendUsername == "" || sharedConfig.FrontendPassword == "" {
+				log15.Warn("EXECUTOR_FRONTEND_USERNAME and EXECUTOR_FRONTEND_PASSWORD are not set, executors must authenticate with access tokens")
+			}
 			w.WriteHeader(http.StatusForbidden)
 			return
 		}
@@ -77,3 +92,31 @@ func basicAuthMiddleware(next http.Handler) http.Handler {
 		next.ServeHTTP(w, r)
 	})
 }
+
+// isExecutorAccessToken returns true if token is a valid access token with the "executor" scope
+// whose subject is the site admin with the given username.
+func isExecutorAccessToken(ctx context.Context, db dbutil.DB, username, token, remoteIP string) (bool, error) {
+	if _, err := hex.DecodeString(token); err != nil {
+		// Not an access token at all.
+		return false, nil
+	}
+
+	subjectUserID, _, err := database.AccessTokens(db).Lookup(ctx, token, []string{authz.ScopeExecutor}, remoteIP)
+	if err != nil {
+		if errors.Is(err, database.ErrAccessTokenNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	user, err := database.Users(db).GetByID(ctx, subjectUserID)
+	if err != nil {
+		return false, err
+	}
+	// 🚨 SECURITY: Confirm that the token's subject is still a site admin, to prevent users from
+	// retaining access to the executor queue after being demoted.
+	if user.Username != username || !user.SiteAdmin {
+		return false, nil
+	}
+	return true, nil
+}
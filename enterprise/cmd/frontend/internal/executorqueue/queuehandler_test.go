@@ -1,10 +1,18 @@
 package executorqueue
 
 import (
+	"context"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
+
+	"github.com/cockroachdb/errors"
+
+	"github.com/sourcegraph/sourcegraph/internal/authz"
+	"github.com/sourcegraph/sourcegraph/internal/database"
+	"github.com/sourcegraph/sourcegraph/internal/database/dbtesting"
+	"github.com/sourcegraph/sourcegraph/internal/types"
 )
 
 func init() {
@@ -13,7 +21,22 @@ func init() {
 }
 
 func TestInternalProxyAuthTokenMiddleware(t *testing.T) {
+	database.Mocks.AccessTokens.Lookup = func(tokenHexEncoded string, acceptedScopes []string, remoteIP string) (int32, []string, error) {
+		if tokenHexEncoded == "deadbeef" {
+			return 0, nil, errors.New("database is down")
+		}
+		if tokenHexEncoded != "abcdef" || len(acceptedScopes) != 1 || acceptedScopes[0] != authz.ScopeExecutor {
+			return 0, nil, database.ErrAccessTokenNotFound
+		}
+		return 1, []string{authz.ScopeExecutor}, nil
+	}
+	database.Mocks.Users.GetByID = func(ctx context.Context, id int32) (*types.User, error) {
+		return &types.User{ID: id, Username: "admin", SiteAdmin: true}, nil
+	}
+	t.Cleanup(func() { database.Mocks = database.MockStores{} })
+
 	ts := httptest.NewServer(basicAuthMiddleware(
+		new(dbtesting.MockDB),
 		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			w.WriteHeader(http.StatusTeapot)
 		}),
@@ -66,4 +89,34 @@ func TestInternalProxyAuthTokenMiddleware(t *testing.T) {
 	if resp.StatusCode != http.StatusTeapot {
 		t.Errorf("unexpected status code. want=%d have=%d", http.StatusTeapot, resp.StatusCode)
 	}
+
+	// executor access token
+	req.SetBasicAuth("admin", "abcdef")
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("unexpected error performing request: %s", err)
+	}
+	if resp.StatusCode != http.StatusTeapot {
+		t.Errorf("unexpected status code. want=%d have=%d", http.StatusTeapot, resp.StatusCode)
+	}
+
+	// executor access token of another user
+	req.SetBasicAuth("alice", "abcdef")
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("unexpected error performing request: %s", err)
+	}
+	if resp.StatusCode != http.StatusForbidden {
+		t.Errorf("unexpected status code. want=%d have=%d", http.StatusForbidden, resp.StatusCode)
+	}
+
+	// access token lookup error
+	req.SetBasicAuth("admin", "deadbeef")
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("unexpected error performing request: %s", err)
+	}
+	if resp.StatusCode != http.StatusInternalServerError {
+		t.Errorf("unexpected status code. want=%d have=%d", http.StatusInternalServerError, resp.StatusCode)
+	}
 }
@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
 
@@ -24,7 +25,7 @@ import (
 
 func TestTransformBatchSpecWorkspaceExecutionJobRecord(t *testing.T) {
 	accessToken := "thisissecret-dont-tell-anyone"
-	database.Mocks.AccessTokens.Create = func(subjectUserID int32, scopes []string, note string, creatorID int32) (int64, string, error) {
+	database.Mocks.AccessTokens.Create = func(subjectUserID int32, scopes []string, note string, creatorID int32, expiresAt *time.Time) (int64, string, error) {
 		return 1234, accessToken, nil
 	}
 	t.Cleanup(func() { database.Mocks.AccessTokens.Create = nil })
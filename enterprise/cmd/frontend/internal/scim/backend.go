@@ -0,0 +1,501 @@
+package scim
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/cockroachdb/errors"
+	"github.com/inconshreveable/log15"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/auth"
+	"github.com/sourcegraph/sourcegraph/internal/database"
+	"github.com/sourcegraph/sourcegraph/internal/database/basestore"
+	"github.com/sourcegraph/sourcegraph/internal/database/dbutil"
+	"github.com/sourcegraph/sourcegraph/internal/errcode"
+	"github.com/sourcegraph/sourcegraph/internal/types"
+)
+
+// group is a SCIM group, which is a Sourcegraph organization.
+type group struct {
+	OrgID       int32
+	DisplayName string
+	// MemberIDs are the user IDs of the members to set when creating or updating the group.
+	MemberIDs []int32
+	// Members are the members of an existing group.
+	Members   []*types.User
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// backend stores the users and groups managed through the SCIM API.
+type backend interface {
+	ListUsers(ctx context.Context, opt database.SCIMUsersListOptions) ([]*database.SCIMUser, int, error)
+	GetUser(ctx context.Context, userID int32) (*database.SCIMUser, error)
+	CreateUser(ctx context.Context, u *database.SCIMUser) (*database.SCIMUser, error)
+	UpdateUser(ctx context.Context, u *database.SCIMUser) (*database.SCIMUser, error)
+	DeleteUser(ctx context.Context, userID int32) error
+
+	// ListGroups lists the groups, only including the group with the given display name if it
+	// is not empty. Groups are the organizations created through the SCIM API, the methods
+	// below don't find other organizations.
+	ListGroups(ctx context.Context, displayName string, limitOffset *database.LimitOffset) ([]*group, int, error)
+	GetGroup(ctx context.Context, orgID int32) (*group, error)
+	CreateGroup(ctx context.Context, g *group) (*group, error)
+	UpdateGroup(ctx context.Context, g *group) (*group, error)
+	DeleteGroup(ctx context.Context, orgID int32) error
+}
+
+// dbBackend is the backend that stores users and groups in the database.
+type dbBackend struct {
+	db dbutil.DB
+}
+
+var _ backend = &dbBackend{}
+
+func (b *dbBackend) ListUsers(ctx context.Context, opt database.SCIMUsersListOptions) ([]*database.SCIMUser, int, error) {
+	store := database.SCIMUsers(b.db)
+	total, err := store.Count(ctx, opt)
+	if err != nil {
+		return nil, 0, err
+	}
+	if opt.LimitOffset != nil && opt.Limit == 0 {
+		return nil, total, nil
+	}
+	users, err := store.List(ctx, opt)
+	return users, total, err
+}
+
+func (b *dbBackend) GetUser(ctx context.Context, userID int32) (*database.SCIMUser, error) {
+	return database.SCIMUsers(b.db).GetByUserID(ctx, userID)
+}
+
+func (b *dbBackend) CreateUser(ctx context.Context, u *database.SCIMUser) (_ *database.SCIMUser, err error) {
+	var events []*database.SecurityEvent
+	tx, err := database.SCIMUsers(b.db).Transact(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		err = tx.Done(err)
+		if err == nil {
+			b.logEvents(ctx, events)
+		}
+	}()
+	users := database.UsersWith(tx)
+
+	// Adopt the existing user with the primary email address, e.g. a user who signed in with SSO
+	// before the identity provider started provisioning users.
+	user, err := users.GetByVerifiedEmail(ctx, u.Emails[0])
+	if err != nil && !errcode.IsNotFound(err) {
+		return nil, err
+	}
+	if user != nil {
+		// 🚨 SECURITY: Site admins aren't adopted, as the identity provider could then take over
+		// their account, e.g. by changing their email addresses or deactivating them.
+		if user.SiteAdmin {
+			return nil, conflict("the user with the email address " + u.Emails[0] + " is a site admin, which can't be provisioned through SCIM")
+		}
+		if _, err := tx.GetByUserID(ctx, user.ID); err == nil {
+			return nil, conflict("a user with the email address " + u.Emails[0] + " already exists")
+		} else if !errcode.IsNotFound(err) {
+			return nil, err
+		}
+	} else {
+		username, err := auth.NormalizeUsername(u.UserName)
+		if err != nil {
+			return nil, badRequest(scimTypeInvalidValue, err.Error())
+		}
+		user, err = users.Create(ctx, database.NewUser{
+			Username:        username,
+			Email:           u.Emails[0],
+			EmailIsVerified: true,
+			DisplayName:     u.DisplayName,
+		})
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, newUserEvent(database.SecurityEventNameAccountCreated, user.ID, nil))
+	}
+
+	u.UserID = user.ID
+	if err := tx.Upsert(ctx, u); err != nil {
+		return nil, err
+	}
+	if err := tx.SetEmails(ctx, u.UserID, u.Emails); err != nil {
+		return nil, err
+	}
+	if !u.Active {
+		if err := tx.Deactivate(ctx, u.UserID); err != nil {
+			return nil, err
+		}
+		events = append(events, newUserEvent(database.SecurityEventNameAccountDeactivated, u.UserID, nil))
+	}
+	return tx.GetByUserID(ctx, u.UserID)
+}
+
+func (b *dbBackend) UpdateUser(ctx context.Context, u *database.SCIMUser) (_ *database.SCIMUser, err error) {
+	var events []*database.SecurityEvent
+	tx, err := database.SCIMUsers(b.db).Transact(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		err = tx.Done(err)
+		if err == nil {
+			b.logEvents(ctx, events)
+		}
+	}()
+
+	current, err := tx.GetByUserID(ctx, u.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !current.Active && u.Active {
+		if err := tx.Reactivate(ctx, u.UserID); err != nil {
+			return nil, err
+		}
+		events = append(events, newUserEvent(database.SecurityEventNameAccountReactivated, u.UserID, nil))
+	}
+
+	if u.Active {
+		// The profile of a deactivated user can't be changed, it is updated when the user is
+		// reactivated.
+		update := database.UserUpdate{}
+		if u.UserName != current.UserName {
+			username, err := auth.NormalizeUsername(u.UserName)
+			if err != nil {
+				return nil, badRequest(scimTypeInvalidValue, err.Error())
+			}
+			if username != current.Username {
+				update.Username = username
+			}
+		}
+		if u.DisplayName != current.DisplayName {
+			update.DisplayName = &u.DisplayName
+		}
+		if update.Username != "" || update.DisplayName != nil {
+			if err := database.UsersWith(tx).Update(ctx, u.UserID, update); err != nil {
+				return nil, err
+			}
+			events = append(events, newUserEvent(database.SecurityEventNameAccountUpdated, u.UserID, map[string]interface{}{
+				"username":    update.Username,
+				"displayName": update.DisplayName,
+			}))
+		}
+		if err := tx.SetEmails(ctx, u.UserID, u.Emails); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Upsert(ctx, u); err != nil {
+		return nil, err
+	}
+
+	if current.Active && !u.Active {
+		if err := tx.Deactivate(ctx, u.UserID); err != nil {
+			return nil, err
+		}
+		events = append(events, newUserEvent(database.SecurityEventNameAccountDeactivated, u.UserID, nil))
+	}
+	return tx.GetByUserID(ctx, u.UserID)
+}
+
+func (b *dbBackend) DeleteUser(ctx context.Context, userID int32) error {
+	// Only users provisioned through SCIM are deleted. The account is soft-deleted, so that a
+	// mistake of the identity provider doesn't lose the user's data.
+	if err := database.SCIMUsers(b.db).Delete(ctx, userID); err != nil {
+		return err
+	}
+	b.logEvents(ctx, []*database.SecurityEvent{newUserEvent(database.SecurityEventNameAccountDeleted, userID, nil)})
+	return nil
+}
+
+func (b *dbBackend) ListGroups(ctx context.Context, displayName string, limitOffset *database.LimitOffset) ([]*group, int, error) {
+	orgs := database.Orgs(b.db)
+
+	if displayName != "" {
+		org, err := orgs.GetByName(ctx, orgName(displayName))
+		if errcode.IsNotFound(err) {
+			return nil, 0, nil
+		} else if err != nil {
+			return nil, 0, err
+		}
+		if org, err = getSCIMOrg(ctx, orgs, org.ID); errcode.IsNotFound(err) {
+			return nil, 0, nil
+		} else if err != nil {
+			return nil, 0, err
+		}
+		g, err := b.getGroup(ctx, orgs, org)
+		if err != nil {
+			return nil, 0, err
+		}
+		return []*group{g}, 1, nil
+	}
+
+	scimGroups := database.SCIMGroups(b.db)
+	total, err := scimGroups.Count(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+	if limitOffset != nil && limitOffset.Limit == 0 {
+		return nil, total, nil
+	}
+	orgIDs, err := scimGroups.ListOrgIDs(ctx, limitOffset)
+	if err != nil {
+		return nil, 0, err
+	}
+	groups := make([]*group, 0, len(orgIDs))
+	for _, orgID := range orgIDs {
+		org, err := orgs.GetByID(ctx, orgID)
+		if err != nil {
+			return nil, 0, err
+		}
+		g, err := b.getGroup(ctx, orgs, org)
+		if err != nil {
+			return nil, 0, err
+		}
+		groups = append(groups, g)
+	}
+	return groups, total, nil
+}
+
+func (b *dbBackend) GetGroup(ctx context.Context, orgID int32) (*group, error) {
+	orgs := database.Orgs(b.db)
+	org, err := getSCIMOrg(ctx, orgs, orgID)
+	if err != nil {
+		return nil, err
+	}
+	return b.getGroup(ctx, orgs, org)
+}
+
+// getSCIMOrg returns the organization orgID if it was created through the SCIM API.
+//
+// 🚨 SECURITY: The SCIM API must only read and change the organizations it created, and not
+// those created by users.
+func getSCIMOrg(ctx context.Context, orgs *database.OrgStore, orgID int32) (*types.Org, error) {
+	ok, err := database.SCIMGroupsWith(orgs).Has(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, &database.OrgNotFoundError{Message: fmt.Sprintf("SCIM group %d", orgID)}
+	}
+	return orgs.GetByID(ctx, orgID)
+}
+
+func (b *dbBackend) getGroup(ctx context.Context, store basestore.ShareableStore, org *types.Org) (*group, error) {
+	memberships, err := database.OrgMembersWith(store).GetByOrgID(ctx, org.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	g := &group{
+		OrgID:       org.ID,
+		DisplayName: org.Name,
+		CreatedAt:   org.CreatedAt,
+		UpdatedAt:   org.UpdatedAt,
+	}
+	if org.DisplayName != nil && *org.DisplayName != "" {
+		g.DisplayName = *org.DisplayName
+	}
+	if len(memberships) == 0 {
+		return g, nil
+	}
+
+	userIDs := make([]int32, 0, len(memberships))
+	for _, m := range memberships {
+		userIDs = append(userIDs, m.UserID)
+	}
+	g.Members, err = database.UsersWith(store).List(ctx, &database.UsersListOptions{UserIDs: userIDs})
+	return g, err
+}
+
+func (b *dbBackend) CreateGroup(ctx context.Context, g *group) (_ *group, err error) {
+	var events []*database.SecurityEvent
+	tx, err := database.Orgs(b.db).Transact(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		err = tx.Done(err)
+		if err == nil {
+			b.logEvents(ctx, events)
+		}
+	}()
+
+	name := orgName(g.DisplayName)
+	if _, err := tx.GetByName(ctx, name); err == nil {
+		return nil, conflict("an organization named " + name + " already exists")
+	} else if !errcode.IsNotFound(err) {
+		return nil, err
+	}
+
+	org, err := tx.Create(ctx, name, &g.DisplayName)
+	if err != nil {
+		return nil, err
+	}
+	if err := database.SCIMGroupsWith(tx).Add(ctx, org.ID); err != nil {
+		return nil, err
+	}
+	events = append(events, newOrgEvent(database.SecurityEventNameOrgCreated, 0, org.ID))
+
+	memberEvents, err := setMembers(ctx, tx, org.ID, g.MemberIDs)
+	if err != nil {
+		return nil, err
+	}
+	events = append(events, memberEvents...)
+	return b.getGroup(ctx, tx, org)
+}
+
+func (b *dbBackend) UpdateGroup(ctx context.Context, g *group) (_ *group, err error) {
+	var events []*database.SecurityEvent
+	tx, err := database.Orgs(b.db).Transact(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		err = tx.Done(err)
+		if err == nil {
+			b.logEvents(ctx, events)
+		}
+	}()
+
+	org, err := getSCIMOrg(ctx, tx, g.OrgID)
+	if err != nil {
+		return nil, err
+	}
+	if org.DisplayName == nil || *org.DisplayName != g.DisplayName {
+		// The name of the organization doesn't change, as it is used in URLs and settings.
+		if org, err = tx.Update(ctx, org.ID, &g.DisplayName); err != nil {
+			return nil, err
+		}
+		events = append(events, newOrgEvent(database.SecurityEventNameOrgUpdated, 0, org.ID))
+	}
+
+	memberEvents, err := setMembers(ctx, tx, org.ID, g.MemberIDs)
+	if err != nil {
+		return nil, err
+	}
+	events = append(events, memberEvents...)
+	return b.getGroup(ctx, tx, org)
+}
+
+func (b *dbBackend) DeleteGroup(ctx context.Context, orgID int32) (err error) {
+	tx, err := database.Orgs(b.db).Transact(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = tx.Done(err)
+		if err == nil {
+			b.logEvents(ctx, []*database.SecurityEvent{newOrgEvent(database.SecurityEventNameOrgDeleted, 0, orgID)})
+		}
+	}()
+
+	if _, err := getSCIMOrg(ctx, tx, orgID); err != nil {
+		return err
+	}
+	return tx.Delete(ctx, orgID)
+}
+
+// setMembers makes userIDs the members of the organization orgID. Users that don't exist are
+// ignored, as identity providers may reference users that were deleted.
+func setMembers(ctx context.Context, store basestore.ShareableStore, orgID int32, userIDs []int32) ([]*database.SecurityEvent, error) {
+	orgMembers := database.OrgMembersWith(store)
+	memberships, err := orgMembers.GetByOrgID(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+
+	current := make(map[int32]bool, len(memberships))
+	for _, m := range memberships {
+		current[m.UserID] = true
+	}
+	desired := make(map[int32]bool, len(userIDs))
+	for _, id := range userIDs {
+		desired[id] = true
+	}
+
+	var events []*database.SecurityEvent
+	users := database.UsersWith(store)
+	for _, id := range userIDs {
+		if current[id] {
+			continue
+		}
+		if _, err := users.GetByID(ctx, id); errcode.IsNotFound(err) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		if _, err := orgMembers.Create(ctx, orgID, id); err != nil {
+			return nil, err
+		}
+		current[id] = true
+		events = append(events, newOrgEvent(database.SecurityEventNameOrgMemberAdded, id, orgID))
+	}
+	for _, m := range memberships {
+		if desired[m.UserID] {
+			continue
+		}
+		if err := orgMembers.Remove(ctx, orgID, m.UserID); err != nil {
+			return nil, err
+		}
+		events = append(events, newOrgEvent(database.SecurityEventNameOrgMemberRemoved, m.UserID, orgID))
+	}
+	return events, nil
+}
+
+// orgName returns the name of the organization for the group displayName.
+func orgName(displayName string) string {
+	name, err := auth.NormalizeUsername(displayName)
+	if err != nil {
+		// NormalizeUsername only fails when nothing is left of the name.
+		return strings.ToLower(displayName)
+	}
+	return name
+}
+
+// logEvents records the security events of the changes made through the SCIM API. Unlike most
+// security events, they are recorded on all instances, as they are the audit trail of the
+// identity provider.
+func (b *dbBackend) logEvents(ctx context.Context, events []*database.SecurityEvent) {
+	store := database.SecurityEventLogs(b.db)
+	for _, e := range events {
+		e.Timestamp = time.Now()
+		if err := store.Insert(ctx, e); err != nil {
+			log15.Error("scim: failed to log security event", "event", e.Name, "error", err)
+		}
+	}
+}
+
+func newUserEvent(name database.SecurityEventName, userID int32, argument map[string]interface{}) *database.SecurityEvent {
+	e := &database.SecurityEvent{
+		Name:   name,
+		UserID: uint32(userID),
+		Source: "SCIM",
+	}
+	if argument != nil {
+		e.Argument, _ = json.Marshal(argument)
+	}
+	return e
+}
+
+func newOrgEvent(name database.SecurityEventName, userID, orgID int32) *database.SecurityEvent {
+	e := newUserEvent(name, userID, map[string]interface{}{"org_id": orgID})
+	if userID == 0 {
+		// Security events must have a user, but organization changes don't always concern one.
+		e.AnonymousUserID = "SCIM"
+	}
+	return e
+}
+
+// errConflict is returned when a resource conflicts with an existing resource.
+type errConflict struct{ msg string }
+
+func (e errConflict) Error() string { return e.msg }
+
+func conflict(msg string) error { return errors.WithStack(errConflict{msg: msg}) }
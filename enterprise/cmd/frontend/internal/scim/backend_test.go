@@ -0,0 +1,49 @@
+package scim
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cockroachdb/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sourcegraph/sourcegraph/internal/database"
+	"github.com/sourcegraph/sourcegraph/internal/database/dbtest"
+	"github.com/sourcegraph/sourcegraph/internal/errcode"
+)
+
+func TestDBBackendUsers(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+	ctx := context.Background()
+	db := dbtest.NewDB(t, "")
+	b := &dbBackend{db: db}
+
+	// Existing users with the primary email address are adopted, except site admins.
+	alice, err := database.Users(db).Create(ctx, database.NewUser{Username: "alice", Email: "alice@example.com", EmailIsVerified: true})
+	require.NoError(t, err)
+	admin, err := database.Users(db).Create(ctx, database.NewUser{Username: "admin", Email: "admin@example.com", EmailIsVerified: true})
+	require.NoError(t, err)
+	require.NoError(t, database.Users(db).SetIsSiteAdmin(ctx, admin.ID, true))
+
+	u, err := b.CreateUser(ctx, &database.SCIMUser{UserName: "alice@example.com", Emails: []string{"alice@example.com"}, Active: true})
+	require.NoError(t, err)
+	assert.Equal(t, alice.ID, u.UserID)
+
+	_, err = b.CreateUser(ctx, &database.SCIMUser{UserName: "admin@example.com", Emails: []string{"admin@example.com"}, Active: true})
+	assert.True(t, errors.HasType(err, errConflict{}), "expected conflict error, got %v", err)
+	_, err = database.SCIMUsers(db).GetByUserID(ctx, admin.ID)
+	assert.True(t, errcode.IsNotFound(err), "expected the site admin not to be a SCIM user, got %v", err)
+
+	// Deleted users are soft-deleted.
+	require.NoError(t, b.DeleteUser(ctx, alice.ID))
+	_, err = database.Users(db).GetByID(ctx, alice.ID)
+	assert.True(t, errcode.IsNotFound(err), "expected the user to be deleted, got %v", err)
+	_, err = b.GetUser(ctx, alice.ID)
+	assert.True(t, errcode.IsNotFound(err), "expected the SCIM user to be deleted, got %v", err)
+	var deleted bool
+	require.NoError(t, db.QueryRowContext(ctx, "SELECT deleted_at IS NOT NULL FROM users WHERE id = $1", alice.ID).Scan(&deleted))
+	assert.True(t, deleted)
+}
@@ -0,0 +1,44 @@
+package scim
+
+import (
+	"strconv"
+	"strings"
+)
+
+// filter is a SCIM filter expression. Identity providers only use filters to look up a resource
+// by a unique attribute before creating it, so only the expression `attribute eq "value"` is
+// supported.
+type filter struct {
+	Attribute string
+	Value     string
+}
+
+// parseFilter parses the filter expression s, where attributes is the set of attributes that
+// can be filtered on. An empty expression returns a nil filter.
+func parseFilter(s string, attributes ...string) (*filter, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil, nil
+	}
+
+	fields := strings.SplitN(s, " ", 3)
+	if len(fields) != 3 || !strings.EqualFold(fields[1], "eq") {
+		return nil, badRequest(scimTypeInvalidFilter, "only filters of the form 'attribute eq \"value\"' are supported")
+	}
+
+	var attribute string
+	for _, a := range attributes {
+		if strings.EqualFold(fields[0], a) {
+			attribute = a
+		}
+	}
+	if attribute == "" {
+		return nil, badRequest(scimTypeInvalidFilter, "filtering on "+strconv.Quote(fields[0])+" is not supported")
+	}
+
+	value, err := strconv.Unquote(strings.TrimSpace(fields[2]))
+	if err != nil {
+		return nil, badRequest(scimTypeInvalidFilter, "the filter value must be a quoted string")
+	}
+	return &filter{Attribute: attribute, Value: value}, nil
+}
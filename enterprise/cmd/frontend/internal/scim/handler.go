@@ -0,0 +1,503 @@
+// Package scim implements the SCIM 2.0 API (RFC 7643 and RFC 7644), which identity providers
+// use to provision users and groups. SCIM users are Sourcegraph users, and SCIM groups are
+// Sourcegraph organizations.
+package scim
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/cockroachdb/errors"
+	"github.com/gorilla/mux"
+	"github.com/inconshreveable/log15"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/globals"
+	"github.com/sourcegraph/sourcegraph/internal/conf"
+	"github.com/sourcegraph/sourcegraph/internal/database"
+	"github.com/sourcegraph/sourcegraph/internal/errcode"
+)
+
+const (
+	pathPrefix = "/.api/scim/v2"
+
+	// defaultCount and maxCount are the default and maximum number of resources per page.
+	defaultCount = 100
+	maxCount     = 1000
+
+	// maxBodySize is the maximum size of request bodies.
+	maxBodySize = 1 << 20
+)
+
+type handler struct {
+	backend backend
+}
+
+func newHandler(b backend) http.Handler {
+	h := &handler{backend: b}
+
+	r := mux.NewRouter().PathPrefix(pathPrefix).Subrouter()
+	r.StrictSlash(true)
+	r.Path("/ServiceProviderConfig").Methods(http.MethodGet).HandlerFunc(h.serviceProviderConfig)
+
+	r.Path("/Users").Methods(http.MethodGet).HandlerFunc(h.listUsers)
+	r.Path("/Users").Methods(http.MethodPost).HandlerFunc(h.createUser)
+	r.Path("/Users/{id}").Methods(http.MethodGet).HandlerFunc(h.getUser)
+	r.Path("/Users/{id}").Methods(http.MethodPut).HandlerFunc(h.replaceUser)
+	r.Path("/Users/{id}").Methods(http.MethodPatch).HandlerFunc(h.patchUser)
+	r.Path("/Users/{id}").Methods(http.MethodDelete).HandlerFunc(h.deleteUser)
+
+	r.Path("/Groups").Methods(http.MethodGet).HandlerFunc(h.listGroups)
+	r.Path("/Groups").Methods(http.MethodPost).HandlerFunc(h.createGroup)
+	r.Path("/Groups/{id}").Methods(http.MethodGet).HandlerFunc(h.getGroup)
+	r.Path("/Groups/{id}").Methods(http.MethodPut).HandlerFunc(h.replaceGroup)
+	r.Path("/Groups/{id}").Methods(http.MethodPatch).HandlerFunc(h.patchGroup)
+	r.Path("/Groups/{id}").Methods(http.MethodDelete).HandlerFunc(h.deleteGroup)
+
+	r.NotFoundHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeError(w, &scimError{Status: http.StatusNotFound, Detail: "unknown endpoint " + r.URL.Path})
+	})
+	r.MethodNotAllowedHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeError(w, &scimError{Status: http.StatusMethodNotAllowed, Detail: "method not allowed"})
+	})
+
+	return authMiddleware(r)
+}
+
+// authMiddleware authenticates the identity provider with the bearer token in the site
+// configuration. The API doesn't exist when SCIM isn't configured.
+func authMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		config := conf.Get().AuthScim
+		if config == nil || config.BearerToken == "" {
+			http.NotFound(w, r)
+			return
+		}
+
+		// 🚨 SECURITY: The SCIM API can create site users, so the token must be compared in
+		// constant time.
+		token := strings.TrimSpace(r.Header.Get("Authorization"))
+		if len(token) < len("Bearer ") || !strings.EqualFold(token[:len("Bearer ")], "Bearer ") ||
+			subtle.ConstantTimeCompare([]byte(strings.TrimSpace(token[len("Bearer "):])), []byte(config.BearerToken)) != 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="SCIM"`)
+			writeError(w, &scimError{Status: http.StatusUnauthorized, Detail: "invalid bearer token"})
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (h *handler) serviceProviderConfig(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"schemas":        []string{schemaServiceProviderConfig},
+		"patch":          map[string]bool{"supported": true},
+		"bulk":           map[string]interface{}{"supported": false, "maxOperations": 0, "maxPayloadSize": 0},
+		"filter":         map[string]interface{}{"supported": true, "maxResults": maxCount},
+		"changePassword": map[string]bool{"supported": false},
+		"sort":           map[string]bool{"supported": false},
+		"etag":           map[string]bool{"supported": false},
+		"authenticationSchemes": []map[string]interface{}{{
+			"type":        "oauthbearertoken",
+			"name":        "OAuth Bearer Token",
+			"description": "Authentication with the bearer token of the auth.scim site configuration",
+			"primary":     true,
+		}},
+	})
+}
+
+func (h *handler) listUsers(w http.ResponseWriter, r *http.Request) {
+	f, err := parseFilter(r.URL.Query().Get("filter"), "userName", "externalId")
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	startIndex, limitOffset, err := parsePagination(r.URL.Query())
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	opt := database.SCIMUsersListOptions{LimitOffset: limitOffset}
+	if f != nil {
+		switch f.Attribute {
+		case "userName":
+			opt.UserName = f.Value
+		case "externalId":
+			opt.ExternalID = f.Value
+		}
+	}
+
+	users, total, err := h.backend.ListUsers(r.Context(), opt)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	resources := make([]*userResource, 0, len(users))
+	for _, u := range users {
+		resources = append(resources, newUserResource(u, location("Users", u.UserID)))
+	}
+	writeJSON(w, http.StatusOK, &listResponse{
+		Schemas:      []string{schemaListResponse},
+		TotalResults: total,
+		StartIndex:   startIndex,
+		ItemsPerPage: len(resources),
+		Resources:    resources,
+	})
+}
+
+func (h *handler) getUser(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	u, err := h.backend.GetUser(r.Context(), id)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, newUserResource(u, location("Users", u.UserID)))
+}
+
+func (h *handler) createUser(w http.ResponseWriter, r *http.Request) {
+	var res userResource
+	if err := readJSON(r, &res); err != nil {
+		writeError(w, err)
+		return
+	}
+	u, err := res.toSCIMUser(0)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if u, err = h.backend.CreateUser(r.Context(), u); err != nil {
+		writeError(w, err)
+		return
+	}
+	l := location("Users", u.UserID)
+	w.Header().Set("Location", l)
+	writeJSON(w, http.StatusCreated, newUserResource(u, l))
+}
+
+func (h *handler) replaceUser(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	var res userResource
+	if err := readJSON(r, &res); err != nil {
+		writeError(w, err)
+		return
+	}
+	h.updateUser(w, r, id, &res)
+}
+
+func (h *handler) patchUser(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	var req patchRequest
+	if err := readJSON(r, &req); err != nil {
+		writeError(w, err)
+		return
+	}
+	u, err := h.backend.GetUser(r.Context(), id)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	var res userResource
+	if err := patchResource(newUserResource(u, ""), req.Operations, &res); err != nil {
+		writeError(w, err)
+		return
+	}
+	h.updateUser(w, r, id, &res)
+}
+
+func (h *handler) updateUser(w http.ResponseWriter, r *http.Request, id int32, res *userResource) {
+	u, err := res.toSCIMUser(id)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if u, err = h.backend.UpdateUser(r.Context(), u); err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, newUserResource(u, location("Users", u.UserID)))
+}
+
+func (h *handler) deleteUser(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if err := h.backend.DeleteUser(r.Context(), id); err != nil {
+		writeError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *handler) listGroups(w http.ResponseWriter, r *http.Request) {
+	f, err := parseFilter(r.URL.Query().Get("filter"), "displayName")
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	startIndex, limitOffset, err := parsePagination(r.URL.Query())
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	var displayName string
+	if f != nil {
+		displayName = f.Value
+	}
+	groups, total, err := h.backend.ListGroups(r.Context(), displayName, limitOffset)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	resources := make([]*groupResource, 0, len(groups))
+	for _, g := range groups {
+		resources = append(resources, newGroupResource(g, location("Groups", g.OrgID)))
+	}
+	writeJSON(w, http.StatusOK, &listResponse{
+		Schemas:      []string{schemaListResponse},
+		TotalResults: total,
+		StartIndex:   startIndex,
+		ItemsPerPage: len(resources),
+		Resources:    resources,
+	})
+}
+
+func (h *handler) getGroup(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	g, err := h.backend.GetGroup(r.Context(), id)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, newGroupResource(g, location("Groups", g.OrgID)))
+}
+
+func (h *handler) createGroup(w http.ResponseWriter, r *http.Request) {
+	var res groupResource
+	if err := readJSON(r, &res); err != nil {
+		writeError(w, err)
+		return
+	}
+	g, err := res.toGroup(0)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if g, err = h.backend.CreateGroup(r.Context(), g); err != nil {
+		writeError(w, err)
+		return
+	}
+	l := location("Groups", g.OrgID)
+	w.Header().Set("Location", l)
+	writeJSON(w, http.StatusCreated, newGroupResource(g, l))
+}
+
+func (h *handler) replaceGroup(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	var res groupResource
+	if err := readJSON(r, &res); err != nil {
+		writeError(w, err)
+		return
+	}
+	h.updateGroup(w, r, id, &res)
+}
+
+func (h *handler) patchGroup(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	var req patchRequest
+	if err := readJSON(r, &req); err != nil {
+		writeError(w, err)
+		return
+	}
+	g, err := h.backend.GetGroup(r.Context(), id)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	var res groupResource
+	if err := patchResource(newGroupResource(g, ""), req.Operations, &res); err != nil {
+		writeError(w, err)
+		return
+	}
+	h.updateGroup(w, r, id, &res)
+}
+
+func (h *handler) updateGroup(w http.ResponseWriter, r *http.Request, id int32, res *groupResource) {
+	g, err := res.toGroup(id)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if g, err = h.backend.UpdateGroup(r.Context(), g); err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, newGroupResource(g, location("Groups", g.OrgID)))
+}
+
+func (h *handler) deleteGroup(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if err := h.backend.DeleteGroup(r.Context(), id); err != nil {
+		writeError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// patchResource applies the PATCH operations to resource, and decodes the result into out.
+func patchResource(resource interface{}, ops []patchOp, out interface{}) error {
+	patched, err := applyPatch(resource, ops)
+	if err != nil {
+		return err
+	}
+	b, err := json.Marshal(patched)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(b, out); err != nil {
+		return badRequest(scimTypeInvalidValue, err.Error())
+	}
+	return nil
+}
+
+// parsePagination parses the 1-based startIndex and count parameters of list requests.
+func parsePagination(q url.Values) (startIndex int, limitOffset *database.LimitOffset, err error) {
+	startIndex, count := 1, defaultCount
+	if s := q.Get("startIndex"); s != "" {
+		if startIndex, err = strconv.Atoi(s); err != nil {
+			return 0, nil, badRequest(scimTypeInvalidValue, "invalid startIndex")
+		}
+		if startIndex < 1 {
+			startIndex = 1
+		}
+	}
+	if s := q.Get("count"); s != "" {
+		if count, err = strconv.Atoi(s); err != nil {
+			return 0, nil, badRequest(scimTypeInvalidValue, "invalid count")
+		}
+		if count < 0 {
+			count = 0
+		} else if count > maxCount {
+			count = maxCount
+		}
+	}
+	return startIndex, &database.LimitOffset{Limit: count, Offset: startIndex - 1}, nil
+}
+
+func pathID(r *http.Request) (int32, error) {
+	id, err := parseID(mux.Vars(r)["id"])
+	if err != nil {
+		// IDs that can't exist are reported as not found, like unknown IDs.
+		return 0, &scimError{Status: http.StatusNotFound, Detail: err.Error()}
+	}
+	return id, nil
+}
+
+func location(resourceType string, id int32) string {
+	u := globals.ExternalURL().ResolveReference(&url.URL{Path: pathPrefix + "/" + resourceType + "/" + strconv.Itoa(int(id))})
+	return u.String()
+}
+
+func readJSON(r *http.Request, v interface{}) error {
+	if err := json.NewDecoder(io.LimitReader(r.Body, maxBodySize)).Decode(v); err != nil {
+		return badRequest(scimTypeInvalidSyntax, "invalid JSON body: "+err.Error())
+	}
+	return nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/scim+json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log15.Error("scim: failed to write response", "error", err)
+	}
+}
+
+// SCIM error types, see https://datatracker.ietf.org/doc/html/rfc7644#section-3.12.
+const (
+	scimTypeInvalidFilter = "invalidFilter"
+	scimTypeInvalidSyntax = "invalidSyntax"
+	scimTypeInvalidPath   = "invalidPath"
+	scimTypeInvalidValue  = "invalidValue"
+	scimTypeNoTarget      = "noTarget"
+	scimTypeUniqueness    = "uniqueness"
+)
+
+// scimError is an error that is returned to the identity provider as a SCIM error response.
+type scimError struct {
+	Status   int
+	ScimType string
+	Detail   string
+}
+
+func (e *scimError) Error() string {
+	return e.Detail
+}
+
+func badRequest(scimType, detail string) error {
+	return &scimError{Status: http.StatusBadRequest, ScimType: scimType, Detail: detail}
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	var e *scimError
+	var c errConflict
+	switch {
+	case errors.As(err, &e):
+	case errors.As(err, &c):
+		e = &scimError{Status: http.StatusConflict, ScimType: scimTypeUniqueness, Detail: c.msg}
+	case database.IsUsernameExists(err) || database.IsEmailExists(err):
+		e = &scimError{Status: http.StatusConflict, ScimType: scimTypeUniqueness, Detail: err.Error()}
+	case errcode.IsNotFound(err):
+		e = &scimError{Status: http.StatusNotFound, Detail: "resource not found"}
+	default:
+		log15.Error("scim: request failed", "error", err)
+		e = &scimError{Status: http.StatusInternalServerError, Detail: "internal error"}
+	}
+
+	writeJSON(w, e.Status, map[string]interface{}{
+		"schemas":  []string{schemaError},
+		"status":   strconv.Itoa(e.Status),
+		"scimType": e.ScimType,
+		"detail":   e.Detail,
+	})
+}
@@ -0,0 +1,357 @@
+package scim
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/globals"
+	"github.com/sourcegraph/sourcegraph/internal/conf"
+	"github.com/sourcegraph/sourcegraph/internal/database"
+	"github.com/sourcegraph/sourcegraph/internal/types"
+	"github.com/sourcegraph/sourcegraph/schema"
+)
+
+const testToken = "0123456789abcdef0123456789abcdef"
+
+// fakeBackend is an in-memory backend.
+type fakeBackend struct {
+	users  map[int32]*database.SCIMUser
+	groups map[int32]*group
+	nextID int32
+}
+
+func newFakeBackend() *fakeBackend {
+	return &fakeBackend{users: map[int32]*database.SCIMUser{}, groups: map[int32]*group{}, nextID: 1}
+}
+
+type notFoundErr struct{}
+
+func (notFoundErr) Error() string  { return "not found" }
+func (notFoundErr) NotFound() bool { return true }
+
+func (b *fakeBackend) ListUsers(_ context.Context, opt database.SCIMUsersListOptions) ([]*database.SCIMUser, int, error) {
+	var users []*database.SCIMUser
+	for id := int32(1); id < b.nextID; id++ {
+		u, ok := b.users[id]
+		if !ok || (opt.UserName != "" && !strings.EqualFold(opt.UserName, u.UserName)) || (opt.ExternalID != "" && opt.ExternalID != u.ExternalID) {
+			continue
+		}
+		users = append(users, u)
+	}
+	total := len(users)
+	if opt.Offset < len(users) {
+		users = users[opt.Offset:]
+	} else {
+		users = nil
+	}
+	if opt.Limit < len(users) {
+		users = users[:opt.Limit]
+	}
+	return users, total, nil
+}
+
+func (b *fakeBackend) GetUser(_ context.Context, userID int32) (*database.SCIMUser, error) {
+	u, ok := b.users[userID]
+	if !ok {
+		return nil, notFoundErr{}
+	}
+	return u, nil
+}
+
+func (b *fakeBackend) CreateUser(_ context.Context, u *database.SCIMUser) (*database.SCIMUser, error) {
+	for _, existing := range b.users {
+		if strings.EqualFold(existing.UserName, u.UserName) {
+			return nil, conflict("user exists")
+		}
+	}
+	u.UserID = b.nextID
+	b.nextID++
+	u.Username = strings.ToLower(u.UserName)
+	u.CreatedAt, u.UpdatedAt = time.Now(), time.Now()
+	b.users[u.UserID] = u
+	return u, nil
+}
+
+func (b *fakeBackend) UpdateUser(_ context.Context, u *database.SCIMUser) (*database.SCIMUser, error) {
+	existing, ok := b.users[u.UserID]
+	if !ok {
+		return nil, notFoundErr{}
+	}
+	u.Username, u.CreatedAt, u.UpdatedAt = existing.Username, existing.CreatedAt, time.Now()
+	b.users[u.UserID] = u
+	return u, nil
+}
+
+func (b *fakeBackend) DeleteUser(_ context.Context, userID int32) error {
+	if _, ok := b.users[userID]; !ok {
+		return notFoundErr{}
+	}
+	delete(b.users, userID)
+	return nil
+}
+
+func (b *fakeBackend) ListGroups(_ context.Context, displayName string, _ *database.LimitOffset) ([]*group, int, error) {
+	var groups []*group
+	for id := int32(1); id < b.nextID; id++ {
+		if g, ok := b.groups[id]; ok && (displayName == "" || g.DisplayName == displayName) {
+			groups = append(groups, g)
+		}
+	}
+	return groups, len(groups), nil
+}
+
+func (b *fakeBackend) GetGroup(_ context.Context, orgID int32) (*group, error) {
+	g, ok := b.groups[orgID]
+	if !ok {
+		return nil, notFoundErr{}
+	}
+	return g, nil
+}
+
+func (b *fakeBackend) setMembers(g *group) {
+	g.Members = nil
+	for _, id := range g.MemberIDs {
+		if u, ok := b.users[id]; ok {
+			g.Members = append(g.Members, &types.User{ID: id, Username: u.Username})
+		}
+	}
+}
+
+func (b *fakeBackend) CreateGroup(_ context.Context, g *group) (*group, error) {
+	g.OrgID = b.nextID
+	b.nextID++
+	b.setMembers(g)
+	b.groups[g.OrgID] = g
+	return g, nil
+}
+
+func (b *fakeBackend) UpdateGroup(_ context.Context, g *group) (*group, error) {
+	if _, ok := b.groups[g.OrgID]; !ok {
+		return nil, notFoundErr{}
+	}
+	b.setMembers(g)
+	b.groups[g.OrgID] = g
+	return g, nil
+}
+
+func (b *fakeBackend) DeleteGroup(_ context.Context, orgID int32) error {
+	if _, ok := b.groups[orgID]; !ok {
+		return notFoundErr{}
+	}
+	delete(b.groups, orgID)
+	return nil
+}
+
+func setup(t *testing.T) (*fakeBackend, http.Handler) {
+	conf.Mock(&conf.Unified{SiteConfiguration: schema.SiteConfiguration{
+		AuthScim: &schema.AuthScim{BearerToken: testToken},
+	}})
+	t.Cleanup(func() { conf.Mock(nil) })
+
+	externalURL := globals.ExternalURL()
+	globals.SetExternalURL(&url.URL{Scheme: "https", Host: "sourcegraph.example.com"})
+	t.Cleanup(func() { globals.SetExternalURL(externalURL) })
+
+	b := newFakeBackend()
+	return b, newHandler(b)
+}
+
+func do(t *testing.T, h http.Handler, method, path, body string) (*httptest.ResponseRecorder, map[string]interface{}) {
+	t.Helper()
+	req := httptest.NewRequest(method, pathPrefix+path, strings.NewReader(body))
+	req.Header.Set("Authorization", "Bearer "+testToken)
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	var res map[string]interface{}
+	if rec.Body.Len() > 0 {
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &res), rec.Body.String())
+	}
+	return rec, res
+}
+
+func TestAuth(t *testing.T) {
+	_, h := setup(t)
+
+	for name, header := range map[string]string{
+		"no token":      "",
+		"invalid token": "Bearer nope",
+		"basic auth":    "Basic " + testToken,
+	} {
+		t.Run(name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", pathPrefix+"/Users", nil)
+			if header != "" {
+				req.Header.Set("Authorization", header)
+			}
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+			assert.Equal(t, http.StatusUnauthorized, rec.Code)
+		})
+	}
+
+	t.Run("not configured", func(t *testing.T) {
+		conf.Mock(&conf.Unified{})
+		req := httptest.NewRequest("GET", pathPrefix+"/Users", nil)
+		req.Header.Set("Authorization", "Bearer "+testToken)
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+}
+
+func TestUsers(t *testing.T) {
+	b, h := setup(t)
+
+	rec, res := do(t, h, "POST", "/Users", `{
+		"schemas": ["urn:ietf:params:scim:schemas:core:2.0:User"],
+		"userName": "alice@example.com",
+		"externalId": "00u1",
+		"name": {"givenName": "Alice", "familyName": "Smith"},
+		"emails": [{"value": "alice@work.example.com"}, {"value": "alice@example.com", "primary": true}],
+		"active": true
+	}`)
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+	assert.Equal(t, "application/scim+json", rec.Header().Get("Content-Type"))
+	assert.Equal(t, "https://sourcegraph.example.com/.api/scim/v2/Users/1", rec.Header().Get("Location"))
+	assert.Equal(t, "1", res["id"])
+	assert.Equal(t, &database.SCIMUser{
+		UserID:      1,
+		UserName:    "alice@example.com",
+		ExternalID:  "00u1",
+		Emails:      []string{"alice@example.com", "alice@work.example.com"},
+		Active:      true,
+		Username:    "alice@example.com",
+		DisplayName: "Alice Smith",
+		CreatedAt:   b.users[1].CreatedAt,
+		UpdatedAt:   b.users[1].UpdatedAt,
+	}, b.users[1])
+
+	t.Run("conflict", func(t *testing.T) {
+		rec, res := do(t, h, "POST", "/Users", `{"userName": "ALICE@example.com"}`)
+		assert.Equal(t, http.StatusConflict, rec.Code)
+		assert.Equal(t, "uniqueness", res["scimType"])
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		rec, res := do(t, h, "POST", "/Users", `{"userName": "bob"}`)
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		assert.Equal(t, "invalidValue", res["scimType"])
+	})
+
+	t.Run("list with filter", func(t *testing.T) {
+		rec, res := do(t, h, "GET", "/Users?filter="+url.QueryEscape(`userName eq "Alice@example.com"`), "")
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, float64(1), res["totalResults"])
+
+		_, res = do(t, h, "GET", "/Users?filter="+url.QueryEscape(`externalId eq "nope"`), "")
+		assert.Equal(t, float64(0), res["totalResults"])
+
+		rec, res = do(t, h, "GET", "/Users?filter="+url.QueryEscape(`emails co "alice"`), "")
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		assert.Equal(t, "invalidFilter", res["scimType"])
+	})
+
+	t.Run("deactivate with PATCH", func(t *testing.T) {
+		rec, res := do(t, h, "PATCH", "/Users/1", `{
+			"schemas": ["urn:ietf:params:scim:api:messages:2.0:PatchOp"],
+			"Operations": [{"op": "Replace", "path": "active", "value": "False"}]
+		}`)
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		assert.Equal(t, false, res["active"])
+		assert.False(t, b.users[1].Active)
+		assert.Equal(t, []string{"alice@example.com", "alice@work.example.com"}, b.users[1].Emails)
+	})
+
+	t.Run("update with PUT", func(t *testing.T) {
+		rec, _ := do(t, h, "PUT", "/Users/1", `{
+			"userName": "alice@example.com",
+			"displayName": "Alice Jones",
+			"emails": [{"value": "alice@example.com"}],
+			"active": true
+		}`)
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		assert.True(t, b.users[1].Active)
+		assert.Equal(t, "Alice Jones", b.users[1].DisplayName)
+		assert.Empty(t, b.users[1].ExternalID)
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		rec, _ := do(t, h, "DELETE", "/Users/1", "")
+		assert.Equal(t, http.StatusNoContent, rec.Code)
+
+		rec, res := do(t, h, "GET", "/Users/1", "")
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+		assert.Equal(t, "404", res["status"])
+
+		rec, _ = do(t, h, "GET", "/Users/nope", "")
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+}
+
+func TestUsersPagination(t *testing.T) {
+	b, h := setup(t)
+	for _, name := range []string{"a@example.com", "b@example.com", "c@example.com"} {
+		_, err := b.CreateUser(context.Background(), &database.SCIMUser{UserName: name, Emails: []string{name}, Active: true})
+		require.NoError(t, err)
+	}
+
+	_, res := do(t, h, "GET", "/Users?startIndex=2&count=1", "")
+	assert.Equal(t, float64(3), res["totalResults"])
+	assert.Equal(t, float64(2), res["startIndex"])
+	assert.Equal(t, float64(1), res["itemsPerPage"])
+	resources := res["Resources"].([]interface{})
+	require.Len(t, resources, 1)
+	assert.Equal(t, "b@example.com", resources[0].(map[string]interface{})["userName"])
+}
+
+func TestGroups(t *testing.T) {
+	b, h := setup(t)
+	for _, name := range []string{"a@example.com", "b@example.com", "c@example.com"} {
+		_, err := b.CreateUser(context.Background(), &database.SCIMUser{UserName: name, Emails: []string{name}, Active: true})
+		require.NoError(t, err)
+	}
+
+	rec, res := do(t, h, "POST", "/Groups", `{
+		"schemas": ["urn:ietf:params:scim:schemas:core:2.0:Group"],
+		"displayName": "Engineering",
+		"members": [{"value": "1"}, {"value": "2"}]
+	}`)
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+	assert.Equal(t, "4", res["id"])
+	assert.Equal(t, []int32{1, 2}, b.groups[4].MemberIDs)
+
+	rec, _ = do(t, h, "PATCH", "/Groups/4", `{
+		"schemas": ["urn:ietf:params:scim:api:messages:2.0:PatchOp"],
+		"Operations": [
+			{"op": "add", "path": "members", "value": [{"value": "3"}, {"value": "1"}]},
+			{"op": "remove", "path": "members[value eq \"2\"]"},
+			{"op": "replace", "value": {"displayName": "Platform"}}
+		]
+	}`)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Equal(t, "Platform", b.groups[4].DisplayName)
+	assert.Equal(t, []int32{1, 3}, b.groups[4].MemberIDs)
+
+	rec, _ = do(t, h, "PATCH", "/Groups/4", `{
+		"schemas": ["urn:ietf:params:scim:api:messages:2.0:PatchOp"],
+		"Operations": [{"op": "Remove", "path": "members", "value": [{"value": "1"}]}]
+	}`)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Equal(t, []int32{3}, b.groups[4].MemberIDs)
+
+	_, res = do(t, h, "GET", "/Groups?filter="+url.QueryEscape(`displayName eq "Platform"`), "")
+	assert.Equal(t, float64(1), res["totalResults"])
+
+	rec, _ = do(t, h, "DELETE", "/Groups/4", "")
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	assert.Empty(t, b.groups)
+}
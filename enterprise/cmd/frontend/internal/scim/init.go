@@ -0,0 +1,16 @@
+package scim
+
+import (
+	"context"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/enterprise"
+	"github.com/sourcegraph/sourcegraph/internal/database/dbutil"
+	"github.com/sourcegraph/sourcegraph/internal/oobmigration"
+)
+
+// Init registers the SCIM API handler. The API is only enabled when auth.scim is set in the
+// site configuration.
+func Init(ctx context.Context, db dbutil.DB, outOfBandMigrationRunner *oobmigration.Runner, enterpriseServices *enterprise.Services) error {
+	enterpriseServices.SCIMHandler = newHandler(&dbBackend{db: db})
+	return nil
+}
@@ -0,0 +1,269 @@
+package scim
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+type patchRequest struct {
+	Schemas    []string  `json:"schemas"`
+	Operations []patchOp `json:"Operations"`
+}
+
+type patchOp struct {
+	Op    string      `json:"op"`
+	Path  string      `json:"path"`
+	Value interface{} `json:"value"`
+}
+
+// applyPatch applies the PATCH operations to the resource, in its JSON representation. The
+// result must be decoded again into a resource to be validated.
+func applyPatch(resource interface{}, ops []patchOp) (interface{}, error) {
+	b, err := json.Marshal(resource)
+	if err != nil {
+		return nil, err
+	}
+	var m map[string]interface{}
+	if err := json.Unmarshal(b, &m); err != nil {
+		return nil, err
+	}
+
+	for _, op := range ops {
+		if err := applyPatchOp(m, op); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+func applyPatchOp(m map[string]interface{}, op patchOp) error {
+	kind := strings.ToLower(op.Op)
+	switch kind {
+	case "add", "replace", "remove":
+	default:
+		return badRequest(scimTypeInvalidSyntax, "unsupported operation "+strconv.Quote(op.Op))
+	}
+
+	if op.Path == "" {
+		// Without a path, the value holds the attributes to change, which some identity
+		// providers set with a path as key, e.g. "name.givenName".
+		values, ok := op.Value.(map[string]interface{})
+		if !ok || kind == "remove" {
+			return badRequest(scimTypeNoTarget, "a path is required")
+		}
+		for key, value := range values {
+			if err := applyPatchOp(m, patchOp{Op: kind, Path: key, Value: value}); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	p, err := parsePath(op.Path)
+	if err != nil {
+		return err
+	}
+	key := findKey(m, p.attribute)
+
+	if p.filter == nil {
+		if p.subAttribute != "" {
+			sub, _ := m[key].(map[string]interface{})
+			if sub == nil {
+				if kind == "remove" {
+					return nil
+				}
+				sub = map[string]interface{}{}
+				m[key] = sub
+			}
+			return setValue(sub, findKey(sub, p.subAttribute), kind, op.Value)
+		}
+		return setValue(m, key, kind, op.Value)
+	}
+
+	// The path selects the elements of a multi-valued attribute, e.g. members[value eq "1"].
+	elems, _ := m[key].([]interface{})
+	var result []interface{}
+	matched := false
+	for _, e := range elems {
+		elem, ok := e.(map[string]interface{})
+		if !ok || !p.filter.matches(elem) {
+			result = append(result, e)
+			continue
+		}
+		matched = true
+
+		switch {
+		case kind == "remove" && p.subAttribute == "":
+			// Drop the element.
+		case p.subAttribute == "":
+			if values, ok := op.Value.(map[string]interface{}); ok {
+				for k, v := range values {
+					elem[findKey(elem, k)] = v
+				}
+				result = append(result, elem)
+			} else {
+				result = append(result, op.Value)
+			}
+		default:
+			if err := setValue(elem, findKey(elem, p.subAttribute), kind, op.Value); err != nil {
+				return err
+			}
+			result = append(result, elem)
+		}
+	}
+	if !matched && kind != "remove" {
+		// Adding an attribute to an element that doesn't exist yet creates the element, e.g.
+		// emails[type eq "work"].value.
+		elem := map[string]interface{}{p.filter.Attribute: p.filter.Value}
+		if p.subAttribute != "" {
+			elem[p.subAttribute] = op.Value
+		} else if values, ok := op.Value.(map[string]interface{}); ok {
+			for k, v := range values {
+				elem[k] = v
+			}
+		}
+		result = append(result, elem)
+	}
+	m[key] = result
+	return nil
+}
+
+// setValue applies the operation kind to the attribute key of m. Adding to a multi-valued
+// attribute appends the values that aren't already present.
+func setValue(m map[string]interface{}, key, kind string, value interface{}) error {
+	switch kind {
+	case "remove":
+		existing, ok := m[key].([]interface{})
+		removed, hasValues := value.([]interface{})
+		if !ok || !hasValues {
+			delete(m, key)
+			return nil
+		}
+		// Some identity providers remove elements of a multi-valued attribute by listing them
+		// as the value, e.g. the members to remove.
+		var kept []interface{}
+		for _, v := range existing {
+			if !containsValue(removed, v) {
+				kept = append(kept, v)
+			}
+		}
+		m[key] = kept
+		return nil
+
+	case "add":
+		existing, ok := m[key].([]interface{})
+		if !ok {
+			break
+		}
+		added, ok := value.([]interface{})
+		if !ok {
+			added = []interface{}{value}
+		}
+		for _, v := range added {
+			if !containsValue(existing, v) {
+				existing = append(existing, v)
+			}
+		}
+		m[key] = existing
+		return nil
+	}
+
+	m[key] = value
+	return nil
+}
+
+func containsValue(values []interface{}, v interface{}) bool {
+	for _, existing := range values {
+		if reflect.DeepEqual(existing, v) {
+			return true
+		}
+		a, aOK := existing.(map[string]interface{})
+		b, bOK := v.(map[string]interface{})
+		if aOK && bOK && a["value"] != nil && reflect.DeepEqual(a["value"], b["value"]) {
+			return true
+		}
+	}
+	return false
+}
+
+// findKey returns the key of m that matches attribute case-insensitively, or attribute if there
+// is none.
+func findKey(m map[string]interface{}, attribute string) string {
+	for k := range m {
+		if strings.EqualFold(k, attribute) {
+			return k
+		}
+	}
+	return attribute
+}
+
+// path is a parsed PATCH path: attribute[filter].subAttribute, where the filter and the
+// sub-attribute are optional.
+type path struct {
+	attribute    string
+	filter       *filter
+	subAttribute string
+}
+
+func parsePath(s string) (*path, error) {
+	// Paths may be prefixed with the schema URN of the attribute.
+	for _, schema := range []string{schemaUser, schemaGroup} {
+		if len(s) > len(schema) && strings.EqualFold(s[:len(schema)+1], schema+":") {
+			s = s[len(schema)+1:]
+		}
+	}
+
+	p := &path{}
+	if i := strings.Index(s, "["); i != -1 {
+		j := strings.LastIndex(s, "]")
+		if j < i {
+			return nil, badRequest(scimTypeInvalidPath, fmt.Sprintf("invalid path %q", s))
+		}
+		expr := s[i+1 : j]
+		p.attribute, s = s[:i], s[j+1:]
+
+		fields := strings.SplitN(strings.TrimSpace(expr), " ", 3)
+		if len(fields) != 3 || !strings.EqualFold(fields[1], "eq") {
+			return nil, badRequest(scimTypeInvalidPath, fmt.Sprintf("unsupported filter %q", expr))
+		}
+		value, err := strconv.Unquote(strings.TrimSpace(fields[2]))
+		if err != nil {
+			// Booleans and numbers aren't quoted.
+			value = strings.TrimSpace(fields[2])
+		}
+		p.filter = &filter{Attribute: fields[0], Value: value}
+
+		if s != "" {
+			if !strings.HasPrefix(s, ".") {
+				return nil, badRequest(scimTypeInvalidPath, fmt.Sprintf("invalid path %q", s))
+			}
+			p.subAttribute = s[1:]
+		}
+	} else if i := strings.Index(s, "."); i != -1 {
+		p.attribute, p.subAttribute = s[:i], s[i+1:]
+	} else {
+		p.attribute = s
+	}
+
+	if p.attribute == "" {
+		return nil, badRequest(scimTypeInvalidPath, "invalid empty path")
+	}
+	return p, nil
+}
+
+// matches returns whether the element of a multi-valued attribute matches the filter.
+func (f *filter) matches(elem map[string]interface{}) bool {
+	v, ok := elem[findKey(elem, f.Attribute)]
+	if !ok {
+		return false
+	}
+	switch v := v.(type) {
+	case string:
+		return strings.EqualFold(v, f.Value)
+	default:
+		return strings.EqualFold(fmt.Sprint(v), f.Value)
+	}
+}
@@ -0,0 +1,124 @@
+package scim
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestApplyPatch(t *testing.T) {
+	const resource = `{
+		"userName": "alice",
+		"name": {"givenName": "Alice"},
+		"emails": [{"value": "alice@example.com", "type": "work", "primary": true}],
+		"active": true
+	}`
+
+	for name, tc := range map[string]struct {
+		ops  string
+		want string
+		err  string
+	}{
+		"replace attribute": {
+			ops:  `[{"op": "replace", "path": "userName", "value": "bob"}]`,
+			want: `{"userName": "bob", "name": {"givenName": "Alice"}, "emails": [{"value": "alice@example.com", "type": "work", "primary": true}], "active": true}`,
+		},
+		"replace without path": {
+			ops:  `[{"op": "Replace", "value": {"active": false, "name.familyName": "Smith"}}]`,
+			want: `{"userName": "alice", "name": {"givenName": "Alice", "familyName": "Smith"}, "emails": [{"value": "alice@example.com", "type": "work", "primary": true}], "active": false}`,
+		},
+		"case-insensitive attributes": {
+			ops:  `[{"op": "replace", "path": "urn:ietf:params:scim:schemas:core:2.0:User:USERNAME", "value": "bob"}]`,
+			want: `{"userName": "bob", "name": {"givenName": "Alice"}, "emails": [{"value": "alice@example.com", "type": "work", "primary": true}], "active": true}`,
+		},
+		"replace with filter": {
+			ops:  `[{"op": "replace", "path": "emails[type eq \"work\"].value", "value": "alice@work.example.com"}]`,
+			want: `{"userName": "alice", "name": {"givenName": "Alice"}, "emails": [{"value": "alice@work.example.com", "type": "work", "primary": true}], "active": true}`,
+		},
+		"add with unmatched filter": {
+			ops:  `[{"op": "add", "path": "emails[type eq \"home\"].value", "value": "alice@home.example.com"}]`,
+			want: `{"userName": "alice", "name": {"givenName": "Alice"}, "emails": [{"value": "alice@example.com", "type": "work", "primary": true}, {"value": "alice@home.example.com", "type": "home"}], "active": true}`,
+		},
+		"add to multi-valued attribute": {
+			ops:  `[{"op": "add", "path": "emails", "value": [{"value": "alice@example.com", "type": "work", "primary": true}, {"value": "a@example.com"}]}]`,
+			want: `{"userName": "alice", "name": {"givenName": "Alice"}, "emails": [{"value": "alice@example.com", "type": "work", "primary": true}, {"value": "a@example.com"}], "active": true}`,
+		},
+		"remove with filter": {
+			ops:  `[{"op": "remove", "path": "emails[primary eq true]"}]`,
+			want: `{"userName": "alice", "name": {"givenName": "Alice"}, "emails": null, "active": true}`,
+		},
+		"remove sub-attribute": {
+			ops:  `[{"op": "remove", "path": "name.givenName"}]`,
+			want: `{"userName": "alice", "name": {}, "emails": [{"value": "alice@example.com", "type": "work", "primary": true}], "active": true}`,
+		},
+		"unsupported operation": {
+			ops: `[{"op": "move", "path": "userName"}]`,
+			err: `unsupported operation "move"`,
+		},
+		"unsupported filter": {
+			ops: `[{"op": "remove", "path": "emails[value co \"alice\"]"}]`,
+			err: `unsupported filter "value co \"alice\""`,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			var r map[string]interface{}
+			if err := json.Unmarshal([]byte(resource), &r); err != nil {
+				t.Fatal(err)
+			}
+			var ops []patchOp
+			if err := json.Unmarshal([]byte(tc.ops), &ops); err != nil {
+				t.Fatal(err)
+			}
+
+			have, err := applyPatch(r, ops)
+			if tc.err != "" {
+				if err == nil || err.Error() != tc.err {
+					t.Fatalf("unexpected error: want %q, have %v", tc.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var want interface{}
+			if err := json.Unmarshal([]byte(tc.want), &want); err != nil {
+				t.Fatal(err)
+			}
+			b, _ := json.Marshal(have)
+			var haveJSON interface{}
+			_ = json.Unmarshal(b, &haveJSON)
+			if diff := cmp.Diff(want, haveJSON); diff != "" {
+				t.Fatalf("unexpected resource (-want +have):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestParseFilter(t *testing.T) {
+	for filter, want := range map[string]*filter{
+		``:                             nil,
+		`userName eq "alice"`:          {Attribute: "userName", Value: "alice"},
+		`USERNAME Eq "alice smith"`:    {Attribute: "userName", Value: "alice smith"},
+		`externalId eq "00u\"1"`:       {Attribute: "externalId", Value: `00u"1`},
+		`displayName eq "Engineering"`: nil,
+		`userName sw "a"`:              nil,
+		`userName eq alice`:            nil,
+	} {
+		have, err := parseFilter(filter, "userName", "externalId")
+		if want == nil && filter != "" {
+			if err == nil {
+				t.Errorf("%q: expected an error", filter)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q: unexpected error: %s", filter, err)
+			continue
+		}
+		if diff := cmp.Diff(want, have); diff != "" {
+			t.Errorf("%q: unexpected filter (-want +have):\n%s", filter, diff)
+		}
+	}
+}
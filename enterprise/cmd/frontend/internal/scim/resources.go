@@ -0,0 +1,228 @@
+package scim
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cockroachdb/errors"
+
+	"github.com/sourcegraph/sourcegraph/internal/database"
+)
+
+const (
+	schemaUser                  = "urn:ietf:params:scim:schemas:core:2.0:User"
+	schemaGroup                 = "urn:ietf:params:scim:schemas:core:2.0:Group"
+	schemaListResponse          = "urn:ietf:params:scim:api:messages:2.0:ListResponse"
+	schemaPatchOp               = "urn:ietf:params:scim:api:messages:2.0:PatchOp"
+	schemaError                 = "urn:ietf:params:scim:api:messages:2.0:Error"
+	schemaServiceProviderConfig = "urn:ietf:params:scim:schemas:core:2.0:ServiceProviderConfig"
+)
+
+// userResource is the SCIM representation of a user.
+type userResource struct {
+	Schemas     []string  `json:"schemas"`
+	ID          string    `json:"id,omitempty"`
+	ExternalID  string    `json:"externalId,omitempty"`
+	UserName    string    `json:"userName"`
+	Name        *name     `json:"name,omitempty"`
+	DisplayName string    `json:"displayName,omitempty"`
+	Emails      []email   `json:"emails,omitempty"`
+	Active      *flexBool `json:"active,omitempty"`
+	Meta        *meta     `json:"meta,omitempty"`
+}
+
+type name struct {
+	Formatted  string `json:"formatted,omitempty"`
+	GivenName  string `json:"givenName,omitempty"`
+	FamilyName string `json:"familyName,omitempty"`
+}
+
+type email struct {
+	Value   string   `json:"value"`
+	Type    string   `json:"type,omitempty"`
+	Primary flexBool `json:"primary,omitempty"`
+}
+
+// groupResource is the SCIM representation of a group, which is a Sourcegraph organization.
+type groupResource struct {
+	Schemas     []string `json:"schemas"`
+	ID          string   `json:"id,omitempty"`
+	DisplayName string   `json:"displayName"`
+	Members     []member `json:"members"`
+	Meta        *meta    `json:"meta,omitempty"`
+}
+
+type member struct {
+	Value   string `json:"value"`
+	Display string `json:"display,omitempty"`
+}
+
+type meta struct {
+	ResourceType string    `json:"resourceType"`
+	Created      time.Time `json:"created"`
+	LastModified time.Time `json:"lastModified"`
+	Location     string    `json:"location"`
+}
+
+type listResponse struct {
+	Schemas      []string    `json:"schemas"`
+	TotalResults int         `json:"totalResults"`
+	StartIndex   int         `json:"startIndex"`
+	ItemsPerPage int         `json:"itemsPerPage"`
+	Resources    interface{} `json:"Resources"`
+}
+
+// flexBool is a boolean that also accepts the strings "true" and "false" in any case, which
+// some identity providers send.
+type flexBool bool
+
+func (b *flexBool) UnmarshalJSON(data []byte) error {
+	var v interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	switch v := v.(type) {
+	case bool:
+		*b = flexBool(v)
+	case string:
+		parsed, err := strconv.ParseBool(strings.ToLower(v))
+		if err != nil {
+			return errors.Errorf("invalid boolean %q", v)
+		}
+		*b = flexBool(parsed)
+	case nil:
+		*b = false
+	default:
+		return errors.Errorf("invalid boolean %v", v)
+	}
+	return nil
+}
+
+// toSCIMUser validates r and converts it to the SCIM user userID.
+func (r *userResource) toSCIMUser(userID int32) (*database.SCIMUser, error) {
+	if r.UserName == "" {
+		return nil, badRequest(scimTypeInvalidValue, "userName is required")
+	}
+
+	// The primary email comes first.
+	var emails []string
+	addEmail := func(e string) {
+		for _, existing := range emails {
+			if strings.EqualFold(existing, e) {
+				return
+			}
+		}
+		emails = append(emails, e)
+	}
+	for _, e := range r.Emails {
+		if e.Primary && e.Value != "" {
+			addEmail(e.Value)
+		}
+	}
+	for _, e := range r.Emails {
+		if e.Value != "" {
+			addEmail(e.Value)
+		}
+	}
+	if len(emails) == 0 && strings.Count(r.UserName, "@") == 1 {
+		// Identity providers that don't send emails commonly use them as userName.
+		emails = append(emails, r.UserName)
+	}
+	if len(emails) == 0 {
+		return nil, badRequest(scimTypeInvalidValue, "at least one email address is required")
+	}
+
+	displayName := r.DisplayName
+	if displayName == "" && r.Name != nil {
+		displayName = r.Name.Formatted
+		if displayName == "" {
+			displayName = strings.TrimSpace(r.Name.GivenName + " " + r.Name.FamilyName)
+		}
+	}
+
+	return &database.SCIMUser{
+		UserID:      userID,
+		UserName:    r.UserName,
+		ExternalID:  r.ExternalID,
+		Emails:      emails,
+		Active:      r.Active == nil || bool(*r.Active),
+		DisplayName: displayName,
+	}, nil
+}
+
+func newUserResource(u *database.SCIMUser, location string) *userResource {
+	emails := make([]email, 0, len(u.Emails))
+	for i, e := range u.Emails {
+		emails = append(emails, email{Value: e, Type: "work", Primary: i == 0})
+	}
+	active := flexBool(u.Active)
+
+	var n *name
+	if u.DisplayName != "" {
+		n = &name{Formatted: u.DisplayName}
+	}
+
+	return &userResource{
+		Schemas:     []string{schemaUser},
+		ID:          strconv.Itoa(int(u.UserID)),
+		ExternalID:  u.ExternalID,
+		UserName:    u.UserName,
+		Name:        n,
+		DisplayName: u.DisplayName,
+		Emails:      emails,
+		Active:      &active,
+		Meta: &meta{
+			ResourceType: "User",
+			Created:      u.CreatedAt,
+			LastModified: u.UpdatedAt,
+			Location:     location,
+		},
+	}
+}
+
+// toGroup validates r and converts it to the group orgID.
+func (r *groupResource) toGroup(orgID int32) (*group, error) {
+	if r.DisplayName == "" {
+		return nil, badRequest(scimTypeInvalidValue, "displayName is required")
+	}
+
+	g := &group{OrgID: orgID, DisplayName: r.DisplayName}
+	for _, m := range r.Members {
+		id, err := parseID(m.Value)
+		if err != nil {
+			return nil, badRequest(scimTypeInvalidValue, "invalid member "+strconv.Quote(m.Value))
+		}
+		g.MemberIDs = append(g.MemberIDs, id)
+	}
+	return g, nil
+}
+
+func newGroupResource(g *group, location string) *groupResource {
+	members := make([]member, 0, len(g.Members))
+	for _, m := range g.Members {
+		members = append(members, member{Value: strconv.Itoa(int(m.ID)), Display: m.Username})
+	}
+
+	return &groupResource{
+		Schemas:     []string{schemaGroup},
+		ID:          strconv.Itoa(int(g.OrgID)),
+		DisplayName: g.DisplayName,
+		Members:     members,
+		Meta: &meta{
+			ResourceType: "Group",
+			Created:      g.CreatedAt,
+			LastModified: g.UpdatedAt,
+			Location:     location,
+		},
+	}
+}
+
+func parseID(s string) (int32, error) {
+	id, err := strconv.ParseInt(s, 10, 32)
+	if err != nil || id <= 0 {
+		return 0, errors.Errorf("invalid id %q", s)
+	}
+	return int32(id), nil
+}
@@ -0,0 +1,266 @@
+package sources
+
+import (
+	"context"
+	"net/url"
+	"strconv"
+
+	"github.com/cockroachdb/errors"
+
+	"github.com/sourcegraph/sourcegraph/internal/database"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/auth"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/bitbucketcloud"
+	"github.com/sourcegraph/sourcegraph/internal/gitserver/protocol"
+	"github.com/sourcegraph/sourcegraph/internal/httpcli"
+	"github.com/sourcegraph/sourcegraph/internal/jsonc"
+	"github.com/sourcegraph/sourcegraph/internal/types"
+	"github.com/sourcegraph/sourcegraph/internal/vcs/git"
+	"github.com/sourcegraph/sourcegraph/schema"
+)
+
+type BitbucketCloudSource struct {
+	client *bitbucketcloud.Client
+	au     auth.Authenticator
+}
+
+var _ ChangesetSource = &BitbucketCloudSource{}
+
+// NewBitbucketCloudSource returns a new BitbucketCloudSource from the given external service.
+func NewBitbucketCloudSource(svc *types.ExternalService, cf *httpcli.Factory) (*BitbucketCloudSource, error) {
+	var c schema.BitbucketCloudConnection
+	if err := jsonc.Unmarshal(svc.Config, &c); err != nil {
+		return nil, errors.Errorf("external service id=%d config error: %s", svc.ID, err)
+	}
+	return newBitbucketCloudSource(&c, cf, nil)
+}
+
+func newBitbucketCloudSource(c *schema.BitbucketCloudConnection, cf *httpcli.Factory, au auth.Authenticator) (*BitbucketCloudSource, error) {
+	apiURLString := c.ApiURL
+	if apiURLString == "" {
+		apiURLString = "https://api.bitbucket.org"
+	}
+	apiURL, err := url.Parse(apiURLString)
+	if err != nil {
+		return nil, err
+	}
+	apiURL = extsvc.NormalizeBaseURL(apiURL)
+
+	if cf == nil {
+		cf = httpcli.ExternalClientFactory
+	}
+
+	cli, err := cf.Doer()
+	if err != nil {
+		return nil, err
+	}
+
+	// Don't modify passed-in parameter.
+	authr := au
+	if authr == nil && c.AppPassword != "" {
+		authr = &auth.BasicAuth{Username: c.Username, Password: c.AppPassword}
+	}
+
+	client := bitbucketcloud.NewClient(apiURL, cli)
+	if authr != nil {
+		client = client.WithAuthenticator(authr)
+	}
+
+	return &BitbucketCloudSource{
+		au:     authr,
+		client: client,
+	}, nil
+}
+
+func (s BitbucketCloudSource) GitserverPushConfig(ctx context.Context, store *database.ExternalServiceStore, repo *types.Repo) (*protocol.PushConfig, error) {
+	return gitserverPushConfig(ctx, store, repo, s.au)
+}
+
+func (s BitbucketCloudSource) WithAuthenticator(a auth.Authenticator) (ChangesetSource, error) {
+	// Bitbucket Cloud only supports app passwords, which are used as the
+	// password of basic authentication.
+	switch a.(type) {
+	case *auth.BasicAuth,
+		*auth.BasicAuthWithSSH:
+		break
+
+	default:
+		return nil, newUnsupportedAuthenticatorError("BitbucketCloudSource", a)
+	}
+
+	sc := s
+	sc.au = a
+	sc.client = sc.client.WithAuthenticator(a)
+
+	return &sc, nil
+}
+
+func (s BitbucketCloudSource) ValidateAuthenticator(ctx context.Context) error {
+	_, err := s.client.CurrentUser(ctx)
+	return err
+}
+
+// CreateChangeset creates a Bitbucket Cloud pull request.
+//
+// Bitbucket Cloud doesn't report whether a pull request already existed for
+// the same branches: it updates the open pull request instead, so the return
+// value is always false.
+func (s BitbucketCloudSource) CreateChangeset(ctx context.Context, c *Changeset) (bool, error) {
+	repo := c.Repo.Metadata.(*bitbucketcloud.Repo)
+
+	pr, err := s.client.CreatePullRequest(ctx, repo, &bitbucketcloud.PullRequestInput{
+		Title:             c.Title,
+		Description:       c.Body,
+		SourceBranch:      git.AbbreviateRef(c.HeadRef),
+		DestinationBranch: git.AbbreviateRef(c.BaseRef),
+	})
+	if err != nil {
+		return false, errors.Wrap(err, "creating the pull request")
+	}
+
+	if err := s.setChangesetMetadata(ctx, repo, pr, c); err != nil {
+		return false, err
+	}
+	return false, nil
+}
+
+// CloseChangeset declines the pull request on Bitbucket Cloud.
+func (s BitbucketCloudSource) CloseChangeset(ctx context.Context, c *Changeset) error {
+	repo := c.Repo.Metadata.(*bitbucketcloud.Repo)
+	pr, ok := c.Changeset.Metadata.(*bitbucketcloud.PullRequest)
+	if !ok {
+		return errors.New("Changeset is not a Bitbucket Cloud pull request")
+	}
+
+	declined, err := s.client.DeclinePullRequest(ctx, repo, pr.ID)
+	if err != nil {
+		return errors.Wrap(err, "declining pull request")
+	}
+
+	return s.setChangesetMetadata(ctx, repo, declined, c)
+}
+
+// ReopenChangeset reopens the pull request on Bitbucket Cloud.
+//
+// Declined pull requests can't be reopened on Bitbucket Cloud, so a new pull
+// request is opened for the same branches instead. As a result, the external
+// ID of the changeset changes.
+func (s BitbucketCloudSource) ReopenChangeset(ctx context.Context, c *Changeset) error {
+	repo := c.Repo.Metadata.(*bitbucketcloud.Repo)
+	pr, ok := c.Changeset.Metadata.(*bitbucketcloud.PullRequest)
+	if !ok {
+		return errors.New("Changeset is not a Bitbucket Cloud pull request")
+	}
+
+	reopened, err := s.client.CreatePullRequest(ctx, repo, &bitbucketcloud.PullRequestInput{
+		Title:             pr.Title,
+		Description:       pr.Description,
+		SourceBranch:      pr.Source.Branch.Name,
+		DestinationBranch: pr.Destination.Branch.Name,
+	})
+	if err != nil {
+		return errors.Wrap(err, "reopening pull request")
+	}
+
+	return s.setChangesetMetadata(ctx, repo, reopened, c)
+}
+
+// LoadChangeset loads the given pull request from Bitbucket Cloud and updates
+// it.
+func (s BitbucketCloudSource) LoadChangeset(ctx context.Context, cs *Changeset) error {
+	repo := cs.Repo.Metadata.(*bitbucketcloud.Repo)
+
+	id, err := strconv.ParseInt(cs.ExternalID, 10, 64)
+	if err != nil {
+		return errors.Wrapf(err, "parsing changeset external ID %s", cs.ExternalID)
+	}
+
+	pr, err := s.client.GetPullRequest(ctx, repo, id)
+	if err != nil {
+		if bitbucketcloud.IsNotFound(err) {
+			return ChangesetNotFoundError{Changeset: cs}
+		}
+		return errors.Wrapf(err, "retrieving pull request %d", id)
+	}
+
+	return s.setChangesetMetadata(ctx, repo, pr, cs)
+}
+
+// UpdateChangeset updates the pull request on Bitbucket Cloud to reflect the
+// local state of the Changeset.
+func (s BitbucketCloudSource) UpdateChangeset(ctx context.Context, c *Changeset) error {
+	repo := c.Repo.Metadata.(*bitbucketcloud.Repo)
+	pr, ok := c.Changeset.Metadata.(*bitbucketcloud.PullRequest)
+	if !ok {
+		return errors.New("Changeset is not a Bitbucket Cloud pull request")
+	}
+
+	updated, err := s.client.UpdatePullRequest(ctx, repo, pr.ID, &bitbucketcloud.PullRequestInput{
+		Title:             c.Title,
+		Description:       c.Body,
+		DestinationBranch: git.AbbreviateRef(c.BaseRef),
+	})
+	if err != nil {
+		return errors.Wrap(err, "updating pull request")
+	}
+
+	return s.setChangesetMetadata(ctx, repo, updated, c)
+}
+
+// CreateComment posts a comment on the Changeset.
+func (s BitbucketCloudSource) CreateComment(ctx context.Context, c *Changeset, text string) error {
+	repo := c.Repo.Metadata.(*bitbucketcloud.Repo)
+	pr, ok := c.Changeset.Metadata.(*bitbucketcloud.PullRequest)
+	if !ok {
+		return errors.New("Changeset is not a Bitbucket Cloud pull request")
+	}
+
+	return s.client.CreatePullRequestComment(ctx, repo, pr.ID, text)
+}
+
+// MergeChangeset merges a Changeset on the code host, if in a mergeable state.
+// If squash is true, a squash-then-merge merge will be performed.
+func (s BitbucketCloudSource) MergeChangeset(ctx context.Context, c *Changeset, squash bool) error {
+	repo := c.Repo.Metadata.(*bitbucketcloud.Repo)
+	pr, ok := c.Changeset.Metadata.(*bitbucketcloud.PullRequest)
+	if !ok {
+		return errors.New("Changeset is not a Bitbucket Cloud pull request")
+	}
+
+	opts := bitbucketcloud.MergePullRequestOpts{}
+	if squash {
+		opts.MergeStrategy = bitbucketcloud.MergeStrategySquash
+	}
+
+	merged, err := s.client.MergePullRequest(ctx, repo, pr.ID, opts)
+	if err != nil {
+		if errors.Is(err, bitbucketcloud.ErrNotMergeable) {
+			return ChangesetNotMergeableError{ErrorMsg: err.Error()}
+		}
+		return errors.Wrap(err, "merging pull request")
+	}
+
+	return s.setChangesetMetadata(ctx, repo, merged, c)
+}
+
+// setChangesetMetadata loads the build statuses of the given pull request and
+// sets it as the metadata of the Changeset.
+func (s BitbucketCloudSource) setChangesetMetadata(ctx context.Context, repo *bitbucketcloud.Repo, pr *bitbucketcloud.PullRequest, c *Changeset) error {
+	statuses, err := s.client.GetPullRequestStatuses(ctx, repo, pr.ID)
+	if err != nil {
+		return errors.Wrapf(err, "retrieving statuses of pull request %d", pr.ID)
+	}
+
+	pr.CommitStatus = make([]*bitbucketcloud.CommitStatus, 0, len(statuses))
+	for _, status := range statuses {
+		pr.CommitStatus = append(pr.CommitStatus, &bitbucketcloud.CommitStatus{
+			Commit: pr.Source.Commit.Hash,
+			Status: *status,
+		})
+	}
+
+	if err := c.Changeset.SetMetadata(pr); err != nil {
+		return errors.Wrapf(err, "setting changeset metadata for pull request %d", pr.ID)
+	}
+	return nil
+}
@@ -0,0 +1,242 @@
+package sources
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/cockroachdb/errors"
+
+	btypes "github.com/sourcegraph/sourcegraph/enterprise/internal/batches/types"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/auth"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/bitbucketcloud"
+	"github.com/sourcegraph/sourcegraph/internal/testutil"
+	"github.com/sourcegraph/sourcegraph/internal/types"
+	"github.com/sourcegraph/sourcegraph/schema"
+)
+
+var bitbucketCloudTestRepo = &types.Repo{
+	Metadata: &bitbucketcloud.Repo{
+		FullName: "sourcegraph-testing/src-cli",
+		UUID:     "{b090a669-7f2c-4f83-9a7b-2d6d1b1e3a11}",
+	},
+}
+
+func newBitbucketCloudTestSource(t *testing.T, name string) (*BitbucketCloudSource, func(testing.TB)) {
+	t.Helper()
+
+	cf, save := newClientFactory(t, name)
+
+	svc := &types.ExternalService{
+		Kind: extsvc.KindBitbucketCloud,
+		Config: marshalJSON(t, &schema.BitbucketCloudConnection{
+			Url:         "https://bitbucket.org",
+			Username:    os.Getenv("BITBUCKET_CLOUD_USERNAME"),
+			AppPassword: os.Getenv("BITBUCKET_CLOUD_APP_PASSWORD"),
+		}),
+	}
+
+	src, err := NewBitbucketCloudSource(svc, cf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return src, save
+}
+
+func TestBitbucketCloudSource_LoadChangeset(t *testing.T) {
+	testCases := []struct {
+		name string
+		cs   *Changeset
+		err  string
+	}{
+		{
+			name: "found",
+			cs:   &Changeset{Repo: bitbucketCloudTestRepo, Changeset: &btypes.Changeset{ExternalID: "1"}},
+			err:  "<nil>",
+		},
+		{
+			name: "not-found",
+			cs:   &Changeset{Repo: bitbucketCloudTestRepo, Changeset: &btypes.Changeset{ExternalID: "999"}},
+			err:  "Changeset with external ID 999 not found",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		tc.name = "BitbucketCloudSource_LoadChangeset_" + tc.name
+
+		t.Run(tc.name, func(t *testing.T) {
+			src, save := newBitbucketCloudTestSource(t, tc.name)
+			defer save(t)
+
+			err := src.LoadChangeset(context.Background(), tc.cs)
+			if have, want := fmt.Sprint(err), tc.err; have != want {
+				t.Errorf("error:\nhave: %q\nwant: %q", have, want)
+			}
+
+			if err != nil {
+				return
+			}
+
+			testutil.AssertGolden(t, "testdata/golden/"+tc.name, update(tc.name), tc.cs.Changeset.Metadata.(*bitbucketcloud.PullRequest))
+		})
+	}
+}
+
+func TestBitbucketCloudSource_CreateChangeset(t *testing.T) {
+	name := "BitbucketCloudSource_CreateChangeset_success"
+	src, save := newBitbucketCloudTestSource(t, name)
+	defer save(t)
+
+	cs := &Changeset{
+		Title:     "This is a test PR",
+		Body:      "This is the body of a test PR",
+		BaseRef:   "refs/heads/main",
+		HeadRef:   "refs/heads/test-pr-bbc-2",
+		Repo:      bitbucketCloudTestRepo,
+		Changeset: &btypes.Changeset{},
+	}
+
+	exists, err := src.CreateChangeset(context.Background(), cs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exists {
+		t.Error("unexpectedly reported an existing pull request")
+	}
+
+	pr := cs.Changeset.Metadata.(*bitbucketcloud.PullRequest)
+	testutil.AssertGolden(t, "testdata/golden/"+name, update(name), pr)
+}
+
+func TestBitbucketCloudSource_CloseAndReopenChangeset(t *testing.T) {
+	cs := &Changeset{
+		Repo: bitbucketCloudTestRepo,
+		Changeset: &btypes.Changeset{Metadata: &bitbucketcloud.PullRequest{
+			ID:          2,
+			Title:       "This is a test PR",
+			Description: "This is the body of a test PR",
+			Source:      bitbucketcloud.PullRequestEndpoint{Branch: bitbucketcloud.PullRequestBranch{Name: "test-pr-bbc-2"}},
+			Destination: bitbucketcloud.PullRequestEndpoint{Branch: bitbucketcloud.PullRequestBranch{Name: "main"}},
+		}},
+	}
+
+	t.Run("BitbucketCloudSource_CloseChangeset_success", func(t *testing.T) {
+		src, save := newBitbucketCloudTestSource(t, "BitbucketCloudSource_CloseChangeset_success")
+		defer save(t)
+
+		if err := src.CloseChangeset(context.Background(), cs); err != nil {
+			t.Fatal(err)
+		}
+
+		if have, want := cs.Changeset.Metadata.(*bitbucketcloud.PullRequest).State, bitbucketcloud.PullRequestStateDeclined; have != want {
+			t.Errorf("state:\nhave: %q\nwant: %q", have, want)
+		}
+	})
+
+	t.Run("BitbucketCloudSource_ReopenChangeset_success", func(t *testing.T) {
+		src, save := newBitbucketCloudTestSource(t, "BitbucketCloudSource_ReopenChangeset_success")
+		defer save(t)
+
+		if err := src.ReopenChangeset(context.Background(), cs); err != nil {
+			t.Fatal(err)
+		}
+
+		if have, want := cs.Changeset.Metadata.(*bitbucketcloud.PullRequest).State, bitbucketcloud.PullRequestStateOpen; have != want {
+			t.Errorf("state:\nhave: %q\nwant: %q", have, want)
+		}
+		// Declined pull requests are reopened as new pull requests.
+		if have, want := cs.ExternalID, "4"; have != want {
+			t.Errorf("external ID:\nhave: %q\nwant: %q", have, want)
+		}
+	})
+}
+
+func TestBitbucketCloudSource_UpdateChangeset(t *testing.T) {
+	name := "BitbucketCloudSource_UpdateChangeset_success"
+	src, save := newBitbucketCloudTestSource(t, name)
+	defer save(t)
+
+	cs := &Changeset{
+		Title:     "This is an updated test PR",
+		Body:      "This is the updated body",
+		BaseRef:   "refs/heads/main",
+		Repo:      bitbucketCloudTestRepo,
+		Changeset: &btypes.Changeset{Metadata: &bitbucketcloud.PullRequest{ID: 2}},
+	}
+
+	if err := src.UpdateChangeset(context.Background(), cs); err != nil {
+		t.Fatal(err)
+	}
+
+	pr := cs.Changeset.Metadata.(*bitbucketcloud.PullRequest)
+	if have, want := pr.Title, "This is an updated test PR"; have != want {
+		t.Errorf("title:\nhave: %q\nwant: %q", have, want)
+	}
+	if have, want := pr.Description, "This is the updated body"; have != want {
+		t.Errorf("description:\nhave: %q\nwant: %q", have, want)
+	}
+}
+
+func TestBitbucketCloudSource_MergeChangeset(t *testing.T) {
+	name := "BitbucketCloudSource_MergeChangeset_not-mergeable"
+	src, save := newBitbucketCloudTestSource(t, name)
+	defer save(t)
+
+	cs := &Changeset{
+		Repo:      bitbucketCloudTestRepo,
+		Changeset: &btypes.Changeset{Metadata: &bitbucketcloud.PullRequest{ID: 3}},
+	}
+
+	err := src.MergeChangeset(context.Background(), cs, true)
+	if !errors.HasType(err, ChangesetNotMergeableError{}) {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestBitbucketCloudSource_WithAuthenticator(t *testing.T) {
+	svc := &types.ExternalService{
+		Kind: extsvc.KindBitbucketCloud,
+		Config: marshalJSON(t, &schema.BitbucketCloudConnection{
+			Url:         "https://bitbucket.org",
+			Username:    "alice",
+			AppPassword: "secret",
+		}),
+	}
+
+	src, err := NewBitbucketCloudSource(svc, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("supported", func(t *testing.T) {
+		for name, tc := range map[string]auth.Authenticator{
+			"BasicAuth":        &auth.BasicAuth{},
+			"BasicAuthWithSSH": &auth.BasicAuthWithSSH{},
+		} {
+			t.Run(name, func(t *testing.T) {
+				if _, err := src.WithAuthenticator(tc); err != nil {
+					t.Errorf("unexpected non-nil error: %v", err)
+				}
+			})
+		}
+	})
+
+	t.Run("unsupported", func(t *testing.T) {
+		for name, tc := range map[string]auth.Authenticator{
+			"nil":              nil,
+			"OAuthBearerToken": &auth.OAuthBearerToken{},
+		} {
+			t.Run(name, func(t *testing.T) {
+				_, err := src.WithAuthenticator(tc)
+				if err == nil {
+					t.Error("unexpected nil error")
+				} else if !errors.HasType(err, UnsupportedAuthenticatorError{}) {
+					t.Errorf("unexpected error of type %T: %v", err, err)
+				}
+			})
+		}
+	})
+}
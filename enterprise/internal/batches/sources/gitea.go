@@ -0,0 +1,271 @@
+package sources
+
+import (
+	"context"
+	"net/url"
+	"strconv"
+
+	"github.com/cockroachdb/errors"
+
+	"github.com/sourcegraph/sourcegraph/internal/database"
+	"github.com/sourcegraph/sourcegraph/internal/errcode"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/auth"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/gitea"
+	"github.com/sourcegraph/sourcegraph/internal/gitserver/protocol"
+	"github.com/sourcegraph/sourcegraph/internal/httpcli"
+	"github.com/sourcegraph/sourcegraph/internal/jsonc"
+	"github.com/sourcegraph/sourcegraph/internal/types"
+	"github.com/sourcegraph/sourcegraph/internal/vcs/git"
+	"github.com/sourcegraph/sourcegraph/schema"
+)
+
+type GiteaSource struct {
+	client *gitea.Client
+	au     auth.Authenticator
+}
+
+var _ ChangesetSource = &GiteaSource{}
+var _ DraftChangesetSource = &GiteaSource{}
+
+// NewGiteaSource returns a new GiteaSource from the given external service.
+func NewGiteaSource(svc *types.ExternalService, cf *httpcli.Factory) (*GiteaSource, error) {
+	var c schema.GiteaConnection
+	if err := jsonc.Unmarshal(svc.Config, &c); err != nil {
+		return nil, errors.Errorf("external service id=%d config error: %s", svc.ID, err)
+	}
+	return newGiteaSource(&c, cf, nil)
+}
+
+func newGiteaSource(c *schema.GiteaConnection, cf *httpcli.Factory, au auth.Authenticator) (*GiteaSource, error) {
+	baseURL, err := url.Parse(c.Url)
+	if err != nil {
+		return nil, err
+	}
+	baseURL = extsvc.NormalizeBaseURL(baseURL)
+
+	if cf == nil {
+		cf = httpcli.ExternalClientFactory
+	}
+
+	cli, err := cf.Doer()
+	if err != nil {
+		return nil, err
+	}
+
+	// Don't modify passed-in parameter.
+	authr := au
+	if authr == nil && c.Token != "" {
+		authr = &auth.OAuthBearerToken{Token: c.Token}
+	}
+
+	return &GiteaSource{
+		au:     authr,
+		client: gitea.NewClient(baseURL, authr, cli),
+	}, nil
+}
+
+func (s GiteaSource) GitserverPushConfig(ctx context.Context, store *database.ExternalServiceStore, repo *types.Repo) (*protocol.PushConfig, error) {
+	return gitserverPushConfig(ctx, store, repo, s.au)
+}
+
+func (s GiteaSource) WithAuthenticator(a auth.Authenticator) (ChangesetSource, error) {
+	switch a.(type) {
+	case *auth.OAuthBearerToken,
+		*auth.OAuthBearerTokenWithSSH:
+		break
+
+	default:
+		return nil, newUnsupportedAuthenticatorError("GiteaSource", a)
+	}
+
+	sc := s
+	sc.au = a
+	sc.client = sc.client.WithAuthenticator(a)
+
+	return &sc, nil
+}
+
+func (s GiteaSource) ValidateAuthenticator(ctx context.Context) error {
+	_, err := s.client.GetAuthenticatedUser(ctx)
+	return err
+}
+
+// CreateChangeset creates a Gitea pull request. If it already exists,
+// *Changeset will be populated and the return value will be true.
+func (s GiteaSource) CreateChangeset(ctx context.Context, c *Changeset) (bool, error) {
+	repo := c.Repo.Metadata.(*gitea.Repository)
+	exists := false
+	head := git.AbbreviateRef(c.HeadRef)
+	base := git.AbbreviateRef(c.BaseRef)
+
+	pr, err := s.client.CreatePullRequest(ctx, repo.Owner.Login, repo.Name, &gitea.CreatePullRequestInput{
+		Title: c.Title,
+		Body:  c.Body,
+		Head:  head,
+		Base:  base,
+	})
+	if err != nil {
+		if err != gitea.ErrPullRequestAlreadyExists {
+			return exists, errors.Wrap(err, "creating the pull request")
+		}
+		exists = true
+
+		pr, err = s.client.GetPullRequestByBranches(ctx, repo.Owner.Login, repo.Name, base, head)
+		if err != nil {
+			return exists, errors.Wrap(err, "retrieving an extant pull request")
+		}
+	}
+
+	if err := c.SetMetadata(pr); err != nil {
+		return exists, errors.Wrap(err, "setting changeset metadata")
+	}
+	return exists, nil
+}
+
+// CreateDraftChangeset creates a Gitea pull request marked as work in
+// progress. If it already exists, *Changeset will be populated and the return
+// value will be true.
+func (s GiteaSource) CreateDraftChangeset(ctx context.Context, c *Changeset) (bool, error) {
+	c.Title = gitea.SetWIP(c.Title)
+
+	exists, err := s.CreateChangeset(ctx, c)
+	if err != nil {
+		return exists, err
+	}
+
+	pr, ok := c.Changeset.Metadata.(*gitea.PullRequest)
+	if !ok {
+		return false, errors.New("Changeset is not a Gitea pull request")
+	}
+
+	// If it already exists, but is not a WIP, we need to update the title.
+	if exists && !pr.IsWorkInProgress() {
+		if err := s.UpdateChangeset(ctx, c); err != nil {
+			return exists, err
+		}
+	}
+	return exists, nil
+}
+
+// UndraftChangeset marks the changeset as *not* work in progress anymore.
+func (s GiteaSource) UndraftChangeset(ctx context.Context, c *Changeset) error {
+	c.Title = gitea.UnsetWIP(c.Title)
+	return s.UpdateChangeset(ctx, c)
+}
+
+// CloseChangeset closes the pull request on Gitea.
+func (s GiteaSource) CloseChangeset(ctx context.Context, c *Changeset) error {
+	return s.setState(ctx, c, gitea.PullRequestStateClosed)
+}
+
+// ReopenChangeset reopens the pull request on Gitea.
+func (s GiteaSource) ReopenChangeset(ctx context.Context, c *Changeset) error {
+	return s.setState(ctx, c, gitea.PullRequestStateOpen)
+}
+
+func (s GiteaSource) setState(ctx context.Context, c *Changeset, state gitea.PullRequestState) error {
+	repo := c.Repo.Metadata.(*gitea.Repository)
+	pr, ok := c.Changeset.Metadata.(*gitea.PullRequest)
+	if !ok {
+		return errors.New("Changeset is not a Gitea pull request")
+	}
+
+	updated, err := s.client.EditPullRequest(ctx, repo.Owner.Login, repo.Name, pr.Number, &gitea.EditPullRequestInput{
+		State: state,
+	})
+	if err != nil {
+		return errors.Wrapf(err, "setting state of Gitea pull request to %s", state)
+	}
+
+	return c.Changeset.SetMetadata(updated)
+}
+
+// LoadChangeset loads the given pull request from Gitea and updates it.
+func (s GiteaSource) LoadChangeset(ctx context.Context, cs *Changeset) error {
+	repo := cs.Repo.Metadata.(*gitea.Repository)
+
+	number, err := strconv.ParseInt(cs.ExternalID, 10, 64)
+	if err != nil {
+		return errors.Wrapf(err, "parsing changeset external ID %s", cs.ExternalID)
+	}
+
+	pr, err := s.client.GetPullRequest(ctx, repo.Owner.Login, repo.Name, number)
+	if err != nil {
+		if errcode.IsNotFound(err) {
+			return ChangesetNotFoundError{Changeset: cs}
+		}
+		return errors.Wrapf(err, "retrieving pull request %d", number)
+	}
+
+	if err := cs.SetMetadata(pr); err != nil {
+		return errors.Wrapf(err, "setting changeset metadata for pull request %d", number)
+	}
+
+	return nil
+}
+
+// UpdateChangeset updates the pull request on Gitea to reflect the local
+// state of the Changeset.
+func (s GiteaSource) UpdateChangeset(ctx context.Context, c *Changeset) error {
+	repo := c.Repo.Metadata.(*gitea.Repository)
+	pr, ok := c.Changeset.Metadata.(*gitea.PullRequest)
+	if !ok {
+		return errors.New("Changeset is not a Gitea pull request")
+	}
+
+	// Avoid accidentally undrafting the changeset by checking its current
+	// status.
+	title := c.Title
+	if pr.IsWorkInProgress() {
+		title = gitea.SetWIP(c.Title)
+	}
+
+	updated, err := s.client.EditPullRequest(ctx, repo.Owner.Login, repo.Name, pr.Number, &gitea.EditPullRequestInput{
+		Title: &title,
+		Body:  &c.Body,
+		Base:  git.AbbreviateRef(c.BaseRef),
+	})
+	if err != nil {
+		return errors.Wrap(err, "updating Gitea pull request")
+	}
+
+	return c.Changeset.SetMetadata(updated)
+}
+
+// CreateComment posts a comment on the Changeset.
+func (s GiteaSource) CreateComment(ctx context.Context, c *Changeset, text string) error {
+	repo := c.Repo.Metadata.(*gitea.Repository)
+	pr, ok := c.Changeset.Metadata.(*gitea.PullRequest)
+	if !ok {
+		return errors.New("Changeset is not a Gitea pull request")
+	}
+
+	return s.client.CreateComment(ctx, repo.Owner.Login, repo.Name, pr.Number, text)
+}
+
+// MergeChangeset merges a Changeset on the code host, if in a mergeable state.
+// If squash is true, a squash-then-merge merge will be performed.
+func (s GiteaSource) MergeChangeset(ctx context.Context, c *Changeset, squash bool) error {
+	repo := c.Repo.Metadata.(*gitea.Repository)
+	pr, ok := c.Changeset.Metadata.(*gitea.PullRequest)
+	if !ok {
+		return errors.New("Changeset is not a Gitea pull request")
+	}
+
+	if err := s.client.MergePullRequest(ctx, repo.Owner.Login, repo.Name, pr.Number, squash); err != nil {
+		if errors.Is(err, gitea.ErrNotMergeable) {
+			return ChangesetNotMergeableError{ErrorMsg: err.Error()}
+		}
+		return errors.Wrap(err, "merging Gitea pull request")
+	}
+
+	// The merge endpoint doesn't return the pull request, so we need to
+	// fetch it again to update the metadata.
+	updated, err := s.client.GetPullRequest(ctx, repo.Owner.Login, repo.Name, pr.Number)
+	if err != nil {
+		return errors.Wrapf(err, "retrieving pull request %d", pr.Number)
+	}
+
+	return c.Changeset.SetMetadata(updated)
+}
@@ -0,0 +1,207 @@
+package sources
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+
+	btypes "github.com/sourcegraph/sourcegraph/enterprise/internal/batches/types"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/gitea"
+	"github.com/sourcegraph/sourcegraph/internal/testutil"
+	"github.com/sourcegraph/sourcegraph/internal/types"
+	"github.com/sourcegraph/sourcegraph/schema"
+)
+
+// The test fixtures and golden files were generated against
+// https://gitea.sgdev.org.
+const giteaInstanceURL = "https://gitea.sgdev.org"
+
+var giteaTestRepo = &types.Repo{
+	Metadata: &gitea.Repository{
+		ID:    21,
+		Owner: &gitea.User{ID: 3, Login: "alice"},
+		Name:  "automation-testing",
+	},
+}
+
+func newGiteaTestSource(t *testing.T, name string) (*GiteaSource, func(testing.TB)) {
+	t.Helper()
+
+	cf, save := newClientFactory(t, name)
+
+	svc := &types.ExternalService{
+		Kind: extsvc.KindGitea,
+		Config: marshalJSON(t, &schema.GiteaConnection{
+			Url:   giteaInstanceURL,
+			Token: os.Getenv("GITEA_TOKEN"),
+		}),
+	}
+
+	src, err := NewGiteaSource(svc, cf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return src, save
+}
+
+func TestGiteaSource_LoadChangeset(t *testing.T) {
+	testCases := []struct {
+		name string
+		cs   *Changeset
+		err  string
+	}{
+		{
+			name: "found",
+			cs:   &Changeset{Repo: giteaTestRepo, Changeset: &btypes.Changeset{ExternalID: "1"}},
+			err:  "<nil>",
+		},
+		{
+			name: "not-found",
+			cs:   &Changeset{Repo: giteaTestRepo, Changeset: &btypes.Changeset{ExternalID: "999"}},
+			err:  "Changeset with external ID 999 not found",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		tc.name = "GiteaSource_LoadChangeset_" + tc.name
+
+		t.Run(tc.name, func(t *testing.T) {
+			src, save := newGiteaTestSource(t, tc.name)
+			defer save(t)
+
+			err := src.LoadChangeset(context.Background(), tc.cs)
+			if have, want := fmt.Sprint(err), tc.err; have != want {
+				t.Errorf("error:\nhave: %q\nwant: %q", have, want)
+			}
+
+			if err != nil {
+				return
+			}
+
+			testutil.AssertGolden(t, "testdata/golden/"+tc.name, update(tc.name), tc.cs.Changeset.Metadata.(*gitea.PullRequest))
+		})
+	}
+}
+
+func TestGiteaSource_CreateChangeset(t *testing.T) {
+	testCases := []struct {
+		name   string
+		cs     *Changeset
+		exists bool
+	}{
+		{
+			name: "success",
+			cs: &Changeset{
+				Title:     "This is a test PR",
+				Body:      "This is the body of a test PR",
+				BaseRef:   "refs/heads/main",
+				HeadRef:   "refs/heads/test-pr-gitea-2",
+				Repo:      giteaTestRepo,
+				Changeset: &btypes.Changeset{},
+			},
+		},
+		{
+			name: "already exists",
+			cs: &Changeset{
+				Title:     "This is a test PR",
+				Body:      "This is the body of a test PR",
+				BaseRef:   "refs/heads/main",
+				HeadRef:   "refs/heads/always-open-pr",
+				Repo:      giteaTestRepo,
+				Changeset: &btypes.Changeset{},
+			},
+			// CreateChangeset is idempotent so if the PR already exists
+			// it is not an error
+			exists: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		tc.name = "GiteaSource_CreateChangeset_" + tc.name
+
+		t.Run(tc.name, func(t *testing.T) {
+			src, save := newGiteaTestSource(t, tc.name)
+			defer save(t)
+
+			exists, err := src.CreateChangeset(context.Background(), tc.cs)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if have, want := exists, tc.exists; have != want {
+				t.Errorf("exists:\nhave: %t\nwant: %t", have, want)
+			}
+
+			pr := tc.cs.Changeset.Metadata.(*gitea.PullRequest)
+			testutil.AssertGolden(t, "testdata/golden/"+tc.name, update(tc.name), pr)
+		})
+	}
+}
+
+func TestGiteaSource_CloseAndReopenChangeset(t *testing.T) {
+	cs := &Changeset{
+		Repo:      giteaTestRepo,
+		Changeset: &btypes.Changeset{Metadata: &gitea.PullRequest{Number: 3}},
+	}
+
+	t.Run("GiteaSource_CloseChangeset_success", func(t *testing.T) {
+		src, save := newGiteaTestSource(t, "GiteaSource_CloseChangeset_success")
+		defer save(t)
+
+		if err := src.CloseChangeset(context.Background(), cs); err != nil {
+			t.Fatal(err)
+		}
+
+		if have, want := cs.Changeset.Metadata.(*gitea.PullRequest).State, gitea.PullRequestStateClosed; have != want {
+			t.Errorf("state:\nhave: %q\nwant: %q", have, want)
+		}
+	})
+
+	t.Run("GiteaSource_ReopenChangeset_success", func(t *testing.T) {
+		src, save := newGiteaTestSource(t, "GiteaSource_ReopenChangeset_success")
+		defer save(t)
+
+		if err := src.ReopenChangeset(context.Background(), cs); err != nil {
+			t.Fatal(err)
+		}
+
+		if have, want := cs.Changeset.Metadata.(*gitea.PullRequest).State, gitea.PullRequestStateOpen; have != want {
+			t.Errorf("state:\nhave: %q\nwant: %q", have, want)
+		}
+	})
+}
+
+func TestGiteaSource_UpdateChangeset(t *testing.T) {
+	name := "GiteaSource_UpdateChangeset_success"
+	src, save := newGiteaTestSource(t, name)
+	defer save(t)
+
+	// The pull request is currently a draft, so the updated title must keep
+	// the WIP prefix.
+	cs := &Changeset{
+		Title:   "This is an updated test PR",
+		Body:    "This is the updated body",
+		BaseRef: "refs/heads/main",
+		Repo:    giteaTestRepo,
+		Changeset: &btypes.Changeset{Metadata: &gitea.PullRequest{
+			Number: 4,
+			Title:  "WIP: This is a test PR",
+		}},
+	}
+
+	if err := src.UpdateChangeset(context.Background(), cs); err != nil {
+		t.Fatal(err)
+	}
+
+	pr := cs.Changeset.Metadata.(*gitea.PullRequest)
+	if have, want := pr.Title, "WIP: This is an updated test PR"; have != want {
+		t.Errorf("title:\nhave: %q\nwant: %q", have, want)
+	}
+	if !pr.IsWorkInProgress() {
+		t.Error("pull request is no longer a work in progress")
+	}
+}
@@ -265,6 +265,14 @@ func loadExternalService(ctx context.Context, s *database.ExternalServiceStore,
 			if cfg.Token != "" {
 				return e, nil
 			}
+		case *schema.GiteaConnection:
+			if cfg.Token != "" {
+				return e, nil
+			}
+		case *schema.BitbucketCloudConnection:
+			if cfg.AppPassword != "" {
+				return e, nil
+			}
 		}
 	}
 
@@ -282,6 +290,10 @@ func buildChangesetSource(store SourcerStore, cf *httpcli.Factory, externalServi
 		return NewGitLabSource(externalService, cf)
 	case extsvc.KindBitbucketServer:
 		return NewBitbucketServerSource(externalService, cf)
+	case extsvc.KindGitea:
+		return NewGiteaSource(externalService, cf)
+	case extsvc.KindBitbucketCloud:
+		return NewBitbucketCloudSource(externalService, cf)
 	default:
 		return nil, errors.Errorf("unsupported external service type %q", extsvc.KindToType(externalService.Kind))
 	}
@@ -328,12 +340,15 @@ func setOAuthTokenAuth(u *vcs.URL, extSvcType, token string) error {
 	case extsvc.TypeGitHub:
 		u.User = url.User(token)
 
-	case extsvc.TypeGitLab:
+	case extsvc.TypeGitLab, extsvc.TypeGitea:
 		u.User = url.UserPassword("git", token)
 
 	case extsvc.TypeBitbucketServer:
 		return errors.New("require username/token to push commits to BitbucketServer")
 
+	case extsvc.TypeBitbucketCloud:
+		return errors.New("require username/app password to push commits to Bitbucket Cloud")
+
 	default:
 		panic(fmt.Sprintf("setOAuthTokenAuth: invalid external service type %q", extSvcType))
 	}
@@ -344,10 +359,10 @@ func setOAuthTokenAuth(u *vcs.URL, extSvcType, token string) error {
 // password combination, with the specific quirks per code host.
 func setBasicAuth(u *vcs.URL, extSvcType, username, password string) error {
 	switch extSvcType {
-	case extsvc.TypeGitHub, extsvc.TypeGitLab:
+	case extsvc.TypeGitHub, extsvc.TypeGitLab, extsvc.TypeGitea:
 		return errors.New("need token to push commits to " + extSvcType)
 
-	case extsvc.TypeBitbucketServer:
+	case extsvc.TypeBitbucketServer, extsvc.TypeBitbucketCloud:
 		u.User = url.UserPassword(username, password)
 
 	default:
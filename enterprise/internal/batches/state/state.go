@@ -16,7 +16,9 @@ import (
 	"github.com/sourcegraph/sourcegraph/internal/api"
 	"github.com/sourcegraph/sourcegraph/internal/database"
 	"github.com/sourcegraph/sourcegraph/internal/extsvc"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/bitbucketcloud"
 	"github.com/sourcegraph/sourcegraph/internal/extsvc/bitbucketserver"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/gitea"
 	"github.com/sourcegraph/sourcegraph/internal/extsvc/github"
 	"github.com/sourcegraph/sourcegraph/internal/extsvc/gitlab"
 	"github.com/sourcegraph/sourcegraph/internal/vcs/git"
@@ -105,6 +107,9 @@ func computeCheckState(c *btypes.Changeset, events ChangesetEvents) btypes.Chang
 
 	case *gitlab.MergeRequest:
 		return computeGitLabCheckState(c.UpdatedAt, m, events)
+
+	case *bitbucketcloud.PullRequest:
+		return computeBitbucketCloudBuildStatus(c.UpdatedAt, m, events)
 	}
 
 	return btypes.ChangesetCheckStateUnknown
@@ -197,6 +202,49 @@ func parseBitbucketBuildState(s string) btypes.ChangesetCheckState {
 	}
 }
 
+func computeBitbucketCloudBuildStatus(lastSynced time.Time, pr *bitbucketcloud.PullRequest, events []*btypes.ChangesetEvent) btypes.ChangesetCheckState {
+	stateMap := make(map[string]btypes.ChangesetCheckState)
+
+	// States from last sync
+	for _, status := range pr.CommitStatus {
+		stateMap[status.Status.Key] = parseBitbucketCloudBuildState(status.Status.State)
+	}
+
+	// Add any events we've received since our last sync
+	for _, e := range events {
+		switch m := e.Metadata.(type) {
+		case *bitbucketcloud.CommitStatus:
+			if !m.MatchesCommit(pr.Source.Commit.Hash) {
+				continue
+			}
+			if m.Status.UpdatedOn.Before(lastSynced) {
+				continue
+			}
+			stateMap[m.Status.Key] = parseBitbucketCloudBuildState(m.Status.State)
+		}
+	}
+
+	states := make([]btypes.ChangesetCheckState, 0, len(stateMap))
+	for _, v := range stateMap {
+		states = append(states, v)
+	}
+
+	return combineCheckStates(states)
+}
+
+func parseBitbucketCloudBuildState(s bitbucketcloud.PullRequestStatusState) btypes.ChangesetCheckState {
+	switch s {
+	case bitbucketcloud.PullRequestStatusStateFailed, bitbucketcloud.PullRequestStatusStateStopped:
+		return btypes.ChangesetCheckStateFailed
+	case bitbucketcloud.PullRequestStatusStateInProgress:
+		return btypes.ChangesetCheckStatePending
+	case bitbucketcloud.PullRequestStatusStateSuccessful:
+		return btypes.ChangesetCheckStatePassed
+	default:
+		return btypes.ChangesetCheckStateUnknown
+	}
+}
+
 func computeGitHubCheckState(lastSynced time.Time, pr *github.PullRequest, events []*btypes.ChangesetEvent) btypes.ChangesetCheckState {
 	// We should only consider the latest commit. This could be from a sync or a webhook that
 	// has occurred later
@@ -450,6 +498,36 @@ func computeSingleChangesetExternalState(c *btypes.Changeset) (s btypes.Changese
 		default:
 			return "", errors.Errorf("unknown GitLab merge request state: %s", m.State)
 		}
+	case *gitea.PullRequest:
+		switch m.State {
+		case gitea.PullRequestStateClosed:
+			// Gitea closes merged pull requests, so we need to tell them
+			// apart from declined ones.
+			if m.Merged {
+				s = btypes.ChangesetExternalStateMerged
+			} else {
+				s = btypes.ChangesetExternalStateClosed
+			}
+		case gitea.PullRequestStateOpen:
+			if m.IsWorkInProgress() {
+				s = btypes.ChangesetExternalStateDraft
+			} else {
+				s = btypes.ChangesetExternalStateOpen
+			}
+		default:
+			return "", errors.Errorf("unknown Gitea pull request state: %s", m.State)
+		}
+	case *bitbucketcloud.PullRequest:
+		switch m.State {
+		case bitbucketcloud.PullRequestStateOpen:
+			s = btypes.ChangesetExternalStateOpen
+		case bitbucketcloud.PullRequestStateMerged:
+			s = btypes.ChangesetExternalStateMerged
+		case bitbucketcloud.PullRequestStateDeclined, bitbucketcloud.PullRequestStateSuperseded:
+			s = btypes.ChangesetExternalStateClosed
+		default:
+			return "", errors.Errorf("unknown Bitbucket Cloud pull request state: %s", m.State)
+		}
 	default:
 		return "", errors.New("unknown changeset type")
 	}
@@ -507,6 +585,26 @@ func computeSingleChangesetReviewState(c *btypes.Changeset) (s btypes.ChangesetR
 		}
 		return btypes.ChangesetReviewStatePending, nil
 
+	case *gitea.PullRequest:
+		// We don't sync Gitea pull request reviews yet, so they are always
+		// pending.
+		return btypes.ChangesetReviewStatePending, nil
+
+	case *bitbucketcloud.PullRequest:
+		for _, p := range m.Participants {
+			if p.Role != bitbucketcloud.ParticipantRoleReviewer {
+				continue
+			}
+			switch p.State {
+			case bitbucketcloud.ParticipantStateApproved:
+				states[btypes.ChangesetReviewStateApproved] = true
+			case bitbucketcloud.ParticipantStateChangesRequested:
+				states[btypes.ChangesetReviewStateChangesRequested] = true
+			default:
+				states[btypes.ChangesetReviewStatePending] = true
+			}
+		}
+
 	default:
 		return "", errors.New("unknown changeset type")
 	}
@@ -9,7 +9,9 @@ import (
 
 	btypes "github.com/sourcegraph/sourcegraph/enterprise/internal/batches/types"
 	"github.com/sourcegraph/sourcegraph/internal/extsvc"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/bitbucketcloud"
 	"github.com/sourcegraph/sourcegraph/internal/extsvc/bitbucketserver"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/gitea"
 	"github.com/sourcegraph/sourcegraph/internal/extsvc/github"
 	"github.com/sourcegraph/sourcegraph/internal/extsvc/gitlab"
 	"github.com/sourcegraph/sourcegraph/internal/timeutil"
@@ -276,6 +278,99 @@ func TestComputeBitbucketBuildStatus(t *testing.T) {
 	}
 }
 
+func TestComputeBitbucketCloudBuildStatus(t *testing.T) {
+	t.Parallel()
+
+	now := timeutil.Now()
+	lastSynced := now.Add(-1 * time.Minute)
+
+	status := func(commit, key string, state bitbucketcloud.PullRequestStatusState, updatedOn time.Time) *bitbucketcloud.CommitStatus {
+		return &bitbucketcloud.CommitStatus{
+			Commit: commit,
+			Status: bitbucketcloud.PullRequestStatus{Key: key, State: state, UpdatedOn: updatedOn},
+		}
+	}
+	statusEvent := func(commit, key string, state bitbucketcloud.PullRequestStatusState, updatedOn time.Time) *btypes.ChangesetEvent {
+		return &btypes.ChangesetEvent{
+			Kind:     btypes.ChangesetEventKindBitbucketCloudCommitStatus,
+			Metadata: status(commit, key, state, updatedOn),
+		}
+	}
+
+	// Bitbucket Cloud abbreviates the hashes in pull requests, but not in
+	// webhook payloads.
+	sha := "abcdef012345"
+	fullSHA := sha + "6789abcdef0123456789abcdef"
+
+	tests := []struct {
+		name     string
+		statuses []*bitbucketcloud.CommitStatus
+		events   []*btypes.ChangesetEvent
+		want     btypes.ChangesetCheckState
+	}{
+		{
+			name: "no statuses",
+			want: btypes.ChangesetCheckStateUnknown,
+		},
+		{
+			name: "synced success",
+			statuses: []*bitbucketcloud.CommitStatus{
+				status(sha, "ctx1", bitbucketcloud.PullRequestStatusStateSuccessful, lastSynced),
+			},
+			want: btypes.ChangesetCheckStatePassed,
+		},
+		{
+			name: "synced success + stopped",
+			statuses: []*bitbucketcloud.CommitStatus{
+				status(sha, "ctx1", bitbucketcloud.PullRequestStatusStateSuccessful, lastSynced),
+				status(sha, "ctx2", bitbucketcloud.PullRequestStatusStateStopped, lastSynced),
+			},
+			want: btypes.ChangesetCheckStateFailed,
+		},
+		{
+			name: "newer event has precedence",
+			statuses: []*bitbucketcloud.CommitStatus{
+				status(sha, "ctx1", bitbucketcloud.PullRequestStatusStateInProgress, lastSynced),
+			},
+			events: []*btypes.ChangesetEvent{
+				statusEvent(fullSHA, "ctx1", bitbucketcloud.PullRequestStatusStateSuccessful, now),
+			},
+			want: btypes.ChangesetCheckStatePassed,
+		},
+		{
+			name: "older event is ignored",
+			statuses: []*bitbucketcloud.CommitStatus{
+				status(sha, "ctx1", bitbucketcloud.PullRequestStatusStateInProgress, lastSynced),
+			},
+			events: []*btypes.ChangesetEvent{
+				statusEvent(fullSHA, "ctx1", bitbucketcloud.PullRequestStatusStateFailed, lastSynced.Add(-time.Minute)),
+			},
+			want: btypes.ChangesetCheckStatePending,
+		},
+		{
+			name: "event for another commit is ignored",
+			events: []*btypes.ChangesetEvent{
+				statusEvent("fedcba", "ctx1", bitbucketcloud.PullRequestStatusStateFailed, now),
+			},
+			want: btypes.ChangesetCheckStateUnknown,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			pr := &bitbucketcloud.PullRequest{
+				Source:       bitbucketcloud.PullRequestEndpoint{Commit: bitbucketcloud.PullRequestCommit{Hash: sha}},
+				CommitStatus: tc.statuses,
+			}
+
+			have := computeBitbucketCloudBuildStatus(lastSynced, pr, tc.events)
+			if diff := cmp.Diff(tc.want, have); diff != "" {
+				t.Fatalf(diff)
+			}
+		})
+	}
+}
+
 func TestComputeGitLabCheckState(t *testing.T) {
 	t.Parallel()
 
@@ -535,6 +630,39 @@ func TestComputeReviewState(t *testing.T) {
 			},
 			want: btypes.ChangesetReviewStateChangesRequested,
 		},
+		{
+			name: "bitbucketcloud - no events, approved",
+			changeset: bitbucketCloudChangeset(daysAgo(0), bitbucketcloud.PullRequestStateOpen,
+				bitbucketcloud.ParticipantStateApproved,
+			),
+			history: []changesetStatesAtTime{},
+			want:    btypes.ChangesetReviewStateApproved,
+		},
+		{
+			name: "bitbucketcloud - no events, approved and changes requested",
+			changeset: bitbucketCloudChangeset(daysAgo(0), bitbucketcloud.PullRequestStateOpen,
+				bitbucketcloud.ParticipantStateApproved,
+				bitbucketcloud.ParticipantStateChangesRequested,
+			),
+			history: []changesetStatesAtTime{},
+			want:    btypes.ChangesetReviewStateChangesRequested,
+		},
+		{
+			name:      "bitbucketcloud - no events, no reviews",
+			changeset: bitbucketCloudChangeset(daysAgo(0), bitbucketcloud.PullRequestStateOpen, ""),
+			history:   []changesetStatesAtTime{},
+			want:      btypes.ChangesetReviewStatePending,
+		},
+		{
+			name: "bitbucketcloud - changeset older than events",
+			changeset: bitbucketCloudChangeset(daysAgo(10), bitbucketcloud.PullRequestStateOpen,
+				bitbucketcloud.ParticipantStateChangesRequested,
+			),
+			history: []changesetStatesAtTime{
+				{t: daysAgo(0), reviewState: btypes.ChangesetReviewStateApproved},
+			},
+			want: btypes.ChangesetReviewStateApproved,
+		},
 	}
 
 	for i, tc := range tests {
@@ -715,6 +843,70 @@ func TestComputeExternalState(t *testing.T) {
 			},
 			want: btypes.ChangesetExternalStateDraft,
 		},
+		{
+			name:      "gitea - no events, open",
+			changeset: giteaChangeset(daysAgo(0), &gitea.PullRequest{State: gitea.PullRequestStateOpen}),
+			history:   []changesetStatesAtTime{},
+			want:      btypes.ChangesetExternalStateOpen,
+		},
+		{
+			name:      "gitea - no events, closed",
+			changeset: giteaChangeset(daysAgo(0), &gitea.PullRequest{State: gitea.PullRequestStateClosed}),
+			history:   []changesetStatesAtTime{},
+			want:      btypes.ChangesetExternalStateClosed,
+		},
+		{
+			name:      "gitea - no events, merged",
+			changeset: giteaChangeset(daysAgo(0), &gitea.PullRequest{State: gitea.PullRequestStateClosed, Merged: true}),
+			history:   []changesetStatesAtTime{},
+			want:      btypes.ChangesetExternalStateMerged,
+		},
+		{
+			name:      "gitea draft - no events",
+			changeset: giteaChangeset(daysAgo(0), &gitea.PullRequest{State: gitea.PullRequestStateOpen, Title: "WIP: Fix the thing"}),
+			history:   []changesetStatesAtTime{},
+			want:      btypes.ChangesetExternalStateDraft,
+		},
+		{
+			name:      "gitea - changeset older than events",
+			changeset: giteaChangeset(daysAgo(10), &gitea.PullRequest{State: gitea.PullRequestStateOpen}),
+			history: []changesetStatesAtTime{
+				{t: daysAgo(0), externalState: btypes.ChangesetExternalStateClosed},
+			},
+			want: btypes.ChangesetExternalStateClosed,
+		},
+		{
+			name:      "bitbucketcloud - no events, open",
+			changeset: bitbucketCloudChangeset(daysAgo(0), bitbucketcloud.PullRequestStateOpen, ""),
+			history:   []changesetStatesAtTime{},
+			want:      btypes.ChangesetExternalStateOpen,
+		},
+		{
+			name:      "bitbucketcloud - no events, declined",
+			changeset: bitbucketCloudChangeset(daysAgo(0), bitbucketcloud.PullRequestStateDeclined, ""),
+			history:   []changesetStatesAtTime{},
+			want:      btypes.ChangesetExternalStateClosed,
+		},
+		{
+			name:      "bitbucketcloud - no events, superseded",
+			changeset: bitbucketCloudChangeset(daysAgo(0), bitbucketcloud.PullRequestStateSuperseded, ""),
+			history:   []changesetStatesAtTime{},
+			want:      btypes.ChangesetExternalStateClosed,
+		},
+		{
+			name:      "bitbucketcloud - no events, merged",
+			changeset: bitbucketCloudChangeset(daysAgo(0), bitbucketcloud.PullRequestStateMerged, ""),
+			history:   []changesetStatesAtTime{},
+			want:      btypes.ChangesetExternalStateMerged,
+		},
+		{
+			name:      "bitbucketcloud - changeset older than events",
+			changeset: bitbucketCloudChangeset(daysAgo(10), bitbucketcloud.PullRequestStateOpen, ""),
+			history: []changesetStatesAtTime{
+				{t: daysAgo(0), externalState: btypes.ChangesetExternalStateMerged},
+			},
+			want: btypes.ChangesetExternalStateMerged,
+		},
 	}
 
 	for i, tc := range tests {
@@ -865,6 +1057,30 @@ func gitLabChangeset(updatedAt time.Time, state gitlab.MergeRequestState, notes
 	}
 }
 
+func giteaChangeset(updatedAt time.Time, pr *gitea.PullRequest) *btypes.Changeset {
+	return &btypes.Changeset{
+		ExternalServiceType: extsvc.TypeGitea,
+		UpdatedAt:           updatedAt,
+		Metadata:            pr,
+	}
+}
+
+func bitbucketCloudChangeset(updatedAt time.Time, state bitbucketcloud.PullRequestState, reviewStates ...bitbucketcloud.ParticipantState) *btypes.Changeset {
+	pr := &bitbucketcloud.PullRequest{State: state}
+	for _, s := range reviewStates {
+		pr.Participants = append(pr.Participants, bitbucketcloud.Participant{
+			Role:  bitbucketcloud.ParticipantRoleReviewer,
+			State: s,
+		})
+	}
+
+	return &btypes.Changeset{
+		ExternalServiceType: extsvc.TypeBitbucketCloud,
+		UpdatedAt:           updatedAt,
+		Metadata:            pr,
+	}
+}
+
 func setDeletedAt(c *btypes.Changeset, deletedAt time.Time) *btypes.Changeset {
 	c.ExternalDeletedAt = deletedAt
 	return c
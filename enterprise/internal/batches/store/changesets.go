@@ -21,7 +21,9 @@ import (
 	"github.com/sourcegraph/sourcegraph/internal/database/basestore"
 	"github.com/sourcegraph/sourcegraph/internal/database/dbutil"
 	"github.com/sourcegraph/sourcegraph/internal/extsvc"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/bitbucketcloud"
 	"github.com/sourcegraph/sourcegraph/internal/extsvc/bitbucketserver"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/gitea"
 	"github.com/sourcegraph/sourcegraph/internal/extsvc/github"
 	"github.com/sourcegraph/sourcegraph/internal/extsvc/gitlab"
 	"github.com/sourcegraph/sourcegraph/internal/observation"
@@ -1190,6 +1192,10 @@ func scanChangeset(t *btypes.Changeset, s scanner) error {
 		t.Metadata = new(bitbucketserver.PullRequest)
 	case extsvc.TypeGitLab:
 		t.Metadata = new(gitlab.MergeRequest)
+	case extsvc.TypeGitea:
+		t.Metadata = new(gitea.PullRequest)
+	case extsvc.TypeBitbucketCloud:
+		t.Metadata = new(bitbucketcloud.PullRequest)
 	default:
 		return errors.New("unknown external service type")
 	}
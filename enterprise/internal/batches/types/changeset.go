@@ -11,7 +11,9 @@ import (
 
 	"github.com/sourcegraph/sourcegraph/internal/api"
 	"github.com/sourcegraph/sourcegraph/internal/extsvc"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/bitbucketcloud"
 	"github.com/sourcegraph/sourcegraph/internal/extsvc/bitbucketserver"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/gitea"
 	"github.com/sourcegraph/sourcegraph/internal/extsvc/github"
 	"github.com/sourcegraph/sourcegraph/internal/extsvc/gitlab"
 	"github.com/sourcegraph/sourcegraph/internal/timeutil"
@@ -379,6 +381,20 @@ func (c *Changeset) SetMetadata(meta interface{}) error {
 		c.ExternalServiceType = extsvc.TypeGitLab
 		c.ExternalBranch = git.EnsureRefPrefix(pr.SourceBranch)
 		c.ExternalUpdatedAt = pr.UpdatedAt.Time
+	case *gitea.PullRequest:
+		c.Metadata = pr
+		c.ExternalID = strconv.FormatInt(pr.Number, 10)
+		c.ExternalServiceType = extsvc.TypeGitea
+		if pr.Head != nil {
+			c.ExternalBranch = git.EnsureRefPrefix(pr.Head.Ref)
+		}
+		c.ExternalUpdatedAt = pr.UpdatedAt
+	case *bitbucketcloud.PullRequest:
+		c.Metadata = pr
+		c.ExternalID = strconv.FormatInt(pr.ID, 10)
+		c.ExternalServiceType = extsvc.TypeBitbucketCloud
+		c.ExternalBranch = git.EnsureRefPrefix(pr.Source.Branch.Name)
+		c.ExternalUpdatedAt = pr.UpdatedOn
 	default:
 		return errors.New("unknown changeset type")
 	}
@@ -404,6 +420,10 @@ func (c *Changeset) Title() (string, error) {
 		return m.Title, nil
 	case *gitlab.MergeRequest:
 		return m.Title, nil
+	case *gitea.PullRequest:
+		return m.Title, nil
+	case *bitbucketcloud.PullRequest:
+		return m.Title, nil
 	default:
 		return "", errors.New("unknown changeset type")
 	}
@@ -421,6 +441,13 @@ func (c *Changeset) AuthorName() (string, error) {
 		return m.Author.User.Name, nil
 	case *gitlab.MergeRequest:
 		return m.Author.Username, nil
+	case *gitea.PullRequest:
+		if m.User == nil {
+			return "", nil
+		}
+		return m.User.Login, nil
+	case *bitbucketcloud.PullRequest:
+		return m.Author.DisplayName, nil
 	default:
 		return "", errors.New("unknown changeset type")
 	}
@@ -444,6 +471,14 @@ func (c *Changeset) AuthorEmail() (string, error) {
 		return m.Author.User.EmailAddress, nil
 	case *gitlab.MergeRequest:
 		return m.Author.Email, nil
+	case *gitea.PullRequest:
+		if m.User == nil {
+			return "", nil
+		}
+		return m.User.Email, nil
+	case *bitbucketcloud.PullRequest:
+		// Bitbucket Cloud never exposes the email addresses of users.
+		return "", nil
 	default:
 		return "", errors.New("unknown changeset type")
 	}
@@ -460,6 +495,10 @@ func (c *Changeset) ExternalCreatedAt() time.Time {
 		return unixMilliToTime(int64(m.CreatedDate))
 	case *gitlab.MergeRequest:
 		return m.CreatedAt.Time
+	case *gitea.PullRequest:
+		return m.CreatedAt
+	case *bitbucketcloud.PullRequest:
+		return m.CreatedOn
 	default:
 		return time.Time{}
 	}
@@ -474,6 +513,10 @@ func (c *Changeset) Body() (string, error) {
 		return m.Description, nil
 	case *gitlab.MergeRequest:
 		return m.Description, nil
+	case *gitea.PullRequest:
+		return m.Body, nil
+	case *bitbucketcloud.PullRequest:
+		return m.Description, nil
 	default:
 		return "", errors.New("unknown changeset type")
 	}
@@ -511,6 +554,10 @@ func (c *Changeset) URL() (s string, err error) {
 		return selfLink.Href, nil
 	case *gitlab.MergeRequest:
 		return m.WebURL, nil
+	case *gitea.PullRequest:
+		return m.HTMLURL, nil
+	case *bitbucketcloud.PullRequest:
+		return m.Links.HTML.Href, nil
 	default:
 		return "", errors.New("unknown changeset type")
 	}
@@ -643,6 +690,22 @@ func (c *Changeset) Events() (events []*ChangesetEvent, err error) {
 				Metadata:    pipeline,
 			})
 		}
+
+	case *bitbucketcloud.PullRequest:
+		events = make([]*ChangesetEvent, 0, len(m.CommitStatus))
+
+		for _, s := range m.CommitStatus {
+			kind, err := ChangesetEventKindFor(s)
+			if err != nil {
+				return nil, err
+			}
+			appendEvent(&ChangesetEvent{
+				ChangesetID: c.ID,
+				Key:         s.Key(),
+				Kind:        kind,
+				Metadata:    s,
+			})
+		}
 	}
 	return events, nil
 }
@@ -658,6 +721,13 @@ func (c *Changeset) HeadRefOid() (string, error) {
 		return "", nil
 	case *gitlab.MergeRequest:
 		return m.DiffRefs.HeadSHA, nil
+	case *gitea.PullRequest:
+		if m.Head == nil {
+			return "", nil
+		}
+		return m.Head.SHA, nil
+	case *bitbucketcloud.PullRequest:
+		return m.Source.Commit.Hash, nil
 	default:
 		return "", errors.New("unknown changeset type")
 	}
@@ -673,6 +743,13 @@ func (c *Changeset) HeadRef() (string, error) {
 		return m.FromRef.ID, nil
 	case *gitlab.MergeRequest:
 		return "refs/heads/" + m.SourceBranch, nil
+	case *gitea.PullRequest:
+		if m.Head == nil {
+			return "", errors.New("gitea pull request has no head branch")
+		}
+		return "refs/heads/" + m.Head.Ref, nil
+	case *bitbucketcloud.PullRequest:
+		return "refs/heads/" + m.Source.Branch.Name, nil
 	default:
 		return "", errors.New("unknown changeset type")
 	}
@@ -689,6 +766,13 @@ func (c *Changeset) BaseRefOid() (string, error) {
 		return "", nil
 	case *gitlab.MergeRequest:
 		return m.DiffRefs.BaseSHA, nil
+	case *gitea.PullRequest:
+		if m.Base == nil {
+			return "", nil
+		}
+		return m.Base.SHA, nil
+	case *bitbucketcloud.PullRequest:
+		return m.Destination.Commit.Hash, nil
 	default:
 		return "", errors.New("unknown changeset type")
 	}
@@ -704,6 +788,13 @@ func (c *Changeset) BaseRef() (string, error) {
 		return m.ToRef.ID, nil
 	case *gitlab.MergeRequest:
 		return "refs/heads/" + m.TargetBranch, nil
+	case *gitea.PullRequest:
+		if m.Base == nil {
+			return "", errors.New("gitea pull request has no base branch")
+		}
+		return "refs/heads/" + m.Base.Ref, nil
+	case *bitbucketcloud.PullRequest:
+		return "refs/heads/" + m.Destination.Branch.Name, nil
 	default:
 		return "", errors.New("unknown changeset type")
 	}
@@ -811,6 +902,16 @@ func (c *Changeset) Labels() []ChangesetLabel {
 			labels[i] = ChangesetLabel{Name: l, Color: "000000"}
 		}
 		return labels
+	case *gitea.PullRequest:
+		labels := make([]ChangesetLabel, len(m.Labels))
+		for i, l := range m.Labels {
+			labels[i] = ChangesetLabel{
+				Name:        l.Name,
+				Color:       l.Color,
+				Description: l.Description,
+			}
+		}
+		return labels
 	default:
 		return []ChangesetLabel{}
 	}
@@ -981,6 +1082,10 @@ func ChangesetEventKindFor(e interface{}) (ChangesetEventKind, error) {
 		return ChangesetEventKindGitLabReopened, nil
 	case *gitlab.MergeRequestMergedEvent:
 		return ChangesetEventKindGitLabMerged, nil
+	case *bitbucketcloud.PullRequestActivity:
+		return ChangesetEventKind("bitbucketcloud:pullrequest:" + string(e.Action)), nil
+	case *bitbucketcloud.CommitStatus:
+		return ChangesetEventKindBitbucketCloudCommitStatus, nil
 	}
 
 	return ChangesetEventKindInvalid, errors.Errorf("unknown changeset event kind for %T", e)
@@ -1061,6 +1166,13 @@ func NewChangesetEventMetadata(k ChangesetEventKind) (interface{}, error) {
 		case ChangesetEventKindGitLabReopened:
 			return new(gitlab.MergeRequestReopenedEvent), nil
 		}
+	case strings.HasPrefix(string(k), "bitbucketcloud"):
+		switch k {
+		case ChangesetEventKindBitbucketCloudCommitStatus:
+			return new(bitbucketcloud.CommitStatus), nil
+		default:
+			return new(bitbucketcloud.PullRequestActivity), nil
+		}
 	}
 	return nil, errors.Errorf("unknown changeset event kind %q", k)
 }
@@ -9,6 +9,7 @@ import (
 	"github.com/cockroachdb/errors"
 	"github.com/inconshreveable/log15"
 
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/bitbucketcloud"
 	"github.com/sourcegraph/sourcegraph/internal/extsvc/bitbucketserver"
 	"github.com/sourcegraph/sourcegraph/internal/extsvc/github"
 	"github.com/sourcegraph/sourcegraph/internal/extsvc/gitlab"
@@ -78,6 +79,14 @@ const (
 	ChangesetEventKindGitLabMarkWorkInProgress   ChangesetEventKind = "gitlab:mark_wip"
 	ChangesetEventKindGitLabUnmarkWorkInProgress ChangesetEventKind = "gitlab:unmark_wip"
 
+	ChangesetEventKindBitbucketCloudApproved              ChangesetEventKind = "bitbucketcloud:pullrequest:approved"
+	ChangesetEventKindBitbucketCloudUnapproved            ChangesetEventKind = "bitbucketcloud:pullrequest:unapproved"
+	ChangesetEventKindBitbucketCloudChangesRequestCreated ChangesetEventKind = "bitbucketcloud:pullrequest:changes_request_created"
+	ChangesetEventKindBitbucketCloudChangesRequestRemoved ChangesetEventKind = "bitbucketcloud:pullrequest:changes_request_removed"
+	ChangesetEventKindBitbucketCloudFulfilled             ChangesetEventKind = "bitbucketcloud:pullrequest:fulfilled"
+	ChangesetEventKindBitbucketCloudRejected              ChangesetEventKind = "bitbucketcloud:pullrequest:rejected"
+	ChangesetEventKindBitbucketCloudCommitStatus          ChangesetEventKind = "bitbucketcloud:commit_status"
+
 	ChangesetEventKindInvalid ChangesetEventKind = "invalid"
 )
 
@@ -121,6 +130,9 @@ func (e *ChangesetEvent) ReviewAuthor() string {
 	case *gitlab.ReviewUnapprovedEvent:
 		return meta.Author.Username
 
+	case *bitbucketcloud.PullRequestActivity:
+		return meta.User.UUID
+
 	default:
 		return ""
 	}
@@ -130,9 +142,13 @@ func (e *ChangesetEvent) ReviewAuthor() string {
 func (e *ChangesetEvent) ReviewState() (ChangesetReviewState, error) {
 	switch e.Kind {
 	case ChangesetEventKindBitbucketServerApproved,
-		ChangesetEventKindGitLabApproved:
+		ChangesetEventKindGitLabApproved,
+		ChangesetEventKindBitbucketCloudApproved:
 		return ChangesetReviewStateApproved, nil
 
+	case ChangesetEventKindBitbucketCloudChangesRequestCreated:
+		return ChangesetReviewStateChangesRequested, nil
+
 	// BitbucketServer's "REVIEWED" activity is created when someone clicks
 	// the "Needs work" button in the UI, which is why we map it to "Changes Requested"
 	case ChangesetEventKindBitbucketServerReviewed:
@@ -155,7 +171,9 @@ func (e *ChangesetEvent) ReviewState() (ChangesetReviewState, error) {
 	case ChangesetEventKindGitHubReviewDismissed,
 		ChangesetEventKindBitbucketServerUnapproved,
 		ChangesetEventKindBitbucketServerDismissed,
-		ChangesetEventKindGitLabUnapproved:
+		ChangesetEventKindGitLabUnapproved,
+		ChangesetEventKindBitbucketCloudUnapproved,
+		ChangesetEventKindBitbucketCloudChangesRequestRemoved:
 		return ChangesetReviewStateDismissed, nil
 
 	default:
@@ -240,6 +258,10 @@ func (e *ChangesetEvent) Timestamp() time.Time {
 		// fall back to the event record we created when we received the
 		// webhook.
 		t = e.CreatedAt
+	case *bitbucketcloud.PullRequestActivity:
+		t = ev.Date
+	case *bitbucketcloud.CommitStatus:
+		t = ev.Status.UpdatedOn
 	}
 
 	return t
@@ -602,6 +624,16 @@ func (e *ChangesetEvent) Update(o *ChangesetEvent) error {
 		// We always get the full event, so safe to replace it
 		*e = *o
 
+	case *bitbucketcloud.PullRequestActivity:
+		o := o.Metadata.(*bitbucketcloud.PullRequestActivity)
+		// We always get the full event, so safe to replace it
+		*e = *o
+
+	case *bitbucketcloud.CommitStatus:
+		o := o.Metadata.(*bitbucketcloud.CommitStatus)
+		// We always get the full event, so safe to replace it
+		*e = *o
+
 	case *github.CheckRun:
 		o := o.Metadata.(*github.CheckRun)
 		if e.Status == "" {
@@ -67,17 +67,6 @@ func (s *Store) DeleteActionsInt64(ctx context.Context, actionIDs []int64, monit
 	return nil
 }
 
-const totalCountActionEmailsFmtStr = `
-SELECT COUNT(*)
-FROM cm_emails
-WHERE monitor = %s;
-`
-
-func (s *Store) TotalCountActionEmails(ctx context.Context, monitorID int64) (count int32, err error) {
-	err = s.QueryRow(ctx, sqlf.Sprintf(totalCountActionEmailsFmtStr, monitorID)).Scan(&count)
-	return count, err
-}
-
 const actionEmailByIDFmtStr = `
 SELECT id, monitor, enabled, priority, header, created_by, created_at, changed_by, changed_at
 FROM cm_emails
@@ -104,6 +93,23 @@ func (s *Store) runEmailQuery(ctx context.Context, q *sqlf.Query) (*MonitorEmail
 	return es[0], nil
 }
 
+const listActionEmailsFmtStr = `
+SELECT id, monitor, enabled, priority, header, created_by, created_at, changed_by, changed_at
+FROM cm_emails
+WHERE monitor = %s
+ORDER BY id ASC
+`
+
+// ListActionEmails returns all email actions of a monitor.
+func (s *Store) ListActionEmails(ctx context.Context, monitorID int64) ([]*MonitorEmail, error) {
+	rows, err := s.Query(ctx, sqlf.Sprintf(listActionEmailsFmtStr, monitorID))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return ScanEmails(rows)
+}
+
 const updateActionEmailFmtStr = `
 UPDATE cm_emails
 SET enabled = %s,
@@ -14,8 +14,14 @@ import (
 )
 
 type ActionJob struct {
-	Id           int
-	Email        int64
+	Id int
+
+	// Exactly one of Email, Webhook and SlackWebhook is set. It is the ID of
+	// the action to run.
+	Email        *int64
+	Webhook      *int64
+	SlackWebhook *int64
+
 	TriggerEvent int
 
 	// Fields demanded by any dbworker.
@@ -40,11 +46,17 @@ type ActionJobMetadata struct {
 
 	// The query with after: filter.
 	Query string
+
+	// Results are the matched commits to include in the notification. They
+	// are capped at codeMonitors.maxResultsPerNotification.
+	Results []*CommitResult
 }
 
 var ActionJobsColumns = []*sqlf.Query{
 	sqlf.Sprintf("cm_action_jobs.id"),
 	sqlf.Sprintf("cm_action_jobs.email"),
+	sqlf.Sprintf("cm_action_jobs.webhook"),
+	sqlf.Sprintf("cm_action_jobs.slack_webhook"),
 	sqlf.Sprintf("cm_action_jobs.trigger_event"),
 	sqlf.Sprintf("cm_action_jobs.state"),
 	sqlf.Sprintf("cm_action_jobs.failure_message"),
@@ -56,8 +68,8 @@ var ActionJobsColumns = []*sqlf.Query{
 	sqlf.Sprintf("cm_action_jobs.log_contents"),
 }
 
-const readActionEmailEventsFmtStr = `
-SELECT id, email, trigger_event, state, failure_message, started_at, finished_at, process_after, num_resets, num_failures, log_contents
+const readActionEventsFmtStr = `
+SELECT id, email, webhook, slack_webhook, trigger_event, state, failure_message, started_at, finished_at, process_after, num_resets, num_failures, log_contents
 FROM cm_action_jobs
 WHERE %s
 AND id > %s
@@ -66,18 +78,27 @@ LIMIT %s;
 `
 
 func (s *Store) ReadActionEmailEvents(ctx context.Context, emailID int64, triggerEventID *int, args *graphqlbackend.ListEventsArgs) (js []*ActionJob, err error) {
-	var where *sqlf.Query
-	if triggerEventID == nil {
-		where = sqlf.Sprintf("email = %s", emailID)
-	} else {
-		where = sqlf.Sprintf("email = %s AND trigger_event = %s", emailID, *triggerEventID)
+	return s.readActionEvents(ctx, sqlf.Sprintf("email = %s", emailID), triggerEventID, args)
+}
+
+func (s *Store) ReadActionWebhookEvents(ctx context.Context, webhookID int64, triggerEventID *int, args *graphqlbackend.ListEventsArgs) (js []*ActionJob, err error) {
+	return s.readActionEvents(ctx, sqlf.Sprintf("webhook = %s", webhookID), triggerEventID, args)
+}
+
+func (s *Store) ReadActionSlackWebhookEvents(ctx context.Context, slackWebhookID int64, triggerEventID *int, args *graphqlbackend.ListEventsArgs) (js []*ActionJob, err error) {
+	return s.readActionEvents(ctx, sqlf.Sprintf("slack_webhook = %s", slackWebhookID), triggerEventID, args)
+}
+
+func (s *Store) readActionEvents(ctx context.Context, where *sqlf.Query, triggerEventID *int, args *graphqlbackend.ListEventsArgs) (js []*ActionJob, err error) {
+	if triggerEventID != nil {
+		where = sqlf.Sprintf("%s AND trigger_event = %s", where, *triggerEventID)
 	}
 	var rows *sql.Rows
 	after, err := unmarshalAfter(args.After)
 	if err != nil {
 		return nil, err
 	}
-	rows, err = s.Query(ctx, sqlf.Sprintf(readActionEmailEventsFmtStr, where, after, args.First))
+	rows, err = s.Query(ctx, sqlf.Sprintf(readActionEventsFmtStr, where, after, args.First))
 	if err != nil {
 		return nil, err
 	}
@@ -85,20 +106,29 @@ func (s *Store) ReadActionEmailEvents(ctx context.Context, emailID int64, trigge
 	return scanActionJobs(rows, err)
 }
 
-const totalActionEmailEventsFmtStr = `
+const totalActionEventsFmtStr = `
 SELECT COUNT(*)
 FROM cm_action_jobs
 WHERE %s
 `
 
 func (s *Store) TotalActionEmailEvents(ctx context.Context, emailID int64, triggerEventID *int) (totalCount int32, err error) {
-	var where *sqlf.Query
-	if triggerEventID == nil {
-		where = sqlf.Sprintf("email = %s", emailID)
-	} else {
-		where = sqlf.Sprintf("email = %s AND trigger_event = %s", emailID, *triggerEventID)
+	return s.totalActionEvents(ctx, sqlf.Sprintf("email = %s", emailID), triggerEventID)
+}
+
+func (s *Store) TotalActionWebhookEvents(ctx context.Context, webhookID int64, triggerEventID *int) (totalCount int32, err error) {
+	return s.totalActionEvents(ctx, sqlf.Sprintf("webhook = %s", webhookID), triggerEventID)
+}
+
+func (s *Store) TotalActionSlackWebhookEvents(ctx context.Context, slackWebhookID int64, triggerEventID *int) (totalCount int32, err error) {
+	return s.totalActionEvents(ctx, sqlf.Sprintf("slack_webhook = %s", slackWebhookID), triggerEventID)
+}
+
+func (s *Store) totalActionEvents(ctx context.Context, where *sqlf.Query, triggerEventID *int) (totalCount int32, err error) {
+	if triggerEventID != nil {
+		where = sqlf.Sprintf("%s AND trigger_event = %s", where, *triggerEventID)
 	}
-	err = s.QueryRow(ctx, sqlf.Sprintf(totalActionEmailEventsFmtStr, where)).Scan(&totalCount)
+	err = s.QueryRow(ctx, sqlf.Sprintf(totalActionEventsFmtStr, where)).Scan(&totalCount)
 	if err != nil {
 		return -1, err
 	}
@@ -124,8 +154,61 @@ func (s *Store) EnqueueActionEmailsForQueryIDInt64(ctx context.Context, queryID
 	return s.Store.Exec(ctx, sqlf.Sprintf(enqueueActionEmailFmtStr, queryID, triggerEventID, triggerEventID))
 }
 
+const enqueueActionWebhookFmtStr = `
+WITH due AS (
+	SELECT w.id
+	FROM cm_webhooks w INNER JOIN cm_queries q ON w.monitor = q.monitor
+	WHERE q.id = %s AND w.enabled = true
+),
+busy AS (
+    SELECT DISTINCT webhook as id FROM cm_action_jobs
+    WHERE state = 'queued'
+    OR state = 'processing'
+)
+INSERT INTO cm_action_jobs (webhook, trigger_event)
+SELECT id, %s::integer from due EXCEPT SELECT id, %s::integer from busy ORDER BY id
+`
+
+func (s *Store) EnqueueActionWebhooksForQueryIDInt64(ctx context.Context, queryID int64, triggerEventID int) (err error) {
+	return s.Store.Exec(ctx, sqlf.Sprintf(enqueueActionWebhookFmtStr, queryID, triggerEventID, triggerEventID))
+}
+
+const enqueueActionSlackWebhookFmtStr = `
+WITH due AS (
+	SELECT w.id
+	FROM cm_slack_webhooks w INNER JOIN cm_queries q ON w.monitor = q.monitor
+	WHERE q.id = %s AND w.enabled = true
+),
+busy AS (
+    SELECT DISTINCT slack_webhook as id FROM cm_action_jobs
+    WHERE state = 'queued'
+    OR state = 'processing'
+)
+INSERT INTO cm_action_jobs (slack_webhook, trigger_event)
+SELECT id, %s::integer from due EXCEPT SELECT id, %s::integer from busy ORDER BY id
+`
+
+func (s *Store) EnqueueActionSlackWebhooksForQueryIDInt64(ctx context.Context, queryID int64, triggerEventID int) (err error) {
+	return s.Store.Exec(ctx, sqlf.Sprintf(enqueueActionSlackWebhookFmtStr, queryID, triggerEventID, triggerEventID))
+}
+
+// EnqueueActionJobsForQueryIDInt64 enqueues a job for every enabled action of
+// the monitor of the given query.
+func (s *Store) EnqueueActionJobsForQueryIDInt64(ctx context.Context, queryID int64, triggerEventID int) (err error) {
+	if err = s.EnqueueActionEmailsForQueryIDInt64(ctx, queryID, triggerEventID); err != nil {
+		return errors.Errorf("store.EnqueueActionEmailsForQueryIDInt64: %w", err)
+	}
+	if err = s.EnqueueActionWebhooksForQueryIDInt64(ctx, queryID, triggerEventID); err != nil {
+		return errors.Errorf("store.EnqueueActionWebhooksForQueryIDInt64: %w", err)
+	}
+	if err = s.EnqueueActionSlackWebhooksForQueryIDInt64(ctx, queryID, triggerEventID); err != nil {
+		return errors.Errorf("store.EnqueueActionSlackWebhooksForQueryIDInt64: %w", err)
+	}
+	return nil
+}
+
 const getActionJobMetadataFmtStr = `
-select cm.description, ctj.query_string, cm.id as monitorID, ctj.num_results from
+select cm.description, ctj.query_string, cm.id as monitorID, ctj.num_results, ctj.search_results, ctj.encryption_key_id from
 cm_action_jobs caj
 inner join cm_trigger_jobs ctj on caj.trigger_event = ctj.id
 inner join cm_queries cq on cq.id = ctj.query
@@ -136,7 +219,15 @@ where caj.id = %s
 func (s *Store) GetActionJobMetadata(ctx context.Context, recordID int) (m *ActionJobMetadata, err error) {
 	row := s.Store.QueryRow(ctx, sqlf.Sprintf(getActionJobMetadataFmtStr, recordID))
 	m = &ActionJobMetadata{}
-	err = row.Scan(&m.Description, &m.Query, &m.MonitorID, &m.NumResults)
+	var (
+		results []byte
+		keyID   string
+	)
+	err = row.Scan(&m.Description, &m.Query, &m.MonitorID, &m.NumResults, &results, &keyID)
+	if err != nil {
+		return nil, err
+	}
+	m.Results, err = unmarshalCommitResults(ctx, results, keyID)
 	if err != nil {
 		return nil, err
 	}
@@ -144,7 +235,7 @@ func (s *Store) GetActionJobMetadata(ctx context.Context, recordID int) (m *Acti
 }
 
 const actionJobForIDFmtStr = `
-SELECT id, email, trigger_event, state, failure_message, started_at, finished_at, process_after, num_resets, num_failures, log_contents
+SELECT id, email, webhook, slack_webhook, trigger_event, state, failure_message, started_at, finished_at, process_after, num_resets, num_failures, log_contents
 FROM cm_action_jobs
 WHERE id = %s
 `
@@ -190,6 +281,8 @@ func scanActionJobs(rows *sql.Rows, err error) ([]*ActionJob, error) {
 		if err := rows.Scan(
 			&aj.Id,
 			&aj.Email,
+			&aj.Webhook,
+			&aj.SlackWebhook,
 			&aj.TriggerEvent,
 			&aj.State,
 			&aj.FailureMessage,
@@ -35,9 +35,10 @@ func TestEnqueueActionEmailsForQueryIDInt64QueryByRecordID(t *testing.T) {
 		t.Fatal(err)
 	}
 
+	wantEmailID := int64(1)
 	want := &ActionJob{
 		Id:             1,
-		Email:          1,
+		Email:          &wantEmailID,
 		TriggerEvent:   1,
 		State:          "queued",
 		FailureMessage: nil,
@@ -73,8 +74,19 @@ func TestGetActionJobMetadata(t *testing.T) {
 		wantNumResults       = 42
 		wantQuery            = testQuery + " after:\"" + s.Now().UTC().Format(time.RFC3339) + "\""
 		wantMonitorID  int64 = 1
+		wantResults          = []*CommitResult{{
+			Repo:    "github.com/sourcegraph/sourcegraph",
+			Commit:  "deadbeef",
+			Author:  "Alice",
+			Date:    time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC),
+			Message: "Add secret",
+			DiffPreview: &HighlightedString{
+				Value:      "+secret = 42\n",
+				Highlights: []HighlightedRange{{Line: 0, Character: 1, Length: 6}},
+			},
+		}}
 	)
-	err = s.LogSearch(ctx, wantQuery, wantNumResults, 1)
+	err = s.LogSearch(ctx, wantQuery, wantNumResults, wantResults, 1)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -92,6 +104,7 @@ func TestGetActionJobMetadata(t *testing.T) {
 		Query:       wantQuery,
 		NumResults:  &wantNumResults,
 		MonitorID:   wantMonitorID,
+		Results:     wantResults,
 	}
 	if diff := cmp.Diff(got, want); diff != "" {
 		t.Fatalf("diff: %s", diff)
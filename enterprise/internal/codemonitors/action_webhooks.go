@@ -0,0 +1,320 @@
+package codemonitors
+
+import (
+	"context"
+	"database/sql"
+	"net"
+	"net/url"
+	"time"
+
+	"github.com/cockroachdb/errors"
+	"github.com/graph-gophers/graphql-go"
+	"github.com/graph-gophers/graphql-go/relay"
+	"github.com/keegancsmith/sqlf"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/graphqlbackend"
+	"github.com/sourcegraph/sourcegraph/internal/actor"
+	"github.com/sourcegraph/sourcegraph/internal/database"
+	"github.com/sourcegraph/sourcegraph/internal/encryption"
+	"github.com/sourcegraph/sourcegraph/internal/encryption/keyring"
+)
+
+// MonitorWebhook is a webhook or a Slack webhook action. Both kinds of
+// actions have the same fields, and only differ in the payload the
+// background worker posts to URL.
+type MonitorWebhook struct {
+	Id        int64
+	Monitor   int64
+	Enabled   bool
+	URL       string
+	CreatedBy int32
+	CreatedAt time.Time
+	ChangedBy int32
+	ChangedAt time.Time
+}
+
+// webhookTable is the table of a kind of webhook action.
+type webhookTable string
+
+const (
+	webhooksTable      webhookTable = "cm_webhooks"
+	slackWebhooksTable webhookTable = "cm_slack_webhooks"
+)
+
+func (t webhookTable) columns() []*sqlf.Query {
+	names := []string{"id", "monitor", "enabled", "url", "encryption_key_id", "created_by", "created_at", "changed_by", "changed_at"}
+	columns := make([]*sqlf.Query, 0, len(names))
+	for _, name := range names {
+		columns = append(columns, sqlf.Sprintf(string(t)+"."+name))
+	}
+	return columns
+}
+
+func (s *Store) CreateActionWebhook(ctx context.Context, monitorID int64, args *graphqlbackend.CreateActionWebhookArgs) (*MonitorWebhook, error) {
+	return s.createWebhook(ctx, webhooksTable, monitorID, args.Enabled, args.URL)
+}
+
+func (s *Store) CreateActionSlackWebhook(ctx context.Context, monitorID int64, args *graphqlbackend.CreateActionSlackWebhookArgs) (*MonitorWebhook, error) {
+	return s.createWebhook(ctx, slackWebhooksTable, monitorID, args.Enabled, args.URL)
+}
+
+const createActionWebhookFmtStr = `
+INSERT INTO %s
+(monitor, enabled, url, encryption_key_id, created_by, created_at, changed_by, changed_at)
+VALUES (%s,%s,%s,%s,%s,%s,%s,%s)
+RETURNING %s;
+`
+
+func (s *Store) createWebhook(ctx context.Context, t webhookTable, monitorID int64, enabled bool, webhookURL string) (*MonitorWebhook, error) {
+	if err := ValidateWebhookURL(webhookURL); err != nil {
+		return nil, err
+	}
+	encrypted, keyID, err := database.MaybeEncrypt(ctx, webhookKey(), webhookURL)
+	if err != nil {
+		return nil, err
+	}
+	now := s.Now()
+	a := actor.FromContext(ctx)
+	q := sqlf.Sprintf(
+		createActionWebhookFmtStr,
+		sqlf.Sprintf(string(t)),
+		monitorID,
+		enabled,
+		encrypted,
+		keyID,
+		a.UID,
+		now,
+		a.UID,
+		now,
+		sqlf.Join(t.columns(), ", "),
+	)
+	return s.runWebhookQuery(ctx, q)
+}
+
+func (s *Store) UpdateActionWebhook(ctx context.Context, monitorID int64, args *graphqlbackend.EditActionWebhookArgs) (*MonitorWebhook, error) {
+	return s.updateWebhook(ctx, webhooksTable, monitorID, args.Id, args.Update.Enabled, args.Update.URL)
+}
+
+func (s *Store) UpdateActionSlackWebhook(ctx context.Context, monitorID int64, args *graphqlbackend.EditActionSlackWebhookArgs) (*MonitorWebhook, error) {
+	return s.updateWebhook(ctx, slackWebhooksTable, monitorID, args.Id, args.Update.Enabled, args.Update.URL)
+}
+
+const updateActionWebhookFmtStr = `
+UPDATE %s
+SET enabled = %s,
+	url = %s,
+	encryption_key_id = %s,
+	changed_by = %s,
+	changed_at = %s
+WHERE id = %s
+AND monitor = %s
+RETURNING %s;
+`
+
+func (s *Store) updateWebhook(ctx context.Context, t webhookTable, monitorID int64, id *graphql.ID, enabled bool, webhookURL string) (*MonitorWebhook, error) {
+	var actionID int64
+	if id == nil {
+		return nil, errors.Errorf("nil is not a valid action ID")
+	}
+	err := relay.UnmarshalSpec(*id, &actionID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Clients only ever see redacted URLs, so they send them back unchanged
+	// when they edit other fields of the action.
+	current, err := s.webhookByID(ctx, t, actionID)
+	if err != nil {
+		return nil, err
+	}
+	if webhookURL == RedactedWebhookURL(current.URL) {
+		webhookURL = current.URL
+	}
+	if err := ValidateWebhookURL(webhookURL); err != nil {
+		return nil, err
+	}
+
+	encrypted, keyID, err := database.MaybeEncrypt(ctx, webhookKey(), webhookURL)
+	if err != nil {
+		return nil, err
+	}
+	now := s.Now()
+	a := actor.FromContext(ctx)
+	q := sqlf.Sprintf(
+		updateActionWebhookFmtStr,
+		sqlf.Sprintf(string(t)),
+		enabled,
+		encrypted,
+		keyID,
+		a.UID,
+		now,
+		actionID,
+		monitorID,
+		sqlf.Join(t.columns(), ", "),
+	)
+	return s.runWebhookQuery(ctx, q)
+}
+
+func (s *Store) DeleteActionWebhooks(ctx context.Context, actionIDs []int64, monitorID int64) error {
+	return s.deleteWebhooks(ctx, webhooksTable, actionIDs, monitorID)
+}
+
+func (s *Store) DeleteActionSlackWebhooks(ctx context.Context, actionIDs []int64, monitorID int64) error {
+	return s.deleteWebhooks(ctx, slackWebhooksTable, actionIDs, monitorID)
+}
+
+const deleteActionWebhookFmtStr = `DELETE FROM %s WHERE id in (%s) AND monitor = %s`
+
+func (s *Store) deleteWebhooks(ctx context.Context, t webhookTable, actionIDs []int64, monitorID int64) error {
+	if len(actionIDs) == 0 {
+		return nil
+	}
+	deleteIDs := make([]*sqlf.Query, 0, len(actionIDs))
+	for _, id := range actionIDs {
+		deleteIDs = append(deleteIDs, sqlf.Sprintf("%s", id))
+	}
+	return s.Exec(ctx, sqlf.Sprintf(deleteActionWebhookFmtStr, sqlf.Sprintf(string(t)), sqlf.Join(deleteIDs, ", "), monitorID))
+}
+
+func (s *Store) ActionWebhookByIDInt64(ctx context.Context, webhookID int64) (*MonitorWebhook, error) {
+	return s.webhookByID(ctx, webhooksTable, webhookID)
+}
+
+func (s *Store) ActionSlackWebhookByIDInt64(ctx context.Context, slackWebhookID int64) (*MonitorWebhook, error) {
+	return s.webhookByID(ctx, slackWebhooksTable, slackWebhookID)
+}
+
+const actionWebhookByIDFmtStr = `
+SELECT %s
+FROM %s
+WHERE id = %s
+`
+
+func (s *Store) webhookByID(ctx context.Context, t webhookTable, id int64) (*MonitorWebhook, error) {
+	return s.runWebhookQuery(ctx, sqlf.Sprintf(actionWebhookByIDFmtStr, sqlf.Join(t.columns(), ", "), sqlf.Sprintf(string(t)), id))
+}
+
+// ListActionWebhooks returns all webhook actions of a monitor.
+func (s *Store) ListActionWebhooks(ctx context.Context, monitorID int64) ([]*MonitorWebhook, error) {
+	return s.listWebhooks(ctx, webhooksTable, monitorID)
+}
+
+// ListActionSlackWebhooks returns all Slack webhook actions of a monitor.
+func (s *Store) ListActionSlackWebhooks(ctx context.Context, monitorID int64) ([]*MonitorWebhook, error) {
+	return s.listWebhooks(ctx, slackWebhooksTable, monitorID)
+}
+
+const listActionWebhooksFmtStr = `
+SELECT %s
+FROM %s
+WHERE monitor = %s
+ORDER BY id ASC
+`
+
+func (s *Store) listWebhooks(ctx context.Context, t webhookTable, monitorID int64) ([]*MonitorWebhook, error) {
+	rows, err := s.Query(ctx, sqlf.Sprintf(listActionWebhooksFmtStr, sqlf.Join(t.columns(), ", "), sqlf.Sprintf(string(t)), monitorID))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return scanWebhooks(ctx, rows)
+}
+
+func (s *Store) runWebhookQuery(ctx context.Context, q *sqlf.Query) (*MonitorWebhook, error) {
+	rows, err := s.Query(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	ws, err := scanWebhooks(ctx, rows)
+	if err != nil {
+		return nil, err
+	}
+	if len(ws) == 0 {
+		return nil, errors.Errorf("operation failed. Query should have returned 1 row")
+	}
+	return ws[0], nil
+}
+
+func scanWebhooks(ctx context.Context, rows *sql.Rows) (ws []*MonitorWebhook, err error) {
+	for rows.Next() {
+		w := &MonitorWebhook{}
+		var keyID string
+		if err = rows.Scan(
+			&w.Id,
+			&w.Monitor,
+			&w.Enabled,
+			&w.URL,
+			&keyID,
+			&w.CreatedBy,
+			&w.CreatedAt,
+			&w.ChangedBy,
+			&w.ChangedAt,
+		); err != nil {
+			return nil, err
+		}
+		if w.URL, err = database.MaybeDecrypt(ctx, webhookKey(), w.URL, keyID); err != nil {
+			return nil, err
+		}
+		ws = append(ws, w)
+	}
+	err = rows.Close()
+	if err != nil {
+		return nil, err
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return ws, nil
+}
+
+// webhookKey returns the key that encrypts the URLs of webhook actions, or nil
+// if encryption is not configured.
+func webhookKey() encryption.Key {
+	return keyring.Default().CodeMonitorWebhookKey
+}
+
+// ValidateWebhookURL returns an error if webhookURL is not an http or https
+// URL, or if its host is an IP address that IsWebhookIPAllowed denies. Host
+// names are checked when the webhook is sent, once they are resolved.
+func ValidateWebhookURL(webhookURL string) error {
+	u, err := url.Parse(webhookURL)
+	if err != nil {
+		return errors.Wrap(err, "invalid webhook URL")
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return errors.Errorf("webhook URL must be an http or https URL, got scheme %q", u.Scheme)
+	}
+	if u.Hostname() == "" {
+		return errors.New("webhook URL has no host")
+	}
+	if ip := net.ParseIP(u.Hostname()); ip != nil && !IsWebhookIPAllowed(ip) {
+		return errors.Errorf("webhook URL must not point to a loopback, private or link-local address, got %s", ip)
+	}
+	return nil
+}
+
+// IsWebhookIPAllowed reports whether webhooks may be sent to ip. Webhook URLs
+// are set by users, so we don't send webhooks to the addresses of the internal
+// network, which would let users reach internal services or the metadata
+// endpoints of cloud providers.
+func IsWebhookIPAllowed(ip net.IP) bool {
+	return !(ip.IsLoopback() ||
+		ip.IsPrivate() ||
+		ip.IsLinkLocalUnicast() ||
+		ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() ||
+		ip.IsMulticast() ||
+		ip.IsUnspecified())
+}
+
+// RedactedWebhookURL returns webhookURL with its path and query replaced, so
+// that it can be shown to users. The path and query of webhook URLs usually
+// contain a secret token, for example that of a Slack incoming webhook.
+func RedactedWebhookURL(webhookURL string) string {
+	u, err := url.Parse(webhookURL)
+	if err != nil || u.Host == "" {
+		return "REDACTED"
+	}
+	return u.Scheme + "://" + u.Host + "/REDACTED"
+}
@@ -3,19 +3,50 @@ package codemonitors
 import (
 	"context"
 
+	"github.com/cockroachdb/errors"
+
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/graphqlbackend"
 )
 
 func (s *Store) CreateActions(ctx context.Context, args []*graphqlbackend.CreateActionArgs, monitorID int64) (err error) {
 	for _, a := range args {
-		e, err := s.CreateActionEmail(ctx, monitorID, a)
-		if err != nil {
-			return err
+		if numActionTypes(a) != 1 {
+			return errors.New("action must specify exactly one of email, webhook or slackWebhook")
 		}
-		err = s.CreateRecipients(ctx, a.Email.Recipients, e.Id)
-		if err != nil {
-			return err
+		switch {
+		case a.Email != nil:
+			e, err := s.CreateActionEmail(ctx, monitorID, a)
+			if err != nil {
+				return err
+			}
+			err = s.CreateRecipients(ctx, a.Email.Recipients, e.Id)
+			if err != nil {
+				return err
+			}
+		case a.Webhook != nil:
+			_, err = s.CreateActionWebhook(ctx, monitorID, a.Webhook)
+			if err != nil {
+				return err
+			}
+		case a.SlackWebhook != nil:
+			_, err = s.CreateActionSlackWebhook(ctx, monitorID, a.SlackWebhook)
+			if err != nil {
+				return err
+			}
 		}
 	}
 	return err
 }
+
+func numActionTypes(a *graphqlbackend.CreateActionArgs) (n int) {
+	if a.Email != nil {
+		n++
+	}
+	if a.Webhook != nil {
+		n++
+	}
+	if a.SlackWebhook != nil {
+		n++
+	}
+	return n
+}
@@ -10,6 +10,7 @@ import (
 	"runtime"
 	"time"
 
+	cm "github.com/sourcegraph/sourcegraph/enterprise/internal/codemonitors"
 	"github.com/sourcegraph/sourcegraph/internal/api"
 	"github.com/sourcegraph/sourcegraph/internal/httpcli"
 
@@ -201,3 +202,58 @@ func extractTime(result interface{}) (t *time.Time, err error) {
 		return nil, errors.Errorf("unexpected result __typename %q", typeName)
 	}
 }
+
+// gqlCommitSearchResult is the part of a CommitSearchResult returned by
+// gqlSearchQuery which we include in notifications.
+type gqlCommitSearchResult struct {
+	Typename       string `json:"__typename"`
+	MessagePreview *cm.HighlightedString
+	DiffPreview    *cm.HighlightedString
+	Commit         struct {
+		Repository struct {
+			Name string
+		}
+		Oid    string
+		Author struct {
+			Person struct {
+				DisplayName string
+			}
+			Date time.Time
+		}
+		Message string
+	}
+}
+
+// extractCommitResults returns the commits in the given search results, with
+// their diff previews truncated to cm.MaxDiffPreviewLines lines.
+func extractCommitResults(results []interface{}) ([]*cm.CommitResult, error) {
+	var commits []*cm.CommitResult
+	for _, result := range results {
+		// Round-trip the result through JSON to decode it into a typed struct.
+		b, err := json.Marshal(result)
+		if err != nil {
+			return nil, err
+		}
+		var r gqlCommitSearchResult
+		if err := json.Unmarshal(b, &r); err != nil {
+			return nil, err
+		}
+		if r.Typename != "CommitSearchResult" {
+			continue
+		}
+
+		if r.DiffPreview != nil {
+			r.DiffPreview.Truncate(cm.MaxDiffPreviewLines)
+		}
+		commits = append(commits, &cm.CommitResult{
+			Repo:           r.Commit.Repository.Name,
+			Commit:         r.Commit.Oid,
+			Author:         r.Commit.Author.Person.DisplayName,
+			Date:           r.Commit.Author.Date,
+			Message:        r.Commit.Message,
+			MessagePreview: r.MessagePreview,
+			DiffPreview:    r.DiffPreview,
+		})
+	}
+	return commits, nil
+}
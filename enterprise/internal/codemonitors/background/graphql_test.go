@@ -0,0 +1,70 @@
+package background
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	cm "github.com/sourcegraph/sourcegraph/enterprise/internal/codemonitors"
+)
+
+func TestExtractCommitResults(t *testing.T) {
+	var results []interface{}
+	err := json.Unmarshal([]byte(`[
+		{
+			"__typename": "CommitSearchResult",
+			"diffPreview": {
+				"value": "a\nb\n",
+				"highlights": [{"line": 1, "character": 0, "length": 1}]
+			},
+			"commit": {
+				"repository": {"name": "github.com/sourcegraph/sourcegraph"},
+				"oid": "deadbeef",
+				"author": {"person": {"displayName": "Alice"}, "date": "2021-06-01T12:00:00Z"},
+				"message": "Add b\n\nDetails"
+			}
+		},
+		{
+			"__typename": "Repository"
+		},
+		{
+			"__typename": "CommitSearchResult",
+			"commit": {
+				"repository": {"name": "github.com/sourcegraph/zoekt"},
+				"oid": "cafebabe",
+				"author": {"person": {"displayName": "Bob"}, "date": "2021-05-01T12:00:00Z"},
+				"message": "Fix"
+			}
+		}
+	]`), &results)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := extractCommitResults(results)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []*cm.CommitResult{{
+		Repo:    "github.com/sourcegraph/sourcegraph",
+		Commit:  "deadbeef",
+		Author:  "Alice",
+		Date:    time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC),
+		Message: "Add b\n\nDetails",
+		DiffPreview: &cm.HighlightedString{
+			Value:      "a\nb\n",
+			Highlights: []cm.HighlightedRange{{Line: 1, Character: 0, Length: 1}},
+		},
+	}, {
+		Repo:    "github.com/sourcegraph/zoekt",
+		Commit:  "cafebabe",
+		Author:  "Bob",
+		Date:    time.Date(2021, 5, 1, 12, 0, 0, 0, time.UTC),
+		Message: "Fix",
+	}}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Fatalf("unexpected results (-want +got):\n%s", diff)
+	}
+}
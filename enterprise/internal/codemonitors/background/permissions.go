@@ -0,0 +1,56 @@
+package background
+
+import (
+	"context"
+
+	cm "github.com/sourcegraph/sourcegraph/enterprise/internal/codemonitors"
+	"github.com/sourcegraph/sourcegraph/internal/actor"
+	"github.com/sourcegraph/sourcegraph/internal/database"
+	"github.com/sourcegraph/sourcegraph/internal/database/dbutil"
+)
+
+// monitorOwner returns the user whose repository permissions apply to the
+// results of m: the user namespace of m, or the user who created m if it
+// belongs to an organization.
+func monitorOwner(m *cm.Monitor) int32 {
+	if m.NamespaceUserID != nil {
+		return *m.NamespaceUserID
+	}
+	return m.CreatedBy
+}
+
+// filterCommitResultsForUser returns the results in repositories that userID
+// can access.
+func filterCommitResultsForUser(ctx context.Context, db dbutil.DB, userID int32, results []*cm.CommitResult) ([]*cm.CommitResult, error) {
+	if len(results) == 0 {
+		return nil, nil
+	}
+
+	seen := make(map[string]struct{}, len(results))
+	names := make([]string, 0, len(results))
+	for _, r := range results {
+		if _, ok := seen[r.Repo]; !ok {
+			seen[r.Repo] = struct{}{}
+			names = append(names, r.Repo)
+		}
+	}
+
+	// The repository store only returns the repositories the actor of the
+	// context can access.
+	repos, err := database.Repos(db).ListRepoNames(actor.WithActor(ctx, actor.FromUser(userID)), database.ReposListOptions{Names: names})
+	if err != nil {
+		return nil, err
+	}
+	accessible := make(map[string]struct{}, len(repos))
+	for _, r := range repos {
+		accessible[string(r.Name)] = struct{}{}
+	}
+
+	filtered := results[:0]
+	for _, r := range results {
+		if _, ok := accessible[r.Repo]; ok {
+			filtered = append(filtered, r)
+		}
+	}
+	return filtered, nil
+}
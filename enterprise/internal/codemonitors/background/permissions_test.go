@@ -0,0 +1,56 @@
+package background
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	cm "github.com/sourcegraph/sourcegraph/enterprise/internal/codemonitors"
+	"github.com/sourcegraph/sourcegraph/internal/actor"
+	"github.com/sourcegraph/sourcegraph/internal/database"
+	"github.com/sourcegraph/sourcegraph/internal/types"
+)
+
+func TestFilterCommitResultsForUser(t *testing.T) {
+	const userID = 42
+	database.Mocks.Repos.ListRepoNames = func(ctx context.Context, opt database.ReposListOptions) ([]types.RepoName, error) {
+		if a := actor.FromContext(ctx); a.UID != userID {
+			t.Fatalf("expected repositories to be listed as user %d, got %d", userID, a.UID)
+		}
+		if diff := cmp.Diff([]string{"github.com/public/repo", "github.com/private/repo"}, opt.Names); diff != "" {
+			t.Fatalf("unexpected names (-want +got):\n%s", diff)
+		}
+		// The user cannot access github.com/private/repo.
+		return []types.RepoName{{ID: 1, Name: "github.com/public/repo"}}, nil
+	}
+	defer func() { database.Mocks.Repos.ListRepoNames = nil }()
+
+	results := []*cm.CommitResult{
+		{Repo: "github.com/public/repo", Commit: "a"},
+		{Repo: "github.com/private/repo", Commit: "b"},
+		{Repo: "github.com/public/repo", Commit: "c"},
+	}
+	got, err := filterCommitResultsForUser(context.Background(), nil, userID, results)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []*cm.CommitResult{
+		{Repo: "github.com/public/repo", Commit: "a"},
+		{Repo: "github.com/public/repo", Commit: "c"},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Fatalf("unexpected results (-want +got):\n%s", diff)
+	}
+}
+
+func TestMonitorOwner(t *testing.T) {
+	userID := int32(1)
+	if got := monitorOwner(&cm.Monitor{CreatedBy: 2, NamespaceUserID: &userID}); got != 1 {
+		t.Fatalf("expected the namespace user, got %d", got)
+	}
+	orgID := int32(3)
+	if got := monitorOwner(&cm.Monitor{CreatedBy: 2, NamespaceOrgID: &orgID}); got != 2 {
+		t.Fatalf("expected the creator of the monitor, got %d", got)
+	}
+}
@@ -0,0 +1,64 @@
+package background
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// slackPayload is the body of a message posted to a Slack incoming webhook.
+// See https://api.slack.com/messaging/webhooks.
+type slackPayload struct {
+	Text string `json:"text"`
+}
+
+func newSlackPayload(p *webhookPayload) *slackPayload {
+	results := "results"
+	if p.ResultCount == 1 {
+		results = "result"
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "Code monitor <%s|%s> found %d new %s for <%s|%s>.",
+		p.MonitorURL, slackEscape(p.MonitorDescription),
+		p.ResultCount, results,
+		p.ResultsURL, slackEscape(p.Query))
+
+	for _, r := range p.Results {
+		fmt.Fprintf(&b, "\n\n*%s* <%s|%s> by %s: %s",
+			slackEscape(r.Repo), r.URL, abbreviateOID(r.Commit),
+			slackEscape(r.Author), slackEscape(r.Subject()))
+
+		preview := r.DiffPreview
+		if preview == nil {
+			preview = r.MessagePreview
+		}
+		if preview == nil || preview.Value == "" {
+			continue
+		}
+		value := strings.TrimSuffix(preview.Value, "\n")
+		if preview.Truncated {
+			value += "\n..."
+		}
+		fmt.Fprintf(&b, "\n```%s```", slackEscape(value))
+	}
+	return &slackPayload{Text: b.String()}
+}
+
+func abbreviateOID(oid string) string {
+	if len(oid) > 7 {
+		return oid[:7]
+	}
+	return oid
+}
+
+// slackEscape escapes the characters Slack treats as control characters in
+// message text.
+func slackEscape(s string) string {
+	return slackEscaper.Replace(s)
+}
+
+var slackEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+func sendSlackNotification(ctx context.Context, url string, payload *slackPayload) error {
+	return postJSON(ctx, url, payload)
+}
@@ -0,0 +1,176 @@
+package background
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+
+	"github.com/cockroachdb/errors"
+	"github.com/inconshreveable/log15"
+
+	cm "github.com/sourcegraph/sourcegraph/enterprise/internal/codemonitors"
+	"github.com/sourcegraph/sourcegraph/enterprise/internal/codemonitors/email"
+	"github.com/sourcegraph/sourcegraph/internal/httpcli"
+)
+
+const (
+	utmSourceWebhook      = "code-monitoring-webhook"
+	utmSourceSlackWebhook = "code-monitoring-slack-webhook"
+)
+
+// webhookPayload is the JSON body posted to a generic webhook when a code
+// monitor finds new results.
+type webhookPayload struct {
+	MonitorDescription string `json:"monitorDescription"`
+	MonitorURL         string `json:"monitorURL"`
+	Query              string `json:"query"`
+	ResultsURL         string `json:"resultsURL"`
+	ResultCount        int    `json:"resultCount"`
+
+	// Results are the first matched commits, if the site configures
+	// codeMonitors.maxResultsPerNotification.
+	Results []*webhookCommitResult `json:"results,omitempty"`
+}
+
+type webhookCommitResult struct {
+	*cm.CommitResult
+	URL string `json:"url"`
+}
+
+func newWebhookPayload(ctx context.Context, m *cm.ActionJobMetadata, utmSource string) (*webhookPayload, error) {
+	searchURL, err := email.GetSearchURL(ctx, m.Query, utmSource)
+	if err != nil {
+		return nil, errors.Errorf("email.GetSearchURL: %w", err)
+	}
+	monitorURL, err := email.GetCodeMonitorURL(ctx, m.MonitorID, utmSource)
+	if err != nil {
+		return nil, errors.Errorf("email.GetCodeMonitorURL: %w", err)
+	}
+	var results []*webhookCommitResult
+	for _, r := range m.Results {
+		commitURL, err := email.GetCommitURL(ctx, r.Repo, r.Commit, utmSource)
+		if err != nil {
+			return nil, errors.Errorf("email.GetCommitURL: %w", err)
+		}
+		results = append(results, &webhookCommitResult{CommitResult: r, URL: commitURL})
+	}
+	return &webhookPayload{
+		MonitorDescription: m.Description,
+		MonitorURL:         monitorURL,
+		Query:              m.Query,
+		ResultsURL:         searchURL,
+		ResultCount:        zeroOrVal(m.NumResults),
+		Results:            results,
+	}, nil
+}
+
+func sendWebhookNotification(ctx context.Context, url string, payload *webhookPayload) error {
+	return postJSON(ctx, url, payload)
+}
+
+// webhookDoer sends the requests of webhook actions. It refuses to send them
+// to hosts that resolve to an address that cm.IsWebhookIPAllowed denies,
+// including when following redirects.
+var webhookDoer = newWebhookDoer()
+
+// newWebhookDoer returns the client of webhook actions. If the client can't be
+// created, every request fails with the error, so that webhooks are never sent
+// without checking their address.
+func newWebhookDoer() httpcli.Doer {
+	doer, err := httpcli.ExternalClientFactory.Doer(denyInternalRedirectsOpt)
+	if err != nil {
+		log15.Error("Failed to create code monitor webhook client", "error", err)
+		return httpcli.DoerFunc(func(*http.Request) (*http.Response, error) {
+			return nil, errors.Wrap(err, "creating webhook client")
+		})
+	}
+	return denyInternalAddresses(doer)
+}
+
+// denyInternalAddresses returns a Doer that checks the host of each request
+// before sending it with cli. The host is resolved here rather than checked
+// when connecting, because the connection goes to the HTTP proxy instead of
+// the host when one is configured.
+func denyInternalAddresses(cli httpcli.Doer) httpcli.Doer {
+	return httpcli.DoerFunc(func(req *http.Request) (*http.Response, error) {
+		if err := checkWebhookHost(req.Context(), req.URL.Hostname()); err != nil {
+			return nil, err
+		}
+		return cli.Do(req)
+	})
+}
+
+func denyInternalRedirectsOpt(cli *http.Client) error {
+	cli.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		// The default policy of http.Client.
+		if len(via) >= 10 {
+			return errors.New("stopped after 10 redirects")
+		}
+		return checkWebhookHost(req.Context(), req.URL.Hostname())
+	}
+	return nil
+}
+
+// lookupIPAddr resolves host names. It is replaced in tests.
+var lookupIPAddr = net.DefaultResolver.LookupIPAddr
+
+// checkWebhookHost returns an error if host is, or resolves to, an address
+// that cm.IsWebhookIPAllowed denies.
+func checkWebhookHost(ctx context.Context, host string) error {
+	var ips []net.IP
+	if ip := net.ParseIP(host); ip != nil {
+		ips = append(ips, ip)
+	} else {
+		addrs, err := lookupIPAddr(ctx, host)
+		if err != nil {
+			return err
+		}
+		for _, addr := range addrs {
+			ips = append(ips, addr.IP)
+		}
+	}
+
+	for _, ip := range ips {
+		if !cm.IsWebhookIPAllowed(ip) {
+			return errors.Errorf("refusing to send webhook to internal address %s", ip)
+		}
+	}
+	return nil
+}
+
+// MockPostJSON, if set, replaces the HTTP request performed by postJSON.
+var MockPostJSON func(ctx context.Context, url string, body interface{}) error
+
+// postJSON posts body encoded as JSON to url and returns an error if the
+// server does not respond with a 2xx status code.
+func postJSON(ctx context.Context, url string, body interface{}) error {
+	if MockPostJSON != nil {
+		return MockPostJSON(ctx, url, body)
+	}
+
+	b, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := webhookDoer.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return errors.Errorf("webhook responded with status %d: %s", resp.StatusCode, msg)
+	}
+	return nil
+}
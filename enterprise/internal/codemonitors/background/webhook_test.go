@@ -0,0 +1,200 @@
+package background
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	cm "github.com/sourcegraph/sourcegraph/enterprise/internal/codemonitors"
+	"github.com/sourcegraph/sourcegraph/internal/httpcli"
+)
+
+func TestPostJSON(t *testing.T) {
+	// The test servers listen on a loopback address, which webhookDoer
+	// refuses to connect to.
+	defer func(d httpcli.Doer) { webhookDoer = d }(webhookDoer)
+	webhookDoer = httpcli.ExternalDoer
+
+	payload := &webhookPayload{
+		MonitorDescription: "My monitor",
+		MonitorURL:         "https://www.sourcegraph.com/code-monitoring/Q29kZU1vbml0b3I6MQ==",
+		Query:              "test patternType:literal",
+		ResultsURL:         "https://www.sourcegraph.com/search?q=test",
+		ResultCount:        2,
+	}
+
+	t.Run("success", func(t *testing.T) {
+		var got webhookPayload
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if ct := r.Header.Get("Content-Type"); ct != "application/json" {
+				t.Errorf("unexpected Content-Type %q", ct)
+			}
+			if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+				t.Error(err)
+			}
+		}))
+		defer srv.Close()
+
+		if err := sendWebhookNotification(context.Background(), srv.URL, payload); err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff(*payload, got); diff != "" {
+			t.Fatalf("unexpected payload (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("error status", func(t *testing.T) {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "gone", http.StatusGone)
+		}))
+		defer srv.Close()
+
+		if err := sendWebhookNotification(context.Background(), srv.URL, payload); err == nil {
+			t.Fatal("expected error")
+		}
+	})
+}
+
+func TestPostJSONDeniesInternalAddresses(t *testing.T) {
+	called := false
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}))
+	defer srv.Close()
+
+	err := postJSON(context.Background(), srv.URL, &webhookPayload{})
+	if err == nil || !strings.Contains(err.Error(), "refusing to send webhook to internal address 127.0.0.1") {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if called {
+		t.Fatal("webhook was sent to a loopback address")
+	}
+}
+
+func TestDenyInternalAddresses(t *testing.T) {
+	defer func(f func(context.Context, string) ([]net.IPAddr, error)) { lookupIPAddr = f }(lookupIPAddr)
+	lookupIPAddr = func(ctx context.Context, host string) ([]net.IPAddr, error) {
+		switch host {
+		case "example.com":
+			return []net.IPAddr{{IP: net.ParseIP("93.184.216.34")}}, nil
+		case "internal.example.com":
+			return []net.IPAddr{{IP: net.ParseIP("93.184.216.34")}, {IP: net.ParseIP("10.0.0.1")}}, nil
+		}
+		return nil, &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+	}
+
+	// The requests are checked before they are sent, so that the check does
+	// not depend on where the client connects to, such as an HTTP proxy.
+	var sent []string
+	doer := denyInternalAddresses(httpcli.DoerFunc(func(req *http.Request) (*http.Response, error) {
+		sent = append(sent, req.URL.String())
+		return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody}, nil
+	}))
+
+	for _, tc := range []struct {
+		url     string
+		wantErr string
+	}{
+		{url: "https://example.com/hook"},
+		{url: "https://93.184.216.34/hook"},
+		{url: "https://internal.example.com/hook", wantErr: "refusing to send webhook to internal address 10.0.0.1"},
+		{url: "http://169.254.169.254/latest/meta-data", wantErr: "refusing to send webhook to internal address 169.254.169.254"},
+		{url: "https://missing.example.com/hook", wantErr: "no such host"},
+	} {
+		req, err := http.NewRequest("POST", tc.url, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = doer.Do(req)
+		if tc.wantErr == "" && err != nil {
+			t.Errorf("%s: unexpected error %v", tc.url, err)
+		} else if tc.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tc.wantErr)) {
+			t.Errorf("%s: got error %v, want %q", tc.url, err, tc.wantErr)
+		}
+	}
+
+	want := []string{"https://example.com/hook", "https://93.184.216.34/hook"}
+	if diff := cmp.Diff(want, sent); diff != "" {
+		t.Fatalf("unexpected requests (-want +got):\n%s", diff)
+	}
+}
+
+func TestPostJSONDeniesInternalRedirects(t *testing.T) {
+	// The test servers listen on loopback addresses, so we only check the
+	// redirects, not the first request.
+	defer func(d httpcli.Doer) { webhookDoer = d }(webhookDoer)
+	cli, err := httpcli.ExternalClientFactory.Doer(denyInternalRedirectsOpt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	webhookDoer = cli
+
+	called := false
+	internal := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}))
+	defer internal.Close()
+	srv := httptest.NewServer(http.RedirectHandler(internal.URL, http.StatusTemporaryRedirect))
+	defer srv.Close()
+
+	err = postJSON(context.Background(), srv.URL, &webhookPayload{})
+	if err == nil || !strings.Contains(err.Error(), "refusing to send webhook to internal address 127.0.0.1") {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if called {
+		t.Fatal("webhook was redirected to a loopback address")
+	}
+}
+
+func TestNewSlackPayload(t *testing.T) {
+	got := newSlackPayload(&webhookPayload{
+		MonitorDescription: "Find <b> & friends",
+		MonitorURL:         "https://www.sourcegraph.com/code-monitoring/Q29kZU1vbml0b3I6MQ==",
+		Query:              "test",
+		ResultsURL:         "https://www.sourcegraph.com/search?q=test",
+		ResultCount:        1,
+	})
+	want := &slackPayload{
+		Text: "Code monitor <https://www.sourcegraph.com/code-monitoring/Q29kZU1vbml0b3I6MQ==|Find &lt;b&gt; &amp; friends> found 1 new result for <https://www.sourcegraph.com/search?q=test|test>.",
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Fatalf("unexpected payload (-want +got):\n%s", diff)
+	}
+}
+
+func TestNewSlackPayloadWithResults(t *testing.T) {
+	got := newSlackPayload(&webhookPayload{
+		MonitorDescription: "Secrets",
+		MonitorURL:         "https://www.sourcegraph.com/code-monitoring/Q29kZU1vbml0b3I6MQ==",
+		Query:              "type:diff secret",
+		ResultsURL:         "https://www.sourcegraph.com/search?q=type%3Adiff+secret",
+		ResultCount:        2,
+		Results: []*webhookCommitResult{{
+			CommitResult: &cm.CommitResult{
+				Repo:    "github.com/sourcegraph/sourcegraph",
+				Commit:  "deadbeefcafe",
+				Author:  "Alice",
+				Message: "Add secret\n\nOops",
+				DiffPreview: &cm.HighlightedString{
+					Value:     "+secret = 42\n",
+					Truncated: true,
+				},
+			},
+			URL: "https://www.sourcegraph.com/github.com/sourcegraph/sourcegraph/-/commit/deadbeefcafe",
+		}},
+	})
+	want := &slackPayload{
+		Text: "Code monitor <https://www.sourcegraph.com/code-monitoring/Q29kZU1vbml0b3I6MQ==|Secrets> found 2 new results for <https://www.sourcegraph.com/search?q=type%3Adiff+secret|type:diff secret>." +
+			"\n\n*github.com/sourcegraph/sourcegraph* <https://www.sourcegraph.com/github.com/sourcegraph/sourcegraph/-/commit/deadbeefcafe|deadbee> by Alice: Add secret" +
+			"\n```+secret = 42\n...```",
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Fatalf("unexpected payload (-want +got):\n%s", diff)
+	}
+}
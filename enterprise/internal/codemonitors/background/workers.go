@@ -12,6 +12,7 @@ import (
 
 	cm "github.com/sourcegraph/sourcegraph/enterprise/internal/codemonitors"
 	"github.com/sourcegraph/sourcegraph/enterprise/internal/codemonitors/email"
+	"github.com/sourcegraph/sourcegraph/internal/conf"
 	"github.com/sourcegraph/sourcegraph/internal/goroutine"
 	"github.com/sourcegraph/sourcegraph/internal/workerutil"
 	"github.com/sourcegraph/sourcegraph/internal/workerutil/dbworker"
@@ -152,6 +153,11 @@ func (r *queryRunner) Handle(ctx context.Context, record workerutil.Record) (err
 	if err != nil {
 		return err
 	}
+	var m *cm.Monitor
+	m, err = s.MonitorByIDInt64(ctx, q.Monitor)
+	if err != nil {
+		return err
+	}
 	newQuery := newQueryWithAfterFilter(q)
 
 	// Search.
@@ -160,14 +166,31 @@ func (r *queryRunner) Handle(ctx context.Context, record workerutil.Record) (err
 	if err != nil {
 		return err
 	}
-	var numResults int
+	var (
+		numResults    int
+		commitResults []*cm.CommitResult
+	)
 	if results != nil {
-		numResults = len(results.Data.Search.Results.Results)
+		commitResults, err = extractCommitResults(results.Data.Search.Results.Results)
+		if err != nil {
+			return errors.Errorf("extractCommitResults: %w", err)
+		}
+		// The search runs as the internal actor, so we drop the commits in
+		// repositories the owner of the monitor cannot access before we count
+		// them or include them in notifications.
+		commitResults, err = filterCommitResultsForUser(ctx, s.Handle().DB(), monitorOwner(m), commitResults)
+		if err != nil {
+			return errors.Errorf("filterCommitResultsForUser: %w", err)
+		}
+		numResults = len(commitResults)
+		if limit := conf.Get().CodeMonitorsMaxResultsPerNotification; len(commitResults) > limit {
+			commitResults = commitResults[:limit]
+		}
 	}
 	if numResults > 0 {
-		err := s.EnqueueActionEmailsForQueryIDInt64(ctx, q.Id, record.RecordID())
+		err := s.EnqueueActionJobsForQueryIDInt64(ctx, q.Id, record.RecordID())
 		if err != nil {
-			return errors.Errorf("store.EnqueueActionEmailsForQueryIDInt64: %w", err)
+			return errors.Errorf("store.EnqueueActionJobsForQueryIDInt64: %w", err)
 		}
 	}
 	// Log next_run and latest_result to table cm_queries.
@@ -177,7 +200,7 @@ func (r *queryRunner) Handle(ctx context.Context, record workerutil.Record) (err
 		return err
 	}
 	// Log the actual query we ran and whether we got any new results.
-	err = s.LogSearch(ctx, newQuery, numResults, record.RecordID())
+	err = s.LogSearch(ctx, newQuery, numResults, commitResults, record.RecordID())
 	if err != nil {
 		return errors.Errorf("LogSearch: %w", err)
 	}
@@ -203,11 +226,8 @@ func (r *actionRunner) Handle(ctx context.Context, record workerutil.Record) (er
 	defer func() { err = s.Done(err) }()
 
 	var (
-		j    *cm.ActionJob
-		m    *cm.ActionJobMetadata
-		e    *cm.MonitorEmail
-		recs []*cm.Recipient
-		data *email.TemplateDataNewSearchResults
+		j *cm.ActionJob
+		m *cm.ActionJobMetadata
 	)
 
 	var ok bool
@@ -221,17 +241,30 @@ func (r *actionRunner) Handle(ctx context.Context, record workerutil.Record) (er
 		return errors.Errorf("store.GetActionJobMetadata: %w", err)
 	}
 
-	e, err = s.ActionEmailByIDInt64(ctx, j.Email)
+	switch {
+	case j.Email != nil:
+		return handleEmail(ctx, s, *j.Email, m)
+	case j.Webhook != nil:
+		return handleWebhook(ctx, s, *j.Webhook, m)
+	case j.SlackWebhook != nil:
+		return handleSlackWebhook(ctx, s, *j.SlackWebhook, m)
+	default:
+		return errors.Errorf("action job %d has no action", j.Id)
+	}
+}
+
+func handleEmail(ctx context.Context, s *cm.Store, emailID int64, m *cm.ActionJobMetadata) error {
+	e, err := s.ActionEmailByIDInt64(ctx, emailID)
 	if err != nil {
 		return errors.Errorf("store.ActionEmailByIDInt64: %w", err)
 	}
 
-	recs, err = s.AllRecipientsForEmailIDInt64(ctx, j.Email)
+	recs, err := s.AllRecipientsForEmailIDInt64(ctx, emailID)
 	if err != nil {
 		return errors.Errorf("store.AllRecipientsForEmailIDInt64: %w", err)
 	}
 
-	data, err = email.NewTemplateDataForNewSearchResults(ctx, m.Description, m.Query, e, zeroOrVal(m.NumResults))
+	data, err := email.NewTemplateDataForNewSearchResults(ctx, m.Description, m.Query, e, zeroOrVal(m.NumResults), m.Results)
 	if err != nil {
 		return errors.Errorf("email.NewTemplateDataForNewSearchResults: %w", err)
 	}
@@ -251,6 +284,32 @@ func (r *actionRunner) Handle(ctx context.Context, record workerutil.Record) (er
 	return nil
 }
 
+func handleWebhook(ctx context.Context, s *cm.Store, webhookID int64, m *cm.ActionJobMetadata) error {
+	w, err := s.ActionWebhookByIDInt64(ctx, webhookID)
+	if err != nil {
+		return errors.Errorf("store.ActionWebhookByIDInt64: %w", err)
+	}
+
+	payload, err := newWebhookPayload(ctx, m, utmSourceWebhook)
+	if err != nil {
+		return err
+	}
+	return sendWebhookNotification(ctx, w.URL, payload)
+}
+
+func handleSlackWebhook(ctx context.Context, s *cm.Store, slackWebhookID int64, m *cm.ActionJobMetadata) error {
+	w, err := s.ActionSlackWebhookByIDInt64(ctx, slackWebhookID)
+	if err != nil {
+		return errors.Errorf("store.ActionSlackWebhookByIDInt64: %w", err)
+	}
+
+	payload, err := newWebhookPayload(ctx, m, utmSourceSlackWebhook)
+	if err != nil {
+		return err
+	}
+	return sendSlackNotification(ctx, w.URL, newSlackPayload(payload))
+}
+
 // newQueryWithAfterFilter constructs a new query which finds search results
 // introduced after the last time we queried.
 func newQueryWithAfterFilter(q *cm.MonitorQuery) string {
@@ -91,7 +91,7 @@ func TestActionRunner(t *testing.T) {
 			if err != nil {
 				t.Fatal(err)
 			}
-			err = ts.LogSearch(ctx, testQuery, tt.numResults, triggerEvent)
+			err = ts.LogSearch(ctx, testQuery, tt.numResults, nil, triggerEvent)
 			if err != nil {
 				t.Fatal(err)
 			}
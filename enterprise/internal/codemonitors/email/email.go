@@ -37,26 +37,35 @@ type TemplateDataNewSearchResults struct {
 	SearchURL                 string
 	Description               string
 	NumberOfResultsWithDetail string
+	Results                   []*TemplateDataCommitResult
 	IsTest                    bool
 }
 
-func NewTemplateDataForNewSearchResults(ctx context.Context, monitorDescription, queryString string, email *codemonitors.MonitorEmail, numResults int) (d *TemplateDataNewSearchResults, err error) {
+func NewTemplateDataForNewSearchResults(ctx context.Context, monitorDescription, queryString string, email *codemonitors.MonitorEmail, numResults int, commitResults []*codemonitors.CommitResult) (d *TemplateDataNewSearchResults, err error) {
 	var (
 		searchURL                 string
 		codeMonitorURL            string
 		priority                  string
 		numberOfResultsWithDetail string
+		results                   []*TemplateDataCommitResult
 	)
-	searchURL, err = getSearchURL(ctx, queryString, utmSourceEmail)
+	searchURL, err = GetSearchURL(ctx, queryString, utmSourceEmail)
 	if err != nil {
 		return nil, err
 	}
 
-	codeMonitorURL, err = getCodeMonitorURL(ctx, email.Monitor, utmSourceEmail)
+	codeMonitorURL, err = GetCodeMonitorURL(ctx, email.Monitor, utmSourceEmail)
 	if err != nil {
 		return nil, err
 	}
 
+	if len(commitResults) > 0 {
+		results, err = NewTemplateDataCommitResults(ctx, commitResults, utmSourceEmail)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	if email.Priority == priorityCritical {
 		priority = "Critical"
 	} else {
@@ -75,6 +84,7 @@ func NewTemplateDataForNewSearchResults(ctx context.Context, monitorDescription,
 		SearchURL:                 searchURL,
 		Description:               monitorDescription,
 		NumberOfResultsWithDetail: numberOfResultsWithDetail,
+		Results:                   results,
 	}, nil
 }
 
@@ -105,11 +115,15 @@ func sendEmail(ctx context.Context, userID int32, template txtypes.Templates, da
 	return nil
 }
 
-func getSearchURL(ctx context.Context, query, utmSource string) (string, error) {
+// GetSearchURL returns an external URL to the search results of query, tagged
+// with the given utm_source.
+func GetSearchURL(ctx context.Context, query, utmSource string) (string, error) {
 	return sourcegraphURL(ctx, "search", query, utmSource)
 }
 
-func getCodeMonitorURL(ctx context.Context, monitorID int64, utmSource string) (string, error) {
+// GetCodeMonitorURL returns an external URL to the code monitor with the given
+// ID, tagged with the given utm_source.
+func GetCodeMonitorURL(ctx context.Context, monitorID int64, utmSource string) (string, error) {
 	return sourcegraphURL(ctx, fmt.Sprintf("code-monitoring/%s", relay.MarshalID(MonitorKind, monitorID)), "", utmSource)
 }
 
@@ -0,0 +1,105 @@
+package email
+
+import (
+	"context"
+	"html/template"
+	"sort"
+	"strings"
+
+	"github.com/sourcegraph/sourcegraph/enterprise/internal/codemonitors"
+)
+
+// TemplateDataCommitResult is a commit matched by a code monitor, as rendered
+// in a notification.
+type TemplateDataCommitResult struct {
+	Repo    string
+	Commit  string
+	Author  string
+	Subject string
+	URL     string
+
+	// Preview is the matched part of the diff or commit message. PreviewHTML
+	// is the same with the matches highlighted.
+	Preview     string
+	PreviewHTML template.HTML
+	Truncated   bool
+}
+
+// GetCommitURL returns an external URL to the given commit, tagged with the
+// given utm_source.
+func GetCommitURL(ctx context.Context, repo, commit, utmSource string) (string, error) {
+	return sourcegraphURL(ctx, repo+"/-/commit/"+commit, "", utmSource)
+}
+
+// NewTemplateDataCommitResults prepares the given commits for rendering in a
+// notification.
+func NewTemplateDataCommitResults(ctx context.Context, results []*codemonitors.CommitResult, utmSource string) ([]*TemplateDataCommitResult, error) {
+	data := make([]*TemplateDataCommitResult, 0, len(results))
+	for _, r := range results {
+		u, err := GetCommitURL(ctx, r.Repo, r.Commit, utmSource)
+		if err != nil {
+			return nil, err
+		}
+		d := &TemplateDataCommitResult{
+			Repo:    r.Repo,
+			Commit:  abbreviateOID(r.Commit),
+			Author:  r.Author,
+			Subject: r.Subject(),
+			URL:     u,
+		}
+		preview := r.DiffPreview
+		if preview == nil {
+			preview = r.MessagePreview
+		}
+		if preview != nil {
+			d.Preview = strings.TrimSuffix(preview.Value, "\n")
+			d.PreviewHTML = template.HTML(strings.TrimSuffix(string(highlightHTML(preview)), "\n"))
+			d.Truncated = preview.Truncated
+		}
+		data = append(data, d)
+	}
+	return data, nil
+}
+
+func abbreviateOID(oid string) string {
+	if len(oid) > 7 {
+		return oid[:7]
+	}
+	return oid
+}
+
+// highlightHTML returns the HTML-escaped value of h with its highlighted
+// ranges wrapped in <mark> elements.
+func highlightHTML(h *codemonitors.HighlightedString) template.HTML {
+	byLine := make(map[int32][]codemonitors.HighlightedRange)
+	for _, hl := range h.Highlights {
+		byLine[hl.Line] = append(byLine[hl.Line], hl)
+	}
+
+	var b strings.Builder
+	for i, line := range strings.SplitAfter(h.Value, "\n") {
+		highlights := byLine[int32(i)]
+		if len(highlights) == 0 {
+			b.WriteString(template.HTMLEscapeString(line))
+			continue
+		}
+		sort.Slice(highlights, func(i, j int) bool { return highlights[i].Character < highlights[j].Character })
+
+		runes := []rune(line)
+		var pos int
+		for _, hl := range highlights {
+			start, end := int(hl.Character), int(hl.Character+hl.Length)
+			if start < pos || end > len(runes) {
+				// Skip overlapping or out of range highlights.
+				continue
+			}
+			b.WriteString(template.HTMLEscapeString(string(runes[pos:start])))
+			b.WriteString("<mark>")
+			b.WriteString(template.HTMLEscapeString(string(runes[start:end])))
+			b.WriteString("</mark>")
+			pos = end
+		}
+		b.WriteString(template.HTMLEscapeString(string(runes[pos:])))
+	}
+	return template.HTML(b.String())
+}
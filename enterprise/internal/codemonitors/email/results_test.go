@@ -0,0 +1,22 @@
+package email
+
+import (
+	"html/template"
+	"testing"
+
+	"github.com/sourcegraph/sourcegraph/enterprise/internal/codemonitors"
+)
+
+func TestHighlightHTML(t *testing.T) {
+	got := highlightHTML(&codemonitors.HighlightedString{
+		Value: "-a < b\n+a > ü secret\n",
+		Highlights: []codemonitors.HighlightedRange{
+			{Line: 1, Character: 7, Length: 6},
+			{Line: 1, Character: 1, Length: 1},
+		},
+	})
+	want := template.HTML("-a &lt; b\n+<mark>a</mark> &gt; ü <mark>secret</mark>\n")
+	if got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
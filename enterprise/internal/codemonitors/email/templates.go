@@ -14,7 +14,13 @@ Code monitoring triggered a new event:
 
 {{.Description}}
 {{.NumberOfResultsWithDetail}}
-
+{{ range .Results }}
+{{.Repo}} {{.Commit}} by {{.Author}}: {{.Subject}}
+{{.URL}}
+{{ if .Preview }}
+{{.Preview}}{{ if .Truncated }}
+...{{ end }}
+{{ end }}{{ end }}
 View search on Sourcegraph {{.SearchURL}}
 
 __
@@ -22,8 +28,8 @@ You are receiving this notification because you are a recipient on a code monito
 
 View code monitor: {{.CodeMonitorURL}}
 
-Search results may contain confidential data. To protect your privacy and security,
-Sourcegraph limits what information is contained in this notification.
+Search results may contain confidential data.{{ if not .Results }} To protect your privacy and security,
+Sourcegraph limits what information is contained in this notification.{{ end }}
 `,
 	HTML: `
 <!DOCTYPE html>
@@ -44,6 +50,15 @@ Sourcegraph limits what information is contained in this notification.
         >{{.NumberOfResultsWithDetail}}</span
       >
     </p>
+	{{ range .Results }}
+	<p style="font-size: 14px; line-height: 21px; margin-bottom: 4px">
+	  <a href="{{.URL}}">{{.Repo}} {{.Commit}}</a> by {{.Author}}: {{.Subject}}
+	</p>
+	{{ if .Preview }}
+	<pre style="font-size: 12px; line-height: 18px; padding: 8px; background-color: #F9FAFB; border-radius: 4px; overflow-x: auto">{{.PreviewHTML}}{{ if .Truncated }}
+...{{ end }}</pre>
+	{{ end }}
+	{{ end }}
 	<p style="font-size: 16px; line-height: 24px">
 	  <a href="{{.SearchURL}}" {{ if .IsTest }}style="color: #9C9FA6; font-weight: 400; text-decoration: underline; cursor: default"{{ end }}>
         View search on Sourcegraph
@@ -62,9 +77,9 @@ Sourcegraph limits what information is contained in this notification.
 	  </a>
     </p>
     <p style="font-size: 12px; line-height: 24px; margin-bottom: 24px">
-      Search results may contain confidential data. To protect your privacy and
+      Search results may contain confidential data.{{ if not .Results }} To protect your privacy and
       security, Sourcegraph limits what information is contained in this
-      notification.
+      notification.{{ end }}
 	</p>
 	<img src="https://about.sourcegraph.com/sourcegraph-logo-small.png" width="106" height="20" alt="Sourcegraph logo" />
   </body>
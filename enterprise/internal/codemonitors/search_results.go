@@ -0,0 +1,116 @@
+package codemonitors
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/sourcegraph/sourcegraph/internal/database"
+	"github.com/sourcegraph/sourcegraph/internal/encryption"
+	"github.com/sourcegraph/sourcegraph/internal/encryption/keyring"
+)
+
+// MaxDiffPreviewLines is the maximum number of lines of a matched diff which
+// are included in a notification.
+const MaxDiffPreviewLines = 30
+
+// CommitResult is a commit matched by the query of a code monitor. The
+// previews and their highlights are the ones the search backend computes for
+// result.CommitMatch.
+type CommitResult struct {
+	Repo    string    `json:"repo"`
+	Commit  string    `json:"commit"`
+	Author  string    `json:"author"`
+	Date    time.Time `json:"date"`
+	Message string    `json:"message"`
+
+	// MessagePreview is set for type:commit queries.
+	MessagePreview *HighlightedString `json:"messagePreview,omitempty"`
+	// DiffPreview is set for type:diff queries.
+	DiffPreview *HighlightedString `json:"diffPreview,omitempty"`
+}
+
+// Subject returns the first line of the commit message.
+func (r *CommitResult) Subject() string {
+	if i := strings.IndexByte(r.Message, '\n'); i >= 0 {
+		return r.Message[:i]
+	}
+	return r.Message
+}
+
+// HighlightedString is a string with highlighted ranges.
+type HighlightedString struct {
+	Value      string             `json:"value"`
+	Highlights []HighlightedRange `json:"highlights,omitempty"`
+	// Truncated is true if lines were dropped from the end of Value.
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+// HighlightedRange is a range within a HighlightedString. Line is 0-based.
+type HighlightedRange struct {
+	Line      int32 `json:"line"`
+	Character int32 `json:"character"`
+	Length    int32 `json:"length"`
+}
+
+// Truncate drops all but the first maxLines lines of h, along with their
+// highlights.
+func (h *HighlightedString) Truncate(maxLines int) {
+	lines := strings.SplitAfter(h.Value, "\n")
+	if len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	if len(lines) <= maxLines {
+		return
+	}
+	h.Value = strings.Join(lines[:maxLines], "")
+	h.Truncated = true
+
+	highlights := h.Highlights[:0]
+	for _, hl := range h.Highlights {
+		if int(hl.Line) < maxLines {
+			highlights = append(highlights, hl)
+		}
+	}
+	h.Highlights = highlights
+}
+
+// marshalCommitResults encodes results, and encrypts them if a key is
+// configured. It returns the version of the key that encrypted them, or the
+// empty string if they are not encrypted.
+func marshalCommitResults(ctx context.Context, results []*CommitResult) (b []byte, keyID string, err error) {
+	if len(results) == 0 {
+		return nil, "", nil
+	}
+	b, err = json.Marshal(results)
+	if err != nil {
+		return nil, "", err
+	}
+	encrypted, keyID, err := database.MaybeEncrypt(ctx, resultsKey(), string(b))
+	if err != nil {
+		return nil, "", err
+	}
+	return []byte(encrypted), keyID, nil
+}
+
+func unmarshalCommitResults(ctx context.Context, b []byte, keyID string) ([]*CommitResult, error) {
+	if len(b) == 0 {
+		return nil, nil
+	}
+	decrypted, err := database.MaybeDecrypt(ctx, resultsKey(), string(b), keyID)
+	if err != nil {
+		return nil, err
+	}
+	var results []*CommitResult
+	if err := json.Unmarshal([]byte(decrypted), &results); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// resultsKey returns the key that encrypts the search results of trigger jobs,
+// or nil if encryption is not configured.
+func resultsKey() encryption.Key {
+	return keyring.Default().CodeMonitorResultsKey
+}
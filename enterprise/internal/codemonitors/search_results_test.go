@@ -0,0 +1,92 @@
+package codemonitors
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/sourcegraph/sourcegraph/internal/encryption/keyring"
+	et "github.com/sourcegraph/sourcegraph/internal/encryption/testing"
+)
+
+func TestHighlightedStringTruncate(t *testing.T) {
+	tests := []struct {
+		name     string
+		in       HighlightedString
+		maxLines int
+		want     HighlightedString
+	}{
+		{
+			name: "short",
+			in: HighlightedString{
+				Value:      "a\nb\n",
+				Highlights: []HighlightedRange{{Line: 1, Character: 0, Length: 1}},
+			},
+			maxLines: 2,
+			want: HighlightedString{
+				Value:      "a\nb\n",
+				Highlights: []HighlightedRange{{Line: 1, Character: 0, Length: 1}},
+			},
+		},
+		{
+			name: "truncated",
+			in: HighlightedString{
+				Value: "a\nb\nc\nd",
+				Highlights: []HighlightedRange{
+					{Line: 0, Character: 0, Length: 1},
+					{Line: 2, Character: 0, Length: 1},
+				},
+			},
+			maxLines: 2,
+			want: HighlightedString{
+				Value:      "a\nb\n",
+				Highlights: []HighlightedRange{{Line: 0, Character: 0, Length: 1}},
+				Truncated:  true,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.in
+			got.Truncate(tt.maxLines)
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Fatalf("unexpected result (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestMarshalCommitResultsEncrypted(t *testing.T) {
+	keyring.MockDefault(keyring.Ring{CodeMonitorResultsKey: et.TestKey{}})
+	defer keyring.MockDefault(keyring.Ring{})
+
+	ctx := context.Background()
+	want := []*CommitResult{{
+		Repo:    "github.com/sourcegraph/sourcegraph",
+		Commit:  "deadbeef",
+		Author:  "Alice",
+		Date:    time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC),
+		Message: "Add secret",
+	}}
+	b, keyID, err := marshalCommitResults(ctx, want)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if keyID == "" {
+		t.Fatal("expected a key ID")
+	}
+	if bytes.Contains(b, []byte("Add secret")) {
+		t.Fatalf("expected encrypted results, got %q", b)
+	}
+
+	got, err := unmarshalCommitResults(ctx, b, keyID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Fatalf("unexpected results (-want +got):\n%s", diff)
+	}
+}
@@ -42,12 +42,20 @@ const logSearchFmtStr = `
 UPDATE cm_trigger_jobs
 SET query_string = %s,
     results = %s,
-    num_results = %s
+    num_results = %s,
+    search_results = %s,
+    encryption_key_id = %s
 WHERE id = %s
 `
 
-func (s *Store) LogSearch(ctx context.Context, queryString string, numResults int, recordID int) error {
-	return s.Store.Exec(ctx, sqlf.Sprintf(logSearchFmtStr, queryString, numResults > 0, numResults, recordID))
+// LogSearch records the query a trigger job ran, along with the number of
+// results and the matched commits to include in notifications.
+func (s *Store) LogSearch(ctx context.Context, queryString string, numResults int, results []*CommitResult, recordID int) error {
+	b, keyID, err := marshalCommitResults(ctx, results)
+	if err != nil {
+		return err
+	}
+	return s.Store.Exec(ctx, sqlf.Sprintf(logSearchFmtStr, queryString, numResults > 0, numResults, b, keyID, recordID))
 }
 
 const deleteObsoleteJobLogsFmtStr = `
@@ -29,6 +29,11 @@ type Actor struct {
 	// cookie, logout would be ineffective.)
 	FromSessionCookie bool `json:"-"`
 
+	// Scopes restricts the actor to the operations allowed by these access token scopes. It is set
+	// when the actor was authenticated with an access token without the "user:all" scope. A nil
+	// value means that the actor is not restricted.
+	Scopes []string `json:"-"`
+
 	// user is populated lazily by (*Actor).User()
 	user     *types.User
 	userErr  error
@@ -50,6 +55,20 @@ func (a *Actor) IsAuthenticated() bool {
 	return a != nil && a.UID != 0
 }
 
+// HasScope returns true if the Actor is not restricted to a set of access token scopes, or if
+// scope is one of them.
+func (a *Actor) HasScope(scope string) bool {
+	if a == nil || a.Scopes == nil {
+		return true
+	}
+	for _, s := range a.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
 // IsInternal returns true if the Actor is an internal actor.
 func (a *Actor) IsInternal() bool {
 	return a != nil && a.Internal
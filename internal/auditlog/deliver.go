@@ -0,0 +1,158 @@
+package auditlog
+
+import (
+	"context"
+	"time"
+
+	"github.com/cockroachdb/errors"
+	"github.com/inconshreveable/log15"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"github.com/sourcegraph/sourcegraph/internal/conf"
+	"github.com/sourcegraph/sourcegraph/internal/database"
+	"github.com/sourcegraph/sourcegraph/internal/database/dbutil"
+	"github.com/sourcegraph/sourcegraph/schema"
+)
+
+const (
+	deliveryInterval = 10 * time.Second
+	batchSize        = 100
+	maxBackoff       = time.Minute
+	// claimDuration is how long a process delivering events to a sink claims it for each batch.
+	// It is longer than sending a batch takes with the default number of attempts: if sending
+	// takes longer, another process may deliver the batch again.
+	claimDuration = 10 * time.Minute
+)
+
+var (
+	deliveredEvents = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "src_security_event_sink_delivered_total",
+		Help: "Total number of security events delivered to sinks.",
+	}, []string{"type"})
+	deliveryErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "src_security_event_sink_errors_total",
+		Help: "Total number of failed deliveries of security events to sinks, after retries.",
+	}, []string{"type"})
+)
+
+// Deliver delivers the security events to the sinks in the site configuration until ctx is
+// canceled. Every event is delivered at least once to each sink, in order. It is safe to run
+// concurrently in several processes, as each sink is claimed by a single process while delivering.
+func Deliver(ctx context.Context, db dbutil.DB) {
+	d := &deliverer{
+		store:   database.SecurityEventLogs(db),
+		newSink: newSink,
+		backoff: time.Second,
+	}
+	for {
+		if log := conf.Get().Log; log != nil && log.SecurityEvents != nil {
+			d.run(ctx, log.SecurityEvents)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(deliveryInterval):
+		}
+	}
+}
+
+type deliverer struct {
+	store   *database.SecurityEventLogStore
+	newSink func(*schema.SecurityEventSink) (sink, error)
+	// backoff is the delay before retrying a failed delivery, which doubles on each attempt.
+	backoff time.Duration
+}
+
+func (d *deliverer) run(ctx context.Context, c *schema.SecurityEventsLog) {
+	maxAttempts := c.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = 5
+	}
+	for _, sc := range c.Sinks {
+		typ, key := sinkKey(sc)
+		if err := d.deliver(ctx, sc, maxAttempts); err != nil {
+			log15.Error("Failed to deliver security events.", "sink", key, "error", err)
+			deliveryErrors.WithLabelValues(typ).Inc()
+		}
+	}
+}
+
+// deliver delivers the events recorded since the last delivery to the sink. It does nothing if
+// another process is delivering events to the sink.
+//
+// No transaction stays open while the events are sent: each batch is listed, sent, and then
+// recorded as delivered by moving the cursor of the sink forward.
+func (d *deliverer) deliver(ctx context.Context, c *schema.SecurityEventSink, maxAttempts int) (err error) {
+	typ, key := sinkKey(c)
+	if key == "" {
+		return errors.New("invalid security event sink configuration")
+	}
+
+	cursor, ok, err := d.store.ClaimSinkCursor(ctx, key, claimDuration)
+	if err != nil || !ok {
+		return err
+	}
+	defer func() {
+		if releaseErr := d.store.ReleaseSinkCursor(ctx, key); err == nil {
+			err = releaseErr
+		}
+	}()
+
+	var s sink
+	defer func() {
+		if s != nil {
+			_ = s.Close()
+		}
+	}()
+
+	for {
+		events, err := d.store.ListAfter(ctx, cursor, batchSize)
+		if err != nil || len(events) == 0 {
+			return err
+		}
+
+		if s == nil {
+			if s, err = d.newSink(c); err != nil {
+				return err
+			}
+		}
+		if err := d.send(ctx, s, events, maxAttempts); err != nil {
+			return err
+		}
+		deliveredEvents.WithLabelValues(typ).Add(float64(len(events)))
+
+		last := events[len(events)-1]
+		cursor = database.SecurityEventCursor{TxID: last.TxID, EventID: last.ID}
+		if err := d.store.UpdateSinkCursor(ctx, key, cursor, claimDuration); err != nil {
+			return err
+		}
+		if len(events) < batchSize {
+			return nil
+		}
+	}
+}
+
+// send sends the events to the sink, retrying with an exponential backoff.
+func (d *deliverer) send(ctx context.Context, s sink, events []*database.SecurityEvent, maxAttempts int) error {
+	backoff := d.backoff
+	for attempt := 1; ; attempt++ {
+		err := s.Send(ctx, events)
+		if err == nil {
+			return nil
+		}
+		if attempt >= maxAttempts {
+			return errors.Wrapf(err, "after %d attempts", attempt)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+		if backoff *= 2; backoff > maxBackoff {
+			backoff = maxBackoff
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package auditlog
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/cockroachdb/errors"
+	"github.com/google/go-cmp/cmp"
+	"github.com/keegancsmith/sqlf"
+
+	"github.com/sourcegraph/sourcegraph/internal/database"
+	"github.com/sourcegraph/sourcegraph/internal/database/dbtest"
+	"github.com/sourcegraph/sourcegraph/schema"
+)
+
+// fakeSink records the IDs of the events it receives, and fails its first failures sends.
+type fakeSink struct {
+	failures int
+	sends    int
+	ids      []int64
+}
+
+func (s *fakeSink) Send(ctx context.Context, events []*database.SecurityEvent) error {
+	s.sends++
+	if s.sends <= s.failures {
+		return errors.New("unavailable")
+	}
+	for _, e := range events {
+		s.ids = append(s.ids, e.ID)
+	}
+	return nil
+}
+
+func (s *fakeSink) Close() error { return nil }
+
+func TestSend(t *testing.T) {
+	d := &deliverer{backoff: time.Millisecond}
+
+	s := &fakeSink{failures: 2}
+	if err := d.send(context.Background(), s, testEvents, 3); err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]int64{1, 2}, s.ids); diff != "" {
+		t.Fatalf("unexpected events (-want +have):\n%s", diff)
+	}
+
+	s = &fakeSink{failures: 3}
+	if err := d.send(context.Background(), s, testEvents, 3); err == nil || err.Error() != "after 3 attempts: unavailable" {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if s.sends != 3 {
+		t.Fatalf("unexpected number of attempts %d", s.sends)
+	}
+}
+
+func TestDeliver(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+	ctx := context.Background()
+	db := dbtest.NewDB(t, "")
+	store := database.SecurityEventLogs(db)
+
+	insert := func(t *testing.T, n int) {
+		t.Helper()
+		for i := 0; i < n; i++ {
+			if err := store.Insert(ctx, &database.SecurityEvent{Name: database.SecurityEventNameSignInSucceeded, UserID: 1, Source: "BACKEND", Timestamp: time.Now()}); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	insert(t, 1)
+
+	s := &fakeSink{}
+	d := &deliverer{
+		store:   store,
+		newSink: func(*schema.SecurityEventSink) (sink, error) { return s, nil },
+		backoff: time.Millisecond,
+	}
+	c := &schema.SecurityEventSink{File: &schema.FileSecurityEventSink{Type: "file", Path: "/tmp/events.jsonl"}}
+
+	// The events recorded before the sink was added aren't delivered.
+	if err := d.deliver(ctx, c, 1); err != nil {
+		t.Fatal(err)
+	}
+	if len(s.ids) != 0 {
+		t.Fatalf("unexpected events %v", s.ids)
+	}
+
+	insert(t, batchSize+1)
+	if err := d.deliver(ctx, c, 1); err != nil {
+		t.Fatal(err)
+	}
+	if len(s.ids) != batchSize+1 {
+		t.Fatalf("unexpected number of events %d", len(s.ids))
+	}
+
+	// Failed deliveries are retried on the next run.
+	insert(t, 2)
+	s.failures = s.sends + 1
+	if err := d.deliver(ctx, c, 1); err == nil {
+		t.Fatal("expected an error")
+	}
+	if err := d.deliver(ctx, c, 1); err != nil {
+		t.Fatal(err)
+	}
+	if len(s.ids) != batchSize+3 {
+		t.Fatalf("unexpected number of events %d", len(s.ids))
+	}
+	for i := 1; i < len(s.ids); i++ {
+		if s.ids[i] <= s.ids[i-1] {
+			t.Fatalf("events delivered out of order: %v", s.ids)
+		}
+	}
+
+	// Events wait for the older transactions to end.
+	tx, err := store.Transact(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = tx.Done(nil) }()
+	if err := tx.Exec(ctx, sqlf.Sprintf("SELECT txid_current()")); err != nil {
+		t.Fatal(err)
+	}
+	insert(t, 1)
+	if err := d.deliver(ctx, c, 1); err != nil {
+		t.Fatal(err)
+	}
+	if len(s.ids) != batchSize+3 {
+		t.Fatalf("unexpected number of events %d", len(s.ids))
+	}
+	if err := tx.Done(nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.deliver(ctx, c, 1); err != nil {
+		t.Fatal(err)
+	}
+	if len(s.ids) != batchSize+4 {
+		t.Fatalf("unexpected number of events %d", len(s.ids))
+	}
+}
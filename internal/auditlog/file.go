@@ -0,0 +1,39 @@
+package auditlog
+
+import (
+	"context"
+	"os"
+
+	"github.com/cockroachdb/errors"
+
+	"github.com/sourcegraph/sourcegraph/internal/database"
+)
+
+// fileSink appends events to a file as newline delimited JSON.
+type fileSink struct {
+	path string
+}
+
+func (s *fileSink) Send(ctx context.Context, events []*database.SecurityEvent) (err error) {
+	b, err := marshalEvents(events)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(s.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+	}()
+
+	if _, err := f.Write(b); err != nil {
+		return errors.Wrap(err, "writing events")
+	}
+	return f.Sync()
+}
+
+func (s *fileSink) Close() error { return nil }
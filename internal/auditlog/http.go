@@ -0,0 +1,68 @@
+package auditlog
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/cockroachdb/errors"
+
+	"github.com/sourcegraph/sourcegraph/internal/database"
+	"github.com/sourcegraph/sourcegraph/internal/httpcli"
+	"github.com/sourcegraph/sourcegraph/schema"
+)
+
+// httpSink POSTs events to an HTTP collector as newline delimited JSON.
+type httpSink struct {
+	url     string
+	headers map[string]string
+	doer    httpcli.Doer
+}
+
+// httpClientFactory doesn't retry requests, as failed deliveries are retried by the deliverer.
+var httpClientFactory = httpcli.NewFactory(
+	httpcli.NewMiddleware(httpcli.ContextErrorMiddleware),
+	httpcli.NewTimeoutOpt(30*time.Second),
+	httpcli.ExternalTransportOpt,
+	httpcli.TracedTransportOpt,
+)
+
+func newHTTPSink(c *schema.HTTPSecurityEventSink) (*httpSink, error) {
+	doer, err := httpClientFactory.Doer()
+	if err != nil {
+		return nil, err
+	}
+	return &httpSink{url: c.Url, headers: c.Headers, doer: doer}, nil
+}
+
+func (s *httpSink) Send(ctx context.Context, events []*database.SecurityEvent) error {
+	b, err := marshalEvents(events)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-ndjson")
+	for k, v := range s.headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := s.doer.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return errors.Errorf("unexpected response status %d: %s", resp.StatusCode, bytes.TrimSpace(body))
+	}
+	return nil
+}
+
+func (s *httpSink) Close() error { return nil }
@@ -0,0 +1,89 @@
+// Package auditlog delivers the security events recorded in the security_event_logs table to
+// the sinks configured in the log.securityEvents site configuration, such as syslog servers and
+// HTTP collectors of SIEM systems.
+package auditlog
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/cockroachdb/errors"
+
+	"github.com/sourcegraph/sourcegraph/internal/database"
+	"github.com/sourcegraph/sourcegraph/schema"
+)
+
+// sink is a destination that security events are delivered to.
+type sink interface {
+	// Send delivers the events, in order. When it returns an error, some of the events may
+	// have been delivered, and all of them are sent again.
+	Send(ctx context.Context, events []*database.SecurityEvent) error
+	Close() error
+}
+
+func newSink(c *schema.SecurityEventSink) (sink, error) {
+	switch {
+	case c.Syslog != nil:
+		return newSyslogSink(c.Syslog)
+	case c.Http != nil:
+		return newHTTPSink(c.Http)
+	case c.File != nil:
+		return &fileSink{path: c.File.Path}, nil
+	}
+	return nil, errors.New("invalid security event sink configuration")
+}
+
+// sinkKey identifies the sink in the security_event_log_sink_cursors table, so that changing
+// other settings of a sink, such as its credentials, doesn't affect which events are delivered.
+func sinkKey(c *schema.SecurityEventSink) (typ, key string) {
+	switch {
+	case c.Syslog != nil:
+		return "syslog", "syslog:" + c.Syslog.Address
+	case c.Http != nil:
+		return "http", "http:" + c.Http.Url
+	case c.File != nil:
+		return "file", "file:" + c.File.Path
+	}
+	return "", ""
+}
+
+// event is the JSON representation of a security event delivered to sinks.
+type event struct {
+	ID              int64           `json:"id"`
+	Name            string          `json:"name"`
+	URL             string          `json:"url,omitempty"`
+	UserID          uint32          `json:"user_id,omitempty"`
+	AnonymousUserID string          `json:"anonymous_user_id,omitempty"`
+	Source          string          `json:"source"`
+	Argument        json.RawMessage `json:"argument,omitempty"`
+	Version         string          `json:"version"`
+	Timestamp       time.Time       `json:"timestamp"`
+}
+
+func marshalEvent(e *database.SecurityEvent) ([]byte, error) {
+	return json.Marshal(event{
+		ID:              e.ID,
+		Name:            string(e.Name),
+		URL:             e.URL,
+		UserID:          e.UserID,
+		AnonymousUserID: e.AnonymousUserID,
+		Source:          e.Source,
+		Argument:        e.Argument,
+		Version:         e.Version,
+		Timestamp:       e.Timestamp.UTC(),
+	})
+}
+
+// marshalEvents returns the events as newline delimited JSON.
+func marshalEvents(events []*database.SecurityEvent) ([]byte, error) {
+	var b []byte
+	for _, e := range events {
+		line, err := marshalEvent(e)
+		if err != nil {
+			return nil, err
+		}
+		b = append(append(b, line...), '\n')
+	}
+	return b, nil
+}
@@ -0,0 +1,176 @@
+package auditlog
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/sourcegraph/sourcegraph/internal/database"
+	"github.com/sourcegraph/sourcegraph/schema"
+)
+
+var testEvents = []*database.SecurityEvent{
+	{
+		ID:        1,
+		Name:      database.SecurityEventNameSignInSucceeded,
+		URL:       "https://sourcegraph.example.com/sign-in",
+		UserID:    1,
+		Source:    "BACKEND",
+		Argument:  json.RawMessage(`{}`),
+		Version:   "3.33.0",
+		Timestamp: time.Date(2021, 10, 1, 12, 0, 0, 123456000, time.UTC),
+	},
+	{
+		ID:        2,
+		Name:      database.SecurityEventNameAccessTokenCreated,
+		UserID:    2,
+		Source:    "BACKEND",
+		Argument:  json.RawMessage(`{"scopes":["user:all"]}`),
+		Version:   "3.33.0",
+		Timestamp: time.Date(2021, 10, 1, 12, 0, 1, 0, time.UTC),
+	},
+}
+
+const testEventsJSON = `{"id":1,"name":"SignInSucceeded","url":"https://sourcegraph.example.com/sign-in","user_id":1,"source":"BACKEND","argument":{},"version":"3.33.0","timestamp":"2021-10-01T12:00:00.123456Z"}
+{"id":2,"name":"AccessTokenCreated","user_id":2,"source":"BACKEND","argument":{"scopes":["user:all"]},"version":"3.33.0","timestamp":"2021-10-01T12:00:01Z"}
+`
+
+func TestSyslogSink(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	s, err := newSyslogSink(&schema.SyslogSecurityEventSink{
+		Address:  "tcp://" + ln.Addr().String(),
+		AppName:  "source graph",
+		Facility: "local4",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.hostname = "frontend-0"
+	defer s.Close()
+
+	received := make(chan string)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			close(received)
+			return
+		}
+		defer conn.Close()
+		b, _ := io.ReadAll(conn)
+		received <- string(b)
+	}()
+
+	if err := s.Send(context.Background(), testEvents); err != nil {
+		t.Fatal(err)
+	}
+	_ = s.Close()
+
+	lines := strings.Split(strings.TrimSpace(testEventsJSON), "\n")
+	first := `<166>1 2021-10-01T12:00:00.123456Z frontend-0 sourcegraph - SignInSucceeded - ` + lines[0]
+	second := `<166>1 2021-10-01T12:00:01.000000Z frontend-0 sourcegraph - AccessTokenCreated - ` + lines[1]
+	want := frame(first) + frame(second)
+	if diff := cmp.Diff(want, <-received); diff != "" {
+		t.Fatalf("unexpected messages (-want +have):\n%s", diff)
+	}
+}
+
+func frame(msg string) string {
+	return strconv.Itoa(len(msg)) + " " + msg
+}
+
+func TestSyslogHeaderField(t *testing.T) {
+	for _, tc := range []struct {
+		value string
+		max   int
+		want  string
+	}{
+		{value: "", max: 48, want: "-"},
+		{value: "frontend-0", max: 48, want: "frontend-0"},
+		{value: "source graph\n", max: 48, want: "sourcegraph"},
+		{value: "évènement", max: 48, want: "vnement"},
+		{value: "AccessTokenCreated", max: 11, want: "AccessToken"},
+	} {
+		if have := syslogHeaderField(tc.value, tc.max); have != tc.want {
+			t.Errorf("%q: want %q, have %q", tc.value, tc.want, have)
+		}
+	}
+}
+
+func TestHTTPSink(t *testing.T) {
+	var have string
+	status := http.StatusServiceUnavailable
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Splunk secret" || r.Header.Get("Content-Type") != "application/x-ndjson" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		b, _ := io.ReadAll(r.Body)
+		have = string(b)
+		w.WriteHeader(status)
+		_, _ = io.WriteString(w, "unavailable\n")
+	}))
+	defer srv.Close()
+
+	s, err := newHTTPSink(&schema.HTTPSecurityEventSink{
+		Url:     srv.URL,
+		Headers: map[string]string{"Authorization": "Splunk secret"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.doer = srv.Client()
+
+	if err := s.Send(context.Background(), testEvents); err == nil || err.Error() != "unexpected response status 503: unavailable" {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	status = http.StatusOK
+	if err := s.Send(context.Background(), testEvents); err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(testEventsJSON, have); diff != "" {
+		t.Fatalf("unexpected body (-want +have):\n%s", diff)
+	}
+}
+
+func TestFileSink(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.jsonl")
+	s := &fileSink{path: path}
+
+	for _, events := range [][]*database.SecurityEvent{testEvents[:1], testEvents[1:]} {
+		if err := s.Send(context.Background(), events); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	var lines []string
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		lines = append(lines, sc.Text())
+	}
+	if diff := cmp.Diff(strings.Split(strings.TrimSpace(testEventsJSON), "\n"), lines); diff != "" {
+		t.Fatalf("unexpected file contents (-want +have):\n%s", diff)
+	}
+}
@@ -0,0 +1,182 @@
+package auditlog
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/cockroachdb/errors"
+
+	"github.com/sourcegraph/sourcegraph/internal/database"
+	"github.com/sourcegraph/sourcegraph/schema"
+)
+
+// syslogFacilities maps the facilities allowed in the site configuration to their codes.
+var syslogFacilities = map[string]int{
+	"auth":     4,
+	"authpriv": 10,
+	"audit":    13,
+	"local0":   16,
+	"local1":   17,
+	"local2":   18,
+	"local3":   19,
+	"local4":   20,
+	"local5":   21,
+	"local6":   22,
+	"local7":   23,
+}
+
+const (
+	syslogSeverityInfo = 6
+
+	// syslogTimeFormat is the TIMESTAMP format of RFC 5424, with microseconds.
+	syslogTimeFormat = "2006-01-02T15:04:05.000000Z07:00"
+
+	syslogTimeout = 30 * time.Second
+)
+
+// syslogSink sends events as RFC 5424 syslog messages, with the event as JSON in the message.
+type syslogSink struct {
+	network   string
+	address   string
+	tlsConfig *tls.Config
+
+	priority int
+	hostname string
+	appName  string
+
+	conn net.Conn
+}
+
+func newSyslogSink(c *schema.SyslogSecurityEventSink) (*syslogSink, error) {
+	u, err := url.Parse(c.Address)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid syslog address")
+	}
+
+	s := &syslogSink{
+		network:  u.Scheme,
+		address:  u.Host,
+		priority: syslogFacilities["authpriv"]*8 + syslogSeverityInfo,
+		hostname: "-",
+		appName:  syslogHeaderField(c.AppName, 48),
+	}
+	if s.appName == "-" {
+		s.appName = "sourcegraph"
+	}
+	if facility, ok := syslogFacilities[c.Facility]; ok {
+		s.priority = facility*8 + syslogSeverityInfo
+	}
+	if hostname, err := os.Hostname(); err == nil {
+		s.hostname = syslogHeaderField(hostname, 255)
+	}
+
+	switch u.Scheme {
+	case "udp", "tcp":
+	case "tls":
+		s.network = "tcp"
+		host, _, err := net.SplitHostPort(u.Host)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid syslog address")
+		}
+		s.tlsConfig = &tls.Config{ServerName: host}
+		if c.CaCertFile != "" {
+			pem, err := os.ReadFile(c.CaCertFile)
+			if err != nil {
+				return nil, errors.Wrap(err, "reading syslog CA certificate")
+			}
+			s.tlsConfig.RootCAs = x509.NewCertPool()
+			if !s.tlsConfig.RootCAs.AppendCertsFromPEM(pem) {
+				return nil, errors.Errorf("no certificates found in %s", c.CaCertFile)
+			}
+		}
+	default:
+		return nil, errors.Errorf("unsupported syslog protocol %q", u.Scheme)
+	}
+	return s, nil
+}
+
+func (s *syslogSink) Send(ctx context.Context, events []*database.SecurityEvent) error {
+	if s.conn == nil {
+		if err := s.dial(ctx); err != nil {
+			return err
+		}
+	}
+
+	for _, e := range events {
+		msg, err := s.message(e)
+		if err != nil {
+			return err
+		}
+		if s.network != "udp" {
+			// Octet counting framing, as specified in RFC 6587.
+			msg = append([]byte(strconv.Itoa(len(msg))+" "), msg...)
+		}
+
+		if err := s.conn.SetWriteDeadline(time.Now().Add(syslogTimeout)); err != nil {
+			return err
+		}
+		if _, err := s.conn.Write(msg); err != nil {
+			// Reconnect on the next attempt.
+			_ = s.Close()
+			return errors.Wrap(err, "writing syslog message")
+		}
+	}
+	return nil
+}
+
+func (s *syslogSink) dial(ctx context.Context) (err error) {
+	dialer := &net.Dialer{Timeout: syslogTimeout}
+	if s.tlsConfig != nil {
+		s.conn, err = (&tls.Dialer{NetDialer: dialer, Config: s.tlsConfig}).DialContext(ctx, s.network, s.address)
+	} else {
+		s.conn, err = dialer.DialContext(ctx, s.network, s.address)
+	}
+	return errors.Wrap(err, "connecting to syslog server")
+}
+
+// message formats the event as an RFC 5424 syslog message, without structured data.
+func (s *syslogSink) message(e *database.SecurityEvent) ([]byte, error) {
+	body, err := marshalEvent(e)
+	if err != nil {
+		return nil, err
+	}
+	header := fmt.Sprintf("<%d>1 %s %s %s - %s - ",
+		s.priority,
+		e.Timestamp.UTC().Format(syslogTimeFormat),
+		s.hostname,
+		s.appName,
+		syslogHeaderField(string(e.Name), 32),
+	)
+	return append([]byte(header), body...), nil
+}
+
+func (s *syslogSink) Close() error {
+	if s.conn == nil {
+		return nil
+	}
+	err := s.conn.Close()
+	s.conn = nil
+	return err
+}
+
+// syslogHeaderField returns v as a syslog header field, which is at most max printable ASCII
+// characters, or "-" when empty.
+func syslogHeaderField(v string, max int) string {
+	b := make([]byte, 0, len(v))
+	for i := 0; i < len(v) && len(b) < max; i++ {
+		if c := v[i]; c > ' ' && c <= '~' {
+			b = append(b, c)
+		}
+	}
+	if len(b) == 0 {
+		return "-"
+	}
+	return string(b)
+}
@@ -2,12 +2,20 @@ package authz
 
 const (
 	// Access token scopes.
-	ScopeUserAll       = "user:all"        // Full control of all resources accessible to the user account.
-	ScopeSiteAdminSudo = "site-admin:sudo" // Ability to perform any action as any other user.
+	ScopeUserAll           = "user:all"            // Full control of all resources accessible to the user account.
+	ScopeSiteAdminSudo     = "site-admin:sudo"     // Ability to perform any action as any other user.
+	ScopeAPIRead           = "api:read"            // Read-only access to the GraphQL and search APIs.
+	ScopeCodeIntelUpload   = "codeintel:upload"    // Ability to upload code intelligence indexes.
+	ScopeBatchChangesWrite = "batch-changes:write" // Ability to read the API and to create and apply batch changes.
+	ScopeExecutor          = "executor"            // Ability to authenticate executors against the executor queue API.
 )
 
 // AllScopes is a list of all known access token scopes.
 var AllScopes = []string{
 	ScopeUserAll,
 	ScopeSiteAdminSudo,
+	ScopeAPIRead,
+	ScopeCodeIntelUpload,
+	ScopeBatchChangesWrite,
+	ScopeExecutor,
 }
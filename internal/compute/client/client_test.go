@@ -0,0 +1,105 @@
+package client
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/sourcegraph/sourcegraph/internal/compute"
+	"github.com/sourcegraph/sourcegraph/internal/search/streaming/api"
+	streamhttp "github.com/sourcegraph/sourcegraph/internal/search/streaming/http"
+)
+
+func TestComputeClient(t *testing.T) {
+	type Event struct {
+		Name  string
+		Value interface{}
+	}
+
+	want := []Event{{
+		Name: "progress",
+		Value: &api.Progress{
+			MatchCount: 5,
+		},
+	}, {
+		Name: "results",
+		Value: []EventResult{
+			&EventMatchContext{
+				Type:       MatchContextType,
+				Repository: "test",
+				Path:       "test",
+				Matches: []compute.Match{{
+					Value:       "test",
+					Environment: compute.Environment{"1": {Value: "t"}},
+				}},
+			},
+			&EventText{
+				Type:  TextType,
+				Path:  "test",
+				Kind:  "output",
+				Value: "test",
+			},
+		},
+	}, {
+		Name: "alert",
+		Value: &streamhttp.EventAlert{
+			Title: "alert",
+		},
+	}, {
+		Name: "error",
+		Value: &streamhttp.EventError{
+			Message: "error",
+		},
+	}}
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ew, err := streamhttp.NewWriter(w)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		for _, e := range want {
+			ew.Event(e.Name, e.Value)
+		}
+		ew.Event("done", struct{}{})
+	}))
+	defer ts.Close()
+
+	req, err := NewRequest(ts.URL, "hello world")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	var got []Event
+	err = ComputeStreamDecoder{
+		OnProgress: func(d *api.Progress) {
+			got = append(got, Event{Name: "progress", Value: d})
+		},
+		OnResults: func(d []EventResult) {
+			got = append(got, Event{Name: "results", Value: d})
+		},
+		OnAlert: func(d *streamhttp.EventAlert) {
+			got = append(got, Event{Name: "alert", Value: d})
+		},
+		OnError: func(d *streamhttp.EventError) {
+			got = append(got, Event{Name: "error", Value: d})
+		},
+		OnUnknown: func(event, data []byte) {
+			t.Fatalf("got unexpected event: %s %s", event, data)
+		},
+	}.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if d := cmp.Diff(want, got); d != "" {
+		t.Fatalf("mismatch (-want +got):\n%s", d)
+	}
+}
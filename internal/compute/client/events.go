@@ -0,0 +1,77 @@
+package client
+
+import (
+	"encoding/json"
+
+	"github.com/cockroachdb/errors"
+
+	"github.com/sourcegraph/sourcegraph/internal/compute"
+)
+
+// ResultType is the type of a compute result in a "results" event.
+type ResultType string
+
+const (
+	MatchContextType ResultType = "matchContext"
+	TextType         ResultType = "text"
+)
+
+// EventResult is an interface which only the compute result event types
+// implement. Use this for your results slice rather than interface{}.
+type EventResult interface {
+	// private marker method so only compute result event types are allowed.
+	eventResult()
+}
+
+// EventMatchContext is a compute.MatchContext for our Event API.
+type EventMatchContext struct {
+	// Type is always MatchContextType. Included here for marshalling.
+	Type ResultType `json:"type"`
+
+	Repository   string          `json:"repository"`
+	RepositoryID int32           `json:"repositoryID"`
+	Commit       string          `json:"commit,omitempty"`
+	Path         string          `json:"path"`
+	Matches      []compute.Match `json:"matches"`
+}
+
+func (e *EventMatchContext) eventResult() {}
+
+// EventText is a compute.Text for our Event API.
+type EventText struct {
+	// Type is always TextType. Included here for marshalling.
+	Type ResultType `json:"type"`
+
+	Repository   string `json:"repository,omitempty"`
+	RepositoryID int32  `json:"repositoryID,omitempty"`
+	Commit       string `json:"commit,omitempty"`
+	Path         string `json:"path,omitempty"`
+	Kind         string `json:"kind,omitempty"`
+	Value        string `json:"value"`
+}
+
+func (e *EventText) eventResult() {}
+
+type eventResultUnmarshaller struct {
+	EventResult
+}
+
+func (r *eventResultUnmarshaller) UnmarshalJSON(b []byte) error {
+	var typeU struct {
+		Type ResultType `json:"type"`
+	}
+
+	if err := json.Unmarshal(b, &typeU); err != nil {
+		return err
+	}
+
+	switch typeU.Type {
+	case MatchContextType:
+		r.EventResult = &EventMatchContext{}
+	case TextType:
+		r.EventResult = &EventText{}
+	default:
+		return errors.Errorf("unknown ResultType %v", typeU.Type)
+	}
+	return json.Unmarshal(b, r.EventResult)
+}
@@ -0,0 +1,156 @@
+package compute
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/cockroachdb/errors"
+
+	"github.com/sourcegraph/sourcegraph/internal/search/result"
+	"github.com/sourcegraph/sourcegraph/internal/vcs/git"
+)
+
+// Command is a computation to run on each search result of a compute query.
+type Command interface {
+	command()
+	String() string
+}
+
+var (
+	_ Command = (*MatchOnly)(nil)
+	_ Command = (*Replace)(nil)
+	_ Command = (*Output)(nil)
+)
+
+func (MatchOnly) command() {}
+func (Replace) command()   {}
+func (Output) command()    {}
+
+// MatchOnly returns the match context of MatchPattern in a file. It is the
+// command that runs when a compute query does not specify one.
+type MatchOnly struct {
+	MatchPattern *regexp.Regexp
+}
+
+// Replace replaces every match of MatchPattern in a file with ReplacePattern,
+// a template that may refer to capture groups of MatchPattern.
+type Replace struct {
+	MatchPattern   *regexp.Regexp
+	ReplacePattern string
+}
+
+// Output expands the template OutputPattern for every match of MatchPattern
+// in a file.
+type Output struct {
+	MatchPattern  *regexp.Regexp
+	OutputPattern string
+}
+
+func (c MatchOnly) String() string {
+	return fmt.Sprintf("Match only: %s", c.MatchPattern.String())
+}
+
+func (c Replace) String() string {
+	return fmt.Sprintf("Replace in place: (%s) -> (%s)", c.MatchPattern.String(), c.ReplacePattern)
+}
+
+func (c Output) String() string {
+	return fmt.Sprintf("Output: (%s) -> (%s)", c.MatchPattern.String(), c.OutputPattern)
+}
+
+// Result is the result of running a command on a search result. It is either
+// a *MatchContext or a *Text.
+type Result interface {
+	result()
+}
+
+func (*MatchContext) result() {}
+func (*Text) result()         {}
+
+// Kinds of Text values produced by commands.
+const (
+	KindReplaceInPlace = "replace-in-place"
+	KindOutput         = "output"
+	// KindError is the kind of Text values reporting that a command failed
+	// on a file. Their value is the error message.
+	KindError = "error"
+)
+
+// maxFileSize is the largest file that Replace rewrites.
+const maxFileSize = 1 << 20
+
+// Run runs the command on a file match. It returns a nil Result if the
+// command produces nothing for the file. If the command fails on the file, it
+// returns a Text of KindError so that other files are still computed; only
+// context errors are returned as errors.
+func Run(ctx context.Context, cmd Command, fm *result.FileMatch) (Result, error) {
+	switch c := cmd.(type) {
+	case *MatchOnly:
+		return FromFileMatch(fm, c.MatchPattern), nil
+	case *Replace:
+		t, err := c.run(ctx, fm)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			return &Text{Value: err.Error(), Kind: KindError}, nil
+		}
+		return t, nil
+	case *Output:
+		return c.run(fm), nil
+	}
+	return nil, nil
+}
+
+func (c *Replace) run(ctx context.Context, fm *result.FileMatch) (*Text, error) {
+	// git.ReadFile silently truncates files to maxFileSize, so we check the
+	// size first rather than return a truncated rewrite.
+	fi, err := git.Stat(ctx, fm.Repo.Name, fm.CommitID, fm.Path)
+	if err != nil {
+		return nil, err
+	}
+	if fi.Size() > maxFileSize {
+		return nil, errors.Errorf("file is larger than the maximum size of %d bytes for replace", maxFileSize)
+	}
+
+	content, err := git.ReadFile(ctx, fm.Repo.Name, fm.CommitID, fm.Path, maxFileSize)
+	if err != nil {
+		return nil, err
+	}
+	return &Text{
+		Value: replace(string(content), c.MatchPattern, c.ReplacePattern, builtinVariables(fm)),
+		Kind:  KindReplaceInPlace,
+	}, nil
+}
+
+func (c *Output) run(fm *result.FileMatch) *Text {
+	builtins := builtinVariables(fm)
+	var values []string
+	for _, l := range fm.LineMatches {
+		for _, m := range c.MatchPattern.FindAllStringSubmatchIndex(l.Preview, -1) {
+			env := fromRegexpMatches([][]int{m}, c.MatchPattern.SubexpNames(), l.Preview, int(l.LineNumber)).Environment
+			values = append(values, substituteMetaVariables(c.OutputPattern, env, builtins))
+		}
+	}
+	if len(values) == 0 {
+		return nil
+	}
+	return &Text{Value: strings.Join(values, "\n"), Kind: KindOutput}
+}
+
+// replace returns content with every match of pattern replaced by the
+// expanded template.
+func replace(content string, pattern *regexp.Regexp, template string, builtins map[string]string) string {
+	var b strings.Builder
+	last := 0
+	for _, m := range pattern.FindAllStringSubmatchIndex(content, -1) {
+		env := fromRegexpMatches([][]int{m}, pattern.SubexpNames(), content, -1).Environment
+		b.WriteString(content[last:m[0]])
+		b.WriteString(substituteMetaVariables(template, env, builtins))
+		last = m[1]
+	}
+	b.WriteString(content[last:])
+	return b.String()
+}
@@ -0,0 +1,109 @@
+package compute
+
+import (
+	"context"
+	"io/fs"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/hexops/autogold"
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/search/result"
+	"github.com/sourcegraph/sourcegraph/internal/types"
+	"github.com/sourcegraph/sourcegraph/internal/vcs/git"
+	"github.com/sourcegraph/sourcegraph/internal/vcs/util"
+)
+
+func TestSubstituteMetaVariables(t *testing.T) {
+	env := Environment{
+		"1":    Data{Value: "one"},
+		"name": Data{Value: "named"},
+	}
+	builtins := map[string]string{"repo": "github.com/foo/bar"}
+	test := func(template string) string {
+		return substituteMetaVariables(template, env, builtins)
+	}
+
+	autogold.Want("numbered", "one").Equal(t, test("$1"))
+	autogold.Want("braces", "named!").Equal(t, test("${name}!"))
+	autogold.Want("comby hole", "named one").Equal(t, test(":[name] :[1]"))
+	autogold.Want("builtin", "github.com/foo/bar: one").Equal(t, test("$repo: $1"))
+	autogold.Want("unknown variable", "$2 :[x]").Equal(t, test("$2 :[x]"))
+}
+
+func TestReplace(t *testing.T) {
+	content := "foo(a, b)\nfoo(c)\nbar(d)\n"
+	got := replace(content, regexp.MustCompile(`foo\((\w+)`), "baz(:[1]", nil)
+	autogold.Want("replace all matches", "baz(a, b)\nbaz(c)\nbar(d)\n").Equal(t, got)
+}
+
+func TestOutput(t *testing.T) {
+	fm := &result.FileMatch{
+		File: result.File{
+			Repo: types.RepoName{Name: "github.com/foo/bar"},
+			Path: "flags.go",
+		},
+		LineMatches: []*result.LineMatch{
+			{Preview: `IsEnabled("a") || IsEnabled("b")`, LineNumber: 1},
+			{Preview: `IsEnabled("c")`, LineNumber: 5},
+		},
+	}
+	cmd := &Output{
+		MatchPattern:  regexp.MustCompile(`IsEnabled\("(?P<key>\w+)"\)`),
+		OutputPattern: "$path: ${key}",
+	}
+	autogold.Want("output every match", &Text{
+		Value: "flags.go: a\nflags.go: b\nflags.go: c",
+		Kind:  "output",
+	}).Equal(t, cmd.run(fm))
+
+	cmd.MatchPattern = regexp.MustCompile("nothing")
+	if got := cmd.run(fm); got != nil {
+		t.Fatalf("expected no output, got %v", got)
+	}
+}
+
+func TestRunReplace(t *testing.T) {
+	t.Cleanup(git.ResetMocks)
+	files := map[string]string{
+		"a.go":   "foo(a)\n",
+		"big.go": strings.Repeat("foo(a)\n", maxFileSize/7+1),
+	}
+	git.Mocks.Stat = func(commit api.CommitID, name string) (fs.FileInfo, error) {
+		content, ok := files[name]
+		if !ok {
+			return nil, &fs.PathError{Op: "ls-tree", Path: name, Err: fs.ErrNotExist}
+		}
+		return &util.FileInfo{Name_: name, Size_: int64(len(content))}, nil
+	}
+	git.Mocks.ReadFile = func(commit api.CommitID, name string) ([]byte, error) {
+		return []byte(files[name]), nil
+	}
+
+	cmd := &Replace{MatchPattern: regexp.MustCompile(`foo`), ReplacePattern: "bar"}
+	run := func(path string) Result {
+		fm := &result.FileMatch{File: result.File{Repo: types.RepoName{Name: "github.com/foo/bar"}, Path: path}}
+		r, err := Run(context.Background(), cmd, fm)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return r
+	}
+
+	autogold.Want("rewrite file", &Text{Value: "bar(a)\n", Kind: "replace-in-place"}).Equal(t, run("a.go"))
+	autogold.Want("file too large", &Text{
+		Value: "file is larger than the maximum size of 1048576 bytes for replace",
+		Kind:  "error",
+	}).Equal(t, run("big.go"))
+	autogold.Want("missing file", &Text{Value: "ls-tree missing.go: file does not exist", Kind: "error"}).Equal(t, run("missing.go"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	git.Mocks.Stat = func(commit api.CommitID, name string) (fs.FileInfo, error) {
+		return nil, ctx.Err()
+	}
+	if _, err := Run(ctx, cmd, &result.FileMatch{}); err != context.Canceled {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+}
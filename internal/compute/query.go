@@ -0,0 +1,129 @@
+package compute
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/cockroachdb/errors"
+
+	"github.com/sourcegraph/sourcegraph/internal/search/query"
+)
+
+// Query is a parsed compute query. It consists of a search query, and a
+// command to run on each of its results.
+type Query struct {
+	// SearchQuery is the search query whose results the command runs on.
+	SearchQuery string
+	Command     Command
+}
+
+// commandNames are the names of the commands that a compute query may
+// contain, like replace(pattern -> template).
+var commandNames = []string{"replace", "output"}
+
+// Parse parses a compute query. A compute query is a search query that may
+// contain one command of the form
+//
+//	replace(<pattern> -> <template>)
+//	output(<pattern> -> <template>)
+//
+// where <pattern> is a regular expression to match in file contents, and
+// <template> may refer to the capture groups of <pattern> as $1, ${name} or
+// :[name], and to the builtin variables $repo, $path and $commit. A query
+// without a command returns the match context of its pattern.
+func Parse(q string) (*Query, error) {
+	name, args, rest, err := scanCommand(q)
+	if err != nil {
+		return nil, err
+	}
+	if name == "" {
+		pattern, err := regexpFromQuery(q)
+		if err != nil {
+			return nil, err
+		}
+		return &Query{SearchQuery: q, Command: &MatchOnly{MatchPattern: pattern}}, nil
+	}
+
+	parts := strings.SplitN(args, " -> ", 2)
+	if len(parts) != 2 || parts[0] == "" {
+		return nil, errors.Errorf("invalid %s command: expected %s(<pattern> -> <template>)", name, name)
+	}
+
+	// The command pattern is what the search has to match, so add it to the
+	// rest of the query as a content pattern.
+	searchQuery := strings.TrimSpace(rest + " content:" + strconv.Quote(parts[0]))
+	pattern, err := regexpFromQuery(searchQuery)
+	if err != nil {
+		return nil, err
+	}
+
+	var command Command
+	switch name {
+	case "replace":
+		command = &Replace{MatchPattern: pattern, ReplacePattern: parts[1]}
+	case "output":
+		command = &Output{MatchPattern: pattern, OutputPattern: parts[1]}
+	}
+	return &Query{SearchQuery: searchQuery, Command: command}, nil
+}
+
+// scanCommand finds the command in q. It returns the command name and its
+// arguments, and q without the command. The name is empty if q does not
+// contain a command.
+func scanCommand(q string) (name, args, rest string, err error) {
+	for i := 0; i < len(q); i++ {
+		if i > 0 && !unicode.IsSpace(rune(q[i-1])) {
+			continue
+		}
+		for _, candidate := range commandNames {
+			if !strings.HasPrefix(q[i:], candidate+"(") {
+				continue
+			}
+			if name != "" {
+				return "", "", "", errors.New("compute queries support only one command")
+			}
+			value, advance, ok := query.ScanBalancedParens([]byte(q[i+len(candidate):]))
+			if !ok {
+				return "", "", "", errors.Errorf("unbalanced parentheses in %s command", candidate)
+			}
+			end := i + len(candidate) + advance
+			name = candidate
+			args = value[1 : len(value)-1]
+			rest = strings.TrimSpace(q[:i]) + " " + strings.TrimSpace(q[end:])
+			q = q[:i] + strings.Repeat(" ", end-i) + q[end:]
+		}
+	}
+	return name, args, strings.TrimSpace(rest), nil
+}
+
+// regexpFromQuery returns the regular expression of the single pattern in q.
+func regexpFromQuery(q string) (*regexp.Regexp, error) {
+	plan, err := query.Pipeline(query.Init(q, query.SearchTypeRegex))
+	if err != nil {
+		return nil, err
+	}
+	if len(plan) != 1 {
+		return nil, errors.New("compute endpoint only supports one search pattern currently ('and' or 'or' operators are not supported yet)")
+	}
+	switch node := plan[0].Pattern.(type) {
+	case query.Operator:
+		if len(node.Operands) == 1 {
+			if pattern, ok := node.Operands[0].(query.Pattern); ok && !pattern.Negated {
+				rp, err := regexp.Compile(pattern.Value)
+				if err != nil {
+					return nil, errors.Wrap(err, "regular expression is not valid for compute endpoint")
+				}
+				return rp, nil
+			}
+		}
+		return nil, errors.New("compute endpoint only supports one search pattern currently ('and' or 'or' operators are not supported yet)")
+	case query.Pattern:
+		if !node.Negated {
+			return regexp.Compile(node.Value)
+		}
+	}
+	// unreachable
+	return nil, nil
+}
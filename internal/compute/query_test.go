@@ -0,0 +1,24 @@
+package compute
+
+import (
+	"testing"
+
+	"github.com/hexops/autogold"
+)
+
+func TestParse(t *testing.T) {
+	test := func(input string) string {
+		q, err := Parse(input)
+		if err != nil {
+			return err.Error()
+		}
+		return q.SearchQuery + " => " + q.Command.String()
+	}
+
+	autogold.Want("no command", `repo:foo a(b) => Match only: a(b)`).Equal(t, test("repo:foo a(b)"))
+	autogold.Want("replace command", `repo:foo content:"a(\\w+)" => Replace in place: (a(\w+)) -> (b$1)`).Equal(t, test(`repo:foo replace(a(\w+) -> b$1)`))
+	autogold.Want("output command", `lang:go file:flags content:"flag\\.(?P<key>\\w+)" => Output: (flag\.(?P<key>\w+)) -> (${key})`).Equal(t, test(`lang:go output(flag\.(?P<key>\w+) -> ${key}) file:flags`))
+	autogold.Want("missing template", `invalid output command: expected output(<pattern> -> <template>)`).Equal(t, test("output(foo)"))
+	autogold.Want("two commands", `compute queries support only one command`).Equal(t, test("output(a -> b) replace(a -> b)"))
+	autogold.Want("command and pattern", `compute endpoint only supports one search pattern currently ('and' or 'or' operators are not supported yet)`).Equal(t, test("foo output(a -> b)"))
+}
@@ -0,0 +1,44 @@
+package compute
+
+import (
+	"regexp"
+
+	"github.com/sourcegraph/sourcegraph/internal/search/result"
+)
+
+// metaVariable matches a reference to a variable in a template. Variables
+// may be written as $name, ${name}, or with comby syntax as :[name].
+var metaVariable = regexp.MustCompile(`\$\{(\w+)\}|\$(\w+)|:\[(\w+)\]`)
+
+// builtinVariables returns the variables that templates may refer to in
+// addition to the capture groups of a match.
+func builtinVariables(fm *result.FileMatch) map[string]string {
+	return map[string]string{
+		"repo":   string(fm.Repo.Name),
+		"path":   fm.Path,
+		"commit": string(fm.CommitID),
+	}
+}
+
+// substituteMetaVariables expands the variables in template with their values
+// in env, falling back to builtins. References to unknown variables are left
+// as-is.
+func substituteMetaVariables(template string, env Environment, builtins map[string]string) string {
+	return metaVariable.ReplaceAllStringFunc(template, func(ref string) string {
+		name := metaVariable.FindStringSubmatch(ref)
+		var variable string
+		for _, v := range name[1:] {
+			if v != "" {
+				variable = v
+				break
+			}
+		}
+		if data, ok := env[variable]; ok {
+			return data.Value
+		}
+		if value, ok := builtins[variable]; ok {
+			return value
+		}
+		return ref
+	})
+}
@@ -0,0 +1,49 @@
+package reposource
+
+import (
+	"testing"
+
+	"github.com/sourcegraph/sourcegraph/schema"
+)
+
+func TestGerrit_cloneURLToRepoName(t *testing.T) {
+	tests := []struct {
+		conn schema.GerritConnection
+		urls []urlToRepoName
+	}{{
+		conn: schema.GerritConnection{
+			Url: "https://gerrit.sgdev.org",
+		},
+		urls: []urlToRepoName{
+			{"https://gerrit.sgdev.org/platform/build", "gerrit.sgdev.org/platform/build"},
+			{"https://admin@gerrit.sgdev.org/a/platform/build", "gerrit.sgdev.org/platform/build"},
+			{"ssh://admin@gerrit.sgdev.org:29418/platform/build.git", "gerrit.sgdev.org/platform/build"},
+
+			{"https://gerrit.sgdev.org/", ""},
+			{"https://asdf.org/platform/build", ""},
+			{"ssh://admin@asdf.org:29418/platform/build", ""},
+		},
+	}, {
+		conn: schema.GerritConnection{
+			Url:                   "https://gerrit.sgdev.org/r/",
+			RepositoryPathPattern: "gerrit/{name}",
+		},
+		urls: []urlToRepoName{
+			{"https://gerrit.sgdev.org/r/platform/build", "gerrit/platform/build"},
+			{"https://admin@gerrit.sgdev.org/r/a/platform/build", "gerrit/platform/build"},
+			{"ssh://admin@gerrit.sgdev.org:29418/platform/build", "gerrit/platform/build"},
+		},
+	}}
+
+	for _, test := range tests {
+		for _, u := range test.urls {
+			repoName, err := Gerrit{&test.conn}.CloneURLToRepoName(u.cloneURL)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if u.repoName != string(repoName) {
+				t.Errorf("expected %q but got %q for clone URL %q (connection: %+v)", u.repoName, repoName, u.cloneURL, test.conn)
+			}
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package reposource
+
+import (
+	"strings"
+
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/schema"
+)
+
+type Gitea struct {
+	*schema.GiteaConnection
+}
+
+var _ RepoSource = Gitea{}
+
+func (c Gitea) CloneURLToRepoName(cloneURL string) (repoName api.RepoName, err error) {
+	parsedCloneURL, baseURL, match, err := parseURLs(cloneURL, c.Url)
+	if err != nil {
+		return "", err
+	}
+	if !match {
+		return "", nil
+	}
+
+	nameWithOwner := strings.TrimSuffix(parsedCloneURL.Path, ".git")
+	if strings.HasPrefix(parsedCloneURL.Scheme, "http") {
+		// HTTP clone URLs are relative to the base URL, which may have a
+		// path prefix.
+		nameWithOwner = strings.TrimPrefix(nameWithOwner, strings.TrimSuffix(baseURL.Path, "/"))
+	}
+	nameWithOwner = strings.Trim(nameWithOwner, "/")
+	if strings.Count(nameWithOwner, "/") != 1 {
+		return "", nil
+	}
+
+	return GiteaRepoName(c.RepositoryPathPattern, baseURL.Hostname(), nameWithOwner), nil
+}
+
+func GiteaRepoName(repositoryPathPattern, host, nameWithOwner string) api.RepoName {
+	if repositoryPathPattern == "" {
+		repositoryPathPattern = "{host}/{nameWithOwner}"
+	}
+	return api.RepoName(strings.NewReplacer(
+		"{host}", host,
+		"{nameWithOwner}", nameWithOwner,
+	).Replace(repositoryPathPattern))
+}
@@ -0,0 +1,49 @@
+package reposource
+
+import (
+	"testing"
+
+	"github.com/sourcegraph/sourcegraph/schema"
+)
+
+func TestGitea_cloneURLToRepoName(t *testing.T) {
+	tests := []struct {
+		conn schema.GiteaConnection
+		urls []urlToRepoName
+	}{{
+		conn: schema.GiteaConnection{
+			Url: "https://gitea.sgdev.org",
+		},
+		urls: []urlToRepoName{
+			{"https://gitea.sgdev.org/sourcegraph/src-cli.git", "gitea.sgdev.org/sourcegraph/src-cli"},
+			{"https://token@gitea.sgdev.org/sourcegraph/src-cli", "gitea.sgdev.org/sourcegraph/src-cli"},
+			{"git@gitea.sgdev.org:sourcegraph/src-cli.git", "gitea.sgdev.org/sourcegraph/src-cli"},
+			{"ssh://git@gitea.sgdev.org:2222/sourcegraph/src-cli.git", "gitea.sgdev.org/sourcegraph/src-cli"},
+
+			{"https://gitea.sgdev.org/sourcegraph", ""},
+			{"https://asdf.org/sourcegraph/src-cli.git", ""},
+			{"git@asdf.org:sourcegraph/src-cli.git", ""},
+		},
+	}, {
+		conn: schema.GiteaConnection{
+			Url:                   "https://gitea.sgdev.org/git/",
+			RepositoryPathPattern: "gitea/{nameWithOwner}",
+		},
+		urls: []urlToRepoName{
+			{"https://gitea.sgdev.org/git/sourcegraph/src-cli.git", "gitea/sourcegraph/src-cli"},
+			{"git@gitea.sgdev.org:sourcegraph/src-cli.git", "gitea/sourcegraph/src-cli"},
+		},
+	}}
+
+	for _, test := range tests {
+		for _, u := range test.urls {
+			repoName, err := Gitea{&test.conn}.CloneURLToRepoName(u.cloneURL)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if u.repoName != string(repoName) {
+				t.Errorf("expected %q but got %q for clone URL %q (connection: %+v)", u.repoName, repoName, u.cloneURL, test.conn)
+			}
+		}
+	}
+}
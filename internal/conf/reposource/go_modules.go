@@ -0,0 +1,108 @@
+package reposource
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	"golang.org/x/mod/module"
+	"golang.org/x/mod/semver"
+
+	"github.com/sourcegraph/sourcegraph/internal/api"
+)
+
+// GoModule is a Go module, identified by its module path.
+type GoModule struct {
+	Path string
+}
+
+func NewGoModule(path string) (GoModule, error) {
+	if err := module.CheckPath(path); err != nil {
+		return GoModule{}, fmt.Errorf("invalid Go module path %q: %s", path, err)
+	}
+	return GoModule{Path: path}, nil
+}
+
+// EscapedPath returns the module path escaped as described in
+// https://golang.org/ref/mod#goproxy-protocol, for use in module proxy URLs.
+func (m *GoModule) EscapedPath() string {
+	// The path was validated in NewGoModule, so escaping can't fail.
+	escaped, _ := module.EscapePath(m.Path)
+	return escaped
+}
+
+func (m *GoModule) MatchesDependencyString(dependency string) bool {
+	return dependency == m.Path || strings.HasPrefix(dependency, m.Path+"@")
+}
+
+func (m *GoModule) RepoName() api.RepoName {
+	return api.RepoName("go/" + m.Path)
+}
+
+func (m *GoModule) CloneURL() string {
+	cloneURL := url.URL{Path: string(m.RepoName())}
+	return cloneURL.String()
+}
+
+// ParseGoModuleFromRepoURL returns a parsed Go module from the provided URL
+// path, without a leading `/`.
+func ParseGoModuleFromRepoURL(urlPath string) (GoModule, error) {
+	if !strings.HasPrefix(urlPath, "go/") {
+		return GoModule{}, fmt.Errorf("failed to parse a Go module from the path %s", urlPath)
+	}
+	return NewGoModule(strings.TrimPrefix(urlPath, "go/"))
+}
+
+// GoDependency is a specific version of a Go module. An empty Version stands
+// for all the versions of the module known to the module proxy.
+type GoDependency struct {
+	GoModule
+	Version string
+}
+
+// PackageManagerSyntax returns the dependency in the syntax used by the go
+// command, for example "golang.org/x/mod@v0.4.2".
+func (d GoDependency) PackageManagerSyntax() string {
+	if d.Version == "" {
+		return d.Path
+	}
+	return fmt.Sprintf("%s@%s", d.Path, d.Version)
+}
+
+// GitTagFromVersion returns the version as is, since Go module versions
+// already follow the "vX.Y.Z" convention of git tags.
+func (d GoDependency) GitTagFromVersion() string {
+	return d.Version
+}
+
+// ParseGoDependency parses a dependency string in the syntax of the go command
+// ("module@version") into a GoDependency. The version is optional.
+func ParseGoDependency(dependency string) (GoDependency, error) {
+	path, version := dependency, ""
+	if i := strings.LastIndex(dependency, "@"); i >= 0 {
+		path, version = dependency[:i], dependency[i+1:]
+		if err := module.Check(path, version); err != nil {
+			return GoDependency{}, fmt.Errorf("invalid Go dependency %q: %s", dependency, err)
+		}
+	}
+
+	mod, err := NewGoModule(path)
+	if err != nil {
+		return GoDependency{}, err
+	}
+
+	return GoDependency{GoModule: mod, Version: version}, nil
+}
+
+// SortGoDependencies sorts the dependencies by the semantic version in
+// descending order. The latest version of a dependency becomes the first
+// element of the slice.
+func SortGoDependencies(dependencies []GoDependency) {
+	sort.Slice(dependencies, func(i, j int) bool {
+		if dependencies[i].GoModule == dependencies[j].GoModule {
+			return semver.Compare(dependencies[i].Version, dependencies[j].Version) > 0
+		}
+		return dependencies[i].Path > dependencies[j].Path
+	})
+}
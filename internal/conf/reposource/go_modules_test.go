@@ -0,0 +1,99 @@
+package reposource
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/sourcegraph/sourcegraph/internal/api"
+)
+
+func TestParseGoDependency(t *testing.T) {
+	for _, tc := range []struct {
+		dependency string
+		want       GoDependency
+		repoName   api.RepoName
+		escaped    string
+	}{
+		{
+			dependency: "golang.org/x/mod@v0.4.2",
+			want:       GoDependency{GoModule: GoModule{Path: "golang.org/x/mod"}, Version: "v0.4.2"},
+			repoName:   "go/golang.org/x/mod",
+			escaped:    "golang.org/x/mod",
+		},
+		{
+			dependency: "github.com/BurntSushi/toml",
+			want:       GoDependency{GoModule: GoModule{Path: "github.com/BurntSushi/toml"}},
+			repoName:   "go/github.com/BurntSushi/toml",
+			escaped:    "github.com/!burnt!sushi/toml",
+		},
+		{
+			dependency: "github.com/go-redis/redis/v8@v8.11.4",
+			want:       GoDependency{GoModule: GoModule{Path: "github.com/go-redis/redis/v8"}, Version: "v8.11.4"},
+			repoName:   "go/github.com/go-redis/redis/v8",
+			escaped:    "github.com/go-redis/redis/v8",
+		},
+	} {
+		t.Run(tc.dependency, func(t *testing.T) {
+			have, err := ParseGoDependency(tc.dependency)
+			assert.Nil(t, err)
+			assert.Equal(t, tc.want, have)
+			assert.Equal(t, tc.dependency, have.PackageManagerSyntax())
+			assert.Equal(t, tc.repoName, have.RepoName())
+			assert.Equal(t, tc.escaped, have.EscapedPath())
+
+			mod, err := ParseGoModuleFromRepoURL(string(tc.repoName))
+			assert.Nil(t, err)
+			assert.Equal(t, tc.want.GoModule, mod)
+		})
+	}
+
+	for _, dependency := range []string{
+		"golang.org/x/mod@",
+		"golang.org/x/mod@latest",
+		"github.com/go-redis/redis@v8.11.4",
+		"../../etc@v1.0.0",
+		"@v1.0.0",
+	} {
+		if _, err := ParseGoDependency(dependency); err == nil {
+			t.Errorf("ParseGoDependency(%q): expected error", dependency)
+		}
+	}
+}
+
+func TestParseGoModuleFromRepoURL(t *testing.T) {
+	for _, urlPath := range []string{
+		"npm/react",
+		"go/",
+		"go/../etc",
+	} {
+		if _, err := ParseGoModuleFromRepoURL(urlPath); err == nil {
+			t.Errorf("ParseGoModuleFromRepoURL(%q): expected error", urlPath)
+		}
+	}
+}
+
+func TestSortGoDependencies(t *testing.T) {
+	parse := func(dependency string) GoDependency {
+		d, err := ParseGoDependency(dependency)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return d
+	}
+
+	dependencies := []GoDependency{
+		parse("example.com/a@v1.2.0"),
+		parse("example.com/b@v1.2.0-rc.1"),
+		parse("example.com/b@v1.11.0"),
+		parse("example.com/b@v1.2.0"),
+	}
+	expected := []GoDependency{
+		parse("example.com/b@v1.11.0"),
+		parse("example.com/b@v1.2.0"),
+		parse("example.com/b@v1.2.0-rc.1"),
+		parse("example.com/a@v1.2.0"),
+	}
+	SortGoDependencies(dependencies)
+	assert.Equal(t, expected, dependencies)
+}
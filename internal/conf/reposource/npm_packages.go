@@ -0,0 +1,141 @@
+package reposource
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/sourcegraph/sourcegraph/internal/api"
+)
+
+// npmNameRegex matches both the scope and the name of an npm package. See
+// https://github.com/npm/validate-npm-package-name for the full set of rules;
+// this is a looser approximation that still guarantees the names are safe to
+// use as repository name path components.
+var npmNameRegex = regexp.MustCompile(`^[a-zA-Z0-9~-][a-zA-Z0-9._~-]*$`)
+
+// NPMPackage is an npm package, identified by its optional scope and its name.
+type NPMPackage struct {
+	// Scope is the scope of the package, without the leading "@". It is empty
+	// for unscoped packages.
+	Scope string
+	Name  string
+}
+
+func NewNPMPackage(scope, name string) (NPMPackage, error) {
+	if scope != "" && !npmNameRegex.MatchString(scope) {
+		return NPMPackage{}, fmt.Errorf("invalid npm package scope %q", scope)
+	}
+	if !npmNameRegex.MatchString(name) {
+		return NPMPackage{}, fmt.Errorf("invalid npm package name %q", name)
+	}
+	return NPMPackage{Scope: scope, Name: name}, nil
+}
+
+// PackageSyntax returns the name of the package as used by npm, for example
+// "@types/node" or "react".
+func (p *NPMPackage) PackageSyntax() string {
+	if p.Scope == "" {
+		return p.Name
+	}
+	return fmt.Sprintf("@%s/%s", p.Scope, p.Name)
+}
+
+func (p *NPMPackage) MatchesDependencyString(dependency string) bool {
+	return strings.HasPrefix(dependency, p.PackageSyntax()+"@")
+}
+
+func (p *NPMPackage) SortText() string {
+	return p.PackageSyntax()
+}
+
+func (p *NPMPackage) RepoName() api.RepoName {
+	if p.Scope == "" {
+		return api.RepoName("npm/" + p.Name)
+	}
+	return api.RepoName(fmt.Sprintf("npm/%s/%s", p.Scope, p.Name))
+}
+
+func (p *NPMPackage) CloneURL() string {
+	cloneURL := url.URL{Path: string(p.RepoName())}
+	return cloneURL.String()
+}
+
+// ParseNPMPackage parses a package name in the npm syntax ("@scope/name" or
+// "name") into an NPMPackage.
+func ParseNPMPackage(pkg string) (NPMPackage, error) {
+	if !strings.HasPrefix(pkg, "@") {
+		return NewNPMPackage("", pkg)
+	}
+	parts := strings.SplitN(strings.TrimPrefix(pkg, "@"), "/", 2)
+	if len(parts) != 2 {
+		return NPMPackage{}, fmt.Errorf("scoped npm package %q must contain a '/' character", pkg)
+	}
+	return NewNPMPackage(parts[0], parts[1])
+}
+
+// ParseNPMPackageFromRepoURL returns a parsed npm package from the provided URL
+// path, without a leading `/`.
+func ParseNPMPackageFromRepoURL(urlPath string) (NPMPackage, error) {
+	parts := strings.Split(strings.TrimPrefix(urlPath, "npm/"), "/")
+	switch len(parts) {
+	case 1:
+		return NewNPMPackage("", parts[0])
+	case 2:
+		return NewNPMPackage(parts[0], parts[1])
+	default:
+		return NPMPackage{}, fmt.Errorf("failed to parse an npm package from the path %s", urlPath)
+	}
+}
+
+// NPMDependency is a specific version of an npm package.
+type NPMDependency struct {
+	NPMPackage
+	Version string
+}
+
+// PackageManagerSyntax returns the dependency in the syntax used by npm, for
+// example "@types/node@16.11.1".
+func (d NPMDependency) PackageManagerSyntax() string {
+	return fmt.Sprintf("%s@%s", d.NPMPackage.PackageSyntax(), d.Version)
+}
+
+func (d NPMDependency) GitTagFromVersion() string {
+	return "v" + d.Version
+}
+
+// ParseNPMDependency parses a dependency string in the npm syntax
+// ("@scope/name@version" or "name@version") into an NPMDependency.
+func ParseNPMDependency(dependency string) (NPMDependency, error) {
+	// The scope starts with an "@" too, so look for the last one.
+	i := strings.LastIndex(dependency, "@")
+	if i <= 0 {
+		return NPMDependency{}, fmt.Errorf("dependency %q must contain an '@' character separating the package from the version", dependency)
+	}
+
+	pkg, err := ParseNPMPackage(dependency[:i])
+	if err != nil {
+		return NPMDependency{}, err
+	}
+
+	version := dependency[i+1:]
+	if version == "" {
+		return NPMDependency{}, fmt.Errorf("dependency %q is missing a version", dependency)
+	}
+
+	return NPMDependency{NPMPackage: pkg, Version: version}, nil
+}
+
+// SortNPMDependencies sorts the dependencies by the semantic version in
+// descending order. The latest version of a dependency becomes the first
+// element of the slice.
+func SortNPMDependencies(dependencies []NPMDependency) {
+	sort.Slice(dependencies, func(i, j int) bool {
+		if dependencies[i].NPMPackage == dependencies[j].NPMPackage {
+			return versionGreaterThan(dependencies[i].Version, dependencies[j].Version)
+		}
+		return dependencies[i].NPMPackage.SortText() > dependencies[j].NPMPackage.SortText()
+	})
+}
@@ -0,0 +1,92 @@
+package reposource
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/sourcegraph/sourcegraph/internal/api"
+)
+
+func TestParseNPMDependency(t *testing.T) {
+	for _, tc := range []struct {
+		dependency string
+		want       NPMDependency
+		repoName   api.RepoName
+	}{
+		{
+			dependency: "react@17.0.2",
+			want:       NPMDependency{NPMPackage: NPMPackage{Name: "react"}, Version: "17.0.2"},
+			repoName:   "npm/react",
+		},
+		{
+			dependency: "@types/node@16.11.1",
+			want:       NPMDependency{NPMPackage: NPMPackage{Scope: "types", Name: "node"}, Version: "16.11.1"},
+			repoName:   "npm/types/node",
+		},
+	} {
+		t.Run(tc.dependency, func(t *testing.T) {
+			have, err := ParseNPMDependency(tc.dependency)
+			assert.Nil(t, err)
+			assert.Equal(t, tc.want, have)
+			assert.Equal(t, tc.dependency, have.PackageManagerSyntax())
+			assert.Equal(t, tc.repoName, have.RepoName())
+
+			pkg, err := ParseNPMPackageFromRepoURL(string(tc.repoName))
+			assert.Nil(t, err)
+			assert.Equal(t, tc.want.NPMPackage, pkg)
+		})
+	}
+
+	for _, dependency := range []string{
+		"react",
+		"react@",
+		"@types/node",
+		"@types@1.0.0",
+		"../../etc@1.0.0",
+		".hidden@1.0.0",
+	} {
+		if _, err := ParseNPMDependency(dependency); err == nil {
+			t.Errorf("ParseNPMDependency(%q): expected error", dependency)
+		}
+	}
+}
+
+func TestParseNPMPackageFromRepoURL(t *testing.T) {
+	for _, urlPath := range []string{
+		"npm/a/b/c",
+		"npm/..",
+		"npm/",
+	} {
+		if _, err := ParseNPMPackageFromRepoURL(urlPath); err == nil {
+			t.Errorf("ParseNPMPackageFromRepoURL(%q): expected error", urlPath)
+		}
+	}
+}
+
+func TestSortNPMDependencies(t *testing.T) {
+	parse := func(dependency string) NPMDependency {
+		d, err := ParseNPMDependency(dependency)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return d
+	}
+
+	dependencies := []NPMDependency{
+		parse("a@1.2.0"),
+		parse("@types/b@1.0.0"),
+		parse("b@1.11.0"),
+		parse("b@1.2.0"),
+		parse("b@1.2.0-rc.1"),
+	}
+	expected := []NPMDependency{
+		parse("b@1.11.0"),
+		parse("b@1.2.0"),
+		parse("b@1.2.0-rc.1"),
+		parse("a@1.2.0"),
+		parse("@types/b@1.0.0"),
+	}
+	SortNPMDependencies(dependencies)
+	assert.Equal(t, expected, dependencies)
+}
@@ -26,6 +26,8 @@ type AccessToken struct {
 	CreatorUserID int32
 	CreatedAt     time.Time
 	LastUsedAt    *time.Time
+	LastUsedIP    string     // the IP address of the client that last used the token, if known
+	ExpiresAt     *time.Time // the time after which the token is rejected, or nil if it never expires
 }
 
 // ErrAccessTokenNotFound occurs when a database operation expects a specific access token to exist
@@ -69,11 +71,13 @@ func (s *AccessTokenStore) Transact(ctx context.Context) (*AccessTokenStore, err
 // space; also bcrypt is slow and would add noticeable latency to each request that supplied a
 // token.
 //
+// If expiresAt is not nil, Lookup rejects the token after that time.
+//
 // 🚨 SECURITY: The caller must ensure that the actor is permitted to create tokens for the
 // specified user (i.e., that the actor is either the user or a site admin).
-func (s *AccessTokenStore) Create(ctx context.Context, subjectUserID int32, scopes []string, note string, creatorUserID int32) (id int64, token string, err error) {
+func (s *AccessTokenStore) Create(ctx context.Context, subjectUserID int32, scopes []string, note string, creatorUserID int32, expiresAt *time.Time) (id int64, token string, err error) {
 	if Mocks.AccessTokens.Create != nil {
-		return Mocks.AccessTokens.Create(subjectUserID, scopes, note, creatorUserID)
+		return Mocks.AccessTokens.Create(subjectUserID, scopes, note, creatorUserID, expiresAt)
 	}
 
 	var b [20]byte
@@ -99,60 +103,64 @@ creator_user AS (
   SELECT id FROM users WHERE id=$5 AND deleted_at IS NULL FOR UPDATE
 ),
 insert_values AS (
-  SELECT subject_user.id AS subject_user_id, $2::text[] AS scopes, $3::bytea AS value_sha256, $4::text AS note, creator_user.id AS creator_user_id
+  SELECT subject_user.id AS subject_user_id, $2::text[] AS scopes, $3::bytea AS value_sha256, $4::text AS note, creator_user.id AS creator_user_id, $6::timestamptz AS expires_at
   FROM subject_user, creator_user
 )
-INSERT INTO access_tokens(subject_user_id, scopes, value_sha256, note, creator_user_id) SELECT * FROM insert_values RETURNING id
+INSERT INTO access_tokens(subject_user_id, scopes, value_sha256, note, creator_user_id, expires_at) SELECT * FROM insert_values RETURNING id
 `,
-		subjectUserID, pq.Array(scopes), toSHA256Bytes(b[:]), note, creatorUserID,
+		subjectUserID, pq.Array(scopes), toSHA256Bytes(b[:]), note, creatorUserID, expiresAt,
 	).Scan(&id); err != nil {
 		return 0, "", err
 	}
 	return id, token, nil
 }
 
-// Lookup looks up the access token. If it's valid and contains the required scope, it returns the
-// subject's user ID. Otherwise ErrAccessTokenNotFound is returned.
+// Lookup looks up the access token. If it's valid, has not expired and contains at least one of
+// the accepted scopes, it returns the subject's user ID and all the scopes of the token. Otherwise
+// ErrAccessTokenNotFound is returned.
 //
-// Calling Lookup also updates the access token's last-used-at date.
+// Calling Lookup also updates the access token's last-used-at date and, if remoteIP is not empty,
+// its last-used IP address.
 //
 // 🚨 SECURITY: This returns a user ID if and only if the tokenHexEncoded corresponds to a valid,
-// non-deleted access token.
-func (s *AccessTokenStore) Lookup(ctx context.Context, tokenHexEncoded, requiredScope string) (subjectUserID int32, err error) {
+// non-deleted, unexpired access token. The caller must only grant the privileges of the returned
+// scopes.
+func (s *AccessTokenStore) Lookup(ctx context.Context, tokenHexEncoded string, acceptedScopes []string, remoteIP string) (subjectUserID int32, scopes []string, err error) {
 	if Mocks.AccessTokens.Lookup != nil {
-		return Mocks.AccessTokens.Lookup(tokenHexEncoded, requiredScope)
+		return Mocks.AccessTokens.Lookup(tokenHexEncoded, acceptedScopes, remoteIP)
 	}
 
-	if requiredScope == "" {
-		return 0, errors.New("no scope provided in access token lookup")
+	if len(acceptedScopes) == 0 {
+		return 0, nil, errors.New("no scope provided in access token lookup")
 	}
 
 	token, err := hex.DecodeString(tokenHexEncoded)
 	if err != nil {
-		return 0, errors.Wrap(err, "AccessTokens.Lookup")
+		return 0, nil, errors.Wrap(err, "AccessTokens.Lookup")
 	}
 
 	if err := s.Handle().DB().QueryRowContext(ctx,
 		// Ensure that subject and creator users still exist.
 		`
-UPDATE access_tokens t SET last_used_at=now()
+UPDATE access_tokens t SET last_used_at=now(), last_used_ip=COALESCE(NULLIF($3, ''), t.last_used_ip)
 WHERE t.id IN (
 	SELECT t2.id FROM access_tokens t2
 	JOIN users subject_user ON t2.subject_user_id=subject_user.id AND subject_user.deleted_at IS NULL
 	JOIN users creator_user ON t2.creator_user_id=creator_user.id AND creator_user.deleted_at IS NULL
 	WHERE t2.value_sha256=$1 AND t2.deleted_at IS NULL AND
-	$2 = ANY (t2.scopes)
+	(t2.expires_at IS NULL OR t2.expires_at > now()) AND
+	$2::text[] && t2.scopes
 )
-RETURNING t.subject_user_id
+RETURNING t.subject_user_id, t.scopes
 `,
-		toSHA256Bytes(token), requiredScope,
-	).Scan(&subjectUserID); err != nil {
+		toSHA256Bytes(token), pq.Array(acceptedScopes), remoteIP,
+	).Scan(&subjectUserID, pq.Array(&scopes)); err != nil {
 		if err == sql.ErrNoRows {
-			return 0, ErrAccessTokenNotFound
+			return 0, nil, ErrAccessTokenNotFound
 		}
-		return 0, err
+		return 0, nil, err
 	}
-	return subjectUserID, nil
+	return subjectUserID, scopes, nil
 }
 
 // GetByID retrieves the access token (if any) given its ID.
@@ -221,7 +229,7 @@ func (s *AccessTokenStore) List(ctx context.Context, opt AccessTokensListOptions
 
 func (s *AccessTokenStore) list(ctx context.Context, conds []*sqlf.Query, limitOffset *LimitOffset) ([]*AccessToken, error) {
 	q := sqlf.Sprintf(`
-SELECT id, subject_user_id, scopes, note, creator_user_id, created_at, last_used_at FROM access_tokens
+SELECT id, subject_user_id, scopes, note, creator_user_id, created_at, last_used_at, COALESCE(last_used_ip, ''), expires_at FROM access_tokens
 WHERE (%s)
 ORDER BY now() - created_at < interval '5 minutes' DESC, -- show recently created tokens first
 last_used_at DESC NULLS FIRST, -- ensure newly created tokens show first
@@ -240,7 +248,7 @@ created_at DESC
 	var results []*AccessToken
 	for rows.Next() {
 		var t AccessToken
-		if err := rows.Scan(&t.ID, &t.SubjectUserID, pq.Array(&t.Scopes), &t.Note, &t.CreatorUserID, &t.CreatedAt, &t.LastUsedAt); err != nil {
+		if err := rows.Scan(&t.ID, &t.SubjectUserID, pq.Array(&t.Scopes), &t.Note, &t.CreatorUserID, &t.CreatedAt, &t.LastUsedAt, &t.LastUsedIP, &t.ExpiresAt); err != nil {
 			return nil, err
 		}
 		results = append(results, &t)
@@ -309,8 +317,8 @@ func toSHA256Bytes(input []byte) []byte {
 }
 
 type MockAccessTokens struct {
-	Create     func(subjectUserID int32, scopes []string, note string, creatorUserID int32) (id int64, token string, err error)
+	Create     func(subjectUserID int32, scopes []string, note string, creatorUserID int32, expiresAt *time.Time) (id int64, token string, err error)
 	DeleteByID func(id int64, subjectUserID int32) error
-	Lookup     func(tokenHexEncoded, requiredScope string) (subjectUserID int32, err error)
+	Lookup     func(tokenHexEncoded string, acceptedScopes []string, remoteIP string) (subjectUserID int32, scopes []string, err error)
 	GetByID    func(id int64) (*AccessToken, error)
 }
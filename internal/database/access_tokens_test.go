@@ -4,6 +4,7 @@ import (
 	"context"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/sourcegraph/sourcegraph/internal/database/dbtest"
 )
@@ -35,7 +36,7 @@ func TestAccessTokens_Create(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	tid0, tv0, err := AccessTokens(db).Create(ctx, subject.ID, []string{"a", "b"}, "n0", creator.ID)
+	tid0, tv0, err := AccessTokens(db).Create(ctx, subject.ID, []string{"a", "b"}, "n0", creator.ID, nil)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -54,7 +55,7 @@ func TestAccessTokens_Create(t *testing.T) {
 		t.Errorf("got %q, want %q", got.Note, want)
 	}
 
-	gotSubjectUserID, err := AccessTokens(db).Lookup(ctx, tv0, "a")
+	gotSubjectUserID, _, err := AccessTokens(db).Lookup(ctx, tv0, []string{"a"}, "")
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -110,11 +111,11 @@ func TestAccessTokens_List(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	_, _, err = AccessTokens(db).Create(ctx, subject1.ID, []string{"a", "b"}, "n0", subject1.ID)
+	_, _, err = AccessTokens(db).Create(ctx, subject1.ID, []string{"a", "b"}, "n0", subject1.ID, nil)
 	if err != nil {
 		t.Fatal(err)
 	}
-	_, _, err = AccessTokens(db).Create(ctx, subject1.ID, []string{"a", "b"}, "n1", subject1.ID)
+	_, _, err = AccessTokens(db).Create(ctx, subject1.ID, []string{"a", "b"}, "n1", subject1.ID, nil)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -190,13 +191,13 @@ func TestAccessTokens_Lookup(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	tid0, tv0, err := AccessTokens(db).Create(ctx, subject.ID, []string{"a", "b"}, "n0", creator.ID)
+	tid0, tv0, err := AccessTokens(db).Create(ctx, subject.ID, []string{"a", "b"}, "n0", creator.ID, nil)
 	if err != nil {
 		t.Fatal(err)
 	}
 
 	for _, scope := range []string{"a", "b"} {
-		gotSubjectUserID, err := AccessTokens(db).Lookup(ctx, tv0, scope)
+		gotSubjectUserID, _, err := AccessTokens(db).Lookup(ctx, tv0, []string{scope}, "")
 		if err != nil {
 			t.Fatal(err)
 		}
@@ -205,13 +206,30 @@ func TestAccessTokens_Lookup(t *testing.T) {
 		}
 	}
 
+	// Lookup with any of the accepted scopes returns all the scopes of the token and records the
+	// client IP.
+	_, gotScopes, err := AccessTokens(db).Lookup(ctx, tv0, []string{"x", "b"}, "10.0.0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"a", "b"}; !reflect.DeepEqual(gotScopes, want) {
+		t.Errorf("got scopes %q, want %q", gotScopes, want)
+	}
+	got, err := AccessTokens(db).GetByID(ctx, tid0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "10.0.0.1"; got.LastUsedIP != want {
+		t.Errorf("got last used IP %q, want %q", got.LastUsedIP, want)
+	}
+
 	// Lookup with a nonexistent scope and ensure it fails.
-	if _, err := AccessTokens(db).Lookup(ctx, tv0, "x"); err == nil {
+	if _, _, err := AccessTokens(db).Lookup(ctx, tv0, []string{"x"}, ""); err == nil {
 		t.Fatal(err)
 	}
 
 	// Lookup with an empty scope and ensure it fails.
-	if _, err := AccessTokens(db).Lookup(ctx, tv0, ""); err == nil {
+	if _, _, err := AccessTokens(db).Lookup(ctx, tv0, nil, ""); err == nil {
 		t.Fatal(err)
 	}
 
@@ -219,16 +237,62 @@ func TestAccessTokens_Lookup(t *testing.T) {
 	if err := AccessTokens(db).DeleteByID(ctx, tid0, subject.ID); err != nil {
 		t.Fatal(err)
 	}
-	if _, err := AccessTokens(db).Lookup(ctx, tv0, "a"); err == nil {
+	if _, _, err := AccessTokens(db).Lookup(ctx, tv0, []string{"a"}, ""); err == nil {
 		t.Fatal(err)
 	}
 
 	// Try to Lookup a token that was never created.
-	if _, err := AccessTokens(db).Lookup(ctx, "abcdefg" /* this token value was never created */, "a"); err == nil {
+	if _, _, err := AccessTokens(db).Lookup(ctx, "abcdefg" /* this token value was never created */, []string{"a"}, ""); err == nil {
 		t.Fatal(err)
 	}
 }
 
+// 🚨 SECURITY: This tests that expired access tokens are rejected.
+func TestAccessTokens_Lookup_expired(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+	t.Parallel()
+	db := dbtest.NewDB(t, "")
+	ctx := context.Background()
+
+	subject, err := Users(db).Create(ctx, NewUser{
+		Email:                 "u1@example.com",
+		Username:              "u1",
+		Password:              "p1",
+		EmailVerificationCode: "c1",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	future := time.Now().Add(time.Hour)
+	_, tv0, err := AccessTokens(db).Create(ctx, subject.ID, []string{"a"}, "n0", subject.ID, &future)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := AccessTokens(db).Lookup(ctx, tv0, []string{"a"}, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	past := time.Now().Add(-time.Hour)
+	tid1, tv1, err := AccessTokens(db).Create(ctx, subject.ID, []string{"a"}, "n1", subject.ID, &past)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := AccessTokens(db).Lookup(ctx, tv1, []string{"a"}, ""); err != ErrAccessTokenNotFound {
+		t.Fatalf("Lookup: want ErrAccessTokenNotFound for expired token, got %v", err)
+	}
+
+	got, err := AccessTokens(db).GetByID(ctx, tid1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.ExpiresAt == nil || !got.ExpiresAt.Before(time.Now()) {
+		t.Errorf("got expiry %v, want %v", got.ExpiresAt, past)
+	}
+}
+
 // 🚨 SECURITY: This tests that deleting the subject or creator user of an access token invalidates
 // the token, and that no new access tokens may be created for deleted users.
 func TestAccessTokens_Lookup_deletedUser(t *testing.T) {
@@ -259,18 +323,18 @@ func TestAccessTokens_Lookup_deletedUser(t *testing.T) {
 			t.Fatal(err)
 		}
 
-		_, tv0, err := AccessTokens(db).Create(ctx, subject.ID, []string{"a"}, "n0", creator.ID)
+		_, tv0, err := AccessTokens(db).Create(ctx, subject.ID, []string{"a"}, "n0", creator.ID, nil)
 		if err != nil {
 			t.Fatal(err)
 		}
 		if err := Users(db).Delete(ctx, subject.ID); err != nil {
 			t.Fatal(err)
 		}
-		if _, err := AccessTokens(db).Lookup(ctx, tv0, "a"); err == nil {
+		if _, _, err := AccessTokens(db).Lookup(ctx, tv0, []string{"a"}, ""); err == nil {
 			t.Fatal("Lookup: want error looking up token for deleted subject user")
 		}
 
-		if _, _, err := AccessTokens(db).Create(ctx, subject.ID, nil, "n0", creator.ID); err == nil {
+		if _, _, err := AccessTokens(db).Create(ctx, subject.ID, nil, "n0", creator.ID, nil); err == nil {
 			t.Fatal("Create: want error creating token for deleted subject user")
 		}
 	})
@@ -295,18 +359,18 @@ func TestAccessTokens_Lookup_deletedUser(t *testing.T) {
 			t.Fatal(err)
 		}
 
-		_, tv0, err := AccessTokens(db).Create(ctx, subject.ID, []string{"a"}, "n0", creator.ID)
+		_, tv0, err := AccessTokens(db).Create(ctx, subject.ID, []string{"a"}, "n0", creator.ID, nil)
 		if err != nil {
 			t.Fatal(err)
 		}
 		if err := Users(db).Delete(ctx, creator.ID); err != nil {
 			t.Fatal(err)
 		}
-		if _, err := AccessTokens(db).Lookup(ctx, tv0, "a"); err == nil {
+		if _, _, err := AccessTokens(db).Lookup(ctx, tv0, []string{"a"}, ""); err == nil {
 			t.Fatal("Lookup: want error looking up token for deleted creator user")
 		}
 
-		if _, _, err := AccessTokens(db).Create(ctx, subject.ID, nil, "n0", creator.ID); err == nil {
+		if _, _, err := AccessTokens(db).Create(ctx, subject.ID, nil, "n0", creator.ID, nil); err == nil {
 			t.Fatal("Create: want error creating token for deleted creator user")
 		}
 	})
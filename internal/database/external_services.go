@@ -123,10 +123,14 @@ var ExternalServiceKinds = map[string]ExternalServiceKind{
 	extsvc.KindAWSCodeCommit:   {CodeHost: true, JSONSchema: schema.AWSCodeCommitSchemaJSON},
 	extsvc.KindBitbucketCloud:  {CodeHost: true, JSONSchema: schema.BitbucketCloudSchemaJSON},
 	extsvc.KindBitbucketServer: {CodeHost: true, JSONSchema: schema.BitbucketServerSchemaJSON},
+	extsvc.KindGerrit:          {CodeHost: true, JSONSchema: schema.GerritSchemaJSON},
+	extsvc.KindGitea:           {CodeHost: true, JSONSchema: schema.GiteaSchemaJSON},
 	extsvc.KindGitHub:          {CodeHost: true, JSONSchema: schema.GitHubSchemaJSON},
 	extsvc.KindGitLab:          {CodeHost: true, JSONSchema: schema.GitLabSchemaJSON},
 	extsvc.KindGitolite:        {CodeHost: true, JSONSchema: schema.GitoliteSchemaJSON},
+	extsvc.KindGoModules:       {CodeHost: true, JSONSchema: schema.GoModulesSchemaJSON},
 	extsvc.KindJVMPackages:     {CodeHost: true, JSONSchema: schema.JVMPackagesSchemaJSON},
+	extsvc.KindNPMPackages:     {CodeHost: true, JSONSchema: schema.NPMPackagesSchemaJSON},
 	extsvc.KindPerforce:        {CodeHost: true, JSONSchema: schema.PerforceSchemaJSON},
 	extsvc.KindPhabricator:     {CodeHost: true, JSONSchema: schema.PhabricatorSchemaJSON},
 	extsvc.KindOther:           {CodeHost: true, JSONSchema: schema.OtherExternalServiceSchemaJSON},
@@ -316,6 +320,20 @@ func (e *ExternalServiceStore) ValidateConfig(ctx context.Context, opt ValidateE
 		}
 		err = e.validateBitbucketCloudConnection(ctx, opt.ExternalServiceID, &c)
 
+	case extsvc.KindGerrit:
+		var c schema.GerritConnection
+		if err = jsoniter.Unmarshal(normalized, &c); err != nil {
+			return nil, err
+		}
+		err = e.validateGerritConnection(ctx, opt.ExternalServiceID, &c)
+
+	case extsvc.KindGitea:
+		var c schema.GiteaConnection
+		if err = jsoniter.Unmarshal(normalized, &c); err != nil {
+			return nil, err
+		}
+		err = e.validateGiteaConnection(ctx, opt.ExternalServiceID, &c)
+
 	case extsvc.KindPerforce:
 		var c schema.PerforceConnection
 		if err = jsoniter.Unmarshal(normalized, &c); err != nil {
@@ -408,6 +426,30 @@ func (e *ExternalServiceStore) validateBitbucketCloudConnection(ctx context.Cont
 	return e.validateDuplicateRateLimits(ctx, id, extsvc.KindBitbucketCloud, c)
 }
 
+func (e *ExternalServiceStore) validateGerritConnection(ctx context.Context, id int64, c *schema.GerritConnection) error {
+	err := new(multierror.Error)
+
+	if c.Username != "" && c.Password == "" {
+		err = multierror.Append(err, errors.New("password must be set when username is set"))
+	}
+
+	err = multierror.Append(err, e.validateDuplicateRateLimits(ctx, id, extsvc.KindGerrit, c))
+
+	return err.ErrorOrNil()
+}
+
+func (e *ExternalServiceStore) validateGiteaConnection(ctx context.Context, id int64, c *schema.GiteaConnection) error {
+	err := new(multierror.Error)
+
+	if len(c.Orgs) == 0 && len(c.Users) == 0 && len(c.Repos) == 0 {
+		err = multierror.Append(err, errors.New("at least one of orgs, users or repos must be set"))
+	}
+
+	err = multierror.Append(err, e.validateDuplicateRateLimits(ctx, id, extsvc.KindGitea, c))
+
+	return err.ErrorOrNil()
+}
+
 func (e *ExternalServiceStore) validatePerforceConnection(ctx context.Context, id int64, c *schema.PerforceConnection) error {
 	err := new(multierror.Error)
 	for _, validate := range e.PerforceValidators {
@@ -72,6 +72,9 @@ INSERT INTO
 type IterateRepoGitserverStatusOptions struct {
 	// If set, will only iterate over repos that have not been assigned to a shard
 	OnlyWithoutShard bool
+	// If set, the replica of each repo on the given shard is returned too, if
+	// it exists
+	ReplicaShardID string
 }
 
 // IterateRepoGitserverStatus iterates over the status of all repos by joining
@@ -92,16 +95,23 @@ SELECT repo.id,
        gr.last_external_service,
        gr.last_error,
        gr.last_fetched,
-       gr.updated_at
+       gr.updated_at,
+       grr.clone_status,
+       grr.last_error,
+       grr.last_fetched,
+       grr.updated_at
 FROM repo
     LEFT JOIN gitserver_repos gr ON gr.repo_id = repo.id
+    LEFT JOIN gitserver_repo_replicas grr ON grr.repo_id = repo.id AND grr.shard_id = %s
     WHERE repo.deleted_at IS NULL
 `
 	if options.OnlyWithoutShard {
 		q = q + "AND (gr.shard_id = '' OR gr IS NULL)"
 	}
 
-	rows, err := s.Query(ctx, sqlf.Sprintf(q))
+	// shard_id is never empty, so this doesn't join any replica when none was
+	// requested.
+	rows, err := s.Query(ctx, sqlf.Sprintf(q, options.ReplicaShardID))
 	if err != nil {
 		return errors.Wrap(err, "fetching gitserver status")
 	}
@@ -110,7 +120,8 @@ FROM repo
 	for rows.Next() {
 		var rgs types.RepoGitserverStatus
 		var gr types.GitserverRepo
-		var cloneStatus string
+		var grr types.GitserverRepoReplica
+		var cloneStatus, replicaCloneStatus string
 
 		if err := rows.Scan(
 			&rgs.ID,
@@ -121,6 +132,10 @@ FROM repo
 			&dbutil.NullString{S: &gr.LastError},
 			&dbutil.NullTime{Time: &gr.LastFetched},
 			&dbutil.NullTime{Time: &gr.UpdatedAt},
+			&dbutil.NullString{S: &replicaCloneStatus},
+			&dbutil.NullString{S: &grr.LastError},
+			&dbutil.NullTime{Time: &grr.LastFetched},
+			&dbutil.NullTime{Time: &grr.UpdatedAt},
 		); err != nil {
 			return errors.Wrap(err, "scanning row")
 		}
@@ -132,6 +147,12 @@ FROM repo
 			gr.RepoID = rgs.ID
 			rgs.GitserverRepo = &gr
 		}
+		if replicaCloneStatus != "" {
+			grr.CloneStatus = types.ParseCloneStatus(replicaCloneStatus)
+			grr.RepoID = rgs.ID
+			grr.ShardID = options.ReplicaShardID
+			rgs.Replica = &grr
+		}
 
 		err := repoFn(rgs)
 		if err != nil {
@@ -157,7 +178,8 @@ SELECT
        last_external_service,
        last_error,
        last_fetched,
-       updated_at
+       updated_at,
+       last_maintained
 FROM gitserver_repos
 WHERE repo_id = %s
 `
@@ -176,6 +198,7 @@ WHERE repo_id = %s
 		&dbutil.NullString{S: &gr.LastError},
 		&dbutil.NullTime{Time: &gr.LastFetched},
 		&gr.UpdatedAt,
+		&dbutil.NullTime{Time: &gr.LastMaintained},
 	)
 	if err != nil {
 		return nil, errors.Wrap(err, "scanning GitserverRepo")
@@ -241,6 +264,174 @@ SET (last_fetched, shard_id, updated_at) =
 	return errors.Wrap(err, "setting last fetched")
 }
 
+// UpsertReplicas adds rows representing the state of replicas of repos on
+// gitservers other than their primary one.
+func (s *GitserverRepoStore) UpsertReplicas(ctx context.Context, replicas ...*types.GitserverRepoReplica) error {
+	values := make([]*sqlf.Query, 0, len(replicas))
+	for _, r := range replicas {
+		values = append(values, sqlf.Sprintf("(%s, %s, %s, %s, %s, now())",
+			r.RepoID,
+			r.ShardID,
+			r.CloneStatus,
+			dbutil.NewNullString(sanitizeToUTF8(r.LastError)),
+			r.LastFetched,
+		))
+	}
+
+	err := s.Exec(ctx, sqlf.Sprintf(`
+-- source: internal/database/gitserver_repos.go:GitserverRepoStore.UpsertReplicas
+INSERT INTO
+    gitserver_repo_replicas(repo_id, shard_id, clone_status, last_error, last_fetched, updated_at)
+    VALUES %s
+    ON CONFLICT (repo_id, shard_id) DO UPDATE
+    SET (clone_status, last_error, last_fetched, updated_at) =
+        (EXCLUDED.clone_status, EXCLUDED.last_error, EXCLUDED.last_fetched, now())
+`, sqlf.Join(values, ",")))
+
+	return errors.Wrap(err, "creating GitserverRepoReplica")
+}
+
+// DeleteReplica removes the row of the replica of a repo on the given shard,
+// once the shard isn't a replica of the repo anymore.
+func (s *GitserverRepoStore) DeleteReplica(ctx context.Context, id api.RepoID, shardID string) error {
+	err := s.Exec(ctx, sqlf.Sprintf(`
+-- source: internal/database/gitserver_repos.go:GitserverRepoStore.DeleteReplica
+DELETE FROM gitserver_repo_replicas WHERE repo_id = %s AND shard_id = %s
+`, id, shardID))
+
+	return errors.Wrap(err, "deleting GitserverRepoReplica")
+}
+
+// ListReplicas returns the state of the replicas of a repo, ordered by shard.
+// The state of its primary copy is returned by GetByID.
+func (s *GitserverRepoStore) ListReplicas(ctx context.Context, id api.RepoID) ([]*types.GitserverRepoReplica, error) {
+	rows, err := s.Query(ctx, sqlf.Sprintf(`
+-- source: internal/database/gitserver_repos.go:GitserverRepoStore.ListReplicas
+SELECT
+       repo_id,
+       shard_id,
+       clone_status,
+       last_error,
+       last_fetched,
+       updated_at,
+       last_maintained
+FROM gitserver_repo_replicas
+WHERE repo_id = %s
+ORDER BY shard_id
+`, id))
+	if err != nil {
+		return nil, errors.Wrap(err, "listing GitserverRepoReplicas")
+	}
+	defer rows.Close()
+
+	var replicas []*types.GitserverRepoReplica
+	for rows.Next() {
+		var r types.GitserverRepoReplica
+		var cloneStatus string
+		if err := rows.Scan(
+			&r.RepoID,
+			&r.ShardID,
+			&cloneStatus,
+			&dbutil.NullString{S: &r.LastError},
+			&r.LastFetched,
+			&r.UpdatedAt,
+			&dbutil.NullTime{Time: &r.LastMaintained},
+		); err != nil {
+			return nil, errors.Wrap(err, "scanning GitserverRepoReplica")
+		}
+		r.CloneStatus = types.ParseCloneStatus(cloneStatus)
+		replicas = append(replicas, &r)
+	}
+
+	return replicas, errors.Wrap(rows.Err(), "iterating rows")
+}
+
+// SetReplicaCloneStatus is the same as SetCloneStatus, for the replica of a
+// repo on the given shard.
+func (s *GitserverRepoStore) SetReplicaCloneStatus(ctx context.Context, name api.RepoName, status types.CloneStatus, shardID string) error {
+	err := s.Exec(ctx, sqlf.Sprintf(`
+-- source: internal/database/gitserver_repos.go:GitserverRepoStore.SetReplicaCloneStatus
+INSERT INTO gitserver_repo_replicas(repo_id, shard_id, clone_status, updated_at)
+SELECT id, %s, %s, now()
+FROM repo
+WHERE name = %s
+ON CONFLICT (repo_id, shard_id) DO UPDATE
+SET (clone_status, updated_at) =
+    (EXCLUDED.clone_status, now())
+    WHERE gitserver_repo_replicas.clone_status IS DISTINCT FROM EXCLUDED.clone_status
+`, shardID, status, name))
+
+	return errors.Wrap(err, "setting replica clone status")
+}
+
+// SetReplicaLastError is the same as SetLastError, for the replica of a repo
+// on the given shard.
+func (s *GitserverRepoStore) SetReplicaLastError(ctx context.Context, name api.RepoName, error, shardID string) error {
+	ns := dbutil.NewNullString(sanitizeToUTF8(error))
+
+	err := s.Exec(ctx, sqlf.Sprintf(`
+-- source: internal/database/gitserver_repos.go:GitserverRepoStore.SetReplicaLastError
+INSERT INTO gitserver_repo_replicas(repo_id, shard_id, last_error, updated_at)
+SELECT id, %s, %s, now()
+FROM repo
+WHERE name = %s
+ON CONFLICT (repo_id, shard_id) DO UPDATE
+    SET (last_error, updated_at) =
+            (EXCLUDED.last_error, now())
+WHERE gitserver_repo_replicas.last_error IS DISTINCT FROM EXCLUDED.last_error
+`, shardID, ns, name))
+
+	return errors.Wrap(err, "setting replica last error")
+}
+
+// SetReplicaLastFetched is the same as SetLastFetched, for the replica of a
+// repo on the given shard.
+func (s *GitserverRepoStore) SetReplicaLastFetched(ctx context.Context, name api.RepoName, lastFetched time.Time, shardID string) error {
+	err := s.Exec(ctx, sqlf.Sprintf(`
+-- source: internal/database/gitserver_repos.go:GitserverRepoStore.SetReplicaLastFetched
+INSERT INTO gitserver_repo_replicas(repo_id, shard_id, last_fetched, updated_at)
+SELECT id, %s, %s, now()
+FROM repo WHERE name = %s
+ON CONFLICT (repo_id, shard_id) DO UPDATE
+SET (last_fetched, updated_at) =
+    (EXCLUDED.last_fetched, now())
+`, shardID, lastFetched, name))
+
+	return errors.Wrap(err, "setting replica last fetched")
+}
+
+// SetLastMaintained will attempt to update ONLY the last maintained time of a
+// GitServerRepo. If a matching row does not yet exist a new one will be created.
+func (s *GitserverRepoStore) SetLastMaintained(ctx context.Context, name api.RepoName, lastMaintained time.Time, shardID string) error {
+	err := s.Exec(ctx, sqlf.Sprintf(`
+-- source: internal/database/gitserver_repos.go:GitserverRepoStore.SetLastMaintained
+INSERT INTO gitserver_repos(repo_id, last_maintained, shard_id, updated_at)
+SELECT id, %s, %s, now()
+FROM repo WHERE name = %s
+ON CONFLICT (repo_id) DO UPDATE
+SET (last_maintained, shard_id, updated_at) =
+    (EXCLUDED.last_maintained, EXCLUDED.shard_id, now())
+`, lastMaintained, shardID, name))
+
+	return errors.Wrap(err, "setting last maintained")
+}
+
+// SetReplicaLastMaintained is the same as SetLastMaintained, for the replica of
+// a repo on the given shard.
+func (s *GitserverRepoStore) SetReplicaLastMaintained(ctx context.Context, name api.RepoName, lastMaintained time.Time, shardID string) error {
+	err := s.Exec(ctx, sqlf.Sprintf(`
+-- source: internal/database/gitserver_repos.go:GitserverRepoStore.SetReplicaLastMaintained
+INSERT INTO gitserver_repo_replicas(repo_id, shard_id, last_maintained, updated_at)
+SELECT id, %s, %s, now()
+FROM repo WHERE name = %s
+ON CONFLICT (repo_id, shard_id) DO UPDATE
+SET (last_maintained, updated_at) =
+    (EXCLUDED.last_maintained, now())
+`, shardID, lastMaintained, name))
+
+	return errors.Wrap(err, "setting replica last maintained")
+}
+
 // sanitizeToUTF8 will remove any null character terminated string. The null character can be
 // represented in one of the following ways in Go:
 //
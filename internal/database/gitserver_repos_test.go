@@ -3,6 +3,7 @@ package database
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
@@ -482,3 +483,122 @@ func TestSanitizeToUTF8(t *testing.T) {
 		}
 	}
 }
+
+func TestGitserverRepoReplicas(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+
+	db := dbtest.NewDB(t, "")
+	ctx := context.Background()
+
+	repo1 := &types.Repo{
+		Name: "github.com/sourcegraph/repo1",
+		URI:  "github.com/sourcegraph/repo1",
+	}
+	if err := Repos(db).Create(ctx, repo1); err != nil {
+		t.Fatal(err)
+	}
+
+	store := GitserverRepos(db)
+	if err := store.SetReplicaCloneStatus(ctx, repo1.Name, types.CloneStatusCloning, "gitserver2"); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.SetReplicaLastError(ctx, repo1.Name, "oops", "gitserver2"); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.UpsertReplicas(ctx, &types.GitserverRepoReplica{
+		RepoID:      repo1.ID,
+		ShardID:     "gitserver3",
+		CloneStatus: types.CloneStatusCloned,
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	replicas, err := store.ListReplicas(ctx, repo1.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []*types.GitserverRepoReplica{
+		{RepoID: repo1.ID, ShardID: "gitserver2", CloneStatus: types.CloneStatusCloning, LastError: "oops"},
+		{RepoID: repo1.ID, ShardID: "gitserver3", CloneStatus: types.CloneStatusCloned},
+	}
+	if diff := cmp.Diff(want, replicas, cmpopts.IgnoreFields(types.GitserverRepoReplica{}, "LastFetched", "UpdatedAt")); diff != "" {
+		t.Fatal(diff)
+	}
+
+	// The replica on the given shard is returned along with the primary state.
+	var replica *types.GitserverRepoReplica
+	err = store.IterateRepoGitserverStatus(ctx, IterateRepoGitserverStatusOptions{ReplicaShardID: "gitserver3"}, func(repo types.RepoGitserverStatus) error {
+		replica = repo.Replica
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(want[1], replica, cmpopts.IgnoreFields(types.GitserverRepoReplica{}, "LastFetched", "UpdatedAt")); diff != "" {
+		t.Fatal(diff)
+	}
+
+	if err := store.DeleteReplica(ctx, repo1.ID, "gitserver2"); err != nil {
+		t.Fatal(err)
+	}
+	replicas, err = store.ListReplicas(ctx, repo1.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(replicas) != 1 || replicas[0].ShardID != "gitserver3" {
+		t.Fatalf("unexpected replicas after delete: %+v", replicas)
+	}
+}
+
+func TestSetLastMaintained(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+
+	db := dbtest.NewDB(t, "")
+	ctx := context.Background()
+	const shardID = "test"
+
+	repo1 := &types.Repo{
+		Name: "github.com/sourcegraph/repo1",
+		URI:  "github.com/sourcegraph/repo1",
+	}
+	if err := Repos(db).Create(ctx, repo1); err != nil {
+		t.Fatal(err)
+	}
+
+	store := GitserverRepos(db)
+	if err := store.SetCloneStatus(ctx, repo1.Name, types.CloneStatusCloned, shardID); err != nil {
+		t.Fatal(err)
+	}
+
+	maintained := time.Now().Truncate(time.Second).UTC()
+	if err := store.SetLastMaintained(ctx, repo1.Name, maintained, shardID); err != nil {
+		t.Fatal(err)
+	}
+
+	fromDB, err := store.GetByID(ctx, repo1.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !fromDB.LastMaintained.Equal(maintained) {
+		t.Fatalf("want last maintained %s, got %s", maintained, fromDB.LastMaintained)
+	}
+	// The rest of the state is left alone.
+	if fromDB.CloneStatus != types.CloneStatusCloned {
+		t.Fatalf("want clone status %q, got %q", types.CloneStatusCloned, fromDB.CloneStatus)
+	}
+
+	if err := store.SetReplicaLastMaintained(ctx, repo1.Name, maintained, "replica"); err != nil {
+		t.Fatal(err)
+	}
+	replicas, err := store.ListReplicas(ctx, repo1.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(replicas) != 1 || !replicas[0].LastMaintained.Equal(maintained) {
+		t.Fatalf("unexpected replicas: %+v", replicas)
+	}
+}
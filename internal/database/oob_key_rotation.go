@@ -0,0 +1,304 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/cockroachdb/errors"
+	"github.com/keegancsmith/sqlf"
+
+	"github.com/sourcegraph/sourcegraph/internal/database/basestore"
+	"github.com/sourcegraph/sourcegraph/internal/database/dbutil"
+	"github.com/sourcegraph/sourcegraph/internal/encryption"
+	"github.com/sourcegraph/sourcegraph/internal/encryption/keyring"
+)
+
+// encryptedTable describes a table with columns encrypted with one of the keys of the keyring. The
+// encryption_key_id column of the table stores the version of the key that encrypted each row.
+type encryptedTable struct {
+	name string
+	// columns are the encrypted columns, which may be NULL.
+	columns []string
+	// bytea is true if the encrypted columns are bytea rather than text columns.
+	bytea bool
+	key   func(keyring.Ring) encryption.Key
+}
+
+// encryptedTables are all the tables with encrypted columns.
+var encryptedTables = []encryptedTable{
+	{
+		name:    "external_services",
+		columns: []string{"config"},
+		key:     func(r keyring.Ring) encryption.Key { return r.ExternalServiceKey },
+	},
+	{
+		name:    "user_external_accounts",
+		columns: []string{"auth_data", "account_data"},
+		key:     func(r keyring.Ring) encryption.Key { return r.UserExternalAccountKey },
+	},
+	{
+		name:    "user_credentials",
+		columns: []string{"credential"},
+		bytea:   true,
+		key:     func(r keyring.Ring) encryption.Key { return r.BatchChangesCredentialKey },
+	},
+	{
+		name:    "batch_changes_site_credentials",
+		columns: []string{"credential"},
+		bytea:   true,
+		key:     func(r keyring.Ring) encryption.Key { return r.BatchChangesCredentialKey },
+	},
+	{
+		name:    "cm_trigger_jobs",
+		columns: []string{"search_results"},
+		bytea:   true,
+		key:     func(r keyring.Ring) encryption.Key { return r.CodeMonitorResultsKey },
+	},
+	{
+		name:    "cm_webhooks",
+		columns: []string{"url"},
+		key:     func(r keyring.Ring) encryption.Key { return r.CodeMonitorWebhookKey },
+	},
+	{
+		name:    "cm_slack_webhooks",
+		columns: []string{"url"},
+		key:     func(r keyring.Ring) encryption.Key { return r.CodeMonitorWebhookKey },
+	},
+}
+
+// unencryptedKeyIDs are the values of encryption_key_id denoting rows that the encryption migrations
+// haven't encrypted yet. The key rotation migration leaves them alone.
+var unencryptedKeyIDs = []string{"", UserCredentialPlaceholderEncryptionKeyID, UserCredentialUnmigratedEncryptionKeyID}
+
+// EncryptionKeyRotationMigrator is a background job that re-encrypts the encrypted columns of rows
+// that were encrypted with a different version of the key than the current one, for example after
+// the key was rotated or moved to a different backend. The keys that encrypted the rows must be
+// able to decrypt them: either they are the same KMS keys, which decrypt all the versions of the
+// key, or they are listed in encryption.keys.previousKeys.
+//
+// Scheduling and progress report is delegated to the out of band migration package.
+// The migration is non destructive: a downgrade can read the rows as long as the keys are
+// configured.
+type EncryptionKeyRotationMigrator struct {
+	store     *basestore.Store
+	BatchSize int
+}
+
+func NewEncryptionKeyRotationMigrator(store *basestore.Store) *EncryptionKeyRotationMigrator {
+	// not locking too many rows at a time to prevent congestion
+	return &EncryptionKeyRotationMigrator{store: store, BatchSize: 50}
+}
+
+func NewEncryptionKeyRotationMigratorWithDB(db dbutil.DB) *EncryptionKeyRotationMigrator {
+	return NewEncryptionKeyRotationMigrator(basestore.NewWithDB(db, sql.TxOptions{}))
+}
+
+// ID of the migration row in the out_of_band_migrations table.
+// This ID was defined arbitrarily in this migration file: frontend/1528395899_encryption_key_rotation_oob_migration.up.sql
+func (m *EncryptionKeyRotationMigrator) ID() int {
+	return 12
+}
+
+// Progress returns a value from 0 to 1 representing the percentage of encrypted rows that are
+// encrypted with the current version of their key.
+func (m *EncryptionKeyRotationMigrator) Progress(ctx context.Context) (float64, error) {
+	ring := keyring.Default()
+
+	var done, total int
+	for _, t := range encryptedTables {
+		key := t.key(ring)
+		if key == nil {
+			// Without a key, rows can't be encrypted and there is nothing to rotate.
+			continue
+		}
+		keyID, err := keyID(ctx, key)
+		if err != nil {
+			return 0, err
+		}
+
+		rows, err := m.store.Query(ctx, sqlf.Sprintf(
+			keyRotationProgressQuery,
+			sqlf.Sprintf(t.name),
+			keyID,
+			sqlf.Sprintf(t.name),
+			unencryptedKeyIDsQuery(),
+		))
+		if err != nil {
+			return 0, err
+		}
+		var tableDone, tableTotal int
+		for rows.Next() {
+			if err := rows.Scan(&tableDone, &tableTotal); err != nil {
+				return 0, basestore.CloseRows(rows, err)
+			}
+		}
+		if err := basestore.CloseRows(rows, nil); err != nil {
+			return 0, err
+		}
+		done += tableDone
+		total += tableTotal
+	}
+
+	if total == 0 {
+		return 1, nil
+	}
+	return float64(done) / float64(total), nil
+}
+
+const keyRotationProgressQuery = `
+-- source: internal/database/oob_key_rotation.go:Progress
+SELECT
+	(SELECT COUNT(*) FROM %s WHERE encryption_key_id = %s),
+	(SELECT COUNT(*) FROM %s WHERE encryption_key_id NOT IN (%s))
+`
+
+// Up loads BatchSize rows of each table that are encrypted with another version of the key than
+// the current one, locks them, and re-encrypts their encrypted columns with the current version of
+// the key returned by keyring.Default().
+// Up ensures the values can be decrypted with the current key before overwriting them.
+// The current key version is stored alongside the encrypted values.
+func (m *EncryptionKeyRotationMigrator) Up(ctx context.Context) (err error) {
+	ring := keyring.Default()
+
+	tx, err := m.store.Transact(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { err = tx.Done(err) }()
+
+	for _, t := range encryptedTables {
+		key := t.key(ring)
+		if key == nil {
+			continue
+		}
+		if err := m.rotate(ctx, tx, t, key); err != nil {
+			return errors.Wrapf(err, "rotating encryption key of %s", t.name)
+		}
+	}
+	return nil
+}
+
+func (m *EncryptionKeyRotationMigrator) rotate(ctx context.Context, tx *basestore.Store, t encryptedTable, key encryption.Key) error {
+	keyID, err := keyID(ctx, key)
+	if err != nil {
+		return err
+	}
+
+	columns := make([]*sqlf.Query, 0, len(t.columns))
+	for _, c := range t.columns {
+		columns = append(columns, sqlf.Sprintf(c))
+	}
+
+	// Select and lock a few records within this transaction. This ensures
+	// that many frontend instances can run the same migration concurrently
+	// without them all trying to convert the same record.
+	rows, err := tx.Query(ctx, sqlf.Sprintf(
+		keyRotationListQuery,
+		sqlf.Join(columns, ", "),
+		sqlf.Sprintf(t.name),
+		keyID,
+		unencryptedKeyIDsQuery(),
+		m.BatchSize,
+	))
+	if err != nil {
+		return err
+	}
+
+	type row struct {
+		id     int64
+		values [][]byte
+	}
+	var toRotate []row
+	for rows.Next() {
+		r := row{values: make([][]byte, len(t.columns))}
+		dest := []interface{}{&r.id}
+		for i := range r.values {
+			dest = append(dest, &r.values[i])
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return basestore.CloseRows(rows, err)
+		}
+		toRotate = append(toRotate, r)
+	}
+	if err := basestore.CloseRows(rows, nil); err != nil {
+		return err
+	}
+
+	for _, r := range toRotate {
+		sets := []*sqlf.Query{sqlf.Sprintf("encryption_key_id = %s", keyID)}
+		for i, value := range r.values {
+			if value == nil {
+				continue
+			}
+
+			reencrypted, err := reencrypt(ctx, key, value)
+			if err != nil {
+				return errors.Wrapf(err, "row %d", r.id)
+			}
+
+			if t.bytea {
+				sets = append(sets, sqlf.Sprintf(t.columns[i]+" = %s", reencrypted))
+			} else {
+				sets = append(sets, sqlf.Sprintf(t.columns[i]+" = %s", string(reencrypted)))
+			}
+		}
+
+		if err := tx.Exec(ctx, sqlf.Sprintf(
+			"UPDATE %s SET %s WHERE id = %s",
+			sqlf.Sprintf(t.name),
+			sqlf.Join(sets, ", "),
+			r.id,
+		)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+const keyRotationListQuery = `
+-- source: internal/database/oob_key_rotation.go:rotate
+SELECT id, %s FROM %s
+WHERE encryption_key_id != %s AND encryption_key_id NOT IN (%s)
+ORDER BY id ASC
+LIMIT %s
+FOR UPDATE SKIP LOCKED
+`
+
+// reencrypt decrypts value, which the key or one of the previous keys encrypted, and encrypts it
+// again with the current version of the key.
+func reencrypt(ctx context.Context, key encryption.Key, value []byte) ([]byte, error) {
+	secret, err := key.Decrypt(ctx, value)
+	if err != nil {
+		return nil, errors.Wrap(err, "decrypting")
+	}
+
+	encrypted, err := key.Encrypt(ctx, []byte(secret.Secret()))
+	if err != nil {
+		return nil, errors.Wrap(err, "encrypting")
+	}
+
+	// ensure encryption round-trip is valid
+	decrypted, err := key.Decrypt(ctx, encrypted)
+	if err != nil {
+		return nil, err
+	}
+	if decrypted.Secret() != secret.Secret() {
+		return nil, errors.New("invalid encryption round-trip")
+	}
+
+	return encrypted, nil
+}
+
+func unencryptedKeyIDsQuery() *sqlf.Query {
+	ids := make([]*sqlf.Query, 0, len(unencryptedKeyIDs))
+	for _, id := range unencryptedKeyIDs {
+		ids = append(ids, sqlf.Sprintf("%s", id))
+	}
+	return sqlf.Join(ids, ", ")
+}
+
+// Down is a no-op: the rows can be read as long as the keys that encrypted them are configured.
+func (m *EncryptionKeyRotationMigrator) Down(ctx context.Context) error {
+	return nil
+}
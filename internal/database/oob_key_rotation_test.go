@@ -0,0 +1,109 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/sourcegraph/sourcegraph/internal/conf"
+	"github.com/sourcegraph/sourcegraph/internal/database/dbtest"
+	"github.com/sourcegraph/sourcegraph/internal/encryption"
+	"github.com/sourcegraph/sourcegraph/internal/encryption/keyring"
+	et "github.com/sourcegraph/sourcegraph/internal/encryption/testing"
+	"github.com/sourcegraph/sourcegraph/internal/types"
+)
+
+// rotatedTestKey is et.TestKey with a newer version.
+type rotatedTestKey struct{ et.TestKey }
+
+func (k rotatedTestKey) Version(ctx context.Context) (encryption.KeyVersion, error) {
+	return encryption.KeyVersion{Type: "testkey", Version: "2"}, nil
+}
+
+func TestEncryptionKeyRotationMigrator(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+	ctx := context.Background()
+	db := dbtest.NewDB(t, "")
+
+	keyring.MockDefault(keyring.Ring{ExternalServiceKey: et.TestKey{}})
+	defer keyring.MockDefault(keyring.Ring{})
+
+	migrator := NewEncryptionKeyRotationMigratorWithDB(db)
+	migrator.BatchSize = 4
+
+	requireProgressEqual := func(want float64) {
+		t.Helper()
+
+		got, err := migrator.Progress(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if fmt.Sprintf("%.3f", want) != fmt.Sprintf("%.3f", got) {
+			t.Fatalf("invalid progress: want %f, got %f", want, got)
+		}
+	}
+
+	// progress on empty tables should be 1
+	requireProgressEqual(1)
+
+	// Create 8 external services encrypted with the current key
+	svcs := types.GenerateExternalServices(8, types.MakeExternalServices()...)
+	confGet := func() *conf.Unified {
+		return &conf.Unified{}
+	}
+	for _, svc := range svcs {
+		if err := ExternalServices(db).Create(ctx, confGet, svc); err != nil {
+			t.Fatal(err)
+		}
+	}
+	requireProgressEqual(1)
+
+	// rotate the key
+	key := rotatedTestKey{}
+	keyring.MockDefault(keyring.Ring{ExternalServiceKey: key})
+	requireProgressEqual(0)
+
+	if err := migrator.Up(ctx); err != nil {
+		t.Fatal(err)
+	}
+	requireProgressEqual(0.5)
+
+	if err := migrator.Up(ctx); err != nil {
+		t.Fatal(err)
+	}
+	requireProgressEqual(1)
+
+	// the configs are encrypted with the new key version
+	rows, err := db.Query("SELECT config, encryption_key_id FROM external_services ORDER BY id")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+
+	version, _ := key.Version(ctx)
+	var i int
+	for rows.Next() {
+		var config, keyID string
+		if err := rows.Scan(&config, &keyID); err != nil {
+			t.Fatal(err)
+		}
+
+		secret, err := key.Decrypt(ctx, []byte(config))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if secret.Secret() != svcs[i].Config {
+			t.Fatalf("decrypted config is different from the original one")
+		}
+		if keyID != version.JSON() {
+			t.Fatalf("wrong encryption_key_id, want %s, got %s", version.JSON(), keyID)
+		}
+
+		i++
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatal(err)
+	}
+}
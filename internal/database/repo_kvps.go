@@ -0,0 +1,183 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/cockroachdb/errors"
+	"github.com/keegancsmith/sqlf"
+
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/database/basestore"
+	"github.com/sourcegraph/sourcegraph/internal/database/dbutil"
+)
+
+// KeyValuePair is a metadata entry attached to a repository. A pair with a nil
+// Value is a tag: only the presence of its key is meaningful.
+type KeyValuePair struct {
+	Key   string
+	Value *string
+}
+
+// RepoKVPFilter selects repositories by their metadata. A filter with a nil
+// Value matches all repositories which have the key, whatever its value.
+type RepoKVPFilter struct {
+	Key   string
+	Value *string
+}
+
+// RepoKVPNotFoundErr is returned when a repository has no metadata with the
+// requested key.
+type RepoKVPNotFoundErr struct {
+	RepoID api.RepoID
+	Key    string
+}
+
+func (e *RepoKVPNotFoundErr) Error() string {
+	return fmt.Sprintf("repo metadata not found: repo=%d key=%q", e.RepoID, e.Key)
+}
+
+func (e *RepoKVPNotFoundErr) NotFound() bool {
+	return true
+}
+
+// RepoKVPStore is responsible for data stored in the repo_kvps table, which
+// holds the key/value metadata that site admins attach to repositories.
+type RepoKVPStore struct {
+	*basestore.Store
+}
+
+// RepoKVPs instantiates and returns a new RepoKVPStore.
+func RepoKVPs(db dbutil.DB) *RepoKVPStore {
+	return &RepoKVPStore{Store: basestore.NewWithDB(db, sql.TxOptions{})}
+}
+
+// RepoKVPsWith instantiates and returns a new RepoKVPStore using the other
+// store handle.
+func RepoKVPsWith(other basestore.ShareableStore) *RepoKVPStore {
+	return &RepoKVPStore{Store: basestore.NewWithHandle(other.Handle())}
+}
+
+func (s *RepoKVPStore) With(other basestore.ShareableStore) *RepoKVPStore {
+	return &RepoKVPStore{Store: s.Store.With(other)}
+}
+
+func (s *RepoKVPStore) Transact(ctx context.Context) (*RepoKVPStore, error) {
+	txBase, err := s.Store.Transact(ctx)
+	return &RepoKVPStore{Store: txBase}, err
+}
+
+// Create adds the key/value pair to the repository. It fails if the
+// repository already has the key.
+func (s *RepoKVPStore) Create(ctx context.Context, repoID api.RepoID, kvp KeyValuePair) error {
+	q := sqlf.Sprintf(`
+-- source: internal/database/repo_kvps.go:RepoKVPStore.Create
+INSERT INTO repo_kvps (repo_id, key, value)
+VALUES (%s, %s, %s)
+`, repoID, kvp.Key, kvp.Value)
+
+	if err := s.Exec(ctx, q); err != nil {
+		if dbutil.IsPostgresError(err, "23505") {
+			return errors.Errorf("repository already has metadata with key %q", kvp.Key)
+		}
+		return err
+	}
+	return nil
+}
+
+// Get returns the metadata of the repository with the given key. It returns a
+// RepoKVPNotFoundErr if the repository does not have the key.
+func (s *RepoKVPStore) Get(ctx context.Context, repoID api.RepoID, key string) (KeyValuePair, error) {
+	q := sqlf.Sprintf(`
+-- source: internal/database/repo_kvps.go:RepoKVPStore.Get
+SELECT key, value
+FROM repo_kvps
+WHERE repo_id = %s
+	AND key = %s
+`, repoID, key)
+
+	kvp, err := scanKVP(s.QueryRow(ctx, q))
+	if err == sql.ErrNoRows {
+		return KeyValuePair{}, &RepoKVPNotFoundErr{RepoID: repoID, Key: key}
+	}
+	return kvp, err
+}
+
+// List returns all the metadata of the repository, ordered by key.
+func (s *RepoKVPStore) List(ctx context.Context, repoID api.RepoID) (_ []KeyValuePair, err error) {
+	q := sqlf.Sprintf(`
+-- source: internal/database/repo_kvps.go:RepoKVPStore.List
+SELECT key, value
+FROM repo_kvps
+WHERE repo_id = %s
+ORDER BY key
+`, repoID)
+
+	rows, err := s.Query(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { err = basestore.CloseRows(rows, err) }()
+
+	var kvps []KeyValuePair
+	for rows.Next() {
+		kvp, err := scanKVP(rows)
+		if err != nil {
+			return nil, err
+		}
+		kvps = append(kvps, kvp)
+	}
+	return kvps, nil
+}
+
+// Update sets the value of an existing key of the repository. It returns a
+// RepoKVPNotFoundErr if the repository does not have the key.
+func (s *RepoKVPStore) Update(ctx context.Context, repoID api.RepoID, kvp KeyValuePair) (KeyValuePair, error) {
+	q := sqlf.Sprintf(`
+-- source: internal/database/repo_kvps.go:RepoKVPStore.Update
+UPDATE repo_kvps
+SET value = %s
+WHERE repo_id = %s
+	AND key = %s
+RETURNING key, value
+`, kvp.Value, repoID, kvp.Key)
+
+	updated, err := scanKVP(s.QueryRow(ctx, q))
+	if err == sql.ErrNoRows {
+		return KeyValuePair{}, &RepoKVPNotFoundErr{RepoID: repoID, Key: kvp.Key}
+	}
+	return updated, err
+}
+
+// Delete removes the key from the repository. Deleting a key the repository
+// does not have is not an error.
+func (s *RepoKVPStore) Delete(ctx context.Context, repoID api.RepoID, key string) error {
+	q := sqlf.Sprintf(`
+-- source: internal/database/repo_kvps.go:RepoKVPStore.Delete
+DELETE FROM repo_kvps
+WHERE repo_id = %s
+	AND key = %s
+`, repoID, key)
+
+	return s.Exec(ctx, q)
+}
+
+func scanKVP(sc dbutil.Scanner) (KeyValuePair, error) {
+	var kvp KeyValuePair
+	return kvp, sc.Scan(&kvp.Key, &kvp.Value)
+}
+
+// kvpFilterConds returns the conditions selecting the repositories which match
+// all the filters. It is used by Repos.List.
+func kvpFilterConds(filters []RepoKVPFilter) []*sqlf.Query {
+	conds := make([]*sqlf.Query, 0, len(filters))
+	for _, filter := range filters {
+		if filter.Value == nil {
+			conds = append(conds, sqlf.Sprintf("EXISTS (SELECT 1 FROM repo_kvps WHERE repo_id = repo.id AND key = %s)", filter.Key))
+		} else {
+			conds = append(conds, sqlf.Sprintf("EXISTS (SELECT 1 FROM repo_kvps WHERE repo_id = repo.id AND key = %s AND value = %s)", filter.Key, *filter.Value))
+		}
+	}
+	return conds
+}
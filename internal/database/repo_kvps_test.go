@@ -0,0 +1,71 @@
+package database
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/sourcegraph/sourcegraph/internal/actor"
+	"github.com/sourcegraph/sourcegraph/internal/database/dbtest"
+	"github.com/sourcegraph/sourcegraph/internal/errcode"
+	"github.com/sourcegraph/sourcegraph/internal/types"
+)
+
+func TestRepoKVPs(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+
+	t.Parallel()
+	db := dbtest.NewDB(t, "")
+	ctx := actor.WithInternalActor(context.Background())
+	kvps := RepoKVPs(db)
+
+	repo := mustCreate(ctx, t, db, &types.Repo{Name: "repo"}, types.CloneStatusCloned)[0]
+	strPtr := func(s string) *string { return &s }
+
+	t.Run("Create", func(t *testing.T) {
+		require.NoError(t, kvps.Create(ctx, repo.ID, KeyValuePair{Key: "tier", Value: strPtr("1")}))
+		require.NoError(t, kvps.Create(ctx, repo.ID, KeyValuePair{Key: "deprecated"}))
+
+		err := kvps.Create(ctx, repo.ID, KeyValuePair{Key: "tier", Value: strPtr("2")})
+		require.Error(t, err)
+	})
+
+	t.Run("Get", func(t *testing.T) {
+		kvp, err := kvps.Get(ctx, repo.ID, "tier")
+		require.NoError(t, err)
+		require.Equal(t, KeyValuePair{Key: "tier", Value: strPtr("1")}, kvp)
+
+		_, err = kvps.Get(ctx, repo.ID, "missing")
+		require.True(t, errcode.IsNotFound(err))
+	})
+
+	t.Run("List", func(t *testing.T) {
+		all, err := kvps.List(ctx, repo.ID)
+		require.NoError(t, err)
+		require.Equal(t, []KeyValuePair{
+			{Key: "deprecated"},
+			{Key: "tier", Value: strPtr("1")},
+		}, all)
+	})
+
+	t.Run("Update", func(t *testing.T) {
+		kvp, err := kvps.Update(ctx, repo.ID, KeyValuePair{Key: "tier", Value: strPtr("2")})
+		require.NoError(t, err)
+		require.Equal(t, KeyValuePair{Key: "tier", Value: strPtr("2")}, kvp)
+
+		_, err = kvps.Update(ctx, repo.ID, KeyValuePair{Key: "missing"})
+		require.True(t, errcode.IsNotFound(err))
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		require.NoError(t, kvps.Delete(ctx, repo.ID, "tier"))
+		require.NoError(t, kvps.Delete(ctx, repo.ID, "missing"))
+
+		all, err := kvps.List(ctx, repo.ID)
+		require.NoError(t, err)
+		require.Equal(t, []KeyValuePair{{Key: "deprecated"}}, all)
+	})
+}
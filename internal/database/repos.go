@@ -32,10 +32,14 @@ import (
 	"github.com/sourcegraph/sourcegraph/internal/extsvc/awscodecommit"
 	"github.com/sourcegraph/sourcegraph/internal/extsvc/bitbucketcloud"
 	"github.com/sourcegraph/sourcegraph/internal/extsvc/bitbucketserver"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/gerrit"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/gitea"
 	"github.com/sourcegraph/sourcegraph/internal/extsvc/github"
 	"github.com/sourcegraph/sourcegraph/internal/extsvc/gitlab"
 	"github.com/sourcegraph/sourcegraph/internal/extsvc/gitolite"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/gomodules"
 	"github.com/sourcegraph/sourcegraph/internal/extsvc/jvmpackages"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/npmpackages"
 	"github.com/sourcegraph/sourcegraph/internal/extsvc/perforce"
 	"github.com/sourcegraph/sourcegraph/internal/extsvc/phabricator"
 	"github.com/sourcegraph/sourcegraph/internal/trace"
@@ -465,6 +469,10 @@ func scanRepo(rows *sql.Rows, r *types.Repo) (err error) {
 		r.Metadata = new(bitbucketserver.Repo)
 	case extsvc.TypeBitbucketCloud:
 		r.Metadata = new(bitbucketcloud.Repo)
+	case extsvc.TypeGerrit:
+		r.Metadata = new(gerrit.Project)
+	case extsvc.TypeGitea:
+		r.Metadata = new(gitea.Repository)
 	case extsvc.TypeAWSCodeCommit:
 		r.Metadata = new(awscodecommit.Repository)
 	case extsvc.TypeGitolite:
@@ -477,6 +485,10 @@ func scanRepo(rows *sql.Rows, r *types.Repo) (err error) {
 		r.Metadata = new(extsvc.OtherRepoMetadata)
 	case extsvc.TypeJVMPackages:
 		r.Metadata = new(jvmpackages.Metadata)
+	case extsvc.TypeNPMPackages:
+		r.Metadata = new(npmpackages.Metadata)
+	case extsvc.TypeGoModules:
+		r.Metadata = new(gomodules.Metadata)
 	default:
 		log15.Warn("scanRepo - unknown service type", "typ", typ)
 		return nil
@@ -548,6 +560,11 @@ type ReposListOptions struct {
 	// SIMILAR TO matching. When zero-valued, this is omitted from the predicate set.
 	ExternalRepoExcludeContains []api.ExternalRepoSpec
 
+	// KVPFilters limits the results to repositories whose key/value metadata
+	// matches all of the filters. When zero-valued, this is omitted from the
+	// predicate set.
+	KVPFilters []RepoKVPFilter
+
 	// PatternQuery is an expression tree of patterns to query. The atoms of
 	// the query are strings which are regular expression patterns.
 	PatternQuery query.Q
@@ -884,6 +901,10 @@ func (s *RepoStore) listSQL(ctx context.Context, opt ReposListOptions) (*sqlf.Qu
 		where = append(where, sqlf.Sprintf("(%s)", sqlf.Join(er, "\n AND ")))
 	}
 
+	if len(opt.KVPFilters) > 0 {
+		where = append(where, kvpFilterConds(opt.KVPFilters)...)
+	}
+
 	if opt.NoForks {
 		where = append(where, sqlf.Sprintf("NOT fork"))
 	}
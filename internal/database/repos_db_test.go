@@ -549,6 +549,49 @@ func TestRepos_List_cloned(t *testing.T) {
 	}
 }
 
+func TestRepos_List_kvps(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+
+	t.Parallel()
+	db := dbtest.NewDB(t, "")
+	ctx := actor.WithInternalActor(context.Background())
+
+	tier1 := mustCreate(ctx, t, db, &types.Repo{Name: "a/r"}, types.CloneStatusCloned)
+	tier2 := mustCreate(ctx, t, db, &types.Repo{Name: "b/r"}, types.CloneStatusCloned)
+	untagged := mustCreate(ctx, t, db, &types.Repo{Name: "c/r"}, types.CloneStatusCloned)
+
+	strPtr := func(s string) *string { return &s }
+	for repo, value := range map[*types.Repo]string{tier1[0]: "1", tier2[0]: "2"} {
+		if err := RepoKVPs(db).Create(ctx, repo.ID, KeyValuePair{Key: "tier", Value: strPtr(value)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name string
+		opt  ReposListOptions
+		want []*types.Repo
+	}{
+		{"key and value", ReposListOptions{KVPFilters: []RepoKVPFilter{{Key: "tier", Value: strPtr("1")}}}, tier1},
+		{"key only", ReposListOptions{KVPFilters: []RepoKVPFilter{{Key: "tier"}}}, append(append([]*types.Repo(nil), tier1...), tier2...)},
+		{"all filters", ReposListOptions{KVPFilters: []RepoKVPFilter{{Key: "tier", Value: strPtr("1")}, {Key: "tier", Value: strPtr("2")}}}, nil},
+		{"missing key", ReposListOptions{KVPFilters: []RepoKVPFilter{{Key: "owner"}}}, nil},
+		{"Default", ReposListOptions{}, append(append(append([]*types.Repo(nil), tier1...), tier2...), untagged...)},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			repos, err := Repos(db).List(ctx, test.opt)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assertJSONEqual(t, test.want, repos)
+		})
+	}
+}
+
 func TestRepos_List_ids(t *testing.T) {
 	if testing.Short() {
 		t.Skip()
@@ -0,0 +1,71 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/keegancsmith/sqlf"
+
+	"github.com/sourcegraph/sourcegraph/internal/database/basestore"
+	"github.com/sourcegraph/sourcegraph/internal/database/dbutil"
+)
+
+// SCIMGroupStore provides access to the `scim_groups` table, which records the organizations
+// created through the SCIM API. The SCIM API only manages these organizations, so that an
+// identity provider can't change or delete the organizations created by users.
+type SCIMGroupStore struct {
+	*basestore.Store
+}
+
+// SCIMGroups instantiates and returns a new SCIMGroupStore with prepared statements.
+func SCIMGroups(db dbutil.DB) *SCIMGroupStore {
+	return &SCIMGroupStore{Store: basestore.NewWithDB(db, sql.TxOptions{})}
+}
+
+// SCIMGroupsWith instantiates and returns a new SCIMGroupStore using the other store handle.
+func SCIMGroupsWith(other basestore.ShareableStore) *SCIMGroupStore {
+	return &SCIMGroupStore{Store: basestore.NewWithHandle(other.Handle())}
+}
+
+// Add records that the organization orgID was created through the SCIM API.
+func (s *SCIMGroupStore) Add(ctx context.Context, orgID int32) error {
+	return s.Exec(ctx, sqlf.Sprintf("INSERT INTO scim_groups (org_id) VALUES (%s) ON CONFLICT DO NOTHING", orgID))
+}
+
+// Has reports whether the organization orgID was created through the SCIM API and is not
+// deleted.
+func (s *SCIMGroupStore) Has(ctx context.Context, orgID int32) (bool, error) {
+	orgIDs, err := s.list(ctx, sqlf.Sprintf("g.org_id = %s", orgID), nil)
+	return len(orgIDs) > 0, err
+}
+
+// ListOrgIDs returns the IDs of the organizations created through the SCIM API that are not
+// deleted, ordered by ID.
+func (s *SCIMGroupStore) ListOrgIDs(ctx context.Context, limitOffset *LimitOffset) ([]int32, error) {
+	return s.list(ctx, sqlf.Sprintf("TRUE"), limitOffset)
+}
+
+// Count counts the organizations created through the SCIM API that are not deleted.
+func (s *SCIMGroupStore) Count(ctx context.Context) (int, error) {
+	count, _, err := basestore.ScanFirstInt(s.Query(ctx, sqlf.Sprintf(`
+-- source: internal/database/scim_groups.go:Count
+SELECT COUNT(*)
+FROM scim_groups g
+JOIN orgs o ON o.id = g.org_id AND o.deleted_at IS NULL
+`)))
+	return count, err
+}
+
+func (s *SCIMGroupStore) list(ctx context.Context, cond *sqlf.Query, limitOffset *LimitOffset) ([]int32, error) {
+	return basestore.ScanInt32s(s.Query(ctx, sqlf.Sprintf(listSCIMGroupsQuery, cond, limitOffset.SQL())))
+}
+
+const listSCIMGroupsQuery = `
+-- source: internal/database/scim_groups.go:list
+SELECT g.org_id
+FROM scim_groups g
+JOIN orgs o ON o.id = g.org_id AND o.deleted_at IS NULL
+WHERE %s
+ORDER BY g.org_id ASC
+%s
+`
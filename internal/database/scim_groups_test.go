@@ -0,0 +1,61 @@
+package database
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/sourcegraph/sourcegraph/internal/database/dbtest"
+)
+
+func TestSCIMGroups(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+	t.Parallel()
+	ctx := context.Background()
+	db := dbtest.NewDB(t, "")
+	store := SCIMGroups(db)
+
+	scimOrg, err := Orgs(db).Create(ctx, "platform", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	userOrg, err := Orgs(db).Create(ctx, "acme", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := store.Add(ctx, scimOrg.ID); err != nil {
+		t.Fatal(err)
+	}
+	// Adding an organization twice is a no-op.
+	if err := store.Add(ctx, scimOrg.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	for orgID, want := range map[int32]bool{scimOrg.ID: true, userOrg.ID: false} {
+		if has, err := store.Has(ctx, orgID); err != nil || has != want {
+			t.Fatalf("Has(%d) = %t, %v, want %t", orgID, has, err, want)
+		}
+	}
+	orgIDs, err := store.ListOrgIDs(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]int32{scimOrg.ID}, orgIDs); diff != "" {
+		t.Fatalf("unexpected org IDs (-want +have):\n%s", diff)
+	}
+
+	// Deleted organizations are not included.
+	if err := Orgs(db).Delete(ctx, scimOrg.ID); err != nil {
+		t.Fatal(err)
+	}
+	if has, err := store.Has(ctx, scimOrg.ID); err != nil || has {
+		t.Fatalf("Has(%d) = %t, %v, want false", scimOrg.ID, has, err)
+	}
+	if count, err := store.Count(ctx); err != nil || count != 0 {
+		t.Fatalf("unexpected count %d (error: %v)", count, err)
+	}
+}
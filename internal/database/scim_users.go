@@ -0,0 +1,333 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/cockroachdb/errors"
+	"github.com/jackc/pgconn"
+	"github.com/keegancsmith/sqlf"
+	"github.com/lib/pq"
+
+	"github.com/sourcegraph/sourcegraph/internal/database/basestore"
+	"github.com/sourcegraph/sourcegraph/internal/database/dbutil"
+)
+
+// SCIMUser is a user provisioned by an identity provider through the SCIM API.
+type SCIMUser struct {
+	UserID int32
+	// UserName is the userName of the user in the identity provider, which may not be a valid
+	// Sourcegraph username.
+	UserName   string
+	ExternalID string
+	// Emails are the email addresses of the user, primary first.
+	Emails []string
+	// Active is false when the user was deactivated, in which case the Sourcegraph user is
+	// soft-deleted.
+	Active bool
+
+	// Username and DisplayName are those of the Sourcegraph user.
+	Username    string
+	DisplayName string
+
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// scimUserNotFoundErr is returned when a SCIM user cannot be found.
+type scimUserNotFoundErr struct {
+	args []interface{}
+}
+
+func (err scimUserNotFoundErr) Error() string {
+	return fmt.Sprintf("scim user not found: %v", err.args)
+}
+
+func (err scimUserNotFoundErr) NotFound() bool {
+	return true
+}
+
+// SCIMUserStore provides access to the `scim_users` table.
+type SCIMUserStore struct {
+	*basestore.Store
+}
+
+// SCIMUsers instantiates and returns a new SCIMUserStore with prepared statements.
+func SCIMUsers(db dbutil.DB) *SCIMUserStore {
+	return &SCIMUserStore{Store: basestore.NewWithDB(db, sql.TxOptions{})}
+}
+
+// SCIMUsersWith instantiates and returns a new SCIMUserStore using the other store handle.
+func SCIMUsersWith(other basestore.ShareableStore) *SCIMUserStore {
+	return &SCIMUserStore{Store: basestore.NewWithHandle(other.Handle())}
+}
+
+func (s *SCIMUserStore) With(other basestore.ShareableStore) *SCIMUserStore {
+	return &SCIMUserStore{Store: s.Store.With(other)}
+}
+
+func (s *SCIMUserStore) Transact(ctx context.Context) (*SCIMUserStore, error) {
+	txBase, err := s.Store.Transact(ctx)
+	return &SCIMUserStore{Store: txBase}, err
+}
+
+// Upsert creates or updates the SCIM attributes of the user u.UserID. It doesn't change whether
+// the user is active, use Deactivate and Reactivate for that.
+func (s *SCIMUserStore) Upsert(ctx context.Context, u *SCIMUser) error {
+	var externalID *string
+	if u.ExternalID != "" {
+		externalID = &u.ExternalID
+	}
+	emails := u.Emails
+	if emails == nil {
+		emails = []string{}
+	}
+
+	err := s.Exec(ctx, sqlf.Sprintf(
+		upsertSCIMUserQuery,
+		u.UserID,
+		u.UserName,
+		externalID,
+		pq.Array(emails),
+	))
+	if err != nil {
+		var e *pgconn.PgError
+		if errors.As(err, &e) && (e.ConstraintName == "scim_users_user_name" || e.ConstraintName == "scim_users_external_id") {
+			return errCannotCreateUser{errorCodeUsernameExists}
+		}
+		return err
+	}
+	return nil
+}
+
+const upsertSCIMUserQuery = `
+-- source: internal/database/scim_users.go:Upsert
+INSERT INTO scim_users (user_id, user_name, external_id, emails)
+VALUES (%s, %s, %s, %s)
+ON CONFLICT (user_id) DO UPDATE SET
+	user_name = EXCLUDED.user_name,
+	external_id = EXCLUDED.external_id,
+	emails = EXCLUDED.emails,
+	updated_at = now()
+`
+
+// GetByUserID returns the SCIM user of the given user, including deactivated users.
+func (s *SCIMUserStore) GetByUserID(ctx context.Context, userID int32) (*SCIMUser, error) {
+	users, err := s.list(ctx, []*sqlf.Query{sqlf.Sprintf("s.user_id = %s", userID)}, nil)
+	if err != nil {
+		return nil, err
+	}
+	if len(users) == 0 {
+		return nil, scimUserNotFoundErr{args: []interface{}{"userID", userID}}
+	}
+	return users[0], nil
+}
+
+// SCIMUsersListOptions specifies the options for listing SCIM users.
+type SCIMUsersListOptions struct {
+	// UserName, if set, only includes the user with this userName, case-insensitively.
+	UserName string
+	// ExternalID, if set, only includes the user with this external ID.
+	ExternalID string
+
+	*LimitOffset
+}
+
+func (o SCIMUsersListOptions) sqlConds() []*sqlf.Query {
+	conds := []*sqlf.Query{sqlf.Sprintf("TRUE")}
+	if o.UserName != "" {
+		conds = append(conds, sqlf.Sprintf("lower(s.user_name) = lower(%s)", o.UserName))
+	}
+	if o.ExternalID != "" {
+		conds = append(conds, sqlf.Sprintf("s.external_id = %s", o.ExternalID))
+	}
+	return conds
+}
+
+// List returns the SCIM users, including deactivated users, ordered by user ID.
+func (s *SCIMUserStore) List(ctx context.Context, opt SCIMUsersListOptions) ([]*SCIMUser, error) {
+	return s.list(ctx, opt.sqlConds(), opt.LimitOffset)
+}
+
+// Count counts the SCIM users, including deactivated users.
+func (s *SCIMUserStore) Count(ctx context.Context, opt SCIMUsersListOptions) (int, error) {
+	count, _, err := basestore.ScanFirstInt(s.Query(ctx, sqlf.Sprintf(
+		"SELECT COUNT(*) FROM scim_users s WHERE %s",
+		sqlf.Join(opt.sqlConds(), "AND"),
+	)))
+	return count, err
+}
+
+func (s *SCIMUserStore) list(ctx context.Context, conds []*sqlf.Query, limitOffset *LimitOffset) ([]*SCIMUser, error) {
+	rows, err := s.Query(ctx, sqlf.Sprintf(listSCIMUsersQuery, sqlf.Join(conds, "AND"), limitOffset.SQL()))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var users []*SCIMUser
+	for rows.Next() {
+		var u SCIMUser
+		var externalID, displayName sql.NullString
+		if err := rows.Scan(
+			&u.UserID,
+			&u.UserName,
+			&externalID,
+			pq.Array(&u.Emails),
+			&u.Active,
+			&u.Username,
+			&displayName,
+			&u.CreatedAt,
+			&u.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		u.ExternalID = externalID.String
+		u.DisplayName = displayName.String
+		users = append(users, &u)
+	}
+	return users, rows.Err()
+}
+
+const listSCIMUsersQuery = `
+-- source: internal/database/scim_users.go:list
+SELECT s.user_id, s.user_name, s.external_id, s.emails, s.active, u.username, u.display_name, s.created_at, s.updated_at
+FROM scim_users s
+JOIN users u ON u.id = s.user_id
+WHERE %s
+ORDER BY s.user_id ASC
+%s
+`
+
+// SetEmails makes emails the verified email addresses of the active user userID, the first one
+// being the primary email address. Other email addresses of the user are removed.
+func (s *SCIMUserStore) SetEmails(ctx context.Context, userID int32, emails []string) (err error) {
+	if len(emails) == 0 {
+		return errors.New("a user must have at least one email address")
+	}
+
+	tx, err := s.Transact(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { err = tx.Done(err) }()
+
+	userEmails := UserEmailsWith(tx)
+	existing, err := userEmails.ListByUser(ctx, UserEmailsListOptions{UserID: userID})
+	if err != nil {
+		return err
+	}
+
+	find := func(email string, in []string) bool {
+		for _, e := range in {
+			if strings.EqualFold(e, email) {
+				return true
+			}
+		}
+		return false
+	}
+	existingEmails := make([]string, 0, len(existing))
+	for _, e := range existing {
+		existingEmails = append(existingEmails, e.Email)
+	}
+
+	for _, email := range emails {
+		if !find(email, existingEmails) {
+			if err := userEmails.Add(ctx, userID, email, nil); err != nil {
+				return err
+			}
+		}
+		if err := userEmails.SetVerified(ctx, userID, email, true); err != nil {
+			return err
+		}
+	}
+	if err := userEmails.SetPrimaryEmail(ctx, userID, emails[0]); err != nil {
+		return err
+	}
+	for _, email := range existingEmails {
+		if !find(email, emails) {
+			if err := userEmails.Remove(ctx, userID, email); err != nil {
+				return err
+			}
+		}
+	}
+
+	return tx.Exec(ctx, sqlf.Sprintf("UPDATE scim_users SET emails = %s, updated_at = now() WHERE user_id = %s", pq.Array(emails), userID))
+}
+
+// Deactivate soft-deletes the user userID, which revokes their access tokens and sessions, and
+// marks the SCIM user as inactive.
+func (s *SCIMUserStore) Deactivate(ctx context.Context, userID int32) (err error) {
+	tx, err := s.Transact(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { err = tx.Done(err) }()
+
+	if err := UsersWith(tx).Delete(ctx, userID); err != nil {
+		return err
+	}
+	return tx.Exec(ctx, sqlf.Sprintf("UPDATE scim_users SET active = false, updated_at = now() WHERE user_id = %s", userID))
+}
+
+// Delete soft-deletes the user userID, unless they were deactivated already, and removes the
+// SCIM user: the user isn't managed through the SCIM API anymore, but the data of their account
+// is kept.
+func (s *SCIMUserStore) Delete(ctx context.Context, userID int32) (err error) {
+	tx, err := s.Transact(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { err = tx.Done(err) }()
+
+	user, err := tx.GetByUserID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if user.Active {
+		if err := UsersWith(tx).Delete(ctx, userID); err != nil {
+			return err
+		}
+	}
+	return tx.Exec(ctx, sqlf.Sprintf("DELETE FROM scim_users WHERE user_id = %s", userID))
+}
+
+// Reactivate restores the user userID that was deactivated, with their username and the email
+// addresses of the SCIM user.
+func (s *SCIMUserStore) Reactivate(ctx context.Context, userID int32) (err error) {
+	tx, err := s.Transact(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { err = tx.Done(err) }()
+
+	user, err := tx.GetByUserID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if user.Active {
+		return nil
+	}
+
+	if err := tx.Exec(ctx, sqlf.Sprintf("UPDATE users SET deleted_at = NULL, updated_at = now() WHERE id = %s", userID)); err != nil {
+		return err
+	}
+	// Claim the username again, it was released when the user was deactivated.
+	if err := tx.Exec(ctx, sqlf.Sprintf("INSERT INTO names (name, user_id) VALUES (%s, %s)", user.Username, userID)); err != nil {
+		var e *pgconn.PgError
+		if errors.As(err, &e) && e.ConstraintName == "names_pkey" {
+			return errCannotCreateUser{errorCodeUsernameExists}
+		}
+		return err
+	}
+	if err := tx.Exec(ctx, sqlf.Sprintf("UPDATE scim_users SET active = true, updated_at = now() WHERE user_id = %s", userID)); err != nil {
+		return err
+	}
+	if len(user.Emails) > 0 {
+		return tx.SetEmails(ctx, userID, user.Emails)
+	}
+	return nil
+}
@@ -0,0 +1,131 @@
+package database
+
+import (
+	"context"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/sourcegraph/sourcegraph/internal/database/dbtest"
+	"github.com/sourcegraph/sourcegraph/internal/errcode"
+)
+
+func TestSCIMUsers(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+	t.Parallel()
+	ctx := context.Background()
+	db := dbtest.NewDB(t, "")
+	store := SCIMUsers(db)
+
+	user, err := Users(db).Create(ctx, NewUser{Username: "alice", Email: "alice@example.com", EmailIsVerified: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := store.GetByUserID(ctx, user.ID); !errcode.IsNotFound(err) {
+		t.Fatalf("expected not found error, got %v", err)
+	}
+
+	u := &SCIMUser{
+		UserID:     user.ID,
+		UserName:   "Alice@example.com",
+		ExternalID: "00u1",
+		Emails:     []string{"alice@work.example.com", "alice@example.com"},
+	}
+	if err := store.Upsert(ctx, u); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.SetEmails(ctx, user.ID, u.Emails); err != nil {
+		t.Fatal(err)
+	}
+
+	have, err := store.GetByUserID(ctx, user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !have.Active || have.Username != "alice" || have.ExternalID != "00u1" {
+		t.Fatalf("unexpected SCIM user: %+v", have)
+	}
+	email, verified, err := UserEmails(db).GetPrimaryEmail(ctx, user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if email != "alice@work.example.com" || !verified {
+		t.Fatalf("unexpected primary email %q (verified: %t)", email, verified)
+	}
+
+	for _, opt := range []SCIMUsersListOptions{
+		{UserName: "alice@EXAMPLE.com"},
+		{ExternalID: "00u1"},
+	} {
+		users, err := store.List(ctx, opt)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(users) != 1 || users[0].UserID != user.ID {
+			t.Fatalf("%+v: unexpected users %+v", opt, users)
+		}
+	}
+
+	// Another user can't have the same userName.
+	other, err := Users(db).Create(ctx, NewUser{Username: "bob", Email: "bob@example.com", EmailIsVerified: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Upsert(ctx, &SCIMUser{UserID: other.ID, UserName: "alice@example.com"}); !IsUsernameExists(err) {
+		t.Fatalf("expected username exists error, got %v", err)
+	}
+
+	// Deactivated users are soft-deleted, and restored when reactivated.
+	if err := store.Deactivate(ctx, user.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Users(db).GetByID(ctx, user.ID); !errcode.IsNotFound(err) {
+		t.Fatalf("expected deactivated user to be deleted, got %v", err)
+	}
+	if count, err := store.Count(ctx, SCIMUsersListOptions{}); err != nil || count != 1 {
+		t.Fatalf("unexpected count %d (error: %v)", count, err)
+	}
+
+	if err := store.Reactivate(ctx, user.ID); err != nil {
+		t.Fatal(err)
+	}
+	reactivated, err := Users(db).GetByID(ctx, user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reactivated.Username != "alice" {
+		t.Fatalf("unexpected username %q", reactivated.Username)
+	}
+	emails, err := UserEmails(db).ListByUser(ctx, UserEmailsListOptions{UserID: user.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var haveEmails []string
+	for _, e := range emails {
+		haveEmails = append(haveEmails, e.Email)
+	}
+	sort.Strings(haveEmails)
+	if diff := cmp.Diff([]string{"alice@example.com", "alice@work.example.com"}, haveEmails); diff != "" {
+		t.Fatalf("unexpected emails (-want +have):\n%s", diff)
+	}
+
+	// Deleted users are soft-deleted, and not SCIM users anymore.
+	if err := store.Delete(ctx, user.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Users(db).GetByID(ctx, user.ID); !errcode.IsNotFound(err) {
+		t.Fatalf("expected deleted user to be soft-deleted, got %v", err)
+	}
+	var deletedAt *time.Time
+	if err := db.QueryRowContext(ctx, "SELECT deleted_at FROM users WHERE id = $1", user.ID).Scan(&deletedAt); err != nil {
+		t.Fatalf("expected deleted user to be kept: %v", err)
+	}
+	if _, err := store.GetByUserID(ctx, user.ID); !errcode.IsNotFound(err) {
+		t.Fatalf("expected not found error, got %v", err)
+	}
+}
@@ -8,8 +8,10 @@ import (
 
 	"github.com/cockroachdb/errors"
 	"github.com/inconshreveable/log15"
+	"github.com/keegancsmith/sqlf"
 
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/envvar"
+	"github.com/sourcegraph/sourcegraph/internal/conf"
 	"github.com/sourcegraph/sourcegraph/internal/database/basestore"
 	"github.com/sourcegraph/sourcegraph/internal/database/dbutil"
 	"github.com/sourcegraph/sourcegraph/internal/sentry"
@@ -32,6 +34,16 @@ const (
 	SecurityEventNameAccountDeleted SecurityEventName = "AccountDeleted"
 	SecurityEventNameAccountNuked   SecurityEventName = "AccountNuked"
 
+	SecurityEventNameAccountUpdated     SecurityEventName = "AccountUpdated"
+	SecurityEventNameAccountDeactivated SecurityEventName = "AccountDeactivated"
+	SecurityEventNameAccountReactivated SecurityEventName = "AccountReactivated"
+
+	SecurityEventNameOrgCreated       SecurityEventName = "OrgCreated"
+	SecurityEventNameOrgUpdated       SecurityEventName = "OrgUpdated"
+	SecurityEventNameOrgDeleted       SecurityEventName = "OrgDeleted"
+	SecurityEventNameOrgMemberAdded   SecurityEventName = "OrgMemberAdded"
+	SecurityEventNameOrgMemberRemoved SecurityEventName = "OrgMemberRemoved"
+
 	SecurityEventNamPasswordResetRequested SecurityEventName = "PasswordResetRequested"
 	SecurityEventNamPasswordRandomized     SecurityEventName = "PasswordRandomized"
 	SecurityEventNamePasswordChanged       SecurityEventName = "PasswordChanged"
@@ -40,12 +52,27 @@ const (
 
 	SecurityEventNameRoleChangeDenied  SecurityEventName = "RoleChangeDenied"
 	SecurityEventNameRoleChangeGranted SecurityEventName = "RoleChangeGranted"
+	SecurityEventNameRoleChangeRevoked SecurityEventName = "RoleChangeRevoked"
 
 	SecurityEventNameAccessGranted SecurityEventName = "AccessGranted"
+
+	SecurityEventNameAccessTokenCreated SecurityEventName = "AccessTokenCreated"
+	SecurityEventNameAccessTokenDeleted SecurityEventName = "AccessTokenDeleted"
+	SecurityEventNameAccessTokenUsed    SecurityEventName = "AccessTokenUsed"
+	SecurityEventNameSudoUsed           SecurityEventName = "SudoUsed"
+
+	SecurityEventNameSiteConfigUpdated SecurityEventName = "SiteConfigUpdated"
+
+	SecurityEventNameRepoPermissionsUpdated SecurityEventName = "RepoPermissionsUpdated"
 )
 
 // SecurityEvent contains information needed for logging a security-relevant event.
 type SecurityEvent struct {
+	// ID, TxID and Version are set when the event is read from the store.
+	ID int64
+	// TxID is the ID of the transaction that recorded the event, see ListAfter.
+	TxID            int64
+	Version         string
 	Name            SecurityEventName
 	URL             string
 	UserID          uint32
@@ -65,6 +92,21 @@ func SecurityEventLogs(db dbutil.DB) *SecurityEventLogStore {
 	return &SecurityEventLogStore{Store: basestore.NewWithDB(db, sql.TxOptions{})}
 }
 
+func (s *SecurityEventLogStore) Transact(ctx context.Context) (*SecurityEventLogStore, error) {
+	txBase, err := s.Store.Transact(ctx)
+	return &SecurityEventLogStore{Store: txBase}, err
+}
+
+// SecurityEventLoggingEnabled reports whether security events are logged by LogEvent, which is
+// always the case on Sourcegraph.com, and on other instances when log.securityEvents is set.
+func SecurityEventLoggingEnabled() bool {
+	if envvar.SourcegraphDotComMode() {
+		return true
+	}
+	log := conf.Get().Log
+	return log != nil && log.SecurityEvents != nil
+}
+
 // Insert adds a new security event to the store.
 func (s *SecurityEventLogStore) Insert(ctx context.Context, e *SecurityEvent) error {
 	argument := e.Argument
@@ -94,9 +136,9 @@ func (s *SecurityEventLogStore) Insert(ctx context.Context, e *SecurityEvent) er
 //
 // Note that it does not return an error and will instead simply log it.
 func (s *SecurityEventLogStore) LogEvent(ctx context.Context, e *SecurityEvent) {
-	// We don't want to begin logging authentication or authorization events in
-	// on-premises installations yet.
-	if !envvar.SourcegraphDotComMode() {
+	// On-premises installations only log authentication and authorization events
+	// when the site admin opted in.
+	if !SecurityEventLoggingEnabled() {
 		return
 	}
 
@@ -108,3 +150,147 @@ func (s *SecurityEventLogStore) LogEvent(ctx context.Context, e *SecurityEvent)
 		sentry.CaptureError(err, map[string]string{})
 	}
 }
+
+// SecurityEventsListOptions specifies the options for listing security events.
+type SecurityEventsListOptions struct {
+	// Name, if set, only lists the events with this name.
+	Name SecurityEventName
+	// UserID, if set, only lists the events of this user.
+	UserID int32
+	// Before, if set, only lists the events with a lower ID. It is used as a cursor to paginate
+	// through the events, newest first.
+	Before int64
+	// Limit is the maximum number of events listed. All events are listed if it is zero.
+	Limit int
+}
+
+func (o SecurityEventsListOptions) sqlConditions() []*sqlf.Query {
+	conds := []*sqlf.Query{sqlf.Sprintf("TRUE")}
+	if o.Name != "" {
+		conds = append(conds, sqlf.Sprintf("name = %s", o.Name))
+	}
+	if o.UserID != 0 {
+		conds = append(conds, sqlf.Sprintf("user_id = %d", o.UserID))
+	}
+	if o.Before != 0 {
+		conds = append(conds, sqlf.Sprintf("id < %d", o.Before))
+	}
+	return conds
+}
+
+// List lists the security events matching the options, newest first.
+func (s *SecurityEventLogStore) List(ctx context.Context, opt SecurityEventsListOptions) ([]*SecurityEvent, error) {
+	limit := sqlf.Sprintf("")
+	if opt.Limit > 0 {
+		limit = sqlf.Sprintf("LIMIT %d", opt.Limit)
+	}
+	return s.list(ctx, sqlf.Sprintf(listSecurityEventsQueryFmtstr, sqlf.Join(opt.sqlConditions(), "AND"), sqlf.Sprintf("id DESC"), limit))
+}
+
+// SecurityEventCursor is a position in the security events, in the order of ListAfter.
+type SecurityEventCursor struct {
+	TxID    int64
+	EventID int64
+}
+
+// ListAfter lists at most limit security events recorded after the cursor, ordered by the ID of
+// the transaction that recorded them, then by ID. Event IDs are allocated when events are inserted
+// rather than when they are committed, so an event may become visible after events with greater
+// IDs. Instead, only the events of the transactions older than the oldest running transaction are
+// listed: as new events can't be recorded by these transactions anymore, readers that resume
+// after the last listed event don't skip any.
+func (s *SecurityEventLogStore) ListAfter(ctx context.Context, after SecurityEventCursor, limit int) ([]*SecurityEvent, error) {
+	cond := sqlf.Sprintf(
+		"(txid, id) > (%s, %s) AND txid < txid_snapshot_xmin(txid_current_snapshot())",
+		after.TxID,
+		after.EventID,
+	)
+	return s.list(ctx, sqlf.Sprintf(listSecurityEventsQueryFmtstr, cond, sqlf.Sprintf("txid, id"), sqlf.Sprintf("LIMIT %d", limit)))
+}
+
+const listSecurityEventsQueryFmtstr = `
+-- source: internal/database/security_event_logs.go:list
+SELECT id, txid, name, url, user_id, anonymous_user_id, source, argument, version, timestamp
+FROM security_event_logs
+WHERE %s
+ORDER BY %s
+%s
+`
+
+func (s *SecurityEventLogStore) list(ctx context.Context, q *sqlf.Query) ([]*SecurityEvent, error) {
+	rows, err := s.Query(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []*SecurityEvent
+	for rows.Next() {
+		var e SecurityEvent
+		if err := rows.Scan(&e.ID, &e.TxID, &e.Name, &e.URL, &e.UserID, &e.AnonymousUserID, &e.Source, &e.Argument, &e.Version, &e.Timestamp); err != nil {
+			return nil, err
+		}
+		events = append(events, &e)
+	}
+	return events, rows.Err()
+}
+
+// Count counts the security events matching the options, ignoring Limit.
+func (s *SecurityEventLogStore) Count(ctx context.Context, opt SecurityEventsListOptions) (int, error) {
+	count, _, err := basestore.ScanFirstInt(s.Query(ctx, sqlf.Sprintf("SELECT COUNT(*) FROM security_event_logs WHERE %s", sqlf.Join(opt.sqlConditions(), "AND"))))
+	return count, err
+}
+
+// ClaimSinkCursor returns the position of the last security event delivered to the sink, and
+// claims the sink for the given duration, or until ReleaseSinkCursor is called. Sinks that are new
+// start at the latest event. It returns false if the sink is already claimed.
+func (s *SecurityEventLogStore) ClaimSinkCursor(ctx context.Context, sink string, d time.Duration) (_ SecurityEventCursor, ok bool, err error) {
+	if err := s.Exec(ctx, sqlf.Sprintf(`
+-- source: internal/database/security_event_logs.go:ClaimSinkCursor
+INSERT INTO security_event_log_sink_cursors (sink, last_txid, last_event_id)
+SELECT %s::text, COALESCE(MAX(txid), 0), COALESCE(MAX(id), 0) FROM security_event_logs
+ON CONFLICT DO NOTHING
+`, sink)); err != nil {
+		return SecurityEventCursor{}, false, err
+	}
+
+	rows, err := s.Query(ctx, sqlf.Sprintf(`
+-- source: internal/database/security_event_logs.go:ClaimSinkCursor
+UPDATE security_event_log_sink_cursors
+SET locked_until = now() + make_interval(secs => %s)
+WHERE sink = %s AND (locked_until IS NULL OR locked_until < now())
+RETURNING last_txid, last_event_id
+`, d.Seconds(), sink))
+	if err != nil {
+		return SecurityEventCursor{}, false, err
+	}
+	defer func() { err = basestore.CloseRows(rows, err) }()
+
+	var c SecurityEventCursor
+	if rows.Next() {
+		if err := rows.Scan(&c.TxID, &c.EventID); err != nil {
+			return SecurityEventCursor{}, false, err
+		}
+		ok = true
+	}
+	return c, ok, nil
+}
+
+// UpdateSinkCursor records that the security events up to the cursor were delivered to the sink,
+// and extends its claim for the given duration. The cursor never moves backwards.
+func (s *SecurityEventLogStore) UpdateSinkCursor(ctx context.Context, sink string, c SecurityEventCursor, d time.Duration) error {
+	return s.Exec(ctx, sqlf.Sprintf(`
+-- source: internal/database/security_event_logs.go:UpdateSinkCursor
+UPDATE security_event_log_sink_cursors
+SET last_txid = %s, last_event_id = %s, locked_until = now() + make_interval(secs => %s), updated_at = now()
+WHERE sink = %s AND (last_txid, last_event_id) < (%s, %s)
+`, c.TxID, c.EventID, d.Seconds(), sink, c.TxID, c.EventID))
+}
+
+// ReleaseSinkCursor releases the claim of ClaimSinkCursor on the sink.
+func (s *SecurityEventLogStore) ReleaseSinkCursor(ctx context.Context, sink string) error {
+	return s.Exec(ctx, sqlf.Sprintf(
+		"UPDATE security_event_log_sink_cursors SET locked_until = NULL WHERE sink = %s",
+		sink,
+	))
+}
@@ -4,6 +4,10 @@ import (
 	"context"
 	"fmt"
 	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/keegancsmith/sqlf"
 
 	"github.com/sourcegraph/sourcegraph/internal/database/dbtest"
 )
@@ -67,3 +71,174 @@ func TestSecurityEventLogs_ValidInfo(t *testing.T) {
 		})
 	}
 }
+
+func TestSecurityEventLogs_List(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+	t.Parallel()
+	db := dbtest.NewDB(t, "")
+	ctx := context.Background()
+	store := SecurityEventLogs(db)
+
+	for _, e := range []*SecurityEvent{
+		{Name: SecurityEventNameSignInSucceeded, UserID: 1, Source: "BACKEND"},
+		{Name: SecurityEventNameAccessTokenCreated, UserID: 2, Source: "BACKEND"},
+		{Name: SecurityEventNameSignInSucceeded, UserID: 2, Source: "BACKEND"},
+	} {
+		if err := store.Insert(ctx, e); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	names := func(events []*SecurityEvent) (names []string) {
+		for _, e := range events {
+			names = append(names, fmt.Sprintf("%s:%d", e.Name, e.UserID))
+		}
+		return names
+	}
+
+	all, err := store.List(ctx, SecurityEventsListOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]string{"SignInSucceeded:2", "AccessTokenCreated:2", "SignInSucceeded:1"}, names(all)); diff != "" {
+		t.Fatalf("unexpected events (-want +have):\n%s", diff)
+	}
+
+	for _, tc := range []struct {
+		opt   SecurityEventsListOptions
+		want  []string
+		count int
+	}{
+		{
+			opt:   SecurityEventsListOptions{Name: SecurityEventNameSignInSucceeded},
+			want:  []string{"SignInSucceeded:2", "SignInSucceeded:1"},
+			count: 2,
+		},
+		{
+			opt:   SecurityEventsListOptions{UserID: 2, Limit: 1},
+			want:  []string{"SignInSucceeded:2"},
+			count: 2,
+		},
+		{
+			opt:   SecurityEventsListOptions{Before: all[0].ID, Limit: 1},
+			want:  []string{"AccessTokenCreated:2"},
+			count: 2,
+		},
+	} {
+		events, err := store.List(ctx, tc.opt)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff(tc.want, names(events)); diff != "" {
+			t.Errorf("%+v: unexpected events (-want +have):\n%s", tc.opt, diff)
+		}
+		if count, err := store.Count(ctx, tc.opt); err != nil || count != tc.count {
+			t.Errorf("%+v: unexpected count %d (error: %v)", tc.opt, count, err)
+		}
+	}
+
+	start := SecurityEventCursor{TxID: all[2].TxID, EventID: all[2].ID}
+	after, err := store.ListAfter(ctx, start, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]string{"AccessTokenCreated:2", "SignInSucceeded:2"}, names(after)); diff != "" {
+		t.Fatalf("unexpected events (-want +have):\n%s", diff)
+	}
+
+	// Events are listed in the order of the transactions that recorded them, up to the oldest
+	// running transaction.
+	tx, err := store.Transact(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = tx.Done(nil) }()
+	if err := tx.Exec(ctx, sqlf.Sprintf("SELECT txid_current()")); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Insert(ctx, &SecurityEvent{Name: SecurityEventNameSignOutSucceeded, UserID: 3, Source: "BACKEND"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Insert(ctx, &SecurityEvent{Name: SecurityEventNameSignInSucceeded, UserID: 3, Source: "BACKEND"}); err != nil {
+		t.Fatal(err)
+	}
+	after, err = store.ListAfter(ctx, start, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]string{"AccessTokenCreated:2", "SignInSucceeded:2"}, names(after)); diff != "" {
+		t.Fatalf("unexpected events (-want +have):\n%s", diff)
+	}
+
+	if err := tx.Done(nil); err != nil {
+		t.Fatal(err)
+	}
+	after, err = store.ListAfter(ctx, start, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]string{"AccessTokenCreated:2", "SignInSucceeded:2", "SignInSucceeded:3", "SignOutSucceeded:3"}, names(after)); diff != "" {
+		t.Fatalf("unexpected events (-want +have):\n%s", diff)
+	}
+}
+
+func TestSecurityEventLogs_SinkCursor(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+	t.Parallel()
+	db := dbtest.NewDB(t, "")
+	ctx := context.Background()
+
+	if err := SecurityEventLogs(db).Insert(ctx, &SecurityEvent{Name: SecurityEventNameSignInSucceeded, UserID: 1, Source: "BACKEND"}); err != nil {
+		t.Fatal(err)
+	}
+	events, err := SecurityEventLogs(db).List(ctx, SecurityEventsListOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const sink = "file:/tmp/events.jsonl"
+	store := SecurityEventLogs(db)
+
+	// New sinks start at the latest event.
+	c, ok, err := store.ClaimSinkCursor(ctx, sink, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (SecurityEventCursor{TxID: events[0].TxID, EventID: events[0].ID}); !ok || c != want {
+		t.Fatalf("unexpected cursor %+v (ok: %t), want %+v", c, ok, want)
+	}
+
+	// The sink can't be claimed again until it is released.
+	if _, ok, err := store.ClaimSinkCursor(ctx, sink, time.Hour); err != nil || ok {
+		t.Fatalf("expected the sink to be claimed (error: %v)", err)
+	}
+
+	next := SecurityEventCursor{TxID: c.TxID + 1, EventID: 1}
+	if err := store.UpdateSinkCursor(ctx, sink, next, time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	// The cursor never moves backwards.
+	if err := store.UpdateSinkCursor(ctx, sink, c, time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.ReleaseSinkCursor(ctx, sink); err != nil {
+		t.Fatal(err)
+	}
+
+	c, ok, err = store.ClaimSinkCursor(ctx, sink, -time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ok || c != next {
+		t.Fatalf("unexpected cursor %+v (ok: %t), want %+v", c, ok, next)
+	}
+
+	// Expired claims don't prevent claiming the sink.
+	if _, ok, err := store.ClaimSinkCursor(ctx, sink, time.Hour); err != nil || !ok {
+		t.Fatalf("expected the expired claim to be ignored (error: %v)", err)
+	}
+}
@@ -655,6 +655,17 @@ func (u *UserStore) SetIsSiteAdmin(ctx context.Context, id int32, isSiteAdmin bo
 	return err
 }
 
+// CountSiteAdmins counts the site admins that are not deleted. In a transaction, it also locks
+// them until the end of the transaction, so that concurrent transactions can't demote them all.
+func (u *UserStore) CountSiteAdmins(ctx context.Context) (int, error) {
+	u.ensureStore()
+
+	count, _, err := basestore.ScanFirstInt(u.Query(ctx, sqlf.Sprintf(
+		"SELECT COUNT(*) FROM (SELECT id FROM users WHERE site_admin AND deleted_at IS NULL FOR UPDATE) admins",
+	)))
+	return count, err
+}
+
 // CheckAndDecrementInviteQuota should be called before the user (identified
 // by userID) is allowed to invite any other user. If ok is false, then the
 // user is not allowed to invite any other user (either because they've
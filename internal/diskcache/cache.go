@@ -139,6 +139,27 @@ func (s *Store) OpenWithPath(ctx context.Context, key string, fetcher FetcherWit
 	}
 }
 
+// OpenIfExists will open a file from the local cache with key. Unlike Open it
+// never fetches: if key is not in the cache an error satisfying os.IsNotExist
+// is returned.
+func (s *Store) OpenIfExists(key string) (*File, error) {
+	if s.Dir == "" {
+		return nil, errors.New("diskcache.Store.Dir must be set")
+	}
+
+	path := s.path(key)
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	// Update modified time. Modified time is used to decide which files to
+	// evict from the cache.
+	touch(path)
+
+	return &File{File: f, Path: path}, nil
+}
+
 // path returns the path for key.
 func (s *Store) path(key string) string {
 	// path uses a sha256 hash of the key since we want to use it for the
@@ -13,6 +13,7 @@ import (
 	"github.com/sourcegraph/sourcegraph/internal/encryption/cache"
 	"github.com/sourcegraph/sourcegraph/internal/encryption/cloudkms"
 	"github.com/sourcegraph/sourcegraph/internal/encryption/mounted"
+	"github.com/sourcegraph/sourcegraph/internal/encryption/vault"
 	"github.com/sourcegraph/sourcegraph/schema"
 )
 
@@ -85,11 +86,37 @@ func NewRing(ctx context.Context, keyConfig *schema.EncryptionKeys) (*Ring, erro
 		err error
 	)
 
+	previous := make([]encryption.Key, 0, len(keyConfig.PreviousKeys))
+	for _, k := range keyConfig.PreviousKeys {
+		key, err := NewKey(ctx, k, keyConfig)
+		if err != nil {
+			return nil, errors.Wrap(err, "previous key")
+		}
+		previous = append(previous, key)
+	}
+
 	if keyConfig.BatchChangesCredentialKey != nil {
 		r.BatchChangesCredentialKey, err = NewKey(ctx, keyConfig.BatchChangesCredentialKey, keyConfig)
 		if err != nil {
 			return nil, err
 		}
+		r.BatchChangesCredentialKey = withPreviousKeys(r.BatchChangesCredentialKey, previous)
+	}
+
+	if keyConfig.CodeMonitorResultsKey != nil {
+		r.CodeMonitorResultsKey, err = NewKey(ctx, keyConfig.CodeMonitorResultsKey, keyConfig)
+		if err != nil {
+			return nil, err
+		}
+		r.CodeMonitorResultsKey = withPreviousKeys(r.CodeMonitorResultsKey, previous)
+	}
+
+	if keyConfig.CodeMonitorWebhookKey != nil {
+		r.CodeMonitorWebhookKey, err = NewKey(ctx, keyConfig.CodeMonitorWebhookKey, keyConfig)
+		if err != nil {
+			return nil, err
+		}
+		r.CodeMonitorWebhookKey = withPreviousKeys(r.CodeMonitorWebhookKey, previous)
 	}
 
 	if keyConfig.ExternalServiceKey != nil {
@@ -97,6 +124,7 @@ func NewRing(ctx context.Context, keyConfig *schema.EncryptionKeys) (*Ring, erro
 		if err != nil {
 			return nil, err
 		}
+		r.ExternalServiceKey = withPreviousKeys(r.ExternalServiceKey, previous)
 	}
 
 	if keyConfig.UserExternalAccountKey != nil {
@@ -104,6 +132,7 @@ func NewRing(ctx context.Context, keyConfig *schema.EncryptionKeys) (*Ring, erro
 		if err != nil {
 			return nil, err
 		}
+		r.UserExternalAccountKey = withPreviousKeys(r.UserExternalAccountKey, previous)
 	}
 
 	return &r, nil
@@ -111,6 +140,8 @@ func NewRing(ctx context.Context, keyConfig *schema.EncryptionKeys) (*Ring, erro
 
 type Ring struct {
 	BatchChangesCredentialKey encryption.Key
+	CodeMonitorResultsKey     encryption.Key
+	CodeMonitorWebhookKey     encryption.Key
 	ExternalServiceKey        encryption.Key
 	UserExternalAccountKey    encryption.Key
 }
@@ -130,6 +161,8 @@ func NewKey(ctx context.Context, k *schema.EncryptionKey, config *schema.Encrypt
 		key, err = awskms.NewKey(ctx, *k.Awskms)
 	case k.Mounted != nil:
 		key, err = mounted.NewKey(ctx, *k.Mounted)
+	case k.Vault != nil:
+		key, err = vault.NewKey(ctx, *k.Vault)
 	case k.Noop != nil:
 		key = &encryption.NoopKey{}
 	default:
@@ -144,3 +177,48 @@ func NewKey(ctx context.Context, k *schema.EncryptionKey, config *schema.Encrypt
 	}
 	return key, err
 }
+
+// withPreviousKeys returns a key that encrypts with key, and decrypts with key or, if that fails,
+// one of the previous keys. This lets data encrypted before a key rotation be read until it is
+// re-encrypted with the current key.
+func withPreviousKeys(key encryption.Key, previous []encryption.Key) encryption.Key {
+	if len(previous) == 0 {
+		return key
+	}
+
+	// Noop keys "decrypt" anything, so they must be tried last.
+	var keys, noop []encryption.Key
+	for _, k := range append([]encryption.Key{key}, previous...) {
+		if isNoop(k) {
+			noop = append(noop, k)
+		} else {
+			keys = append(keys, k)
+		}
+	}
+	return &rotatedKey{Key: key, decrypters: append(keys, noop...)}
+}
+
+func isNoop(k encryption.Key) bool {
+	if c, ok := k.(*cache.Key); ok {
+		k = c.Key
+	}
+	_, ok := k.(*encryption.NoopKey)
+	return ok
+}
+
+// rotatedKey is an encryption.Key that can also decrypt data encrypted with previous keys.
+type rotatedKey struct {
+	encryption.Key
+	decrypters []encryption.Key
+}
+
+func (k *rotatedKey) Decrypt(ctx context.Context, ciphertext []byte) (*encryption.Secret, error) {
+	var err error
+	for _, d := range k.decrypters {
+		var secret *encryption.Secret
+		if secret, err = d.Decrypt(ctx, ciphertext); err == nil {
+			return secret, nil
+		}
+	}
+	return nil, err
+}
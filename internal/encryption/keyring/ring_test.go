@@ -0,0 +1,81 @@
+package keyring
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/util/rand"
+
+	"github.com/sourcegraph/sourcegraph/schema"
+)
+
+func TestNewRing_PreviousKeys(t *testing.T) {
+	ctx := context.Background()
+
+	mountedKey := func(name string) *schema.EncryptionKey {
+		envVar := "testnewring_previouskeys_" + name
+		require.NoError(t, os.Setenv(envVar, rand.String(32)))
+		t.Cleanup(func() { os.Unsetenv(envVar) })
+
+		return &schema.EncryptionKey{Mounted: &schema.MountedEncryptionKey{
+			Type:       "mounted",
+			Keyname:    "testkey/" + name,
+			EnvVarName: envVar,
+		}}
+	}
+
+	oldKey := mountedKey("old")
+	newKey := mountedKey("new")
+
+	old, err := NewRing(ctx, &schema.EncryptionKeys{ExternalServiceKey: oldKey})
+	require.NoError(t, err)
+	oldCiphertext, err := old.ExternalServiceKey.Encrypt(ctx, []byte("old secret"))
+	require.NoError(t, err)
+
+	t.Run("without previous keys", func(t *testing.T) {
+		ring, err := NewRing(ctx, &schema.EncryptionKeys{ExternalServiceKey: newKey})
+		require.NoError(t, err)
+
+		_, err = ring.ExternalServiceKey.Decrypt(ctx, oldCiphertext)
+		assert.Error(t, err)
+	})
+
+	t.Run("with previous keys", func(t *testing.T) {
+		ring, err := NewRing(ctx, &schema.EncryptionKeys{
+			ExternalServiceKey: newKey,
+			PreviousKeys: []*schema.EncryptionKey{
+				{Noop: &schema.NoOpEncryptionKey{Type: "noop"}},
+				oldKey,
+			},
+		})
+		require.NoError(t, err)
+		key := ring.ExternalServiceKey
+
+		// Data encrypted with the previous key can still be read, and the
+		// noop key is not tried before it.
+		secret, err := key.Decrypt(ctx, oldCiphertext)
+		require.NoError(t, err)
+		assert.Equal(t, "old secret", secret.Secret())
+
+		// New data is encrypted with the current key.
+		newCiphertext, err := key.Encrypt(ctx, []byte("new secret"))
+		require.NoError(t, err)
+		_, err = old.ExternalServiceKey.Decrypt(ctx, newCiphertext)
+		assert.Error(t, err)
+		secret, err = key.Decrypt(ctx, newCiphertext)
+		require.NoError(t, err)
+		assert.Equal(t, "new secret", secret.Secret())
+
+		version, err := key.Version(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, "testkey/new", version.Name)
+
+		// Plaintext data is read through the noop key.
+		secret, err = key.Decrypt(ctx, []byte("plaintext"))
+		require.NoError(t, err)
+		assert.Equal(t, "plaintext", secret.Secret())
+	})
+}
@@ -0,0 +1,354 @@
+// Package vault implements an encryption.Key backed by the transit secrets
+// engine of HashiCorp Vault.
+package vault
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cockroachdb/errors"
+
+	"github.com/sourcegraph/sourcegraph/internal/encryption"
+	"github.com/sourcegraph/sourcegraph/internal/httpcli"
+	"github.com/sourcegraph/sourcegraph/schema"
+)
+
+const (
+	defaultMountPath        = "transit"
+	defaultAppRoleMountPath = "approle"
+)
+
+func NewKey(ctx context.Context, config schema.VaultEncryptionKey) (encryption.Key, error) {
+	if config.Address == "" || config.Keyname == "" {
+		return nil, errors.New("vault key must have an address and a keyname")
+	}
+
+	login, err := newLogin(config)
+	if err != nil {
+		return nil, errors.Wrapf(err, "configuring vault authentication for %q", config.Keyname)
+	}
+
+	var opts []httpcli.Opt
+	if config.CaCertFile != "" {
+		cert, err := os.ReadFile(config.CaCertFile)
+		if err != nil {
+			return nil, errors.Wrap(err, "reading vault CA certificate")
+		}
+		opts = append(opts, httpcli.NewCertPoolOpt(string(cert)))
+	}
+	cli, err := clientFactory.Doer(opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	mountPath := strings.Trim(config.MountPath, "/")
+	if mountPath == "" {
+		mountPath = defaultMountPath
+	}
+
+	k := &Key{
+		address:   strings.TrimRight(config.Address, "/"),
+		namespace: config.Namespace,
+		mountPath: mountPath,
+		keyname:   config.Keyname,
+		cli:       cli,
+		login:     login,
+	}
+	// Test client connection and authentication.
+	_, err = k.Version(ctx)
+	return k, err
+}
+
+// clientFactory doesn't use the caching transport of the external client
+// factory, responses from Vault must never be cached.
+var clientFactory = httpcli.NewFactory(
+	httpcli.NewMiddleware(
+		httpcli.ContextErrorMiddleware,
+	),
+	httpcli.NewTimeoutOpt(30*time.Second),
+	httpcli.TracedTransportOpt,
+)
+
+// loginFunc returns a Vault token, and the time after which it must not be
+// used anymore. A zero time means that the token doesn't expire, and is only
+// renewed when Vault rejects it.
+type loginFunc func(ctx context.Context, k *Key) (token string, expiry time.Time, err error)
+
+func newLogin(config schema.VaultEncryptionKey) (loginFunc, error) {
+	switch {
+	case config.TokenFile != "" && config.TokenEnvVarName == "" && config.AppRole == nil:
+		return func(context.Context, *Key) (string, time.Time, error) {
+			token, err := os.ReadFile(config.TokenFile)
+			if err != nil {
+				return "", time.Time{}, errors.Wrap(err, "reading vault token file")
+			}
+			return strings.TrimSpace(string(token)), time.Time{}, nil
+		}, nil
+
+	case config.TokenEnvVarName != "" && config.TokenFile == "" && config.AppRole == nil:
+		return func(context.Context, *Key) (string, time.Time, error) {
+			token := os.Getenv(config.TokenEnvVarName)
+			if token == "" {
+				return "", time.Time{}, errors.Errorf("vault token environment variable %q is empty", config.TokenEnvVarName)
+			}
+			return token, time.Time{}, nil
+		}, nil
+
+	case config.AppRole != nil && config.TokenFile == "" && config.TokenEnvVarName == "":
+		return newAppRoleLogin(*config.AppRole)
+
+	default:
+		// Either the user has set none of the auth methods or several of them in their config. Either way we return an error.
+		return nil, errors.New("must use exactly one of tokenFile, tokenEnvVarName and appRole")
+	}
+}
+
+func newAppRoleLogin(config schema.VaultAppRole) (loginFunc, error) {
+	if config.SecretIdFile != "" && config.SecretIdEnvVarName != "" {
+		return nil, errors.New("must use only one of secretIdFile and secretIdEnvVarName")
+	}
+
+	mountPath := strings.Trim(config.MountPath, "/")
+	if mountPath == "" {
+		mountPath = defaultAppRoleMountPath
+	}
+
+	return func(ctx context.Context, k *Key) (string, time.Time, error) {
+		req := appRoleLoginRequest{RoleID: config.RoleId}
+		if config.SecretIdFile != "" {
+			secretID, err := os.ReadFile(config.SecretIdFile)
+			if err != nil {
+				return "", time.Time{}, errors.Wrap(err, "reading vault AppRole secret ID file")
+			}
+			req.SecretID = strings.TrimSpace(string(secretID))
+		} else if config.SecretIdEnvVarName != "" {
+			req.SecretID = os.Getenv(config.SecretIdEnvVarName)
+		}
+
+		var res appRoleLoginResponse
+		if _, err := k.request(ctx, http.MethodPost, "auth/"+mountPath+"/login", "", req, &res); err != nil {
+			return "", time.Time{}, errors.Wrap(err, "logging in to vault with AppRole")
+		}
+		if res.Auth.ClientToken == "" {
+			return "", time.Time{}, errors.New("logging in to vault with AppRole: no token returned")
+		}
+
+		var expiry time.Time
+		if res.Auth.LeaseDuration > 0 {
+			// Log in again a bit before the token expires.
+			lease := time.Duration(res.Auth.LeaseDuration) * time.Second
+			expiry = time.Now().Add(lease - lease/10)
+		}
+		return res.Auth.ClientToken, expiry, nil
+	}, nil
+}
+
+// Key is an encryption.Key implementation that encrypts and decrypts data
+// with a key of the transit secrets engine of Vault. The transit engine
+// handles key rotation: data is encrypted with the latest version of the key,
+// and the ciphertext records the version that encrypted it.
+type Key struct {
+	address   string
+	namespace string
+	mountPath string
+	keyname   string
+	cli       httpcli.Doer
+	login     loginFunc
+
+	mu     sync.Mutex
+	token  string
+	expiry time.Time
+}
+
+func (k *Key) Version(ctx context.Context) (encryption.KeyVersion, error) {
+	var res struct {
+		Data struct {
+			Name          string `json:"name"`
+			LatestVersion int    `json:"latest_version"`
+		} `json:"data"`
+	}
+	if err := k.do(ctx, http.MethodGet, k.mountPath+"/keys/"+url.PathEscape(k.keyname), nil, &res); err != nil {
+		return encryption.KeyVersion{}, errors.Wrap(err, "getting key version")
+	}
+	return encryption.KeyVersion{
+		Type:    "vault",
+		Name:    k.mountPath + "/" + k.keyname,
+		Version: strconv.Itoa(res.Data.LatestVersion),
+	}, nil
+}
+
+// Encrypt a secret, storing it as the ciphertext returned by Vault, which is
+// a string prefixed with the version of the key, e.g. "vault:v1:...".
+func (k *Key) Encrypt(ctx context.Context, plaintext []byte) ([]byte, error) {
+	req := struct {
+		Plaintext string `json:"plaintext"`
+	}{Plaintext: base64.StdEncoding.EncodeToString(plaintext)}
+
+	var res struct {
+		Data struct {
+			Ciphertext string `json:"ciphertext"`
+		} `json:"data"`
+	}
+	if err := k.do(ctx, http.MethodPost, k.mountPath+"/encrypt/"+url.PathEscape(k.keyname), req, &res); err != nil {
+		return nil, errors.Wrap(err, "encrypting")
+	}
+	return []byte(res.Data.Ciphertext), nil
+}
+
+// Decrypt a secret, it must have been encrypted with the same Key.
+func (k *Key) Decrypt(ctx context.Context, ciphertext []byte) (*encryption.Secret, error) {
+	if !bytes.HasPrefix(ciphertext, []byte("vault:v")) {
+		return nil, errors.New("invalid vault ciphertext")
+	}
+
+	req := struct {
+		Ciphertext string `json:"ciphertext"`
+	}{Ciphertext: string(ciphertext)}
+
+	var res struct {
+		Data struct {
+			Plaintext string `json:"plaintext"`
+		} `json:"data"`
+	}
+	if err := k.do(ctx, http.MethodPost, k.mountPath+"/decrypt/"+url.PathEscape(k.keyname), req, &res); err != nil {
+		return nil, errors.Wrap(err, "decrypting")
+	}
+
+	plaintext, err := base64.StdEncoding.DecodeString(res.Data.Plaintext)
+	if err != nil {
+		return nil, errors.Wrap(err, "decoding plaintext")
+	}
+	s := encryption.NewSecret(string(plaintext))
+	return &s, nil
+}
+
+// do sends an authenticated request to the Vault API. If Vault rejects the
+// token, do logs in again and retries the request once.
+func (k *Key) do(ctx context.Context, method, path string, in, out interface{}) error {
+	for retried := false; ; retried = true {
+		token, err := k.getToken(ctx)
+		if err != nil {
+			return err
+		}
+
+		status, err := k.request(ctx, method, path, token, in, out)
+		if status == http.StatusForbidden && !retried {
+			k.resetToken(token)
+			continue
+		}
+		return err
+	}
+}
+
+func (k *Key) getToken(ctx context.Context) (string, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	if k.token != "" && (k.expiry.IsZero() || time.Now().Before(k.expiry)) {
+		return k.token, nil
+	}
+
+	token, expiry, err := k.login(ctx, k)
+	if err != nil {
+		return "", err
+	}
+	k.token, k.expiry = token, expiry
+	return token, nil
+}
+
+// resetToken forgets the token, unless another request already replaced it.
+func (k *Key) resetToken(token string) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	if k.token == token {
+		k.token = ""
+	}
+}
+
+// request sends a request to the Vault API, and decodes the JSON response
+// into out. It returns the status code of the response.
+func (k *Key) request(ctx context.Context, method, path, token string, in, out interface{}) (int, error) {
+	var body io.Reader
+	if in != nil {
+		b, err := json.Marshal(in)
+		if err != nil {
+			return 0, err
+		}
+		body = bytes.NewReader(b)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, k.address+"/v1/"+path, body)
+	if err != nil {
+		return 0, err
+	}
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if token != "" {
+		req.Header.Set("X-Vault-Token", token)
+	}
+	if k.namespace != "" {
+		req.Header.Set("X-Vault-Namespace", k.namespace)
+	}
+
+	resp, err := k.cli.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		var res errorResponse
+		_ = json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&res)
+		return resp.StatusCode, &apiError{Method: method, Path: path, StatusCode: resp.StatusCode, Errors: res.Errors}
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return resp.StatusCode, errors.Wrap(err, "decoding vault response")
+	}
+	return resp.StatusCode, nil
+}
+
+type appRoleLoginRequest struct {
+	RoleID   string `json:"role_id"`
+	SecretID string `json:"secret_id,omitempty"`
+}
+
+type appRoleLoginResponse struct {
+	Auth struct {
+		ClientToken   string `json:"client_token"`
+		LeaseDuration int    `json:"lease_duration"`
+	} `json:"auth"`
+}
+
+type errorResponse struct {
+	Errors []string `json:"errors"`
+}
+
+// apiError is returned when the Vault API responds with an error status.
+type apiError struct {
+	Method     string
+	Path       string
+	StatusCode int
+	Errors     []string
+}
+
+func (e *apiError) Error() string {
+	msg := fmt.Sprintf("vault: %s %s: unexpected status %d", e.Method, e.Path, e.StatusCode)
+	if len(e.Errors) > 0 {
+		msg += ": " + strings.Join(e.Errors, "; ")
+	}
+	return msg
+}
@@ -0,0 +1,310 @@
+package vault
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sourcegraph/sourcegraph/internal/encryption/cache"
+	"github.com/sourcegraph/sourcegraph/schema"
+)
+
+// fakeTransit is a fake of the Vault transit secrets engine API, with a
+// single key and an AppRole auth method.
+type fakeTransit struct {
+	mu sync.Mutex
+
+	keyname   string
+	namespace string
+	roleID    string
+	secretID  string
+
+	version int
+	tokens  map[string]bool
+	logins  int
+	// requests counts the requests to the transit engine by operation.
+	requests map[string]int
+}
+
+func newFakeTransit(t *testing.T, token string) (*fakeTransit, *httptest.Server) {
+	f := &fakeTransit{
+		keyname:  "sourcegraph",
+		roleID:   "role",
+		secretID: "secret",
+		version:  1,
+		tokens:   map[string]bool{token: true},
+		requests: map[string]int{},
+	}
+	srv := httptest.NewServer(f)
+	t.Cleanup(srv.Close)
+	return f, srv
+}
+
+func (f *fakeTransit) rotate() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.version++
+}
+
+func (f *fakeTransit) revokeTokens() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.tokens = map[string]bool{}
+}
+
+func (f *fakeTransit) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	writeErr := func(status int, msg string) {
+		w.WriteHeader(status)
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"errors": []string{msg}})
+	}
+	writeData := func(key string, data interface{}) {
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{key: data})
+	}
+
+	if r.Header.Get("X-Vault-Namespace") != f.namespace {
+		writeErr(http.StatusNotFound, "no handler for route")
+		return
+	}
+
+	var body map[string]string
+	if r.Method == http.MethodPost {
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			writeErr(http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+
+	if r.URL.Path == "/v1/auth/approle/login" {
+		if body["role_id"] != f.roleID || body["secret_id"] != f.secretID {
+			writeErr(http.StatusBadRequest, "invalid role or secret ID")
+			return
+		}
+		f.logins++
+		token := fmt.Sprintf("approle-token-%d", f.logins)
+		f.tokens[token] = true
+		writeData("auth", map[string]interface{}{"client_token": token, "lease_duration": 3600})
+		return
+	}
+
+	if !f.tokens[r.Header.Get("X-Vault-Token")] {
+		writeErr(http.StatusForbidden, "permission denied")
+		return
+	}
+
+	switch {
+	case r.Method == http.MethodGet && r.URL.Path == "/v1/transit/keys/"+f.keyname:
+		f.requests["keys"]++
+		writeData("data", map[string]interface{}{"name": f.keyname, "latest_version": f.version})
+
+	case r.Method == http.MethodPost && r.URL.Path == "/v1/transit/encrypt/"+f.keyname:
+		f.requests["encrypt"]++
+		// The fake "encryption" records the version and reverses the plaintext.
+		writeData("data", map[string]interface{}{
+			"ciphertext":  fmt.Sprintf("vault:v%d:%s", f.version, reverse(body["plaintext"])),
+			"key_version": f.version,
+		})
+
+	case r.Method == http.MethodPost && r.URL.Path == "/v1/transit/decrypt/"+f.keyname:
+		f.requests["decrypt"]++
+		parts := strings.SplitN(body["ciphertext"], ":", 3)
+		if len(parts) != 3 || parts[1] > fmt.Sprintf("v%d", f.version) {
+			writeErr(http.StatusBadRequest, "invalid ciphertext")
+			return
+		}
+		writeData("data", map[string]interface{}{"plaintext": reverse(parts[2])})
+
+	default:
+		writeErr(http.StatusNotFound, "no handler for route")
+	}
+}
+
+func reverse(s string) string {
+	r := []rune(s)
+	for i, j := 0, len(r)-1; i < j; i, j = i+1, j-1 {
+		r[i], r[j] = r[j], r[i]
+	}
+	return string(r)
+}
+
+func writeFile(t *testing.T, name, content string) string {
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0600))
+	return path
+}
+
+func TestRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	f, srv := newFakeTransit(t, "root")
+
+	key, err := NewKey(ctx, schema.VaultEncryptionKey{
+		Type:      "vault",
+		Address:   srv.URL,
+		Keyname:   f.keyname,
+		TokenFile: writeFile(t, "token", "root\n"),
+	})
+	require.NoError(t, err)
+
+	version, err := key.Version(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "vault", version.Type)
+	assert.Equal(t, "transit/sourcegraph", version.Name)
+	assert.Equal(t, "1", version.Version)
+
+	ciphertext, err := key.Encrypt(ctx, []byte("very secret"))
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(string(ciphertext), "vault:v1:"))
+	assert.NotContains(t, string(ciphertext), base64.StdEncoding.EncodeToString([]byte("very secret")))
+
+	secret, err := key.Decrypt(ctx, ciphertext)
+	require.NoError(t, err)
+	assert.Equal(t, "very secret", secret.Secret())
+
+	// After a rotation, new data is encrypted with the new version of the
+	// key, and old data can still be decrypted.
+	f.rotate()
+
+	version, err = key.Version(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "2", version.Version)
+
+	rotated, err := key.Encrypt(ctx, []byte("very secret"))
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(string(rotated), "vault:v2:"))
+
+	secret, err = key.Decrypt(ctx, ciphertext)
+	require.NoError(t, err)
+	assert.Equal(t, "very secret", secret.Secret())
+
+	// Data that wasn't encrypted by Vault is rejected without calling Vault.
+	requests := f.requests["decrypt"]
+	_, err = key.Decrypt(ctx, []byte("plaintext"))
+	assert.Error(t, err)
+	assert.Equal(t, requests, f.requests["decrypt"])
+}
+
+func TestCache(t *testing.T) {
+	ctx := context.Background()
+	f, srv := newFakeTransit(t, "root")
+
+	os.Setenv("TEST_VAULT_TOKEN", "root")
+	t.Cleanup(func() { os.Unsetenv("TEST_VAULT_TOKEN") })
+
+	k, err := NewKey(ctx, schema.VaultEncryptionKey{
+		Type:            "vault",
+		Address:         srv.URL,
+		Keyname:         f.keyname,
+		TokenEnvVarName: "TEST_VAULT_TOKEN",
+	})
+	require.NoError(t, err)
+	key, err := cache.New(k, 10)
+	require.NoError(t, err)
+
+	ciphertext, err := key.Encrypt(ctx, []byte("very secret"))
+	require.NoError(t, err)
+
+	for i := 0; i < 3; i++ {
+		secret, err := key.Decrypt(ctx, ciphertext)
+		require.NoError(t, err)
+		assert.Equal(t, "very secret", secret.Secret())
+	}
+	assert.Equal(t, 1, f.requests["decrypt"])
+}
+
+func TestAppRole(t *testing.T) {
+	ctx := context.Background()
+	f, srv := newFakeTransit(t, "root")
+	f.namespace = "team"
+
+	key, err := NewKey(ctx, schema.VaultEncryptionKey{
+		Type:      "vault",
+		Address:   srv.URL + "/",
+		Keyname:   f.keyname,
+		Namespace: "team",
+		AppRole: &schema.VaultAppRole{
+			RoleId:       f.roleID,
+			SecretIdFile: writeFile(t, "secret-id", f.secretID),
+		},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 1, f.logins)
+
+	ciphertext, err := key.Encrypt(ctx, []byte("very secret"))
+	require.NoError(t, err)
+	assert.Equal(t, 1, f.logins)
+
+	// When the token is revoked, the key logs in again.
+	f.revokeTokens()
+
+	secret, err := key.Decrypt(ctx, ciphertext)
+	require.NoError(t, err)
+	assert.Equal(t, "very secret", secret.Secret())
+	assert.Equal(t, 2, f.logins)
+}
+
+func TestNewKeyErrors(t *testing.T) {
+	ctx := context.Background()
+	f, srv := newFakeTransit(t, "root")
+
+	for name, tc := range map[string]struct {
+		config schema.VaultEncryptionKey
+		err    string
+	}{
+		"no auth": {
+			config: schema.VaultEncryptionKey{Address: srv.URL, Keyname: f.keyname},
+			err:    "must use exactly one of tokenFile, tokenEnvVarName and appRole",
+		},
+		"several auth methods": {
+			config: schema.VaultEncryptionKey{
+				Address:         srv.URL,
+				Keyname:         f.keyname,
+				TokenEnvVarName: "TEST_VAULT_TOKEN",
+				AppRole:         &schema.VaultAppRole{RoleId: f.roleID},
+			},
+			err: "must use exactly one of tokenFile, tokenEnvVarName and appRole",
+		},
+		"invalid token": {
+			config: schema.VaultEncryptionKey{
+				Address:   srv.URL,
+				Keyname:   f.keyname,
+				TokenFile: writeFile(t, "token", "nope"),
+			},
+			err: "getting key version: vault: GET transit/keys/sourcegraph: unexpected status 403: permission denied",
+		},
+		"unknown key": {
+			config: schema.VaultEncryptionKey{
+				Address:   srv.URL,
+				Keyname:   "unknown",
+				TokenFile: writeFile(t, "token", "root"),
+			},
+			err: "getting key version: vault: GET transit/keys/unknown: unexpected status 404: no handler for route",
+		},
+		"invalid secret ID": {
+			config: schema.VaultEncryptionKey{
+				Address: srv.URL,
+				Keyname: f.keyname,
+				AppRole: &schema.VaultAppRole{RoleId: f.roleID, SecretIdEnvVarName: "TEST_VAULT_UNSET_SECRET_ID"},
+			},
+			err: "getting key version: logging in to vault with AppRole: vault: POST auth/approle/login: unexpected status 400: invalid role or secret ID",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := NewKey(ctx, tc.config)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tc.err)
+		})
+	}
+}
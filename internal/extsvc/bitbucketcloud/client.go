@@ -16,6 +16,8 @@ import (
 	"github.com/opentracing-contrib/go-stdlib/nethttp"
 	"golang.org/x/time/rate"
 
+	"github.com/sourcegraph/sourcegraph/internal/errcode"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/auth"
 	"github.com/sourcegraph/sourcegraph/internal/httpcli"
 	"github.com/sourcegraph/sourcegraph/internal/metrics"
 	"github.com/sourcegraph/sourcegraph/internal/ratelimit"
@@ -50,6 +52,10 @@ type Client struct {
 	// The username and app password credentials for accessing the server.
 	Username, AppPassword string
 
+	// Auth, if set, is used to authenticate requests instead of Username and
+	// AppPassword.
+	Auth auth.Authenticator
+
 	// RateLimit is the self-imposed rate limiter (since Bitbucket does not have a concept
 	// of rate limiting in HTTP response headers).
 	RateLimit *rate.Limiter
@@ -86,6 +92,32 @@ func NewClient(apiURL *url.URL, httpClient httpcli.Doer) *Client {
 	}
 }
 
+// WithAuthenticator returns a new Client that uses the same configuration,
+// HTTP client, and rate limiter as the current Client, but authenticates
+// requests with the given authenticator.
+func (c *Client) WithAuthenticator(a auth.Authenticator) *Client {
+	return &Client{
+		httpClient: c.httpClient,
+		URL:        c.URL,
+		RateLimit:  c.RateLimit,
+		Auth:       a,
+	}
+}
+
+// CurrentUser returns the user associated with the authenticator in use.
+func (c *Client) CurrentUser(ctx context.Context) (*Account, error) {
+	req, err := http.NewRequest("GET", "/2.0/user", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var user Account
+	if err := c.do(ctx, req, &user); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
 // Repos returns a list of repositories that are fetched and populated based on given account
 // name and pagination criteria. If the account requested is a team, results will be filtered
 // down to the ones that the app password's user has access to.
@@ -195,6 +227,9 @@ func (c *Client) do(ctx context.Context, req *http.Request, result interface{})
 }
 
 func (c *Client) authenticate(req *http.Request) error {
+	if c.Auth != nil {
+		return c.Auth.Authenticate(req)
+	}
 	req.SetBasicAuth(c.Username, c.AppPassword)
 	return nil
 }
@@ -241,6 +276,18 @@ type Links struct {
 	HTML  Link       `json:"html"`
 }
 
+// Account is a Bitbucket Cloud user or team.
+type Account struct {
+	UUID        string `json:"uuid"`
+	Username    string `json:"username,omitempty"`
+	Nickname    string `json:"nickname,omitempty"`
+	DisplayName string `json:"display_name"`
+	AccountID   string `json:"account_id,omitempty"`
+	Links       struct {
+		HTML Link `json:"html"`
+	} `json:"links"`
+}
+
 type CloneLinks []struct {
 	Href string `json:"href"`
 	Name string `json:"name"`
@@ -277,3 +324,8 @@ func (e *httpError) Unauthorized() bool {
 func (e *httpError) NotFound() bool {
 	return e.StatusCode == http.StatusNotFound
 }
+
+// IsNotFound reports whether err is a Bitbucket Cloud API not found error.
+func IsNotFound(err error) bool {
+	return errcode.IsNotFound(err)
+}
@@ -0,0 +1,128 @@
+package bitbucketcloud
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/cockroachdb/errors"
+)
+
+const (
+	eventTypeHeader = "X-Event-Key"
+)
+
+func WebhookEventType(r *http.Request) string {
+	return r.Header.Get(eventTypeHeader)
+}
+
+// ParseWebhookEvent parses the payload of a webhook request of the given
+// event type.
+//
+// See https://support.atlassian.com/bitbucket-cloud/docs/event-payloads/
+func ParseWebhookEvent(eventType string, payload []byte) (e interface{}, err error) {
+	switch eventType {
+	case "repo:push":
+		e = &PushEvent{}
+		return e, json.Unmarshal(payload, e)
+	case "repo:commit_status_created", "repo:commit_status_updated":
+		e = &RepoCommitStatusEvent{}
+		return e, json.Unmarshal(payload, e)
+	case "pullrequest:approved", "pullrequest:unapproved",
+		"pullrequest:changes_request_created", "pullrequest:changes_request_removed",
+		"pullrequest:fulfilled", "pullrequest:rejected":
+		return parsePullRequestActivityEvent(PullRequestAction(strings.TrimPrefix(eventType, "pullrequest:")), payload)
+	case "pullrequest:created", "pullrequest:updated",
+		"pullrequest:comment_created", "pullrequest:comment_updated", "pullrequest:comment_deleted":
+		e = &PullRequestEvent{}
+		return e, json.Unmarshal(payload, e)
+	default:
+		return nil, errors.Errorf("unknown webhook event type: %q", eventType)
+	}
+}
+
+func parsePullRequestActivityEvent(action PullRequestAction, payload []byte) (*PullRequestActivityEvent, error) {
+	type participation struct {
+		Date time.Time `json:"date"`
+		User Account   `json:"user"`
+	}
+	var raw struct {
+		PullRequestEvent
+		Approval       *participation `json:"approval"`
+		ChangesRequest *participation `json:"changes_request"`
+	}
+	if err := json.Unmarshal(payload, &raw); err != nil {
+		return nil, err
+	}
+
+	activity := &PullRequestActivity{Action: action}
+	switch {
+	case raw.Approval != nil:
+		activity.Date = raw.Approval.Date
+		activity.User = raw.Approval.User
+	case raw.ChangesRequest != nil:
+		activity.Date = raw.ChangesRequest.Date
+		activity.User = raw.ChangesRequest.User
+	default:
+		// Merges and declines don't have a dedicated object in the payload.
+		activity.Date = raw.PullRequest.UpdatedOn
+		activity.User = raw.Actor
+	}
+
+	return &PullRequestActivityEvent{
+		PullRequestEvent: raw.PullRequestEvent,
+		Activity:         activity,
+	}, nil
+}
+
+// PushEvent is sent when commits are pushed to a repository.
+type PushEvent struct {
+	Actor      Account `json:"actor"`
+	Repository Repo    `json:"repository"`
+}
+
+// RepoCommitStatusEvent is sent when a build status is created or updated.
+type RepoCommitStatusEvent struct {
+	Actor        Account           `json:"actor"`
+	Repository   Repo              `json:"repository"`
+	CommitStatus PullRequestStatus `json:"commit_status"`
+}
+
+// PullRequestEvent is sent when something happens to a pull request.
+type PullRequestEvent struct {
+	Actor       Account     `json:"actor"`
+	Repository  Repo        `json:"repository"`
+	PullRequest PullRequest `json:"pullrequest"`
+}
+
+// PullRequestActivityEvent is sent when a pull request is approved,
+// unapproved, merged or declined, or when changes are requested.
+type PullRequestActivityEvent struct {
+	PullRequestEvent
+	Activity *PullRequestActivity
+}
+
+// PullRequestAction is the action taken in a PullRequestActivity.
+type PullRequestAction string
+
+const (
+	PullRequestActionApproved              PullRequestAction = "approved"
+	PullRequestActionUnapproved            PullRequestAction = "unapproved"
+	PullRequestActionChangesRequestCreated PullRequestAction = "changes_request_created"
+	PullRequestActionChangesRequestRemoved PullRequestAction = "changes_request_removed"
+	PullRequestActionFulfilled             PullRequestAction = "fulfilled"
+	PullRequestActionRejected              PullRequestAction = "rejected"
+)
+
+// PullRequestActivity is an action a user took on a pull request.
+type PullRequestActivity struct {
+	Action PullRequestAction `json:"action"`
+	Date   time.Time         `json:"date"`
+	User   Account           `json:"user"`
+}
+
+func (a *PullRequestActivity) Key() string {
+	return fmt.Sprintf("%s:%s:%d", a.Action, a.User.UUID, a.Date.UnixNano())
+}
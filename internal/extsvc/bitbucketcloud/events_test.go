@@ -0,0 +1,90 @@
+package bitbucketcloud
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestParseWebhookEvent(t *testing.T) {
+	load := func(t *testing.T, name string) []byte {
+		t.Helper()
+		data, err := os.ReadFile(filepath.Join("testdata/webhooks", name+".json"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return data
+	}
+
+	t.Run("pullrequest:approved", func(t *testing.T) {
+		e, err := ParseWebhookEvent("pullrequest:approved", load(t, "pullrequest-approved"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		ev := e.(*PullRequestActivityEvent)
+		if have, want := ev.PullRequest.ID, int64(1); have != want {
+			t.Errorf("pull request id: have %d, want %d", have, want)
+		}
+		if have, want := ev.Activity.Action, PullRequestActionApproved; have != want {
+			t.Errorf("action: have %q, want %q", have, want)
+		}
+		if have, want := ev.Activity.User.Nickname, "bob"; have != want {
+			t.Errorf("user: have %q, want %q", have, want)
+		}
+		if have, want := ev.Activity.Date, time.Date(2026, 10, 16, 15, 8, 0, 0, time.UTC); !have.Equal(want) {
+			t.Errorf("date: have %s, want %s", have, want)
+		}
+	})
+
+	t.Run("pullrequest:changes_request_created", func(t *testing.T) {
+		e, err := ParseWebhookEvent("pullrequest:changes_request_created", load(t, "pullrequest-changes-request-created"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		ev := e.(*PullRequestActivityEvent)
+		if have, want := ev.Activity.Action, PullRequestActionChangesRequestCreated; have != want {
+			t.Errorf("action: have %q, want %q", have, want)
+		}
+		if have, want := ev.Activity.Date, time.Date(2026, 10, 16, 15, 9, 0, 0, time.UTC); !have.Equal(want) {
+			t.Errorf("date: have %s, want %s", have, want)
+		}
+	})
+
+	t.Run("pullrequest:fulfilled", func(t *testing.T) {
+		e, err := ParseWebhookEvent("pullrequest:fulfilled", load(t, "pullrequest-fulfilled"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		ev := e.(*PullRequestActivityEvent)
+		if have, want := ev.Activity.User.Nickname, "alice"; have != want {
+			t.Errorf("user: have %q, want %q", have, want)
+		}
+		if have, want := ev.Activity.Date, ev.PullRequest.UpdatedOn; !have.Equal(want) {
+			t.Errorf("date: have %s, want %s", have, want)
+		}
+	})
+
+	t.Run("repo:commit_status_updated", func(t *testing.T) {
+		e, err := ParseWebhookEvent("repo:commit_status_updated", load(t, "repo-commit-status-updated"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		ev := e.(*RepoCommitStatusEvent)
+		if have, want := ev.Repository.UUID, "{b090a669-7f2c-4f83-9a7b-2d6d1b1e3a11}"; have != want {
+			t.Errorf("repository: have %q, want %q", have, want)
+		}
+		if have, want := ev.CommitStatus.State, PullRequestStatusStateFailed; have != want {
+			t.Errorf("state: have %q, want %q", have, want)
+		}
+		if ev.CommitStatus.Commit == nil || ev.CommitStatus.Commit.Hash == "" {
+			t.Error("missing commit")
+		}
+	})
+
+	t.Run("unknown", func(t *testing.T) {
+		if _, err := ParseWebhookEvent("issue:created", []byte("{}")); err == nil {
+			t.Error("expected an error for an unknown event type")
+		}
+	})
+}
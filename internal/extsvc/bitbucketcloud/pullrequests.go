@@ -0,0 +1,363 @@
+package bitbucketcloud
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cockroachdb/errors"
+)
+
+// ErrNotMergeable is returned by MergePullRequest when Bitbucket Cloud refuses
+// to merge the pull request, for example because of merge conflicts or unmet
+// merge checks.
+var ErrNotMergeable = errors.New("pull request is not mergeable")
+
+// PullRequestInput is the input used to create or update a pull request.
+type PullRequestInput struct {
+	Title             string
+	Description       string
+	SourceBranch      string
+	DestinationBranch string
+
+	// SourceRepo is the repository containing the source branch. If nil, the
+	// repository the pull request is created in is used.
+	SourceRepo *Repo
+}
+
+func (input *PullRequestInput) MarshalJSON() ([]byte, error) {
+	type branch struct {
+		Name string `json:"name"`
+	}
+	type repository struct {
+		FullName string `json:"full_name"`
+	}
+	type source struct {
+		Branch     branch      `json:"branch"`
+		Repository *repository `json:"repository,omitempty"`
+	}
+	type destination struct {
+		Branch branch `json:"branch"`
+	}
+
+	body := struct {
+		Title       string       `json:"title"`
+		Description string       `json:"description,omitempty"`
+		Source      *source      `json:"source,omitempty"`
+		Destination *destination `json:"destination,omitempty"`
+	}{
+		Title:       input.Title,
+		Description: input.Description,
+	}
+	if input.SourceBranch != "" {
+		body.Source = &source{Branch: branch{Name: input.SourceBranch}}
+		if input.SourceRepo != nil {
+			body.Source.Repository = &repository{FullName: input.SourceRepo.FullName}
+		}
+	}
+	if input.DestinationBranch != "" {
+		body.Destination = &destination{Branch: branch{Name: input.DestinationBranch}}
+	}
+
+	return json.Marshal(body)
+}
+
+// CreatePullRequest opens a new pull request in the given repository.
+//
+// Note that Bitbucket Cloud doesn't return an error if an open pull request
+// already exists for the same source and destination branches: instead, the
+// existing pull request is updated and returned.
+func (c *Client) CreatePullRequest(ctx context.Context, repo *Repo, input *PullRequestInput) (*PullRequest, error) {
+	req, err := newJSONRequest("POST", pullRequestsPath(repo), input)
+	if err != nil {
+		return nil, err
+	}
+
+	var pr PullRequest
+	if err := c.do(ctx, req, &pr); err != nil {
+		return nil, err
+	}
+	return &pr, nil
+}
+
+// GetPullRequest retrieves a single pull request.
+func (c *Client) GetPullRequest(ctx context.Context, repo *Repo, id int64) (*PullRequest, error) {
+	req, err := http.NewRequest("GET", pullRequestPath(repo, id), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var pr PullRequest
+	if err := c.do(ctx, req, &pr); err != nil {
+		return nil, err
+	}
+	return &pr, nil
+}
+
+// GetPullRequestStatuses retrieves all the build statuses of the commits of
+// the given pull request.
+func (c *Client) GetPullRequestStatuses(ctx context.Context, repo *Repo, id int64) ([]*PullRequestStatus, error) {
+	var all []*PullRequestStatus
+	next, err := c.page(ctx, pullRequestPath(repo, id)+"/statuses", nil, nil, &all)
+	if err != nil {
+		return nil, err
+	}
+
+	for next.HasMore() {
+		var page []*PullRequestStatus
+		if next, err = c.reqPage(ctx, next.Next, &page); err != nil {
+			return nil, err
+		}
+		all = append(all, page...)
+	}
+	return all, nil
+}
+
+// UpdatePullRequest updates the title, description and destination branch of
+// the given pull request.
+func (c *Client) UpdatePullRequest(ctx context.Context, repo *Repo, id int64, input *PullRequestInput) (*PullRequest, error) {
+	req, err := newJSONRequest("PUT", pullRequestPath(repo, id), input)
+	if err != nil {
+		return nil, err
+	}
+
+	var pr PullRequest
+	if err := c.do(ctx, req, &pr); err != nil {
+		return nil, err
+	}
+	return &pr, nil
+}
+
+// DeclinePullRequest declines (closes) the given pull request.
+func (c *Client) DeclinePullRequest(ctx context.Context, repo *Repo, id int64) (*PullRequest, error) {
+	req, err := http.NewRequest("POST", pullRequestPath(repo, id)+"/decline", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var pr PullRequest
+	if err := c.do(ctx, req, &pr); err != nil {
+		return nil, err
+	}
+	return &pr, nil
+}
+
+// CreatePullRequestComment adds a comment to the given pull request.
+func (c *Client) CreatePullRequestComment(ctx context.Context, repo *Repo, id int64, text string) error {
+	body := struct {
+		Content struct {
+			Raw string `json:"raw"`
+		} `json:"content"`
+	}{}
+	body.Content.Raw = text
+
+	req, err := newJSONRequest("POST", pullRequestPath(repo, id)+"/comments", &body)
+	if err != nil {
+		return err
+	}
+	return c.do(ctx, req, nil)
+}
+
+// MergeStrategy is a strategy Bitbucket Cloud can use to merge a pull request.
+type MergeStrategy string
+
+const (
+	MergeStrategyMergeCommit MergeStrategy = "merge_commit"
+	MergeStrategySquash      MergeStrategy = "squash"
+	MergeStrategyFastForward MergeStrategy = "fast_forward"
+)
+
+// MergePullRequestOpts are the options used when merging a pull request.
+type MergePullRequestOpts struct {
+	Message           string        `json:"message,omitempty"`
+	CloseSourceBranch bool          `json:"close_source_branch,omitempty"`
+	MergeStrategy     MergeStrategy `json:"merge_strategy,omitempty"`
+}
+
+// MergePullRequest merges the given pull request. If Bitbucket Cloud refuses
+// to merge it, an error wrapping ErrNotMergeable is returned.
+func (c *Client) MergePullRequest(ctx context.Context, repo *Repo, id int64, opts MergePullRequestOpts) (*PullRequest, error) {
+	req, err := newJSONRequest("POST", pullRequestPath(repo, id)+"/merge", &opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var pr PullRequest
+	if err := c.do(ctx, req, &pr); err != nil {
+		var e *httpError
+		if errors.As(err, &e) && (e.StatusCode == http.StatusBadRequest || e.StatusCode == http.StatusConflict) {
+			return nil, errors.Wrap(ErrNotMergeable, string(e.Body))
+		}
+		return nil, err
+	}
+	return &pr, nil
+}
+
+func newJSONRequest(method, path string, body interface{}) (*http.Request, error) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return nil, errors.Wrap(err, "marshalling request body")
+	}
+	return http.NewRequest(method, path, bytes.NewReader(data))
+}
+
+func pullRequestsPath(repo *Repo) string {
+	return fmt.Sprintf("/2.0/repositories/%s/pullrequests", escapeFullName(repo.FullName))
+}
+
+func pullRequestPath(repo *Repo, id int64) string {
+	return pullRequestsPath(repo) + "/" + strconv.FormatInt(id, 10)
+}
+
+// escapeFullName escapes the workspace and slug of a repository full name
+// separately, so that the separating slash is preserved.
+func escapeFullName(fullName string) string {
+	parts := strings.SplitN(fullName, "/", 2)
+	for i := range parts {
+		parts[i] = url.PathEscape(parts[i])
+	}
+	return strings.Join(parts, "/")
+}
+
+// PullRequestState is the state of a Bitbucket Cloud pull request.
+type PullRequestState string
+
+const (
+	PullRequestStateOpen       PullRequestState = "OPEN"
+	PullRequestStateMerged     PullRequestState = "MERGED"
+	PullRequestStateDeclined   PullRequestState = "DECLINED"
+	PullRequestStateSuperseded PullRequestState = "SUPERSEDED"
+)
+
+// PullRequest is a Bitbucket Cloud pull request.
+type PullRequest struct {
+	ID                int64               `json:"id"`
+	Title             string              `json:"title"`
+	Description       string              `json:"description"`
+	State             PullRequestState    `json:"state"`
+	Author            Account             `json:"author"`
+	Source            PullRequestEndpoint `json:"source"`
+	Destination       PullRequestEndpoint `json:"destination"`
+	MergeCommit       *PullRequestCommit  `json:"merge_commit,omitempty"`
+	CommentCount      int64               `json:"comment_count"`
+	TaskCount         int64               `json:"task_count"`
+	CloseSourceBranch bool                `json:"close_source_branch"`
+	ClosedBy          *Account            `json:"closed_by,omitempty"`
+	Reason            string              `json:"reason,omitempty"`
+	CreatedOn         time.Time           `json:"created_on"`
+	UpdatedOn         time.Time           `json:"updated_on"`
+	Reviewers         []Account           `json:"reviewers"`
+	Participants      []Participant       `json:"participants"`
+	Links             struct {
+		HTML Link `json:"html"`
+	} `json:"links"`
+
+	// CommitStatus holds the build statuses of the pull request's source
+	// commit. They aren't part of the pull request returned by the API, and
+	// are populated separately.
+	CommitStatus []*CommitStatus `json:"commit_status,omitempty"`
+}
+
+// PullRequestEndpoint is the source or destination of a pull request.
+type PullRequestEndpoint struct {
+	Repo   PullRequestRepo   `json:"repository"`
+	Branch PullRequestBranch `json:"branch"`
+	Commit PullRequestCommit `json:"commit"`
+}
+
+// PullRequestRepo is the abbreviated repository included in pull request
+// endpoints.
+type PullRequestRepo struct {
+	FullName string `json:"full_name"`
+	Name     string `json:"name"`
+	UUID     string `json:"uuid"`
+}
+
+type PullRequestBranch struct {
+	Name string `json:"name"`
+}
+
+// PullRequestCommit is a commit referenced by a pull request. Bitbucket Cloud
+// usually returns abbreviated hashes here.
+type PullRequestCommit struct {
+	Hash string `json:"hash"`
+}
+
+// ParticipantRole is the role of a participant in a pull request.
+type ParticipantRole string
+
+const (
+	ParticipantRoleParticipant ParticipantRole = "PARTICIPANT"
+	ParticipantRoleReviewer    ParticipantRole = "REVIEWER"
+)
+
+// ParticipantState is the review state of a participant in a pull request.
+type ParticipantState string
+
+const (
+	ParticipantStateApproved         ParticipantState = "approved"
+	ParticipantStateChangesRequested ParticipantState = "changes_requested"
+)
+
+// Participant is a user who participated in a pull request, either as a
+// reviewer or by commenting on it.
+type Participant struct {
+	User           Account          `json:"user"`
+	Role           ParticipantRole  `json:"role"`
+	Approved       bool             `json:"approved"`
+	State          ParticipantState `json:"state"`
+	ParticipatedOn *time.Time       `json:"participated_on"`
+}
+
+// PullRequestStatusState is the state of a build status.
+type PullRequestStatusState string
+
+const (
+	PullRequestStatusStateSuccessful PullRequestStatusState = "SUCCESSFUL"
+	PullRequestStatusStateFailed     PullRequestStatusState = "FAILED"
+	PullRequestStatusStateInProgress PullRequestStatusState = "INPROGRESS"
+	PullRequestStatusStateStopped    PullRequestStatusState = "STOPPED"
+)
+
+// PullRequestStatus is a build status reported on a commit.
+type PullRequestStatus struct {
+	UUID        string                 `json:"uuid"`
+	Key         string                 `json:"key"`
+	RefName     string                 `json:"refname"`
+	URL         string                 `json:"url"`
+	State       PullRequestStatusState `json:"state"`
+	Name        string                 `json:"name"`
+	Description string                 `json:"description"`
+	CreatedOn   time.Time              `json:"created_on"`
+	UpdatedOn   time.Time              `json:"updated_on"`
+	Commit      *PullRequestCommit     `json:"commit,omitempty"`
+}
+
+// CommitStatus is the build status of a specific commit.
+type CommitStatus struct {
+	Commit string            `json:"commit"`
+	Status PullRequestStatus `json:"status"`
+}
+
+func (s *CommitStatus) Key() string {
+	return fmt.Sprintf("%s:%s", s.Commit, s.Status.Key)
+}
+
+// MatchesCommit reports whether the status was reported on the given commit.
+// Since Bitbucket Cloud abbreviates commit hashes inconsistently, the shorter
+// of the two hashes is treated as a prefix of the other.
+func (s *CommitStatus) MatchesCommit(hash string) bool {
+	if s.Commit == "" || hash == "" {
+		return false
+	}
+	if len(s.Commit) < len(hash) {
+		return strings.HasPrefix(hash, s.Commit)
+	}
+	return strings.HasPrefix(s.Commit, hash)
+}
@@ -0,0 +1,116 @@
+package bitbucketcloud
+
+import (
+	"context"
+	"net/url"
+	"testing"
+
+	"github.com/cockroachdb/errors"
+)
+
+func TestClient_PullRequests(t *testing.T) {
+	cli, save := NewTestClient(t, "PullRequests", *update, &url.URL{Scheme: "https", Host: "api.bitbucket.org"})
+	defer save()
+
+	ctx := context.Background()
+	repo := &Repo{FullName: "sourcegraph-testing/src-cli"}
+
+	t.Run("CreatePullRequest", func(t *testing.T) {
+		pr, err := cli.CreatePullRequest(ctx, repo, &PullRequestInput{
+			Title:             "This is a test PR",
+			Description:       "This is the body of a test PR",
+			SourceBranch:      "test-pr-bbc-2",
+			DestinationBranch: "main",
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if have, want := pr.ID, int64(2); have != want {
+			t.Errorf("id: have %d, want %d", have, want)
+		}
+		if have, want := pr.Source.Branch.Name, "test-pr-bbc-2"; have != want {
+			t.Errorf("source branch: have %q, want %q", have, want)
+		}
+	})
+
+	t.Run("GetPullRequest", func(t *testing.T) {
+		pr, err := cli.GetPullRequest(ctx, repo, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if have, want := pr.State, PullRequestStateOpen; have != want {
+			t.Errorf("state: have %q, want %q", have, want)
+		}
+		if len(pr.Participants) != 1 || pr.Participants[0].State != ParticipantStateApproved {
+			t.Errorf("unexpected participants: %+v", pr.Participants)
+		}
+
+		if _, err := cli.GetPullRequest(ctx, repo, 999); !IsNotFound(err) {
+			t.Errorf("expected not found error, got %v", err)
+		}
+	})
+
+	t.Run("GetPullRequestStatuses", func(t *testing.T) {
+		statuses, err := cli.GetPullRequestStatuses(ctx, repo, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var keys []string
+		for _, s := range statuses {
+			keys = append(keys, s.Key+"="+string(s.State))
+		}
+		if have, want := len(keys), 2; have != want {
+			t.Fatalf("statuses: have %v, want %d statuses", keys, want)
+		}
+		if keys[0] != "build=SUCCESSFUL" || keys[1] != "lint=INPROGRESS" {
+			t.Errorf("unexpected statuses: %v", keys)
+		}
+	})
+
+	t.Run("UpdatePullRequest", func(t *testing.T) {
+		pr, err := cli.UpdatePullRequest(ctx, repo, 2, &PullRequestInput{
+			Title:             "This is an updated test PR",
+			Description:       "This is the updated body",
+			DestinationBranch: "main",
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if have, want := pr.Title, "This is an updated test PR"; have != want {
+			t.Errorf("title: have %q, want %q", have, want)
+		}
+	})
+
+	t.Run("DeclinePullRequest", func(t *testing.T) {
+		pr, err := cli.DeclinePullRequest(ctx, repo, 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if have, want := pr.State, PullRequestStateDeclined; have != want {
+			t.Errorf("state: have %q, want %q", have, want)
+		}
+	})
+
+	t.Run("MergePullRequest not mergeable", func(t *testing.T) {
+		_, err := cli.MergePullRequest(ctx, repo, 3, MergePullRequestOpts{MergeStrategy: MergeStrategySquash})
+		if !errors.Is(err, ErrNotMergeable) {
+			t.Errorf("expected ErrNotMergeable, got %v", err)
+		}
+	})
+}
+
+func TestCommitStatus_MatchesCommit(t *testing.T) {
+	s := &CommitStatus{Commit: "a1b2c3d4e5f6"}
+	for hash, want := range map[string]bool{
+		"a1b2c3d4e5f6": true,
+		"a1b2c3d4e5f6a1b2c3d4e5f6a1b2c3d4e5f6a1b2": true,
+		"a1b2c3":       true,
+		"0f9e8d7c6b5a": false,
+		"":             false,
+	} {
+		if have := s.MatchesCommit(hash); have != want {
+			t.Errorf("MatchesCommit(%q): have %t, want %t", hash, have, want)
+		}
+	}
+}
@@ -21,6 +21,9 @@ func ParseWebhookEvent(eventType string, payload []byte) (e interface{}, err err
 	switch eventType {
 	case "ping":
 		return PingEvent{}, nil
+	case "repo:refs_changed":
+		e = &RepositoryRefsChangedEvent{}
+		return e, json.Unmarshal(payload, e)
 	case "repo:build_status":
 		e = &BuildStatusEvent{}
 		return e, json.Unmarshal(payload, e)
@@ -60,6 +63,23 @@ func (a *ParticipantStatusEvent) Key() string {
 	return fmt.Sprintf("%s:%d:%d", a.Action, a.User.ID, a.CreatedDate)
 }
 
+// RepositoryRefsChangedEvent is sent when branches or tags of a repository are
+// pushed, created or deleted.
+type RepositoryRefsChangedEvent struct {
+	Date       time.Time   `json:"date"`
+	Actor      User        `json:"actor"`
+	Repository Repo        `json:"repository"`
+	Changes    []RefChange `json:"changes"`
+}
+
+// RefChange describes a change of a ref in a RepositoryRefsChangedEvent.
+type RefChange struct {
+	RefID    string `json:"refId"`
+	FromHash string `json:"fromHash"`
+	ToHash   string `json:"toHash"`
+	Type     string `json:"type"`
+}
+
 type BuildStatusEvent struct {
 	Commit       string        `json:"commit"`
 	Status       BuildStatus   `json:"status"`
@@ -0,0 +1,246 @@
+package gerrit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cockroachdb/errors"
+	"github.com/inconshreveable/log15"
+	"github.com/opentracing-contrib/go-stdlib/nethttp"
+	"golang.org/x/time/rate"
+
+	"github.com/sourcegraph/sourcegraph/internal/httpcli"
+	"github.com/sourcegraph/sourcegraph/internal/metrics"
+	"github.com/sourcegraph/sourcegraph/internal/ratelimit"
+	"github.com/sourcegraph/sourcegraph/internal/trace/ot"
+)
+
+var requestCounter = metrics.NewRequestMeter("gerrit_requests_count", "Total number of requests sent to the Gerrit API.")
+
+// These fields define the self-imposed Gerrit rate limit (since Gerrit does not have a
+// concept of rate limiting in HTTP response headers).
+//
+// See https://godoc.org/golang.org/x/time/rate#Limiter for an explanation of these fields.
+const (
+	rateLimitRequestsPerSecond = 2 // 120/min or 7200/hr
+	RateLimitMaxBurstRequests  = 500
+)
+
+// xssiPrefix is prepended by Gerrit to every JSON response body to prevent
+// cross-site script inclusion. It has to be stripped before decoding.
+//
+// See https://gerrit-review.googlesource.com/Documentation/rest-api.html#output
+var xssiPrefix = []byte(")]}'")
+
+// Client access a Gerrit instance via its REST API.
+type Client struct {
+	// HTTP Client used to communicate with the API
+	httpClient httpcli.Doer
+
+	// URL is the base URL of the Gerrit instance.
+	URL *url.URL
+
+	// The username and HTTP password credentials for accessing the server. If
+	// Username is empty, requests are made anonymously.
+	Username, Password string
+
+	// RateLimit is the self-imposed rate limiter (since Gerrit does not have a concept
+	// of rate limiting in HTTP response headers).
+	RateLimit *rate.Limiter
+}
+
+// NewClient creates a new Gerrit API client with the given base URL. If a nil
+// httpClient is provided, httpcli.ExternalDoer will be used.
+func NewClient(baseURL *url.URL, httpClient httpcli.Doer) *Client {
+	if httpClient == nil {
+		httpClient = httpcli.ExternalDoer
+	}
+
+	httpClient = requestCounter.Doer(httpClient, func(u *url.URL) string {
+		// The REST API collection the request is made to maps to the type of
+		// API request we are making.
+		parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+		for _, part := range parts {
+			if part == "projects" || part == "changes" || part == "accounts" {
+				return part
+			}
+		}
+		return ""
+	})
+
+	defaultLimiter := rate.NewLimiter(rateLimitRequestsPerSecond, RateLimitMaxBurstRequests)
+	l := ratelimit.DefaultRegistry.GetOrSet(baseURL.String(), defaultLimiter)
+
+	// Gerrit is often served under a path prefix such as /r/, so API paths are
+	// resolved relative to the base URL, which therefore needs a trailing slash.
+	if !strings.HasSuffix(baseURL.Path, "/") {
+		u := *baseURL
+		u.Path += "/"
+		baseURL = &u
+	}
+
+	return &Client{
+		httpClient: httpClient,
+		URL:        baseURL,
+		RateLimit:  l,
+	}
+}
+
+// ListProjectsArgs are the arguments of ListProjects.
+type ListProjectsArgs struct {
+	// Query is a project query, such as "state:active" or "inname:kernel".
+	Query string
+	// Skip is the number of matching projects to skip.
+	Skip int
+	// Limit is the maximum number of projects to return.
+	Limit int
+}
+
+// ListProjects returns the projects matching args.Query, ordered by name, and
+// whether more projects match the query.
+//
+// See https://gerrit-review.googlesource.com/Documentation/rest-api-projects.html#query-projects
+func (c *Client) ListProjects(ctx context.Context, args ListProjectsArgs) (projects []*Project, more bool, err error) {
+	qry := url.Values{"query": {args.Query}}
+	if args.Limit > 0 {
+		qry.Set("n", strconv.Itoa(args.Limit))
+	}
+	if args.Skip > 0 {
+		qry.Set("S", strconv.Itoa(args.Skip))
+	}
+
+	req, err := http.NewRequest("GET", c.path("projects/")+"?"+qry.Encode(), nil)
+	if err != nil {
+		return nil, false, err
+	}
+	if err := c.do(ctx, req, &projects); err != nil {
+		return nil, false, err
+	}
+	if n := len(projects); n > 0 {
+		more = projects[n-1].MoreProjects
+	}
+	return projects, more, nil
+}
+
+// GetProject returns the project with the given name.
+//
+// See https://gerrit-review.googlesource.com/Documentation/rest-api-projects.html#get-project
+func (c *Client) GetProject(ctx context.Context, name string) (*Project, error) {
+	req, err := http.NewRequest("GET", c.path("projects/"+url.PathEscape(name)), nil)
+	if err != nil {
+		return nil, err
+	}
+	var p Project
+	if err := c.do(ctx, req, &p); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// path returns the given REST API path, prefixed with "a/" if the client
+// authenticates, which is how Gerrit tells authenticated and anonymous
+// requests apart.
+func (c *Client) path(p string) string {
+	if c.Username != "" {
+		return "a/" + p
+	}
+	return p
+}
+
+func (c *Client) do(ctx context.Context, req *http.Request, result interface{}) error {
+	req.URL = c.URL.ResolveReference(req.URL)
+	req.Header.Set("Accept", "application/json")
+
+	req, ht := nethttp.TraceRequest(ot.GetTracer(ctx),
+		req.WithContext(ctx),
+		nethttp.OperationName("Gerrit"),
+		nethttp.ClientTrace(false))
+	defer ht.Finish()
+
+	if c.Username != "" {
+		req.SetBasicAuth(c.Username, c.Password)
+	}
+
+	startWait := time.Now()
+	if err := c.RateLimit.Wait(ctx); err != nil {
+		return err
+	}
+
+	if d := time.Since(startWait); d > 200*time.Millisecond {
+		log15.Warn("Gerrit self-enforced API rate limit: request delayed longer than expected due to rate limit", "delay", d)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+
+	bs, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 400 {
+		return errors.WithStack(&httpError{
+			URL:        req.URL,
+			StatusCode: resp.StatusCode,
+			Body:       bs,
+		})
+	}
+
+	if result != nil {
+		return json.Unmarshal(bytes.TrimPrefix(bs, xssiPrefix), result)
+	}
+
+	return nil
+}
+
+// Project is a Gerrit project, as returned by the ProjectInfo entity of the
+// REST API.
+type Project struct {
+	ID          string    `json:"id"`
+	Name        string    `json:"name"`
+	Parent      string    `json:"parent,omitempty"`
+	Description string    `json:"description,omitempty"`
+	State       string    `json:"state,omitempty"`
+	WebLinks    []WebLink `json:"web_links,omitempty"`
+
+	// MoreProjects is set on the last project of a page of results if more
+	// projects match the query.
+	MoreProjects bool `json:"_more_projects,omitempty"`
+}
+
+// WebLink is a link to a project in an external web interface, such as
+// Gitiles.
+type WebLink struct {
+	Name string `json:"name"`
+	URL  string `json:"url"`
+}
+
+type httpError struct {
+	StatusCode int
+	URL        *url.URL
+	Body       []byte
+}
+
+func (e *httpError) Error() string {
+	return fmt.Sprintf("Gerrit API HTTP error: code=%d url=%q body=%q", e.StatusCode, e.URL, e.Body)
+}
+
+func (e *httpError) Unauthorized() bool {
+	return e.StatusCode == http.StatusUnauthorized
+}
+
+func (e *httpError) NotFound() bool {
+	return e.StatusCode == http.StatusNotFound
+}
@@ -0,0 +1,104 @@
+package gerrit
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net/url"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/sourcegraph/sourcegraph/internal/errcode"
+)
+
+var update = flag.Bool("update", false, "update testdata")
+
+var testURL = &url.URL{Scheme: "https", Host: "gerrit.sgdev.org"}
+
+func TestClient_ListProjects(t *testing.T) {
+	cli, save := NewTestClient(t, "ListProjects", *update, testURL)
+	defer save()
+
+	var (
+		names []string
+		skip  int
+		pages int
+	)
+	for {
+		projects, more, err := cli.ListProjects(context.Background(), ListProjectsArgs{
+			Query: "state:active",
+			Skip:  skip,
+			Limit: 2,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		pages++
+		for _, p := range projects {
+			names = append(names, p.Name)
+		}
+		if !more {
+			break
+		}
+		skip += len(projects)
+	}
+
+	want := []string{
+		"All-Projects",
+		"All-Users",
+		"device/generic",
+		"platform/build",
+		"platform/sdk",
+		"tools/repo",
+	}
+	if diff := cmp.Diff(want, names); diff != "" {
+		t.Errorf("projects mismatch (-want +got):\n%s", diff)
+	}
+	if pages != 3 {
+		t.Errorf("want 3 pages, got %d", pages)
+	}
+}
+
+func TestClient_GetProject(t *testing.T) {
+	cli, save := NewTestClient(t, "GetProject", *update, testURL)
+	defer save()
+
+	for _, tc := range []struct {
+		name    string
+		project *Project
+		err     string
+	}{
+		{
+			name: "platform/build",
+			project: &Project{
+				ID:          "platform%2Fbuild",
+				Name:        "platform/build",
+				Parent:      "All-Projects",
+				Description: "The Android build system",
+				State:       "ACTIVE",
+				WebLinks: []WebLink{
+					{Name: "browse", URL: "/plugins/gitiles/platform/build"},
+				},
+			},
+			err: "<nil>",
+		},
+		{
+			name: "missing",
+			err:  `Gerrit API HTTP error: code=404 url="https://gerrit.sgdev.org/a/projects/missing" body="Not found: missing\n"`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			project, err := cli.GetProject(context.Background(), tc.name)
+			if have, want := fmt.Sprint(err), tc.err; have != want {
+				t.Errorf("error:\nhave: %q\nwant: %q", have, want)
+			}
+			if err != nil && !errcode.IsNotFound(err) {
+				t.Errorf("want not found error, got %v", err)
+			}
+			if diff := cmp.Diff(tc.project, project); diff != "" {
+				t.Errorf("project mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
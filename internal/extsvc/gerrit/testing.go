@@ -0,0 +1,53 @@
+package gerrit
+
+import (
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sourcegraph/sourcegraph/internal/httpcli"
+	"github.com/sourcegraph/sourcegraph/internal/httptestutil"
+	"github.com/sourcegraph/sourcegraph/internal/lazyregexp"
+)
+
+func GetenvTestGerritUsername() string {
+	username := os.Getenv("GERRIT_USERNAME")
+	if username == "" {
+		username = "admin"
+	}
+	return username
+}
+
+// NewTestClient returns a gerrit.Client that records its interactions
+// to testdata/vcr/.
+func NewTestClient(t testing.TB, name string, update bool, baseURL *url.URL) (*Client, func()) {
+	t.Helper()
+
+	cassete := filepath.Join("testdata/vcr/", normalize(name))
+	rec, err := httptestutil.NewRecorder(cassete, update)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	hc, err := httpcli.NewFactory(nil, httptestutil.NewRecorderOpt(rec)).Doer()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cli := NewClient(baseURL, hc)
+	cli.Username = GetenvTestGerritUsername()
+	cli.Password = os.Getenv("GERRIT_PASSWORD")
+
+	return cli, func() {
+		if err := rec.Stop(); err != nil {
+			t.Errorf("failed to update test data: %s", err)
+		}
+	}
+}
+
+var normalizer = lazyregexp.New("[^A-Za-z0-9-]+")
+
+func normalize(path string) string {
+	return normalizer.ReplaceAllLiteralString(path, "-")
+}
@@ -0,0 +1,528 @@
+package gitea
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cockroachdb/errors"
+	"github.com/inconshreveable/log15"
+	"github.com/opentracing-contrib/go-stdlib/nethttp"
+	"golang.org/x/time/rate"
+
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/auth"
+	"github.com/sourcegraph/sourcegraph/internal/httpcli"
+	"github.com/sourcegraph/sourcegraph/internal/metrics"
+	"github.com/sourcegraph/sourcegraph/internal/ratelimit"
+	"github.com/sourcegraph/sourcegraph/internal/trace/ot"
+)
+
+var requestCounter = metrics.NewRequestMeter("gitea_requests_count", "Total number of requests sent to the Gitea API.")
+
+// These fields define the self-imposed Gitea rate limit (since Gitea does not have a
+// concept of rate limiting in HTTP response headers).
+//
+// See https://godoc.org/golang.org/x/time/rate#Limiter for an explanation of these fields.
+const (
+	rateLimitRequestsPerSecond = 2 // 120/min or 7200/hr
+	RateLimitMaxBurstRequests  = 500
+)
+
+// ErrPullRequestAlreadyExists is returned by CreatePullRequest when a pull
+// request for the same head and base branches is already open.
+var ErrPullRequestAlreadyExists = errors.New("pull request already exists")
+
+// Client access a Gitea (or Forgejo) instance via its REST API v1.
+type Client struct {
+	// HTTP Client used to communicate with the API
+	httpClient httpcli.Doer
+
+	// URL is the base URL of the Gitea instance.
+	URL *url.URL
+
+	// Auth is the authentication method used when accessing the server. Gitea
+	// accepts access tokens as OAuth bearer tokens, so this is usually an
+	// auth.OAuthBearerToken.
+	Auth auth.Authenticator
+
+	// RateLimit is the self-imposed rate limiter (since Gitea does not have a concept
+	// of rate limiting in HTTP response headers).
+	RateLimit *rate.Limiter
+}
+
+// NewClient creates a new Gitea API client with the given base URL. If a nil
+// httpClient is provided, httpcli.ExternalDoer will be used.
+func NewClient(baseURL *url.URL, a auth.Authenticator, httpClient httpcli.Doer) *Client {
+	if httpClient == nil {
+		httpClient = httpcli.ExternalDoer
+	}
+
+	httpClient = requestCounter.Doer(httpClient, func(u *url.URL) string {
+		// The first component of the path after the API prefix mostly maps
+		// to the type of API request we are making.
+		parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+		for i := 0; i+2 < len(parts); i++ {
+			if parts[i] == "api" && parts[i+1] == "v1" {
+				return parts[i+2]
+			}
+		}
+		return ""
+	})
+
+	defaultLimiter := rate.NewLimiter(rateLimitRequestsPerSecond, RateLimitMaxBurstRequests)
+	l := ratelimit.DefaultRegistry.GetOrSet(baseURL.String(), defaultLimiter)
+
+	// Gitea can be served under a path prefix, so API paths are resolved
+	// relative to the base URL, which therefore needs a trailing slash.
+	if !strings.HasSuffix(baseURL.Path, "/") {
+		u := *baseURL
+		u.Path += "/"
+		baseURL = &u
+	}
+
+	return &Client{
+		httpClient: httpClient,
+		URL:        baseURL,
+		Auth:       a,
+		RateLimit:  l,
+	}
+}
+
+// WithAuthenticator returns a new Client that uses the same configuration,
+// HTTPClient, and RateLimiter as the current Client, except authenticated with
+// the given authenticator instance.
+func (c *Client) WithAuthenticator(a auth.Authenticator) *Client {
+	return &Client{
+		httpClient: c.httpClient,
+		URL:        c.URL,
+		Auth:       a,
+		RateLimit:  c.RateLimit,
+	}
+}
+
+// ListReposArgs are the arguments of ListOrgRepos and ListUserRepos.
+type ListReposArgs struct {
+	// Page is the 1-based page number of the results to return.
+	Page int
+	// Limit is the maximum number of repositories to return. Gitea caps this
+	// at its configured MAX_RESPONSE_ITEMS, which defaults to 50.
+	Limit int
+}
+
+// ListOrgRepos returns a page of the repositories of the given organization
+// that are visible to the authenticated user, and whether there are more pages.
+//
+// See https://try.gitea.io/api/swagger#/organization/orgListRepos
+func (c *Client) ListOrgRepos(ctx context.Context, org string, args ListReposArgs) ([]*Repository, bool, error) {
+	return c.listRepos(ctx, "orgs/"+url.PathEscape(org)+"/repos", args)
+}
+
+// ListUserRepos returns a page of the repositories owned by the given user
+// that are visible to the authenticated user, and whether there are more pages.
+//
+// See https://try.gitea.io/api/swagger#/user/userListRepos
+func (c *Client) ListUserRepos(ctx context.Context, user string, args ListReposArgs) ([]*Repository, bool, error) {
+	return c.listRepos(ctx, "users/"+url.PathEscape(user)+"/repos", args)
+}
+
+func (c *Client) listRepos(ctx context.Context, path string, args ListReposArgs) (repos []*Repository, more bool, err error) {
+	qry := url.Values{}
+	if args.Page > 0 {
+		qry.Set("page", strconv.Itoa(args.Page))
+	}
+	if args.Limit > 0 {
+		qry.Set("limit", strconv.Itoa(args.Limit))
+	}
+
+	req, err := http.NewRequest("GET", apiPath(path)+"?"+qry.Encode(), nil)
+	if err != nil {
+		return nil, false, err
+	}
+	header, err := c.do(ctx, req, &repos)
+	if err != nil {
+		return nil, false, err
+	}
+	return repos, hasNextPage(header), nil
+}
+
+// GetRepo returns the repository with the given owner and name.
+//
+// See https://try.gitea.io/api/swagger#/repository/repoGet
+func (c *Client) GetRepo(ctx context.Context, owner, name string) (*Repository, error) {
+	req, err := http.NewRequest("GET", apiPath(repoPath(owner, name)), nil)
+	if err != nil {
+		return nil, err
+	}
+	var r Repository
+	if _, err := c.do(ctx, req, &r); err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+// GetAuthenticatedUser returns the user the client is authenticated as.
+//
+// See https://try.gitea.io/api/swagger#/user/userGetCurrent
+func (c *Client) GetAuthenticatedUser(ctx context.Context) (*User, error) {
+	req, err := http.NewRequest("GET", apiPath("user"), nil)
+	if err != nil {
+		return nil, err
+	}
+	var u User
+	if _, err := c.do(ctx, req, &u); err != nil {
+		return nil, err
+	}
+	return &u, nil
+}
+
+// GetPullRequest returns the pull request with the given index in the given
+// repository.
+//
+// See https://try.gitea.io/api/swagger#/repository/repoGetPullRequest
+func (c *Client) GetPullRequest(ctx context.Context, owner, repo string, index int64) (*PullRequest, error) {
+	req, err := http.NewRequest("GET", apiPath(pullRequestPath(owner, repo, index)), nil)
+	if err != nil {
+		return nil, err
+	}
+	var pr PullRequest
+	if _, err := c.do(ctx, req, &pr); err != nil {
+		return nil, err
+	}
+	return &pr, nil
+}
+
+// GetPullRequestByBranches returns the most recent pull request from the head
+// branch into the base branch of the given repository.
+//
+// See https://try.gitea.io/api/swagger#/repository/repoGetPullRequestByBaseHead
+func (c *Client) GetPullRequestByBranches(ctx context.Context, owner, repo, base, head string) (*PullRequest, error) {
+	path := repoPath(owner, repo) + "/pulls/" + url.PathEscape(base) + "/" + url.PathEscape(head)
+	req, err := http.NewRequest("GET", apiPath(path), nil)
+	if err != nil {
+		return nil, err
+	}
+	var pr PullRequest
+	if _, err := c.do(ctx, req, &pr); err != nil {
+		return nil, err
+	}
+	return &pr, nil
+}
+
+// CreatePullRequestInput are the fields of a new pull request.
+type CreatePullRequestInput struct {
+	Title string `json:"title"`
+	Body  string `json:"body"`
+	// Head and Base are branch names, not fully qualified refs.
+	Head string `json:"head"`
+	Base string `json:"base"`
+}
+
+// CreatePullRequest opens a new pull request in the given repository. If a
+// pull request between the same branches is already open,
+// ErrPullRequestAlreadyExists is returned.
+//
+// See https://try.gitea.io/api/swagger#/repository/repoCreatePullRequest
+func (c *Client) CreatePullRequest(ctx context.Context, owner, repo string, in *CreatePullRequestInput) (*PullRequest, error) {
+	req, err := newJSONRequest("POST", apiPath(repoPath(owner, repo)+"/pulls"), in)
+	if err != nil {
+		return nil, err
+	}
+	var pr PullRequest
+	if _, err := c.do(ctx, req, &pr); err != nil {
+		var e *httpError
+		if errors.As(err, &e) && e.StatusCode == http.StatusConflict {
+			return nil, ErrPullRequestAlreadyExists
+		}
+		return nil, err
+	}
+	return &pr, nil
+}
+
+// EditPullRequestInput are the fields of a pull request to update. Nil fields
+// are left unchanged.
+type EditPullRequestInput struct {
+	Title *string `json:"title,omitempty"`
+	Body  *string `json:"body,omitempty"`
+	Base  string  `json:"base,omitempty"`
+	// State is either PullRequestStateOpen or PullRequestStateClosed.
+	State PullRequestState `json:"state,omitempty"`
+}
+
+// EditPullRequest updates the pull request with the given index, and returns
+// the updated pull request.
+//
+// See https://try.gitea.io/api/swagger#/repository/repoEditPullRequest
+func (c *Client) EditPullRequest(ctx context.Context, owner, repo string, index int64, in *EditPullRequestInput) (*PullRequest, error) {
+	req, err := newJSONRequest("PATCH", apiPath(pullRequestPath(owner, repo, index)), in)
+	if err != nil {
+		return nil, err
+	}
+	var pr PullRequest
+	if _, err := c.do(ctx, req, &pr); err != nil {
+		return nil, err
+	}
+	return &pr, nil
+}
+
+// CreateComment adds a comment to the pull request (or issue) with the given
+// index.
+//
+// See https://try.gitea.io/api/swagger#/issue/issueCreateComment
+func (c *Client) CreateComment(ctx context.Context, owner, repo string, index int64, body string) error {
+	path := repoPath(owner, repo) + "/issues/" + strconv.FormatInt(index, 10) + "/comments"
+	req, err := newJSONRequest("POST", apiPath(path), map[string]string{"body": body})
+	if err != nil {
+		return err
+	}
+	_, err = c.do(ctx, req, nil)
+	return err
+}
+
+// ErrNotMergeable is returned by MergePullRequest when the pull request cannot
+// be merged, because a precondition isn't met.
+var ErrNotMergeable = errors.New("pull request is not in a mergeable state")
+
+// MergePullRequest merges the pull request with the given index. If squash
+// is true, the commits of the pull request are squashed into a single commit.
+//
+// See https://try.gitea.io/api/swagger#/repository/repoMergePullRequest
+func (c *Client) MergePullRequest(ctx context.Context, owner, repo string, index int64, squash bool) error {
+	style := "merge"
+	if squash {
+		style = "squash"
+	}
+	req, err := newJSONRequest("POST", apiPath(pullRequestPath(owner, repo, index)+"/merge"), map[string]string{"Do": style})
+	if err != nil {
+		return err
+	}
+	if _, err := c.do(ctx, req, nil); err != nil {
+		var e *httpError
+		if errors.As(err, &e) && (e.StatusCode == http.StatusMethodNotAllowed || e.StatusCode == http.StatusConflict) {
+			return errors.Wrap(ErrNotMergeable, err.Error())
+		}
+		return err
+	}
+	return nil
+}
+
+func apiPath(p string) string { return "api/v1/" + p }
+
+func repoPath(owner, name string) string {
+	return "repos/" + url.PathEscape(owner) + "/" + url.PathEscape(name)
+}
+
+func pullRequestPath(owner, repo string, index int64) string {
+	return repoPath(owner, repo) + "/pulls/" + strconv.FormatInt(index, 10)
+}
+
+func newJSONRequest(method, path string, body interface{}) (*http.Request, error) {
+	bs, err := json.Marshal(body)
+	if err != nil {
+		return nil, errors.Wrap(err, "marshalling request body")
+	}
+	req, err := http.NewRequest(method, path, bytes.NewReader(bs))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	return req, nil
+}
+
+// hasNextPage reports whether the Link header of a paginated response
+// references a next page.
+func hasNextPage(h http.Header) bool {
+	for _, link := range strings.Split(h.Get("Link"), ",") {
+		if strings.Contains(link, `rel="next"`) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *Client) do(ctx context.Context, req *http.Request, result interface{}) (http.Header, error) {
+	req.URL = c.URL.ResolveReference(req.URL)
+	req.Header.Set("Accept", "application/json")
+
+	req, ht := nethttp.TraceRequest(ot.GetTracer(ctx),
+		req.WithContext(ctx),
+		nethttp.OperationName("Gitea"),
+		nethttp.ClientTrace(false))
+	defer ht.Finish()
+
+	if c.Auth != nil {
+		if err := c.Auth.Authenticate(req); err != nil {
+			return nil, errors.Wrap(err, "authenticating request")
+		}
+	}
+
+	startWait := time.Now()
+	if err := c.RateLimit.Wait(ctx); err != nil {
+		return nil, err
+	}
+
+	if d := time.Since(startWait); d > 200*time.Millisecond {
+		log15.Warn("Gitea self-enforced API rate limit: request delayed longer than expected due to rate limit", "delay", d)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	defer resp.Body.Close()
+
+	bs, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 400 {
+		return nil, errors.WithStack(&httpError{
+			URL:        req.URL,
+			StatusCode: resp.StatusCode,
+			Body:       bs,
+		})
+	}
+
+	if result != nil {
+		return resp.Header, json.Unmarshal(bs, result)
+	}
+
+	return resp.Header, nil
+}
+
+// User is a Gitea user or organization.
+type User struct {
+	ID       int64  `json:"id"`
+	Login    string `json:"login"`
+	FullName string `json:"full_name,omitempty"`
+	Email    string `json:"email,omitempty"`
+}
+
+// Repository is a Gitea repository.
+type Repository struct {
+	ID            int64  `json:"id"`
+	Owner         *User  `json:"owner"`
+	Name          string `json:"name"`
+	FullName      string `json:"full_name"`
+	Description   string `json:"description"`
+	Private       bool   `json:"private"`
+	Internal      bool   `json:"internal"`
+	Fork          bool   `json:"fork"`
+	Mirror        bool   `json:"mirror"`
+	Archived      bool   `json:"archived"`
+	Empty         bool   `json:"empty"`
+	HTMLURL       string `json:"html_url"`
+	SSHURL        string `json:"ssh_url"`
+	CloneURL      string `json:"clone_url"`
+	DefaultBranch string `json:"default_branch"`
+	Stars         int    `json:"stars_count"`
+}
+
+// PullRequestState is the state of a Gitea pull request. Merged pull requests
+// are closed, with Merged set.
+type PullRequestState string
+
+const (
+	PullRequestStateOpen   PullRequestState = "open"
+	PullRequestStateClosed PullRequestState = "closed"
+)
+
+// workInProgressPrefixes are the title prefixes that mark a pull request as
+// work in progress in Gitea's default configuration.
+var workInProgressPrefixes = []string{"WIP:", "[WIP]"}
+
+// IsWIP returns true if the given pull request title marks it as work in
+// progress.
+func IsWIP(title string) bool {
+	upper := strings.ToUpper(title)
+	for _, prefix := range workInProgressPrefixes {
+		if strings.HasPrefix(upper, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// SetWIP ensures the given title is prefixed with "WIP: ".
+func SetWIP(title string) string {
+	if IsWIP(title) {
+		return title
+	}
+	return "WIP: " + title
+}
+
+// UnsetWIP removes the work in progress prefixes from the given title.
+func UnsetWIP(title string) string {
+	for _, prefix := range workInProgressPrefixes {
+		if len(title) >= len(prefix) && strings.EqualFold(title[:len(prefix)], prefix) {
+			return strings.TrimLeft(title[len(prefix):], " ")
+		}
+	}
+	return title
+}
+
+// PullRequest is a Gitea pull request.
+type PullRequest struct {
+	ID        int64            `json:"id"`
+	Number    int64            `json:"number"`
+	User      *User            `json:"user"`
+	Title     string           `json:"title"`
+	Body      string           `json:"body"`
+	Labels    []*Label         `json:"labels"`
+	State     PullRequestState `json:"state"`
+	HTMLURL   string           `json:"html_url"`
+	Mergeable bool             `json:"mergeable"`
+	Merged    bool             `json:"merged"`
+	MergedAt  *time.Time       `json:"merged_at"`
+	Head      *PRBranchInfo    `json:"head"`
+	Base      *PRBranchInfo    `json:"base"`
+	CreatedAt time.Time        `json:"created_at"`
+	UpdatedAt time.Time        `json:"updated_at"`
+	ClosedAt  *time.Time       `json:"closed_at"`
+}
+
+// IsWorkInProgress reports whether the pull request is marked as work in
+// progress, which Gitea does by prefixing its title.
+func (pr *PullRequest) IsWorkInProgress() bool { return IsWIP(pr.Title) }
+
+// PRBranchInfo is the head or base branch of a pull request.
+type PRBranchInfo struct {
+	Label  string `json:"label"`
+	Ref    string `json:"ref"`
+	SHA    string `json:"sha"`
+	RepoID int64  `json:"repo_id"`
+}
+
+// Label is a label of an issue or pull request.
+type Label struct {
+	ID          int64  `json:"id"`
+	Name        string `json:"name"`
+	Color       string `json:"color"`
+	Description string `json:"description"`
+}
+
+type httpError struct {
+	StatusCode int
+	URL        *url.URL
+	Body       []byte
+}
+
+func (e *httpError) Error() string {
+	return fmt.Sprintf("Gitea API HTTP error: code=%d url=%q body=%q", e.StatusCode, e.URL, e.Body)
+}
+
+func (e *httpError) Unauthorized() bool {
+	return e.StatusCode == http.StatusUnauthorized
+}
+
+func (e *httpError) NotFound() bool {
+	return e.StatusCode == http.StatusNotFound
+}
@@ -0,0 +1,125 @@
+package gitea
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net/url"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/sourcegraph/sourcegraph/internal/errcode"
+)
+
+var update = flag.Bool("update", false, "update testdata")
+
+var testURL = &url.URL{Scheme: "https", Host: "gitea.sgdev.org"}
+
+func TestClient_ListOrgRepos(t *testing.T) {
+	cli, save := NewTestClient(t, "ListOrgRepos", *update, testURL)
+	defer save()
+
+	var (
+		names []string
+		pages int
+	)
+	for page := 1; ; page++ {
+		repos, more, err := cli.ListOrgRepos(context.Background(), "sourcegraph", ListReposArgs{Page: page, Limit: 2})
+		if err != nil {
+			t.Fatal(err)
+		}
+		pages++
+		for _, r := range repos {
+			names = append(names, r.FullName)
+		}
+		if !more {
+			break
+		}
+	}
+
+	want := []string{
+		"sourcegraph/go-diff",
+		"sourcegraph/internal-tools",
+		"sourcegraph/legacy",
+		"sourcegraph/sourcegraph",
+		"sourcegraph/src-cli",
+	}
+	if diff := cmp.Diff(want, names); diff != "" {
+		t.Errorf("repos mismatch (-want +got):\n%s", diff)
+	}
+	if pages != 3 {
+		t.Errorf("want 3 pages, got %d", pages)
+	}
+}
+
+func TestClient_GetRepo(t *testing.T) {
+	cli, save := NewTestClient(t, "GetRepo", *update, testURL)
+	defer save()
+
+	for _, tc := range []struct {
+		name string
+		repo *Repository
+		err  string
+	}{
+		{
+			name: "dotfiles",
+			repo: &Repository{
+				ID:            20,
+				Owner:         &User{ID: 3, Login: "alice", FullName: "Alice", Email: "alice@sourcegraph.com"},
+				Name:          "dotfiles",
+				FullName:      "alice/dotfiles",
+				Description:   "My dotfiles",
+				HTMLURL:       "https://gitea.sgdev.org/alice/dotfiles",
+				SSHURL:        "git@gitea.sgdev.org:alice/dotfiles.git",
+				CloneURL:      "https://gitea.sgdev.org/alice/dotfiles.git",
+				DefaultBranch: "main",
+			},
+			err: "<nil>",
+		},
+		{
+			name: "missing",
+			err:  `Gitea API HTTP error: code=404 url="https://gitea.sgdev.org/api/v1/repos/alice/missing" body="{\"errors\":null,\"message\":\"The target couldn't be found.\",\"url\":\"https://gitea.sgdev.org/api/swagger\"}\n"`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			repo, err := cli.GetRepo(context.Background(), "alice", tc.name)
+			if have, want := fmt.Sprint(err), tc.err; have != want {
+				t.Errorf("error:\nhave: %q\nwant: %q", have, want)
+			}
+			if err != nil && !errcode.IsNotFound(err) {
+				t.Errorf("want not found error, got %v", err)
+			}
+			if diff := cmp.Diff(tc.repo, repo); diff != "" {
+				t.Errorf("repo mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestWIP(t *testing.T) {
+	for _, tc := range []struct {
+		title string
+		isWIP bool
+		set   string
+		unset string
+	}{
+		{title: "Fix the thing", isWIP: false, set: "WIP: Fix the thing", unset: "Fix the thing"},
+		{title: "WIP: Fix the thing", isWIP: true, set: "WIP: Fix the thing", unset: "Fix the thing"},
+		{title: "wip: Fix the thing", isWIP: true, set: "wip: Fix the thing", unset: "Fix the thing"},
+		{title: "[WIP] Fix the thing", isWIP: true, set: "[WIP] Fix the thing", unset: "Fix the thing"},
+		{title: "Wipe the disk", isWIP: false, set: "WIP: Wipe the disk", unset: "Wipe the disk"},
+	} {
+		t.Run(tc.title, func(t *testing.T) {
+			if have := IsWIP(tc.title); have != tc.isWIP {
+				t.Errorf("IsWIP: have %v, want %v", have, tc.isWIP)
+			}
+			if have := SetWIP(tc.title); have != tc.set {
+				t.Errorf("SetWIP: have %q, want %q", have, tc.set)
+			}
+			if have := UnsetWIP(tc.title); have != tc.unset {
+				t.Errorf("UnsetWIP: have %q, want %q", have, tc.unset)
+			}
+		})
+	}
+}
@@ -0,0 +1,50 @@
+package gitea
+
+import (
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/auth"
+	"github.com/sourcegraph/sourcegraph/internal/httpcli"
+	"github.com/sourcegraph/sourcegraph/internal/httptestutil"
+	"github.com/sourcegraph/sourcegraph/internal/lazyregexp"
+)
+
+// GetenvTestGiteaToken returns the access token used by tests recording
+// interactions with a Gitea instance.
+func GetenvTestGiteaToken() string {
+	return os.Getenv("GITEA_TOKEN")
+}
+
+// NewTestClient returns a gitea.Client that records its interactions
+// to testdata/vcr/.
+func NewTestClient(t testing.TB, name string, update bool, baseURL *url.URL) (*Client, func()) {
+	t.Helper()
+
+	cassete := filepath.Join("testdata/vcr/", normalize(name))
+	rec, err := httptestutil.NewRecorder(cassete, update)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	hc, err := httpcli.NewFactory(nil, httptestutil.NewRecorderOpt(rec)).Doer()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cli := NewClient(baseURL, &auth.OAuthBearerToken{Token: GetenvTestGiteaToken()}, hc)
+
+	return cli, func() {
+		if err := rec.Stop(); err != nil {
+			t.Errorf("failed to update test data: %s", err)
+		}
+	}
+}
+
+var normalizer = lazyregexp.New("[^A-Za-z0-9-]+")
+
+func normalize(path string) string {
+	return normalizer.ReplaceAllLiteralString(path, "-")
+}
@@ -25,6 +25,16 @@ type PipelineEvent struct {
 	MergeRequest *gitlab.MergeRequest `json:"merge_request"`
 }
 
+// PushEvent is sent when commits or tags are pushed to a project. Tag pushes
+// use the same payload, with the "tag_push" object kind.
+type PushEvent struct {
+	EventCommon
+
+	Ref    string `json:"ref"`
+	Before string `json:"before"`
+	After  string `json:"after"`
+}
+
 var ErrObjectKindUnknown = errors.New("unknown object kind")
 
 type downcaster interface {
@@ -60,6 +70,8 @@ func UnmarshalEvent(data []byte) (interface{}, error) {
 		typedEvent = &mergeRequestEvent{}
 	case "pipeline":
 		typedEvent = &PipelineEvent{}
+	case "push", "tag_push":
+		typedEvent = &PushEvent{}
 	default:
 		return nil, errors.Wrapf(ErrObjectKindUnknown, "kind: %s", event.ObjectKind)
 	}
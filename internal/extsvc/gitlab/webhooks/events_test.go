@@ -110,4 +110,31 @@ func TestUnmarshalEvent(t *testing.T) {
 			t.Errorf("unexpected IID: have %d; want %d", pe.Pipeline.ID, want)
 		}
 	})
+	t.Run("valid push", func(t *testing.T) {
+		event, err := UnmarshalEvent([]byte(`
+			{
+				"object_kind": "push",
+				"ref": "refs/heads/main",
+				"after": "da1560886d4f094c3e6c9ef40349f7d38b5d27d7",
+				"project": {
+					"id": 15,
+					"path_with_namespace": "mike/diaspora"
+				}
+			}
+		`))
+		if event == nil {
+			t.Error("unexpected nil event")
+		}
+		if err != nil {
+			t.Errorf("unexpected error: %+v", err)
+		}
+
+		pe := event.(*PushEvent)
+		if want := 15; pe.Project.ID != want {
+			t.Errorf("unexpected project ID: have %d; want %d", pe.Project.ID, want)
+		}
+		if want := "refs/heads/main"; pe.Ref != want {
+			t.Errorf("unexpected ref: have %s; want %s", pe.Ref, want)
+		}
+	})
 }
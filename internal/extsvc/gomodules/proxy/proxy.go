@@ -0,0 +1,217 @@
+// Package proxy implements a minimal client for the Go module proxy protocol,
+// covering what's needed to mirror Go modules on Sourcegraph.
+//
+// See https://golang.org/ref/mod#goproxy-protocol
+package proxy
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/cockroachdb/errors"
+	"golang.org/x/mod/module"
+	"golang.org/x/mod/semver"
+	"golang.org/x/time/rate"
+
+	"github.com/sourcegraph/sourcegraph/internal/conf/reposource"
+	"github.com/sourcegraph/sourcegraph/internal/httpcli"
+	"github.com/sourcegraph/sourcegraph/internal/ratelimit"
+	"github.com/sourcegraph/sourcegraph/schema"
+)
+
+// DefaultURL is the public Go module proxy, used when no proxy is configured.
+const DefaultURL = "https://proxy.golang.org"
+
+// Client accesses a list of Go module proxies. Like the go command does with a
+// comma separated GOPROXY, each request falls back to the next proxy when a
+// proxy responds that it doesn't know the module or version.
+type Client struct {
+	proxies []*url.URL
+	doer    httpcli.Doer
+	limiter *rate.Limiter
+}
+
+// NewClient returns a client for the proxies of the given connection. If a
+// nil doer is provided, httpcli.ExternalDoer will be used.
+func NewClient(config *schema.GoModulesConnection, doer httpcli.Doer) (*Client, error) {
+	if doer == nil {
+		doer = httpcli.ExternalDoer
+	}
+
+	rawURLs := config.Urls
+	if len(rawURLs) == 0 {
+		rawURLs = []string{DefaultURL}
+	}
+
+	proxies := make([]*url.URL, 0, len(rawURLs))
+	for _, rawURL := range rawURLs {
+		u, err := url.Parse(strings.TrimSuffix(rawURL, "/"))
+		if err != nil {
+			return nil, errors.Wrap(err, "parsing Go module proxy URL")
+		}
+		proxies = append(proxies, u)
+	}
+
+	limit := rate.Inf
+	if config.RateLimit != nil && config.RateLimit.Enabled {
+		limit = rate.Limit(config.RateLimit.RequestsPerHour / 3600.0)
+	}
+
+	// All the proxies of a connection share the limiter, which is keyed by
+	// the first one without its credentials.
+	key := *proxies[0]
+	key.User = nil
+
+	return &Client{
+		proxies: proxies,
+		doer:    doer,
+		limiter: ratelimit.DefaultRegistry.GetOrSet(key.String(), rate.NewLimiter(limit, 100)),
+	}, nil
+}
+
+// ListVersions returns the release versions of the given module known to the
+// proxies, sorted in descending semantic version order. Pseudo-versions are
+// never listed by proxies.
+func (c *Client) ListVersions(ctx context.Context, mod reposource.GoModule) ([]string, error) {
+	body, err := c.get(ctx, mod.EscapedPath()+"/@v/list")
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	var versions []string
+	scanner := bufio.NewScanner(body)
+	for scanner.Scan() {
+		if v := strings.TrimSpace(scanner.Text()); semver.IsValid(v) {
+			versions = append(versions, v)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Wrapf(err, "reading versions of %s", mod.Path)
+	}
+
+	sort.Slice(versions, func(i, j int) bool {
+		return semver.Compare(versions[i], versions[j]) > 0
+	})
+	return versions, nil
+}
+
+// Exists reports whether the given version of a module is known to the
+// proxies. A dependency without a version exists if the module has at least
+// one listed version.
+func (c *Client) Exists(ctx context.Context, dependency reposource.GoDependency) (bool, error) {
+	if dependency.Version == "" {
+		versions, err := c.ListVersions(ctx, dependency.GoModule)
+		if err != nil && !IsNotFound(err) {
+			return false, err
+		}
+		return len(versions) > 0, nil
+	}
+
+	body, err := c.get(ctx, c.versionPath(dependency, ".info"))
+	if err != nil {
+		if IsNotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	body.Close()
+	return true, nil
+}
+
+// GetMod returns the go.mod file of the given version of a module.
+func (c *Client) GetMod(ctx context.Context, dependency reposource.GoDependency) ([]byte, error) {
+	body, err := c.get(ctx, c.versionPath(dependency, ".mod"))
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+	return io.ReadAll(body)
+}
+
+// GetZip returns the zip archive of the given version of a module. The caller
+// must close the returned reader.
+func (c *Client) GetZip(ctx context.Context, dependency reposource.GoDependency) (io.ReadCloser, error) {
+	return c.get(ctx, c.versionPath(dependency, ".zip"))
+}
+
+func (c *Client) versionPath(dependency reposource.GoDependency, ext string) string {
+	// Versions are escaped like module paths, since they can contain upper
+	// case letters too.
+	escaped, _ := module.EscapeVersion(dependency.Version)
+	return fmt.Sprintf("%s/@v/%s%s", dependency.EscapedPath(), escaped, ext)
+}
+
+// get requests the given path from each proxy in turn, until one of them
+// doesn't respond with a not found error.
+func (c *Client) get(ctx context.Context, path string) (body io.ReadCloser, err error) {
+	for _, proxy := range c.proxies {
+		body, err = c.getFrom(ctx, proxy, path)
+		if err == nil || !IsNotFound(err) {
+			return body, err
+		}
+	}
+	return nil, err
+}
+
+func (c *Client) getFrom(ctx context.Context, proxy *url.URL, path string) (io.ReadCloser, error) {
+	// The path is appended as is, since the escaping of module paths uses
+	// characters like "!" that url.URL would escape again.
+	u := *proxy
+	u.User = nil
+	rawURL := u.String() + "/" + path
+
+	req, err := http.NewRequestWithContext(ctx, "GET", rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	if proxy.User != nil {
+		password, _ := proxy.User.Password()
+		req.SetBasicAuth(proxy.User.Username(), password)
+	}
+
+	if err := c.limiter.Wait(ctx); err != nil {
+		return nil, err
+	}
+
+	resp, err := c.doer.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, &httpError{URL: rawURL, StatusCode: resp.StatusCode, Body: body}
+	}
+	return resp.Body, nil
+}
+
+type httpError struct {
+	URL        string
+	StatusCode int
+	Body       []byte
+}
+
+func (e *httpError) Error() string {
+	return fmt.Sprintf("Go module proxy request to %s failed with status %d: %s", e.URL, e.StatusCode, string(e.Body))
+}
+
+// NotFound reports whether the proxy doesn't know the module or version.
+// Like the go command, 410 Gone is treated the same as 404 Not Found.
+func (e *httpError) NotFound() bool {
+	return e.StatusCode == http.StatusNotFound || e.StatusCode == http.StatusGone
+}
+
+// IsNotFound reports whether err is a proxy response saying the module or
+// version doesn't exist.
+func IsNotFound(err error) bool {
+	var e *httpError
+	return errors.As(err, &e) && e.NotFound()
+}
@@ -0,0 +1,133 @@
+package proxy
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/sourcegraph/sourcegraph/internal/conf/reposource"
+	"github.com/sourcegraph/sourcegraph/schema"
+)
+
+func TestClient(t *testing.T) {
+	var requests []string
+	private := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, password, _ := r.BasicAuth()
+		requests = append(requests, fmt.Sprintf("private %s:%s %s", user, password, r.URL.EscapedPath()))
+		switch r.URL.EscapedPath() {
+		case "/example.com/!private/@v/list":
+			fmt.Fprint(w, "v1.0.0\nv1.10.0\nv1.2.0\n")
+		case "/example.com/!private/@v/v1.10.0.zip":
+			fmt.Fprint(w, "private zip")
+		default:
+			http.Error(w, "not found", http.StatusNotFound)
+		}
+	}))
+	defer private.Close()
+
+	public := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, "public "+r.URL.EscapedPath())
+		switch r.URL.EscapedPath() {
+		case "/golang.org/x/mod/@v/v0.4.2.info":
+			fmt.Fprint(w, `{"Version":"v0.4.2"}`)
+		case "/golang.org/x/mod/@v/v0.4.2.mod":
+			fmt.Fprint(w, "module golang.org/x/mod\n")
+		default:
+			http.Error(w, "gone", http.StatusGone)
+		}
+	}))
+	defer public.Close()
+
+	privateURL := "http://user:secret@" + private.Listener.Addr().String()
+	client, err := NewClient(&schema.GoModulesConnection{
+		Urls: []string{privateURL, public.URL + "/"},
+	}, http.DefaultClient)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parse := func(dependency string) reposource.GoDependency {
+		d, err := reposource.ParseGoDependency(dependency)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return d
+	}
+
+	ctx := context.Background()
+
+	t.Run("list versions", func(t *testing.T) {
+		requests = nil
+		have, err := client.ListVersions(ctx, parse("example.com/Private").GoModule)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := []string{"v1.10.0", "v1.2.0", "v1.0.0"}; !reflect.DeepEqual(have, want) {
+			t.Errorf("wrong versions: have %q, want %q", have, want)
+		}
+		if want := []string{"private user:secret /example.com/!private/@v/list"}; !reflect.DeepEqual(requests, want) {
+			t.Errorf("wrong requests: have %q, want %q", requests, want)
+		}
+	})
+
+	t.Run("exists", func(t *testing.T) {
+		for dependency, want := range map[string]bool{
+			"golang.org/x/mod@v0.4.2": true,
+			"golang.org/x/mod@v0.0.1": false,
+			"example.com/Private":     true,
+			"example.com/unknown":     false,
+		} {
+			have, err := client.Exists(ctx, parse(dependency))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if have != want {
+				t.Errorf("Exists(%q): have %t, want %t", dependency, have, want)
+			}
+		}
+	})
+
+	t.Run("fallback", func(t *testing.T) {
+		requests = nil
+		mod, err := client.GetMod(ctx, parse("golang.org/x/mod@v0.4.2"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(mod) != "module golang.org/x/mod\n" {
+			t.Errorf("wrong go.mod: %q", mod)
+		}
+		want := []string{
+			"private user:secret /golang.org/x/mod/@v/v0.4.2.mod",
+			"public /golang.org/x/mod/@v/v0.4.2.mod",
+		}
+		if !reflect.DeepEqual(requests, want) {
+			t.Errorf("wrong requests: have %q, want %q", requests, want)
+		}
+	})
+
+	t.Run("zip", func(t *testing.T) {
+		rc, err := client.GetZip(ctx, parse("example.com/Private@v1.10.0"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer rc.Close()
+		data, err := io.ReadAll(rc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != "private zip" {
+			t.Errorf("wrong zip: %q", data)
+		}
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		_, err := client.GetZip(ctx, parse("golang.org/x/mod@v0.0.1"))
+		if !IsNotFound(err) {
+			t.Fatalf("expected not found error, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,174 @@
+// Package npm implements a minimal client for npm registries, covering what's
+// needed to mirror published packages on Sourcegraph.
+package npm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/cockroachdb/errors"
+	"golang.org/x/time/rate"
+
+	"github.com/sourcegraph/sourcegraph/internal/conf/reposource"
+	"github.com/sourcegraph/sourcegraph/internal/httpcli"
+	"github.com/sourcegraph/sourcegraph/internal/ratelimit"
+	"github.com/sourcegraph/sourcegraph/schema"
+)
+
+// DefaultRegistry is the public npm registry, used when no registry is
+// configured.
+const DefaultRegistry = "https://registry.npmjs.org"
+
+// Client accesses an npm registry via its HTTP API.
+type Client struct {
+	registryURL string
+	credentials string
+	doer        httpcli.Doer
+	limiter     *rate.Limiter
+}
+
+// NewClient returns a client for the registry of the given connection. If a
+// nil doer is provided, httpcli.ExternalDoer will be used.
+func NewClient(config *schema.NPMPackagesConnection, doer httpcli.Doer) *Client {
+	if doer == nil {
+		doer = httpcli.ExternalDoer
+	}
+
+	registryURL := strings.TrimSuffix(config.Registry, "/")
+	if registryURL == "" {
+		registryURL = DefaultRegistry
+	}
+
+	limit := rate.Inf
+	if config.RateLimit != nil && config.RateLimit.Enabled {
+		limit = rate.Limit(config.RateLimit.RequestsPerHour / 3600.0)
+	}
+
+	return &Client{
+		registryURL: registryURL,
+		credentials: config.Credentials,
+		doer:        doer,
+		limiter:     ratelimit.DefaultRegistry.GetOrSet(registryURL, rate.NewLimiter(limit, 100)),
+	}
+}
+
+// Version is the metadata of a published version of a package.
+type Version struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+	Dist    struct {
+		Tarball string `json:"tarball"`
+	} `json:"dist"`
+}
+
+// GetVersion returns the metadata of the given version of a package.
+//
+// See https://github.com/npm/registry/blob/master/docs/REGISTRY-API.md#getpackageversion
+func (c *Client) GetVersion(ctx context.Context, dependency reposource.NPMDependency) (*Version, error) {
+	// The slash separating the scope from the name has to be escaped.
+	u := fmt.Sprintf("%s/%s/%s", c.registryURL, url.PathEscape(dependency.PackageSyntax()), url.PathEscape(dependency.Version))
+
+	body, err := c.get(ctx, u, true)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	var v Version
+	if err := json.NewDecoder(body).Decode(&v); err != nil {
+		return nil, errors.Wrapf(err, "decoding metadata of %s", dependency.PackageManagerSyntax())
+	}
+	if v.Dist.Tarball == "" {
+		return nil, errors.Errorf("no tarball for %s", dependency.PackageManagerSyntax())
+	}
+	return &v, nil
+}
+
+// Exists reports whether the given version of a package is published on the
+// registry.
+func (c *Client) Exists(ctx context.Context, dependency reposource.NPMDependency) (bool, error) {
+	if _, err := c.GetVersion(ctx, dependency); err != nil {
+		if IsNotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// FetchTarball returns the gzipped tarball of the given version of a package.
+// The caller must close the returned reader.
+func (c *Client) FetchTarball(ctx context.Context, dependency reposource.NPMDependency) (io.ReadCloser, error) {
+	v, err := c.GetVersion(ctx, dependency)
+	if err != nil {
+		return nil, err
+	}
+
+	// Tarballs can be served from a different host than the registry, in
+	// which case the credentials mustn't be sent along.
+	return c.get(ctx, v.Dist.Tarball, c.isRegistryURL(v.Dist.Tarball))
+}
+
+func (c *Client) isRegistryURL(rawURL string) bool {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return false
+	}
+	registry, err := url.Parse(c.registryURL)
+	if err != nil {
+		return false
+	}
+	return u.Scheme == registry.Scheme && u.Host == registry.Host
+}
+
+func (c *Client) get(ctx context.Context, u string, authenticate bool) (io.ReadCloser, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", u, nil)
+	if err != nil {
+		return nil, err
+	}
+	if authenticate && c.credentials != "" {
+		req.Header.Set("Authorization", "Bearer "+c.credentials)
+	}
+
+	if err := c.limiter.Wait(ctx); err != nil {
+		return nil, err
+	}
+
+	resp, err := c.doer.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, &httpError{URL: u, StatusCode: resp.StatusCode, Body: body}
+	}
+	return resp.Body, nil
+}
+
+type httpError struct {
+	URL        string
+	StatusCode int
+	Body       []byte
+}
+
+func (e *httpError) Error() string {
+	return fmt.Sprintf("npm registry request to %s failed with status %d: %s", e.URL, e.StatusCode, string(e.Body))
+}
+
+func (e *httpError) NotFound() bool {
+	return e.StatusCode == http.StatusNotFound
+}
+
+// IsNotFound reports whether err is a registry response saying the package or
+// version doesn't exist.
+func IsNotFound(err error) bool {
+	var e *httpError
+	return errors.As(err, &e) && e.NotFound()
+}
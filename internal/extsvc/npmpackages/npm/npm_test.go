@@ -0,0 +1,115 @@
+package npm
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sourcegraph/sourcegraph/internal/conf/reposource"
+	"github.com/sourcegraph/sourcegraph/schema"
+)
+
+func TestClient(t *testing.T) {
+	var authHeaders []string
+	mirror := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authHeaders = append(authHeaders, r.Header.Get("Authorization"))
+		fmt.Fprint(w, "mirrored tarball")
+	}))
+	defer mirror.Close()
+
+	var registry *httptest.Server
+	registry = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authHeaders = append(authHeaders, r.Header.Get("Authorization"))
+		switch r.URL.EscapedPath() {
+		case "/@types%2Fnode/16.11.1":
+			fmt.Fprintf(w, `{"name":"@types/node","version":"16.11.1","dist":{"tarball":"%s/node.tgz"}}`, registry.URL)
+		case "/react/17.0.2":
+			fmt.Fprintf(w, `{"name":"react","version":"17.0.2","dist":{"tarball":"%s/react.tgz"}}`, mirror.URL)
+		case "/node.tgz":
+			fmt.Fprint(w, "registry tarball")
+		default:
+			http.Error(w, `{"error":"Not found"}`, http.StatusNotFound)
+		}
+	}))
+	defer registry.Close()
+
+	client := NewClient(&schema.NPMPackagesConnection{
+		Registry:    registry.URL + "/",
+		Credentials: "secret",
+	}, http.DefaultClient)
+
+	parse := func(dependency string) reposource.NPMDependency {
+		d, err := reposource.ParseNPMDependency(dependency)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return d
+	}
+
+	ctx := context.Background()
+
+	t.Run("exists", func(t *testing.T) {
+		for dependency, want := range map[string]bool{
+			"@types/node@16.11.1": true,
+			"@types/node@0.0.0":   false,
+			"left-pad@1.3.0":      false,
+		} {
+			have, err := client.Exists(ctx, parse(dependency))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if have != want {
+				t.Errorf("Exists(%q): have %t, want %t", dependency, have, want)
+			}
+		}
+	})
+
+	for _, tc := range []struct {
+		dependency string
+		tarball    string
+		auth       []string
+	}{
+		{
+			dependency: "@types/node@16.11.1",
+			tarball:    "registry tarball",
+			auth:       []string{"Bearer secret", "Bearer secret"},
+		},
+		{
+			// The credentials must not leak to other hosts.
+			dependency: "react@17.0.2",
+			tarball:    "mirrored tarball",
+			auth:       []string{"Bearer secret", ""},
+		},
+	} {
+		t.Run("fetch "+tc.dependency, func(t *testing.T) {
+			authHeaders = nil
+
+			rc, err := client.FetchTarball(ctx, parse(tc.dependency))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer rc.Close()
+
+			data, err := io.ReadAll(rc)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(data) != tc.tarball {
+				t.Errorf("wrong tarball: have %q, want %q", data, tc.tarball)
+			}
+			if fmt.Sprint(authHeaders) != fmt.Sprint(tc.auth) {
+				t.Errorf("wrong authorization headers: have %q, want %q", authHeaders, tc.auth)
+			}
+		})
+	}
+
+	t.Run("not found", func(t *testing.T) {
+		_, err := client.FetchTarball(ctx, parse("left-pad@1.3.0"))
+		if !IsNotFound(err) {
+			t.Fatalf("expected not found error, got %v", err)
+		}
+	})
+}
@@ -77,12 +77,16 @@ const (
 	KindAWSCodeCommit   = "AWSCODECOMMIT"
 	KindBitbucketServer = "BITBUCKETSERVER"
 	KindBitbucketCloud  = "BITBUCKETCLOUD"
+	KindGerrit          = "GERRIT"
+	KindGitea           = "GITEA"
 	KindGitHub          = "GITHUB"
 	KindGitLab          = "GITLAB"
 	KindGitolite        = "GITOLITE"
 	KindPerforce        = "PERFORCE"
 	KindPhabricator     = "PHABRICATOR"
 	KindJVMPackages     = "JVMPACKAGES"
+	KindNPMPackages     = "NPMPACKAGES"
+	KindGoModules       = "GOMODULES"
 	KindOther           = "OTHER"
 )
 
@@ -102,6 +106,14 @@ const (
 	// ServiceID value is the base URL to the Bitbucket Cloud.
 	TypeBitbucketCloud = "bitbucketCloud"
 
+	// TypeGerrit is the (api.ExternalRepoSpec).ServiceType value for Gerrit projects. The ServiceID value
+	// is the base URL to the Gerrit instance.
+	TypeGerrit = "gerrit"
+
+	// TypeGitea is the (api.ExternalRepoSpec).ServiceType value for Gitea (and Forgejo) repositories. The
+	// ServiceID value is the base URL to the Gitea instance.
+	TypeGitea = "gitea"
+
 	// TypeGitHub is the (api.ExternalRepoSpec).ServiceType value for GitHub repositories. The ServiceID value
 	// is the base URL to the GitHub instance (https://github.com or the GitHub Enterprise URL).
 	TypeGitHub = "github"
@@ -122,6 +134,12 @@ const (
 	// TypeJVMPackages is the (api.ExternalRepoSpec).ServiceType value for Maven packages (Java/JVM ecosystem libraries).
 	TypeJVMPackages = "jvmPackages"
 
+	// TypeNPMPackages is the (api.ExternalRepoSpec).ServiceType value for npm packages (JavaScript/TypeScript ecosystem libraries).
+	TypeNPMPackages = "npmPackages"
+
+	// TypeGoModules is the (api.ExternalRepoSpec).ServiceType value for Go modules.
+	TypeGoModules = "goModules"
+
 	// TypeOther is the (api.ExternalRepoSpec).ServiceType value for other projects.
 	TypeOther = "other"
 
@@ -138,6 +156,10 @@ func KindToType(kind string) string {
 		return TypeBitbucketServer
 	case KindBitbucketCloud:
 		return TypeBitbucketCloud
+	case KindGerrit:
+		return TypeGerrit
+	case KindGitea:
+		return TypeGitea
 	case KindGitHub:
 		return TypeGitHub
 	case KindGitLab:
@@ -150,6 +172,10 @@ func KindToType(kind string) string {
 		return TypePerforce
 	case KindJVMPackages:
 		return TypeJVMPackages
+	case KindNPMPackages:
+		return TypeNPMPackages
+	case KindGoModules:
+		return TypeGoModules
 	case KindOther:
 		return TypeOther
 	default:
@@ -167,6 +193,10 @@ func TypeToKind(t string) string {
 		return KindBitbucketServer
 	case TypeBitbucketCloud:
 		return KindBitbucketCloud
+	case TypeGerrit:
+		return KindGerrit
+	case TypeGitea:
+		return KindGitea
 	case TypeGitHub:
 		return KindGitHub
 	case TypeGitLab:
@@ -179,6 +209,10 @@ func TypeToKind(t string) string {
 		return KindPhabricator
 	case TypeJVMPackages:
 		return KindJVMPackages
+	case TypeNPMPackages:
+		return KindNPMPackages
+	case TypeGoModules:
+		return KindGoModules
 	case TypeOther:
 		return KindOther
 	default:
@@ -191,6 +225,8 @@ var (
 	bbsLower = strings.ToLower(TypeBitbucketServer)
 	bbcLower = strings.ToLower(TypeBitbucketCloud)
 	jvmLower = strings.ToLower(TypeJVMPackages)
+	npmLower = strings.ToLower(TypeNPMPackages)
+	goLower  = strings.ToLower(TypeGoModules)
 )
 
 // ParseServiceType will return a ServiceType constant after doing a case insensitive match on s.
@@ -203,6 +239,10 @@ func ParseServiceType(s string) (string, bool) {
 		return TypeBitbucketServer, true
 	case bbcLower:
 		return TypeBitbucketCloud, true
+	case TypeGerrit:
+		return TypeGerrit, true
+	case TypeGitea:
+		return TypeGitea, true
 	case TypeGitHub:
 		return TypeGitHub, true
 	case TypeGitLab:
@@ -215,6 +255,10 @@ func ParseServiceType(s string) (string, bool) {
 		return TypePhabricator, true
 	case jvmLower:
 		return TypeJVMPackages, true
+	case npmLower:
+		return TypeNPMPackages, true
+	case goLower:
+		return TypeGoModules, true
 	case TypeOther:
 		return TypeOther, true
 	default:
@@ -232,6 +276,10 @@ func ParseServiceKind(s string) (string, bool) {
 		return KindBitbucketServer, true
 	case KindBitbucketCloud:
 		return KindBitbucketCloud, true
+	case KindGerrit:
+		return KindGerrit, true
+	case KindGitea:
+		return KindGitea, true
 	case KindGitHub:
 		return KindGitHub, true
 	case KindGitLab:
@@ -244,6 +292,10 @@ func ParseServiceKind(s string) (string, bool) {
 		return KindPhabricator, true
 	case KindJVMPackages:
 		return KindJVMPackages, true
+	case KindNPMPackages:
+		return KindNPMPackages, true
+	case KindGoModules:
+		return KindGoModules, true
 	case KindOther:
 		return KindOther, true
 	default:
@@ -273,6 +325,10 @@ func ParseConfig(kind, config string) (cfg interface{}, _ error) {
 		cfg = &schema.BitbucketServerConnection{}
 	case KindBitbucketCloud:
 		cfg = &schema.BitbucketCloudConnection{}
+	case KindGerrit:
+		cfg = &schema.GerritConnection{}
+	case KindGitea:
+		cfg = &schema.GiteaConnection{}
 	case KindGitHub:
 		cfg = &schema.GitHubConnection{}
 	case KindGitLab:
@@ -285,6 +341,10 @@ func ParseConfig(kind, config string) (cfg interface{}, _ error) {
 		cfg = &schema.PhabricatorConnection{}
 	case KindJVMPackages:
 		cfg = &schema.JVMPackagesConnection{}
+	case KindNPMPackages:
+		cfg = &schema.NPMPackagesConnection{}
+	case KindGoModules:
+		cfg = &schema.GoModulesConnection{}
 	case KindOther:
 		cfg = &schema.OtherExternalServiceConnection{}
 	default:
@@ -396,6 +456,20 @@ func GetLimitFromConfig(kind string, config interface{}) (rlc RateLimitConfig, e
 			rlc.IsDefault = false
 		}
 		rlc.BaseURL = c.Url
+	case *schema.GerritConnection:
+		rlc.Limit = defaultRateLimit
+		if c != nil && c.RateLimit != nil {
+			rlc.Limit = limitOrInf(c.RateLimit.Enabled, c.RateLimit.RequestsPerHour)
+			rlc.IsDefault = false
+		}
+		rlc.BaseURL = c.Url
+	case *schema.GiteaConnection:
+		rlc.Limit = defaultRateLimit
+		if c != nil && c.RateLimit != nil {
+			rlc.Limit = limitOrInf(c.RateLimit.Enabled, c.RateLimit.RequestsPerHour)
+			rlc.IsDefault = false
+		}
+		rlc.BaseURL = c.Url
 	case *schema.PerforceConnection:
 		rlc.Limit = rate.Limit(5000.0 / 3600.0)
 		if c != nil && c.RateLimit != nil {
@@ -410,6 +484,20 @@ func GetLimitFromConfig(kind string, config interface{}) (rlc RateLimitConfig, e
 			rlc.IsDefault = false
 		}
 		rlc.BaseURL = "maven"
+	case *schema.NPMPackagesConnection:
+		rlc.Limit = defaultRateLimit
+		if c != nil && c.RateLimit != nil {
+			rlc.Limit = limitOrInf(c.RateLimit.Enabled, c.RateLimit.RequestsPerHour)
+			rlc.IsDefault = false
+		}
+		rlc.BaseURL = "npm"
+	case *schema.GoModulesConnection:
+		rlc.Limit = rate.Limit(57600.0 / 3600.0)
+		if c != nil && c.RateLimit != nil {
+			rlc.Limit = limitOrInf(c.RateLimit.Enabled, c.RateLimit.RequestsPerHour)
+			rlc.IsDefault = false
+		}
+		rlc.BaseURL = "go"
 	default:
 		return rlc, ErrRateLimitUnsupported{codehostKind: kind}
 	}
@@ -492,6 +580,10 @@ func UniqueCodeHostIdentifier(kind, config string) (string, error) {
 		rawURL = c.Url
 	case *schema.BitbucketCloudConnection:
 		rawURL = c.Url
+	case *schema.GerritConnection:
+		rawURL = c.Url
+	case *schema.GiteaConnection:
+		rawURL = c.Url
 	case *schema.PhabricatorConnection:
 		rawURL = c.Url
 	case *schema.OtherExternalServiceConnection:
@@ -507,6 +599,10 @@ func UniqueCodeHostIdentifier(kind, config string) (string, error) {
 		return c.P4Port, nil
 	case *schema.JVMPackagesConnection:
 		return KindJVMPackages, nil
+	case *schema.NPMPackagesConnection:
+		return KindNPMPackages, nil
+	case *schema.GoModulesConnection:
+		return KindGoModules, nil
 	default:
 		return "", errors.Errorf("unknown external service kind: %s", kind)
 	}
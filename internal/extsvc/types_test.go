@@ -304,6 +304,18 @@ func TestUniqueCodeHostIdentifier(t *testing.T) {
 			want:   "https://bitbucket.org/",
 		},
 
+		{
+			kind:   KindGerrit,
+			config: `{"url": "https://gerrit.sgdev.org"}`,
+			want:   "https://gerrit.sgdev.org/",
+		},
+
+		{
+			kind:   KindGitea,
+			config: `{"url": "https://gitea.sgdev.org", "token": "secret"}`,
+			want:   "https://gitea.sgdev.org/",
+		},
+
 		{
 			kind:   KindGitolite,
 			config: `{"host": "ssh://git@gitolite.example.com:2222/"}`,
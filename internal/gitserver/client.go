@@ -12,6 +12,7 @@ import (
 	"net/url"
 	"os"
 	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
@@ -22,6 +23,7 @@ import (
 	"github.com/inconshreveable/log15"
 	"github.com/neelance/parallel"
 	"github.com/opentracing-contrib/go-stdlib/nethttp"
+	"github.com/opentracing/opentracing-go"
 	"github.com/opentracing/opentracing-go/ext"
 	otlog "github.com/opentracing/opentracing-go/log"
 	"github.com/prometheus/client_golang/prometheus"
@@ -50,8 +52,9 @@ func NewClient(cli httpcli.Doer) *Client {
 		Addrs: func() []string {
 			return conf.Get().ServiceConnections.GitServers
 		},
-		HTTPClient:  cli,
-		HTTPLimiter: parallel.NewRun(500),
+		ReplicationFactor: ReplicationFactor,
+		HTTPClient:        cli,
+		HTTPLimiter:       parallel.NewRun(500),
 		// Use the binary name for UserAgent. This should effectively identify
 		// which service is making the request (excluding requests proxied via the
 		// frontend internal API)
@@ -72,6 +75,12 @@ type Client struct {
 	// concurrent use. It may return different results at different times.
 	Addrs func() []string
 
+	// ReplicationFactor is a function which should return the number of
+	// gitservers each repository is cloned on, see AddrsForRepo. It is called
+	// each time a request is made. If it is nil, repositories aren't
+	// replicated.
+	ReplicationFactor func() int
+
 	// UserAgent is a string identifying who the client is. It will be logged in
 	// the telemetry in gitserver.
 	UserAgent string
@@ -86,6 +95,20 @@ func (c *Client) AddrForRepo(repo api.RepoName) string {
 	return AddrForRepo(repo, addrs)
 }
 
+// AddrsForRepo returns the addresses of the gitservers the given repo is
+// cloned on, starting with its primary one.
+func (c *Client) AddrsForRepo(repo api.RepoName) []string {
+	addrs := c.Addrs()
+	if len(addrs) == 0 {
+		panic("unexpected state: no gitserver addresses")
+	}
+	replicationFactor := 1
+	if c.ReplicationFactor != nil {
+		replicationFactor = c.ReplicationFactor()
+	}
+	return AddrsForRepo(repo, addrs, replicationFactor)
+}
+
 // addrForKey returns the gitserver address to use for the given string key,
 // which is hashed for sharding purposes.
 func (c *Client) addrForKey(key string) string {
@@ -118,6 +141,57 @@ func addrForKey(key string, addrs []string) string {
 	return addrs[serverIndex]
 }
 
+// ReplicationFactor returns the number of gitservers each repository is cloned
+// on, as configured in experimentalFeatures.gitServerReplicationFactor.
+func ReplicationFactor() int {
+	if f := conf.Get().ExperimentalFeatures; f != nil && f.GitServerReplicationFactor > 1 {
+		return f.GitServerReplicationFactor
+	}
+	return 1
+}
+
+// AddrsForRepo returns the addresses of the gitservers the given repo name is
+// cloned on when repositories are replicated on replicationFactor gitservers.
+// It should never be called with an empty slice.
+//
+// The first address is the primary one, which is always AddrForRepo(repo,
+// addrs): turning on replication doesn't move any repository. The other
+// replicas are chosen among the remaining addresses by rendezvous hashing, so
+// that adding or removing a gitserver only moves the replicas it gains or
+// loses.
+func AddrsForRepo(repo api.RepoName, addrs []string, replicationFactor int) []string {
+	repo = protocol.NormalizeRepo(repo)
+	primary := addrForKey(string(repo), addrs)
+	if replicationFactor > len(addrs) {
+		replicationFactor = len(addrs)
+	}
+	if replicationFactor <= 1 {
+		return []string{primary}
+	}
+
+	type candidate struct {
+		addr   string
+		weight uint64
+	}
+	candidates := make([]candidate, 0, len(addrs))
+	for _, addr := range addrs {
+		if addr == primary {
+			continue
+		}
+		sum := md5.Sum([]byte(addr + "\x00" + string(repo)))
+		candidates = append(candidates, candidate{addr: addr, weight: binary.BigEndian.Uint64(sum[:])})
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].weight > candidates[j].weight
+	})
+
+	replicas := []string{primary}
+	for i := 0; i < len(candidates) && len(replicas) < replicationFactor; i++ {
+		replicas = append(replicas, candidates[i].addr)
+	}
+	return replicas
+}
+
 // ArchiveOptions contains options for the Archive func.
 type ArchiveOptions struct {
 	Treeish string   // the tree or commit to produce an archive for
@@ -190,8 +264,9 @@ func (c *Client) Archive(ctx context.Context, repo api.RepoName, opt ArchiveOpti
 		return nil, err
 	}
 
+	// Pass the path rather than the URL to fall back to replicas.
 	u := c.ArchiveURL(repo, opt)
-	resp, err := c.do(ctx, repo, "GET", u.String(), nil)
+	resp, err := c.do(ctx, repo, "GET", "archive?"+u.RawQuery, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -466,6 +541,11 @@ func (c *Client) ListCloned(ctx context.Context) ([]string, error) {
 		repos []string
 	)
 	addrs := c.Addrs()
+	replicationFactor := 1
+	if c.ReplicationFactor != nil {
+		replicationFactor = c.ReplicationFactor()
+	}
+	seen := make(map[string]bool)
 	for _, addr := range addrs {
 		wg.Add(1)
 		go func(addr string) {
@@ -476,8 +556,11 @@ func (c *Client) ListCloned(ctx context.Context) ([]string, error) {
 			if len(r) > 0 {
 				filtered := r[:0]
 				for _, repo := range r {
-					if addrForKey(repo, addrs) == addr {
-						filtered = append(filtered, repo)
+					for _, replica := range AddrsForRepo(api.RepoName(repo), addrs, replicationFactor) {
+						if replica == addr {
+							filtered = append(filtered, repo)
+							break
+						}
 					}
 				}
 				r = filtered
@@ -486,7 +569,13 @@ func (c *Client) ListCloned(ctx context.Context) ([]string, error) {
 			if e != nil {
 				err = e
 			}
-			repos = append(repos, r...)
+			// Replicated repos are listed by each of their replicas.
+			for _, repo := range r {
+				if !seen[repo] {
+					seen[repo] = true
+					repos = append(repos, repo)
+				}
+			}
 			mu.Unlock()
 		}(addr)
 	}
@@ -545,7 +634,33 @@ func (c *Client) RequestRepoUpdate(ctx context.Context, repo api.RepoName, since
 		Repo:  repo,
 		Since: since,
 	}
-	resp, err := c.httpPost(ctx, repo, "repo-update", req)
+
+	// Every replica has to be updated. The update succeeds as long as one of
+	// them is available.
+	var (
+		info     *protocol.RepoUpdateResponse
+		firstErr error
+	)
+	resps, errs := c.httpPostReplicas(ctx, repo, "repo-update", req)
+	for i := range resps {
+		res, err := readRepoUpdateResponse(resps[i], errs[i])
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		if info == nil {
+			info = res
+		}
+	}
+	if info == nil {
+		return nil, firstErr
+	}
+	return info, nil
+}
+
+func readRepoUpdateResponse(resp *http.Response, err error) (*protocol.RepoUpdateResponse, error) {
 	if err != nil {
 		return nil, err
 	}
@@ -619,6 +734,37 @@ func (e *RepoNotCloneableErr) Error() string {
 	return fmt.Sprintf("repo not found (name=%s notfound=%v) because %s", e.repo, e.notFound, e.reason)
 }
 
+// ClonedAddrForRepo returns the address of the first gitserver, in the order
+// of AddrsForRepo, which has cloned the given repo. It returns the primary
+// address of the repo if it isn't replicated or if no gitserver could confirm
+// it has cloned it.
+func (c *Client) ClonedAddrForRepo(ctx context.Context, repo api.RepoName) string {
+	addrs := c.AddrsForRepo(repo)
+	if len(addrs) == 1 {
+		return addrs[0]
+	}
+
+	req := &protocol.IsRepoClonedRequest{
+		Repo: repo,
+	}
+	for i, addr := range addrs {
+		resp, err := c.httpPost(ctx, repo, "http://"+addr+"/is-repo-cloned", req)
+		if err == nil {
+			resp.Body.Close()
+			if resp.StatusCode == http.StatusOK {
+				return addr
+			}
+		}
+		if ctx.Err() != nil {
+			break
+		}
+		if i < len(addrs)-1 {
+			replicaFallbackCounter.Inc()
+		}
+	}
+	return addrs[0]
+}
+
 func (c *Client) IsRepoCloned(ctx context.Context, repo api.RepoName) (bool, error) {
 	req := &protocol.IsRepoClonedRequest{
 		Repo: repo,
@@ -637,52 +783,35 @@ func (c *Client) IsRepoCloned(ctx context.Context, repo api.RepoName) (bool, err
 }
 
 func (c *Client) RepoCloneProgress(ctx context.Context, repos ...api.RepoName) (*protocol.RepoCloneProgressResponse, error) {
-	numPossibleShards := len(c.Addrs())
-	shards := make(map[string]*protocol.RepoCloneProgressRequest, (len(repos)/numPossibleShards)*2) // 2x because it may not be a perfect division
-
-	for _, r := range repos {
-		addr := c.AddrForRepo(r)
-		shard := shards[addr]
-
-		if shard == nil {
-			shard = new(protocol.RepoCloneProgressRequest)
-			shards[addr] = shard
-		}
-
-		shard.Repos = append(shard.Repos, r)
-	}
-
 	type op struct {
-		req *protocol.RepoCloneProgressRequest
-		res *protocol.RepoCloneProgressResponse
-		err error
+		shard *replicaShard
+		res   map[api.RepoName]*protocol.RepoCloneProgress
+		err   error
 	}
 
+	shards := c.shardByReplicas(repos)
 	ch := make(chan op, len(shards))
-	for _, req := range shards {
+	for _, shard := range shards {
 		go func(o op) {
-			var resp *http.Response
-			resp, o.err = c.httpPost(ctx, o.req.Repos[0], "repo-clone-progress", o.req)
-			if o.err != nil {
-				ch <- o
-				return
-			}
-
-			defer resp.Body.Close()
-			if resp.StatusCode != http.StatusOK {
-				o.err = &url.Error{
-					URL: resp.Request.URL.String(),
-					Op:  "RepoCloneProgress",
-					Err: errors.Errorf("RepoCloneProgress: http status %d", resp.StatusCode),
+			o.res = make(map[api.RepoName]*protocol.RepoCloneProgress, len(o.shard.repos))
+			o.err = c.postShard(ctx, o.shard, "repo-clone-progress", func(repos []api.RepoName) interface{} {
+				return &protocol.RepoCloneProgressRequest{Repos: repos}
+			}, func(body io.Reader) ([]api.RepoName, error) {
+				var res protocol.RepoCloneProgressResponse
+				if err := json.NewDecoder(body).Decode(&res); err != nil {
+					return nil, err
 				}
-				ch <- o
-				return // we never get an error status code AND result
-			}
-
-			o.res = new(protocol.RepoCloneProgressResponse)
-			o.err = json.NewDecoder(resp.Body).Decode(o.res)
+				var uncloned []api.RepoName
+				for repo, info := range res.Results {
+					o.res[repo] = info
+					if !info.Cloned {
+						uncloned = append(uncloned, repo)
+					}
+				}
+				return uncloned, nil
+			})
 			ch <- o
-		}(op{req: req})
+		}(op{shard: shard})
 	}
 
 	err := new(multierror.Error)
@@ -695,10 +824,9 @@ func (c *Client) RepoCloneProgress(ctx context.Context, repos ...api.RepoName) (
 
 		if o.err != nil {
 			err = multierror.Append(err, o.err)
-			continue
 		}
 
-		for repo, info := range o.res.Results {
+		for repo, info := range o.res {
 			res.Results[repo] = info
 		}
 	}
@@ -714,52 +842,35 @@ func (c *Client) RepoCloneProgress(ctx context.Context, repos ...api.RepoName) (
 // If multiple errors occurred, an incomplete result is returned along with a
 // *multierror.Error.
 func (c *Client) RepoInfo(ctx context.Context, repos ...api.RepoName) (*protocol.RepoInfoResponse, error) {
-	numPossibleShards := len(c.Addrs())
-	shards := make(map[string]*protocol.RepoInfoRequest, (len(repos)/numPossibleShards)*2) // 2x because it may not be a perfect division
-
-	for _, r := range repos {
-		addr := c.AddrForRepo(r)
-		shard := shards[addr]
-
-		if shard == nil {
-			shard = new(protocol.RepoInfoRequest)
-			shards[addr] = shard
-		}
-
-		shard.Repos = append(shard.Repos, r)
-	}
-
 	type op struct {
-		req *protocol.RepoInfoRequest
-		res *protocol.RepoInfoResponse
-		err error
+		shard *replicaShard
+		res   map[api.RepoName]*protocol.RepoInfo
+		err   error
 	}
 
+	shards := c.shardByReplicas(repos)
 	ch := make(chan op, len(shards))
-	for _, req := range shards {
+	for _, shard := range shards {
 		go func(o op) {
-			var resp *http.Response
-			resp, o.err = c.httpPost(ctx, o.req.Repos[0], "repos", o.req)
-			if o.err != nil {
-				ch <- o
-				return
-			}
-
-			defer resp.Body.Close()
-			if resp.StatusCode != http.StatusOK {
-				o.err = &url.Error{
-					URL: resp.Request.URL.String(),
-					Op:  "RepoInfo",
-					Err: errors.Errorf("RepoInfo: http status %d", resp.StatusCode),
+			o.res = make(map[api.RepoName]*protocol.RepoInfo, len(o.shard.repos))
+			o.err = c.postShard(ctx, o.shard, "repos", func(repos []api.RepoName) interface{} {
+				return &protocol.RepoInfoRequest{Repos: repos}
+			}, func(body io.Reader) ([]api.RepoName, error) {
+				var res protocol.RepoInfoResponse
+				if err := json.NewDecoder(body).Decode(&res); err != nil {
+					return nil, err
 				}
-				ch <- o
-				return // we never get an error status code AND result
-			}
-
-			o.res = new(protocol.RepoInfoResponse)
-			o.err = json.NewDecoder(resp.Body).Decode(o.res)
+				var uncloned []api.RepoName
+				for repo, info := range res.Results {
+					o.res[repo] = info
+					if !info.Cloned {
+						uncloned = append(uncloned, repo)
+					}
+				}
+				return uncloned, nil
+			})
 			ch <- o
-		}(op{req: req})
+		}(op{shard: shard})
 	}
 
 	err := new(multierror.Error)
@@ -772,10 +883,9 @@ func (c *Client) RepoInfo(ctx context.Context, repos ...api.RepoName) (*protocol
 
 		if o.err != nil {
 			err = multierror.Append(err, o.err)
-			continue
 		}
 
-		for repo, info := range o.res.Results {
+		for repo, info := range o.res {
 			res.Results[repo] = info
 		}
 	}
@@ -783,6 +893,82 @@ func (c *Client) RepoInfo(ctx context.Context, repos ...api.RepoName) (*protocol
 	return &res, err.ErrorOrNil()
 }
 
+// replicaShard is a group of repos cloned on the same gitservers.
+type replicaShard struct {
+	addrs []string // see AddrsForRepo
+	repos []api.RepoName
+}
+
+// shardByReplicas groups repos by the gitservers they are cloned on.
+func (c *Client) shardByReplicas(repos []api.RepoName) map[string]*replicaShard {
+	shards := make(map[string]*replicaShard)
+	for _, r := range repos {
+		addrs := c.AddrsForRepo(r)
+		key := strings.Join(addrs, " ")
+		shard := shards[key]
+
+		if shard == nil {
+			shard = &replicaShard{addrs: addrs}
+			shards[key] = shard
+		}
+
+		shard.repos = append(shard.repos, r)
+	}
+	return shards
+}
+
+// postShard posts the request built by newReq for the repos of shard to op on
+// its primary gitserver, falling back to the next replica like do if a
+// gitserver is unavailable or fails. The repos a gitserver reports as not
+// cloned are asked to the next replica. decode decodes a successful response
+// and returns those repos.
+//
+// An error is only returned if no gitserver answered.
+func (c *Client) postShard(ctx context.Context, shard *replicaShard, op string, newReq func([]api.RepoName) interface{}, decode func(io.Reader) ([]api.RepoName, error)) error {
+	repos := shard.repos
+	var answered bool
+	var err error
+	for i, addr := range shard.addrs {
+		if i > 0 {
+			replicaFallbackCounter.Inc()
+		}
+
+		var uncloned []api.RepoName
+		uncloned, err = c.postShardTo(ctx, addr, op, newReq(repos), decode)
+		if err == nil {
+			answered = true
+			repos = uncloned
+			if len(repos) == 0 {
+				return nil
+			}
+		} else if ctx.Err() != nil {
+			break
+		}
+	}
+	if answered {
+		return nil
+	}
+	return err
+}
+
+func (c *Client) postShardTo(ctx context.Context, addr, op string, payload interface{}, decode func(io.Reader) ([]api.RepoName, error)) ([]api.RepoName, error) {
+	uri := "http://" + addr + "/" + op
+	resp, err := c.httpPost(ctx, "", uri, payload)
+	if err != nil {
+		return nil, err
+	}
+
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, &url.Error{
+			URL: uri,
+			Op:  op,
+			Err: errors.Errorf("%s: http status %d", op, resp.StatusCode),
+		}
+	}
+	return decode(resp.Body)
+}
+
 // ReposStats will return a map of the ReposStats for each gitserver in a
 // map. If we fail to fetch a stat from a gitserver, it won't be in the
 // returned map and will be appended to the error. If no errors occur err will
@@ -825,22 +1011,51 @@ func (c *Client) doReposStats(ctx context.Context, addr string) (*protocol.Repos
 	return &stats, nil
 }
 
-// Remove removes the repository clone from gitserver.
+// Remove removes the repository clone from gitserver, including all its
+// replicas.
 func (c *Client) Remove(ctx context.Context, repo api.RepoName) error {
 	req := &protocol.RepoDeleteRequest{
 		Repo: repo,
 	}
-	resp, err := c.httpPost(ctx, repo, "delete", req)
-	if err != nil {
-		return err
+
+	var errs *multierror.Error
+	resps, respErrs := c.httpPostReplicas(ctx, repo, "delete", req)
+	for i, resp := range resps {
+		if respErrs[i] != nil {
+			errs = multierror.Append(errs, respErrs[i])
+			continue
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			// best-effort inclusion of body in error message
+			body, _ := io.ReadAll(io.LimitReader(resp.Body, 200))
+			errs = multierror.Append(errs, &url.Error{URL: resp.Request.URL.String(), Op: "RepoRemove", Err: errors.Errorf("RepoRemove: http status %d: %s", resp.StatusCode, string(body))})
+		}
 	}
-	defer resp.Body.Close()
-	if resp.StatusCode != http.StatusOK {
-		// best-effort inclusion of body in error message
-		body, _ := io.ReadAll(io.LimitReader(resp.Body, 200))
-		return &url.Error{URL: resp.Request.URL.String(), Op: "RepoRemove", Err: errors.Errorf("RepoRemove: http status %d: %s", resp.StatusCode, string(body))}
+	if errs.Len() == 1 {
+		return errs.Errors[0]
 	}
-	return nil
+	return errs.ErrorOrNil()
+}
+
+// httpPostReplicas posts the request to every gitserver the repo is cloned
+// on, concurrently. The responses and errors are in the order of
+// AddrsForRepo.
+func (c *Client) httpPostReplicas(ctx context.Context, repo api.RepoName, op string, payload interface{}) ([]*http.Response, []error) {
+	addrs := c.AddrsForRepo(repo)
+	resps := make([]*http.Response, len(addrs))
+	errs := make([]error, len(addrs))
+
+	var wg sync.WaitGroup
+	for i, addr := range addrs {
+		wg.Add(1)
+		go func(i int, addr string) {
+			defer wg.Done()
+			resps[i], errs[i] = c.httpPost(ctx, repo, "http://"+addr+"/"+op, payload)
+		}(i, addr)
+	}
+	wg.Wait()
+	return resps, errs
 }
 
 func (c *Client) httpPost(ctx context.Context, repo api.RepoName, op string, payload interface{}) (resp *http.Response, err error) {
@@ -849,6 +1064,10 @@ func (c *Client) httpPost(ctx context.Context, repo api.RepoName, op string, pay
 
 // do performs a request to a gitserver, sharding based on the given
 // repo name (the repo name is otherwise not used).
+//
+// When op is relative and the repo is replicated, the request falls back to
+// the next replica if a gitserver is unavailable, fails or doesn't have the
+// repo. The response of the last replica tried is returned.
 func (c *Client) do(ctx context.Context, repo api.RepoName, method, op string, payload interface{}) (resp *http.Response, err error) {
 	span, ctx := ot.StartSpanFromContext(ctx, "Client.do")
 	defer func() {
@@ -865,11 +1084,28 @@ func (c *Client) do(ctx context.Context, repo api.RepoName, method, op string, p
 		return nil, err
 	}
 
-	uri := op
-	if !strings.HasPrefix(op, "http") {
-		uri = "http://" + c.AddrForRepo(repo) + "/" + op
+	if strings.HasPrefix(op, "http") {
+		return c.doURI(ctx, span, method, op, reqBody)
 	}
 
+	addrs := c.AddrsForRepo(repo)
+	for i, addr := range addrs {
+		resp, err = c.doURI(ctx, span, method, "http://"+addr+"/"+op, reqBody)
+		if i == len(addrs)-1 || ctx.Err() != nil || !shouldTryReplica(resp, err) {
+			break
+		}
+
+		if resp != nil {
+			resp.Body.Close()
+		}
+		replicaFallbackCounter.Inc()
+		span.LogKV("event", "falling back to replica", "addr", addr, "next", addrs[i+1])
+	}
+	return resp, err
+}
+
+// doURI performs a single request to the given gitserver URI.
+func (c *Client) doURI(ctx context.Context, span opentracing.Span, method, uri string, reqBody []byte) (*http.Response, error) {
 	req, err := http.NewRequest(method, uri, bytes.NewReader(reqBody))
 	if err != nil {
 		return nil, err
@@ -894,6 +1130,21 @@ func (c *Client) do(ctx context.Context, repo api.RepoName, method, op string, p
 	return c.HTTPClient.Do(req)
 }
 
+var replicaFallbackCounter = promauto.NewCounter(prometheus.CounterOpts{
+	Name: "src_gitserver_client_replica_fallback_total",
+	Help: "Number of gitserver requests retried on another replica of the repository",
+})
+
+// shouldTryReplica reports whether a request should be retried on another
+// replica given the outcome of the request to the current one: the gitserver
+// is unreachable, fails, or doesn't have the repository (yet).
+func shouldTryReplica(resp *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+	return resp.StatusCode == http.StatusNotFound || resp.StatusCode >= http.StatusInternalServerError
+}
+
 func userFromContext(ctx context.Context) string {
 	a := actor.FromContext(ctx)
 	if a == nil {
@@ -5,6 +5,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/base64"
+	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
@@ -14,6 +15,7 @@ import (
 	"os/exec"
 	"path/filepath"
 	"sort"
+	"strings"
 	"testing"
 
 	"github.com/cockroachdb/errors"
@@ -23,6 +25,7 @@ import (
 	"github.com/sourcegraph/sourcegraph/cmd/gitserver/server"
 	"github.com/sourcegraph/sourcegraph/internal/api"
 	"github.com/sourcegraph/sourcegraph/internal/gitserver"
+	"github.com/sourcegraph/sourcegraph/internal/gitserver/protocol"
 	"github.com/sourcegraph/sourcegraph/internal/httpcli"
 )
 
@@ -287,6 +290,216 @@ func TestAddrForRepo(t *testing.T) {
 	}
 }
 
+func TestAddrsForRepo(t *testing.T) {
+	addrs := []string{"gitserver-1", "gitserver-2", "gitserver-3", "gitserver-4"}
+
+	for _, repo := range []api.RepoName{"repo1", "github.com/sourcegraph/sourcegraph", "gitlab.com/foo/bar"} {
+		if got := gitserver.AddrsForRepo(repo, addrs, 1); !cmp.Equal(got, []string{gitserver.AddrForRepo(repo, addrs)}) {
+			t.Errorf("%s: unexpected addrs without replication: %v", repo, got)
+		}
+
+		replicas := gitserver.AddrsForRepo(repo, addrs, 3)
+		if len(replicas) != 3 {
+			t.Fatalf("%s: want 3 replicas, got %v", repo, replicas)
+		}
+		// Turning on replication doesn't move the repo.
+		if replicas[0] != gitserver.AddrForRepo(repo, addrs) {
+			t.Errorf("%s: primary %q isn't AddrForRepo", repo, replicas[0])
+		}
+		seen := map[string]bool{}
+		for _, addr := range replicas {
+			if seen[addr] {
+				t.Errorf("%s: duplicate replica %q in %v", repo, addr, replicas)
+			}
+			seen[addr] = true
+		}
+
+		// Removing a gitserver other than the primary only replaces the
+		// replica it held.
+		var removed string
+		var remaining []string
+		for _, addr := range addrs {
+			if removed == "" && addr != replicas[0] {
+				removed = addr
+				continue
+			}
+			remaining = append(remaining, addr)
+		}
+		if gitserver.AddrForRepo(repo, remaining) != replicas[0] {
+			// The primary moved with the removal, nothing to check.
+			continue
+		}
+		for _, addr := range gitserver.AddrsForRepo(repo, remaining, 3) {
+			delete(seen, addr)
+		}
+		if len(seen) > 1 || (len(seen) == 1 && !seen[removed]) {
+			t.Errorf("%s: unexpected replicas moved after removing %q: %v", repo, removed, seen)
+		}
+	}
+
+	if got := gitserver.AddrsForRepo("repo1", addrs[:2], 3); len(got) != 2 {
+		t.Errorf("replication factor isn't capped by the number of gitservers: %v", got)
+	}
+}
+
+func TestClient_ReplicaFallback(t *testing.T) {
+	addrs := []string{"gitserver-0", "gitserver-1", "gitserver-2"}
+	repo := api.RepoName("github.com/sourcegraph/sourcegraph")
+	replicas := gitserver.AddrsForRepo(repo, addrs, 2)
+
+	var requested []string
+	cli := &gitserver.Client{
+		Addrs:             func() []string { return addrs },
+		ReplicationFactor: func() int { return 2 },
+		HTTPClient: httpcli.DoerFunc(func(r *http.Request) (*http.Response, error) {
+			requested = append(requested, r.URL.Host)
+			if r.URL.Host == replicas[0] {
+				return nil, errors.New("connection refused")
+			}
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       io.NopCloser(bytes.NewBufferString("")),
+			}, nil
+		}),
+	}
+
+	cloned, err := cli.IsRepoCloned(context.Background(), repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !cloned {
+		t.Error("expected the replica to report the repo as cloned")
+	}
+	if !cmp.Equal(requested, replicas) {
+		t.Errorf("unexpected gitservers requested: want %v, got %v", replicas, requested)
+	}
+}
+
+func TestClient_RepoInfoReplicaFallback(t *testing.T) {
+	addrs := []string{"gitserver-0", "gitserver-1", "gitserver-2"}
+	repo := api.RepoName("github.com/sourcegraph/sourcegraph")
+	replicas := gitserver.AddrsForRepo(repo, addrs, 2)
+
+	for _, tc := range []struct {
+		name    string
+		primary func() (*http.Response, error)
+	}{{
+		name: "unavailable",
+		primary: func() (*http.Response, error) {
+			return nil, errors.New("connection refused")
+		},
+	}, {
+		name: "not cloned",
+		primary: func() (*http.Response, error) {
+			return jsonResponse(t, &protocol.RepoInfoResponse{
+				Results: map[api.RepoName]*protocol.RepoInfo{repo: {CloneInProgress: true}},
+			}), nil
+		},
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			var requested []string
+			cli := &gitserver.Client{
+				Addrs:             func() []string { return addrs },
+				ReplicationFactor: func() int { return 2 },
+				HTTPClient: httpcli.DoerFunc(func(r *http.Request) (*http.Response, error) {
+					requested = append(requested, r.URL.Host)
+					if r.URL.Host == replicas[0] {
+						return tc.primary()
+					}
+					return jsonResponse(t, &protocol.RepoInfoResponse{
+						Results: map[api.RepoName]*protocol.RepoInfo{repo: {Cloned: true}},
+					}), nil
+				}),
+			}
+
+			res, err := cli.RepoInfo(context.Background(), repo)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if info := res.Results[repo]; info == nil || !info.Cloned {
+				t.Errorf("expected the replica to report the repo as cloned, got %+v", info)
+			}
+			if !cmp.Equal(requested, replicas) {
+				t.Errorf("unexpected gitservers requested: want %v, got %v", replicas, requested)
+			}
+		})
+	}
+}
+
+func TestClient_ClonedAddrForRepo(t *testing.T) {
+	addrs := []string{"gitserver-0", "gitserver-1", "gitserver-2"}
+	repo := api.RepoName("github.com/sourcegraph/sourcegraph")
+	replicas := gitserver.AddrsForRepo(repo, addrs, 2)
+
+	cli := &gitserver.Client{
+		Addrs:             func() []string { return addrs },
+		ReplicationFactor: func() int { return 2 },
+		HTTPClient: httpcli.DoerFunc(func(r *http.Request) (*http.Response, error) {
+			status := http.StatusNotFound
+			if r.URL.Host == replicas[1] {
+				status = http.StatusOK
+			}
+			return &http.Response{
+				StatusCode: status,
+				Body:       io.NopCloser(bytes.NewBufferString("")),
+			}, nil
+		}),
+	}
+
+	if got := cli.ClonedAddrForRepo(context.Background(), repo); got != replicas[1] {
+		t.Errorf("got %q, want the replica %q", got, replicas[1])
+	}
+}
+
+func TestReverseProxy_ReplicaFallback(t *testing.T) {
+	unavailable := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer unavailable.Close()
+	echo := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.Copy(w, r.Body)
+	}))
+	defer echo.Close()
+
+	addrs := []string{
+		strings.TrimPrefix(unavailable.URL, "http://"),
+		strings.TrimPrefix(echo.URL, "http://"),
+	}
+	body := []byte(`{"args":["log"]}`)
+	director := func(req *http.Request) {
+		req.URL.Scheme = "http"
+		req.URL.Path = "/exec"
+		req.Body = io.NopCloser(bytes.NewReader(body))
+		req.GetBody = func() (io.ReadCloser, error) {
+			return io.NopCloser(bytes.NewReader(body)), nil
+		}
+		req.ContentLength = int64(len(body))
+	}
+
+	proxy := gitserver.NewReverseProxy(http.DefaultTransport, nil)
+	w := httptest.NewRecorder()
+	proxy.ServeHTTP("github.com/sourcegraph/sourcegraph", "POST", "exec", addrs, director, w, httptest.NewRequest("POST", "/git/exec", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("got status %d, want the response of the replica", w.Code)
+	}
+	if got := w.Body.String(); got != string(body) {
+		t.Errorf("got body %q, want %q", got, body)
+	}
+}
+
+func jsonResponse(t *testing.T, v interface{}) *http.Response {
+	t.Helper()
+	b, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Body:       io.NopCloser(bytes.NewReader(b)),
+	}
+}
+
 func TestClient_P4Exec(t *testing.T) {
 	root, err := os.MkdirTemp("", t.Name())
 	if err != nil {
@@ -34,9 +34,11 @@ type ReverseProxy struct {
 }
 
 // ServeHTTP creates a one-shot proxy with the given director and proxies the given request
-// to gitserver. The director must rewrite the request to the correct gitserver address, which
-// should be obtained via a gitserver client's AddrForRepo method.
-func (p *ReverseProxy) ServeHTTP(repo api.RepoName, method, op string, director func(req *http.Request), res http.ResponseWriter, req *http.Request) {
+// to gitserver. The request is sent to the first of addrs, which should be obtained via a
+// gitserver client's AddrsForRepo method, and falls back to the next one like the client
+// does if a gitserver is unavailable, fails or doesn't have the repository. The director
+// must rewrite the request but not its host, and set its GetBody if it sets its body.
+func (p *ReverseProxy) ServeHTTP(repo api.RepoName, method, op string, addrs []string, director func(req *http.Request), res http.ResponseWriter, req *http.Request) {
 	span, _ := ot.StartSpanFromContext(req.Context(), "ReverseProxy.ServeHTTP")
 	defer func() {
 		span.LogKV("repo", string(repo), "method", method, "op", op)
@@ -49,10 +51,46 @@ func (p *ReverseProxy) ServeHTTP(repo api.RepoName, method, op string, director
 		span.LogKV("event", "Acquired HTTP limiter")
 	}
 
+	transport := p.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+
 	proxy := &httputil.ReverseProxy{
 		Director:  director,
-		Transport: p.Transport,
+		Transport: &replicaTransport{transport: transport, addrs: addrs},
 	}
 
 	proxy.ServeHTTP(res, req)
 }
+
+// replicaTransport sends a request to the first of addrs and falls back to the
+// next one like Client.do.
+type replicaTransport struct {
+	transport http.RoundTripper
+	addrs     []string
+}
+
+func (t *replicaTransport) RoundTrip(req *http.Request) (resp *http.Response, err error) {
+	for i, addr := range t.addrs {
+		r := req.Clone(req.Context())
+		r.URL.Host = addr
+		if i > 0 && req.GetBody != nil {
+			if r.Body, err = req.GetBody(); err != nil {
+				return nil, err
+			}
+		}
+
+		resp, err = t.transport.RoundTrip(r)
+		replayable := req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+		if i == len(t.addrs)-1 || !replayable || req.Context().Err() != nil || !shouldTryReplica(resp, err) {
+			break
+		}
+
+		if resp != nil {
+			resp.Body.Close()
+		}
+		replicaFallbackCounter.Inc()
+	}
+	return resp, err
+}
@@ -0,0 +1,18 @@
+package httpserver
+
+import (
+	"net"
+	"net/http"
+)
+
+// RemoteIP returns the IP address of the peer that sent r.
+//
+// 🚨 SECURITY: Headers such as X-Forwarded-For are set by clients and may be
+// spoofed, so they are ignored. Behind a load balancer, RemoteIP returns the
+// address of the load balancer.
+func RemoteIP(r *http.Request) string {
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		return host
+	}
+	return r.RemoteAddr
+}
@@ -0,0 +1,28 @@
+package httpserver
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRemoteIP(t *testing.T) {
+	for _, tc := range []struct {
+		remoteAddr   string
+		forwardedFor string
+		want         string
+	}{
+		{remoteAddr: "203.0.113.10:4321", want: "203.0.113.10"},
+		{remoteAddr: "[2001:db8::1]:4321", want: "2001:db8::1"},
+		{remoteAddr: "203.0.113.10", want: "203.0.113.10"},
+		{remoteAddr: "203.0.113.10:4321", forwardedFor: "198.51.100.1", want: "203.0.113.10"},
+	} {
+		r := httptest.NewRequest("GET", "/", nil)
+		r.RemoteAddr = tc.remoteAddr
+		if tc.forwardedFor != "" {
+			r.Header.Set("X-Forwarded-For", tc.forwardedFor)
+		}
+		if got := RemoteIP(r); got != tc.want {
+			t.Errorf("RemoteIP(RemoteAddr=%q, X-Forwarded-For=%q) = %q, want %q", tc.remoteAddr, tc.forwardedFor, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,222 @@
+package repos
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/cockroachdb/errors"
+
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/conf/reposource"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/gerrit"
+	"github.com/sourcegraph/sourcegraph/internal/httpcli"
+	"github.com/sourcegraph/sourcegraph/internal/jsonc"
+	"github.com/sourcegraph/sourcegraph/internal/types"
+	"github.com/sourcegraph/sourcegraph/schema"
+)
+
+// gerritPageSize is the number of projects requested per page when listing
+// the projects matching a query.
+const gerritPageSize = 100
+
+// A GerritSource yields repositories from a single Gerrit connection configured
+// in Sourcegraph via the external services configuration.
+type GerritSource struct {
+	svc     *types.ExternalService
+	config  *schema.GerritConnection
+	baseURL *url.URL
+	exclude excludeFunc
+	client  *gerrit.Client
+}
+
+// NewGerritSource returns a new GerritSource from the given external service.
+func NewGerritSource(svc *types.ExternalService, cf *httpcli.Factory) (*GerritSource, error) {
+	var c schema.GerritConnection
+	if err := jsonc.Unmarshal(svc.Config, &c); err != nil {
+		return nil, errors.Errorf("external service id=%d config error: %s", svc.ID, err)
+	}
+	return newGerritSource(svc, &c, cf)
+}
+
+func newGerritSource(svc *types.ExternalService, c *schema.GerritConnection, cf *httpcli.Factory) (*GerritSource, error) {
+	baseURL, err := url.Parse(c.Url)
+	if err != nil {
+		return nil, err
+	}
+	baseURL = extsvc.NormalizeBaseURL(baseURL)
+
+	if cf == nil {
+		cf = httpcli.ExternalClientFactory
+	}
+
+	cli, err := cf.Doer()
+	if err != nil {
+		return nil, err
+	}
+
+	var eb excludeBuilder
+	for _, r := range c.Exclude {
+		eb.Exact(r.Name)
+		eb.Pattern(r.Pattern)
+	}
+	exclude, err := eb.Build()
+	if err != nil {
+		return nil, err
+	}
+
+	client := gerrit.NewClient(baseURL, cli)
+	client.Username = c.Username
+	client.Password = c.Password
+
+	return &GerritSource{
+		svc:     svc,
+		config:  c,
+		baseURL: baseURL,
+		exclude: exclude,
+		client:  client,
+	}, nil
+}
+
+// ListRepos returns all Gerrit projects configured with this GerritSource's
+// config.
+func (s *GerritSource) ListRepos(ctx context.Context, results chan SourceResult) {
+	seen := make(map[string]bool)
+	send := func(p *gerrit.Project) {
+		if seen[p.Name] || s.excludes(p) {
+			return
+		}
+		seen[p.Name] = true
+		results <- SourceResult{Source: s, Repo: s.makeRepo(p)}
+	}
+
+	for _, name := range s.config.Projects {
+		p, err := s.client.GetProject(ctx, name)
+		if err != nil {
+			results <- SourceResult{Source: s, Err: errors.Wrapf(err, "gerrit.projects: item=%q", name)}
+			continue
+		}
+		send(p)
+	}
+
+	for _, query := range s.projectQueries() {
+		for skip := 0; ; {
+			projects, more, err := s.client.ListProjects(ctx, gerrit.ListProjectsArgs{
+				Query: query,
+				Skip:  skip,
+				Limit: gerritPageSize,
+			})
+			if err != nil {
+				results <- SourceResult{Source: s, Err: errors.Wrapf(err, "gerrit.projectQuery: item=%q, skip=%d", query, skip)}
+				break
+			}
+			for _, p := range projects {
+				send(p)
+			}
+			if !more || len(projects) == 0 {
+				break
+			}
+			skip += len(projects)
+		}
+	}
+}
+
+// projectQueries returns the configured project queries. If neither projects
+// nor queries are configured, all active projects are listed.
+func (s *GerritSource) projectQueries() []string {
+	if len(s.config.ProjectQuery) == 0 {
+		if len(s.config.Projects) == 0 {
+			return []string{"state:active"}
+		}
+		return nil
+	}
+
+	queries := make([]string, 0, len(s.config.ProjectQuery))
+	for _, q := range s.config.ProjectQuery {
+		if q != "none" {
+			queries = append(queries, q)
+		}
+	}
+	return queries
+}
+
+// ExternalServices returns a singleton slice containing the external service.
+func (s *GerritSource) ExternalServices() types.ExternalServices {
+	return types.ExternalServices{s.svc}
+}
+
+func (s *GerritSource) excludes(p *gerrit.Project) bool {
+	return s.exclude(p.Name)
+}
+
+func (s *GerritSource) makeRepo(p *gerrit.Project) *types.Repo {
+	// Project IDs are the URL-encoded project names, and are therefore as
+	// stable as the names themselves, since Gerrit doesn't support renaming
+	// projects.
+	id := p.ID
+	if id == "" {
+		id = url.PathEscape(p.Name)
+	}
+
+	urn := s.svc.URN()
+	return &types.Repo{
+		Name: reposource.GerritRepoName(
+			s.config.RepositoryPathPattern,
+			s.baseURL.Hostname(),
+			p.Name,
+		),
+		URI: string(reposource.GerritRepoName(
+			"",
+			s.baseURL.Hostname(),
+			p.Name,
+		)),
+		ExternalRepo: api.ExternalRepoSpec{
+			ID:          id,
+			ServiceType: extsvc.TypeGerrit,
+			ServiceID:   s.baseURL.String(),
+		},
+		Description: p.Description,
+		Archived:    p.State == "READ_ONLY",
+		Sources: map[string]*types.SourceInfo{
+			urn: {
+				ID:       urn,
+				CloneURL: gerritRemoteURL(s.baseURL, s.config, p.Name),
+			},
+		},
+		Metadata: p,
+	}
+}
+
+// gerritRemoteURL returns the Git remote URL of the Gerrit project with the
+// given name, without credentials.
+//
+// if you need to get an authenticated clone url use repos.CloneURL
+func gerritRemoteURL(baseURL *url.URL, cfg *schema.GerritConnection, name string) string {
+	if cfg.GitURLType == "ssh" {
+		port := cfg.SshPort
+		if port == 0 {
+			port = 29418
+		}
+		u := url.URL{
+			Scheme: "ssh",
+			Host:   fmt.Sprintf("%s:%d", baseURL.Hostname(), port),
+			Path:   "/" + name,
+		}
+		if cfg.Username != "" {
+			u.User = url.User(cfg.Username)
+		}
+		return u.String()
+	}
+
+	u := *baseURL
+	u.Path = strings.TrimSuffix(u.Path, "/") + "/"
+	if cfg.Username != "" {
+		// Authenticated Git requests are served under the /a/ prefix, like
+		// authenticated REST API requests.
+		u.Path += "a/"
+	}
+	u.Path += name
+	return u.String()
+}
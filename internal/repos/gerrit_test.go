@@ -0,0 +1,175 @@
+package repos
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/sourcegraph/sourcegraph/internal/extsvc"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/gerrit"
+	"github.com/sourcegraph/sourcegraph/internal/testutil"
+	"github.com/sourcegraph/sourcegraph/internal/types"
+	"github.com/sourcegraph/sourcegraph/schema"
+)
+
+func TestGerritSource_ListRepos(t *testing.T) {
+	assertAllReposListed := func(want []string) types.ReposAssertion {
+		return func(t testing.TB, rs types.Repos) {
+			t.Helper()
+
+			have := rs.Names()
+			sort.Strings(have)
+			sort.Strings(want)
+
+			if diff := cmp.Diff(want, have); diff != "" {
+				t.Errorf("Mismatch (-want +got):\n%s", diff)
+			}
+		}
+	}
+
+	testCases := []struct {
+		name   string
+		assert types.ReposAssertion
+		conf   *schema.GerritConnection
+		err    string
+	}{
+		{
+			name: "all active projects",
+			assert: assertAllReposListed([]string{
+				"gerrit.sgdev.org/All-Projects",
+				"gerrit.sgdev.org/All-Users",
+				"gerrit.sgdev.org/device/generic",
+				"gerrit.sgdev.org/platform/build",
+				"gerrit.sgdev.org/platform/sdk",
+				"gerrit.sgdev.org/tools/repo",
+			}),
+			conf: &schema.GerritConnection{
+				Url:      "https://gerrit.sgdev.org",
+				Username: gerrit.GetenvTestGerritUsername(),
+				Password: os.Getenv("GERRIT_PASSWORD"),
+			},
+			err: "<nil>",
+		},
+		{
+			name: "projects and queries",
+			assert: assertAllReposListed([]string{
+				"gerrit.sgdev.org/platform/build",
+				"gerrit.sgdev.org/tools/repo",
+			}),
+			conf: &schema.GerritConnection{
+				Url:          "https://gerrit.sgdev.org",
+				Username:     gerrit.GetenvTestGerritUsername(),
+				Password:     os.Getenv("GERRIT_PASSWORD"),
+				Projects:     []string{"tools/repo", "platform/build"},
+				ProjectQuery: []string{"inname:platform"},
+				Exclude: []*schema.ExcludedGerritProject{
+					{Pattern: "sdk$"},
+				},
+			},
+			err: "<nil>",
+		},
+		{
+			name: "only projects",
+			assert: assertAllReposListed([]string{
+				"gerrit.sgdev.org/tools/repo",
+			}),
+			conf: &schema.GerritConnection{
+				Url:          "https://gerrit.sgdev.org",
+				Username:     gerrit.GetenvTestGerritUsername(),
+				Password:     os.Getenv("GERRIT_PASSWORD"),
+				Projects:     []string{"tools/repo"},
+				ProjectQuery: []string{"none"},
+			},
+			err: "<nil>",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		tc.name = "GERRIT-LIST-REPOS/" + tc.name
+		t.Run(tc.name, func(t *testing.T) {
+			cf, save := newClientFactory(t, tc.name)
+			defer save(t)
+
+			svc := &types.ExternalService{
+				Kind:   extsvc.KindGerrit,
+				Config: marshalJSON(t, tc.conf),
+			}
+
+			src, err := newGerritSource(svc, tc.conf, cf)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			repos, err := listAll(context.Background(), src)
+			if have, want := fmt.Sprint(err), tc.err; have != want {
+				t.Errorf("error:\nhave: %q\nwant: %q", have, want)
+			}
+
+			if tc.assert != nil {
+				tc.assert(t, repos)
+			}
+		})
+	}
+}
+
+func TestGerritSource_makeRepo(t *testing.T) {
+	projects := []*gerrit.Project{
+		{ID: "platform%2Fbuild", Name: "platform/build", Description: "The build system", State: "ACTIVE"},
+		{ID: "platform%2Fold", Name: "platform/old", State: "READ_ONLY"},
+	}
+
+	svc := types.ExternalService{ID: 1, Kind: extsvc.KindGerrit}
+
+	tests := []struct {
+		name   string
+		schema *schema.GerritConnection
+	}{
+		{
+			name: "simple",
+			schema: &schema.GerritConnection{
+				Url:      "https://gerrit.sgdev.org",
+				Username: "alice",
+				Password: "secret",
+			},
+		}, {
+			name: "anonymous",
+			schema: &schema.GerritConnection{
+				Url: "https://gerrit.sgdev.org/r/",
+			},
+		}, {
+			name: "ssh",
+			schema: &schema.GerritConnection{
+				Url:        "https://gerrit.sgdev.org",
+				Username:   "alice",
+				GitURLType: "ssh",
+			},
+		}, {
+			name: "path-pattern",
+			schema: &schema.GerritConnection{
+				Url:                   "https://gerrit.sgdev.org",
+				RepositoryPathPattern: "gerrit/{name}",
+			},
+		},
+	}
+	for _, test := range tests {
+		test.name = "GerritSource_makeRepo_" + test.name
+		t.Run(test.name, func(t *testing.T) {
+			s, err := newGerritSource(&svc, test.schema, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var got []*types.Repo
+			for _, p := range projects {
+				got = append(got, s.makeRepo(p))
+			}
+
+			testutil.AssertGolden(t, "testdata/golden/"+test.name, update(test.name), got)
+		})
+	}
+}
@@ -0,0 +1,231 @@
+package repos
+
+import (
+	"context"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/cockroachdb/errors"
+
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/conf/reposource"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/auth"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/gitea"
+	"github.com/sourcegraph/sourcegraph/internal/httpcli"
+	"github.com/sourcegraph/sourcegraph/internal/jsonc"
+	"github.com/sourcegraph/sourcegraph/internal/types"
+	"github.com/sourcegraph/sourcegraph/schema"
+)
+
+// giteaPageSize is the number of repositories requested per page. It matches
+// Gitea's default MAX_RESPONSE_ITEMS, above which Gitea silently caps pages.
+const giteaPageSize = 50
+
+// A GiteaSource yields repositories from a single Gitea (or Forgejo)
+// connection configured in Sourcegraph via the external services
+// configuration.
+type GiteaSource struct {
+	svc             *types.ExternalService
+	config          *schema.GiteaConnection
+	baseURL         *url.URL
+	exclude         excludeFunc
+	excludeArchived bool
+	excludeForks    bool
+	client          *gitea.Client
+}
+
+// NewGiteaSource returns a new GiteaSource from the given external service.
+func NewGiteaSource(svc *types.ExternalService, cf *httpcli.Factory) (*GiteaSource, error) {
+	var c schema.GiteaConnection
+	if err := jsonc.Unmarshal(svc.Config, &c); err != nil {
+		return nil, errors.Errorf("external service id=%d config error: %s", svc.ID, err)
+	}
+	return newGiteaSource(svc, &c, cf)
+}
+
+func newGiteaSource(svc *types.ExternalService, c *schema.GiteaConnection, cf *httpcli.Factory) (*GiteaSource, error) {
+	baseURL, err := url.Parse(c.Url)
+	if err != nil {
+		return nil, err
+	}
+	baseURL = extsvc.NormalizeBaseURL(baseURL)
+
+	if cf == nil {
+		cf = httpcli.ExternalClientFactory
+	}
+
+	cli, err := cf.Doer()
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		eb              excludeBuilder
+		excludeArchived bool
+		excludeForks    bool
+	)
+
+	for _, r := range c.Exclude {
+		eb.Exact(r.Name)
+		if r.Id != 0 {
+			eb.Exact(strconv.Itoa(r.Id))
+		}
+		eb.Pattern(r.Pattern)
+
+		if r.Archived {
+			excludeArchived = true
+		}
+
+		if r.Forks {
+			excludeForks = true
+		}
+	}
+
+	exclude, err := eb.Build()
+	if err != nil {
+		return nil, err
+	}
+
+	return &GiteaSource{
+		svc:             svc,
+		config:          c,
+		baseURL:         baseURL,
+		exclude:         exclude,
+		excludeArchived: excludeArchived,
+		excludeForks:    excludeForks,
+		client:          gitea.NewClient(baseURL, &auth.OAuthBearerToken{Token: c.Token}, cli),
+	}, nil
+}
+
+// ListRepos returns all Gitea repositories configured with this GiteaSource's
+// config.
+func (s *GiteaSource) ListRepos(ctx context.Context, results chan SourceResult) {
+	seen := make(map[int64]bool)
+	send := func(r *gitea.Repository) {
+		if seen[r.ID] || s.excludes(r) {
+			return
+		}
+		seen[r.ID] = true
+		results <- SourceResult{Source: s, Repo: s.makeRepo(r)}
+	}
+
+	for _, nameWithOwner := range s.config.Repos {
+		owner, name, err := splitGiteaNameWithOwner(nameWithOwner)
+		if err != nil {
+			results <- SourceResult{Source: s, Err: errors.Wrapf(err, "gitea.repos: item=%q", nameWithOwner)}
+			continue
+		}
+		r, err := s.client.GetRepo(ctx, owner, name)
+		if err != nil {
+			results <- SourceResult{Source: s, Err: errors.Wrapf(err, "gitea.repos: item=%q", nameWithOwner)}
+			continue
+		}
+		send(r)
+	}
+
+	for _, org := range s.config.Orgs {
+		s.listPages(ctx, results, "gitea.orgs", org, s.client.ListOrgRepos, send)
+	}
+
+	for _, user := range s.config.Users {
+		s.listPages(ctx, results, "gitea.users", user, s.client.ListUserRepos, send)
+	}
+}
+
+// listPages sends all repositories returned by the paginated list function
+// for the given owner.
+func (s *GiteaSource) listPages(
+	ctx context.Context,
+	results chan SourceResult,
+	field, owner string,
+	list func(context.Context, string, gitea.ListReposArgs) ([]*gitea.Repository, bool, error),
+	send func(*gitea.Repository),
+) {
+	for page := 1; ; page++ {
+		repos, more, err := list(ctx, owner, gitea.ListReposArgs{Page: page, Limit: giteaPageSize})
+		if err != nil {
+			results <- SourceResult{Source: s, Err: errors.Wrapf(err, "%s: item=%q, page=%d", field, owner, page)}
+			return
+		}
+		for _, r := range repos {
+			send(r)
+		}
+		if !more || len(repos) == 0 {
+			return
+		}
+	}
+}
+
+// ExternalServices returns a singleton slice containing the external service.
+func (s *GiteaSource) ExternalServices() types.ExternalServices {
+	return types.ExternalServices{s.svc}
+}
+
+func (s *GiteaSource) excludes(r *gitea.Repository) bool {
+	if s.exclude(r.FullName) || s.exclude(strconv.FormatInt(r.ID, 10)) {
+		return true
+	}
+
+	if s.excludeArchived && r.Archived {
+		return true
+	}
+
+	if s.excludeForks && r.Fork {
+		return true
+	}
+
+	return false
+}
+
+func (s *GiteaSource) makeRepo(r *gitea.Repository) *types.Repo {
+	urn := s.svc.URN()
+	return &types.Repo{
+		Name: reposource.GiteaRepoName(
+			s.config.RepositoryPathPattern,
+			s.baseURL.Hostname(),
+			r.FullName,
+		),
+		URI: string(reposource.GiteaRepoName(
+			"",
+			s.baseURL.Hostname(),
+			r.FullName,
+		)),
+		ExternalRepo: api.ExternalRepoSpec{
+			ID:          strconv.FormatInt(r.ID, 10),
+			ServiceType: extsvc.TypeGitea,
+			ServiceID:   s.baseURL.String(),
+		},
+		Description: r.Description,
+		Fork:        r.Fork,
+		Archived:    r.Archived,
+		Stars:       r.Stars,
+		Private:     r.Private,
+		Sources: map[string]*types.SourceInfo{
+			urn: {
+				ID:       urn,
+				CloneURL: s.remoteURL(r),
+			},
+		},
+		Metadata: r,
+	}
+}
+
+// remoteURL returns the repository's Git remote URL, without credentials.
+//
+// if you need to get an authenticated clone url use repos.CloneURL
+func (s *GiteaSource) remoteURL(r *gitea.Repository) string {
+	if s.config.GitURLType == "ssh" {
+		return r.SSHURL // SSH authentication must be provided out-of-band
+	}
+	return r.CloneURL
+}
+
+func splitGiteaNameWithOwner(nameWithOwner string) (owner, name string, err error) {
+	parts := strings.SplitN(nameWithOwner, "/", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", errors.Errorf("invalid Gitea repository name %q, expected \"owner/name\"", nameWithOwner)
+	}
+	return parts[0], parts[1], nil
+}
@@ -0,0 +1,194 @@
+package repos
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/sourcegraph/sourcegraph/internal/extsvc"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/gitea"
+	"github.com/sourcegraph/sourcegraph/internal/testutil"
+	"github.com/sourcegraph/sourcegraph/internal/types"
+	"github.com/sourcegraph/sourcegraph/schema"
+)
+
+func TestGiteaSource_ListRepos(t *testing.T) {
+	assertAllReposListed := func(want []string) types.ReposAssertion {
+		return func(t testing.TB, rs types.Repos) {
+			t.Helper()
+
+			have := rs.Names()
+			sort.Strings(have)
+			sort.Strings(want)
+
+			if diff := cmp.Diff(want, have); diff != "" {
+				t.Errorf("Mismatch (-want +got):\n%s", diff)
+			}
+		}
+	}
+
+	testCases := []struct {
+		name   string
+		assert types.ReposAssertion
+		conf   *schema.GiteaConnection
+		err    string
+	}{
+		{
+			name: "orgs",
+			assert: assertAllReposListed([]string{
+				"gitea.sgdev.org/sourcegraph/internal-tools",
+				"gitea.sgdev.org/sourcegraph/sourcegraph",
+				"gitea.sgdev.org/sourcegraph/src-cli",
+			}),
+			conf: &schema.GiteaConnection{
+				Url:   "https://gitea.sgdev.org",
+				Token: gitea.GetenvTestGiteaToken(),
+				Orgs:  []string{"sourcegraph"},
+				Exclude: []*schema.ExcludedGiteaRepo{
+					{Forks: true},
+					{Archived: true},
+				},
+			},
+			err: "<nil>",
+		},
+		{
+			name: "orgs users and repos",
+			assert: assertAllReposListed([]string{
+				"gitea.sgdev.org/alice/automation-testing",
+				"gitea.sgdev.org/alice/dotfiles",
+				"gitea.sgdev.org/sourcegraph/internal-tools",
+				"gitea.sgdev.org/sourcegraph/sourcegraph",
+				"gitea.sgdev.org/sourcegraph/src-cli",
+			}),
+			conf: &schema.GiteaConnection{
+				Url:   "https://gitea.sgdev.org",
+				Token: gitea.GetenvTestGiteaToken(),
+				Repos: []string{"alice/dotfiles", "sourcegraph/src-cli"},
+				Orgs:  []string{"sourcegraph"},
+				Users: []string{"alice"},
+				Exclude: []*schema.ExcludedGiteaRepo{
+					{Name: "sourcegraph/legacy"},
+					{Pattern: "^sourcegraph/go-"},
+				},
+			},
+			err: "<nil>",
+		},
+		{
+			name: "missing repo",
+			assert: assertAllReposListed([]string{
+				"gitea.sgdev.org/alice/dotfiles",
+			}),
+			conf: &schema.GiteaConnection{
+				Url:   "https://gitea.sgdev.org",
+				Token: gitea.GetenvTestGiteaToken(),
+				Repos: []string{"alice/missing", "alice/dotfiles"},
+			},
+			err: "1 error occurred:\n\t* gitea.repos: item=\"alice/missing\": Gitea API HTTP error: code=404 url=\"https://gitea.sgdev.org/api/v1/repos/alice/missing\" body=\"{\\\"errors\\\":null,\\\"message\\\":\\\"The target couldn't be found.\\\",\\\"url\\\":\\\"https://gitea.sgdev.org/api/swagger\\\"}\\n\"\n\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		tc.name = "GITEA-LIST-REPOS/" + tc.name
+		t.Run(tc.name, func(t *testing.T) {
+			cf, save := newClientFactory(t, tc.name)
+			defer save(t)
+
+			svc := &types.ExternalService{
+				Kind:   extsvc.KindGitea,
+				Config: marshalJSON(t, tc.conf),
+			}
+
+			src, err := newGiteaSource(svc, tc.conf, cf)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			repos, err := listAll(context.Background(), src)
+			if have, want := fmt.Sprint(err), tc.err; have != want {
+				t.Errorf("error:\nhave: %q\nwant: %q", have, want)
+			}
+
+			if tc.assert != nil {
+				tc.assert(t, repos)
+			}
+		})
+	}
+}
+
+func TestGiteaSource_makeRepo(t *testing.T) {
+	owner := &gitea.User{ID: 2, Login: "sourcegraph"}
+	repos := []*gitea.Repository{
+		{
+			ID:          10,
+			Owner:       owner,
+			Name:        "src-cli",
+			FullName:    "sourcegraph/src-cli",
+			Description: "Sourcegraph CLI",
+			Stars:       12,
+			HTMLURL:     "https://gitea.sgdev.org/sourcegraph/src-cli",
+			SSHURL:      "git@gitea.sgdev.org:sourcegraph/src-cli.git",
+			CloneURL:    "https://gitea.sgdev.org/sourcegraph/src-cli.git",
+		},
+		{
+			ID:       12,
+			Owner:    owner,
+			Name:     "legacy",
+			FullName: "sourcegraph/legacy",
+			Private:  true,
+			Fork:     true,
+			Archived: true,
+			HTMLURL:  "https://gitea.sgdev.org/sourcegraph/legacy",
+			SSHURL:   "git@gitea.sgdev.org:sourcegraph/legacy.git",
+			CloneURL: "https://gitea.sgdev.org/sourcegraph/legacy.git",
+		},
+	}
+
+	svc := types.ExternalService{ID: 1, Kind: extsvc.KindGitea}
+
+	tests := []struct {
+		name   string
+		schema *schema.GiteaConnection
+	}{
+		{
+			name: "simple",
+			schema: &schema.GiteaConnection{
+				Url:   "https://gitea.sgdev.org",
+				Token: "secret",
+			},
+		}, {
+			name: "ssh",
+			schema: &schema.GiteaConnection{
+				Url:        "https://gitea.sgdev.org",
+				Token:      "secret",
+				GitURLType: "ssh",
+			},
+		}, {
+			name: "path-pattern",
+			schema: &schema.GiteaConnection{
+				Url:                   "https://gitea.sgdev.org",
+				Token:                 "secret",
+				RepositoryPathPattern: "gitea/{nameWithOwner}",
+			},
+		},
+	}
+	for _, test := range tests {
+		test.name = "GiteaSource_makeRepo_" + test.name
+		t.Run(test.name, func(t *testing.T) {
+			s, err := newGiteaSource(&svc, test.schema, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var got []*types.Repo
+			for _, r := range repos {
+				got = append(got, s.makeRepo(r))
+			}
+
+			testutil.AssertGolden(t, "testdata/golden/"+test.name, update(test.name), got)
+		})
+	}
+}
@@ -0,0 +1,176 @@
+package repos
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/inconshreveable/log15"
+
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/conf/reposource"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/npmpackages"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/npmpackages/npm"
+	"github.com/sourcegraph/sourcegraph/internal/httpcli"
+	"github.com/sourcegraph/sourcegraph/internal/jsonc"
+	"github.com/sourcegraph/sourcegraph/internal/types"
+	"github.com/sourcegraph/sourcegraph/schema"
+)
+
+// An NPMPackagesSource creates git repositories from the tarballs of published
+// npm packages from the JavaScript/TypeScript ecosystem.
+type NPMPackagesSource struct {
+	svc    *types.ExternalService
+	config *schema.NPMPackagesConnection
+	client *npm.Client
+}
+
+// NewNPMPackagesSource returns a new NPMPackagesSource from the given external
+// service.
+func NewNPMPackagesSource(svc *types.ExternalService, cf *httpcli.Factory) (*NPMPackagesSource, error) {
+	var c schema.NPMPackagesConnection
+	if err := jsonc.Unmarshal(svc.Config, &c); err != nil {
+		return nil, fmt.Errorf("external service id=%d config error: %s", svc.ID, err)
+	}
+
+	if cf == nil {
+		cf = httpcli.ExternalClientFactory
+	}
+	cli, err := cf.Doer()
+	if err != nil {
+		return nil, err
+	}
+
+	return &NPMPackagesSource{
+		svc:    svc,
+		config: &c,
+		client: npm.NewClient(&c, cli),
+	}, nil
+}
+
+// ListRepos returns all npm packages configured in the external service.
+func (s *NPMPackagesSource) ListRepos(ctx context.Context, results chan SourceResult) {
+	pkgs, err := NPMPackages(*s.config)
+	if err != nil {
+		results <- SourceResult{Err: err}
+		return
+	}
+	for _, pkg := range pkgs {
+		results <- SourceResult{
+			Source: s,
+			Repo:   s.makeRepo(pkg),
+		}
+	}
+}
+
+func (s *NPMPackagesSource) GetRepo(ctx context.Context, packagePath string) (*types.Repo, error) {
+	pkg, err := reposource.ParseNPMPackageFromRepoURL(packagePath)
+	if err != nil {
+		return nil, err
+	}
+
+	dependencies, err := NPMDependencies(*s.config)
+	if err != nil {
+		return nil, err
+	}
+
+	nonExistentDependencies := make([]reposource.NPMDependency, 0)
+	hasAtLeastOneValidDependency := false
+	for _, dep := range dependencies {
+		if dep.NPMPackage != pkg {
+			continue
+		}
+		exists, err := s.client.Exists(ctx, dep)
+		if err != nil {
+			return nil, err
+		}
+		if exists {
+			hasAtLeastOneValidDependency = true
+		} else {
+			nonExistentDependencies = append(nonExistentDependencies, dep)
+		}
+	}
+
+	if !hasAtLeastOneValidDependency {
+		return nil, &npmDependencyNotFound{
+			dependencies: nonExistentDependencies,
+		}
+	}
+
+	for _, nonExistentDependency := range nonExistentDependencies {
+		// Like for JVM packages, a single version that fails to resolve
+		// doesn't reject the whole package.
+		log15.Warn("Skipping non-existing npm package", "nonExistentDependency", nonExistentDependency.PackageManagerSyntax())
+	}
+
+	return s.makeRepo(pkg), nil
+}
+
+type npmDependencyNotFound struct {
+	dependencies []reposource.NPMDependency
+}
+
+func (e *npmDependencyNotFound) Error() string {
+	return fmt.Sprintf("not found: npm dependency '%v'", e.dependencies)
+}
+
+func (e *npmDependencyNotFound) NotFound() bool {
+	return true
+}
+
+func (s *NPMPackagesSource) makeRepo(pkg reposource.NPMPackage) *types.Repo {
+	urn := s.svc.URN()
+	cloneURL := pkg.CloneURL()
+	return &types.Repo{
+		Name: pkg.RepoName(),
+		URI:  string(pkg.RepoName()),
+		ExternalRepo: api.ExternalRepoSpec{
+			ID:          string(pkg.RepoName()),
+			ServiceID:   extsvc.TypeNPMPackages,
+			ServiceType: extsvc.TypeNPMPackages,
+		},
+		Private: false,
+		Sources: map[string]*types.SourceInfo{
+			urn: {
+				ID:       urn,
+				CloneURL: cloneURL,
+			},
+		},
+		Metadata: &npmpackages.Metadata{
+			Package: pkg,
+		},
+	}
+}
+
+// ExternalServices returns a singleton slice containing the external service.
+func (s *NPMPackagesSource) ExternalServices() types.ExternalServices {
+	return types.ExternalServices{s.svc}
+}
+
+func NPMDependencies(connection schema.NPMPackagesConnection) (dependencies []reposource.NPMDependency, err error) {
+	for _, dep := range connection.Dependencies {
+		dependency, err := reposource.ParseNPMDependency(dep)
+		if err != nil {
+			return nil, err
+		}
+		dependencies = append(dependencies, dependency)
+	}
+	return dependencies, nil
+}
+
+func NPMPackages(connection schema.NPMPackagesConnection) ([]reposource.NPMPackage, error) {
+	isAdded := make(map[reposource.NPMPackage]bool)
+	pkgs := []reposource.NPMPackage{}
+	dependencies, err := NPMDependencies(connection)
+	if err != nil {
+		return nil, err
+	}
+	for _, dep := range dependencies {
+		pkg := dep.NPMPackage
+		if !isAdded[pkg] {
+			pkgs = append(pkgs, pkg)
+		}
+		isAdded[pkg] = true
+	}
+	return pkgs, nil
+}
@@ -50,6 +50,10 @@ func NewSource(svc *types.ExternalService, cf *httpcli.Factory) (Source, error)
 		return NewBitbucketServerSource(svc, cf)
 	case extsvc.KindBitbucketCloud:
 		return NewBitbucketCloudSource(svc, cf)
+	case extsvc.KindGerrit:
+		return NewGerritSource(svc, cf)
+	case extsvc.KindGitea:
+		return NewGiteaSource(svc, cf)
 	case extsvc.KindGitolite:
 		return NewGitoliteSource(svc, cf)
 	case extsvc.KindPhabricator:
@@ -60,6 +64,10 @@ func NewSource(svc *types.ExternalService, cf *httpcli.Factory) (Source, error)
 		return NewPerforceSource(svc)
 	case extsvc.KindJVMPackages:
 		return NewJVMPackagesSource(svc)
+	case extsvc.KindNPMPackages:
+		return NewNPMPackagesSource(svc, cf)
+	case extsvc.KindGoModules:
+		return NewGoModulesSource(svc, cf)
 	case extsvc.KindOther:
 		return NewOtherSource(svc, cf)
 	default:
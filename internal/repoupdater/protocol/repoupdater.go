@@ -10,6 +10,8 @@ import (
 	"github.com/sourcegraph/sourcegraph/internal/extsvc"
 	"github.com/sourcegraph/sourcegraph/internal/extsvc/awscodecommit"
 	"github.com/sourcegraph/sourcegraph/internal/extsvc/bitbucketserver"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/gerrit"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/gitea"
 	"github.com/sourcegraph/sourcegraph/internal/extsvc/github"
 	"github.com/sourcegraph/sourcegraph/internal/extsvc/gitlab"
 	"github.com/sourcegraph/sourcegraph/internal/types"
@@ -159,6 +161,43 @@ func NewRepoInfo(r *types.Repo) *RepoInfo {
 			Blob:   pathAppend(root, "/browse/{path}?at={rev}"),
 			Commit: pathAppend(root, "/commits/{commit}"),
 		}
+	case extsvc.TypeGerrit:
+		proj := r.Metadata.(*gerrit.Project)
+		// Prefer the project's Gitiles web link, if the instance has the
+		// plugin, since Gerrit itself can't browse files.
+		for _, l := range proj.WebLinks {
+			if l.Name != "gitiles" && l.Name != "browse" {
+				continue
+			}
+			root := l.URL
+			if strings.HasPrefix(root, "/") {
+				root = pathAppend(r.ExternalRepo.ServiceID, root)
+			}
+			info.Links = &RepoLinks{
+				Root:   root,
+				Tree:   pathAppend(root, "/+/{rev}/{path}"),
+				Blob:   pathAppend(root, "/+/{rev}/{path}"),
+				Commit: pathAppend(root, "/+/{commit}"),
+			}
+			break
+		}
+		if info.Links == nil {
+			info.Links = &RepoLinks{
+				Root:   pathAppend(r.ExternalRepo.ServiceID, "/admin/repos/"+proj.Name),
+				Commit: pathAppend(r.ExternalRepo.ServiceID, "/q/commit:{commit}"),
+			}
+		}
+	case extsvc.TypeGitea:
+		repo := r.Metadata.(*gitea.Repository)
+		if repo.HTMLURL == "" {
+			break
+		}
+		info.Links = &RepoLinks{
+			Root:   repo.HTMLURL,
+			Tree:   pathAppend(repo.HTMLURL, "/src/{rev}/{path}"),
+			Blob:   pathAppend(repo.HTMLURL, "/src/{rev}/{path}"),
+			Commit: pathAppend(repo.HTMLURL, "/commit/{commit}"),
+		}
 	case extsvc.TypeAWSCodeCommit:
 		repo := r.Metadata.(*awscodecommit.Repository)
 		if repo.ARN == "" {
@@ -0,0 +1,232 @@
+// Package codeowners parses CODEOWNERS files and resolves the owners of files
+// in a repository. It implements the `file:has.owner()` predicate and the
+// `select:file.owners` selector.
+package codeowners
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/cockroachdb/errors"
+
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/vcs/git"
+)
+
+// Paths are the locations of CODEOWNERS files in a repository, in order of
+// precedence. Only the first CODEOWNERS file found is used.
+var Paths = []string{".github/CODEOWNERS", "CODEOWNERS", "docs/CODEOWNERS"}
+
+// maxFileSize is the largest CODEOWNERS file we read.
+const maxFileSize = 1 << 20
+
+// File is a parsed CODEOWNERS file.
+type File struct {
+	Path  string
+	Rules []*Rule
+}
+
+// Rule is a line of a CODEOWNERS file which assigns owners to the files
+// matching a pattern.
+type Rule struct {
+	Pattern string
+	Owners  []string
+
+	// LineNumber is the 0-based line number of the rule in the file, and
+	// Line is its contents.
+	LineNumber int32
+	Line       string
+
+	// Regexp is the pattern translated to a regular expression that matches
+	// the paths of the files the rule applies to.
+	Regexp string
+	re     *regexp.Regexp
+}
+
+// Parse parses the contents of the CODEOWNERS file at path.
+func Parse(path string, content []byte) (*File, error) {
+	f := &File{Path: path}
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for lineNumber := int32(0); scanner.Scan(); lineNumber++ {
+		line := scanner.Text()
+		fields := strings.Fields(stripComment(line))
+		if len(fields) == 0 {
+			continue
+		}
+		// GitLab sections like "[Documentation]" group rules but do not
+		// change their meaning.
+		if strings.HasPrefix(fields[0], "[") || strings.HasPrefix(fields[0], "^[") {
+			continue
+		}
+
+		expr, err := PatternToRegexp(fields[0])
+		if err != nil {
+			return nil, errors.Errorf("%s:%d: %w", path, lineNumber+1, err)
+		}
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, errors.Errorf("%s:%d: %w", path, lineNumber+1, err)
+		}
+		f.Rules = append(f.Rules, &Rule{
+			Pattern:    fields[0],
+			Owners:     fields[1:],
+			LineNumber: lineNumber,
+			Line:       line,
+			Regexp:     expr,
+			re:         re,
+		})
+	}
+	return f, scanner.Err()
+}
+
+// stripComment removes a trailing comment from a line. An escaped \# does not
+// start a comment.
+func stripComment(line string) string {
+	for i := 0; i < len(line); i++ {
+		switch line[i] {
+		case '\\':
+			i++
+		case '#':
+			return line[:i]
+		}
+	}
+	return line
+}
+
+// Match returns the rule which determines the owners of the file at path, or
+// nil if no rule matches. As in GitHub and GitLab, the last matching rule
+// takes precedence.
+func (f *File) Match(path string) *Rule {
+	for i := len(f.Rules) - 1; i >= 0; i-- {
+		if f.Rules[i].re.MatchString(path) {
+			return f.Rules[i]
+		}
+	}
+	return nil
+}
+
+// HasOwner returns true if owner is one of the owners of the rule. Owners are
+// compared case-insensitively.
+func (r *Rule) HasOwner(owner string) bool {
+	for _, o := range r.Owners {
+		if strings.EqualFold(o, owner) {
+			return true
+		}
+	}
+	return false
+}
+
+// OwnedBy describes the files owned by owner. A file is owned by owner if it
+// matches the Include regexp of one of the patterns and none of the Excludes
+// from its ExcludeFrom index on, which are the rules after it that override
+// the owner.
+func (f *File) OwnedBy(owner string) *OwnedPatterns {
+	owned := &OwnedPatterns{}
+	for _, r := range f.Rules {
+		if !r.HasOwner(owner) {
+			owned.Excludes = append(owned.Excludes, r.Regexp)
+			continue
+		}
+		// The rules after r which override the owner are the ones we
+		// haven't added to Excludes yet.
+		owned.Patterns = append(owned.Patterns, OwnedPattern{
+			Include:     r.Regexp,
+			ExcludeFrom: len(owned.Excludes),
+		})
+	}
+	return owned
+}
+
+// OwnedPatterns are the files owned by an owner. See File.OwnedBy.
+type OwnedPatterns struct {
+	Patterns []OwnedPattern
+	// Excludes are the regexps of the rules which are not owned by the
+	// owner, in the order of the rules. They are shared by all patterns so
+	// that they are only turned into a query once.
+	Excludes []string
+}
+
+// OwnedPattern is a set of paths owned by an owner. See File.OwnedBy.
+type OwnedPattern struct {
+	Include     string
+	ExcludeFrom int
+}
+
+// PatternToRegexp translates a CODEOWNERS path pattern, which uses the syntax
+// of .gitignore files, to a regular expression matching the paths of the files
+// it applies to.
+func PatternToRegexp(pattern string) (string, error) {
+	if pattern == "" {
+		return "", errors.New("empty pattern")
+	}
+	if strings.HasPrefix(pattern, "!") {
+		return "", errors.Errorf("negated pattern %q is not supported", pattern)
+	}
+
+	// A pattern with a slash at the beginning or in the middle is relative
+	// to the root of the repository. Otherwise it matches at any depth.
+	anchored := strings.Contains(strings.TrimSuffix(pattern, "/"), "/")
+	pattern = strings.TrimPrefix(pattern, "/")
+
+	var b strings.Builder
+	if anchored {
+		b.WriteString("^")
+	} else {
+		b.WriteString("(^|/)")
+	}
+
+	for i := 0; i < len(pattern); i++ {
+		c := pattern[i]
+		switch {
+		case strings.HasPrefix(pattern[i:], "**/"):
+			b.WriteString("(.*/)?")
+			i += 2
+		case strings.HasPrefix(pattern[i:], "**") && i+2 == len(pattern):
+			b.WriteString(".*")
+			i++
+		case c == '*':
+			b.WriteString("[^/]*")
+		case c == '?':
+			b.WriteString("[^/]")
+		case c == '\\' && i+1 < len(pattern):
+			i++
+			b.WriteString(regexp.QuoteMeta(pattern[i : i+1]))
+		default:
+			b.WriteString(regexp.QuoteMeta(pattern[i : i+1]))
+		}
+	}
+
+	switch {
+	case strings.HasSuffix(pattern, "/"):
+		// A directory pattern matches all files in the directory.
+		return b.String(), nil
+	case strings.HasSuffix(pattern, "/*"):
+		// As on GitHub, "docs/*" matches the files in docs, but not those
+		// in its subdirectories.
+		b.WriteString("$")
+		return b.String(), nil
+	}
+	// A pattern matches files, as well as all files in matching directories.
+	b.WriteString("(/|$)")
+	return b.String(), nil
+}
+
+// Find returns the CODEOWNERS file of repo at commit, or nil if the repository
+// has none.
+func Find(ctx context.Context, repo api.RepoName, commit api.CommitID) (*File, error) {
+	for _, path := range Paths {
+		content, err := git.ReadFile(ctx, repo, commit, path, maxFileSize)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		return Parse(path, content)
+	}
+	return nil, nil
+}
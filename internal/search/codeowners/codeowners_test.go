@@ -0,0 +1,188 @@
+package codeowners
+
+import (
+	"context"
+	"os"
+	"regexp"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/search/result"
+	"github.com/sourcegraph/sourcegraph/internal/types"
+	"github.com/sourcegraph/sourcegraph/internal/vcs/git"
+)
+
+func TestPatternToRegexp(t *testing.T) {
+	tests := []struct {
+		pattern  string
+		matches  []string
+		excludes []string
+	}{
+		{
+			pattern: "*",
+			matches: []string{"a", "a/b.go", ".github/CODEOWNERS"},
+		},
+		{
+			pattern:  "*.go",
+			matches:  []string{"a.go", "cmd/a.go"},
+			excludes: []string{"a.gox", "a.go.txt"},
+		},
+		{
+			pattern:  "/build/logs/",
+			matches:  []string{"build/logs/a.log", "build/logs/b/c.log"},
+			excludes: []string{"a/build/logs/a.log", "build/logs"},
+		},
+		{
+			pattern:  "docs/*",
+			matches:  []string{"docs/a.md"},
+			excludes: []string{"a/docs/a.md", "docs/a/b.md"},
+		},
+		{
+			pattern:  "apps/",
+			matches:  []string{"apps/a", "a/apps/b/c"},
+			excludes: []string{"apps", "myapps/a"},
+		},
+		{
+			pattern:  "/docs/",
+			matches:  []string{"docs/a", "docs/a/b"},
+			excludes: []string{"a/docs/b"},
+		},
+		{
+			pattern:  "**/logs",
+			matches:  []string{"logs", "a/logs", "a/b/logs/c.log"},
+			excludes: []string{"mylogs"},
+		},
+		{
+			pattern:  "/web/**/*.ts",
+			matches:  []string{"web/a.ts", "web/a/b/c.ts"},
+			excludes: []string{"a/web/a.ts", "web/a.tsx"},
+		},
+		{
+			pattern:  "internal/**",
+			matches:  []string{"internal/a", "internal/a/b"},
+			excludes: []string{"a/internal/b"},
+		},
+		{
+			pattern:  "file?.txt",
+			matches:  []string{"file1.txt"},
+			excludes: []string{"file10.txt", "file/.txt"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pattern, func(t *testing.T) {
+			expr, err := PatternToRegexp(tt.pattern)
+			if err != nil {
+				t.Fatal(err)
+			}
+			re := regexp.MustCompile(expr)
+			for _, path := range tt.matches {
+				if !re.MatchString(path) {
+					t.Errorf("%q (%s) does not match %q", tt.pattern, expr, path)
+				}
+			}
+			for _, path := range tt.excludes {
+				if re.MatchString(path) {
+					t.Errorf("%q (%s) matches %q", tt.pattern, expr, path)
+				}
+			}
+		})
+	}
+}
+
+const testCodeowners = `# Default owners
+*       @sourcegraph/everyone
+
+*.go    @sourcegraph/backend    # Go code
+/web/   @sourcegraph/frontend
+/web/src/api/ @sourcegraph/backend docs@sourcegraph.com
+/internal/unowned/
+`
+
+func TestFile(t *testing.T) {
+	f, err := Parse("CODEOWNERS", []byte(testCodeowners))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("Match", func(t *testing.T) {
+		for path, want := range map[string]int32{
+			"README.md":                    1,
+			"cmd/main.go":                  3,
+			"web/src/index.ts":             4,
+			"web/src/api/client.go":        5,
+			"internal/unowned/unowned.go":  6,
+			"internal/database/schema.go":  3,
+			"web/src/api/nested/client.ts": 5,
+		} {
+			rule := f.Match(path)
+			if rule == nil {
+				t.Errorf("no rule for %q", path)
+				continue
+			}
+			if rule.LineNumber != want {
+				t.Errorf("%q matched line %d, want %d", path, rule.LineNumber, want)
+			}
+		}
+	})
+
+	t.Run("OwnedBy", func(t *testing.T) {
+		got := f.OwnedBy("@SourceGraph/Backend")
+		want := &OwnedPatterns{
+			Patterns: []OwnedPattern{{
+				Include:     `(^|/)[^/]*\.go(/|$)`,
+				ExcludeFrom: 1,
+			}, {
+				Include:     `^web/src/api/`,
+				ExcludeFrom: 2,
+			}},
+			Excludes: []string{`(^|/)[^/]*(/|$)`, `^web/`, `^internal/unowned/`},
+		}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Fatalf("unexpected owned patterns (-want +got):\n%s", diff)
+		}
+	})
+}
+
+func TestSelectOwners(t *testing.T) {
+	git.Mocks.ReadFile = func(commit api.CommitID, name string) ([]byte, error) {
+		if name == "CODEOWNERS" {
+			return []byte(testCodeowners), nil
+		}
+		return nil, &os.PathError{Op: "open", Path: name, Err: os.ErrNotExist}
+	}
+	t.Cleanup(func() { git.Mocks.ReadFile = nil })
+
+	repo := types.RepoName{ID: 1, Name: "github.com/sourcegraph/sourcegraph"}
+	fileMatch := func(path string) *result.FileMatch {
+		return &result.FileMatch{File: result.File{Repo: repo, CommitID: "deadbeef", Path: path}}
+	}
+
+	got, err := SelectOwners(context.Background(), []result.Match{
+		fileMatch("web/src/index.ts"),
+		fileMatch("cmd/main.go"),
+		fileMatch("internal/search/search.go"),
+		fileMatch("internal/unowned/unowned.go"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []result.Match{&result.FileMatch{
+		File: result.File{Repo: repo, CommitID: "deadbeef", Path: "CODEOWNERS"},
+		LineMatches: []*result.LineMatch{{
+			Preview:          "*.go    @sourcegraph/backend    # Go code",
+			LineNumber:       3,
+			OffsetAndLengths: [][2]int32{{0, 41}},
+		}, {
+			Preview:          "/web/   @sourcegraph/frontend",
+			LineNumber:       4,
+			OffsetAndLengths: [][2]int32{{0, 29}},
+		}},
+	}}
+	if diff := cmp.Diff(want, got, cmpopts.IgnoreUnexported(result.File{})); diff != "" {
+		t.Fatalf("unexpected matches (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,87 @@
+package codeowners
+
+import (
+	"context"
+	"sort"
+	"unicode/utf8"
+
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/search/result"
+)
+
+// SelectOwners implements `select:file.owners`. It replaces the file matches
+// in matches with the CODEOWNERS files of their repositories. Each CODEOWNERS
+// file has a line match for every rule that determines the owners of one of
+// the matched files. Files without owners are dropped.
+func SelectOwners(ctx context.Context, matches []result.Match) ([]result.Match, error) {
+	type key struct {
+		repo   api.RepoName
+		commit api.CommitID
+	}
+	var (
+		files   = map[key]*File{}
+		owners  = map[key]*result.FileMatch{}
+		lines   = map[key]map[int32]struct{}{}
+		ordered []key
+	)
+
+	for _, match := range matches {
+		fm, ok := match.(*result.FileMatch)
+		if !ok {
+			continue
+		}
+		k := key{repo: fm.Repo.Name, commit: fm.CommitID}
+
+		f, ok := files[k]
+		if !ok {
+			var err error
+			f, err = Find(ctx, fm.Repo.Name, fm.CommitID)
+			if err != nil {
+				return nil, err
+			}
+			files[k] = f
+		}
+		if f == nil {
+			continue
+		}
+
+		rule := f.Match(fm.Path)
+		if rule == nil || len(rule.Owners) == 0 {
+			continue
+		}
+
+		ownersMatch, ok := owners[k]
+		if !ok {
+			ownersMatch = &result.FileMatch{
+				File: result.File{
+					InputRev: fm.InputRev,
+					Repo:     fm.Repo,
+					CommitID: fm.CommitID,
+					Path:     f.Path,
+				},
+			}
+			owners[k] = ownersMatch
+			lines[k] = map[int32]struct{}{}
+			ordered = append(ordered, k)
+		}
+		if _, ok := lines[k][rule.LineNumber]; ok {
+			continue
+		}
+		lines[k][rule.LineNumber] = struct{}{}
+		ownersMatch.LineMatches = append(ownersMatch.LineMatches, &result.LineMatch{
+			Preview:          rule.Line,
+			LineNumber:       rule.LineNumber,
+			OffsetAndLengths: [][2]int32{{0, int32(utf8.RuneCountInString(rule.Line))}},
+		})
+	}
+
+	selected := make([]result.Match, 0, len(ordered))
+	for _, k := range ordered {
+		m := owners[k]
+		sort.Slice(m.LineMatches, func(i, j int) bool {
+			return m.LineMatches[i].LineNumber < m.LineMatches[j].LineNumber
+		})
+		selected = append(selected, m)
+	}
+	return selected, nil
+}
@@ -15,12 +15,16 @@ const (
 	FieldType               = "type"
 	FieldRepoHasFile        = "repohasfile"
 	FieldRepoHasCommitAfter = "repohascommitafter"
+	FieldRepoHasKVP         = "repohaskvp"
 	FieldPatternType        = "patterntype"
 	FieldContent            = "content"
 	FieldVisibility         = "visibility"
 	FieldRev                = "rev"
 	FieldContext            = "context"
 
+	// For symbol search only:
+	FieldContainer = "container"
+
 	// For diff and commit search only:
 	FieldBefore    = "before"
 	FieldAfter     = "after"
@@ -56,6 +60,7 @@ var allFields = map[string]struct{}{
 	FieldVisibility:         empty,
 	FieldRepoHasFile:        empty,
 	FieldRepoHasCommitAfter: empty,
+	FieldRepoHasKVP:         empty,
 	FieldBefore:             empty,
 	"until":                 empty,
 	FieldAfter:              empty,
@@ -72,6 +77,7 @@ var allFields = map[string]struct{}{
 	FieldRev:                empty,
 	"revision":              empty,
 	FieldSelect:             empty,
+	FieldContainer:          empty,
 }
 
 var aliases = map[string]string{
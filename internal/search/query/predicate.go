@@ -31,10 +31,12 @@ var DefaultPredicateRegistry = predicateRegistry{
 		"contains.file":         func() Predicate { return &RepoContainsFilePredicate{} },
 		"contains.content":      func() Predicate { return &RepoContainsContentPredicate{} },
 		"contains.commit.after": func() Predicate { return &RepoContainsCommitAfterPredicate{} },
+		"has.meta":              func() Predicate { return &RepoHasMetaPredicate{} },
 	},
 	FieldFile: {
 		"contains.content": func() Predicate { return &FileContainsContentPredicate{} },
 		"contains":         func() Predicate { return &FileContainsContentPredicate{} },
+		"has.owner":        func() Predicate { return &FileHasOwnerPredicate{} },
 	},
 }
 
@@ -249,6 +251,62 @@ func (f *RepoContainsCommitAfterPredicate) Plan(parent Basic) (Plan, error) {
 	return ToPlan(Dnf(nodes))
 }
 
+/* repo:has.meta(key:value) */
+
+// RepoHasMetaPredicate represents the `repo:has.meta()` predicate, which
+// filters to repos with the given key/value metadata. If Value is nil, it
+// filters to repos which have the key, whatever its value.
+type RepoHasMetaPredicate struct {
+	Key   string
+	Value *string
+}
+
+func (f *RepoHasMetaPredicate) ParseParams(params string) (err error) {
+	f.Key, f.Value, err = ParseKVP(params)
+	if err != nil {
+		return errors.Errorf("has.meta argument: %w", err)
+	}
+	return nil
+}
+
+func (f *RepoHasMetaPredicate) Field() string { return FieldRepo }
+func (f *RepoHasMetaPredicate) Name() string  { return "has.meta" }
+func (f *RepoHasMetaPredicate) Plan(parent Basic) (Plan, error) {
+	value := f.Key
+	if f.Value != nil {
+		value += ":" + *f.Value
+	}
+
+	nodes := make([]Node, 0, 3)
+	nodes = append(nodes, Parameter{
+		Field: FieldCount,
+		Value: "99999",
+	}, Parameter{
+		Field: FieldRepoHasKVP,
+		Value: value,
+	})
+
+	nodes = append(nodes, nonPredicateRepos(parent)...)
+	return ToPlan(Dnf(nodes))
+}
+
+// ParseKVP parses the `key` or `key:value` syntax used to match repository
+// metadata. The key extends up to the first colon, so values may contain
+// colons but keys may not. A nil value means that any value matches.
+func ParseKVP(s string) (key string, value *string, err error) {
+	s = strings.TrimSpace(s)
+	if i := strings.Index(s, ":"); i >= 0 {
+		v := strings.TrimSpace(s[i+1:])
+		key, value = strings.TrimSpace(s[:i]), &v
+	} else {
+		key = s
+	}
+	if key == "" {
+		return "", nil, errors.New("key should not be empty")
+	}
+	return key, value, nil
+}
+
 type FileContainsContentPredicate struct {
 	Pattern string
 }
@@ -284,6 +342,58 @@ func (f *FileContainsContentPredicate) Plan(parent Basic) (Plan, error) {
 	return ToPlan(Dnf(nodes))
 }
 
+/* file:has.owner(owner) */
+
+// codeownersFilePattern matches the locations GitHub and GitLab look for
+// CODEOWNERS files in.
+const codeownersFilePattern = `^(\.github/|docs/)?CODEOWNERS$`
+
+// FileHasOwnerPredicate represents the `file:has.owner()` predicate, which
+// filters to files owned by the given user or team according to the
+// repository's CODEOWNERS file.
+type FileHasOwnerPredicate struct {
+	Owner string
+}
+
+func (f *FileHasOwnerPredicate) ParseParams(params string) error {
+	owner := strings.TrimSpace(params)
+	if owner == "" {
+		return errors.Errorf("file:has.owner argument should not be empty")
+	}
+	if strings.ContainsAny(owner, " \t") {
+		return errors.Errorf("file:has.owner argument should be a single user, team or email")
+	}
+	f.Owner = owner
+	return nil
+}
+
+func (f FileHasOwnerPredicate) Field() string { return FieldFile }
+func (f FileHasOwnerPredicate) Name() string  { return "has.owner" }
+
+// Plan finds the CODEOWNERS files which mention the owner. The caller is
+// responsible for turning the rules in those files into the owned files.
+func (f *FileHasOwnerPredicate) Plan(parent Basic) (Plan, error) {
+	nodes := make([]Node, 0, 4)
+	nodes = append(nodes, Parameter{
+		Field: FieldCount,
+		Value: "99999",
+	}, Parameter{
+		Field: FieldType,
+		Value: "file",
+	}, Parameter{
+		Field: FieldFile,
+		Value: codeownersFilePattern,
+	}, Pattern{
+		Value:      regexp.QuoteMeta(f.Owner),
+		Annotation: Annotation{Labels: Regexp},
+	})
+
+	// Repo nodes include the revision being searched, so we read the
+	// CODEOWNERS files at that revision.
+	nodes = append(nodes, nonPredicateRepos(parent)...)
+	return ToPlan(Dnf(nodes))
+}
+
 // nonPredicateRepos returns the repo nodes in a query that aren't predicates,
 // respecting parameters that determine repo results.
 func nonPredicateRepos(q Basic) []Node {
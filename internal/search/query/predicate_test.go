@@ -80,3 +80,88 @@ func TestParseAsPredicate(t *testing.T) {
 	}
 
 }
+
+func TestFileHasOwnerPredicate(t *testing.T) {
+	t.Run("ParseParams", func(t *testing.T) {
+		for _, params := range []string{``, ` `, `@a @b`} {
+			p := &FileHasOwnerPredicate{}
+			if err := p.ParseParams(params); err == nil {
+				t.Errorf("expected error for %q but got none", params)
+			}
+		}
+
+		p := &FileHasOwnerPredicate{}
+		if err := p.ParseParams(` @sourcegraph/search `); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if want := (&FileHasOwnerPredicate{Owner: "@sourcegraph/search"}); !reflect.DeepEqual(want, p) {
+			t.Fatalf("expected %#v, got %#v", want, p)
+		}
+	})
+
+	t.Run("Plan", func(t *testing.T) {
+		plan, err := Pipeline(InitRegexp(`repo:^github\.com/sourcegraph/sourcegraph$ rev:main file:has.owner(@sourcegraph/search) foo`))
+		if err != nil {
+			t.Fatal(err)
+		}
+		p := &FileHasOwnerPredicate{Owner: "@sourcegraph/search"}
+		got, err := p.Plan(plan[0])
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := `(and "count:99999" "type:file" "file:^(\\.github/|docs/)?CODEOWNERS$" "repo:^github\\.com/sourcegraph/sourcegraph$@main" "@sourcegraph/search")`
+		if got := toString(got.ToParseTree()); got != want {
+			t.Fatalf("got %s, want %s", got, want)
+		}
+	})
+}
+
+func TestRepoHasMetaPredicate(t *testing.T) {
+	t.Run("ParseParams", func(t *testing.T) {
+		strPtr := func(s string) *string { return &s }
+
+		valid := []struct {
+			params   string
+			expected *RepoHasMetaPredicate
+		}{
+			{`tier:1`, &RepoHasMetaPredicate{Key: "tier", Value: strPtr("1")}},
+			{` owner : search `, &RepoHasMetaPredicate{Key: "owner", Value: strPtr("search")}},
+			{`deprecated`, &RepoHasMetaPredicate{Key: "deprecated"}},
+			{`empty:`, &RepoHasMetaPredicate{Key: "empty", Value: strPtr("")}},
+			{`url:https://example.com`, &RepoHasMetaPredicate{Key: "url", Value: strPtr("https://example.com")}},
+		}
+		for _, tc := range valid {
+			p := &RepoHasMetaPredicate{}
+			if err := p.ParseParams(tc.params); err != nil {
+				t.Fatalf("unexpected error for %q: %s", tc.params, err)
+			}
+			if !reflect.DeepEqual(tc.expected, p) {
+				t.Fatalf("expected %#v, got %#v", tc.expected, p)
+			}
+		}
+
+		for _, params := range []string{``, ` `, `:value`} {
+			p := &RepoHasMetaPredicate{}
+			if err := p.ParseParams(params); err == nil {
+				t.Errorf("expected error for %q but got none", params)
+			}
+		}
+	})
+
+	t.Run("Plan", func(t *testing.T) {
+		plan, err := Pipeline(InitRegexp(`repo:^github\.com/sourcegraph/ repo:has.meta(tier:1) foo`))
+		if err != nil {
+			t.Fatal(err)
+		}
+		value := "1"
+		p := &RepoHasMetaPredicate{Key: "tier", Value: &value}
+		got, err := p.Plan(plan[0])
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := `(and "count:99999" "repohaskvp:tier:1" "repo:^github\\.com/sourcegraph/")`
+		if got := toString(got.ToParseTree()); got != want {
+			t.Fatalf("got %s, want %s", got, want)
+		}
+	})
+}
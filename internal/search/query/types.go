@@ -393,11 +393,14 @@ func (q Q) valueToTypedValue(field, value string, label labels) []*Value {
 		FieldContent:
 		return []*Value{{String: &value}}
 
-	case FieldRepoHasFile:
+	case
+		FieldRepoHasFile,
+		FieldContainer:
 		return []*Value{{Regexp: parseRegexpOrPanic(field, value)}}
 
 	case
 		FieldRepoHasCommitAfter,
+		FieldRepoHasKVP,
 		FieldBefore, "until",
 		FieldAfter, "since":
 		return []*Value{{String: &value}}
@@ -44,6 +44,10 @@ func IsPatternAtom(b Basic) bool {
 // pattern node.
 func IsStreamingCompatible(p Plan) bool {
 	if len(p) == 1 {
+		if sp, _ := p[0].ToParseTree().StringValue(FieldSelect); sp == "file.owners" {
+			// Owners are resolved after all file results are in.
+			return false
+		}
 		if p[0].Pattern == nil {
 			return true
 		}
@@ -266,6 +270,11 @@ func validateField(field, value string, negated bool, seen map[string]struct{})
 		return nil
 	}
 
+	isValidKVP := func() error {
+		_, _, err := ParseKVP(value)
+		return err
+	}
+
 	isUnrecognizedField := func() error {
 		return errors.Errorf("unrecognized field %q", field)
 	}
@@ -318,6 +327,9 @@ func validateField(field, value string, negated bool, seen map[string]struct{})
 	case
 		FieldRepoHasCommitAfter:
 		return satisfies(isSingular, isNotNegated)
+	case
+		FieldRepoHasKVP:
+		return satisfies(isNotNegated, isValidKVP)
 	case
 		FieldBefore,
 		FieldAfter:
@@ -347,6 +359,9 @@ func validateField(field, value string, negated bool, seen map[string]struct{})
 	case
 		FieldSelect:
 		return satisfies(isSingular, isNotNegated, isValidSelect)
+	case
+		FieldContainer:
+		return satisfies(isSingular, isNotNegated, isValidRegexp)
 	default:
 		return isUnrecognizedField()
 	}
@@ -407,6 +422,24 @@ func validateCommitParameters(nodes []Node) error {
 	return nil
 }
 
+// Queries containing container: without type:symbol are not valid, since only
+// symbols have containers.
+func validateContainer(nodes []Node) error {
+	var seenContainer, seenTypeSymbol bool
+	VisitParameter(nodes, func(field, value string, _ bool, _ Annotation) {
+		if field == FieldContainer {
+			seenContainer = true
+		}
+		if field == FieldType && strings.EqualFold(value, "symbol") {
+			seenTypeSymbol = true
+		}
+	})
+	if seenContainer && !seenTypeSymbol {
+		return errors.New("your query contains the field 'container', which requires type:symbol in the query")
+	}
+	return nil
+}
+
 func validateTypeStructural(nodes []Node) error {
 	seenStructural := false
 	seenType := false
@@ -536,6 +569,7 @@ func validate(nodes []Node) error {
 		validateRepoRevPair,
 		validateRepoHasFile,
 		validateCommitParameters,
+		validateContainer,
 		validateTypeStructural,
 	)
 }
@@ -95,6 +95,18 @@ func TestAndOrQuery_Validation(t *testing.T) {
 			input: "-context:a",
 			want:  `field "context" does not support negation`,
 		},
+		{
+			input: "repo:foo container:Server Start",
+			want:  `your query contains the field 'container', which requires type:symbol in the query`,
+		},
+		{
+			input: "type:symbol container:a container:b",
+			want:  `field "container" may not be used more than once`,
+		},
+		{
+			input: "type:symbol -container:a",
+			want:  `field "container" does not support negation`,
+		},
 		{
 			input: "type:symbol select:symbol.timelime",
 			want:  `invalid field "timelime" on select path "symbol.timelime"`,
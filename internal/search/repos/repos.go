@@ -125,7 +125,7 @@ func (r *Resolver) Resolve(ctx context.Context, op search.RepoOptions) (Resolved
 
 	var searchableRepos []types.RepoName
 
-	if envvar.SourcegraphDotComMode() && len(includePatterns) == 0 && !query.HasTypeRepo(op.Query) && searchcontexts.IsGlobalSearchContext(searchContext) {
+	if envvar.SourcegraphDotComMode() && len(includePatterns) == 0 && len(op.HasKVPs) == 0 && !query.HasTypeRepo(op.Query) && searchcontexts.IsGlobalSearchContext(searchContext) {
 		start := time.Now()
 		searchableRepos, err = searchableRepositories(ctx, r.SearchableReposFunc, excludePatterns)
 		if err != nil {
@@ -163,6 +163,10 @@ func (r *Resolver) Resolve(ctx context.Context, op search.RepoOptions) (Resolved
 			OnlyPrivate:  op.Visibility == query.Private,
 		}
 
+		for _, kvp := range op.HasKVPs {
+			options.KVPFilters = append(options.KVPFilters, database.RepoKVPFilter{Key: kvp.Key, Value: kvp.Value})
+		}
+
 		if searchContext.ID != 0 {
 			options.SearchContextID = searchContext.ID
 		} else if searchContext.NamespaceUserID != 0 {
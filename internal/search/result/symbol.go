@@ -3,10 +3,12 @@ package result
 import (
 	"fmt"
 	"net/url"
+	"sort"
 	"strconv"
 	"strings"
 	"unicode/utf8"
 
+	"github.com/go-enry/go-enry/v2"
 	"github.com/sourcegraph/go-lsp"
 )
 
@@ -310,3 +312,91 @@ func SelectSymbolKind(symbols []*SymbolMatch, field string) []*SymbolMatch {
 		return field == toSelectKind[strings.ToLower(s.Symbol.Kind)]
 	})
 }
+
+// SymbolKindsForSelectKind returns the internal symbol kinds (cf. ctagsKind)
+// that correspond to the symbol selector kind value `field`, e.g. "function"
+// for `select:symbol.function`. It is the inverse of the mapping used by
+// SelectSymbolKind.
+func SymbolKindsForSelectKind(field string) []string {
+	var kinds []string
+	for kind, selectKind := range toSelectKind {
+		if selectKind == field {
+			kinds = append(kinds, kind)
+		}
+	}
+	sort.Strings(kinds)
+	return kinds
+}
+
+// ctagsLanguages maps the lowercase names of languages as reported by enry,
+// which is what lang: filters refer to, to the names ctags reports for them.
+// Languages that ctags does not know are missing.
+var ctagsLanguages = map[string]string{
+	"batchfile":       "DosBatch",
+	"c":               "C",
+	"c#":              "C#",
+	"c++":             "C++",
+	"clojure":         "Clojure",
+	"common lisp":     "Lisp",
+	"css":             "CSS",
+	"d":               "D",
+	"elixir":          "Elixir",
+	"elm":             "Elm",
+	"emacs lisp":      "EmacsLisp",
+	"erlang":          "Erlang",
+	"fortran":         "Fortran",
+	"go":              "Go",
+	"haskell":         "Haskell",
+	"html":            "HTML",
+	"java":            "Java",
+	"javascript":      "JavaScript",
+	"json":            "JSON",
+	"julia":           "Julia",
+	"kotlin":          "Kotlin",
+	"lua":             "Lua",
+	"makefile":        "Make",
+	"markdown":        "Markdown",
+	"matlab":          "MatLab",
+	"objective-c":     "ObjectiveC",
+	"ocaml":           "OCaml",
+	"pascal":          "Pascal",
+	"perl":            "Perl",
+	"php":             "PHP",
+	"powershell":      "PowerShell",
+	"protocol buffer": "Protobuf",
+	"python":          "Python",
+	"r":               "R",
+	"raku":            "Perl6",
+	"ruby":            "Ruby",
+	"rust":            "Rust",
+	"scala":           "Scala",
+	"scheme":          "Scheme",
+	"scss":            "SCSS",
+	"shell":           "Sh",
+	"sql":             "SQL",
+	"swift":           "Swift",
+	"tcl":             "Tcl",
+	"tex":             "Tex",
+	"typescript":      "TypeScript",
+	"verilog":         "Verilog",
+	"vhdl":            "VHDL",
+	"vim script":      "Vim",
+	"xml":             "XML",
+	"yaml":            "Yaml",
+}
+
+// SymbolLanguagesForLangs returns the languages as reported by ctags that
+// correspond to the values of lang: filters, e.g. "Sh" for `lang:bash`. ok is
+// false if one of the values has no ctags language, in which case symbols
+// cannot be filtered by language.
+func SymbolLanguagesForLangs(langs []string) (languages []string, ok bool) {
+	for _, lang := range langs {
+		name, _ := enry.GetLanguageByAlias(lang)
+		language, ok := ctagsLanguages[strings.ToLower(name)]
+		if !ok {
+			return nil, false
+		}
+		languages = append(languages, language)
+	}
+	return languages, true
+}
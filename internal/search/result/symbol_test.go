@@ -67,3 +67,27 @@ func TestSymbolURL(t *testing.T) {
 		})
 	}
 }
+
+func TestSymbolKindsForSelectKind(t *testing.T) {
+	require.Equal(t, []string{"const", "constant"}, SymbolKindsForSelectKind("constant"))
+	require.Equal(t, []string{"enum member", "enumconstant"}, SymbolKindsForSelectKind("enum-member"))
+	require.Empty(t, SymbolKindsForSelectKind("not-a-kind"))
+
+	for _, kind := range SymbolKindsForSelectKind("function") {
+		require.Equal(t, "function", toSelectKind[kind])
+	}
+}
+
+func TestSymbolLanguagesForLangs(t *testing.T) {
+	languages, ok := SymbolLanguagesForLangs([]string{"go", "bash", "TypeScript"})
+	require.True(t, ok)
+	require.Equal(t, []string{"Go", "Sh", "TypeScript"}, languages)
+
+	languages, ok = SymbolLanguagesForLangs(nil)
+	require.True(t, ok)
+	require.Empty(t, languages)
+
+	// Languages that ctags does not know can't be pushed down.
+	_, ok = SymbolLanguagesForLangs([]string{"go", "coq"})
+	require.False(t, ok)
+}
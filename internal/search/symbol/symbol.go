@@ -20,6 +20,8 @@ import (
 	"github.com/sourcegraph/sourcegraph/internal/conf"
 	"github.com/sourcegraph/sourcegraph/internal/goroutine"
 	"github.com/sourcegraph/sourcegraph/internal/search"
+	"github.com/sourcegraph/sourcegraph/internal/search/filter"
+	"github.com/sourcegraph/sourcegraph/internal/search/query"
 	searchrepos "github.com/sourcegraph/sourcegraph/internal/search/repos"
 	"github.com/sourcegraph/sourcegraph/internal/search/result"
 	"github.com/sourcegraph/sourcegraph/internal/search/streaming"
@@ -57,6 +59,17 @@ func Search(ctx context.Context, args *search.TextParameters, limit int, stream
 	ctx, stream, cancel := streaming.WithLimit(ctx, stream, limit)
 	defer cancel()
 
+	// Indexed search cannot filter symbols by container, so we filter all
+	// results here before they count against the limit.
+	container, _ := args.Query.StringValue(query.FieldContainer)
+	if container != "" {
+		containerRe, err := compileContainerPattern(container, args.PatternInfo.IsCaseSensitive)
+		if err != nil {
+			return err
+		}
+		stream = withContainer(stream, containerRe)
+	}
+
 	request, err := zoektutil.NewIndexedSearchRequest(ctx, args, search.SymbolRequest, zoektutil.MissingRepoRevStatus(stream))
 	if err != nil {
 		return err
@@ -92,7 +105,7 @@ func Search(ctx context.Context, args *search.TextParameters, limit int, stream
 		goroutine.Go(func() {
 			defer run.Release()
 
-			matches, err := searchInRepo(ctx, repoRevs, args.PatternInfo, limit)
+			matches, err := searchInRepo(ctx, repoRevs, args.PatternInfo, container, limit)
 			stats, err := searchrepos.HandleRepoSearchResult(repoRevs, len(matches) > limit, false, err)
 			stream.Send(streaming.SearchEvent{
 				Results: matches,
@@ -112,7 +125,7 @@ func Search(ctx context.Context, args *search.TextParameters, limit int, stream
 	return run.Wait()
 }
 
-func searchInRepo(ctx context.Context, repoRevs *search.RepositoryRevisions, patternInfo *search.TextPatternInfo, limit int) (res []result.Match, err error) {
+func searchInRepo(ctx context.Context, repoRevs *search.RepositoryRevisions, patternInfo *search.TextPatternInfo, container string, limit int) (res []result.Match, err error) {
 	span, ctx := ot.StartSpanFromContext(ctx, "Search symbols in repo")
 	defer func() {
 		if err != nil {
@@ -135,17 +148,27 @@ func searchInRepo(ctx context.Context, repoRevs *search.RepositoryRevisions, pat
 	}
 	span.SetTag("commit", string(commitID))
 
-	symbols, err := backend.Symbols.ListTags(ctx, search.SymbolsParameters{
-		Repo:            repoRevs.Repo.Name,
-		CommitID:        commitID,
-		Query:           patternInfo.Pattern,
-		IsCaseSensitive: patternInfo.IsCaseSensitive,
-		IsRegExp:        patternInfo.IsRegExp,
-		IncludePatterns: patternInfo.IncludePatterns,
-		ExcludePattern:  patternInfo.ExcludePattern,
+	args := search.SymbolsParameters{
+		Repo:             repoRevs.Repo.Name,
+		CommitID:         commitID,
+		Query:            patternInfo.Pattern,
+		IsCaseSensitive:  patternInfo.IsCaseSensitive,
+		IsRegExp:         patternInfo.IsRegExp,
+		IncludePatterns:  patternInfo.IncludePatterns,
+		ExcludePattern:   patternInfo.ExcludePattern,
+		Kinds:            selectedSymbolKinds(patternInfo.Select),
+		ContainerPattern: container,
 		// Ask for limit + 1 so we can detect whether there are more results than the limit.
 		First: limit + 1,
-	})
+	}
+	// lang: filters are also part of IncludePatterns, which only match file
+	// extensions. We additionally filter on the language reported by ctags
+	// unless one of the languages is unknown to ctags.
+	if languages, ok := result.SymbolLanguagesForLangs(patternInfo.Languages); ok {
+		args.Languages = languages
+	}
+
+	symbols, err := backend.Symbols.ListTags(ctx, args)
 
 	// All symbols are from the same repo, so we can just partition them by path
 	// to build file matches
@@ -184,6 +207,53 @@ func searchInRepo(ctx context.Context, repoRevs *search.RepositoryRevisions, pat
 	return matches, err
 }
 
+// selectedSymbolKinds returns the symbol kinds selected by a select:symbol.<kind>
+// filter so they can be pushed down to the symbols service. It returns nil if
+// no kind is selected.
+func selectedSymbolKinds(selectPath filter.SelectPath) []string {
+	if selectPath.Root() != filter.Symbol || len(selectPath) < 2 {
+		return nil
+	}
+	return result.SymbolKindsForSelectKind(selectPath[1])
+}
+
+// compileContainerPattern compiles the value of a container: filter.
+func compileContainerPattern(pattern string, isCaseSensitive bool) (*regexp.Regexp, error) {
+	if !isCaseSensitive {
+		pattern = "(?i:" + pattern + ")"
+	}
+	return regexp.Compile(pattern)
+}
+
+// withContainer returns a child Sender of parent that only keeps the symbols
+// whose parent matches container, dropping file matches without symbols left.
+func withContainer(parent streaming.Sender, container *regexp.Regexp) streaming.Sender {
+	return streaming.StreamFunc(func(e streaming.SearchEvent) {
+		filtered := e.Results[:0]
+		for _, match := range e.Results {
+			fm, ok := match.(*result.FileMatch)
+			if !ok {
+				filtered = append(filtered, match)
+				continue
+			}
+
+			symbols := fm.Symbols[:0]
+			for _, symbol := range fm.Symbols {
+				if container.MatchString(symbol.Symbol.Parent) {
+					symbols = append(symbols, symbol)
+				}
+			}
+			if len(symbols) == 0 {
+				continue
+			}
+			fm.Symbols = symbols
+			filtered = append(filtered, fm)
+		}
+		e.Results = filtered
+		parent.Send(e)
+	})
+}
+
 // indexedSymbols checks to see if Zoekt has indexed symbols information for a
 // repository at a specific commit. If it has it returns the branch name (for
 // use when querying zoekt). Otherwise an empty string is returned.
@@ -85,8 +85,28 @@ type SymbolsParameters struct {
 	// need to match to get included in the result
 	ExcludePattern string
 
+	// Kinds is an optional list of symbol kinds as reported by ctags (e.g.
+	// "func" or "struct"). If non-empty, only symbols of one of these kinds
+	// are returned. Kinds are matched case-insensitively.
+	Kinds []string
+
+	// Languages is an optional list of languages as reported by ctags (e.g.
+	// "Go" or "JavaScript"). If non-empty, only symbols in one of these
+	// languages are returned. Languages are matched case-insensitively.
+	Languages []string
+
+	// ContainerPattern is an optional regex that the name of a symbol's
+	// parent (e.g. the class of a method) needs to match to get included in
+	// the result.
+	ContainerPattern string
+
 	// First indicates that only the first n symbols should be returned.
 	First int
+
+	// After is an opaque cursor returned by a previous paginated search. If
+	// set, only symbols after the cursor are returned. It is only used by
+	// paginated searches.
+	After string
 }
 
 // GlobalSearchMode designates code paths which optimize performance for global
@@ -300,6 +320,7 @@ type RepoOptions struct {
 	NoArchived         bool
 	OnlyArchived       bool
 	CommitAfter        string
+	HasKVPs            []RepoKVPFilter
 	Visibility         query.RepoVisibility
 	Ranked             bool // Return results ordered by rank
 	Limit              int
@@ -307,6 +328,20 @@ type RepoOptions struct {
 	Query              query.Q
 }
 
+// RepoKVPFilter selects repositories by their key/value metadata. A nil Value
+// matches any value of the key.
+type RepoKVPFilter struct {
+	Key   string
+	Value *string
+}
+
+func (f RepoKVPFilter) String() string {
+	if f.Value == nil {
+		return strconv.Quote(f.Key)
+	}
+	return strconv.Quote(f.Key + ":" + *f.Value)
+}
+
 func (op *RepoOptions) String() string {
 	var b strings.Builder
 	if len(op.RepoFilters) == 0 {
@@ -331,6 +366,9 @@ func (op *RepoOptions) String() string {
 	if op.CommitAfter != "" {
 		_, _ = fmt.Fprintf(&b, " CommitAfter=%q", op.CommitAfter)
 	}
+	for _, kvp := range op.HasKVPs {
+		_, _ = fmt.Fprintf(&b, " HasKVP=%s", kvp.String())
+	}
 
 	if op.NoForks {
 		b.WriteString(" NoForks")
@@ -107,6 +107,73 @@ func (c *Client) Search(ctx context.Context, args search.SymbolsParameters) (res
 	return result, err
 }
 
+// SearchPage is a page of results of a paginated symbol search.
+type SearchPage struct {
+	// Symbols are the symbols in this page. The order of symbols is stable
+	// across pages of the same search.
+	Symbols result.Symbols `json:"symbols"`
+
+	// NextCursor is the cursor to pass as SymbolsParameters.After to get the
+	// next page. It is empty if this is the last page.
+	NextCursor string `json:"nextCursor,omitempty"`
+}
+
+// SearchPage performs a paginated symbol search on the symbols service. It
+// returns up to args.First symbols after the cursor args.After.
+func (c *Client) SearchPage(ctx context.Context, args search.SymbolsParameters) (page *SearchPage, err error) {
+	span, ctx := ot.StartSpanFromContext(ctx, "symbols.Client.SearchPage")
+	defer func() {
+		if err != nil {
+			ext.Error.Set(span, true)
+			span.LogFields(otlog.Error(err))
+		}
+		span.Finish()
+	}()
+	span.SetTag("Repo", string(args.Repo))
+	span.SetTag("CommitID", string(args.CommitID))
+	span.SetTag("After", args.After)
+
+	resp, err := c.httpPost(ctx, "paginated-search", key{repo: args.Repo, commitID: args.CommitID}, args)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		// best-effort inclusion of body in error message
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 200))
+		return nil, errors.Errorf(
+			"Symbol.SearchPage http status %d for %+v: %s",
+			resp.StatusCode,
+			args,
+			string(body),
+		)
+	}
+
+	err = json.NewDecoder(resp.Body).Decode(&page)
+	return page, err
+}
+
+// SearchAll walks through all pages of a paginated symbol search, starting at
+// the cursor args.After, and calls f with the symbols of each page. args.First
+// is the page size. It stops early if f returns an error, and returns that
+// error.
+func (c *Client) SearchAll(ctx context.Context, args search.SymbolsParameters, f func(result.Symbols) error) error {
+	for {
+		page, err := c.SearchPage(ctx, args)
+		if err != nil {
+			return err
+		}
+		if err := f(page.Symbols); err != nil {
+			return err
+		}
+		if page.NextCursor == "" {
+			return nil
+		}
+		args.After = page.NextCursor
+	}
+}
+
 func (c *Client) httpPost(
 	ctx context.Context,
 	method string,
@@ -9,6 +9,7 @@
 package types
 
 import (
+	"net/url"
 	"strings"
 
 	"github.com/cockroachdb/errors"
@@ -52,7 +53,20 @@ func (e *ExternalService) RedactConfigSecrets() (string, error) {
 		}
 		newCfg, err = redactField(e.Config, fields...)
 	case *schema.BitbucketCloudConnection:
-		newCfg, err = redactField(e.Config, []string{"appPassword"})
+		fields := [][]string{{"appPassword"}}
+		if cfg.WebhookSecret != "" {
+			fields = append(fields, []string{"webhookSecret"})
+		}
+		newCfg, err = redactField(e.Config, fields...)
+	case *schema.GerritConnection:
+		// Gerrit can be accessed anonymously, without a password
+		var fields [][]string
+		if cfg.Password != "" {
+			fields = append(fields, []string{"password"})
+		}
+		newCfg, err = redactField(e.Config, fields...)
+	case *schema.GiteaConnection:
+		newCfg, err = redactField(e.Config, []string{"token"})
 	case *schema.AWSCodeCommitConnection:
 		newCfg, err = redactField(e.Config, []string{"secretAccessKey"}, []string{"gitCredentials", "password"})
 	case *schema.PhabricatorConnection:
@@ -66,6 +80,16 @@ func (e *ExternalService) RedactConfigSecrets() (string, error) {
 		newCfg, err = redactField(e.Config, []string{"url"})
 	case *schema.JVMPackagesConnection:
 		newCfg, err = e.Config, nil
+	case *schema.NPMPackagesConnection:
+		// The public registry can be accessed anonymously, without credentials
+		var fields [][]string
+		if cfg.Credentials != "" {
+			fields = append(fields, []string{"credentials"})
+		}
+		newCfg, err = redactField(e.Config, fields...)
+	case *schema.GoModulesConnection:
+		// Credentials can only be given as the user info of the proxy URLs
+		newCfg, err = redactURLPasswords(e.Config, cfg.Urls, "urls")
 	default:
 		// return an error here, it's safer to fail than to incorrectly return unsafe data.
 		err = errors.Errorf("RedactExternalServiceConfig: kind %q not implemented", e.Kind)
@@ -128,7 +152,19 @@ func (e *ExternalService) UnredactConfig(old *ExternalService) error {
 		}
 		unredacted, err = unredactField(old.Config, e.Config, &cfg, fields...)
 	case *schema.BitbucketCloudConnection:
-		unredacted, err = unredactField(old.Config, e.Config, &cfg, jsonStringField{[]string{"appPassword"}, &cfg.AppPassword})
+		fields := []jsonStringField{{[]string{"appPassword"}, &cfg.AppPassword}}
+		if cfg.WebhookSecret != "" {
+			fields = append(fields, jsonStringField{[]string{"webhookSecret"}, &cfg.WebhookSecret})
+		}
+		unredacted, err = unredactField(old.Config, e.Config, &cfg, fields...)
+	case *schema.GerritConnection:
+		var fields []jsonStringField
+		if cfg.Password != "" {
+			fields = append(fields, jsonStringField{[]string{"password"}, &cfg.Password})
+		}
+		unredacted, err = unredactField(old.Config, e.Config, &cfg, fields...)
+	case *schema.GiteaConnection:
+		unredacted, err = unredactField(old.Config, e.Config, &cfg, jsonStringField{[]string{"token"}, &cfg.Token})
 	case *schema.AWSCodeCommitConnection:
 		unredacted, err = unredactField(old.Config,
 			e.Config,
@@ -147,6 +183,17 @@ func (e *ExternalService) UnredactConfig(old *ExternalService) error {
 		unredacted, err = unredactField(old.Config, e.Config, &cfg, jsonStringField{[]string{"url"}, &cfg.Url})
 	case *schema.JVMPackagesConnection:
 		unredacted, err = e.Config, nil
+	case *schema.NPMPackagesConnection:
+		var fields []jsonStringField
+		if cfg.Credentials != "" {
+			fields = append(fields, jsonStringField{[]string{"credentials"}, &cfg.Credentials})
+		}
+		unredacted, err = unredactField(old.Config, e.Config, &cfg, fields...)
+	case *schema.GoModulesConnection:
+		var oldCfg interface{}
+		if oldCfg, err = old.Configuration(); err == nil {
+			unredacted, err = unredactURLPasswords(e.Config, cfg.Urls, oldCfg.(*schema.GoModulesConnection).Urls, "urls")
+		}
 	default:
 		// return an error here, it's safer to fail than to incorrectly return unsafe data.
 		err = errors.Errorf("UnRedactExternalServiceConfig: kind %q not implemented", e.Kind)
@@ -158,6 +205,69 @@ func (e *ExternalService) UnredactConfig(old *ExternalService) error {
 	return nil
 }
 
+// redactURLPasswords replaces the passwords in the user info of the URLs found
+// at the given path with RedactedSecret. Unlike with redactField, the rest of
+// the URLs stays readable.
+func redactURLPasswords(buf string, urls []string, path ...string) (string, error) {
+	redacted := make([]string, len(urls))
+	changed := false
+	for i, rawURL := range urls {
+		u, err := url.Parse(rawURL)
+		if err != nil {
+			return buf, err
+		}
+		if _, ok := u.User.Password(); ok {
+			u.User = url.UserPassword(u.User.Username(), RedactedSecret)
+			changed = true
+		}
+		redacted[i] = u.String()
+	}
+	if !changed {
+		return buf, nil
+	}
+	return jsonc.Edit(buf, redacted, path...)
+}
+
+// unredactURLPasswords is the inverse of redactURLPasswords: it replaces the
+// redacted passwords of the URLs at the given path with the passwords of the
+// old URLs having the same scheme, host and username.
+func unredactURLPasswords(buf string, urls, oldURLs []string, path ...string) (string, error) {
+	passwords := make(map[string]string, len(oldURLs))
+	for _, rawURL := range oldURLs {
+		u, err := url.Parse(rawURL)
+		if err != nil {
+			return buf, err
+		}
+		if password, ok := u.User.Password(); ok {
+			passwords[u.Scheme+"://"+u.User.Username()+"@"+u.Host] = password
+		}
+	}
+
+	unredacted := make([]string, len(urls))
+	changed := false
+	for i, rawURL := range urls {
+		unredacted[i] = rawURL
+		u, err := url.Parse(rawURL)
+		if err != nil {
+			return buf, err
+		}
+		if password, _ := u.User.Password(); password != RedactedSecret {
+			continue
+		}
+		password, ok := passwords[u.Scheme+"://"+u.User.Username()+"@"+u.Host]
+		if !ok {
+			return buf, errors.Errorf("no password to unredact for URL %s", rawURL)
+		}
+		u.User = url.UserPassword(u.User.Username(), password)
+		unredacted[i] = u.String()
+		changed = true
+	}
+	if !changed {
+		return buf, nil
+	}
+	return jsonc.Edit(buf, unredacted, path...)
+}
+
 type jsonStringField struct {
 	path []string
 	ptr  *string
@@ -425,6 +425,10 @@ type RepoGitserverStatus struct {
 
 	// GitserverRepo data if it exists
 	*GitserverRepo
+
+	// Replica is the data of the replica of the repo on a given shard, if it
+	// exists and was requested.
+	Replica *GitserverRepoReplica
 }
 
 type CloneStatus string
@@ -458,6 +462,23 @@ type GitserverRepo struct {
 	LastError   string
 	LastFetched time.Time
 	UpdatedAt   time.Time
+	// The last time the janitor ran maintenance tasks on the repo, or zero
+	LastMaintained time.Time
+}
+
+// GitserverRepoReplica represents the data gitserver knows about a copy of a
+// repo on a shard other than its primary one.
+type GitserverRepoReplica struct {
+	RepoID api.RepoID
+	// Usually represented by a gitserver hostname
+	ShardID     string
+	CloneStatus CloneStatus
+	// The last error that occurred or empty if the last action was successful
+	LastError   string
+	LastFetched time.Time
+	UpdatedAt   time.Time
+	// The last time the janitor ran maintenance tasks on the repo, or zero
+	LastMaintained time.Time
 }
 
 // ExternalService is a connection to an external service.
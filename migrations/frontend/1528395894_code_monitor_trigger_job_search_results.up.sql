@@ -0,0 +1,7 @@
+BEGIN;
+
+ALTER TABLE cm_trigger_jobs ADD COLUMN IF NOT EXISTS search_results jsonb;
+
+COMMENT ON COLUMN cm_trigger_jobs.search_results IS 'The commits matched by the query, truncated to the configured number of results per notification. Actions include them in their notifications.';
+
+COMMIT;
@@ -0,0 +1,13 @@
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS repo_kvps (
+    repo_id integer NOT NULL REFERENCES repo(id) ON DELETE CASCADE,
+    key text NOT NULL,
+    value text,
+    PRIMARY KEY (repo_id, key) INCLUDE (value)
+);
+
+COMMENT ON TABLE repo_kvps IS 'Key/value metadata attached to repositories by site admins or through the API. Used by the repo:has.meta() search predicate.';
+COMMENT ON COLUMN repo_kvps.value IS 'The value of the key. A NULL value denotes a tag, i.e. a key without a value.';
+
+COMMIT;
@@ -0,0 +1,15 @@
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS gitserver_repo_replicas (
+    repo_id integer NOT NULL REFERENCES repo(id) ON DELETE CASCADE,
+    shard_id text NOT NULL,
+    clone_status text NOT NULL DEFAULT 'not_cloned',
+    last_error text,
+    last_fetched timestamp with time zone NOT NULL DEFAULT now(),
+    updated_at timestamp with time zone NOT NULL DEFAULT now(),
+    PRIMARY KEY (repo_id, shard_id)
+);
+
+COMMENT ON TABLE gitserver_repo_replicas IS 'State of the copies of repositories cloned on gitservers other than their primary one when gitserver replication is enabled. The state of the primary copy is in gitserver_repos.';
+
+COMMIT;
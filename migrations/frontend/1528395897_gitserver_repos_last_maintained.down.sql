@@ -0,0 +1,6 @@
+BEGIN;
+
+ALTER TABLE gitserver_repos DROP COLUMN IF EXISTS last_maintained;
+ALTER TABLE gitserver_repo_replicas DROP COLUMN IF EXISTS last_maintained;
+
+COMMIT;
@@ -0,0 +1,9 @@
+BEGIN;
+
+ALTER TABLE gitserver_repos ADD COLUMN IF NOT EXISTS last_maintained timestamp with time zone;
+ALTER TABLE gitserver_repo_replicas ADD COLUMN IF NOT EXISTS last_maintained timestamp with time zone;
+
+COMMENT ON COLUMN gitserver_repos.last_maintained IS 'The last time the gitserver janitor ran maintenance tasks on the repository, see experimentalFeatures.gitMaintenance.';
+COMMENT ON COLUMN gitserver_repo_replicas.last_maintained IS 'The last time the gitserver janitor ran maintenance tasks on the replica, see experimentalFeatures.gitMaintenance.';
+
+COMMIT;
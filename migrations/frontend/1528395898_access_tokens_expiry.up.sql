@@ -0,0 +1,9 @@
+BEGIN;
+
+ALTER TABLE access_tokens ADD COLUMN IF NOT EXISTS expires_at timestamp with time zone;
+ALTER TABLE access_tokens ADD COLUMN IF NOT EXISTS last_used_ip text;
+
+COMMENT ON COLUMN access_tokens.expires_at IS 'The time after which the access token is rejected. Tokens without an expiry never expire.';
+COMMENT ON COLUMN access_tokens.last_used_ip IS 'The IP address of the client that last used the access token to authenticate a request.';
+
+COMMIT;
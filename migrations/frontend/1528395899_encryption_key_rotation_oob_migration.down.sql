@@ -0,0 +1,5 @@
+BEGIN;
+
+-- Do not remove oob migration when downgrading
+
+COMMIT;
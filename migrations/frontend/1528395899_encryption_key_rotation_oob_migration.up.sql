@@ -0,0 +1,7 @@
+BEGIN;
+
+INSERT INTO out_of_band_migrations (id, team, component, description, introduced_version_major, introduced_version_minor, non_destructive)
+VALUES (12, 'core-application', 'frontend-db.encryption-keys', 'Re-encrypt secrets with the current encryption keys', 3, 33, true)
+ON CONFLICT DO NOTHING;
+
+COMMIT;
@@ -0,0 +1,22 @@
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS scim_users (
+    user_id integer PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE DEFERRABLE,
+    user_name text NOT NULL,
+    external_id text,
+    emails text[] NOT NULL DEFAULT '{}'::text[],
+    active boolean NOT NULL DEFAULT true,
+    created_at timestamp with time zone NOT NULL DEFAULT now(),
+    updated_at timestamp with time zone NOT NULL DEFAULT now()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS scim_users_user_name ON scim_users (lower(user_name));
+CREATE UNIQUE INDEX IF NOT EXISTS scim_users_external_id ON scim_users (external_id) WHERE external_id IS NOT NULL;
+
+COMMENT ON TABLE scim_users IS 'Users provisioned by an identity provider through the SCIM API.';
+COMMENT ON COLUMN scim_users.user_name IS 'The userName of the user in the identity provider, which may not be a valid Sourcegraph username.';
+COMMENT ON COLUMN scim_users.external_id IS 'The identifier of the user in the identity provider.';
+COMMENT ON COLUMN scim_users.emails IS 'The email addresses of the user in the identity provider, primary first. They are restored when a deactivated user is reactivated.';
+COMMENT ON COLUMN scim_users.active IS 'Whether the user is active. Deactivated users are soft-deleted.';
+
+COMMIT;
@@ -0,0 +1,13 @@
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS security_event_log_sink_cursors (
+    sink text PRIMARY KEY,
+    last_event_id bigint NOT NULL,
+    updated_at timestamp with time zone NOT NULL DEFAULT now()
+);
+
+COMMENT ON TABLE security_event_log_sink_cursors IS 'The position of each configured security event sink in the security_event_logs table.';
+COMMENT ON COLUMN security_event_log_sink_cursors.sink IS 'The type and destination of the sink, such as syslog:tls://siem.example.com:6514.';
+COMMENT ON COLUMN security_event_log_sink_cursors.last_event_id IS 'The ID of the last security event delivered to the sink.';
+
+COMMIT;
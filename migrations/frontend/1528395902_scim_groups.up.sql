@@ -0,0 +1,10 @@
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS scim_groups (
+    org_id integer PRIMARY KEY REFERENCES orgs(id) ON DELETE CASCADE DEFERRABLE,
+    created_at timestamp with time zone NOT NULL DEFAULT now()
+);
+
+COMMENT ON TABLE scim_groups IS 'Organizations created by an identity provider through the SCIM API. The SCIM API only manages these organizations.';
+
+COMMIT;
@@ -0,0 +1,12 @@
+BEGIN;
+
+-- Encrypted search results can't be converted back to jsonb.
+UPDATE cm_trigger_jobs SET search_results = NULL WHERE encryption_key_id != '';
+
+ALTER TABLE cm_trigger_jobs
+    ALTER COLUMN search_results TYPE jsonb USING convert_from(search_results, 'UTF8')::jsonb,
+    DROP COLUMN IF EXISTS encryption_key_id;
+
+COMMENT ON COLUMN cm_trigger_jobs.search_results IS 'The commits matched by the query, truncated to the configured number of results per notification. Actions include them in their notifications.';
+
+COMMIT;
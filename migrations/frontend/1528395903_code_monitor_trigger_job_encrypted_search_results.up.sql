@@ -0,0 +1,12 @@
+BEGIN;
+
+-- Search results may be encrypted, so we store them as bytea along with the
+-- version of the key that encrypted them.
+ALTER TABLE cm_trigger_jobs
+    ALTER COLUMN search_results TYPE bytea USING convert_to(search_results::text, 'UTF8'),
+    ADD COLUMN IF NOT EXISTS encryption_key_id text NOT NULL DEFAULT '';
+
+COMMENT ON COLUMN cm_trigger_jobs.search_results IS 'The JSON encoded commits matched by the query in repositories the owner of the monitor can access, truncated to the configured number of results per notification. Actions include them in their notifications.';
+COMMENT ON COLUMN cm_trigger_jobs.encryption_key_id IS 'The version of the key that encrypted search_results, or the empty string if search_results is not encrypted';
+
+COMMIT;
@@ -0,0 +1,9 @@
+BEGIN;
+
+ALTER TABLE security_event_log_sink_cursors DROP COLUMN IF EXISTS locked_until;
+ALTER TABLE security_event_log_sink_cursors DROP COLUMN IF EXISTS last_txid;
+
+DROP INDEX IF EXISTS security_event_logs_txid_id;
+ALTER TABLE security_event_logs DROP COLUMN IF EXISTS txid;
+
+COMMIT;
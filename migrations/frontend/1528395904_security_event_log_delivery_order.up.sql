@@ -0,0 +1,16 @@
+BEGIN;
+
+-- The events recorded before the column was added are delivered first, in the order of their IDs.
+ALTER TABLE security_event_logs ADD COLUMN IF NOT EXISTS txid bigint NOT NULL DEFAULT 0;
+ALTER TABLE security_event_logs ALTER COLUMN txid SET DEFAULT txid_current();
+CREATE INDEX IF NOT EXISTS security_event_logs_txid_id ON security_event_logs (txid, id);
+
+COMMENT ON COLUMN security_event_logs.txid IS 'The ID of the transaction that recorded the event, which orders the delivery of events to sinks, or 0 for events recorded before it was added.';
+
+ALTER TABLE security_event_log_sink_cursors ADD COLUMN IF NOT EXISTS last_txid bigint NOT NULL DEFAULT 0;
+ALTER TABLE security_event_log_sink_cursors ADD COLUMN IF NOT EXISTS locked_until timestamp with time zone;
+
+COMMENT ON COLUMN security_event_log_sink_cursors.last_txid IS 'The transaction ID of the last security event delivered to the sink.';
+COMMENT ON COLUMN security_event_log_sink_cursors.locked_until IS 'The time until which a process delivering events to the sink claims it.';
+
+COMMIT;
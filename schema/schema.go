@@ -78,6 +78,24 @@ type AuthAccessTokens struct {
 	Allow string `json:"allow,omitempty"`
 }
 
+// AuthGroupMapping description: Maps the groups that users belong to in the identity provider to Sourcegraph organization memberships and site admin status. The mapping is applied each time a user signs in: users are added to the organizations of their groups, and removed from the mapped organizations of the groups they no longer belong to.
+type AuthGroupMapping struct {
+	// Attribute description: The name of the SAML attribute or OpenID Connect claim that lists the groups of the user.
+	Attribute string `json:"attribute"`
+	// Organizations description: The organizations that the members of each group belong to. Organizations that don't exist are created. Memberships of organizations that are not listed here are not changed.
+	Organizations []*AuthGroupOrganization `json:"organizations,omitempty"`
+	// SiteAdminGroups description: The groups whose members are site admins. If set, users who are not in any of these groups lose their site admin status when they sign in with this provider. If not set, site admin status is not changed.
+	SiteAdminGroups []string `json:"siteAdminGroups,omitempty"`
+}
+
+// AuthGroupOrganization description: Makes the members of a group members of an organization.
+type AuthGroupOrganization struct {
+	// Group description: The name or ID of the group, as sent by the identity provider.
+	Group string `json:"group"`
+	// Organization description: The name of the organization.
+	Organization string `json:"organization"`
+}
+
 // AuthProviderCommon description: Common properties for authentication providers.
 type AuthProviderCommon struct {
 	// DisplayName description: The name to use when displaying this authentication provider in the UI. Defaults to an auto-generated name with the type of authentication provider and other relevant identifiers (such as a hostname).
@@ -137,6 +155,11 @@ func (v *AuthProviders) UnmarshalJSON(data []byte) error {
 	return fmt.Errorf("tagged union type must have a %q property whose value is one of %s", "type", []string{"builtin", "saml", "openidconnect", "http-header", "github", "gitlab"})
 }
 
+// AuthScim description: Settings for the SCIM 2.0 API at /.api/scim/v2, which lets an identity provider such as Okta or Azure AD provision and deprovision users, and manage organization memberships with groups.
+type AuthScim struct {
+	// BearerToken description: The bearer token the identity provider authenticates with. Use a long random value, e.g. generated with `openssl rand -hex 32`.
+	BearerToken string `json:"bearerToken"`
+}
 type BackendInsight struct {
 	// Description description: The description of this insight
 	Description string          `json:"description,omitempty"`
@@ -217,6 +240,8 @@ type BitbucketCloudConnection struct {
 	Url string `json:"url"`
 	// Username description: The username to use when authenticating to the Bitbucket Cloud. Also set the corresponding "appPassword" field.
 	Username string `json:"username"`
+	// WebhookSecret description: A shared secret used to authenticate incoming webhooks from Bitbucket Cloud. Bitbucket Cloud doesn't sign webhook payloads, so the secret must be passed as the "secret" query parameter of the webhook URL.
+	WebhookSecret string `json:"webhookSecret,omitempty"`
 }
 
 // BitbucketCloudRateLimit description: Rate limit applied when making background API requests to Bitbucket Cloud.
@@ -431,6 +456,7 @@ type EncryptionKey struct {
 	Cloudkms *CloudKMSEncryptionKey
 	Awskms   *AWSKMSEncryptionKey
 	Mounted  *MountedEncryptionKey
+	Vault    *VaultEncryptionKey
 	Noop     *NoOpEncryptionKey
 }
 
@@ -444,6 +470,9 @@ func (v EncryptionKey) MarshalJSON() ([]byte, error) {
 	if v.Mounted != nil {
 		return json.Marshal(v.Mounted)
 	}
+	if v.Vault != nil {
+		return json.Marshal(v.Vault)
+	}
 	if v.Noop != nil {
 		return json.Marshal(v.Noop)
 	}
@@ -465,19 +494,25 @@ func (v *EncryptionKey) UnmarshalJSON(data []byte) error {
 		return json.Unmarshal(data, &v.Mounted)
 	case "noop":
 		return json.Unmarshal(data, &v.Noop)
+	case "vault":
+		return json.Unmarshal(data, &v.Vault)
 	}
-	return fmt.Errorf("tagged union type must have a %q property whose value is one of %s", "type", []string{"cloudkms", "awskms", "mounted", "noop"})
+	return fmt.Errorf("tagged union type must have a %q property whose value is one of %s", "type", []string{"cloudkms", "awskms", "mounted", "vault", "noop"})
 }
 
 // EncryptionKeys description: Configuration for encryption keys used to encrypt data at rest in the database.
 type EncryptionKeys struct {
 	BatchChangesCredentialKey *EncryptionKey `json:"batchChangesCredentialKey,omitempty"`
 	// CacheSize description: number of values to keep in LRU cache
-	CacheSize int `json:"cacheSize,omitempty"`
+	CacheSize             int            `json:"cacheSize,omitempty"`
+	CodeMonitorResultsKey *EncryptionKey `json:"codeMonitorResultsKey,omitempty"`
+	CodeMonitorWebhookKey *EncryptionKey `json:"codeMonitorWebhookKey,omitempty"`
 	// EnableCache description: enable LRU cache for decryption APIs
-	EnableCache            bool           `json:"enableCache,omitempty"`
-	ExternalServiceKey     *EncryptionKey `json:"externalServiceKey,omitempty"`
-	UserExternalAccountKey *EncryptionKey `json:"userExternalAccountKey,omitempty"`
+	EnableCache        bool           `json:"enableCache,omitempty"`
+	ExternalServiceKey *EncryptionKey `json:"externalServiceKey,omitempty"`
+	// PreviousKeys description: Keys that were previously used to encrypt data. They are only used to decrypt the data that was encrypted with them until the encryption key rotation migration re-encrypted it with the current keys. Remove a key from this list once the migration is complete.
+	PreviousKeys           []*EncryptionKey `json:"previousKeys,omitempty"`
+	UserExternalAccountKey *EncryptionKey   `json:"userExternalAccountKey,omitempty"`
 }
 type ExcludedAWSCodeCommitRepo struct {
 	// Id description: The ID of an AWS Code Commit repository (as returned by the AWS API) to exclude from mirroring. Use this to exclude the repository, even if renamed, or to differentiate between repositories with the same name in multiple regions.
@@ -501,6 +536,12 @@ type ExcludedBitbucketServerRepo struct {
 	// Pattern description: Regular expression which matches against the name of a Bitbucket Server repo.
 	Pattern string `json:"pattern,omitempty"`
 }
+type ExcludedGerritProject struct {
+	// Name description: The name of a Gerrit project ("platform/build") to exclude from mirroring.
+	Name string `json:"name,omitempty"`
+	// Pattern description: Regular expression which matches against the name of a Gerrit project.
+	Pattern string `json:"pattern,omitempty"`
+}
 type ExcludedGitHubRepo struct {
 	// Archived description: If set to true, archived repositories will be excluded.
 	Archived bool `json:"archived,omitempty"`
@@ -519,6 +560,18 @@ type ExcludedGitLabProject struct {
 	// Name description: The name of a GitLab project ("group/name") to exclude from mirroring.
 	Name string `json:"name,omitempty"`
 }
+type ExcludedGiteaRepo struct {
+	// Archived description: If set to true, archived repositories will be excluded.
+	Archived bool `json:"archived,omitempty"`
+	// Forks description: If set to true, forks will be excluded.
+	Forks bool `json:"forks,omitempty"`
+	// Id description: The ID of a Gitea repository (as returned by the Gitea API) to exclude from mirroring.
+	Id int `json:"id,omitempty"`
+	// Name description: The name of a Gitea repository ("owner/name") to exclude from mirroring.
+	Name string `json:"name,omitempty"`
+	// Pattern description: Regular expression which matches against the name of a Gitea repository ("owner/name").
+	Pattern string `json:"pattern,omitempty"`
+}
 type ExcludedGitoliteRepo struct {
 	// Name description: The name of a Gitolite repo ("my-repo") to exclude from mirroring.
 	Name string `json:"name,omitempty"`
@@ -550,8 +603,18 @@ type ExperimentalFeatures struct {
 	EnablePostSignupFlow bool `json:"enablePostSignupFlow,omitempty"`
 	// EventLogging description: Enables user event logging inside of the Sourcegraph instance. This will allow admins to have greater visibility of user activity, such as frequently viewed pages, frequent searches, and more. These event logs (and any specific user actions) are only stored locally, and never leave this Sourcegraph instance.
 	EventLogging string `json:"eventLogging,omitempty"`
+	// GitMaintenance description: Configures the maintenance tasks the gitserver janitor runs on repositories. They write files that speed up git operations on large repositories, such as commit-graph files for commit search and blame. No maintenance tasks run when this isn't set.
+	GitMaintenance *GitMaintenance `json:"gitMaintenance,omitempty"`
+	// GitPartialClone description: JSON array of repositories to clone as partial clones, which only fetch the blobs they need on demand. Use it for very large repositories that are slow to clone or use too much disk space.
+	GitPartialClone []*GitPartialCloneMapping `json:"gitPartialClone,omitempty"`
+	// GitServerReplicationFactor description: Number of gitserver shards each repository is cloned on. With a value greater than 1, requests fall back to another replica when the primary shard of a repository is unavailable, e.g. during rollouts or node failures. Every replica uses as much disk space as the primary, so gitserver storage has to be scaled up accordingly.
+	GitServerReplicationFactor int `json:"gitServerReplicationFactor,omitempty"`
+	// GoModules description: Allow adding Go module proxy code host connections
+	GoModules string `json:"goModules,omitempty"`
 	// JvmPackages description: Allow adding JVM packages code host connections
 	JvmPackages string `json:"jvmPackages,omitempty"`
+	// NpmPackages description: Allow adding npm packages code host connections
+	NpmPackages string `json:"npmPackages,omitempty"`
 	// Perforce description: Allow adding Perforce code host connections
 	Perforce string `json:"perforce,omitempty"`
 	// Ranking description: Experimental search result ranking options.
@@ -591,6 +654,59 @@ type ExternalIdentity struct {
 	Type           string `json:"type"`
 }
 
+// FileSecurityEventSink description: Appends security events to a file, one JSON object per line. The file is created if it doesn't exist.
+type FileSecurityEventSink struct {
+	// Path description: The path of the file on the frontend containers.
+	Path string `json:"path"`
+	Type string `json:"type"`
+}
+
+// GerritConnection description: Configuration for a connection to Gerrit.
+type GerritConnection struct {
+	// Exclude description: A list of projects to never mirror from Gerrit. Takes precedence over "projects" and "projectQuery" configuration.
+	//
+	// Supports excluding by exact name ({"name": "platform/build"}) or by regular expression ({"pattern": "^device/.*"}).
+	Exclude []*ExcludedGerritProject `json:"exclude,omitempty"`
+	// GitURLType description: The type of Git URLs to use for cloning and fetching Git repositories on this Gerrit instance.
+	//
+	// If "http", Sourcegraph will access Gerrit projects using Git URLs of the form https://gerrit.example.com/a/myproject, authenticated with the configured username and HTTP password.
+	//
+	// If "ssh", Sourcegraph will access Gerrit projects using Git URLs of the form ssh://username@gerrit.example.com:29418/myproject. See the documentation for how to provide SSH private keys and known_hosts: https://docs.sourcegraph.com/admin/repo/auth#repositories-that-need-http-s-or-ssh-authentication.
+	GitURLType string `json:"gitURLType,omitempty"`
+	// Password description: The HTTP password of the Gerrit account, as generated in the account's settings under "HTTP Credentials". This is not the password used to sign in to Gerrit.
+	Password string `json:"password,omitempty"`
+	// ProjectQuery description: An array of Gerrit project queries, using the query syntax of Gerrit's project search (see https://gerrit-review.googlesource.com/Documentation/user-search-projects.html). Sourcegraph mirrors all projects matching any of the queries.
+	//
+	// If neither "projects" nor "projectQuery" is set, Sourcegraph mirrors all active projects (the query "state:active"). Use the special value "none" to only mirror the projects listed in "projects".
+	ProjectQuery []string `json:"projectQuery,omitempty"`
+	// Projects description: An array of Gerrit project names to mirror on Sourcegraph.
+	Projects []string `json:"projects,omitempty"`
+	// RateLimit description: Rate limit applied when making background API requests to Gerrit.
+	RateLimit *GerritRateLimit `json:"rateLimit,omitempty"`
+	// RepositoryPathPattern description: The pattern used to generate the corresponding Sourcegraph repository name for a Gerrit project.
+	//
+	//  - "{host}" is replaced with the Gerrit URL's host (such as gerrit.example.com), and "{name}" is replaced with the Gerrit project's name (such as "platform/build").
+	//
+	// For example, if your Gerrit is https://gerrit.example.com and your Sourcegraph is https://src.example.com, then a repositoryPathPattern of "{host}/{name}" would mean that the Gerrit project "platform/build" is available on Sourcegraph at https://src.example.com/gerrit.example.com/platform/build.
+	//
+	// It is important that the Sourcegraph repository name generated with this pattern be unique to this code host. If different code hosts generate repository names that collide, Sourcegraph's behavior is undefined.
+	RepositoryPathPattern string `json:"repositoryPathPattern,omitempty"`
+	// SshPort description: The port of the SSH daemon of Gerrit, used when "gitURLType" is "ssh".
+	SshPort int `json:"sshPort,omitempty"`
+	// Url description: URL of a Gerrit instance, such as https://gerrit.example.com.
+	Url string `json:"url"`
+	// Username description: The username of the Gerrit account used to list and clone projects. Also set the corresponding "password" field. If no username is set, Sourcegraph uses anonymous access and only mirrors the projects visible to anonymous users.
+	Username string `json:"username,omitempty"`
+}
+
+// GerritRateLimit description: Rate limit applied when making background API requests to Gerrit.
+type GerritRateLimit struct {
+	// Enabled description: true if rate limiting is enabled.
+	Enabled bool `json:"enabled"`
+	// RequestsPerHour description: Requests per hour permitted. This is an average, calculated per second. Internally, the burst limit is set to 100, which implies that for a requests per hour limit as low as 1, users will continue to be able to send a maximum of 100 requests immediately, provided that the complexity cost of each request is 1.
+	RequestsPerHour float64 `json:"requestsPerHour"`
+}
+
 // GitCommitAuthor description: The author of the Git commit.
 type GitCommitAuthor struct {
 	// Email description: The Git commit author email.
@@ -798,6 +914,68 @@ type GitLabWebhook struct {
 	Secret string `json:"secret"`
 }
 
+// GitMaintenance description: Configures the maintenance tasks the gitserver janitor runs on repositories. They write files that speed up git operations on large repositories, such as commit-graph files for commit search and blame. No maintenance tasks run when this isn't set.
+type GitMaintenance struct {
+	// IntervalHours description: Minimum number of hours between two maintenances of a repository.
+	IntervalHours int `json:"intervalHours,omitempty"`
+	// MinSizeMB description: Only the repositories whose packs are at least this large, in megabytes, are maintained.
+	MinSizeMB int `json:"minSizeMB,omitempty"`
+	// RepackPackCount description: The repack task only runs on repositories with at least this number of packs. The multi-pack-index task only runs on repositories with at least 2 packs.
+	RepackPackCount int `json:"repackPackCount,omitempty"`
+	// Tasks description: The maintenance tasks to run, in that order: "repack" repacks all objects into a single pack with a reachability bitmap, "multi-pack-index" indexes the objects of all packs, and "commit-graph" writes incremental commit-graph files. All tasks run when this isn't set.
+	Tasks []string `json:"tasks,omitempty"`
+}
+
+// GitPartialCloneMapping description: Mapping from repositories to the object filter to clone them with. The `repo` field contains a repository name, or a prefix of repository names such as "github.com/myorg" to match all the repositories under it. The `filter` field is passed to `git fetch --filter`: "blob:none" makes a blobless clone, "blob:limit=<n>[kmg]" only omits the blobs larger than the given size.
+type GitPartialCloneMapping struct {
+	// Filter description: Object filter of the partial clone
+	Filter string `json:"filter"`
+	// Repo description: Repository name or prefix of repository names
+	Repo string `json:"repo"`
+}
+
+// GiteaConnection description: Configuration for a connection to Gitea or Forgejo.
+type GiteaConnection struct {
+	// Exclude description: A list of repositories to never mirror from Gitea. Takes precedence over "orgs", "users" and "repos" configuration.
+	//
+	// Supports excluding by name ({"name": "owner/name"}), by ID ({"id": 42}), by regular expression ({"pattern": "^myorg/.*"}), and forks or archived repositories.
+	Exclude []*ExcludedGiteaRepo `json:"exclude,omitempty"`
+	// GitURLType description: The type of Git URLs to use for cloning and fetching Git repositories on this Gitea instance.
+	//
+	// If "http", Sourcegraph will access Gitea repositories using Git URLs of the form https://gitea.example.com/myorg/myrepo.git, authenticated with the configured token.
+	//
+	// If "ssh", Sourcegraph will access Gitea repositories using Git URLs of the form git@gitea.example.com:myorg/myrepo.git. See the documentation for how to provide SSH private keys and known_hosts: https://docs.sourcegraph.com/admin/repo/auth#repositories-that-need-http-s-or-ssh-authentication.
+	GitURLType string `json:"gitURLType,omitempty"`
+	// Orgs description: An array of Gitea organization names. All repositories of these organizations that are visible to the token's user are mirrored on Sourcegraph.
+	Orgs []string `json:"orgs,omitempty"`
+	// RateLimit description: Rate limit applied when making background API requests to Gitea.
+	RateLimit *GiteaRateLimit `json:"rateLimit,omitempty"`
+	// Repos description: An array of repository "owner/name" strings specifying which Gitea repositories to mirror on Sourcegraph.
+	Repos []string `json:"repos,omitempty"`
+	// RepositoryPathPattern description: The pattern used to generate the corresponding Sourcegraph repository name for a Gitea repository.
+	//
+	//  - "{host}" is replaced with the Gitea URL's host (such as gitea.example.com), and "{nameWithOwner}" is replaced with the Gitea repository's "owner/name" path (such as "myorg/myrepo").
+	//
+	// For example, if your Gitea is https://gitea.example.com and your Sourcegraph is https://src.example.com, then a repositoryPathPattern of "{host}/{nameWithOwner}" would mean that the Gitea repository "myorg/myrepo" is available on Sourcegraph at https://src.example.com/gitea.example.com/myorg/myrepo.
+	//
+	// It is important that the Sourcegraph repository name generated with this pattern be unique to this code host. If different code hosts generate repository names that collide, Sourcegraph's behavior is undefined.
+	RepositoryPathPattern string `json:"repositoryPathPattern,omitempty"`
+	// Token description: An access token for a Gitea user, generated in the user's settings under "Applications". The token needs read access to the repositories to mirror. To use batch changes with this code host, it also needs write access to repositories and issues.
+	Token string `json:"token"`
+	// Url description: URL of a Gitea or Forgejo instance, such as https://gitea.example.com.
+	Url string `json:"url"`
+	// Users description: An array of Gitea user names. All repositories owned by these users that are visible to the token's user are mirrored on Sourcegraph.
+	Users []string `json:"users,omitempty"`
+}
+
+// GiteaRateLimit description: Rate limit applied when making background API requests to Gitea.
+type GiteaRateLimit struct {
+	// Enabled description: true if rate limiting is enabled.
+	Enabled bool `json:"enabled"`
+	// RequestsPerHour description: Requests per hour permitted. This is an average, calculated per second. Internally, the burst limit is set to 100, which implies that for a requests per hour limit as low as 1, users will continue to be able to send a maximum of 100 requests immediately, provided that the complexity cost of each request is 1.
+	RequestsPerHour float64 `json:"requestsPerHour"`
+}
+
 // GitoliteConnection description: Configuration for a connection to Gitolite.
 type GitoliteConnection struct {
 	// Exclude description: A list of repositories to never mirror from this Gitolite instance. Supports excluding by exact name ({"name": "foo"}).
@@ -814,6 +992,24 @@ type GitoliteConnection struct {
 	Prefix string `json:"prefix"`
 }
 
+// GoModulesConnection description: Configuration for a connection to Go module proxies
+type GoModulesConnection struct {
+	// Dependencies description: An array of "module@version" strings specifying which Go modules to mirror on Sourcegraph. A module without a version mirrors all the versions listed by the proxy.
+	Dependencies []string `json:"dependencies,omitempty"`
+	// RateLimit description: Rate limit applied when making background API requests to the configured Go module proxies.
+	RateLimit *GoRateLimit `json:"rateLimit,omitempty"`
+	// Urls description: The list of Go module proxy URLs to fetch modules from. Each module is fetched from the first proxy that has it, like the go command does with a comma separated GOPROXY. Credentials can be included as the user info of the URLs.
+	Urls []string `json:"urls,omitempty"`
+}
+
+// GoRateLimit description: Rate limit applied when making background API requests to the configured Go module proxies.
+type GoRateLimit struct {
+	// Enabled description: true if rate limiting is enabled.
+	Enabled bool `json:"enabled"`
+	// RequestsPerHour description: Requests per hour permitted. This is an average, calculated per second. Internally, the burst limit is set to 100, which implies that for a requests per hour limit as low as 1, users will continue to be able to send a maximum of 100 requests immediately, provided that the complexity cost of each request is 1.
+	RequestsPerHour float64 `json:"requestsPerHour"`
+}
+
 // HTTPHeaderAuthProvider description: Configures the HTTP header authentication provider (which authenticates users by consulting an HTTP request header set by an authentication proxy such as https://github.com/bitly/oauth2_proxy).
 type HTTPHeaderAuthProvider struct {
 	// EmailHeader description: The name (case-insensitive) of an HTTP header whose value is taken to be the email of the client requesting the page. Set this value when using an HTTP proxy that authenticates requests, and you don't want the extra configurability of the other authentication methods.
@@ -825,6 +1021,15 @@ type HTTPHeaderAuthProvider struct {
 	UsernameHeader string `json:"usernameHeader"`
 }
 
+// HTTPSecurityEventSink description: POSTs security events to an HTTP collector as newline delimited JSON, for example to the raw endpoint of a Splunk HTTP Event Collector.
+type HTTPSecurityEventSink struct {
+	// Headers description: Additional headers sent with each request, such as an Authorization header.
+	Headers map[string]string `json:"headers,omitempty"`
+	Type    string            `json:"type"`
+	// Url description: The URL events are POSTed to.
+	Url string `json:"url"`
+}
+
 // IdentityProvider description: The source of identity to use when computing permissions. This defines how to compute the GitLab identity to use for a given Sourcegraph user.
 type IdentityProvider struct {
 	Oauth    *OAuthIdentity
@@ -911,6 +1116,8 @@ type JVMPackagesConnection struct {
 
 // Log description: Configuration for logging and alerting, including to external services.
 type Log struct {
+	// SecurityEvents description: Records security events, such as sign ins, access token usage, site configuration changes and permission changes, and delivers them to SIEM systems. Security events are recorded when this is set, and always on Sourcegraph.com. Site admins can list them with the securityEvents GraphQL query.
+	SecurityEvents *SecurityEventsLog `json:"securityEvents,omitempty"`
 	// Sentry description: Configuration for Sentry
 	Sentry *Sentry `json:"sentry,omitempty"`
 }
@@ -944,6 +1151,26 @@ type MountedEncryptionKey struct {
 	Version    string `json:"version,omitempty"`
 }
 
+// NPMPackagesConnection description: Configuration for a connection to an npm packages repository.
+type NPMPackagesConnection struct {
+	// Credentials description: Access token for logging into the npm registry. It is sent as a bearer token in the Authorization header of every request to the registry.
+	Credentials string `json:"credentials,omitempty"`
+	// Dependencies description: An array of "(@scope/)?packageName@version" strings specifying which npm packages to mirror on Sourcegraph.
+	Dependencies []string `json:"dependencies,omitempty"`
+	// RateLimit description: Rate limit applied when making background API requests to the npm registry.
+	RateLimit *NPMRateLimit `json:"rateLimit,omitempty"`
+	// Registry description: The URL at which the npm registry can be found.
+	Registry string `json:"registry,omitempty"`
+}
+
+// NPMRateLimit description: Rate limit applied when making background API requests to the npm registry.
+type NPMRateLimit struct {
+	// Enabled description: true if rate limiting is enabled.
+	Enabled bool `json:"enabled"`
+	// RequestsPerHour description: Requests per hour permitted. This is an average, calculated per second. Internally, the burst limit is set to 100, which implies that for a requests per hour limit as low as 1, users will continue to be able to send a maximum of 100 requests immediately, provided that the complexity cost of each request is 1.
+	RequestsPerHour float64 `json:"requestsPerHour"`
+}
+
 // NoOpEncryptionKey description: This encryption key is a no op, leaving your data in plaintext (not recommended).
 type NoOpEncryptionKey struct {
 	Type string `json:"type"`
@@ -1105,6 +1332,8 @@ type OpenIDConnectAuthProvider struct {
 	// ConfigID description: An identifier that can be used to reference this authentication provider in other parts of the config. For example, in configuration for a code host, you may want to designate this authentication provider as the identity provider for the code host.
 	ConfigID    string `json:"configID,omitempty"`
 	DisplayName string `json:"displayName,omitempty"`
+	// GroupMapping description: Maps the groups of users in the identity provider to organization memberships and site admin status, which are updated each time a user signs in.
+	GroupMapping *AuthGroupMapping `json:"groupMapping,omitempty"`
 	// Issuer description: The URL of the OpenID Connect issuer.
 	//
 	// For Google Apps: https://accounts.google.com
@@ -1238,6 +1467,8 @@ type SAMLAuthProvider struct {
 	// ConfigID description: An identifier that can be used to reference this authentication provider in other parts of the config. For example, in configuration for a code host, you may want to designate this authentication provider as the identity provider for the code host.
 	ConfigID    string `json:"configID,omitempty"`
 	DisplayName string `json:"displayName,omitempty"`
+	// GroupMapping description: Maps the groups of users in the identity provider to organization memberships and site admin status, which are updated each time a user signs in.
+	GroupMapping *AuthGroupMapping `json:"groupMapping,omitempty"`
 	// IdentityProviderMetadata description: The SAML Identity Provider metadata XML contents (for static configuration of the SAML Service Provider). The value of this field should be an XML document whose root element is `<EntityDescriptor>` or `<EntityDescriptors>`. To escape the value into a JSON string, you may want to use a tool like https://json-escape-text.now.sh.
 	IdentityProviderMetadata string `json:"identityProviderMetadata,omitempty"`
 	// IdentityProviderMetadataURL description: The SAML Identity Provider metadata URL (for dynamic configuration of the SAML Service Provider).
@@ -1307,6 +1538,51 @@ type SearchScope struct {
 	Value string `json:"value"`
 }
 
+// SecurityEventSink description: A destination that security events are delivered to.
+type SecurityEventSink struct {
+	Syslog *SyslogSecurityEventSink
+	Http   *HTTPSecurityEventSink
+	File   *FileSecurityEventSink
+}
+
+func (v SecurityEventSink) MarshalJSON() ([]byte, error) {
+	if v.Syslog != nil {
+		return json.Marshal(v.Syslog)
+	}
+	if v.Http != nil {
+		return json.Marshal(v.Http)
+	}
+	if v.File != nil {
+		return json.Marshal(v.File)
+	}
+	return nil, errors.New("tagged union type must have exactly 1 non-nil field value")
+}
+func (v *SecurityEventSink) UnmarshalJSON(data []byte) error {
+	var d struct {
+		DiscriminantProperty string `json:"type"`
+	}
+	if err := json.Unmarshal(data, &d); err != nil {
+		return err
+	}
+	switch d.DiscriminantProperty {
+	case "file":
+		return json.Unmarshal(data, &v.File)
+	case "http":
+		return json.Unmarshal(data, &v.Http)
+	case "syslog":
+		return json.Unmarshal(data, &v.Syslog)
+	}
+	return fmt.Errorf("tagged union type must have a %q property whose value is one of %s", "type", []string{"syslog", "http", "file"})
+}
+
+// SecurityEventsLog description: Records security events, such as sign ins, access token usage, site configuration changes and permission changes, and delivers them to SIEM systems. Security events are recorded when this is set, and always on Sourcegraph.com. Site admins can list them with the securityEvents GraphQL query.
+type SecurityEventsLog struct {
+	// MaxAttempts description: The number of attempts to deliver a batch of events to a sink before giving up until the next delivery run. Undelivered events are never skipped.
+	MaxAttempts int `json:"maxAttempts,omitempty"`
+	// Sinks description: The sinks that every security event is delivered to. Events are delivered at least once and in order. A sink only receives the events recorded after it was added.
+	Sinks []*SecurityEventSink `json:"sinks,omitempty"`
+}
+
 // Sentry description: Configuration for Sentry
 type Sentry struct {
 	// BackendDSN description: Sentry Data Source Name (DSN) for backend errors. Per the Sentry docs (https://docs.sentry.io/quickstart/#about-the-dsn), it should match the following pattern: '{PROTOCOL}://{PUBLIC_KEY}@{HOST}/{PATH}{PROJECT_ID}'.
@@ -1450,6 +1726,8 @@ type SiteConfiguration struct {
 	AuthProviders []AuthProviders `json:"auth.providers,omitempty"`
 	// AuthPublic description: WARNING: This option has been removed as of 3.8.
 	AuthPublic bool `json:"auth.public,omitempty"`
+	// AuthScim description: Settings for the SCIM 2.0 API at /.api/scim/v2, which lets an identity provider such as Okta or Azure AD provision and deprovision users, and manage organization memberships with groups.
+	AuthScim *AuthScim `json:"auth.scim,omitempty"`
 	// AuthSessionExpiry description: The duration of a user session, after which it expires and the user is required to re-authenticate. The default is 90 days. There is typically no need to set this, but some users may have specific internal security requirements.
 	//
 	// The string format is that of the Duration type in the Go time package (https://golang.org/pkg/time/#ParseDuration). E.g., "720h", "43200m", "2592000s" all indicate a timespan of 30 days.
@@ -1484,6 +1762,8 @@ type SiteConfiguration struct {
 	CampaignsRestrictToAdmins *bool `json:"campaigns.restrictToAdmins,omitempty"`
 	// CodeIntelAutoIndexingEnabled description: Enables/disables the code intel auto indexing feature. This feature is currently supported only on certain managed Sourcegraph instances.
 	CodeIntelAutoIndexingEnabled *bool `json:"codeIntelAutoIndexing.enabled,omitempty"`
+	// CodeMonitorsMaxResultsPerNotification description: The maximum number of matched commits, with their author, message and truncated diff, to include in each code monitor notification. If 0, notifications only contain the number of new results and a link to them. Matched commits are included regardless of the repository permissions of the notification's recipients.
+	CodeMonitorsMaxResultsPerNotification int `json:"codeMonitors.maxResultsPerNotification,omitempty"`
 	// CorsOrigin description: Required when using any of the native code host integrations for Phabricator, GitLab, or Bitbucket Server. It is a space-separated list of allowed origins for cross-origin HTTP requests which should be the base URL for your Phabricator, GitLab, or Bitbucket Server instance.
 	CorsOrigin string `json:"corsOrigin,omitempty"`
 	// DebugSearchSymbolsParallelism description: (debug) controls the amount of symbol search parallelism. Defaults to 20. It is not recommended to change this outside of debugging scenarios. This option will be removed in a future version.
@@ -1610,6 +1890,19 @@ type Step struct {
 	Run string `json:"run"`
 }
 
+// SyslogSecurityEventSink description: Sends security events as RFC 5424 syslog messages with a JSON message body.
+type SyslogSecurityEventSink struct {
+	// Address description: The address of the syslog server. TCP and TLS connections use octet counting framing (RFC 6587).
+	Address string `json:"address"`
+	// AppName description: The APP-NAME of the syslog messages.
+	AppName string `json:"appName,omitempty"`
+	// CaCertFile description: Path to a PEM encoded CA certificate file used to verify the certificate of a TLS syslog server.
+	CaCertFile string `json:"caCertFile,omitempty"`
+	// Facility description: The syslog facility of the messages.
+	Facility string `json:"facility,omitempty"`
+	Type     string `json:"type"`
+}
+
 // TlsExternal description: Global TLS/SSL settings for Sourcegraph to use when communicating with code hosts.
 type TlsExternal struct {
 	// Certificates description: TLS certificates to accept. This is only necessary if you are using self-signed certificates or an internal CA. Can be an internal CA certificate or a self-signed certificate. To get the certificate of a webserver run `openssl s_client -connect HOST:443 -showcerts < /dev/null 2> /dev/null | openssl x509 -outform PEM`. To escape the value into a JSON string, you may want to use a tool like https://json-escape-text.now.sh.
@@ -1634,6 +1927,39 @@ type UsernameIdentity struct {
 	Type string `json:"type"`
 }
 
+// VaultAppRole description: Authenticate with the AppRole auth method. Mutually exclusive with tokenFile and tokenEnvVarName.
+type VaultAppRole struct {
+	// MountPath description: The path the AppRole auth method is mounted at.
+	MountPath string `json:"mountPath,omitempty"`
+	// RoleId description: The role ID.
+	RoleId string `json:"roleId"`
+	// SecretIdEnvVarName description: Name of an environment variable containing the secret ID.
+	SecretIdEnvVarName string `json:"secretIdEnvVarName,omitempty"`
+	// SecretIdFile description: Path to a file containing the secret ID.
+	SecretIdFile string `json:"secretIdFile,omitempty"`
+}
+
+// VaultEncryptionKey description: HashiCorp Vault transit secrets engine key, used to encrypt data without a cloud KMS. Authenticates with a token or with AppRole.
+type VaultEncryptionKey struct {
+	// Address description: The address of the Vault server.
+	Address string `json:"address"`
+	// AppRole description: Authenticate with the AppRole auth method. Mutually exclusive with tokenFile and tokenEnvVarName.
+	AppRole *VaultAppRole `json:"appRole,omitempty"`
+	// CaCertFile description: Path to a PEM encoded CA certificate file used to verify the Vault server's certificate.
+	CaCertFile string `json:"caCertFile,omitempty"`
+	// Keyname description: The name of the transit key.
+	Keyname string `json:"keyname"`
+	// MountPath description: The path the transit secrets engine is mounted at.
+	MountPath string `json:"mountPath,omitempty"`
+	// Namespace description: The Vault Enterprise namespace of the transit secrets engine and of the AppRole auth method.
+	Namespace string `json:"namespace,omitempty"`
+	// TokenEnvVarName description: Name of an environment variable containing a Vault token. Mutually exclusive with tokenFile and appRole.
+	TokenEnvVarName string `json:"tokenEnvVarName,omitempty"`
+	// TokenFile description: Path to a file containing a Vault token, for example written by Vault Agent. The file is read again when the token is rejected. Mutually exclusive with tokenEnvVarName and appRole.
+	TokenFile string `json:"tokenFile,omitempty"`
+	Type      string `json:"type"`
+}
+
 // VersionContext description: Configuration of the version context
 type VersionContext struct {
 	// Description description: Description of the version context
@@ -22,6 +22,14 @@ var BitbucketServerSchemaJSON string
 //go:embed changeset_spec.schema.json
 var ChangesetSpecSchemaJSON string
 
+// GerritSchemaJSON is the content of the file "gerrit.schema.json".
+//go:embed gerrit.schema.json
+var GerritSchemaJSON string
+
+// GiteaSchemaJSON is the content of the file "gitea.schema.json".
+//go:embed gitea.schema.json
+var GiteaSchemaJSON string
+
 // GitHubSchemaJSON is the content of the file "github.schema.json".
 //go:embed github.schema.json
 var GitHubSchemaJSON string
@@ -34,10 +42,18 @@ var GitLabSchemaJSON string
 //go:embed gitolite.schema.json
 var GitoliteSchemaJSON string
 
+// GoModulesSchemaJSON is the content of the file "go-modules.schema.json".
+//go:embed go-modules.schema.json
+var GoModulesSchemaJSON string
+
 // JVMPackagesSchemaJSON is the content of the file "jvm-packages.schema.json".
 //go:embed jvm-packages.schema.json
 var JVMPackagesSchemaJSON string
 
+// NPMPackagesSchemaJSON is the content of the file "npm-packages.schema.json".
+//go:embed npm-packages.schema.json
+var NPMPackagesSchemaJSON string
+
 // OtherExternalServiceSchemaJSON is the content of the file "other_external_service.schema.json".
 //go:embed other_external_service.schema.json
 var OtherExternalServiceSchemaJSON string